@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	"github.com/spf13/cobra"
+)
+
+var appendCmd = &cobra.Command{
+	Use:     "append [BUNDLE_TARBALL]",
+	Args:    cobra.ExactArgs(1),
+	Short:   lang.CmdAppendShort,
+	Example: "  uds append uds-bundle-app-amd64-0.0.1.tar.zst --package oci://ghcr.io/my-org/podinfo:0.0.1 --output uds-bundle-app-amd64-0.0.2.tar.zst",
+	PreRun: func(_ *cobra.Command, args []string) {
+		if helpers.IsOCIURL(args[0]) {
+			fatalf(errCodeInvalidArgument, nil, "First argument (%q) must be a bundle tarball, bundles in a registry can't be appended to", args[0])
+		}
+		if !helpers.IsOCIURL(bundleCfg.AppendOpts.Package) {
+			fatalf(errCodeInvalidArgument, nil, "--package (%q) must be an OCI ref (oci://)", bundleCfg.AppendOpts.Package)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.AppendOpts.Source = args[0]
+		configureZarf()
+
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.Append(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodeAppend, err, "Failed to append to bundle: %s", err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(appendCmd)
+	appendCmd.Flags().StringVar(&bundleCfg.AppendOpts.Package, "package", "", lang.CmdAppendFlagPackage)
+	appendCmd.Flags().StringVar(&bundleCfg.AppendOpts.Name, "name", "", lang.CmdAppendFlagName)
+	appendCmd.Flags().StringVarP(&bundleCfg.AppendOpts.Output, "output", "o", "", lang.CmdAppendFlagOutput)
+	appendCmd.Flags().StringVarP(&bundleCfg.AppendOpts.SigningKeyPath, "signing-key", "k", "", lang.CmdAppendFlagSigningKey)
+	appendCmd.Flags().StringVarP(&bundleCfg.AppendOpts.SigningKeyPassword, "signing-key-password", "p", "", lang.CmdBundleCreateFlagSigningKeyPassword)
+	appendCmd.Flags().StringVar(&bundleCfg.AppendOpts.SigningKeyPasswordFile, "key-password-file", "", lang.CmdBundleCreateFlagKeyPasswordFile)
+	_ = appendCmd.MarkFlagRequired("package")
+	_ = appendCmd.MarkFlagRequired("output")
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	"github.com/spf13/cobra"
+)
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: lang.CmdCatalogShort,
+}
+
+var catalogPushCmd = &cobra.Command{
+	Use:     "push [OCI_REF]",
+	Args:    cobra.ExactArgs(1),
+	Short:   lang.CmdCatalogPushShort,
+	Example: "  uds catalog push oci://ghcr.io/my-org/catalog --bundle oci://ghcr.io/my-org/bundles/app:0.1.0-amd64",
+	PreRun:  firstArgIsCatalogRef,
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.CatalogOpts.Source = args[0]
+		configureZarf()
+
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.CatalogPush(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodeCatalog, err, "Failed to push catalog: %s", err.Error())
+		}
+	},
+}
+
+var catalogListCmd = &cobra.Command{
+	Use:     "list [OCI_REF]",
+	Aliases: []string{"ls"},
+	Args:    cobra.ExactArgs(1),
+	Short:   lang.CmdCatalogListShort,
+	PreRun:  firstArgIsCatalogRef,
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.CatalogOpts.Source = args[0]
+		configureZarf()
+
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.CatalogList(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodeCatalog, err, "Failed to list catalog: %s", err.Error())
+		}
+	},
+}
+
+// firstArgIsCatalogRef checks the first arg is an oci:// ref, catalogs only live in registries
+func firstArgIsCatalogRef(_ *cobra.Command, args []string) {
+	if !helpers.IsOCIURL(args[0]) {
+		fatalf(errCodeInvalidArgument, nil, "First argument (%q) must be an OCI ref (oci://)", args[0])
+	}
+	for _, ref := range bundleCfg.CatalogOpts.Bundles {
+		if !helpers.IsOCIURL(ref) {
+			fatalf(errCodeInvalidArgument, nil, "--bundle %q must be the OCI ref (oci://) of a published bundle", ref)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+	catalogCmd.AddCommand(catalogPushCmd)
+	catalogPushCmd.Flags().StringArrayVar(&bundleCfg.CatalogOpts.Bundles, "bundle", []string{}, lang.CmdCatalogPushFlagBundle)
+	_ = catalogPushCmd.MarkFlagRequired("bundle")
+
+	catalogCmd.AddCommand(catalogListCmd)
+	catalogListCmd.Flags().BoolVar(&bundleCfg.CatalogOpts.JSON, "json", false, lang.CmdCatalogListFlagJSON)
+}
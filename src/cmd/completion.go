@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"os"
+
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/corang/uds-cli/src/pkg/bundle"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:                   "completion [bash|zsh|fish|powershell]",
+	Short:                 lang.CmdCompletionShort,
+	Long:                  lang.CmdCompletionLong,
+	DisableFlagsInUseLine: true,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Run: func(cmd *cobra.Command, args []string) {
+		root := cmd.Root()
+		var err error
+		switch args[0] {
+		case "bash":
+			err = root.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			err = root.GenZshCompletion(os.Stdout)
+		case "fish":
+			err = root.GenFishCompletion(os.Stdout, true)
+		case "powershell":
+			err = root.GenPowerShellCompletionWithDesc(os.Stdout)
+		}
+		if err != nil {
+			fatalf(errCodeInternal, err, "Failed to generate %s completion: %s", args[0], err.Error())
+		}
+	},
+}
+
+// isCompletionCmd returns true for `uds completion` and cobra's hidden __complete commands, which must keep stdout clean
+func isCompletionCmd(cmd *cobra.Command) bool {
+	return cmd.Name() == "completion" || cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd
+}
+
+// completeBundleTarball completes the first argument with bundle tarballs (encrypted or not) from the filesystem
+func completeBundleTarball(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return []string{"zst", "age"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeAttachments completes --attachment with the names of the files attached to the bundle tarball being inspected
+func completeAttachments(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	// only local tarballs are read, completing against a registry would be too slow to be useful
+	if len(args) == 0 || !utils.IsValidTarballPath(args[0]) || utils.IsEncrypted(args[0]) || utils.IsSplitTarball(args[0]) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, err := bundle.ListTarballAttachments(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeValues completes a flag with a fixed set of values
+func completeValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func init() {
+	// replace cobra's default completion cmd so its help text and behavior match the rest of UDS
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.AddCommand(completionCmd)
+
+	for _, cmd := range []*cobra.Command{deployCmd, inspectCmd, removeCmd, publishCmd} {
+		cmd.ValidArgsFunction = completeBundleTarball
+	}
+}
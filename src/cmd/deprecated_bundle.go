@@ -11,7 +11,6 @@ import (
 
 	"github.com/corang/uds-cli/src/config"
 	"github.com/corang/uds-cli/src/config/lang"
-	"github.com/corang/uds-cli/src/pkg/bundle"
 	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
@@ -36,16 +35,16 @@ var bundleCreateCmd = &cobra.Command{
 		message.Warnf("Greetings 🦄! I noticed you used the `uds bundle %s` syntax!\n"+
 			"This syntax will be deprecated in favor of `uds %s` in an upcoming release", cmd.Use, cmd.Use)
 		if len(args) > 0 && !zarfUtils.IsDir(args[0]) {
-			message.Fatalf(nil, "(%q) is not a valid path to a directory", args[0])
+			fatalf(errCodeInvalidArgument, nil, "(%q) is not a valid path to a directory", args[0])
 		}
 		if _, err := os.Stat(config.BundleYAML); len(args) == 0 && err != nil {
-			message.Fatalf(err, "%s not found in directory", config.BundleYAML)
+			fatalf(errCodeInvalidArgument, err, "%s not found in directory", config.BundleYAML)
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		srcDir, err := os.Getwd()
 		if err != nil {
-			message.Fatalf(err, "error reading the current working directory")
+			fatalf(errCodeInternal, err, "error reading the current working directory")
 		}
 		if len(args) > 0 {
 			srcDir = args[0]
@@ -54,12 +53,12 @@ var bundleCreateCmd = &cobra.Command{
 
 		bundleCfg.CreateOpts.SetVariables = utils.MergeVariables(v.GetStringMapString(V_BNDL_CREATE_SET), bundleCfg.CreateOpts.SetVariables)
 
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Create(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to create bundle: %s", err.Error())
+			fatalf(errCodeCreate, err, "Failed to create bundle: %s", err.Error())
 		}
 	},
 }
@@ -82,21 +81,21 @@ var bundleDeployCmd = &cobra.Command{
 		if v.ConfigFileUsed() != "" {
 			err := v.ReadInConfig()
 			if err != nil {
-				message.Fatalf(err, "Failed to read config: %s", err.Error())
+				fatalf(errCodeInvalidConfig, err, "Failed to read config: %s", err.Error())
 				return
 			}
 			err = v.UnmarshalKey(V_BNDL_DEPLOY_ZARF_PACKAGES, &bundleCfg.DeployOpts.ZarfPackageVariables)
 			if err != nil {
-				message.Fatalf(err, "Failed to unmarshal config: %s", err.Error())
+				fatalf(errCodeInvalidConfig, err, "Failed to unmarshal config: %s", err.Error())
 				return
 			}
 		}
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Deploy(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to deploy bundle: %s", err.Error())
+			fatalf(errCodeDeploy, err, "Failed to deploy bundle: %s", err.Error())
 		}
 	},
 }
@@ -111,19 +110,19 @@ var bundleInspectCmd = &cobra.Command{
 			"This syntax will be deprecated in favor of `uds %s` in an upcoming release", cmd.Use, cmd.Use)
 		firstArgIsEitherOCIorTarball(nil, args)
 		if cmd.Flag("extract").Value.String() == "true" && cmd.Flag("sbom").Value.String() == "false" {
-			message.Fatal(nil, "cannot use 'extract' flag without 'sbom' flag")
+			fatalf(errCodeInvalidArgument, nil, "cannot use 'extract' flag without 'sbom' flag")
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		bundleCfg.InspectOpts.Source = choosePackage(args)
 		configureZarf()
 
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Inspect(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to inspect bundle: %s", err.Error())
+			fatalf(errCodeInspect, err, "Failed to inspect bundle: %s", err.Error())
 		}
 	},
 }
@@ -142,12 +141,12 @@ var bundleRemoveCmd = &cobra.Command{
 		bundleCfg.RemoveOpts.Source = args[0]
 		configureZarf()
 
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Remove(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to remove bundle: %s", err.Error())
+			fatalf(errCodeRemove, err, "Failed to remove bundle: %s", err.Error())
 		}
 	},
 }
@@ -161,23 +160,23 @@ var bundlePublishCmd = &cobra.Command{
 		message.Warnf("Greetings 🦄! I noticed you used the `uds bundle %s` syntax!\n"+
 			"This syntax will be deprecated in favor of `uds %s` in an upcoming release", cmd.Use, cmd.Use)
 		if _, err := os.Stat(args[0]); err != nil {
-			message.Fatalf(err, "First argument (%q) must be a valid local Bundle path: %s", args[0], err.Error())
+			fatalf(errCodeInvalidArgument, err, "First argument (%q) must be a valid local Bundle path: %s", args[0], err.Error())
 		}
 		if !strings.HasPrefix(args[1], helpers.OCIURLPrefix) {
 			err := fmt.Errorf("oci url reference must begin with %s", helpers.OCIURLPrefix)
-			message.Fatalf(err, "Second argument (%q) must be a valid OCI URL: %s", args[0], err.Error())
+			fatalf(errCodeInvalidArgument, err, "Second argument (%q) must be a valid OCI URL: %s", args[0], err.Error())
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		bundleCfg.PublishOpts.Source = args[0]
 		bundleCfg.PublishOpts.Destination = args[1]
 		configureZarf()
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Publish(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to publish bundle: %s", err.Error())
+			fatalf(errCodePublish, err, "Failed to publish bundle: %s", err.Error())
 		}
 	},
 }
@@ -191,18 +190,18 @@ var bundlePullCmd = &cobra.Command{
 		message.Warnf("Greetings 🦄! I noticed you used the `uds bundle %s` syntax!\n"+
 			"This syntax will be deprecated in favor of `uds %s` in an upcoming release", cmd.Use, cmd.Use)
 		if err := oci.ValidateReference(args[0]); err != nil {
-			message.Fatalf(err, "First argument (%q) must be a valid OCI URL: %s", args[0], err.Error())
+			fatalf(errCodeInvalidArgument, err, "First argument (%q) must be a valid OCI URL: %s", args[0], err.Error())
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		bundleCfg.PullOpts.Source = args[0]
 		configureZarf()
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Pull(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to pull bundle: %s", err.Error())
+			fatalf(errCodePull, err, "Failed to pull bundle: %s", err.Error())
 		}
 	},
 }
@@ -218,6 +217,7 @@ func initDeprecated(cmd *cobra.Command) {
 	bundleCreateCmd.Flags().StringVarP(&bundleCfg.CreateOpts.SigningKeyPath, "signing-key", "k", v.GetString(V_BNDL_CREATE_SIGNING_KEY), lang.CmdBundleCreateFlagSigningKey)
 	bundleCreateCmd.Flags().StringVarP(&bundleCfg.CreateOpts.SigningKeyPassword, "signing-key-password", "p", v.GetString(V_BNDL_CREATE_SIGNING_KEY_PASSWORD), lang.CmdBundleCreateFlagSigningKeyPassword)
 	bundleCreateCmd.Flags().StringToStringVarP(&bundleCfg.CreateOpts.SetVariables, "set", "s", v.GetStringMapString(V_BNDL_CREATE_SET), lang.CmdBundleCreateFlagSet)
+	bundleCreateCmd.Flags().StringVar(&config.CommonOptions.OCIVersion, "oci-version", v.GetString(V_BNDL_OCI_VERSION), lang.CmdBundleFlagOCIVersion)
 	// deploy cmd flags
 	bundleCmd.AddCommand(bundleDeployCmd)
 	// todo: add "set" flag on deploy for high-level bundle configs?
@@ -227,7 +227,7 @@ func initDeprecated(cmd *cobra.Command) {
 	bundleCmd.AddCommand(bundleInspectCmd)
 	bundleInspectCmd.Flags().BoolVarP(&bundleCfg.InspectOpts.IncludeSBOM, "sbom", "s", false, lang.CmdPackageInspectFlagSBOM)
 	bundleInspectCmd.Flags().BoolVarP(&bundleCfg.InspectOpts.ExtractSBOM, "extract", "e", false, lang.CmdPackageInspectFlagExtractSBOM)
-	bundleInspectCmd.Flags().StringVarP(&bundleCfg.InspectOpts.PublicKeyPath, "key", "k", v.GetString(V_BNDL_INSPECT_KEY), lang.CmdBundleInspectFlagKey)
+	bundleInspectCmd.Flags().StringArrayVarP(&bundleCfg.InspectOpts.PublicKeyPaths, "key", "k", v.GetStringSlice(V_BNDL_INSPECT_KEY), lang.CmdBundleInspectFlagKey)
 
 	// remove cmd flags
 	bundleCmd.AddCommand(bundleRemoveCmd)
@@ -237,9 +237,10 @@ func initDeprecated(cmd *cobra.Command) {
 
 	// publish cmd flags
 	bundleCmd.AddCommand(bundlePublishCmd)
+	bundlePublishCmd.Flags().StringVar(&config.CommonOptions.OCIVersion, "oci-version", v.GetString(V_BNDL_OCI_VERSION), lang.CmdBundleFlagOCIVersion)
 
 	// pull cmd flags
 	bundleCmd.AddCommand(bundlePullCmd)
 	bundlePullCmd.Flags().StringVarP(&bundleCfg.PullOpts.OutputDirectory, "output", "o", v.GetString(V_BNDL_PULL_OUTPUT), lang.CmdBundlePullFlagOutput)
-	bundlePullCmd.Flags().StringVarP(&bundleCfg.PullOpts.PublicKeyPath, "key", "k", v.GetString(V_BNDL_PULL_KEY), lang.CmdBundlePullFlagKey)
+	bundlePullCmd.Flags().StringArrayVarP(&bundleCfg.PullOpts.PublicKeyPaths, "key", "k", v.GetStringSlice(V_BNDL_PULL_KEY), lang.CmdBundlePullFlagKey)
 }
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/bundle"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+)
+
+// error codes reported by --json-errors, automation matches on these so they must not change
+const (
+	errCodeUsage           = "usage"
+	errCodeInvalidArgument = "invalid_argument"
+	errCodeInvalidConfig   = "invalid_config"
+	errCodeInternal        = "internal"
+	errCodeCreate          = "create_failed"
+	errCodeDeploy          = "deploy_failed"
+	errCodeInspect         = "inspect_failed"
+	errCodeRemove          = "remove_failed"
+	errCodePublish         = "publish_failed"
+	errCodePull            = "pull_failed"
+	errCodeExtract         = "extract_failed"
+	errCodeValidate        = "validate_failed"
+	errCodeWrap            = "wrap_failed"
+	errCodeMigrate         = "migrate_failed"
+	errCodeVerify          = "verify_failed"
+	errCodeCatalog         = "catalog_failed"
+	errCodePrune           = "prune_failed"
+	errCodeAppend          = "append_failed"
+	errCodeVerifyLayout    = "verify_layout_failed"
+)
+
+// activeCommand is the full path of the command being run (ie. uds deploy), set before any command runs
+var activeCommand = "uds"
+
+var (
+	// errOutput is where fatalf prints a jsonError with --json-errors
+	errOutput io.Writer = os.Stderr
+
+	// exit ends the process once fatalf has printed a jsonError
+	exit = os.Exit
+)
+
+// jsonError is the single object printed to stderr when a command fails with --json-errors
+type jsonError struct {
+	Command string `json:"command"`
+	Error   string `json:"error"`
+	Code    string `json:"code"`
+}
+
+// fatalf exits with a 1 after printing the error, as a jsonError on stderr when --json-errors is set
+func fatalf(code string, err error, format string, a ...any) {
+	if !config.JSONErrors {
+		message.Fatalf(err, format, a...)
+	}
+
+	out, marshalErr := json.Marshal(jsonError{
+		Command: activeCommand,
+		Error:   fmt.Sprintf(format, a...),
+		Code:    code,
+	})
+	if marshalErr != nil {
+		message.Fatalf(err, format, a...)
+	}
+	fmt.Fprintln(errOutput, string(out))
+	exit(1)
+}
+
+// newBundlerOrDie creates a Bundler from the command's config, exiting through fatalf so a bad config is reported like
+// any other failure
+func newBundlerOrDie() *bundle.Bundler {
+	bndlClient, err := bundle.New(&bundleCfg)
+	if err != nil {
+		fatalf(errCodeInvalidConfig, err, "bundler unable to setup, bad config: %s", err.Error())
+	}
+	return bndlClient
+}
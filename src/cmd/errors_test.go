@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+)
+
+// exitStatus is what the stubbed exit panics with, it stops the failing command like os.Exit would
+type exitStatus int
+
+// runFatal runs fn with --json-errors and returns the jsonError fatalf printed and the status it exited with
+func runFatal(t *testing.T, fn func()) (got jsonError, status int) {
+	t.Helper()
+	var out bytes.Buffer
+	jsonErrors, errOut, exitFn := config.JSONErrors, errOutput, exit
+	config.JSONErrors, errOutput = true, &out
+	exit = func(code int) { panic(exitStatus(code)) }
+	defer func() {
+		config.JSONErrors, errOutput, exit = jsonErrors, errOut, exitFn
+		r := recover()
+		code, ok := r.(exitStatus)
+		if !ok {
+			t.Fatalf("fatalf() didn't exit, recovered %v", r)
+		}
+		status = int(code)
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("fatalf() printed %q, not a jsonError: %v", out.String(), err)
+		}
+	}()
+	fn()
+	return got, status
+}
+
+func Test_fatalfCodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		setup       func(t *testing.T)
+		run         func()
+		wantCode    string
+	}{
+		{
+			name:        "Usage",
+			description: "unknown flags are usage errors",
+			setup: func(t *testing.T) {
+				args := os.Args
+				os.Args = []string{"uds", "--json-errors", "--not-a-flag"}
+				t.Cleanup(func() { os.Args = args })
+			},
+			run:      Execute,
+			wantCode: errCodeUsage,
+		}, {
+			name:        "InvalidArgument",
+			description: "a create path that isn't a directory is an invalid argument",
+			run:         func() { createCmd.PreRun(createCmd, []string{filepath.Join(os.TempDir(), "does-not-exist")}) },
+			wantCode:    errCodeInvalidArgument,
+		}, {
+			name:        "PruneWithoutConfirm",
+			description: "prune without --confirm or --dry-run is an invalid argument",
+			setup: func(t *testing.T) {
+				confirm, dryRun := config.CommonOptions.Confirm, bundleCfg.PruneOpts.DryRun
+				config.CommonOptions.Confirm, bundleCfg.PruneOpts.DryRun = false, false
+				t.Cleanup(func() { config.CommonOptions.Confirm, bundleCfg.PruneOpts.DryRun = confirm, dryRun })
+			},
+			run:      func() { pruneCmd.PreRun(pruneCmd, []string{"oci://localhost:888/bundles"}) },
+			wantCode: errCodeInvalidArgument,
+		}, {
+			name:        "InvalidConfig",
+			description: "a bundler that can't be set up is an invalid config",
+			setup: func(t *testing.T) {
+				t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "does-not-exist"))
+			},
+			run:      func() { newBundlerOrDie() },
+			wantCode: errCodeInvalidConfig,
+		}, {
+			name:        "CommandFailure",
+			description: "commands report their own failure code",
+			run:         func() { fatalf(errCodeDeploy, nil, "Failed to deploy bundle: %s", "timed out") },
+			wantCode:    errCodeDeploy,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+			got, status := runFatal(t, tt.run)
+			if got.Code != tt.wantCode {
+				t.Errorf("fatalf() code = %s, want %s (%s)", got.Code, tt.wantCode, tt.description)
+			}
+			if status != 1 {
+				t.Errorf("fatalf() exited with %d, want 1 (%s)", status, tt.description)
+			}
+			if got.Error == "" {
+				t.Errorf("fatalf() printed no error message (%s)", tt.description)
+			}
+		})
+	}
+}
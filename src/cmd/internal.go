@@ -11,7 +11,6 @@ import (
 	"github.com/alecthomas/jsonschema"
 	"github.com/corang/uds-cli/src/config/lang"
 	"github.com/corang/uds-cli/src/types"
-	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/spf13/cobra"
 )
 
@@ -30,7 +29,7 @@ var configSchemaCmd = &cobra.Command{
 		schema := jsonschema.Reflect(&types.UDSBundle{})
 		output, err := json.MarshalIndent(schema, "", "  ")
 		if err != nil {
-			message.Fatal(err, lang.CmdInternalConfigSchemaErr)
+			fatalf(errCodeInternal, err, lang.CmdInternalConfigSchemaErr)
 		}
 		fmt.Print(string(output) + "\n")
 	},
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/corang/uds-cli/src/pkg/bundle"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [BUNDLE_YAML]",
+	Args:  cobra.ExactArgs(1),
+	Short: lang.CmdMigrateShort,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if bundleCfg.MigrateOpts.InPlace && bundleCfg.MigrateOpts.OutputFile != "" {
+			fatalf(errCodeInvalidArgument, nil, "cannot use 'in-place' flag with 'output' flag")
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		src := args[0]
+		info, err := os.Stat(src)
+		if err != nil {
+			fatalf(errCodeInvalidArgument, err, "First argument (%q) must be a valid path to a %s: %s", src, config.BundleYAML, err.Error())
+		}
+		bundleYAML, err := os.ReadFile(src)
+		if err != nil {
+			fatalf(errCodeMigrate, err, "Failed to read %s: %s", src, err.Error())
+		}
+
+		migrated, changes, err := bundle.MigrateBundleYAML(bundleYAML)
+		if err != nil {
+			fatalf(errCodeMigrate, err, "Failed to migrate %s: %s", src, err.Error())
+		}
+
+		dst := bundleCfg.MigrateOpts.OutputFile
+		if bundleCfg.MigrateOpts.InPlace {
+			dst = src
+		}
+		if dst == "" {
+			fmt.Print(string(migrated))
+		} else if err := os.WriteFile(dst, migrated, info.Mode().Perm()); err != nil {
+			fatalf(errCodeMigrate, err, "Failed to write %s: %s", dst, err.Error())
+		}
+
+		if len(changes) == 0 {
+			message.Successf("%s is already at %s", src, config.BundleAPIVersion)
+			return
+		}
+		message.Infof("Migrated %s to %s:", src, config.BundleAPIVersion)
+		for _, change := range changes {
+			message.Info(change)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().BoolVarP(&bundleCfg.MigrateOpts.InPlace, "in-place", "i", false, lang.CmdMigrateFlagInPlace)
+	migrateCmd.Flags().StringVarP(&bundleCfg.MigrateOpts.OutputFile, "output", "o", "", lang.CmdMigrateFlagOutput)
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:     "prune [OCI_REPOSITORY]",
+	Args:    cobra.ExactArgs(1),
+	Short:   lang.CmdPruneShort,
+	Example: "  uds prune oci://ghcr.io/my-org/bundles/app --keep-last 5 --older-than 30d --dry-run",
+	PreRun: func(_ *cobra.Command, args []string) {
+		if !helpers.IsOCIURL(args[0]) {
+			fatalf(errCodeInvalidArgument, nil, "First argument (%q) must be an OCI repository (oci://)", args[0])
+		}
+		// deleting from a registry can't be undone
+		if !bundleCfg.PruneOpts.DryRun && !config.CommonOptions.Confirm {
+			fatalf(errCodeInvalidArgument, nil, "prune deletes bundles from the registry, pass --confirm to delete them or --dry-run to list them")
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.PruneOpts.Source = args[0]
+		configureZarf()
+
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.Prune(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodePrune, err, "Failed to prune bundles: %s", err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().IntVar(&bundleCfg.PruneOpts.KeepLast, "keep-last", 0, lang.CmdPruneFlagKeepLast)
+	pruneCmd.Flags().StringVar(&bundleCfg.PruneOpts.OlderThan, "older-than", "", lang.CmdPruneFlagOlderThan)
+	pruneCmd.Flags().BoolVar(&bundleCfg.PruneOpts.DryRun, "dry-run", false, lang.CmdPruneFlagDryRun)
+	// confirm does not use the Viper config
+	pruneCmd.Flags().BoolVarP(&config.CommonOptions.Confirm, "confirm", "c", false, lang.CmdPruneFlagConfirm)
+}
@@ -41,13 +41,23 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
+		activeCommand = cmd.CommandPath()
+
 		exec.ExitOnInterrupt()
 
 		// Don't add the logo to the help command
 		if cmd.Parent() == nil {
 			config.SkipLogFile = true
 		}
+
+		// completions run on every <TAB>, don't leave a log file behind each time
+		if isCompletionCmd(cmd) {
+			config.SkipLogFile = true
+		}
 		cliSetup()
+
+		// resolve the architecture once so every part of the command agrees on it
+		bundleCfg.Arch = config.NewArchContext(config.CLIArch)
 	},
 	Short: lang.RootCmdShort,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -58,7 +68,24 @@ var rootCmd = &cobra.Command{
 
 // Execute is the entrypoint for the CLI.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	// usage errors (ie. an unknown flag) happen before --json-errors is parsed, so look for it up front
+	for _, arg := range os.Args[1:] {
+		if arg == "--json-errors" || arg == "--json-errors=true" {
+			config.JSONErrors = true
+			rootCmd.SilenceErrors = true
+			rootCmd.SilenceUsage = true
+		}
+	}
+
+	if err := rootCmd.Execute(); err != nil {
+		if config.JSONErrors {
+			if cmd, _, findErr := rootCmd.Find(os.Args[1:]); findErr == nil {
+				activeCommand = cmd.CommandPath()
+			}
+			fatalf(errCodeUsage, err, "%s", err.Error())
+		}
+		cobra.CheckErr(err)
+	}
 }
 
 // RootCmd returns the root command.
@@ -81,16 +108,28 @@ func init() {
 	v.SetDefault(V_ARCHITECTURE, "")
 	v.SetDefault(V_NO_LOG_FILE, false)
 	v.SetDefault(V_NO_PROGRESS, false)
+	v.SetDefault(V_NO_COLOR, false)
 	v.SetDefault(V_INSECURE, false)
+	v.SetDefault(V_PLAIN_HTTP, false)
 	v.SetDefault(V_ZARF_CACHE, zarfConfig.ZarfDefaultCachePath)
 	v.SetDefault(V_TMP_DIR, "")
+	v.SetDefault(V_JSON_ERRORS, false)
+	v.SetDefault(V_USER_AGENT, "")
 
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", v.GetString(V_LOG_LEVEL), lang.RootCmdFlagLogLevel)
+	_ = rootCmd.RegisterFlagCompletionFunc("log-level", completeValues("warn", "info", "debug", "trace"))
+	rootCmd.PersistentFlags().StringVarP(&config.CLIArch, "architecture", "a", v.GetString(V_ARCHITECTURE), lang.RootCmdFlagArch)
 	rootCmd.PersistentFlags().BoolVar(&config.SkipLogFile, "no-log-file", v.GetBool(V_NO_LOG_FILE), lang.RootCmdFlagSkipLogFile)
+	rootCmd.PersistentFlags().StringVar(&config.LogFile, "log-file", v.GetString(V_LOG_FILE), lang.RootCmdFlagLogFile)
+	_ = rootCmd.MarkPersistentFlagFilename("log-file", "log")
 	rootCmd.PersistentFlags().BoolVar(&message.NoProgress, "no-progress", v.GetBool(V_NO_PROGRESS), lang.RootCmdFlagNoProgress)
+	rootCmd.PersistentFlags().BoolVar(&config.NoColor, "no-color", v.GetBool(V_NO_COLOR), lang.RootCmdFlagNoColor)
 	rootCmd.PersistentFlags().StringVar(&config.CommonOptions.CachePath, "zarf-cache", v.GetString(V_ZARF_CACHE), lang.RootCmdFlagCachePath)
 	rootCmd.PersistentFlags().StringVar(&config.CommonOptions.TempDirectory, "tmpdir", v.GetString(V_TMP_DIR), lang.RootCmdFlagTempDir)
 	rootCmd.PersistentFlags().BoolVar(&config.CommonOptions.Insecure, "insecure", v.GetBool(V_INSECURE), lang.RootCmdFlagInsecure)
+	rootCmd.PersistentFlags().BoolVar(&config.CommonOptions.PlainHTTP, "plain-http", v.GetBool(V_PLAIN_HTTP), lang.RootCmdFlagPlainHTTP)
+	rootCmd.PersistentFlags().BoolVar(&config.JSONErrors, "json-errors", v.GetBool(V_JSON_ERRORS), lang.RootCmdFlagJSONErrors)
+	rootCmd.PersistentFlags().StringVar(&config.CommonOptions.UserAgent, "user-agent", v.GetString(V_USER_AGENT), lang.RootCmdFlagUserAgent)
 }
 
 func cliSetup() {
@@ -101,6 +140,14 @@ func cliSetup() {
 		"trace": message.TraceLevel,
 	}
 
+	// NO_COLOR (https://no-color.org) disables colors like --no-color
+	if os.Getenv("NO_COLOR") != "" {
+		config.NoColor = true
+	}
+	if config.NoColor {
+		message.DisableColor()
+	}
+
 	printViperConfigUsed()
 
 	// No log level set, so use the default
@@ -120,6 +167,6 @@ func cliSetup() {
 	}
 
 	if !config.SkipLogFile {
-		utils.UseLogFile()
+		utils.UseLogFile(config.LogFile)
 	}
 }
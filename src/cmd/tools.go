@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var extractCmd = &cobra.Command{
+	Use:   "extract [BUNDLE_TARBALL]",
+	Args:  cobra.ExactArgs(1),
+	Short: lang.CmdToolsExtractShort,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if !utils.IsValidTarballPath(args[0]) {
+			fatalf(errCodeInvalidArgument, nil, "First argument (%q) must be a valid path to a bundle tarball", args[0])
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.ExtractOpts.Source = args[0]
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.Extract(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodeExtract, err, "Failed to extract from bundle: %s", err.Error())
+		}
+	},
+}
+
+var verifyLayoutCmd = &cobra.Command{
+	Use:   "verify-layout [BUNDLE_TARBALL|OCI_LAYOUT_DIR]",
+	Args:  cobra.ExactArgs(1),
+	Short: lang.CmdToolsVerifyLayoutShort,
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.VerifyLayoutOpts.Source = args[0]
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.VerifyLayout(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodeVerifyLayout, err, "Failed to verify bundle layout: %s", err.Error())
+		}
+	},
+}
+
+func init() {
+	// tools is added to the root cmd by Zarf, find it so UDS-specific tools can live alongside the vendored ones
+	toolsCmd, _, err := rootCmd.Find([]string{"tools"})
+	if err != nil || toolsCmd == rootCmd {
+		return
+	}
+
+	toolsCmd.AddCommand(extractCmd)
+	extractCmd.Flags().StringVar(&bundleCfg.ExtractOpts.Path, "path", "", lang.CmdToolsExtractFlagPath)
+	extractCmd.Flags().StringVarP(&bundleCfg.ExtractOpts.OutputFile, "out", "o", "", lang.CmdToolsExtractFlagOut)
+	_ = extractCmd.MarkFlagRequired("path")
+
+	toolsCmd.AddCommand(verifyLayoutCmd)
+	verifyLayoutCmd.Flags().BoolVar(&bundleCfg.VerifyLayoutOpts.Repair, "repair", false, lang.CmdToolsVerifyLayoutFlagRepair)
+}
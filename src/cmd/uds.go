@@ -13,7 +13,6 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/corang/uds-cli/src/config"
 	"github.com/corang/uds-cli/src/config/lang"
-	"github.com/corang/uds-cli/src/pkg/bundle"
 	zarfConfig "github.com/defenseunicorns/zarf/src/config"
 	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/oci"
@@ -32,16 +31,16 @@ var createCmd = &cobra.Command{
 	Short:   lang.CmdBundleCreateShort,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		if len(args) > 0 && !zarfUtils.IsDir(args[0]) {
-			message.Fatalf(nil, "(%q) is not a valid path to a directory", args[0])
+			fatalf(errCodeInvalidArgument, nil, "(%q) is not a valid path to a directory", args[0])
 		}
 		if _, err := os.Stat(config.BundleYAML); len(args) == 0 && err != nil {
-			message.Fatalf(err, "%s not found in directory", config.BundleYAML)
+			fatalf(errCodeInvalidArgument, err, "%s not found in directory", config.BundleYAML)
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		srcDir, err := os.Getwd()
 		if err != nil {
-			message.Fatalf(err, "error reading the current working directory")
+			fatalf(errCodeInternal, err, "error reading the current working directory")
 		}
 		if len(args) > 0 {
 			srcDir = args[0]
@@ -50,18 +49,18 @@ var createCmd = &cobra.Command{
 
 		bundleCfg.CreateOpts.SetVariables = utils.MergeVariables(v.GetStringMapString(V_BNDL_CREATE_SET), bundleCfg.CreateOpts.SetVariables)
 
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Create(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to create bundle: %s", err.Error())
+			fatalf(errCodeCreate, err, "Failed to create bundle: %s", err.Error())
 		}
 	},
 }
 
 var deployCmd = &cobra.Command{
-	Use:     "deploy [BUNDLE_TARBALL|OCI_REF]",
+	Use:     "deploy [BUNDLE_TARBALL|OCI_REF|OBJECT_URL]",
 	Aliases: []string{"d"},
 	Short:   lang.CmdBundleDeployShort,
 	Args:    cobra.MaximumNArgs(1),
@@ -74,46 +73,97 @@ var deployCmd = &cobra.Command{
 		if v.ConfigFileUsed() != "" {
 			err := v.ReadInConfig()
 			if err != nil {
-				message.Fatalf(err, "Failed to read config: %s", err.Error())
+				fatalf(errCodeInvalidConfig, err, "Failed to read config: %s", err.Error())
 				return
 			}
 			err = v.UnmarshalKey(V_BNDL_DEPLOY_ZARF_PACKAGES, &bundleCfg.DeployOpts.ZarfPackageVariables)
 			if err != nil {
-				message.Fatalf(err, "Failed to unmarshal config: %s", err.Error())
+				fatalf(errCodeInvalidConfig, err, "Failed to unmarshal config: %s", err.Error())
 				return
 			}
 		}
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Deploy(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to deploy bundle: %s", err.Error())
+			fatalf(errCodeDeploy, err, "Failed to deploy bundle: %s", err.Error())
 		}
 	},
 }
 
 var inspectCmd = &cobra.Command{
-	Use:     "inspect [BUNDLE_TARBALL|OCI_REF]",
+	Use:     "inspect [BUNDLE_TARBALL|OCI_REF|OBJECT_URL|BUNDLE_NAME]",
 	Aliases: []string{"i"},
 	Short:   lang.CmdBundleInspectShort,
 	Args:    cobra.MaximumNArgs(1),
 	PreRun: func(cmd *cobra.Command, args []string) {
+		if bundleCfg.InspectOpts.FromCluster {
+			if bundleCfg.InspectOpts.IncludeSBOM {
+				fatalf(errCodeInvalidArgument, nil, "cannot use 'sbom' flag with 'from-cluster' flag")
+			}
+			if bundleCfg.InspectOpts.Variables || bundleCfg.InspectOpts.Tree || bundleCfg.InspectOpts.ExportZarfPackages != "" {
+				fatalf(errCodeInvalidArgument, nil, "cannot use 'variables', 'tree' or 'export-zarf-packages' flag with 'from-cluster' flag")
+			}
+			return
+		}
+		if bundleCfg.InspectOpts.Variables && bundleCfg.InspectOpts.Tree {
+			fatalf(errCodeInvalidArgument, nil, "cannot use 'variables' flag with 'tree' flag")
+		}
+		if bundleCfg.InspectOpts.SignedDigest && (bundleCfg.InspectOpts.Variables || bundleCfg.InspectOpts.Tree || bundleCfg.InspectOpts.Docs || bundleCfg.InspectOpts.IncludeSBOM) {
+			fatalf(errCodeInvalidArgument, nil, "cannot use 'signed-digest' flag with 'variables', 'tree', 'docs' or 'sbom' flag")
+		}
+		if bundleCfg.InspectOpts.DiffDeployed && (bundleCfg.InspectOpts.Variables || bundleCfg.InspectOpts.Tree || bundleCfg.InspectOpts.Docs || bundleCfg.InspectOpts.ExportZarfPackages != "") {
+			fatalf(errCodeInvalidArgument, nil, "cannot use 'diff-deployed' flag with 'variables', 'tree', 'docs' or 'export-zarf-packages' flag")
+		}
+		if bundleCfg.InspectOpts.JSON && !bundleCfg.InspectOpts.Variables && !bundleCfg.InspectOpts.Tree && !bundleCfg.InspectOpts.SignedDigest && !bundleCfg.InspectOpts.DiffDeployed {
+			fatalf(errCodeInvalidArgument, nil, "cannot use 'json' flag without 'from-cluster', 'variables', 'tree', 'signed-digest' or 'diff-deployed' flag")
+		}
 		firstArgIsEitherOCIorTarball(nil, args)
 		if cmd.Flag("extract").Value.String() == "true" && cmd.Flag("sbom").Value.String() == "false" {
-			message.Fatal(nil, "cannot use 'extract' flag without 'sbom' flag")
+			fatalf(errCodeInvalidArgument, nil, "cannot use 'extract' flag without 'sbom' flag")
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		bundleCfg.InspectOpts.Source = choosePackage(args)
+		if bundleCfg.InspectOpts.FromCluster {
+			if len(args) > 0 {
+				bundleCfg.InspectOpts.Source = args[0]
+			}
+		} else {
+			bundleCfg.InspectOpts.Source = choosePackage(args)
+		}
 		configureZarf()
 
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Inspect(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to inspect bundle: %s", err.Error())
+			fatalf(errCodeInspect, err, "Failed to inspect bundle: %s", err.Error())
+		}
+	},
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [BUNDLE_TARBALL|OCI_REF]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: lang.CmdVerifyShort,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if output := bundleCfg.VerifyOpts.Output; output != config.VerifyOutputText && output != config.VerifyOutputJSON {
+			fatalf(errCodeInvalidArgument, nil, "invalid output %q, must be %s or %s", output, config.VerifyOutputText, config.VerifyOutputJSON)
+		}
+		firstArgIsEitherOCIorTarball(nil, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.VerifyOpts.Source = choosePackage(args)
+		configureZarf()
+
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.Verify(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodeVerify, err, "Failed to verify bundle: %s", err.Error())
 		}
 	},
 }
@@ -128,12 +178,12 @@ var removeCmd = &cobra.Command{
 		bundleCfg.RemoveOpts.Source = args[0]
 		configureZarf()
 
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Remove(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to remove bundle: %s", err.Error())
+			fatalf(errCodeRemove, err, "Failed to remove bundle: %s", err.Error())
 		}
 	},
 }
@@ -142,49 +192,80 @@ var publishCmd = &cobra.Command{
 	Use:     "publish [BUNDLE_TARBALL] [OCI_REF]",
 	Aliases: []string{"p"},
 	Short:   lang.CmdBundlePullShort,
-	Args:    cobra.ExactArgs(2),
+	Args: func(cmd *cobra.Command, args []string) error {
+		// --check-only doesn't need a bundle, only where it would be published
+		if bundleCfg.PublishOpts.CheckOnly {
+			return cobra.RangeArgs(1, 2)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	PreRun: func(cmd *cobra.Command, args []string) {
-		if _, err := os.Stat(args[0]); err != nil {
-			message.Fatalf(err, "First argument (%q) must be a valid local Bundle path: %s", args[0], err.Error())
+		if len(args) == 2 {
+			if _, err := os.Stat(args[0]); err != nil {
+				fatalf(errCodeInvalidArgument, err, "First argument (%q) must be a valid local Bundle path: %s", args[0], err.Error())
+			}
 		}
-		if !strings.HasPrefix(args[1], helpers.OCIURLPrefix) {
+		if dest := args[len(args)-1]; !strings.HasPrefix(dest, helpers.OCIURLPrefix) {
 			err := fmt.Errorf("oci url reference must begin with %s", helpers.OCIURLPrefix)
-			message.Fatalf(err, "Second argument (%q) must be a valid OCI URL: %s", args[0], err.Error())
+			fatalf(errCodeInvalidArgument, err, "Argument (%q) must be a valid OCI URL: %s", dest, err.Error())
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		bundleCfg.PublishOpts.Source = args[0]
-		bundleCfg.PublishOpts.Destination = args[1]
+		if len(args) == 2 {
+			bundleCfg.PublishOpts.Source = args[0]
+		}
+		bundleCfg.PublishOpts.Destination = args[len(args)-1]
 		configureZarf()
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
+		if bundleCfg.PublishOpts.CheckOnly {
+			if err := bndlClient.CheckPush(); err != nil {
+				bndlClient.ClearPaths()
+				fatalf(errCodePublish, err, "Unable to push to %s: %s", bundleCfg.PublishOpts.Destination, err.Error())
+			}
+			return
+		}
 		if err := bndlClient.Publish(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to publish bundle: %s", err.Error())
+			fatalf(errCodePublish, err, "Failed to publish bundle: %s", err.Error())
 		}
 	},
 }
 
 var pullCmd = &cobra.Command{
-	Use:     "pull [OCI_REF]",
+	Use:     "pull [OCI_REF|OBJECT_URL]",
 	Aliases: []string{"p"},
 	Short:   lang.CmdBundlePullShort,
-	Args:    cobra.ExactArgs(1),
+	Args:    cobra.MaximumNArgs(1),
 	PreRun: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			if bundleCfg.PullOpts.FromFile == "" {
+				fatalf(errCodeInvalidArgument, nil, "pull needs an OCI ref, or --from-file with --metadata-only")
+			}
+			return
+		}
+		if utils.IsObjectStoreURL(args[0]) {
+			if _, _, err := utils.ParseObjectStoreURL(args[0]); err != nil {
+				fatalf(errCodeInvalidArgument, err, "First argument (%q) must be a valid OCI, s3:// or gs:// URL: %s", args[0], err.Error())
+			}
+			return
+		}
 		if err := oci.ValidateReference(args[0]); err != nil {
-			message.Fatalf(err, "First argument (%q) must be a valid OCI URL: %s", args[0], err.Error())
+			fatalf(errCodeInvalidArgument, err, "First argument (%q) must be a valid OCI URL: %s", args[0], err.Error())
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		bundleCfg.PullOpts.Source = args[0]
+		if len(args) > 0 {
+			bundleCfg.PullOpts.Source = args[0]
+		}
 		configureZarf()
-		bndlClient := bundle.NewOrDie(&bundleCfg)
+		bndlClient := newBundlerOrDie()
 		defer bndlClient.ClearPaths()
 
 		if err := bndlClient.Pull(); err != nil {
 			bndlClient.ClearPaths()
-			message.Fatalf(err, "Failed to pull bundle: %s", err.Error())
+			fatalf(errCodePull, err, "Failed to pull bundle: %s", err.Error())
 		}
 	},
 }
@@ -198,19 +279,38 @@ func firstArgIsEitherOCIorTarball(_ *cobra.Command, args []string) {
 	if utils.IsValidTarballPath(args[0]) {
 		return
 	}
+	if utils.IsObjectStoreURL(args[0]) {
+		if _, _, err := utils.ParseObjectStoreURL(args[0]); err != nil {
+			fatalf(errCodeInvalidArgument, err, "Failed to validate first argument: %s", err.Error())
+		}
+		return
+	}
 	if !helpers.IsOCIURL(args[0]) && !utils.IsValidTarballPath(args[0]) {
 		errString = fmt.Sprintf("First argument (%q) must either be a valid OCI URL or a valid path to a bundle tarball", args[0])
 	} else {
 		err = oci.ValidateReference(args[0])
 	}
 	if errString != "" {
-		message.Fatalf(err, "Failed to validate first argument: %s", errString)
+		fatalf(errCodeInvalidArgument, err, "Failed to validate first argument: %s", errString)
 	}
 }
 
+// addObjectStoreFlags adds the flags for downloading bundle tarballs from s3:// and gs:// URLs to cmd
+func addObjectStoreFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&bundleCfg.ObjectStoreOpts.Region, "s3-region", v.GetString(V_BNDL_S3_REGION), lang.CmdBundleFlagS3Region)
+	cmd.Flags().StringVar(&bundleCfg.ObjectStoreOpts.Endpoint, "s3-endpoint", v.GetString(V_BNDL_S3_ENDPOINT), lang.CmdBundleFlagS3Endpoint)
+	cmd.Flags().BoolVar(&bundleCfg.ObjectStoreOpts.NoVerifySSL, "no-verify-ssl", false, lang.CmdBundleFlagNoVerifySSL)
+}
+
 func init() {
 	initViper()
 	v.SetDefault(V_BNDL_OCI_CONCURRENCY, 3)
+	v.SetDefault(V_BNDL_OCI_VERSION, config.DefaultOCIVersion)
+	v.SetDefault(V_BNDL_CREATE_INCLUDE_STRATEGY, config.IncludeStrategyError)
+	v.SetDefault(V_BNDL_CREATE_REGISTRY_STYLE, config.RegistryStyleAuto)
+	v.SetDefault(V_BNDL_CREATE_PARALLEL_PACKAGES, 1)
+	v.SetDefault(V_BNDL_CREATE_TIMEOUT, config.DefaultCreateTimeout)
+	v.SetDefault(V_BNDL_PUBLISH_REGISTRY_STYLE, config.RegistryStyleAuto)
 
 	// remove after deprecating 'bundle' syntax
 	initDeprecated(rootCmd)
@@ -220,34 +320,162 @@ func init() {
 	// create cmd flags
 	rootCmd.AddCommand(createCmd)
 	createCmd.Flags().BoolVarP(&config.CommonOptions.Confirm, "confirm", "c", false, lang.CmdBundleRemoveFlagConfirm)
+	createCmd.Flags().StringVar(&config.CommonOptions.OCIVersion, "oci-version", v.GetString(V_BNDL_OCI_VERSION), lang.CmdBundleFlagOCIVersion)
 	createCmd.Flags().StringVarP(&bundleCfg.CreateOpts.Output, "output", "o", v.GetString(V_BNDL_CREATE_OUTPUT), lang.CmdBundleCreateFlagOutput)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.OutputDirectory, "output-dir", v.GetString(V_BNDL_CREATE_OUTPUT_DIR), lang.CmdBundleCreateFlagOutputDir)
 	createCmd.Flags().StringVarP(&bundleCfg.CreateOpts.SigningKeyPath, "signing-key", "k", v.GetString(V_BNDL_CREATE_SIGNING_KEY), lang.CmdBundleCreateFlagSigningKey)
 	createCmd.Flags().StringVarP(&bundleCfg.CreateOpts.SigningKeyPassword, "signing-key-password", "p", v.GetString(V_BNDL_CREATE_SIGNING_KEY_PASSWORD), lang.CmdBundleCreateFlagSigningKeyPassword)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.SigningKeyPasswordFile, "key-password-file", v.GetString(V_BNDL_CREATE_KEY_PASSWORD_FILE), lang.CmdBundleCreateFlagKeyPasswordFile)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.StripHistory, "strip-history", v.GetBool(V_BNDL_CREATE_STRIP_HISTORY), lang.CmdBundleCreateFlagStripHistory)
+	createCmd.Flags().StringArrayVar(&bundleCfg.CreateOpts.DenyImages, "deny-image", v.GetStringSlice(V_BNDL_CREATE_DENY_IMAGES), lang.CmdBundleCreateFlagDenyImage)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.ImagePolicy, "image-policy", v.GetString(V_BNDL_CREATE_IMAGE_POLICY), lang.CmdBundleCreateFlagImagePolicy)
 	createCmd.Flags().StringToStringVarP(&bundleCfg.CreateOpts.SetVariables, "set", "s", v.GetStringMapString(V_BNDL_CREATE_SET), lang.CmdBundleCreateFlagSet)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.PlatformVariant, "platform-variant", v.GetString(V_BNDL_CREATE_PLATFORM_VARIANT), lang.CmdBundleCreateFlagPlatformVariant)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.SignMethod, "sign-method", v.GetString(V_BNDL_CREATE_SIGN_METHOD), lang.CmdBundleCreateFlagSignMethod)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.SigAlgo, "sig-algo", v.GetString(V_BNDL_CREATE_SIG_ALGO), lang.CmdBundleCreateFlagSigAlgo)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.NotationKey, "notation-key", v.GetString(V_BNDL_CREATE_NOTATION_KEY), lang.CmdBundleCreateFlagNotationKey)
+	createCmd.Flags().StringToStringVar(&bundleCfg.CreateOpts.Attachments, "attach", map[string]string{}, lang.CmdBundleCreateFlagAttach)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.SizeReport, "size-report", "", lang.CmdBundleCreateFlagSizeReport)
+	createCmd.Flags().Lookup("size-report").NoOptDefVal = config.SizeReportTable
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.RepoPrefix, "repo-prefix", v.GetString(V_BNDL_CREATE_REPO_PREFIX), lang.CmdBundleCreateFlagRepoPrefix)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.ValidateSchema, "validate-schema", false, lang.CmdBundleCreateFlagValidateSchema)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.IncludeStrategy, "include-strategy", v.GetString(V_BNDL_CREATE_INCLUDE_STRATEGY), lang.CmdBundleCreateFlagIncludeStrategy)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.RegistryStyle, "registry-style", v.GetString(V_BNDL_CREATE_REGISTRY_STYLE), lang.CmdBundleCreateFlagRegistryStyle)
+	createCmd.Flags().IntVar(&bundleCfg.CreateOpts.ParallelPackages, "parallel-packages", v.GetInt(V_BNDL_CREATE_PARALLEL_PACKAGES), lang.CmdBundleCreateFlagParallelPackages)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.LegacyConfigMediaType, "legacy-config-media-type", false, lang.CmdBundleCreateFlagLegacyConfigMediaType)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.ArtifactType, "artifact-type", "", lang.CmdBundleCreateFlagArtifactType)
+	_ = createCmd.RegisterFlagCompletionFunc("artifact-type", completeValues(config.BundleArtifactType))
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.ExcludeSBOM, "exclude-sbom", false, lang.CmdBundleCreateFlagExcludeSBOM)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.Offline, "offline", false, lang.CmdBundleCreateFlagOffline)
+	createCmd.Flags().StringArrayVar(&bundleCfg.CreateOpts.PackageBuildArgs, "package-build-args", []string{}, lang.CmdBundleCreateFlagPackageBuildArgs)
+	createCmd.Flags().DurationVar(&bundleCfg.CreateOpts.Timeout, "timeout", v.GetDuration(V_BNDL_CREATE_TIMEOUT), lang.CmdBundleCreateFlagTimeout)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.Encrypt, "encrypt", false, lang.CmdBundleCreateFlagEncrypt)
+	createCmd.Flags().StringArrayVar(&bundleCfg.CreateOpts.Recipients, "recipient", v.GetStringSlice(V_BNDL_CREATE_RECIPIENTS), lang.CmdBundleCreateFlagRecipient)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.ManifestOnly, "manifest-only", false, lang.CmdBundleCreateFlagManifestOnly)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.FailFast, "fail-fast", true, lang.CmdBundleCreateFlagFailFast)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.OptimizeLayers, "optimize-layers", false, lang.CmdBundleCreateFlagOptimizeLayers)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.Strict, "strict", false, lang.CmdBundleCreateFlagStrict)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.PinImages, "pin-images", false, lang.CmdBundleCreateFlagPinImages)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.Created, "created", "", lang.CmdBundleCreateFlagCreated)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.Snapshot, "snapshot", false, lang.CmdBundleCreateFlagSnapshot)
+	createCmd.Flags().StringSliceVar(&bundleCfg.CreateOpts.ExcludePackages, "exclude-package", []string{}, lang.CmdBundleCreateFlagExcludePackage)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.AllowEmpty, "allow-empty", false, lang.CmdBundleCreateFlagAllowEmpty)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.SplitSize, "split-size", v.GetString(V_BNDL_CREATE_SPLIT_SIZE), lang.CmdBundleCreateFlagSplitSize)
+	createCmd.Flags().IntVar(&bundleCfg.CreateOpts.ArchiveWorkers, "archive-workers", v.GetInt(V_BNDL_CREATE_ARCHIVE_WORKERS), lang.CmdBundleCreateFlagArchiveWorkers)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.ArchiveMaxInFlight, "archive-max-in-flight", v.GetString(V_BNDL_CREATE_ARCHIVE_MAX_IN_FLIGHT), lang.CmdBundleCreateFlagArchiveMaxInFlight)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.AnnotationsFile, "annotations-file", v.GetString(V_BNDL_CREATE_ANNOTATIONS_FILE), lang.CmdBundleCreateFlagAnnotationsFile)
+	createCmd.Flags().BoolVar(&bundleCfg.CreateOpts.AllowOverride, "allow-override", false, lang.CmdBundleCreateFlagAllowOverride)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.IncludeZarfInit, "include-zarf-init", "", lang.CmdBundleCreateFlagIncludeZarfInit)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.StoreBackend, "store-backend", v.GetString(V_BNDL_CREATE_STORE_BACKEND), lang.CmdBundleCreateFlagStoreBackend)
+	createCmd.Flags().StringVar(&bundleCfg.CreateOpts.CredHelper, "cred-helper", v.GetString(V_BNDL_CREATE_CRED_HELPER), lang.CmdBundleCreateFlagCredHelper)
+	_ = createCmd.RegisterFlagCompletionFunc("sign-method", completeValues(config.SignMethodSig, config.SignMethodNotation))
+	_ = createCmd.RegisterFlagCompletionFunc("sig-algo", completeValues(config.SigAlgos...))
+	_ = createCmd.RegisterFlagCompletionFunc("oci-version", completeValues(config.OCIVersions...))
+	_ = createCmd.RegisterFlagCompletionFunc("size-report", completeValues(config.SizeReportTable, config.SizeReportJSON))
+	_ = createCmd.RegisterFlagCompletionFunc("include-strategy", completeValues(config.IncludeStrategyError, config.IncludeStrategyOverride))
+	_ = createCmd.MarkFlagDirname("output-dir")
+	_ = createCmd.MarkFlagFilename("key-password-file")
+	_ = createCmd.MarkFlagFilename("annotations-file", "yaml", "yml")
+	_ = createCmd.MarkFlagFilename("image-policy", "yaml", "yml")
+	_ = createCmd.RegisterFlagCompletionFunc("registry-style", completeValues(config.RegistryStyles...))
 
 	// deploy cmd flags
 	rootCmd.AddCommand(deployCmd)
 	deployCmd.Flags().BoolVarP(&config.CommonOptions.Confirm, "confirm", "c", false, lang.CmdBundleDeployFlagConfirm)
-	// todo: add "set" flag on deploy for high-level bundle configs?
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.SkipVariantCheck, "skip-variant-check", false, lang.CmdBundleDeployFlagSkipVariantCheck)
+	deployCmd.Flags().StringArrayVarP(&bundleCfg.DeployOpts.PublicKeyPaths, "key", "k", v.GetStringSlice(V_BNDL_DEPLOY_KEY), lang.CmdBundleDeployFlagKey)
+	deployCmd.Flags().StringVar(&bundleCfg.DeployOpts.SignMethod, "sign-method", v.GetString(V_BNDL_DEPLOY_SIGN_METHOD), lang.CmdBundleDeployFlagSignMethod)
+	deployCmd.Flags().DurationVar(&bundleCfg.DeployOpts.Timeout, "timeout", v.GetDuration(V_BNDL_DEPLOY_TIMEOUT), lang.CmdBundleDeployFlagTimeout)
+	deployCmd.Flags().DurationVar(&bundleCfg.DeployOpts.TotalTimeout, "total-timeout", v.GetDuration(V_BNDL_DEPLOY_TOTAL_TIMEOUT), lang.CmdBundleDeployFlagTotalTimeout)
+	deployCmd.Flags().StringVar(&bundleCfg.DeployOpts.NamespacePrefix, "namespace-prefix", "", lang.CmdBundleDeployFlagNamespacePrefix)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.Resume, "resume", false, lang.CmdBundleDeployFlagResume)
+	deployCmd.Flags().IntVar(&bundleCfg.DeployOpts.PackageRetries, "package-retries", v.GetInt(V_BNDL_DEPLOY_PACKAGE_RETRIES), lang.CmdBundleDeployFlagPackageRetries)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.OnlyChanged, "only-changed", false, lang.CmdBundleDeployFlagOnlyChanged)
+	deployCmd.Flags().StringVar(&bundleCfg.DeployOpts.PinFile, "pin-file", v.GetString(V_BNDL_DEPLOY_PIN_FILE), lang.CmdBundleDeployFlagPinFile)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.UpdatePins, "update-pins", false, lang.CmdBundleDeployFlagUpdatePins)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.SkipArchCheck, "skip-arch-check", false, lang.CmdBundleDeployFlagSkipArchCheck)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.Prefetch, "prefetch", false, lang.CmdBundleDeployFlagPrefetch)
+	deployCmd.Flags().StringVar(&bundleCfg.DeployOpts.Kubeconfig, "kubeconfig", v.GetString(V_BNDL_DEPLOY_KUBECONFIG), lang.CmdBundleDeployFlagKubeconfig)
+	deployCmd.Flags().StringVar(&bundleCfg.DeployOpts.KubeContext, "kube-context", v.GetString(V_BNDL_DEPLOY_KUBE_CONTEXT), lang.CmdBundleDeployFlagKubeContext)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.SkipVersionCheck, "skip-version-check", false, lang.CmdBundleFlagSkipVersionCheck)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.Decrypt, "decrypt", false, lang.CmdBundleFlagDecrypt)
+	deployCmd.Flags().StringVar(&bundleCfg.DeployOpts.IdentityPath, "identity", v.GetString(V_BNDL_DEPLOY_IDENTITY), lang.CmdBundleFlagIdentity)
+	addObjectStoreFlags(deployCmd)
+	deployCmd.Flags().StringVar(&bundleCfg.DeployOpts.ValuesFile, "values-file", v.GetString(V_BNDL_DEPLOY_VALUES_FILE), lang.CmdBundleDeployFlagValuesFile)
+	deployCmd.Flags().StringToStringVar(&bundleCfg.DeployOpts.SetVariables, "set", map[string]string{}, lang.CmdBundleDeployFlagSet)
+	deployCmd.Flags().StringToStringVar(&bundleCfg.DeployOpts.BundleLabels, "bundle-labels", map[string]string{}, lang.CmdBundleDeployFlagBundleLabels)
+	deployCmd.Flags().BoolVar(&bundleCfg.DeployOpts.SkipBundleLabels, "skip-bundle-labels", false, lang.CmdBundleDeployFlagSkipBundleLabels)
+	_ = deployCmd.RegisterFlagCompletionFunc("sign-method", completeValues(config.SignMethodSig, config.SignMethodNotation))
+	_ = deployCmd.MarkFlagFilename("values-file", "yaml", "yml")
+	_ = deployCmd.MarkFlagFilename("kubeconfig")
 	// inspect cmd flags
 	rootCmd.AddCommand(inspectCmd)
 	inspectCmd.Flags().BoolVarP(&bundleCfg.InspectOpts.IncludeSBOM, "sbom", "s", false, lang.CmdPackageInspectFlagSBOM)
 	inspectCmd.Flags().BoolVarP(&bundleCfg.InspectOpts.ExtractSBOM, "extract", "e", false, lang.CmdPackageInspectFlagExtractSBOM)
-	inspectCmd.Flags().StringVarP(&bundleCfg.InspectOpts.PublicKeyPath, "key", "k", v.GetString(V_BNDL_INSPECT_KEY), lang.CmdBundleInspectFlagKey)
+	inspectCmd.Flags().StringArrayVarP(&bundleCfg.InspectOpts.PublicKeyPaths, "key", "k", v.GetStringSlice(V_BNDL_INSPECT_KEY), lang.CmdBundleInspectFlagKey)
+	inspectCmd.Flags().StringArrayVar(&bundleCfg.InspectOpts.Attachments, "attachment", []string{}, lang.CmdBundleInspectFlagAttachment)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.FromCluster, "from-cluster", false, lang.CmdBundleInspectFlagFromCluster)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.JSON, "json", false, lang.CmdBundleInspectFlagJSON)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.Variables, "variables", false, lang.CmdBundleInspectFlagVariables)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.Tree, "tree", false, lang.CmdBundleInspectFlagTree)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.DiffDeployed, "diff-deployed", false, lang.CmdBundleInspectFlagDiffDeployed)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.Docs, "docs", false, lang.CmdBundleInspectFlagDocs)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.SignedDigest, "signed-digest", false, lang.CmdBundleInspectFlagSignedDigest)
+	inspectCmd.Flags().StringVar(&bundleCfg.InspectOpts.Package, "package", "", lang.CmdBundleInspectFlagPackage)
+	inspectCmd.Flags().StringVar(&bundleCfg.InspectOpts.ExportZarfPackages, "export-zarf-packages", "", lang.CmdBundleInspectFlagExportZarfPackages)
+	_ = inspectCmd.MarkFlagFilename("export-zarf-packages", "yaml", "yml")
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.Decrypt, "decrypt", false, lang.CmdBundleFlagDecrypt)
+	inspectCmd.Flags().StringVar(&bundleCfg.InspectOpts.IdentityPath, "identity", v.GetString(V_BNDL_INSPECT_IDENTITY), lang.CmdBundleFlagIdentity)
+	inspectCmd.Flags().BoolVar(&bundleCfg.InspectOpts.SkipVersionCheck, "skip-version-check", false, lang.CmdBundleFlagSkipVersionCheck)
+	addObjectStoreFlags(inspectCmd)
+	_ = inspectCmd.RegisterFlagCompletionFunc("attachment", completeAttachments)
+
+	// verify cmd flags
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringArrayVarP(&bundleCfg.VerifyOpts.PublicKeyPaths, "key", "k", v.GetStringSlice(V_BNDL_VERIFY_KEY), lang.CmdVerifyFlagKey)
+	verifyCmd.Flags().BoolVar(&bundleCfg.VerifyOpts.Digests, "digests", false, lang.CmdVerifyFlagDigests)
+	verifyCmd.Flags().StringVarP(&bundleCfg.VerifyOpts.Output, "output", "o", config.VerifyOutputText, lang.CmdVerifyFlagOutput)
+	verifyCmd.Flags().BoolVar(&bundleCfg.VerifyOpts.Decrypt, "decrypt", false, lang.CmdBundleFlagDecrypt)
+	verifyCmd.Flags().StringVar(&bundleCfg.VerifyOpts.IdentityPath, "identity", v.GetString(V_BNDL_VERIFY_IDENTITY), lang.CmdBundleFlagIdentity)
+	_ = verifyCmd.RegisterFlagCompletionFunc("output", completeValues(config.VerifyOutputText, config.VerifyOutputJSON))
 
 	// remove cmd flags
 	rootCmd.AddCommand(removeCmd)
 	// confirm does not use the Viper config
 	removeCmd.Flags().BoolVarP(&config.CommonOptions.Confirm, "confirm", "c", false, lang.CmdBundleRemoveFlagConfirm)
 	_ = removeCmd.MarkFlagRequired("confirm")
+	removeCmd.Flags().StringVar(&bundleCfg.RemoveOpts.NamespacePrefix, "namespace-prefix", "", lang.CmdBundleRemoveFlagNamespacePrefix)
 
 	// publish cmd flags
 	rootCmd.AddCommand(publishCmd)
+	publishCmd.Flags().StringVar(&bundleCfg.PublishOpts.SignMethod, "sign-method", v.GetString(V_BNDL_PUBLISH_SIGN_METHOD), lang.CmdBundlePublishFlagSignMethod)
+	publishCmd.Flags().StringVar(&bundleCfg.PublishOpts.NotationKey, "notation-key", v.GetString(V_BNDL_PUBLISH_NOTATION_KEY), lang.CmdBundlePublishFlagNotationKey)
+	publishCmd.Flags().BoolVar(&bundleCfg.PublishOpts.LegacyConfigMediaType, "legacy-config-media-type", false, lang.CmdBundlePublishFlagLegacyConfigMediaType)
+	publishCmd.Flags().StringVar(&config.CommonOptions.OCIVersion, "oci-version", v.GetString(V_BNDL_OCI_VERSION), lang.CmdBundleFlagOCIVersion)
+	publishCmd.Flags().StringVar(&bundleCfg.PublishOpts.ArtifactType, "artifact-type", "", lang.CmdBundlePublishFlagArtifactType)
+	_ = publishCmd.RegisterFlagCompletionFunc("artifact-type", completeValues(config.BundleArtifactType))
+	publishCmd.Flags().StringVar(&bundleCfg.PublishOpts.RegistryStyle, "registry-style", v.GetString(V_BNDL_PUBLISH_REGISTRY_STYLE), lang.CmdBundlePublishFlagRegistryStyle)
+	publishCmd.Flags().BoolVar(&bundleCfg.PublishOpts.Decrypt, "decrypt", false, lang.CmdBundleFlagDecrypt)
+	publishCmd.Flags().StringVar(&bundleCfg.PublishOpts.IdentityPath, "identity", v.GetString(V_BNDL_PUBLISH_IDENTITY), lang.CmdBundleFlagIdentity)
+	publishCmd.Flags().BoolVar(&bundleCfg.PublishOpts.CheckOnly, "check-only", false, lang.CmdBundlePublishFlagCheckOnly)
+	publishCmd.Flags().BoolVar(&bundleCfg.PublishOpts.NoReferrers, "no-referrers", false, lang.CmdBundlePublishFlagNoReferrers)
+	_ = publishCmd.RegisterFlagCompletionFunc("sign-method", completeValues(config.SignMethodSig, config.SignMethodNotation))
+	_ = publishCmd.RegisterFlagCompletionFunc("registry-style", completeValues(config.RegistryStyles...))
+	_ = publishCmd.RegisterFlagCompletionFunc("oci-version", completeValues(config.OCIVersions...))
 
 	// pull cmd flags
 	rootCmd.AddCommand(pullCmd)
 	pullCmd.Flags().StringVarP(&bundleCfg.PullOpts.OutputDirectory, "output", "o", v.GetString(V_BNDL_PULL_OUTPUT), lang.CmdBundlePullFlagOutput)
-	pullCmd.Flags().StringVarP(&bundleCfg.PullOpts.PublicKeyPath, "key", "k", v.GetString(V_BNDL_PULL_KEY), lang.CmdBundlePullFlagKey)
+	pullCmd.Flags().StringArrayVarP(&bundleCfg.PullOpts.PublicKeyPaths, "key", "k", v.GetStringSlice(V_BNDL_PULL_KEY), lang.CmdBundlePullFlagKey)
+	pullCmd.Flags().BoolVar(&bundleCfg.PullOpts.SignatureOnly, "signature-only", false, lang.CmdBundlePullFlagSignatureOnly)
+	pullCmd.Flags().StringVar(&bundleCfg.PullOpts.TarballName, "tarball-name", "", lang.CmdBundlePullFlagTarballName)
+	pullCmd.Flags().BoolVar(&bundleCfg.PullOpts.NoReferrers, "no-referrers", false, lang.CmdBundlePullFlagNoReferrers)
+	pullCmd.Flags().BoolVar(&bundleCfg.PullOpts.MetadataOnly, "metadata-only", false, lang.CmdBundlePullFlagMetadataOnly)
+	pullCmd.Flags().StringVar(&bundleCfg.PullOpts.FromFile, "from-file", "", lang.CmdBundlePullFlagFromFile)
+	pullCmd.Flags().BoolVar(&bundleCfg.PullOpts.Decrypt, "decrypt", false, lang.CmdBundleFlagDecrypt)
+	pullCmd.Flags().StringVar(&bundleCfg.PullOpts.IdentityPath, "identity", v.GetString(V_BNDL_PULL_IDENTITY), lang.CmdBundleFlagIdentity)
+	pullCmd.Flags().BoolVar(&bundleCfg.PullOpts.SkipVersionCheck, "skip-version-check", false, lang.CmdBundleFlagSkipVersionCheck)
+	addObjectStoreFlags(pullCmd)
 }
 
 // configureZarf copies configs from UDS-CLI to Zarf
@@ -259,6 +487,33 @@ func configureZarf() {
 		Confirm:        config.CommonOptions.Confirm,
 		CachePath:      config.CommonOptions.CachePath,
 	}
+
+	// Zarf's output follows uds', the packages Zarf deploys share its message output in this process
+	zarfConfig.NoColor = config.NoColor
+	setZarfOutputEnv()
+}
+
+// setZarfOutputEnv passes uds' output settings to the Zarf commands package actions run in child processes, which read
+// them from ZARF_* env vars. Env vars that are already set win, like they do for Zarf itself
+func setZarfOutputEnv() {
+	env := map[string]string{}
+	if logLevel != "" {
+		env["ZARF_LOG_LEVEL"] = logLevel
+	}
+	if message.NoProgress {
+		env["ZARF_NO_PROGRESS"] = "true"
+	}
+	if config.NoColor {
+		env["ZARF_NO_COLOR"] = "true"
+	}
+	if config.SkipLogFile {
+		env["ZARF_NO_LOG_FILE"] = "true"
+	}
+	for key, value := range env {
+		if _, ok := os.LookupEnv(key); !ok {
+			_ = os.Setenv(key, value)
+		}
+	}
 }
 
 // choosePackage provides a file picker when users don't specify a file
@@ -281,7 +536,7 @@ func choosePackage(args []string) string {
 	}
 
 	if err := survey.AskOne(prompt, &path, survey.WithValidator(survey.Required)); err != nil {
-		message.Fatalf(nil, lang.CmdPackageChooseErr, err.Error())
+		fatalf(errCodeInvalidArgument, nil, lang.CmdPackageChooseErr, err.Error())
 	}
 
 	return path
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/corang/uds-cli/src/pkg/bundle"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [DIRECTORY]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: lang.CmdValidateShort,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		bundleYAML := filepath.Join(dir, config.BundleYAML)
+		if err := bundle.ValidateBundleSchema(bundleYAML); err != nil {
+			fatalf(errCodeValidate, err, "Failed to validate bundle: %s", err.Error())
+		}
+		message.Successf("%s is valid", bundleYAML)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
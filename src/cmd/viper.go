@@ -20,32 +20,83 @@ const (
 	V_LOG_LEVEL    = "log_level"
 	V_ARCHITECTURE = "architecture"
 	V_NO_LOG_FILE  = "no_log_file"
+	V_LOG_FILE     = "log_file"
 	V_NO_PROGRESS  = "no_progress"
+	V_NO_COLOR     = "no_color"
 	V_ZARF_CACHE   = "zarf_cache"
 	V_TMP_DIR      = "tmp_dir"
 	V_INSECURE     = "insecure"
+	V_PLAIN_HTTP   = "plain_http"
+	V_JSON_ERRORS  = "json_errors"
+	V_USER_AGENT   = "user_agent"
 
 	// Bundle config keys
 	V_BNDL_OCI_CONCURRENCY = "bundle.oci_concurrency"
+	V_BNDL_S3_REGION       = "bundle.s3.region"
+	V_BNDL_S3_ENDPOINT     = "bundle.s3.endpoint"
+	V_BNDL_OCI_VERSION     = "bundle.oci_version"
 
 	// Bundle create config keys
-	V_BNDL_CREATE_OUTPUT               = "bundle.create.output"
-	V_BNDL_CREATE_SIGNING_KEY          = "bundle.create.signing_key"
-	V_BNDL_CREATE_SIGNING_KEY_PASSWORD = "bundle.create.signing_key_password"
-	V_BNDL_CREATE_SET                  = "bundle.create.set"
+	V_BNDL_CREATE_OUTPUT                = "bundle.create.output"
+	V_BNDL_CREATE_SIGNING_KEY           = "bundle.create.signing_key"
+	V_BNDL_CREATE_SIGNING_KEY_PASSWORD  = "bundle.create.signing_key_password"
+	V_BNDL_CREATE_KEY_PASSWORD_FILE     = "bundle.create.key_password_file"
+	V_BNDL_CREATE_STRIP_HISTORY         = "bundle.create.strip_history"
+	V_BNDL_CREATE_DENY_IMAGES           = "bundle.create.deny_images"
+	V_BNDL_CREATE_IMAGE_POLICY          = "bundle.create.image_policy"
+	V_BNDL_CREATE_SET                   = "bundle.create.set"
+	V_BNDL_CREATE_PLATFORM_VARIANT      = "bundle.create.platform_variant"
+	V_BNDL_CREATE_RECIPIENTS            = "bundle.create.recipients"
+	V_BNDL_CREATE_SIGN_METHOD           = "bundle.create.sign_method"
+	V_BNDL_CREATE_SIG_ALGO              = "bundle.create.sig_algo"
+	V_BNDL_CREATE_NOTATION_KEY          = "bundle.create.notation_key"
+	V_BNDL_CREATE_REPO_PREFIX           = "bundle.create.repo_prefix"
+	V_BNDL_CREATE_INCLUDE_STRATEGY      = "bundle.create.include_strategy"
+	V_BNDL_CREATE_REGISTRY_STYLE        = "bundle.create.registry_style"
+	V_BNDL_CREATE_PARALLEL_PACKAGES     = "bundle.create.parallel_packages"
+	V_BNDL_CREATE_OUTPUT_DIR            = "bundle.create.output_dir"
+	V_BNDL_CREATE_TIMEOUT               = "bundle.create.timeout"
+	V_BNDL_CREATE_SPLIT_SIZE            = "bundle.create.split_size"
+	V_BNDL_CREATE_ANNOTATIONS_FILE      = "bundle.create.annotations_file"
+	V_BNDL_CREATE_STORE_BACKEND         = "bundle.create.store_backend"
+	V_BNDL_CREATE_CRED_HELPER           = "bundle.create.cred_helper"
+	V_BNDL_CREATE_ARCHIVE_WORKERS       = "bundle.create.archive_workers"
+	V_BNDL_CREATE_ARCHIVE_MAX_IN_FLIGHT = "bundle.create.archive_max_in_flight"
 
 	// Bundle deploy config keys
-	V_BNDL_DEPLOY_ZARF_PACKAGES = "bundle.deploy.zarf-packages"
+	V_BNDL_DEPLOY_ZARF_PACKAGES   = "bundle.deploy.zarf-packages"
+	V_BNDL_DEPLOY_IDENTITY        = "bundle.deploy.identity"
+	V_BNDL_DEPLOY_KEY             = "bundle.deploy.key"
+	V_BNDL_DEPLOY_KUBECONFIG      = "bundle.deploy.kubeconfig"
+	V_BNDL_DEPLOY_KUBE_CONTEXT    = "bundle.deploy.kube_context"
+	V_BNDL_DEPLOY_PIN_FILE        = "bundle.deploy.pin_file"
+	V_BNDL_DEPLOY_PACKAGE_RETRIES = "bundle.deploy.package_retries"
+	V_BNDL_DEPLOY_SIGN_METHOD     = "bundle.deploy.sign_method"
+	V_BNDL_DEPLOY_TIMEOUT         = "bundle.deploy.timeout"
+	V_BNDL_DEPLOY_TOTAL_TIMEOUT   = "bundle.deploy.total_timeout"
+	V_BNDL_DEPLOY_VALUES_FILE     = "bundle.deploy.values_file"
 
 	// Bundle inspect config keys
-	V_BNDL_INSPECT_KEY = "bundle.inspect.key"
+	V_BNDL_INSPECT_KEY      = "bundle.inspect.key"
+	V_BNDL_INSPECT_IDENTITY = "bundle.inspect.identity"
+
+	// Bundle verify config keys
+	V_BNDL_VERIFY_KEY      = "bundle.verify.key"
+	V_BNDL_VERIFY_IDENTITY = "bundle.verify.identity"
+
+	// Bundle publish config keys
+	V_BNDL_PUBLISH_IDENTITY       = "bundle.publish.identity"
+	V_BNDL_PUBLISH_SIGN_METHOD    = "bundle.publish.sign_method"
+	V_BNDL_PUBLISH_NOTATION_KEY   = "bundle.publish.notation_key"
+	V_BNDL_PUBLISH_REGISTRY_STYLE = "bundle.publish.registry_style"
 
 	// Bundle remove config keys
 	V_BNDL_REMOVE_PACKAGES = "bundle.remove.packages"
 
 	// Bundle pull config keys
-	V_BNDL_PULL_OUTPUT = "bundle.pull.output"
-	V_BNDL_PULL_KEY    = "bundle.pull.key"
+	V_BNDL_PULL_OUTPUT   = "bundle.pull.output"
+	V_BNDL_PULL_KEY      = "bundle.pull.key"
+	V_BNDL_PULL_IDENTITY = "bundle.pull.identity"
 )
 
 func initViper() {
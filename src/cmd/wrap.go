@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package cmd contains the CLI commands for UDS.
+package cmd
+
+import (
+	"os"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/config/lang"
+	"github.com/spf13/cobra"
+)
+
+var wrapCmd = &cobra.Command{
+	Use:   "wrap [ZARF_PACKAGE_TARBALL]",
+	Args:  cobra.ExactArgs(1),
+	Short: lang.CmdWrapShort,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if _, err := os.Stat(args[0]); err != nil {
+			fatalf(errCodeInvalidArgument, err, "First argument (%q) must be a valid path to a Zarf package tarball: %s", args[0], err.Error())
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleCfg.WrapOpts.PackagePath = args[0]
+
+		bndlClient := newBundlerOrDie()
+		defer bndlClient.ClearPaths()
+
+		if err := bndlClient.Wrap(); err != nil {
+			bndlClient.ClearPaths()
+			fatalf(errCodeWrap, err, "Failed to wrap Zarf package: %s", err.Error())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(wrapCmd)
+	wrapCmd.Flags().BoolVarP(&config.CommonOptions.Confirm, "confirm", "c", false, lang.CmdWrapFlagConfirm)
+	wrapCmd.Flags().StringVar(&bundleCfg.WrapOpts.Name, "name", "", lang.CmdWrapFlagName)
+	wrapCmd.Flags().StringVar(&bundleCfg.WrapOpts.Version, "version", "", lang.CmdWrapFlagVersion)
+	wrapCmd.Flags().StringVar(&bundleCfg.WrapOpts.Description, "description", "", lang.CmdWrapFlagDescription)
+}
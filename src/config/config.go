@@ -5,29 +5,52 @@
 package config
 
 import (
+	"fmt"
+	"os"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/corang/uds-cli/src/types"
 	zarfConfig "github.com/defenseunicorns/zarf/src/config"
 	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	specs "github.com/opencontainers/image-spec/specs-go"
 )
 
 const (
 	// ZarfYAML is the string for zarf.yaml
 	ZarfYAML = "zarf.yaml"
 
-	// BlobsDir is the string for the blobs/sha256 dir in an OCI artifact
-	BlobsDir = "blobs/sha256"
+	// BlobsRoot is the string for the blobs dir in an OCI artifact, blobs are stored in subdirectories named after their digest algorithm
+	BlobsRoot = "blobs"
+
+	// BlobsDir is the string for the blobs/sha256 dir in an OCI artifact, the default digest algorithm
+	BlobsDir = BlobsRoot + "/sha256"
 
 	// BundleYAML is the string for zarf.yaml
 	BundleYAML = "uds-bundle.yaml"
 
+	// PackageCacheDir is the dir in the Zarf cache that uds pull stores the OCI layout of pulled bundles in
+	PackageCacheDir = "packages"
+
+	// SBOMCacheDir is the dir in the Zarf cache that the sboms.tar of each package inspected with --sbom is cached in
+	SBOMCacheDir = "sboms"
+
+	// ZarfInitCacheDir is the dir in the Zarf cache that init packages downloaded for --include-zarf-init are cached in
+	ZarfInitCacheDir = "init"
+
+	// ZarfReleasesURL is where Zarf's release assets are downloaded from, ie. <url>/<version>/zarf-init-<arch>-<version>.tar.zst
+	ZarfReleasesURL = "https://github.com/defenseunicorns/zarf/releases/download"
+
 	// BundlePrefix is the prefix for compiled uds bundles
 	BundlePrefix = "uds-bundle-"
 
 	// SBOMsTar is the sboms.tar file in a Zarf pkg
 	SBOMsTar = "sboms.tar"
 
+	// UDSIgnore lists gitignore-style patterns of files to leave out when bundling an unpacked Zarf package directory
+	UDSIgnore = ".udsignore"
+
 	// BundleSBOMTar is the name of the tarball containing the bundle's SBOM
 	BundleSBOMTar = "bundle-sboms.tar"
 
@@ -37,14 +60,199 @@ const (
 	// BundleYAMLSignature is the name of the bundle's metadata signature file
 	BundleYAMLSignature = "uds-bundle.yaml.sig"
 
+	// BundleMetadataIndex is the name of the file listing the bundles pulled with pull --metadata-only
+	BundleMetadataIndex = "uds-bundle-index.json"
+
 	// PublicKeyFile is the name of the public key file
 	PublicKeyFile = "public.key"
+
+	// MultiOS is the OS used in platform descriptors, bundles aren't tied to a single OS
+	MultiOS = "multi"
+
+	// DeployRecordNamespace is the namespace deploy records are written to, alongside Zarf's own package secrets
+	DeployRecordNamespace = "zarf"
+
+	// DeployRecordLabel is the label used to find bundle deploy records in the cluster
+	DeployRecordLabel = "uds.dev/deploy-record"
+
+	// BundleNameLabel is the label on the namespaces a bundle's packages deploy into holding the bundle's name
+	BundleNameLabel = "uds.dev/bundle-name"
+
+	// BundleVersionLabel is the label on the namespaces a bundle's packages deploy into holding the bundle's version
+	BundleVersionLabel = "uds.dev/bundle-version"
+
+	// ReferrerSubjectAnnotation is the annotation on the index.json entries of referrers pulled with a bundle holding the digest of the manifest each refers to
+	ReferrerSubjectAnnotation = "uds.dev/referrer-subject"
+
+	// BundleRevisionLabel is the label on the namespaces a bundle's packages deploy into holding the revision the bundle was built from
+	BundleRevisionLabel = "uds.dev/bundle-revision"
+
+	// NodePlatformVariantLabel is the label on a cluster's nodes holding their CPU variant (ie. v7, v8), Kubernetes doesn't report it
+	NodePlatformVariantLabel = "uds.dev/platform-variant"
+
+	// DeployRecordDataKey is the key in a deploy record secret containing the serialized record
+	DeployRecordDataKey = "data"
+
+	// PackageTagAnnotation is the annotation on a Zarf package's manifest descriptor holding the tag its digest was resolved from
+	PackageTagAnnotation = "uds.dev/package-tag"
+
+	// PackageRepositoryAnnotation is the annotation on a Zarf package's manifest descriptor holding the repository its layers were pushed to with --repo-prefix
+	PackageRepositoryAnnotation = "uds.dev/package-repository"
+
+	// PlatformVariantAnnotation is the annotation on a bundle's root manifest holding the CPU variant it was built for
+	PlatformVariantAnnotation = "uds.dev/platform-variant"
+
+	// CatalogConfigMediaType is the media type of the config blob of each entry in a bundle catalog
+	CatalogConfigMediaType = "application/vnd.uds.catalog.config.v1+json"
+
+	// CatalogEntryMediaType is the media type of the layer holding a catalog entry's bundle as JSON
+	CatalogEntryMediaType = "application/vnd.uds.catalog.entry.v1+json"
+
+	// CatalogBundleNameAnnotation is the annotation on a catalog entry holding its bundle's metadata.name
+	CatalogBundleNameAnnotation = "uds.dev/bundle-name"
+
+	// CatalogBundleVersionAnnotation is the annotation on a catalog entry holding its bundle's metadata.version
+	CatalogBundleVersionAnnotation = "uds.dev/bundle-version"
+
+	// CatalogBundleArchitectureAnnotation is the annotation on a catalog entry holding its bundle's architecture
+	CatalogBundleArchitectureAnnotation = "uds.dev/bundle-architecture"
+
+	// CatalogBundleRefAnnotation is the annotation on a catalog entry holding the OCI ref its bundle was published to
+	CatalogBundleRefAnnotation = "uds.dev/bundle-ref"
+
+	// CatalogBundleDigestAnnotation is the annotation on a catalog entry holding the digest of its bundle's root manifest
+	CatalogBundleDigestAnnotation = "uds.dev/bundle-digest"
+
+	// BundleArtifactType is the artifactType recommended for a bundle's root manifest with --artifact-type
+	BundleArtifactType = "application/vnd.uds.bundle.v1"
+
+	// BundleConfigMediaType is the media type of the config blob of a bundle's root manifest
+	BundleConfigMediaType = "application/vnd.uds.bundle.config.v1+json"
+
+	// SignMethodSig signs the bundle's uds-bundle.yaml, stored as the uds-bundle.yaml.sig layer
+	SignMethodSig = "sig"
+
+	// SignMethodNotation signs the bundle's manifest with Notary v2, stored as a referrer in the registry
+	SignMethodNotation = "notation"
+
+	// SigAlgoAnnotation is the annotation on the uds-bundle.yaml.sig layer holding the algorithm of the signature
+	SigAlgoAnnotation = "uds.dev/signature-algorithm"
+
+	// SigAlgoECDSAP256 is an ECDSA signature over SHA-256 with a P-256 key
+	SigAlgoECDSAP256 = "ecdsa-p256"
+
+	// SigAlgoECDSAP384 is an ECDSA signature over SHA-256 with a P-384 key
+	SigAlgoECDSAP384 = "ecdsa-p384"
+
+	// SigAlgoECDSAP521 is an ECDSA signature over SHA-256 with a P-521 key
+	SigAlgoECDSAP521 = "ecdsa-p521"
+
+	// SigAlgoEd25519 is an Ed25519 signature
+	SigAlgoEd25519 = "ed25519"
+
+	// SigAlgoRSA is an RSA PKCS #1 v1.5 signature over SHA-256
+	SigAlgoRSA = "rsa"
+
+	// NotationBinary is the name of the notation CLI used to produce/verify Notary v2 signatures
+	NotationBinary = "notation"
+
+	// GitBinary is the name of the git CLI used to clone the git sources of packages, so git's own credential helpers and SSH config apply
+	GitBinary = "git"
+
+	// SizeReportTable prints create's --size-report as a table
+	SizeReportTable = "table"
+
+	// SizeReportJSON prints create's --size-report as JSON
+	SizeReportJSON = "json"
+
+	// VerifyOutputText prints the result of uds verify as one line per check
+	VerifyOutputText = "text"
+
+	// VerifyOutputJSON prints the result of uds verify as JSON
+	VerifyOutputJSON = "json"
+
+	// DefaultCreateTimeout is the default --timeout of uds create
+	DefaultCreateTimeout = 30 * time.Minute
+
+	// PackageRetryBackoff is the wait before the first --package-retries retry of a package deploy, it doubles with
+	// each retry
+	PackageRetryBackoff = 10 * time.Second
+
+	// DefaultReadinessTimeout is how long deploy waits for a package's readiness check without a timeout
+	DefaultReadinessTimeout = 5 * time.Minute
+
+	// ReadinessPollInterval is the wait between attempts of a failing readiness check
+	ReadinessPollInterval = 5 * time.Second
+
+	// RegistryHeadTimeout bounds each request that checks whether a registry already has a blob
+	RegistryHeadTimeout = 30 * time.Second
+
+	// RegistryResponseHeaderTimeout bounds the wait for a registry (or its token service) to start responding to a
+	// request, it doesn't limit how long blobs take to transfer
+	RegistryResponseHeaderTimeout = 2 * time.Minute
+
+	// DefaultOCIVersion is the ociVersion written to bundle and package manifest configs
+	DefaultOCIVersion = "1.0.1"
+
+	// OCIVersionSpec resolves --oci-version to the release of the OCI image-spec UDS is built against
+	OCIVersionSpec = "spec"
+
+	// IncludeStrategyError fails create when an included package conflicts with one of the bundle's own packages
+	IncludeStrategyError = "error"
+
+	// IncludeStrategyOverride keeps the bundle's own package when it conflicts with an included package
+	IncludeStrategyOverride = "override"
+
+	// RegistryStyleAuto detects the registry type from the publish destination's host
+	RegistryStyleAuto = "auto"
+
+	// RegistryStyleGeneric applies no registry-specific path rules
+	RegistryStyleGeneric = "generic"
+
+	// RegistryStyleHarbor requires a project in the repository path
+	RegistryStyleHarbor = "harbor"
+
+	// RegistryStyleECR requires the repository to exist before pushing, ECR doesn't create repositories on push
+	RegistryStyleECR = "ecr"
+
+	// RegistryStyleGHCR requires an owner in the repository path
+	RegistryStyleGHCR = "ghcr"
+
+	// RegistryStyleDockerHub requires a namespace in the repository path and pushes to Docker Hub's API host
+	RegistryStyleDockerHub = "dockerhub"
+
+	// RegistryStyleArtifactRegistry requires a project and repository in the repository path
+	RegistryStyleArtifactRegistry = "artifact-registry"
+
+	// SourceDateEpochEnvVar is the env var used to set reproducible timestamps in created bundles
+	SourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+
+	// BundleAPIVersionV1Alpha1 is the first versioned uds-bundle.yaml spec
+	BundleAPIVersionV1Alpha1 = "uds.dev/v1alpha1"
+
+	// BundleAPIVersion is the uds-bundle.yaml spec version uds migrate upgrades bundles to
+	BundleAPIVersion = BundleAPIVersionV1Alpha1
+
+	// KeyPasswordEnvVar is the env var used to pass the signing key's password without a prompt
+	KeyPasswordEnvVar = "UDS_KEY_PASSWORD"
 )
 
 var (
+	// RegistryStyles are the valid values of --registry-style
+	RegistryStyles = []string{RegistryStyleAuto, RegistryStyleGeneric, RegistryStyleHarbor, RegistryStyleECR, RegistryStyleGHCR, RegistryStyleDockerHub, RegistryStyleArtifactRegistry}
+
+	// OCIVersions are the valid values of --oci-version, the released versions of the OCI image-spec
+	OCIVersions = []string{"1.0.0", "1.0.1", "1.0.2", "1.1.0", OCIVersionSpec}
+
+	// SigAlgos are the valid values of --sig-algo
+	SigAlgos = []string{SigAlgoECDSAP256, SigAlgoECDSAP384, SigAlgoECDSAP521, SigAlgoEd25519, SigAlgoRSA}
+
 	// CommonOptions tracks user-defined values that apply across commands.
 	CommonOptions types.BundlerCommonOptions
 
+	// JSONErrors prints command failures as a single JSON object on stderr
+	JSONErrors bool
+
 	// CLIVersion track the version of the CLI
 	CLIVersion = "unset"
 
@@ -53,21 +261,64 @@ var (
 
 	// SkipLogFile is a flag to skip logging to a file
 	SkipLogFile bool
+
+	// NoColor disables colors in the output of uds and the Zarf operations it runs
+	NoColor bool
+
+	// LogFile is where the log file is written instead of a timestamped file in the temp directory
+	LogFile string
 )
 
+// NewArchContext resolves the architecture of a command from --architecture and the host
+func NewArchContext(cliArch string) types.ArchContext {
+	return types.ArchContext{Override: cliArch, Host: runtime.GOARCH}
+}
+
 // GetArch returns the arch based on a priority list with options for overriding.
+//
+// the bundler resolves architectures with the ArchContext of its BundlerConfig instead
 func GetArch(archs ...string) string {
-	// List of architecture overrides.
-	priority := append([]string{CLIArch}, archs...)
+	return NewArchContext(CLIArch).Resolve(archs...)
+}
 
-	// Find the first architecture that is specified.
-	for _, arch := range priority {
-		if arch != "" {
-			return arch
-		}
+// GetUserAgent returns the User-Agent header sent on registry requests
+func GetUserAgent() string {
+	if CommonOptions.UserAgent != "" {
+		return CommonOptions.UserAgent
+	}
+	return "uds-cli/" + CLIVersion
+}
+
+// GetOCIVersion returns the ociVersion to write to manifest configs
+func GetOCIVersion() string {
+	switch CommonOptions.OCIVersion {
+	case "":
+		return DefaultOCIVersion
+	case OCIVersionSpec:
+		// drop the pre-release suffix (ie. -rc.4), registries validate against released versions of the spec
+		return fmt.Sprintf("%d.%d.%d", specs.VersionMajor, specs.VersionMinor, specs.VersionPatch)
+	default:
+		return CommonOptions.OCIVersion
 	}
+}
 
-	return runtime.GOARCH
+// GetArchVariant returns the CPU variant (ie. v7, v8) of the device executing the CLI commands for the given arch,
+// it is read from the "CPU architecture" of /proc/cpuinfo and is empty when it can't be told
+func GetArchVariant(arch string) string {
+	if arch != runtime.GOARCH || (arch != "arm" && arch != "arm64") {
+		return ""
+	}
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(key) == "CPU architecture" && strings.TrimSpace(value) != "" {
+			return "v" + strings.TrimSpace(value)
+		}
+	}
+	return ""
 }
 
 var (
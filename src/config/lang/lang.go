@@ -7,46 +7,202 @@ package lang
 const (
 	// root UDS-CLI cmds
 	RootCmdShort              = "CLI for UDS Bundles"
+	RootCmdFlagArch           = "Architecture for bundles and Zarf packages, overrides metadata.architecture and the host's architecture"
 	RootCmdFlagSkipLogFile    = "Disable log file creation"
+	RootCmdFlagLogFile        = "Write the log file to this path instead of a timestamped file in the temp directory (ignored with --no-log-file)"
 	RootCmdFlagNoProgress     = "Disable fancy UI progress bars, spinners, logos, etc"
+	RootCmdFlagNoColor        = "Disable colors in output, including the output of Zarf operations (also set by the NO_COLOR env var)"
 	RootCmdFlagCachePath      = "Specify the location of the Zarf cache directory"
 	RootCmdFlagTempDir        = "Specify the temporary directory to use for intermediate files"
+	RootCmdFlagUserAgent      = "User-Agent header sent on registry requests (default uds-cli/<version>)"
+	RootCmdFlagPlainHTTP      = "Connect to OCI registries over plain HTTP (ie. a local registry without TLS). Unlike --insecure, this doesn't disable TLS verification, checksums or signature validation."
 	RootCmdFlagInsecure       = "Allow access to insecure registries and disable other recommended security enforcements such as package checksum and signature validation. This flag should only be used if you have a specific reason and accept the reduced security posture."
+	RootCmdFlagJSONErrors     = "Print command failures as a single JSON object ({\"command\", \"error\", \"code\"}) on stderr for automation"
 	RootCmdFlagLogLevel       = "Log level when running UDS-CLI. Valid options are: warn, info, debug, trace"
 	RootCmdErrInvalidLogLevel = "Invalid log level. Valid options are: warn, info, debug, trace."
 
 	// bundle
 	CmdBundleShort           = "Commands for creating, deploying, removing, pulling, and inspecting bundles"
 	CmdBundleFlagConcurrency = "Number of concurrent layer operations to perform when interacting with a remote bundle."
+	CmdBundleFlagOCIVersion  = "The ociVersion written to bundle and package manifest configs, use 'spec' for the OCI image-spec version UDS is built against."
 
 	// bundle create
 	CmdBundleCreateShort = "Create a bundle from a given directory or the current directory"
 	//CmdBundleCreateFlagConfirm            = "Confirm bundle creation without prompting"
-	CmdBundleCreateFlagOutput             = "Specify the output (an oci:// URL) for the created bundle"
-	CmdBundleCreateFlagSigningKey         = "Path to private key file for signing bundles"
-	CmdBundleCreateFlagSigningKeyPassword = "Password to the private key file used for signing bundles"
-	CmdBundleCreateFlagSet                = "Specify bundle template variables to set on the command line (KEY=value)"
+	CmdBundleCreateFlagOutput                = "Specify the output (an oci:// URL) for the created bundle, or - or a named pipe to stream the bundle tarball to"
+	CmdBundleCreateFlagSigningKey            = "Path to private key file for signing bundles"
+	CmdBundleCreateFlagSigningKeyPassword    = "Password to the private key file used for signing bundles"
+	CmdBundleCreateFlagDenyImage             = "Fail the create if a bundled image matches this pattern, * matches anything (ie. '*:latest' or 'docker.io/*'), can be repeated"
+	CmdBundleCreateFlagImagePolicy           = "Path to an image policy file with deny patterns and an optional scanner command to check each bundled image with"
+	CmdBundleCreateFlagStripHistory          = "Leave the build host, build user, metadata.authors and metadata.source out of the bundle and its manifest annotations, for bundles shared externally"
+	CmdBundleCreateFlagKeyPasswordFile       = "Path to a file containing the password to the private key file used for signing bundles (UDS_KEY_PASSWORD can also be used)"
+	CmdBundleCreateFlagSet                   = "Specify bundle template variables to set on the command line (KEY=value)"
+	CmdBundleCreateFlagPlatformVariant       = "Specify the CPU variant of the target architecture (ie. v7, v8), overrides metadata.platformVariant"
+	CmdBundleCreateFlagSignMethod            = "Method used to sign the bundle: 'sig' signs uds-bundle.yaml with --signing-key, 'notation' produces a Notary v2 signature over the manifest (requires --output and the notation CLI)"
+	CmdBundleCreateFlagSigAlgo               = "Signature algorithm the --signing-key must sign with: ecdsa-p256, ecdsa-p384, ecdsa-p521, ed25519 or rsa (defaults to the key's own algorithm)"
+	CmdBundleCreateFlagNotationKey           = "Name of the notation signing key to use with --sign-method notation (defaults to notation's default key)"
+	CmdBundleCreateFlagAttach                = "Attach an extra file to the bundle as a named layer (name=path), can be repeated"
+	CmdBundleCreateFlagSizeReport            = "Print a per-layer size breakdown of the bundle tarball after it is created, as a table or json (ie. --size-report=json)"
+	CmdBundleCreateFlagRepoPrefix            = "Push each package's layers to <prefix>/<package repository name> instead of the bundle's repository (ie. myregistry.com/mirror), requires --output"
+	CmdBundleCreateFlagValidateSchema        = "Validate the uds-bundle.yaml against the UDSBundle JSON schema before creating, reporting unknown keys, wrong types and missing fields"
+	CmdBundleCreateFlagIncludeStrategy       = "How to handle a package in an included bundle that has the same name as one of the bundle's own packages but a different ref: error or override (keep the bundle's own package)"
+	CmdBundleCreateFlagRegistryStyle         = "Path rules of the --output registry: auto (detect from the host), generic, harbor, ecr, ghcr, dockerhub or artifact-registry"
+	CmdBundleCreateFlagOutputDir             = "Directory to write the bundle tarball to, created if it doesn't exist (defaults to the bundle's directory)"
+	CmdBundleCreateFlagArtifactType          = "Set the bundle manifest's artifactType (ie. application/vnd.uds.bundle.v1) so registries can tell it apart from images, it is dropped for registries that reject it"
+	CmdBundleCreateFlagLegacyConfigMediaType = "Give the bundle manifest's config the media type used by older versions of uds instead of application/vnd.uds.bundle.config.v1+json, for registries and tools that expect it"
+	CmdBundleCreateFlagParallelPackages      = "Number of packages to fetch into a bundle tarball at once"
+	CmdBundleCreateFlagExcludeSBOM           = "Leave every package's SBOMs (sboms.tar) out of the bundle to save space, this reduces the bundle's provenance"
+	CmdBundleCreateFlagTimeout               = "Maximum time for all of the create's registry requests (ie. 1h), a stuck registry fails the create instead of hanging it, 0 waits forever"
+	CmdBundleCreateFlagPackageBuildArgs      = "Zarf package create flags for a package built from its git source (PACKAGE=\"--set KEY=value --skip-sbom\"), added after the package's buildArgs, can be repeated"
+	CmdBundleCreateFlagOffline               = "Create the bundle without network access, fails if any Zarf package references a remote repository"
+	CmdBundleCreateFlagEncrypt               = "Encrypt the bundle tarball at rest, requires at least one --recipient"
+	CmdBundleCreateFlagRecipient             = "age public key (age1...) that can decrypt the bundle tarball, can be repeated"
+	CmdBundleCreateFlagManifestOnly          = "Only push the bundle's manifests, config, uds-bundle.yaml and signature, failing if the package layers don't already exist in the registry, requires --output"
+	CmdBundleCreateFlagFailFast              = "Stop at the first package that fails to push, set to false to attempt every package and report which failed, requires --output"
+	CmdBundleCreateFlagStrict                = "Fail the create instead of warning when a package's zarf.yaml references images that aren't in the package"
+	CmdBundleCreateFlagPinImages             = "[ADVANCED] Pin each tagged image of the bundle's packages to the digest of the image packaged for it, listing the pinned refs alongside the tags and changing the packages' digests and removing their signatures, only for bundle tarballs"
+	CmdBundleCreateFlagOptimizeLayers        = "[ADVANCED] Rewrite packages whose images have layers with the same uncompressed content as another package's to share one copy, changing their digests and removing their signatures, only for bundle tarballs"
+	CmdBundleCreateFlagCreated               = "RFC3339 timestamp to stamp as the bundle's build timestamp and org.opencontainers.image.created annotation instead of the build time (ie. 2023-07-22T04:26:40Z), wins over SOURCE_DATE_EPOCH"
+	CmdBundleCreateFlagAnnotationsFile       = "Path to a YAML map of annotation keys to values to add to the bundle's root manifest"
+	CmdBundleCreateFlagAllowOverride         = "Allow --annotations-file to replace the reserved org.opencontainers.* annotations set from the bundle's metadata"
+	CmdBundleCreateFlagIncludeZarfInit       = "Download the Zarf init package of this version (ie. v0.29.1) for the bundle's architecture and bundle it as the first package, so the bundle can initialize a bare cluster"
+	CmdBundleCreateFlagCredHelper            = "Docker credential helper binary (a path, a binary on the PATH or a docker-credential-<name> name) to get credentials for the --output registry and remote package sources from before the docker config"
+	CmdBundleCreateFlagStoreBackend          = "Where the bundle tarball's blobs are stored while it's built: local (a temporary directory) or dir:<path> (ie. dir:/mnt/staging for a mounted object store or network share)"
+	CmdBundleCreateFlagExcludePackage        = "Names of packages in the uds-bundle.yaml to leave out of this build of the bundle (ie. name1,name2)"
+	CmdBundleCreateFlagAllowEmpty            = "Create the bundle even if it has no packages, ie. when --exclude-package removes all of them"
+	CmdBundleCreateFlagSnapshot              = "Name the bundle tarball by its sha256 (uds-bundle-<sha256>.tar.zst) for content-addressed storage and write a <name>-<arch>-<version>.snapshot.json mapping to it, only for bundle tarballs"
+	CmdBundleCreateFlagArchiveWorkers        = "Number of zstd encoders that compress the bundle tarball at once (default is one per CPU)"
+	CmdBundleCreateFlagArchiveMaxInFlight    = "Most memory the encoders may buffer while compressing the bundle tarball (ie. 256MiB), smaller limits compress less well"
+	CmdBundleCreateFlagSplitSize             = "Split the bundle tarball into numbered parts of at most this size (ie. 4GB) with a manifest of their checksums, deploy and the other commands reassemble them"
 
 	// bundle deploy
-	
-	CmdBundleDeployShort = "Deploy a bundle from a local tarball or oci:// URL"
-	//CmdBundleDeployFlagSet     = "Specify deployment variables to set on the command line (KEY=value)"
-	CmdBundleDeployFlagConfirm = "Confirms bundle deployment without prompting. ONLY use with bundles you trust. Skips prompts to review SBOM, configure variables, select optional components and review potential breaking changes."
+
+	CmdBundleDeployShort                = "Deploy a bundle from a local tarball or oci:// URL"
+	CmdBundleDeployFlagSet              = "Package variables to set on the command line (PACKAGE.VARIABLE=value), these override --values-file"
+	CmdBundleDeployFlagBundleLabels     = "Bundle manifest annotations to label the namespaces packages deploy into with (ANNOTATION=label-key), replacing the default name, version and revision labels"
+	CmdBundleDeployFlagSkipBundleLabels = "Don't label the namespaces packages deploy into with the bundle that deployed them"
+	CmdBundleDeployFlagValuesFile       = "Path to a YAML file mapping package names to the variables to set for them (ie. one file per environment)"
+	CmdBundleDeployFlagConfirm          = "Confirms bundle deployment without prompting. ONLY use with bundles you trust. Skips prompts to review SBOM, configure variables, select optional components and review potential breaking changes."
+	CmdBundleDeployFlagSkipVariantCheck = "Deploy even if the bundle's platform variant does not match the host's"
+	CmdBundleDeployFlagSignMethod       = "Additional signature to verify: 'notation' verifies the bundle's Notary v2 signature against the configured notation trust policy"
+	CmdBundleDeployFlagTimeout          = "Maximum time to wait for each Zarf package to deploy (ie. 15m), 0 waits forever"
+	CmdBundleDeployFlagTotalTimeout     = "Maximum time to wait for the entire bundle to deploy (ie. 1h), 0 waits forever"
+	CmdBundleDeployFlagNamespacePrefix  = "Prefix the namespaces each package's charts and manifests deploy into (ie. tenant-a-), Zarf init packages are never prefixed"
+	CmdBundleDeployFlagResume           = "Skip packages that the last deploy of this bundle already applied unchanged and continue from the first new or changed package"
+	CmdBundleDeployFlagPackageRetries   = "Number of times to retry a package deploy that fails on a transient cluster error (ie. an API server timeout or image pull backoff), with a doubling backoff between retries"
+	CmdBundleDeployFlagOnlyChanged      = "Only deploy the packages whose digest or optional components changed since the last deploy of this bundle"
+	CmdBundleDeployFlagPinFile          = "Path to a file of trusted package digests: packages are pinned on first deploy and later deploys fail if a pinned digest changes"
+	CmdBundleDeployFlagUpdatePins       = "Trust the bundle's current package digests, replacing its pins in --pin-file"
+	CmdBundleDeployFlagKey              = "Path, keyring directory, https:// URL or oci:// ref of a public key that will be used to validate a signed bundle, can be repeated to trust any of several keys"
+	CmdBundleDeployFlagSkipArchCheck    = "Deploy even if the bundle's architecture does not match the cluster's nodes (ie. heterogeneous clusters with multi-arch images)"
+	CmdBundleDeployFlagKubeconfig       = "Path to the kubeconfig of the cluster to deploy to instead of the ambient one (KUBECONFIG or ~/.kube/config)"
+	CmdBundleDeployFlagKubeContext      = "Kubeconfig context of the cluster to deploy to instead of the kubeconfig's current context"
+	CmdBundleDeployFlagPrefetch         = "Push the images of every package into the Zarf registry before applying any of them, so an image push failure can't leave the bundle partly deployed"
+
+	// bundle decryption (deploy, inspect, publish, pull)
+	CmdBundleFlagDecrypt     = "Decrypt an encrypted bundle tarball before use (implied for tarballs ending in .age)"
+	CmdBundleFlagIdentity    = "Path to an age identity (private key) file used to decrypt an encrypted bundle tarball"
+	CmdBundleFlagS3Region    = "AWS region of s3:// bundle tarballs (defaults to the region from the ambient AWS config)"
+	CmdBundleFlagS3Endpoint  = "URL of an S3-compatible object store to download s3:// bundle tarballs from (ie. https://minio.example.com)"
+	CmdBundleFlagNoVerifySSL = "Skip TLS verification when downloading s3:// bundle tarballs, for S3-compatible stores with self-signed certificates"
+
+	// bundle version check (deploy, inspect, pull)
+	CmdBundleFlagSkipVersionCheck = "Proceed even if the bundle's metadata.minUdsVersion is newer than this CLI"
 
 	// bundle inspect
-	CmdBundleInspectShort            = "Display the metadata of a bundle"
-	CmdBundleInspectFlagKey          = "Path to a public key file that will be used to validate a signed bundle"
-	CmdPackageInspectFlagSBOM        = "Create a tarball of SBOMs contained in the bundle"
-	CmdPackageInspectFlagExtractSBOM = "Create a folder of SBOMs contained in the bundle"
+	CmdBundleInspectShort                  = "Display the metadata of a bundle"
+	CmdBundleInspectFlagKey                = "Path, keyring directory, https:// URL or oci:// ref of a public key that will be used to validate a signed bundle, can be repeated to trust any of several keys"
+	CmdPackageInspectFlagSBOM              = "Create a tarball of SBOMs contained in the bundle"
+	CmdPackageInspectFlagExtractSBOM       = "Create a folder of SBOMs contained in the bundle"
+	CmdBundleInspectFlagAttachment         = "Name of a file attached to the bundle with --attach to extract into the current directory, can be repeated"
+	CmdBundleInspectFlagFromCluster        = "Read the deploy record(s) of bundles installed in the current cluster instead of a bundle tarball or OCI ref, the argument is an optional bundle name"
+	CmdBundleInspectFlagJSON               = "Output the bundle metadata as JSON (only with --from-cluster, --variables, --tree, --signed-digest or --diff-deployed)"
+	CmdBundleInspectFlagDiffDeployed       = "Compare the bundle's packages to the deploy record in the current cluster and report which a deploy would add, update or leave unchanged, without deploying"
+	CmdBundleInspectFlagTree               = "Show the bundle's packages and their components, images and charts as a tree with sizes"
+	CmdBundleInspectFlagDocs               = "Print the README and docs/ of the bundle's packages as plain text instead of the bundle's metadata"
+	CmdBundleInspectFlagPackage            = "Only print the docs of the named package, used with --docs"
+	CmdBundleInspectFlagExportZarfPackages = "Write the bundle's packages to this YAML file as digest-pinned Zarf OCI refs that can be deployed one at a time with zarf package deploy"
+	CmdBundleInspectFlagVariables          = "List the deploy variables each package in the bundle accepts, with their descriptions, defaults and whether they're required"
+	CmdBundleInspectFlagSignedDigest       = "Print the digest of the uds-bundle.yaml covered by the bundle's signature, verifying it only when --key is given"
 
 	// bundle remove
-	CmdBundleRemoveShort       = "Remove a bundle that has been deployed already"
-	CmdBundleRemoveFlagConfirm = "REQUIRED. Confirm the removal action to prevent accidental deletions"
+	CmdBundleRemoveShort               = "Remove a bundle that has been deployed already"
+	CmdBundleRemoveFlagConfirm         = "REQUIRED. Confirm the removal action to prevent accidental deletions"
+	CmdBundleRemoveFlagNamespacePrefix = "Remove the packages deployed with this --namespace-prefix (ie. tenant-a-)"
 
 	// bundle pull
-	CmdBundlePullShort      = "Pull a bundle from a remote registry and save to the local file system"
-	CmdBundlePullFlagOutput = "Specify the output directory for the pulled bundle"
-	CmdBundlePullFlagKey    = "Path to a public key file that will be used to validate a signed bundle"
+	CmdBundlePullShort             = "Pull a bundle from a remote registry and save to the local file system"
+	CmdBundlePullFlagOutput        = "Specify the output directory for the pulled bundle"
+	CmdBundlePullFlagKey           = "Path, keyring directory, https:// URL or oci:// ref of a public key that will be used to validate a signed bundle, can be repeated to trust any of several keys"
+	CmdBundlePullFlagSignatureOnly = "Only pull the bundle's uds-bundle.yaml and its signature into the output directory, skipping the Zarf packages"
+	CmdBundlePullFlagTarballName   = "File name of the pulled bundle tarball in the output directory instead of uds-bundle-<name>-<arch>-<version>.tar.zst"
+	CmdBundlePullFlagNoReferrers   = "Don't pull the referrers attached to the bundle in the registry (signatures, SBOMs, attestations)"
+	CmdBundlePullFlagMetadataOnly  = "Only pull each bundle's uds-bundle.yaml, signature and manifest annotations into a local index in the output directory, skipping the Zarf packages"
+	CmdBundlePullFlagFromFile      = "Path to a file of OCI refs to pull with --metadata-only, one per line"
+
+	// bundle publish
+	CmdBundlePublishFlagSignMethod            = "Method used to sign the published bundle: 'notation' produces a Notary v2 signature over the manifest (requires the notation CLI)"
+	CmdBundlePublishFlagArtifactType          = "Set the bundle manifest's artifactType (ie. application/vnd.uds.bundle.v1) so registries can tell it apart from images, it is dropped for registries that reject it"
+	CmdBundlePublishFlagLegacyConfigMediaType = "Give the bundle manifest's config the media type used by older versions of uds instead of application/vnd.uds.bundle.config.v1+json, for registries and tools that expect it"
+	CmdBundlePublishFlagRegistryStyle         = "Path rules of the destination registry: auto (detect from the host), generic, harbor, ecr, ghcr, dockerhub or artifact-registry"
+	CmdBundlePublishFlagNoReferrers           = "Don't push the referrers a pulled bundle tarball carries (signatures, SBOMs, attestations)"
+	CmdBundlePublishFlagCheckOnly             = "Only check that this identity can push to the destination and exit without publishing, the bundle tarball may be left out to check the OCI_REF repository itself"
+	CmdBundlePublishFlagNotationKey           = "Name of the notation signing key to use with --sign-method notation (defaults to notation's default key)"
+
+	// uds-cli completion
+	CmdCompletionShort = "Generate the autocompletion script for the specified shell"
+	CmdCompletionLong  = "Generate the autocompletion script for uds for bash, zsh, fish or powershell (ie. source <(uds completion bash))"
+
+	// uds-cli validate
+	CmdValidateShort = "Validate a uds-bundle.yaml against the UDSBundle JSON schema"
+
+	// uds-cli migrate
+	CmdMigrateShort       = "Upgrade a uds-bundle.yaml written for an older spec version to the current one"
+	CmdMigrateFlagInPlace = "Overwrite the uds-bundle.yaml with the migrated bundle instead of printing it"
+	CmdMigrateFlagOutput  = "Write the migrated bundle to this file instead of printing it"
+
+	// uds-cli verify
+	CmdVerifyShort       = "Check a bundle's signature, architecture and (optionally) layer digests"
+	CmdVerifyFlagKey     = "Path, keyring directory, https:// URL or oci:// ref of a public key the bundle's signature is checked against, can be repeated to trust any of several keys"
+	CmdVerifyFlagDigests = "Also read every layer of the bundle and check it against its digest"
+	CmdVerifyFlagOutput  = "Output format of the verification result, text or json"
+
+	// uds-cli catalog
+	CmdCatalogShort          = "Publish and browse catalogs listing bundles published to OCI registries"
+	CmdCatalogPushShort      = "Push a catalog listing the --bundle refs to an OCI registry, replacing the catalog already there"
+	CmdCatalogPushFlagBundle = "OCI ref of a published bundle to list in the catalog (can be repeated)"
+	CmdCatalogListShort      = "List the bundles in a catalog"
+	CmdCatalogListFlagJSON   = "Print the catalog's bundles as JSON"
+
+	// uds-cli prune
+	CmdPruneShort         = "Delete old bundles from an OCI repository, keeping the most recent ones"
+	CmdPruneFlagKeepLast  = "Keep the N most recently created bundles in the repository"
+	CmdPruneFlagOlderThan = "Only delete bundles created longer ago than this (ie. 30d, 12h)"
+	CmdPruneFlagDryRun    = "List the bundles that would be deleted without deleting them"
+	CmdPruneFlagConfirm   = "REQUIRED unless --dry-run. Confirm the deletion of the selected bundles"
+
+	// uds-cli append
+	CmdAppendShort          = "Add a Zarf package from an OCI registry to a bundle tarball without rebuilding the bundle"
+	CmdAppendFlagPackage    = "REQUIRED. OCI ref of the Zarf package to add (ie. oci://ghcr.io/my-org/podinfo:0.0.1)"
+	CmdAppendFlagName       = "Name of the package in the bundle (defaults to the Zarf package's metadata.name)"
+	CmdAppendFlagOutput     = "REQUIRED. Path of the new bundle tarball, which must end in .tar.zst"
+	CmdAppendFlagSigningKey = "Path to a private key file to sign the new bundle with, required when the bundle was signed"
+
+	// uds-cli wrap
+	CmdWrapShort           = "Create a single-package bundle from a local Zarf package tarball"
+	CmdWrapFlagName        = "Name of the bundle (defaults to the Zarf package's metadata.name)"
+	CmdWrapFlagVersion     = "Version of the bundle (defaults to the Zarf package's metadata.version)"
+	CmdWrapFlagDescription = "Description of the bundle (defaults to the Zarf package's metadata.description)"
+	CmdWrapFlagConfirm     = "Confirm bundle creation without prompting"
+
+	// uds-cli tools extract
+	CmdToolsExtractShort    = "Extract a single file from a bundle tarball"
+	CmdToolsExtractFlagPath = "Path of the file inside the bundle to extract (ie. blobs/sha256/<digest> or uds-bundle.yaml)"
+	CmdToolsExtractFlagOut  = "Specify the output file for the extracted file (defaults to the file's name in the current directory)"
+
+	// uds-cli tools verify-layout
+	CmdToolsVerifyLayoutShort      = "Check that a bundle's index.json lists only the bundle's root manifest"
+	CmdToolsVerifyLayoutFlagRepair = "Remove the other entries from the index.json of an unpacked bundle (OCI layout directory)"
 
 	// cmd viper setup
 	CmdViperErrLoadingConfigFile = "failed to load config file: %s"
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+)
+
+// reservedAnnotationPrefix is the namespace of the OCI pre-defined annotations, the ones the bundle's metadata maps to
+const reservedAnnotationPrefix = "org.opencontainers."
+
+// loadAnnotationsFile reads a YAML map of manifest annotation keys to values from path
+func loadAnnotationsFile(path string) (map[string]string, error) {
+	annotations := map[string]string{}
+	if err := utils.ReadYaml(path, &annotations); err != nil {
+		return nil, fmt.Errorf("unable to read annotations from %s: %w", path, err)
+	}
+	return annotations, nil
+}
+
+// validateAnnotations ensures annotation keys are set and only replace the reserved OCI annotations with allowOverride
+func validateAnnotations(annotations map[string]string, allowOverride bool) error {
+	for key := range annotations {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid annotation key %q, it must not be empty", key)
+		}
+		if strings.HasPrefix(key, reservedAnnotationPrefix) && !allowOverride {
+			return fmt.Errorf("annotation %s is reserved for the bundle's metadata, use --allow-override to replace it", key)
+		}
+	}
+	return nil
+}
+
+// mergeAnnotations returns the annotations of base with those of extra added over them
+func mergeAnnotations(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func Test_validateAnnotations(t *testing.T) {
+	tests := []struct {
+		name          string
+		description   string
+		annotations   map[string]string
+		allowOverride bool
+		wantErr       bool
+	}{
+		{
+			name:        "Custom",
+			description: "annotations outside the OCI namespace are added as is",
+			annotations: map[string]string{"com.example.team": "platform", "com.example.ticket": "OPS-1"},
+		},
+		{
+			name:        "Reserved",
+			description: "OCI annotations are set from the bundle's metadata",
+			annotations: map[string]string{ocispec.AnnotationVendor: "Example"},
+			wantErr:     true,
+		},
+		{
+			name:          "ReservedWithOverride",
+			description:   "--allow-override replaces the OCI annotations",
+			annotations:   map[string]string{ocispec.AnnotationVendor: "Example"},
+			allowOverride: true,
+		},
+		{
+			name:          "EmptyKey",
+			description:   "annotation keys can't be empty, even with --allow-override",
+			annotations:   map[string]string{" ": "value"},
+			allowOverride: true,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAnnotations(tt.annotations, tt.allowOverride); (err != nil) != tt.wantErr {
+				t.Errorf("validateAnnotations() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_mergeAnnotations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotations.yaml")
+	if err := os.WriteFile(path, []byte("com.example.team: platform\norg.opencontainers.image.vendor: Example\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	extra, err := loadAnnotationsFile(path)
+	if err != nil {
+		t.Fatalf("loadAnnotationsFile() error = %v", err)
+	}
+
+	base := manifestAnnotationsFromMetadata(&types.UDSMetadata{Description: "test", Vendor: "UDS"}, &types.UDSBuildData{})
+	got := mergeAnnotations(base, extra)
+	if got["com.example.team"] != "platform" || got[ocispec.AnnotationVendor] != "Example" || got[ocispec.AnnotationDescription] != "test" {
+		t.Errorf("mergeAnnotations() = %v, want the metadata annotations with the file's added over them", got)
+	}
+	if base[ocispec.AnnotationVendor] != "UDS" {
+		t.Errorf("mergeAnnotations() modified its base annotations: %v", base)
+	}
+}
@@ -0,0 +1,321 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/bundler"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/interactive"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	goyaml "github.com/goccy/go-yaml"
+	av3 "github.com/mholt/archiver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	ocistore "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry"
+)
+
+// Append adds a Zarf package from an OCI registry to a bundle tarball, writing the result to a new tarball
+//
+// : unarchive the bundle's OCI layout
+// : fetch the package's manifest and layers into the layout like create does
+// : add the package to uds-bundle.yaml and the root manifest, re-signing it if the bundle was signed
+// : rebuild index.json around the new root manifest and archive the layout
+func (b *Bundler) Append() error {
+	opts := b.cfg.AppendOpts
+	if !strings.HasSuffix(opts.Output, ".tar.zst") {
+		return fmt.Errorf("--output %s must be a bundle tarball ending in .tar.zst", opts.Output)
+	}
+	source, err := b.decryptSource(opts.Source, false, "")
+	if err != nil {
+		return err
+	}
+	if source, err = b.joinSource(source); err != nil {
+		return err
+	}
+
+	// unarchive the bundle's OCI layout
+	dir := filepath.Join(b.tmp, "layout")
+	if err := av3.Unarchive(source, dir); err != nil {
+		return fmt.Errorf("unable to unarchive %s: %w", opts.Source, err)
+	}
+	layout, err := checkLayoutIndex(func(path string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, path))
+	})
+	if err != nil {
+		return err
+	}
+	ctx := utils.NetworkContext()
+	store, err := ocistore.NewWithContext(ctx, dir)
+	if err != nil {
+		return err
+	}
+	var root oci.ZarfOCIManifest
+	if err := fetchJSON(ctx, store, layout.root, &root); err != nil {
+		return err
+	}
+	bundleYAML, err := content.FetchAll(ctx, store, root.Locate(config.BundleYAML))
+	if err != nil {
+		return err
+	}
+	if err := goyaml.Unmarshal(bundleYAML, &b.bundle); err != nil {
+		return err
+	}
+	signed := !oci.IsEmptyDescriptor(root.Locate(config.BundleYAMLSignature))
+	if signed && opts.SigningKeyPath == "" {
+		return fmt.Errorf("%s is signed and its signature won't cover the new %s, use --signing-key to sign the new bundle", opts.Source, config.BundleYAML)
+	}
+
+	// fetch the package into the layout
+	pkg, url, err := appendedPackage(opts.Package, b.bundle.Metadata.Architecture)
+	if err != nil {
+		return err
+	}
+	zarfPkg, err := fetchPackageMetadata(url)
+	if err != nil {
+		return err
+	}
+	pkg.Name = firstNonEmpty(opts.Name, zarfPkg.Metadata.Name)
+	if err := checkAppendedPackage(&b.bundle, pkg, zarfPkg); err != nil {
+		return err
+	}
+	pkgManifestDesc, err := b.fetchAppendedPackage(store, &pkg, url)
+	if err != nil {
+		return err
+	}
+	b.bundle.ZarfPackages = append(b.bundle.ZarfPackages, pkg)
+
+	// add the package to uds-bundle.yaml and the root manifest
+	var signature []byte
+	var sigAlgo string
+	if opts.SigningKeyPath != "" {
+		if signature, sigAlgo, err = b.signAppendedBundle(); err != nil {
+			return err
+		}
+	}
+	rootDesc, err := appendToRootManifest(ctx, store, layout.root, &b.bundle, pkgManifestDesc, signature, sigAlgo)
+	if err != nil {
+		return err
+	}
+
+	// pushing the new root manifest added it to index.json next to the old one, only the new one is kept
+	rootDesc.Platform = layout.root.Platform
+	if err := rebuildBundleIndex(dir, rootDesc); err != nil {
+		return err
+	}
+
+	// archive only what the new root manifest references, the old root manifest and uds-bundle.yaml are left out
+	paths, err := layoutPaths(ctx, store, dir, rootDesc)
+	if err != nil {
+		return err
+	}
+	tarballPath, err := writeTarball(&b.bundle, paths, b.tmp, archiveLimits{})
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tarballPath, opts.Output); err != nil {
+		// tmp may be on a different filesystem
+		if err := zarfUtils.CreatePathAndCopy(tarballPath, opts.Output); err != nil {
+			return err
+		}
+	}
+	message.Successf("Appended package %s to %s as %s", pkg.Name, opts.Source, opts.Output)
+	return nil
+}
+
+// appendedPackage returns the bundle package for the Zarf package at ref and the URL to fetch it from, tags without
+// the bundle's architecture get it appended like create does for a package's ref
+func appendedPackage(ref, arch string) (types.BundleZarfPackage, string, error) {
+	parsed, err := registry.ParseReference(strings.TrimPrefix(ref, helpers.OCIURLPrefix))
+	if err != nil {
+		return types.BundleZarfPackage{}, "", fmt.Errorf("invalid --package %s: %w", ref, err)
+	}
+	if parsed.ValidateReferenceAsTag() != nil {
+		return types.BundleZarfPackage{}, "", fmt.Errorf("--package %s must be tagged (ie. oci://ghcr.io/my-org/podinfo:0.0.1)", ref)
+	}
+	tag := parsed.Reference
+	if !strings.HasSuffix(tag, "-"+arch) {
+		tag += "-" + arch
+	}
+	repository := parsed.Registry + "/" + parsed.Repository
+	return types.BundleZarfPackage{Repository: repository, Ref: tag}, repository + ":" + tag, nil
+}
+
+// fetchPackageMetadata reads the zarf.yaml of the remote Zarf package at url
+func fetchPackageMetadata(url string) (zarfTypes.ZarfPackage, error) {
+	pkgTmp, err := zarfUtils.MakeTempDir()
+	if err != nil {
+		return zarfTypes.ZarfPackage{}, err
+	}
+	defer os.RemoveAll(pkgTmp)
+	remote, err := utils.NewOrasRemote(url)
+	if err != nil {
+		return zarfTypes.ZarfPackage{}, err
+	}
+	if _, err := remote.PullPackageMetadata(pkgTmp); err != nil {
+		return zarfTypes.ZarfPackage{}, fmt.Errorf("unable to read the metadata of %s: %w", url, err)
+	}
+	var zarfPkg zarfTypes.ZarfPackage
+	err = zarfUtils.ReadYaml(filepath.Join(pkgTmp, config.ZarfYAML), &zarfPkg)
+	return zarfPkg, err
+}
+
+// checkAppendedPackage ensures pkg can be added to bundle, its name must be new and it must be built for the
+// bundle's architecture
+func checkAppendedPackage(bundle *types.UDSBundle, pkg types.BundleZarfPackage, zarfPkg zarfTypes.ZarfPackage) error {
+	if pkg.Name == "" {
+		return fmt.Errorf("%s has no metadata.name, use --name to name it in the bundle", pkg.Repository)
+	}
+	for _, existing := range bundle.ZarfPackages {
+		if existing.Name == pkg.Name {
+			return fmt.Errorf("bundle %s already has a package named %s, use --name to add it under another name", bundle.Metadata.Name, pkg.Name)
+		}
+	}
+	if arch := zarfPkg.Build.Architecture; arch != "" && arch != bundle.Metadata.Architecture {
+		return fmt.Errorf("zarf pkg %s was built for %s but bundle %s is %s", pkg.Name, arch, bundle.Metadata.Name, bundle.Metadata.Architecture)
+	}
+	return nil
+}
+
+// fetchAppendedPackage fetches the package's manifest and layers into store like create does, pinning its ref to the
+// manifest's digest
+func (b *Bundler) fetchAppendedPackage(store *ocistore.Store, pkg *types.BundleZarfPackage, url string) (ocispec.Descriptor, error) {
+	spinner := message.NewProgressSpinner("Fetching package %s", pkg.Name)
+	defer spinner.Stop()
+
+	remoteBundler, err := bundler.NewRemoteBundler(*pkg, url, store, nil)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	pkgManifestDesc, err := remoteBundler.PushManifest()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	pkg.Ref = repinRef(pkg.Ref, pkgManifestDesc.Digest)
+	annotatePackageTag(&pkgManifestDesc, pkg.Ref)
+	count := len(b.bundle.ZarfPackages) + 1
+	if _, err := remoteBundler.PushLayers(spinner, count, count); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	spinner.Successf("Fetched package: %s", pkg.Name)
+	return pkgManifestDesc, nil
+}
+
+// signAppendedBundle signs the bundle's new uds-bundle.yaml with --signing-key
+func (b *Bundler) signAppendedBundle() ([]byte, string, error) {
+	opts := b.cfg.AppendOpts
+	bundlePath := filepath.Join(b.tmp, config.BundleYAML)
+	bundleYAML, err := goyaml.Marshal(&b.bundle)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.WriteFile(bundlePath, bundleYAML, 0600); err != nil {
+		return nil, "", err
+	}
+
+	var passwords [][]byte
+	defer func() {
+		for _, password := range passwords {
+			utils.ZeroBytes(password)
+		}
+	}()
+	getSigPassword := func(_ bool) ([]byte, error) {
+		password, err := utils.KeyPassword(opts.SigningKeyPassword, opts.SigningKeyPasswordFile, interactive.PromptSigPassword)
+		passwords = append(passwords, password)
+		return password, err
+	}
+	return utils.SignBlob(bundlePath, filepath.Join(b.tmp, config.BundleYAMLSignature), opts.SigningKeyPath, "", getSigPassword)
+}
+
+// appendToRootManifest pushes a new root manifest for bundle to store, adding pkgManifestDesc after the root's other
+// package manifests and replacing its uds-bundle.yaml and signature
+func appendToRootManifest(ctx context.Context, store *ocistore.Store, rootDesc ocispec.Descriptor, bundle *types.UDSBundle, pkgManifestDesc ocispec.Descriptor, signature []byte, sigAlgo string) (ocispec.Descriptor, error) {
+	var root ocispec.Manifest
+	if err := fetchJSON(ctx, store, rootDesc, &root); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	bundleYAMLDesc, err := pushBundleManifestToStore(ctx, store, bundle)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	layers := []ocispec.Descriptor{}
+	added := false
+	for _, layer := range root.Layers {
+		switch layer.Annotations[ocispec.AnnotationTitle] {
+		case config.BundleYAMLSignature:
+			continue
+		case config.BundleYAML:
+			layers = append(layers, pkgManifestDesc, bundleYAMLDesc)
+			added = true
+			continue
+		}
+		layers = append(layers, layer)
+	}
+	if !added {
+		return ocispec.Descriptor{}, fmt.Errorf("the root manifest %s has no %s layer", rootDesc.Digest, config.BundleYAML)
+	}
+	if len(signature) > 0 {
+		signatureDesc, err := pushBundleSignature(ctx, store, signature, sigAlgo)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		layers = append(layers, signatureDesc)
+	}
+	root.Layers = layers
+
+	rootBytes, err := json.Marshal(root)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return pushBlob(ctx, store, ocispec.MediaTypeImageManifest, rootBytes)
+}
+
+// layoutPaths maps the blobs the root manifest references in the OCI layout at dir, including the layers of its
+// package manifests, to their paths in a bundle tarball
+//
+// package manifests also list the layers of optional components that weren't bundled, blobs missing from dir are skipped
+func layoutPaths(ctx context.Context, store content.Fetcher, dir string, rootDesc ocispec.Descriptor) (PathMap, error) {
+	var root ocispec.Manifest
+	if err := fetchJSON(ctx, store, rootDesc, &root); err != nil {
+		return nil, err
+	}
+	paths := make(PathMap)
+	paths.addBlob(dir, rootDesc)
+	paths.addBlob(dir, root.Config)
+	for _, layer := range root.Layers {
+		paths.addBlob(dir, layer)
+		// package manifests are the root's only untitled layers
+		if _, ok := layer.Annotations[ocispec.AnnotationTitle]; ok {
+			continue
+		}
+		var manifest ocispec.Manifest
+		if err := fetchJSON(ctx, store, layer, &manifest); err != nil {
+			return nil, err
+		}
+		for _, pkgLayer := range append([]ocispec.Descriptor{manifest.Config}, manifest.Layers...) {
+			if zarfUtils.InvalidPath(filepath.Join(dir, utils.BlobPath(pkgLayer.Digest))) {
+				continue
+			}
+			paths.addBlob(dir, pkgLayer)
+		}
+	}
+	paths[filepath.Join(dir, "index.json")] = "index.json"
+	paths[filepath.Join(dir, "oci-layout")] = "oci-layout"
+	return paths, nil
+}
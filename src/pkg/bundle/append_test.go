@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	ocistore "oras.land/oras-go/v2/content/oci"
+)
+
+func Test_appendedPackage(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		ref         string
+		wantURL     string
+		wantErr     bool
+	}{
+		{
+			name:        "Tag",
+			description: "the bundle's architecture is appended to the tag like create does",
+			ref:         "oci://ghcr.io/my-org/podinfo:0.0.1",
+			wantURL:     "ghcr.io/my-org/podinfo:0.0.1-amd64",
+		},
+		{
+			name:        "ArchTag",
+			description: "tags that already name the architecture are used as is",
+			ref:         "oci://localhost:5000/podinfo:0.0.1-amd64",
+			wantURL:     "localhost:5000/podinfo:0.0.1-amd64",
+		},
+		{
+			name:        "Digest",
+			description: "packages must be tagged to have a ref in uds-bundle.yaml",
+			ref:         "oci://ghcr.io/my-org/podinfo@sha256:3d8b5ce0bc1e8a8f4af3b7e5f1f2f4b1a1ab5ee0e1e4ab2bad5d2e4ed9d0cc7c",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, url, err := appendedPackage(tt.ref, "amd64")
+			if (err != nil) != tt.wantErr || url != tt.wantURL {
+				t.Errorf("appendedPackage() = %q, %v, want %q, wantErr %v (%s)", url, err, tt.wantURL, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_checkAppendedPackage(t *testing.T) {
+	bundle := &types.UDSBundle{
+		Metadata:     types.UDSMetadata{Name: "app", Architecture: "amd64"},
+		ZarfPackages: []types.BundleZarfPackage{{Name: "nginx"}},
+	}
+	tests := []struct {
+		name        string
+		description string
+		pkg         string
+		arch        string
+		wantErr     bool
+	}{
+		{
+			name:        "New",
+			description: "a new package built for the bundle's architecture can be added",
+			pkg:         "podinfo",
+			arch:        "amd64",
+		},
+		{
+			name:        "Duplicate",
+			description: "package names must be unique in a bundle",
+			pkg:         "nginx",
+			arch:        "amd64",
+			wantErr:     true,
+		},
+		{
+			name:        "Architecture",
+			description: "packages must be built for the bundle's architecture",
+			pkg:         "podinfo",
+			arch:        "arm64",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zarfPkg := zarfTypes.ZarfPackage{Build: zarfTypes.ZarfBuildData{Architecture: tt.arch}}
+			if err := checkAppendedPackage(bundle, types.BundleZarfPackage{Name: tt.pkg}, zarfPkg); (err != nil) != tt.wantErr {
+				t.Errorf("checkAppendedPackage() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_appendToRootManifest(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+
+	// a signed bundle with one package, the appended package's optional layer wasn't bundled
+	oldPkg := writeLayoutManifest(t, dir, "zarf.yaml", "kind: ZarfPackageConfig")
+	oldPkg.Annotations = nil
+	newLayer := writeLayoutBlob(t, dir, ocispec.MediaTypeImageLayer, []byte("podinfo"))
+	newLayer.Annotations = map[string]string{ocispec.AnnotationTitle: "images/blobs/sha256/podinfo"}
+	unbundled := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer, Digest: "sha256:3d8b5ce0bc1e8a8f4af3b7e5f1f2f4b1a1ab5ee0e1e4ab2bad5d2e4ed9d0cc7c", Size: 1}
+	newPkgBytes, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    writeLayoutBlob(t, dir, ocispec.MediaTypeImageConfig, []byte(`{"podinfo":true}`)),
+		Layers:    []ocispec.Descriptor{newLayer, unbundled},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPkg := writeLayoutBlob(t, dir, oci.ZarfLayerMediaTypeBlob, newPkgBytes)
+
+	bundleYAML := writeLayoutBlob(t, dir, oci.ZarfLayerMediaTypeBlob, []byte("kind: UDSBundle"))
+	bundleYAML.Annotations = map[string]string{ocispec.AnnotationTitle: config.BundleYAML}
+	signature := writeLayoutBlob(t, dir, oci.ZarfLayerMediaTypeBlob, []byte("old signature"))
+	signature.Annotations = signatureAnnotations(config.SigAlgoECDSAP256)
+	rootBytes, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    writeLayoutBlob(t, dir, ocispec.MediaTypeImageConfig, []byte("{}")),
+		Layers:    []ocispec.Descriptor{oldPkg, bundleYAML, signature},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootDesc := writeLayoutBlob(t, dir, ocispec.MediaTypeImageManifest, rootBytes)
+	writeLayoutIndex(t, dir, rootDesc)
+
+	store, err := ocistore.NewWithContext(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := &types.UDSBundle{Kind: "UDSBundle", ZarfPackages: []types.BundleZarfPackage{{Name: "nginx"}, {Name: "podinfo"}}}
+	newRootDesc, err := appendToRootManifest(ctx, store, rootDesc, bundle, newPkg, []byte("new signature"), config.SigAlgoECDSAP256)
+	if err != nil {
+		t.Fatalf("appendToRootManifest() error = %v", err)
+	}
+
+	var newRoot oci.ZarfOCIManifest
+	if err := fetchJSON(ctx, store, newRootDesc, &newRoot); err != nil {
+		t.Fatal(err)
+	}
+	if len(newRoot.Layers) != 4 || newRoot.Layers[0].Digest != oldPkg.Digest || newRoot.Layers[1].Digest != newPkg.Digest {
+		t.Fatalf("appendToRootManifest() layers = %v, want the old package, the new package, uds-bundle.yaml and the new signature", newRoot.Layers)
+	}
+	if newRoot.Locate(config.BundleYAML).Digest == bundleYAML.Digest {
+		t.Errorf("appendToRootManifest() kept the old %s", config.BundleYAML)
+	}
+	if sig := newRoot.Locate(config.BundleYAMLSignature); sig.Digest == signature.Digest || sig.Annotations[config.SigAlgoAnnotation] != config.SigAlgoECDSAP256 {
+		t.Errorf("appendToRootManifest() signature = %v, want the new signature with its algorithm", sig)
+	}
+
+	paths, err := layoutPaths(ctx, store, dir, newRootDesc)
+	if err != nil {
+		t.Fatalf("layoutPaths() error = %v", err)
+	}
+	for _, desc := range []ocispec.Descriptor{newRootDesc, newPkg, newLayer, newRoot.Locate(config.BundleYAML)} {
+		if _, ok := paths[filepath.Join(dir, utils.BlobPath(desc.Digest))]; !ok {
+			t.Errorf("layoutPaths() is missing %s", desc.Digest)
+		}
+	}
+	for _, desc := range []ocispec.Descriptor{rootDesc, bundleYAML, signature, unbundled} {
+		if _, ok := paths[filepath.Join(dir, utils.BlobPath(desc.Digest))]; ok {
+			t.Errorf("layoutPaths() has %s, which the new root manifest doesn't reference or wasn't bundled", desc.Digest)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/docker/go-units"
+	"github.com/klauspost/compress/zstd"
+)
+
+// defaultArchiveWindow is the zstd window archiving uses at its default level, --archive-max-in-flight only shrinks it
+const defaultArchiveWindow = 8 << 20
+
+// archiveLimits bounds the resources archiving a bundle tarball uses, the zero value keeps archiver's defaults of one
+// encoder per CPU with the default window
+type archiveLimits struct {
+	// Workers is how many zstd encoders compress the tarball at once
+	Workers int
+	// MaxInFlight is the most bytes the encoders buffer at once
+	MaxInFlight int64
+}
+
+// archiveLimitsFromOpts reads the archive limits of a create, refusing worker counts and sizes archiving can't honor
+func archiveLimitsFromOpts(opts *types.BundlerCreateOptions) (archiveLimits, error) {
+	limits := archiveLimits{Workers: opts.ArchiveWorkers}
+	if limits.Workers < 0 {
+		return archiveLimits{}, fmt.Errorf("--archive-workers must be at least 1, got %d", limits.Workers)
+	}
+	if opts.ArchiveMaxInFlight == "" {
+		return limits, nil
+	}
+	maxInFlight, err := units.RAMInBytes(opts.ArchiveMaxInFlight)
+	if err != nil {
+		return archiveLimits{}, fmt.Errorf("invalid --archive-max-in-flight %q: %w", opts.ArchiveMaxInFlight, err)
+	}
+	limits.MaxInFlight = maxInFlight
+	if limits.window() < zstd.MinWindowSize {
+		return archiveLimits{}, fmt.Errorf("--archive-max-in-flight %q is too small for %d archive workers, each needs at least %s",
+			opts.ArchiveMaxInFlight, limits.workers(), units.BytesSize(2*zstd.MinWindowSize))
+	}
+	return limits, nil
+}
+
+// workers returns how many encoders compress the tarball at once
+func (l archiveLimits) workers() int {
+	if l.Workers > 0 {
+		return l.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// window returns the zstd window of each encoder, the largest power of 2 that keeps every encoder's history and the
+// block it's compressing within MaxInFlight
+func (l archiveLimits) window() int {
+	if l.MaxInFlight == 0 {
+		return defaultArchiveWindow
+	}
+	perWorker := l.MaxInFlight / int64(l.workers()) / 2
+	window := 1
+	for int64(window)*2 <= perWorker && window < defaultArchiveWindow {
+		window *= 2
+	}
+	if int64(window) > perWorker {
+		return 0
+	}
+	return window
+}
+
+// queueSize returns how many files are queued for the archiver at once, enough to keep every encoder busy
+func (l archiveLimits) queueSize() int {
+	return 2 * l.workers()
+}
+
+// encoderOptions returns the zstd options that apply the limits, none for the zero value
+func (l archiveLimits) encoderOptions() []zstd.EOption {
+	opts := []zstd.EOption{}
+	if l.Workers > 0 {
+		opts = append(opts, zstd.WithEncoderConcurrency(l.Workers))
+	}
+	if l.MaxInFlight > 0 {
+		opts = append(opts, zstd.WithWindowSize(l.window()), zstd.WithLowerEncoderMem(true))
+	}
+	return opts
+}
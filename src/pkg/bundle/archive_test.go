@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/klauspost/compress/zstd"
+)
+
+func Test_archiveLimitsFromOpts(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		opts        types.BundlerCreateOptions
+		wantWindow  int
+		wantErr     bool
+	}{
+		{
+			name:        "Defaults",
+			description: "no limits keep the default window",
+			opts:        types.BundlerCreateOptions{},
+			wantWindow:  defaultArchiveWindow,
+		},
+		{
+			name:        "LargeLimit",
+			description: "a limit above what the encoders need doesn't grow the window",
+			opts:        types.BundlerCreateOptions{ArchiveWorkers: 2, ArchiveMaxInFlight: "1GiB"},
+			wantWindow:  defaultArchiveWindow,
+		},
+		{
+			name:        "SharedLimit",
+			description: "the limit is split across the workers, each holding a window and a block",
+			opts:        types.BundlerCreateOptions{ArchiveWorkers: 4, ArchiveMaxInFlight: "16MiB"},
+			wantWindow:  2 << 20,
+		},
+		{
+			name:        "RoundsDown",
+			description: "windows are powers of 2 within the limit",
+			opts:        types.BundlerCreateOptions{ArchiveWorkers: 3, ArchiveMaxInFlight: "16MiB"},
+			wantWindow:  2 << 20,
+		},
+		{
+			name:        "TooSmall",
+			description: "a limit below the smallest zstd window is refused",
+			opts:        types.BundlerCreateOptions{ArchiveWorkers: 4, ArchiveMaxInFlight: "4KiB"},
+			wantErr:     true,
+		},
+		{
+			name:        "InvalidSize",
+			description: "a limit that isn't a size is refused",
+			opts:        types.BundlerCreateOptions{ArchiveMaxInFlight: "lots"},
+			wantErr:     true,
+		},
+		{
+			name:        "NegativeWorkers",
+			description: "a negative worker count is refused",
+			opts:        types.BundlerCreateOptions{ArchiveWorkers: -1},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits, err := archiveLimitsFromOpts(&tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("archiveLimitsFromOpts() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if err != nil {
+				return
+			}
+			if got := limits.window(); got != tt.wantWindow {
+				t.Errorf("archiveLimits.window() = %d, want %d (%s)", got, tt.wantWindow, tt.description)
+			}
+			// the options have to be accepted by the encoder
+			if _, err := zstd.NewWriter(io.Discard, limits.encoderOptions()...); err != nil {
+				t.Errorf("archiveLimits.encoderOptions() aren't valid: %v (%s)", err, tt.description)
+			}
+		})
+	}
+}
+
+func Test_archiveBundleFailure(t *testing.T) {
+	dir := t.TempDir()
+	paths := make(PathMap)
+	// random files don't compress, so the encoder writes them out rather than buffering them
+	data := make([]byte, 256<<10)
+	for i := 0; i < 20; i++ {
+		if _, err := rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("file-%d", i))
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths[path] = filepath.Base(path)
+	}
+
+	// a failed write stops the archive instead of hanging on the files queued after it
+	if err := archiveBundle(failingWriter{}, paths, "failing", archiveLimits{Workers: 1}); err == nil {
+		t.Errorf("archiveBundle() didn't fail for a failing writer")
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, fmt.Errorf("write failed")
+}
+
+// benchmarkPaths writes count random files of size bytes for a benchmark
+func benchmarkPaths(b *testing.B, count int, size int) PathMap {
+	dir := b.TempDir()
+	paths := make(PathMap)
+	data := make([]byte, size)
+	for i := 0; i < count; i++ {
+		if _, err := rand.Read(data); err != nil {
+			b.Fatal(err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("blob-%d", i))
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			b.Fatal(err)
+		}
+		paths[path] = filepath.Base(path)
+	}
+	return paths
+}
+
+func Benchmark_archiveBundle(b *testing.B) {
+	layouts := []struct {
+		name  string
+		count int
+		size  int
+	}{
+		{name: "FewLargeFiles", count: 4, size: 16 << 20},
+		{name: "ManySmallFiles", count: 2000, size: 4 << 10},
+	}
+	limits := []struct {
+		name   string
+		limits archiveLimits
+	}{
+		{name: "Default", limits: archiveLimits{}},
+		{name: "Tuned", limits: archiveLimits{Workers: 2, MaxInFlight: 16 << 20}},
+	}
+	for _, layout := range layouts {
+		paths := benchmarkPaths(b, layout.count, layout.size)
+		for _, l := range limits {
+			b.Run(layout.name+"/"+l.name, func(b *testing.B) {
+				b.SetBytes(int64(layout.count * layout.size))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := archiveBundle(io.Discard, paths, "benchmark", l.limits); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
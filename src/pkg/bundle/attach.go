@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	ocistore "oras.land/oras-go/v2/content/oci"
+)
+
+// validateAttachments ensures attachment names are plain file names that don't shadow the bundle's own layers
+func validateAttachments(attachments map[string]string) error {
+	for name, path := range attachments {
+		if name == "" || name != filepath.Base(name) {
+			return fmt.Errorf("invalid attachment name %q, must be a file name without a directory", name)
+		}
+		if helpers.SliceContains(config.BundleAlwaysPull, name) {
+			return fmt.Errorf("invalid attachment name %q, it is reserved for the bundle's metadata", name)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("unable to attach %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// sortedAttachmentNames returns attachment names in a stable order so created bundles are reproducible
+func sortedAttachmentNames(attachments map[string]string) []string {
+	names := make([]string, 0, len(attachments))
+	for name := range attachments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// attachmentDescriptor reads an attached file and returns its layer descriptor and contents
+func attachmentDescriptor(name, path string) (ocispec.Descriptor, []byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	desc := content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, b)
+	desc.Annotations = map[string]string{
+		ocispec.AnnotationTitle: name,
+	}
+	return desc, b, nil
+}
+
+// pushAttachmentsToStore pushes attached files as layers into the bundle's OCI store
+func pushAttachmentsToStore(ctx context.Context, store *ocistore.Store, attachments map[string]string) ([]ocispec.Descriptor, error) {
+	descs := []ocispec.Descriptor{}
+	for _, name := range sortedAttachmentNames(attachments) {
+		desc, b, err := attachmentDescriptor(name, attachments[name])
+		if err != nil {
+			return nil, err
+		}
+		if exists, err := store.Exists(ctx, desc); err != nil {
+			return nil, err
+		} else if !exists {
+			if err := store.Push(ctx, desc, bytes.NewReader(b)); err != nil {
+				return nil, err
+			}
+		}
+		message.Debug("Pushed attachment", name+":", message.JSONValue(desc))
+		descs = append(descs, desc)
+	}
+	return descs, nil
+}
+
+// pushAttachmentsToRemote pushes attached files as layers into the bundle's remote repository
+func pushAttachmentsToRemote(remote *oci.OrasRemote, attachments map[string]string) ([]ocispec.Descriptor, error) {
+	descs := []ocispec.Descriptor{}
+	for _, name := range sortedAttachmentNames(attachments) {
+		b, err := os.ReadFile(attachments[name])
+		if err != nil {
+			return nil, err
+		}
+		desc, err := utils.PushLayer(remote, b, oci.ZarfLayerMediaTypeBlob)
+		if err != nil {
+			return nil, err
+		}
+		desc.Annotations = map[string]string{
+			ocispec.AnnotationTitle: name,
+		}
+		message.Debug("Pushed attachment", name+":", message.JSONValue(desc))
+		descs = append(descs, desc)
+	}
+	return descs, nil
+}
+
+// attachmentNames returns the names of files attached to the bundle with --attach
+func attachmentNames(manifest *oci.ZarfOCIManifest) []string {
+	names := []string{}
+	for _, layer := range manifest.Layers {
+		name := layer.Annotations[ocispec.AnnotationTitle]
+		if layer.MediaType != oci.ZarfLayerMediaTypeBlob || name == "" || helpers.SliceContains(config.BundleAlwaysPull, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// ListAttachments returns the names of the files attached to a remote bundle
+func (op *ociProvider) ListAttachments() ([]string, error) {
+	if err := op.getBundleManifest(); err != nil {
+		return nil, err
+	}
+	return attachmentNames(op.manifest), nil
+}
+
+// ExtractAttachment pulls the named attached file from a remote bundle into dstDir
+func (op *ociProvider) ExtractAttachment(name, dstDir string) error {
+	names, err := op.ListAttachments()
+	if err != nil {
+		return err
+	}
+	if !helpers.SliceContains(names, name) {
+		return fmt.Errorf("%s is not attached to %s", name, op.src)
+	}
+	return op.PullLayer(op.manifest.Locate(name), dstDir)
+}
+
+// ListAttachments returns the names of the files attached to a bundle tarball
+func (tp *tarballBundleProvider) ListAttachments() ([]string, error) {
+	if err := tp.getBundleManifest(); err != nil {
+		return nil, err
+	}
+	return attachmentNames(tp.manifest), nil
+}
+
+// ListTarballAttachments returns the names of the files attached to a bundle tarball without a Bundler (ie. for shell completion)
+func ListTarballAttachments(src string) ([]string, error) {
+	tmp, err := os.MkdirTemp("", "uds-attachments-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	tp := &tarballBundleProvider{ctx: context.TODO(), src: src, dst: tmp}
+	return tp.ListAttachments()
+}
+
+// ExtractAttachment extracts the named attached file from a bundle tarball into dstDir
+func (tp *tarballBundleProvider) ExtractAttachment(name, dstDir string) error {
+	names, err := tp.ListAttachments()
+	if err != nil {
+		return err
+	}
+	if !helpers.SliceContains(names, name) {
+		return fmt.Errorf("%s is not attached to %s", name, tp.src)
+	}
+	pathInArchive, err := tp.resolvePathInArchive(name)
+	if err != nil {
+		return err
+	}
+	return tp.extractFile(pathInArchive, filepath.Join(dstDir, name))
+}
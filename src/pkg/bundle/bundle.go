@@ -8,22 +8,34 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/oci"
-	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
 	goyaml "github.com/goccy/go-yaml"
 	"github.com/mholt/archiver/v4"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
 	"golang.org/x/sync/errgroup"
 	"oras.land/oras-go/v2/content"
 	ocistore "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote/errcode"
 
 	"github.com/corang/uds-cli/src/config"
 	"github.com/corang/uds-cli/src/pkg/bundler"
+	"github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
 )
 
@@ -35,93 +47,96 @@ func Create(b *Bundler, signature []byte) error {
 		return fmt.Errorf("architecture is required for bundling")
 	}
 	bundle := &b.bundle
-	ctx := context.TODO()
-	message.Debug("Bundling", bundle.Metadata.Name, "to", b.tmp)
-	store, err := ocistore.NewWithContext(context.TODO(), b.tmp)
+	ctx := utils.NetworkContext()
+	// --store-backend picks where the bundle's OCI layout is built, it's removed once the tarball is written
+	cleanupStore, err := b.openStore()
+	defer cleanupStore()
+	if err != nil {
+		return err
+	}
+	message.Debug("Bundling", bundle.Metadata.Name, "to", b.layout)
+	store, err := ocistore.NewWithContext(context.TODO(), b.layout)
 	if err != nil {
 		return err
 	}
 
 	artifactPathMap := make(PathMap)
+	report := newSizeReport()
 
 	// create root manifest for OCI artifact, will populate with refs to uds-bundle.yaml and zarf.yamls
 	rootManifest := ocispec.Manifest{}
 	rootManifest.MediaType = ocispec.MediaTypeImageManifest
 
-	// grab all Zarf pkgs from OCI and put blobs in OCI store
-	for i, pkg := range bundle.ZarfPackages {
-		fetchSpinner := message.NewProgressSpinner("Fetching package %s", pkg.Name)
-
-		defer fetchSpinner.Stop()
-
-		if pkg.Repository != "" {
-			url := fmt.Sprintf("%s:%s", pkg.Repository, pkg.Ref)
-			remoteBundler, err := bundler.NewRemoteBundler(pkg, url, store, nil)
-			if err != nil {
-				return err
-			}
-
-			pkgManifestDesc, err := remoteBundler.PushManifest()
-			if err != nil {
-				return err
-			}
-
-			// append zarf pkg manifest to root manifest and grab path for archiving
-			rootManifest.Layers = append(rootManifest.Layers, pkgManifestDesc)
-			digest := pkgManifestDesc.Digest.Encoded()
-			artifactPathMap[filepath.Join(b.tmp, config.BlobsDir, digest)] = filepath.Join(config.BlobsDir, digest)
-
-			message.Debugf("Pushed %s sub-manifest into %s: %s", url, b.tmp, message.JSONValue(pkgManifestDesc))
-			layerDescs, err := remoteBundler.PushLayers(fetchSpinner, i+1, len(bundle.ZarfPackages))
-			if err != nil {
-				return err
-			}
-
-			// grab layers for archiving
-			for _, layerDesc := range layerDescs {
-				digest = layerDesc.Digest.Encoded()
-				artifactPathMap[filepath.Join(b.tmp, config.BlobsDir, digest)] = filepath.Join(config.BlobsDir, digest)
-			}
-		} else if pkg.Path != "" {
-			pkgTmp, err := utils.MakeTempDir()
-			defer os.RemoveAll(pkgTmp)
-			if err != nil {
-				return err
-			}
-
-			localBundler := bundler.NewLocalBundler(pkg.Path, pkgTmp)
-			if err != nil {
-				return err
-			}
-
-			err = localBundler.Extract()
-			if err != nil {
-				return err
-			}
-
-			zarfPkg, err := localBundler.Load()
-			if err != nil {
-				return err
-			}
-
-			zarfPkgDesc, err := localBundler.ToBundle(store, zarfPkg, artifactPathMap, b.tmp, pkgTmp)
-			if err != nil {
-				return err
-			}
-
-			// put digest in uds-bundle.yaml to reference during deploy
-			bundle.ZarfPackages[i].Ref = bundle.ZarfPackages[i].Ref + "-" + bundle.Metadata.Architecture + "@sha256:" + zarfPkgDesc.Digest.Encoded()
+	// grab all Zarf pkgs from OCI and put blobs in OCI store, --parallel-packages fetches several at once
+	fetched := make([]fetchedPackage, len(bundle.ZarfPackages))
+	parallel := b.cfg.CreateOpts.ParallelPackages
+	if parallel < 1 {
+		parallel = 1
+	}
+	// packages fetched in parallel each get a line of one progress display instead of sharing Zarf's single spinner
+	var progress *utils.MultiProgress
+	if parallel > 1 {
+		progress = utils.NewMultiProgress()
+	}
+	fetchGroup := errgroup.Group{}
+	fetchGroup.SetLimit(parallel)
+	for i := range bundle.ZarfPackages {
+		i := i
+		fetchGroup.Go(func() error {
+			var err error
+			fetched[i], err = b.fetchPackage(store, i, progress)
+			return err
+		})
+	}
+	if err := fetchGroup.Wait(); err != nil {
+		return err
+	}
+	missing := []missingImage{}
+	for _, pkg := range fetched {
+		missing = append(missing, pkg.missing...)
+	}
+	if err := reportMissingImages(missing, b.cfg.CreateOpts.Strict); err != nil {
+		return err
+	}
 
-			// append zarf.yaml layer to root manifest and grab path for archiving
-			rootManifest.Layers = append(rootManifest.Layers, zarfPkgDesc)
-			digest := zarfPkgDesc.Digest.Encoded()
-			artifactPathMap[filepath.Join(b.tmp, config.BlobsDir, digest)] = filepath.Join(config.BlobsDir, digest)
+	// --optimize-layers rewrites packages to share their image layers, before their refs are written to uds-bundle.yaml
+	if b.cfg.CreateOpts.OptimizeLayers {
+		optimizeSpinner := message.NewProgressSpinner("Optimizing image layers across packages")
+		defer optimizeSpinner.Stop()
+		optimization, err := b.optimizeLayers(ctx, store, fetched)
+		if err != nil {
+			return err
+		}
+		optimizeSpinner.Successf("Optimized image layers across packages")
+		if err := optimization.print(); err != nil {
+			return err
+		}
+	}
 
-		} else {
-			return fmt.Errorf("todo: haven't we already validated that Path or Repository is valid")
+	// --pin-images adds the digests of the packaged images to packages' image refs, after --optimize-layers has settled them
+	if b.cfg.CreateOpts.PinImages {
+		pinSpinner := message.NewProgressSpinner("Pinning package images to their digests")
+		defer pinSpinner.Stop()
+		pinning, err := b.pinImages(ctx, store, fetched)
+		if err != nil {
+			return err
 		}
+		pinSpinner.Successf("Pinned package images to their digests")
+		if err := pinning.print(); err != nil {
+			return err
+		}
+	}
 
-		fetchSpinner.Successf("Fetched package: %s", pkg.Name)
+	// merge in package order so the root manifest's layers don't depend on which fetch finished first
+	for i, pkg := range bundle.ZarfPackages {
+		rootManifest.Layers = append(rootManifest.Layers, fetched[i].manifestDesc)
+		for path, rel := range fetched[i].paths {
+			artifactPathMap[path] = rel
+		}
+		// report from the full manifest, remote packages' layers only list the blobs that weren't already in the store
+		if err := report.addPackageManifest(ctx, store, pkg.Name, fetched[i].manifestDesc); err != nil {
+			return err
+		}
 	}
 
 	message.HeaderInfof("🚧 Building Bundle")
@@ -134,17 +149,40 @@ func Create(b *Bundler, signature []byte) error {
 
 	// append uds-bundle.yaml layer to rootManifest and grab path for archiving
 	rootManifest.Layers = append(rootManifest.Layers, bundleManifestDesc)
-	digest := bundleManifestDesc.Digest.Encoded()
-	artifactPathMap[filepath.Join(b.tmp, config.BlobsDir, digest)] = filepath.Join(config.BlobsDir, digest)
+	artifactPathMap.addBlob(b.layout, bundleManifestDesc)
+
+	// push files attached with --attach to OCI store
+	attachmentDescs, err := pushAttachmentsToStore(ctx, store, b.cfg.CreateOpts.Attachments)
+	if err != nil {
+		return err
+	}
+	for _, desc := range attachmentDescs {
+		rootManifest.Layers = append(rootManifest.Layers, desc)
+		artifactPathMap.addBlob(b.layout, desc)
+	}
+
+	// push the bundle's signature, it has to be in the root manifest before the manifest is written
+	if len(signature) > 0 {
+		signatureDesc, err := pushBundleSignature(ctx, store, signature, b.cfg.CreateOpts.SigAlgo)
+		if err != nil {
+			return err
+		}
+		rootManifest.Layers = append(rootManifest.Layers, signatureDesc)
+		artifactPathMap.addBlob(b.layout, signatureDesc)
+		report.add(sizeReportBundleSource, signatureDesc)
+		message.Debug("Pushed", config.BundleYAMLSignature+":", message.JSONValue(signatureDesc))
+	}
 
 	// create and push bundle manifest config
-	manifestConfigDesc, err := createManifestConfig(bundle.Metadata, bundle.Build)
+	configMediaType := manifestConfigMediaType(b.cfg.CreateOpts.LegacyConfigMediaType, ocispec.MediaTypeImageManifest)
+	manifestConfigDesc, err := createManifestConfig(bundle.Metadata, bundle.Build, configMediaType)
 	if err != nil {
 		return err
 	}
 	rootManifest.Config = manifestConfigDesc
 	rootManifest.SchemaVersion = 2
-	rootManifest.Annotations = manifestAnnotationsFromMetadata(&bundle.Metadata) // maps to registry UI
+	rootManifest.ArtifactType = b.cfg.CreateOpts.ArtifactType
+	rootManifest.Annotations = mergeAnnotations(manifestAnnotationsFromMetadata(&bundle.Metadata, &bundle.Build), b.cfg.CreateOpts.Annotations) // maps to registry UI
 	manifestBytes, err := json.Marshal(rootManifest)
 	if err != nil {
 		return err
@@ -153,93 +191,232 @@ func Create(b *Bundler, signature []byte) error {
 	if err := store.Push(ctx, manifestDesc, bytes.NewReader(manifestBytes)); err != nil {
 		return err
 	}
-	digest = manifestDesc.Digest.Encoded()
-	artifactPathMap[filepath.Join(b.tmp, config.BlobsDir, digest)] = filepath.Join(config.BlobsDir, digest)
+	artifactPathMap.addBlob(b.layout, manifestDesc)
+	report.add(sizeReportBundleSource, bundleManifestDesc, manifestConfigDesc, manifestDesc)
+	report.add(sizeReportBundleSource, attachmentDescs...)
 
 	// rebuild index.json because pushing Zarf image manifests adds unnecessary entries
-	indexBytes, err := os.ReadFile(filepath.Join(b.tmp, "index.json"))
-	if err != nil {
-		return err
+	manifestDesc.Platform = &ocispec.Platform{
+		Architecture: bundle.Metadata.Architecture,
+		OS:           config.MultiOS,
+		Variant:      bundle.Metadata.PlatformVariant,
 	}
-	var index ocispec.Index
-	if err := json.Unmarshal(indexBytes, &index); err != nil {
+	if err := rebuildBundleIndex(b.layout, manifestDesc); err != nil {
 		return err
 	}
-	index.Manifests = []ocispec.Descriptor{manifestDesc} // use only the bundle-level manifest for index.json
-	bundleIndexBytes, err := json.Marshal(index)
-	if err != nil {
-		return err
+	artifactPathMap[filepath.Join(b.layout, "index.json")] = "index.json"
+
+	// grab oci-layout
+	artifactPathMap[filepath.Join(b.layout, "oci-layout")] = "oci-layout"
+
+	// --output - or a named pipe streams the tarball in one pass, nothing reads it back afterwards
+	if b.stream != "" {
+		if err := b.streamTarball(artifactPathMap); err != nil {
+			return err
+		}
+		if b.cfg.CreateOpts.SizeReport != "" {
+			return report.print(b.cfg.CreateOpts.SizeReport)
+		}
+		return nil
 	}
-	indexFile, err := os.Create(filepath.Join(b.tmp, "index.json"))
+
+	// tarball the bundle
+	limits, err := archiveLimitsFromOpts(&b.cfg.CreateOpts)
 	if err != nil {
 		return err
 	}
-	defer indexFile.Close()
-	_, err = indexFile.Write(bundleIndexBytes)
+	tarballPath, err := writeTarball(bundle, artifactPathMap, b.cfg.CreateOpts.OutputDirectory, limits)
 	if err != nil {
 		return err
 	}
-	artifactPathMap[filepath.Join(b.tmp, "index.json")] = "index.json"
 
-	// grab oci-layout
-	artifactPathMap[filepath.Join(b.tmp, "oci-layout")] = "oci-layout"
+	// encrypt the bundle at rest, the OCI-published form of a bundle is never encrypted
+	if b.cfg.CreateOpts.Encrypt {
+		encryptSpinner := message.NewProgressSpinner("Encrypting bundle archive")
+		defer encryptSpinner.Stop()
 
-	// push the bundle's signature todo: need to understand functionality and add tests
-	if len(signature) > 0 {
-		signatureDesc, err := pushBundleSignature(ctx, store, signature)
+		encryptedPath, err := encryptTarball(tarballPath, b.cfg.CreateOpts.Recipients)
 		if err != nil {
 			return err
 		}
-		rootManifest.Layers = append(rootManifest.Layers, signatureDesc)
-		message.Debug("Pushed", config.BundleYAMLSignature+":", message.JSONValue(signatureDesc))
+		encryptSpinner.Successf("Encrypted bundle archive at: %s", encryptedPath)
+		tarballPath = encryptedPath
 	}
 
-	// tarball the bundle
-	err = writeTarball(bundle, artifactPathMap)
-	if err != nil {
-		return err
+	// --snapshot names the final tarball by its content, before it's split so the parts share the name
+	if b.cfg.CreateOpts.Snapshot {
+		if tarballPath, err = snapshotTarball(bundle, tarballPath); err != nil {
+			return err
+		}
 	}
 
+	// split the bundle last so each part is a slice of exactly what would have been written as one tarball
+	if b.cfg.CreateOpts.SplitSize != "" {
+		partSize, err := parseSplitSize(b.cfg.CreateOpts.SplitSize)
+		if err != nil {
+			return err
+		}
+		splitSpinner := message.NewProgressSpinner("Splitting bundle archive")
+		defer splitSpinner.Stop()
+
+		manifestPath, err := utils.SplitFile(tarballPath, partSize)
+		if err != nil {
+			return err
+		}
+		splitSpinner.Successf("Split bundle archive into parts listed in: %s", manifestPath)
+	}
+
+	if b.cfg.CreateOpts.SizeReport != "" {
+		return report.print(b.cfg.CreateOpts.SizeReport)
+	}
 	return nil
 }
 
-// CreateAndPublish creates the bundle in an OCI registry publishes w/ optional signature to the remote repository.
-func CreateAndPublish(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, signature []byte) error {
-	if bundle.Metadata.Architecture == "" {
-		return fmt.Errorf("architecture is required for bundling")
-	}
-	dstRef := remoteDst.Repo().Reference
-	message.Debug("Bundling", bundle.Metadata.Name, "to", dstRef)
+// fetchedPackage holds the result of fetching a single Zarf package into a bundle's OCI store
+type fetchedPackage struct {
+	manifestDesc ocispec.Descriptor
+	paths        PathMap
+	layers       []ocispec.Descriptor
+	missing      []missingImage
+}
 
-	rootManifest := ocispec.Manifest{}
+// fetchPackage fetches the i-th Zarf package of the bundle into store, it only writes to its own package's entry
+// in the bundle so several packages can be fetched at once
+func (b *Bundler) fetchPackage(store *ocistore.Store, i int, progress *utils.MultiProgress) (fetchedPackage, error) {
+	bundle := &b.bundle
+	pkg := bundle.ZarfPackages[i]
+	fetched := fetchedPackage{paths: make(PathMap)}
 
-	for i, pkg := range bundle.ZarfPackages {
+	fetchSpinner := progress.Start("Fetching package %s", pkg.Name)
+	defer fetchSpinner.Stop()
+
+	if pkg.Repository != "" {
 		url := fmt.Sprintf("%s:%s", pkg.Repository, pkg.Ref)
-		remoteBundler, err := bundler.NewRemoteBundler(pkg, url, nil, remoteDst)
+		remoteBundler, err := bundler.NewRemoteBundler(pkg, url, store, nil)
 		if err != nil {
-			return err
+			return fetched, err
+		}
+		if b.cfg.CreateOpts.ExcludeSBOM {
+			remoteBundler.ExcludeSBOM()
 		}
 
-		zarfManifestDesc, err := remoteBundler.PushManifest()
+		pkgManifestDesc, err := remoteBundler.PushManifest()
 		if err != nil {
-			return err
+			return fetched, err
+		}
+		if b.cfg.CreateOpts.ExcludeSBOM {
+			// the rewritten package manifest has a new digest
+			bundle.ZarfPackages[i].Ref = repinRef(pkg.Ref, pkgManifestDesc.Digest)
 		}
 
-		// hack the media type to be a manifest and append to bundle root manifest
-		zarfManifestDesc.MediaType = ocispec.MediaTypeImageManifest
-		message.Debugf("Pushed %s sub-manifest into %s: %s", url, dstRef, message.JSONValue(zarfManifestDesc))
-		rootManifest.Layers = append(rootManifest.Layers, zarfManifestDesc)
+		// grab zarf pkg manifest for the root manifest and its path for archiving
+		annotatePackageTag(&pkgManifestDesc, pkg.Ref)
+		fetched.manifestDesc = pkgManifestDesc
+		fetched.paths.addBlob(b.layout, pkgManifestDesc)
 
-		pushSpinner := message.NewProgressSpinner("")
+		message.Debugf("Pushed %s sub-manifest into %s: %s", url, b.layout, message.JSONValue(pkgManifestDesc))
+		layerDescs, err := remoteBundler.PushLayers(fetchSpinner, i+1, len(bundle.ZarfPackages))
+		if err != nil {
+			return fetched, err
+		}
 
-		defer pushSpinner.Stop()
+		// grab layers for archiving
+		for _, layerDesc := range layerDescs {
+			fetched.paths.addBlob(b.layout, layerDesc)
+		}
+		fetched.layers = layerDescs
+	} else if pkg.Path != "" {
+		pkgTmp, err := zarfUtils.MakeTempDir()
+		defer os.RemoveAll(pkgTmp)
+		if err != nil {
+			return fetched, err
+		}
 
-		_, err = remoteBundler.PushLayers(pushSpinner, i+1, len(bundle.ZarfPackages))
+		localBundler := bundler.NewLocalBundler(pkg.Path, pkgTmp)
+		if b.cfg.CreateOpts.ExcludeSBOM {
+			localBundler.ExcludeSBOM()
+		}
+		if pkg.ExpectedSha256 != "" {
+			localBundler.ExpectSha256(pkg.ExpectedSha256)
+		}
+
+		err = localBundler.Extract()
 		if err != nil {
-			return err
+			return fetched, err
 		}
 
-		pushSpinner.Successf("Pushed package: %s", pkg.Name)
+		zarfPkg, err := localBundler.Load()
+		if err != nil {
+			return fetched, err
+		}
+
+		zarfPkgDesc, err := localBundler.ToBundle(store, zarfPkg, fetched.paths, b.layout, localBundler.PackageDir())
+		if err != nil {
+			return fetched, err
+		}
+
+		// put digest in uds-bundle.yaml to reference during deploy
+		bundle.ZarfPackages[i].Ref = bundle.ZarfPackages[i].Ref + "-" + bundle.Metadata.Architecture + "@" + zarfPkgDesc.Digest.String()
+
+		// grab zarf.yaml layer for the root manifest and its path for archiving
+		fetched.manifestDesc = zarfPkgDesc
+		fetched.paths.addBlob(b.layout, zarfPkgDesc)
+
+		// remote packages had their images checked by validation, local ones can only be checked once unpacked
+		var manifest oci.ZarfOCIManifest
+		if err := fetchJSON(utils.NetworkContext(), store, zarfPkgDesc, &manifest); err != nil {
+			return fetched, err
+		}
+		if fetched.missing, err = findMissingImages(utils.NetworkContext(), store, &manifest, pkg, zarfPkg); err != nil {
+			return fetched, err
+		}
+	} else {
+		return fetched, fmt.Errorf("todo: haven't we already validated that Path or Repository is valid")
+	}
+
+	fetchSpinner.Successf("Fetched package: %s", pkg.Name)
+	return fetched, nil
+}
+
+// CreateAndPublish creates the bundle in an OCI registry publishes w/ optional signature to the remote repository.
+func CreateAndPublish(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, signature []byte, opts *types.BundlerCreateOptions) error {
+	if bundle.Metadata.Architecture == "" {
+		return fmt.Errorf("architecture is required for bundling")
+	}
+	dstRef := remoteDst.Repo().Reference
+	message.Debug("Bundling", bundle.Metadata.Name, "to", dstRef)
+
+	rootManifest := ocispec.Manifest{}
+
+	// layers referenced by the bundle that --manifest-only didn't find in the registry
+	var missingLayers []string
+
+	// with --fail-fast=false every package is attempted and the failures are reported together
+	var results []packagePublishResult
+
+	for i, pkg := range bundle.ZarfPackages {
+		zarfManifestDesc, missing, err := publishPackage(remoteDst, bundle, i, opts)
+		if err != nil {
+			if opts.FailFast {
+				return err
+			}
+			message.Warnf("Failed to push package %s: %s", pkg.Name, err.Error())
+		} else {
+			rootManifest.Layers = append(rootManifest.Layers, zarfManifestDesc)
+			missingLayers = append(missingLayers, missing...)
+		}
+		results = append(results, packagePublishResult{Package: pkg.Name, Err: err})
+	}
+	if !opts.FailFast {
+		if err := summarizePackagePublish(results); err != nil {
+			return err
+		}
+	}
+	if len(missingLayers) > 0 {
+		return fmt.Errorf("--manifest-only requires the package layers to already exist in the registry, %d are missing:\n%s", len(missingLayers), strings.Join(missingLayers, "\n"))
+	}
+	if opts.ManifestOnly {
+		message.Successf("All layers of the %d packages exist in the registry", len(bundle.ZarfPackages))
 	}
 
 	// push the bundle's metadata
@@ -247,7 +424,7 @@ func CreateAndPublish(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, signat
 	if err != nil {
 		return err
 	}
-	bundleYamlDesc, err := remoteDst.PushLayer(bundleYamlBytes, oci.ZarfLayerMediaTypeBlob)
+	bundleYamlDesc, err := utils.PushLayer(remoteDst, bundleYamlBytes, oci.ZarfLayerMediaTypeBlob)
 	if err != nil {
 		return err
 	}
@@ -258,21 +435,27 @@ func CreateAndPublish(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, signat
 	message.Debug("Pushed", config.BundleYAML+":", message.JSONValue(bundleYamlDesc))
 	rootManifest.Layers = append(rootManifest.Layers, bundleYamlDesc)
 
+	// push files attached with --attach
+	attachmentDescs, err := pushAttachmentsToRemote(remoteDst, opts.Attachments)
+	if err != nil {
+		return err
+	}
+	rootManifest.Layers = append(rootManifest.Layers, attachmentDescs...)
+
 	// push the bundle's signature
 	if len(signature) > 0 {
-		bundleYamlSigDesc, err := remoteDst.PushLayer(signature, oci.ZarfLayerMediaTypeBlob)
+		bundleYamlSigDesc, err := utils.PushLayer(remoteDst, signature, oci.ZarfLayerMediaTypeBlob)
 		if err != nil {
 			return err
 		}
-		bundleYamlSigDesc.Annotations = map[string]string{
-			ocispec.AnnotationTitle: config.BundleYAMLSignature,
-		}
+		bundleYamlSigDesc.Annotations = signatureAnnotations(opts.SigAlgo)
 		rootManifest.Layers = append(rootManifest.Layers, bundleYamlSigDesc)
 		message.Debug("Pushed", config.BundleYAMLSignature+":", message.JSONValue(bundleYamlSigDesc))
 	}
 
 	// push the bundle manifest config
-	configDesc, err := pushManifestConfigFromMetadata(remoteDst, &bundle.Metadata, &bundle.Build)
+	configMediaType := manifestConfigMediaType(opts.LegacyConfigMediaType, ocispec.MediaTypeImageConfig)
+	configDesc, err := pushManifestConfigFromMetadata(remoteDst, &bundle.Metadata, &bundle.Build, configMediaType)
 	if err != nil {
 		return err
 	}
@@ -283,18 +466,13 @@ func CreateAndPublish(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, signat
 
 	rootManifest.SchemaVersion = 2
 
-	rootManifest.Annotations = manifestAnnotationsFromMetadata(&bundle.Metadata) // maps to registry UI
-	b, err := json.Marshal(rootManifest)
+	rootManifest.ArtifactType = opts.ArtifactType
+
+	rootManifest.Annotations = mergeAnnotations(manifestAnnotationsFromMetadata(&bundle.Metadata, &bundle.Build), opts.Annotations) // maps to registry UI
+	expected, err := pushRootManifest(utils.NetworkContext(), remoteDst.Repo().Manifests(), rootManifest, dstRef.Reference)
 	if err != nil {
 		return err
 	}
-	expected := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, b)
-
-	message.Debug("Pushing manifest:", message.JSONValue(expected))
-
-	if err := remoteDst.Repo().Manifests().PushReference(context.TODO(), expected, bytes.NewReader(b), dstRef.Reference); err != nil {
-		return fmt.Errorf("failed to push manifest: %w", err)
-	}
 
 	message.Successf("Published %s [%s]", dstRef, expected.MediaType)
 
@@ -302,6 +480,8 @@ func CreateAndPublish(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, signat
 	flags := ""
 	if config.CommonOptions.Insecure {
 		flags = "--insecure"
+	} else if config.CommonOptions.PlainHTTP {
+		flags = "--plain-http"
 	}
 	message.Title("To inspect/deploy/pull:", "")
 	message.Command("inspect oci://%s %s", dstRef, flags)
@@ -311,30 +491,187 @@ func CreateAndPublish(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, signat
 	return nil
 }
 
+// publishPackage pushes the manifest and layers of the bundle's i-th package to remoteDst (or its --repo-prefix
+// repository), returning the descriptor the root manifest references it by
+//
+// with --manifest-only the layers aren't pushed, the layers missing from the registry are returned instead
+func publishPackage(remoteDst *oci.OrasRemote, bundle *types.UDSBundle, i int, opts *types.BundlerCreateOptions) (ocispec.Descriptor, []string, error) {
+	pkg := bundle.ZarfPackages[i]
+	url := fmt.Sprintf("%s:%s", pkg.Repository, pkg.Ref)
+
+	// --repo-prefix pushes the package's layers to their own repository instead of the bundle's
+	pkgDst := remoteDst
+	if opts.RepoPrefix != "" {
+		mirror, err := normalizeRegistryRef(packageMirrorRepository(opts.RepoPrefix, pkg), opts.RegistryStyle)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		pkgDst, err = utils.NewOrasRemote(mirror)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		if err := checkRepositoryExists(pkgDst, opts.RegistryStyle); err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+	}
+	remoteBundler, err := bundler.NewRemoteBundler(pkg, url, nil, pkgDst)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	if opts.ExcludeSBOM {
+		remoteBundler.ExcludeSBOM()
+	}
+
+	zarfManifestDesc, err := remoteBundler.PushManifest()
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	if opts.ExcludeSBOM {
+		// the rewritten package manifest has a new digest
+		bundle.ZarfPackages[i].Ref = repinRef(pkg.Ref, zarfManifestDesc.Digest)
+	}
+	if pkgDst != remoteDst {
+		// the bundle's root manifest references the package manifest, so it must also exist in the bundle's repo
+		zarfManifestDesc, err = pushPackageManifestToBundle(remoteDst, remoteBundler.PkgRootManifest)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		pkgRef := pkgDst.Repo().Reference
+		zarfManifestDesc.Annotations = map[string]string{
+			config.PackageRepositoryAnnotation: pkgRef.Registry + "/" + pkgRef.Repository,
+		}
+	}
+
+	// hack the media type to be a manifest so the bundle root manifest can reference it
+	zarfManifestDesc.MediaType = ocispec.MediaTypeImageManifest
+	annotatePackageTag(&zarfManifestDesc, pkg.Ref)
+	message.Debugf("Pushed %s sub-manifest into %s: %s", url, remoteDst.Repo().Reference, message.JSONValue(zarfManifestDesc))
+
+	// --manifest-only expects the package's layers to already be in the registry
+	if opts.ManifestOnly {
+		missing, err := remoteBundler.MissingLayers()
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		var missingLayers []string
+		for _, layer := range missing {
+			missingLayers = append(missingLayers, fmt.Sprintf("%s %s (%s)", pkg.Name, layer.Digest, layer.Annotations[ocispec.AnnotationTitle]))
+		}
+		return zarfManifestDesc, missingLayers, nil
+	}
+
+	pushSpinner := message.NewProgressSpinner("")
+
+	defer pushSpinner.Stop()
+
+	_, err = remoteBundler.PushLayers(pushSpinner, i+1, len(bundle.ZarfPackages))
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+
+	pushSpinner.Successf("Pushed package: %s", pkg.Name)
+	return zarfManifestDesc, nil, nil
+}
+
+// packagePublishResult is the outcome of pushing one of a bundle's packages
+type packagePublishResult struct {
+	Package string
+	Err     error
+}
+
+// summarizePackagePublish prints which packages were pushed and which failed, returning an error naming the failures
+func summarizePackagePublish(results []packagePublishResult) error {
+	table := pterm.TableData{{"Package", "Status", "Error"}}
+	var failed []string
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result.Package)
+			table = append(table, []string{result.Package, "failed", result.Err.Error()})
+			continue
+		}
+		table = append(table, []string{result.Package, "pushed", ""})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to push %d of %d packages: %s", len(failed), len(results), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// packageMirrorRepository returns the repository a package is pushed to with --repo-prefix, named after the last element of its original repository
+func packageMirrorRepository(repoPrefix string, pkg types.BundleZarfPackage) string {
+	prefix := strings.TrimSuffix(strings.TrimPrefix(repoPrefix, helpers.OCIURLPrefix), "/")
+	return fmt.Sprintf("%s/%s", prefix, path.Base(pkg.Repository))
+}
+
+// pushPackageManifestToBundle pushes a Zarf package's manifest into the bundle's repository as a blob
+func pushPackageManifestToBundle(remoteDst *oci.OrasRemote, pkgManifest *oci.ZarfOCIManifest) (ocispec.Descriptor, error) {
+	pkgManifestBytes, err := json.Marshal(pkgManifest)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return utils.PushLayer(remoteDst, pkgManifestBytes, oci.ZarfLayerMediaTypeBlob)
+}
+
+// encryptTarball encrypts the bundle tarball at tarballPath to the recipients and returns the encrypted tarball's path,
+// the plaintext tarball is removed even if encryption fails so it's never left at rest
+func encryptTarball(tarballPath string, recipients []string) (string, error) {
+	encryptedPath := tarballPath + utils.EncryptedSuffix
+	if err := utils.EncryptFile(tarballPath, encryptedPath, recipients); err != nil {
+		// a partially encrypted tarball can't be decrypted
+		_ = os.Remove(encryptedPath)
+		_ = os.Remove(tarballPath)
+		return "", err
+	}
+	return encryptedPath, os.Remove(tarballPath)
+}
+
+// annotatePackageTag keeps the human-readable tag of a digest-pinned package ref on its manifest descriptor
+func annotatePackageTag(desc *ocispec.Descriptor, ref string) {
+	tag, _, _ := strings.Cut(ref, "@")
+	if desc.Annotations == nil {
+		desc.Annotations = map[string]string{}
+	}
+	desc.Annotations[config.PackageTagAnnotation] = tag
+}
+
+// repinRef points a package's tag-arch@digest ref at a different manifest digest
+func repinRef(ref string, dgst digest.Digest) string {
+	tag, _, _ := strings.Cut(ref, "@")
+	return tag + "@" + dgst.String()
+}
+
 // copied from: https://github.com/defenseunicorns/zarf/blob/main/src/pkg/oci/push.go
-func pushManifestConfigFromMetadata(r *oci.OrasRemote, metadata *types.UDSMetadata, build *types.UDSBuildData) (ocispec.Descriptor, error) {
+func pushManifestConfigFromMetadata(r *oci.OrasRemote, metadata *types.UDSMetadata, build *types.UDSBuildData, mediaType string) (ocispec.Descriptor, error) {
 	annotations := map[string]string{
 		ocispec.AnnotationTitle:       metadata.Name,
 		ocispec.AnnotationDescription: metadata.Description,
 	}
 	manifestConfig := oci.ConfigPartial{
 		Architecture: build.Architecture,
-		OCIVersion:   "1.0.1",
+		OCIVersion:   config.GetOCIVersion(),
 		Annotations:  annotations,
 	}
 	manifestConfigBytes, err := json.Marshal(manifestConfig)
 	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
-	return r.PushLayer(manifestConfigBytes, ocispec.MediaTypeImageConfig)
+	return utils.PushLayer(r, manifestConfigBytes, mediaType)
 }
 
 // copied from: https://github.com/defenseunicorns/zarf/blob/main/src/pkg/oci/push.go
-func manifestAnnotationsFromMetadata(metadata *types.UDSMetadata) map[string]string {
+func manifestAnnotationsFromMetadata(metadata *types.UDSMetadata, build *types.UDSBuildData) map[string]string {
 	annotations := map[string]string{
 		ocispec.AnnotationDescription: metadata.Description,
 	}
 
+	// build.Timestamp already honors SOURCE_DATE_EPOCH, re-use it so the two never drift
+	if created, err := time.Parse(time.RFC1123Z, build.Timestamp); err == nil {
+		annotations[ocispec.AnnotationCreated] = created.UTC().Format(time.RFC3339)
+	}
+
 	if url := metadata.URL; url != "" {
 		annotations[ocispec.AnnotationURL] = url
 	}
@@ -350,6 +687,10 @@ func manifestAnnotationsFromMetadata(metadata *types.UDSMetadata) map[string]str
 	if vendor := metadata.Vendor; vendor != "" {
 		annotations[ocispec.AnnotationVendor] = vendor
 	}
+	// bundles in a registry have no index.json entry to hold their platform's variant
+	if variant := metadata.PlatformVariant; variant != "" {
+		annotations[config.PlatformVariantAnnotation] = variant
+	}
 
 	return annotations
 }
@@ -372,103 +713,188 @@ func pushBundleManifestToStore(ctx context.Context, store *ocistore.Store, bundl
 	return bundleYamlDesc, err
 }
 
+// pushRootManifest pushes a bundle's root manifest and tags it with reference
+//
+// a manifest with an artifactType is first pushed as is, registries that predate artifactType can reject it, so a
+// rejected manifest falls back to being pushed without it
+func pushRootManifest(ctx context.Context, pusher registry.ReferencePusher, manifest ocispec.Manifest, reference string) (ocispec.Descriptor, error) {
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	expected := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, b)
+	message.Debug("Pushing manifest:", message.JSONValue(expected))
+	err = pusher.PushReference(ctx, expected, bytes.NewReader(b), reference)
+	if err != nil && manifest.ArtifactType != "" && isManifestRejected(err) {
+		message.Warnf("The registry rejected the bundle manifest with artifactType %s, pushing it without one", manifest.ArtifactType)
+		manifest.ArtifactType = ""
+		return pushRootManifest(ctx, pusher, manifest, reference)
+	}
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest: %w", err)
+	}
+	return expected, nil
+}
+
+// isManifestRejected returns true if the registry refused a manifest as invalid or unsupported rather than failing to push it
+func isManifestRejected(err error) bool {
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return false
+	}
+	return errResp.StatusCode == http.StatusBadRequest || errResp.StatusCode == http.StatusUnsupportedMediaType
+}
+
+// manifestConfigMediaType returns the media type of a bundle manifest's config, legacyMediaType is the one used before bundles had their own config media type
+func manifestConfigMediaType(legacy bool, legacyMediaType string) string {
+	if legacy {
+		return legacyMediaType
+	}
+	return config.BundleConfigMediaType
+}
+
 // createManifestConfig creates a manifest config based on the uds-bundle.yaml
-func createManifestConfig(metadata types.UDSMetadata, build types.UDSBuildData) (ocispec.Descriptor, error) {
+func createManifestConfig(metadata types.UDSMetadata, build types.UDSBuildData, mediaType string) (ocispec.Descriptor, error) {
 	annotations := map[string]string{
 		ocispec.AnnotationTitle:       metadata.Name,
 		ocispec.AnnotationDescription: metadata.Description,
 	}
 	manifestConfig := oci.ConfigPartial{
 		Architecture: build.Architecture,
-		OCIVersion:   "1.0.1",
+		OCIVersion:   config.GetOCIVersion(),
 		Annotations:  annotations,
 	}
 	manifestConfigBytes, err := json.Marshal(manifestConfig)
 	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
-	manifestConfigDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestConfigBytes)
+	manifestConfigDesc := content.NewDescriptorFromBytes(mediaType, manifestConfigBytes)
 	return manifestConfigDesc, err
 }
 
-// writeTarball builds and writes a bundle tarball to disk based on a file map
-func writeTarball(bundle *types.UDSBundle, artifactPathMap PathMap) error {
-	format := archiver.CompressedArchive{
-		Compression: archiver.Zstd{},
-		Archival:    archiver.Tar{},
-	}
-	filename := fmt.Sprintf("%s%s-%s-%s.tar.zst", config.BundlePrefix, bundle.Metadata.Name, bundle.Metadata.Architecture, bundle.Metadata.Version)
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
+// writeTarball builds and writes a bundle tarball into dstDir (the working directory if empty) based on a file map and returns the path to the tarball
+func writeTarball(bundle *types.UDSBundle, artifactPathMap PathMap, dstDir string, limits archiveLimits) (string, error) {
+	filename := bundleTarballName(&bundle.Metadata)
+	if dstDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		dstDir = cwd
 	}
-	dst := filepath.Join(cwd, filename)
+	dst := filepath.Join(dstDir, filename)
 
 	_ = os.RemoveAll(dst)
 
 	out, err := os.Create(dst)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer out.Close()
+	if err := archiveBundle(out, artifactPathMap, dst, limits); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// archiveBundle writes the bundle tarball of a file map to out, described as dst in its progress. The tarball is
+// written in a single forward pass: tar and zstd never seek back, so out can be a pipe
+func archiveBundle(out io.Writer, artifactPathMap PathMap, dst string, limits archiveLimits) error {
+	format := archiver.CompressedArchive{
+		Compression: archiver.Zstd{EncoderOptions: limits.encoderOptions()},
+		Archival:    archiver.Tar{},
+	}
 	files, err := archiver.FilesFromDisk(nil, artifactPathMap)
 	if err != nil {
 		return err
 	}
 
-	archiveErrorChan := make(chan error, len(files))
-	jobs := make(chan archiver.ArchiveAsyncJob, len(files))
-
-	for _, file := range files {
-		archiveJob := archiver.ArchiveAsyncJob{
-			File:   file,
-			Result: archiveErrorChan,
-		}
-		jobs <- archiveJob
+	// stamp tar headers with SOURCE_DATE_EPOCH so identical inputs produce identical archives
+	epoch, ok, err := utils.SourceDateEpoch()
+	if err != nil {
+		return err
+	}
+	if ok {
+		utils.SetArchiveModTimes(files, epoch)
 	}
 
-	close(jobs)
+	// files are queued a few at a time rather than all up front, so bundles with thousands of files don't hold a job
+	// and a result for each of them at once
+	archiveErrorChan := make(chan error, limits.queueSize())
+	jobs := make(chan archiver.ArchiveAsyncJob, limits.queueSize())
 
-	archiveErrGroup, ctx := errgroup.WithContext(context.TODO())
+	cancelCtx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	archiveErrGroup, ctx := errgroup.WithContext(cancelCtx)
 
-	archiveBar := message.NewProgressBar(int64(len(jobs)), "Creating bundle archive")
+	archiveBar := message.NewProgressBar(int64(len(files)), "Creating bundle archive")
 
 	defer archiveBar.Stop()
 
 	archiveErrGroup.Go(func() error {
+		defer close(jobs)
+		for _, file := range files {
+			archiveJob := archiver.ArchiveAsyncJob{
+				File:   file,
+				Result: archiveErrorChan,
+			}
+			select {
+			case jobs <- archiveJob:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+
+	archiveErrGroup.Go(func() error {
+		// the archiver sends every result before it returns
+		defer close(archiveErrorChan)
 		return format.ArchiveAsync(ctx, out, jobs)
 	})
 
-jobLoop:
-	for len(jobs) != 0 {
-		select {
-		case err := <-archiveErrorChan:
-			if err != nil {
-				return err
-			} else {
-				archiveBar.Add(1)
+	// results are drained until the archiver returns, a failed file cancels the files queued after it
+	var archiveErr error
+	for err := range archiveErrorChan {
+		if err != nil {
+			if archiveErr == nil {
+				archiveErr = err
+				cancel()
 			}
-		case <-ctx.Done():
-			break jobLoop
+			continue
 		}
+		archiveBar.Add(1)
 	}
 
-	if err := archiveErrGroup.Wait(); err != nil {
-		return err
+	if err := archiveErrGroup.Wait(); archiveErr == nil {
+		archiveErr = err
+	}
+	if archiveErr != nil {
+		return archiveErr
 	}
 
 	archiveBar.Successf("Created bundle archive at: %s", dst)
 	return nil
 }
 
-func pushBundleSignature(ctx context.Context, store *ocistore.Store, signature []byte) (ocispec.Descriptor, error) {
+func pushBundleSignature(ctx context.Context, store *ocistore.Store, signature []byte, sigAlgo string) (ocispec.Descriptor, error) {
 	signatureDesc := content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, signature)
 	err := store.Push(ctx, signatureDesc, bytes.NewReader(signature))
 	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
-	signatureDesc.Annotations = map[string]string{
+	signatureDesc.Annotations = signatureAnnotations(sigAlgo)
+	return signatureDesc, err
+}
+
+// signatureAnnotations returns the annotations of the uds-bundle.yaml.sig layer, recording the signature's algorithm
+// so verification can check it against the public key
+func signatureAnnotations(sigAlgo string) map[string]string {
+	annotations := map[string]string{
 		ocispec.AnnotationTitle: config.BundleYAMLSignature,
 	}
-	return signatureDesc, err
+	if sigAlgo != "" {
+		annotations[config.SigAlgoAnnotation] = sigAlgo
+	}
+	return annotations
 }
@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// manifestRecorder records the manifests pushed to it, returning err for manifests with an artifactType
+type manifestRecorder struct {
+	err    error
+	pushed []ocispec.Manifest
+}
+
+func (r *manifestRecorder) PushReference(_ context.Context, _ ocispec.Descriptor, content io.Reader, _ string) error {
+	var manifest ocispec.Manifest
+	if err := json.NewDecoder(content).Decode(&manifest); err != nil {
+		return err
+	}
+	r.pushed = append(r.pushed, manifest)
+	if manifest.ArtifactType != "" {
+		return r.err
+	}
+	return nil
+}
+
+func Test_pushRootManifest(t *testing.T) {
+	tests := []struct {
+		name             string
+		description      string
+		artifactType     string
+		err              error
+		wantArtifactType string
+		wantPushes       int
+		wantErr          bool
+	}{
+		{
+			name:        "NoArtifactType",
+			description: "manifests without an artifactType are pushed as before",
+			wantPushes:  1,
+		},
+		{
+			name:             "Accepted",
+			description:      "registries that accept the artifactType keep it",
+			artifactType:     config.BundleArtifactType,
+			wantArtifactType: config.BundleArtifactType,
+			wantPushes:       1,
+		},
+		{
+			name:         "Rejected",
+			description:  "registries that reject the manifest get it again without the artifactType",
+			artifactType: config.BundleArtifactType,
+			err:          &errcode.ErrorResponse{StatusCode: http.StatusBadRequest},
+			wantPushes:   2,
+		},
+		{
+			name:         "Failed",
+			description:  "errors other than a rejected manifest aren't retried",
+			artifactType: config.BundleArtifactType,
+			err:          errors.New("connection reset"),
+			wantPushes:   1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &manifestRecorder{err: tt.err}
+			manifest := ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: tt.artifactType}
+			desc, err := pushRootManifest(context.TODO(), recorder, manifest, "0.0.1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pushRootManifest() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if len(recorder.pushed) != tt.wantPushes {
+				t.Fatalf("pushRootManifest() pushed %d manifests, want %d (%s)", len(recorder.pushed), tt.wantPushes, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := recorder.pushed[len(recorder.pushed)-1].ArtifactType; got != tt.wantArtifactType {
+				t.Errorf("pushRootManifest() artifactType = %q, want %q (%s)", got, tt.wantArtifactType, tt.description)
+			}
+			if desc.MediaType != ocispec.MediaTypeImageManifest {
+				t.Errorf("pushRootManifest() media type = %s, want %s", desc.MediaType, ocispec.MediaTypeImageManifest)
+			}
+		})
+	}
+}
+
+func Test_signatureAlgorithm(t *testing.T) {
+	layer := func(annotations map[string]string) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, []byte("signature"))
+		desc.Annotations = annotations
+		return desc
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		layers      []ocispec.Descriptor
+		want        string
+	}{
+		{
+			name:        "Recorded",
+			description: "the algorithm annotated on the signature layer is returned",
+			layers:      []ocispec.Descriptor{layer(signatureAnnotations(config.SigAlgoEd25519))},
+			want:        config.SigAlgoEd25519,
+		},
+		{
+			name:        "NotRecorded",
+			description: "signatures from before the algorithm was recorded have none",
+			layers:      []ocispec.Descriptor{layer(signatureAnnotations(""))},
+		},
+		{
+			name:        "Unsigned",
+			description: "unsigned bundles have no signature layer",
+			layers:      []ocispec.Descriptor{layer(map[string]string{ocispec.AnnotationTitle: config.BundleYAML})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := &oci.ZarfOCIManifest{Manifest: ocispec.Manifest{Layers: tt.layers}}
+			if got := signatureAlgorithm(manifest); got != tt.want {
+				t.Errorf("signatureAlgorithm() = %q, want %q (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_summarizePackagePublish(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		results     []packagePublishResult
+		wantErr     bool
+	}{
+		{
+			name:        "AllPushed",
+			description: "publishing succeeds when every package was pushed",
+			results:     []packagePublishResult{{Package: "init"}, {Package: "podinfo"}},
+		},
+		{
+			name:        "SomeFailed",
+			description: "publishing fails when any package failed to push",
+			results:     []packagePublishResult{{Package: "init"}, {Package: "podinfo", Err: errors.New("unauthorized")}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := summarizePackagePublish(tt.results); (err != nil) != tt.wantErr {
+				t.Errorf("summarizePackagePublish() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_encryptTarball(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		description string
+		recipients  []string
+		wantErr     bool
+	}{
+		{
+			name:        "Encrypted",
+			description: "the plaintext tarball is replaced by the encrypted one",
+			recipients:  []string{identity.Recipient().String()},
+		}, {
+			name:        "InvalidRecipient",
+			description: "neither the plaintext nor a partially encrypted tarball is left behind when encryption fails",
+			recipients:  []string{"not-a-recipient"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tarballPath := filepath.Join(t.TempDir(), "uds-bundle-test-amd64-0.0.1.tar.zst")
+			if err := os.WriteFile(tarballPath, []byte("plaintext bundle"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			encryptedPath, err := encryptTarball(tarballPath, tt.recipients)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encryptTarball() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if _, err := os.Stat(tarballPath); !os.IsNotExist(err) {
+				t.Errorf("encryptTarball() left the plaintext tarball at %s (%s)", tarballPath, tt.description)
+			}
+			_, err = os.Stat(tarballPath + utils.EncryptedSuffix)
+			if tt.wantErr && !os.IsNotExist(err) {
+				t.Errorf("encryptTarball() left a partially encrypted tarball (%s)", tt.description)
+			}
+			if !tt.wantErr && (err != nil || encryptedPath != tarballPath+utils.EncryptedSuffix) {
+				t.Errorf("encryptTarball() = %s, %v, want %s (%s)", encryptedPath, err, tarballPath+utils.EncryptedSuffix, tt.description)
+			}
+		})
+	}
+}
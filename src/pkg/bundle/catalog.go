@@ -0,0 +1,269 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+)
+
+// defaultCatalogTag is the tag catalogs are pushed to and listed from when their ref doesn't have one
+const defaultCatalogTag = "latest"
+
+// catalogEntry is a published bundle listed in a catalog
+type catalogEntry struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Architecture string `json:"architecture"`
+	Description  string `json:"description,omitempty"`
+	Ref          string `json:"ref"`
+	Digest       string `json:"digest"`
+}
+
+// annotations returns the entry as annotations on its descriptor in the catalog's index
+func (e catalogEntry) annotations() map[string]string {
+	annotations := map[string]string{
+		config.CatalogBundleNameAnnotation:         e.Name,
+		config.CatalogBundleVersionAnnotation:      e.Version,
+		config.CatalogBundleArchitectureAnnotation: e.Architecture,
+		config.CatalogBundleRefAnnotation:          e.Ref,
+		config.CatalogBundleDigestAnnotation:       e.Digest,
+	}
+	if e.Description != "" {
+		annotations[ocispec.AnnotationDescription] = e.Description
+	}
+	return annotations
+}
+
+// catalogEntryFromAnnotations reads an entry back out of its descriptor's annotations
+func catalogEntryFromAnnotations(annotations map[string]string) catalogEntry {
+	return catalogEntry{
+		Name:         annotations[config.CatalogBundleNameAnnotation],
+		Version:      annotations[config.CatalogBundleVersionAnnotation],
+		Architecture: annotations[config.CatalogBundleArchitectureAnnotation],
+		Description:  annotations[ocispec.AnnotationDescription],
+		Ref:          annotations[config.CatalogBundleRefAnnotation],
+		Digest:       annotations[config.CatalogBundleDigestAnnotation],
+	}
+}
+
+// CatalogPush pushes an index listing the bundles published to the --bundle refs to the catalog's ref, replacing the
+// catalog that was there
+func (b *Bundler) CatalogPush() error {
+	if len(b.cfg.CatalogOpts.Bundles) == 0 {
+		return fmt.Errorf("a catalog needs at least one --bundle")
+	}
+
+	entries := []catalogEntry{}
+	refs := make(map[string]bool)
+	for _, ref := range b.cfg.CatalogOpts.Bundles {
+		spinner := message.NewProgressSpinner("Reading bundle %s", ref)
+		entry, err := loadCatalogEntry(ref)
+		if err != nil {
+			spinner.Stop()
+			return fmt.Errorf("unable to add %s to the catalog: %w", ref, err)
+		}
+		if refs[entry.Ref] {
+			spinner.Stop()
+			return fmt.Errorf("%s is listed more than once", ref)
+		}
+		refs[entry.Ref] = true
+		entries = append(entries, entry)
+		spinner.Successf("Read bundle %s %s (%s)", entry.Name, entry.Version, entry.Architecture)
+	}
+
+	remote, err := utils.NewOrasRemote(b.cfg.CatalogOpts.Source)
+	if err != nil {
+		return err
+	}
+	repo := remote.Repo()
+	tag := catalogTag(repo.Reference.Reference)
+	indexDesc, err := pushCatalog(context.TODO(), repo, tag, entries)
+	if err != nil {
+		return err
+	}
+	message.Successf("Pushed a catalog of %d bundles to %s/%s:%s (%s)", len(entries), repo.Reference.Registry, repo.Reference.Repository, tag, indexDesc.Digest)
+	return nil
+}
+
+// CatalogList lists the bundles in the catalog at the catalog's ref
+func (b *Bundler) CatalogList() error {
+	remote, err := utils.NewOrasRemote(b.cfg.CatalogOpts.Source)
+	if err != nil {
+		return err
+	}
+	repo := remote.Repo()
+	entries, err := listCatalog(context.TODO(), repo, catalogTag(repo.Reference.Reference))
+	if err != nil {
+		return err
+	}
+
+	if b.cfg.CatalogOpts.JSON {
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	table := pterm.TableData{{"Bundle", "Version", "Architecture", "Ref", "Description"}}
+	for _, entry := range entries {
+		table = append(table, []string{entry.Name, entry.Version, entry.Architecture, entry.Ref, entry.Description})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+}
+
+// catalogTag returns the tag of a catalog ref, or the default tag if it doesn't have one
+func catalogTag(reference string) string {
+	if reference == "" {
+		return defaultCatalogTag
+	}
+	return reference
+}
+
+// loadCatalogEntry reads the metadata of the bundle published to ref
+func loadCatalogEntry(ref string) (catalogEntry, error) {
+	remote, err := utils.NewOrasRemote(ref)
+	if err != nil {
+		return catalogEntry{}, err
+	}
+	rootDesc, err := remote.ResolveRoot()
+	if err != nil {
+		return catalogEntry{}, err
+	}
+	root, err := remote.FetchRoot()
+	if err != nil {
+		return catalogEntry{}, err
+	}
+	bundleYAMLDesc := root.Locate(config.BundleYAML)
+	if oci.IsEmptyDescriptor(bundleYAMLDesc) {
+		return catalogEntry{}, fmt.Errorf("%s is not a bundle, it has no %s", ref, config.BundleYAML)
+	}
+	b, err := remote.FetchLayer(bundleYAMLDesc)
+	if err != nil {
+		return catalogEntry{}, err
+	}
+	var bundle types.UDSBundle
+	if err := goyaml.Unmarshal(b, &bundle); err != nil {
+		return catalogEntry{}, err
+	}
+	return catalogEntry{
+		Name:         bundle.Metadata.Name,
+		Version:      bundle.Metadata.Version,
+		Architecture: bundle.Metadata.Architecture,
+		Description:  bundle.Metadata.Description,
+		Ref:          remote.Repo().Reference.String(),
+		Digest:       rootDesc.Digest.String(),
+	}, nil
+}
+
+// pushCatalog pushes an index of entries to target and tags it
+//
+// registries only accept indexes of manifests in the same repository, so each entry is a small manifest in the
+// catalog's repository pointing at its bundle rather than the bundle's own manifest
+func pushCatalog(ctx context.Context, target oras.Target, tag string, entries []catalogEntry) (ocispec.Descriptor, error) {
+	configDesc, err := pushCatalogBlob(ctx, target, config.CatalogConfigMediaType, []byte("{}"))
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{},
+	}
+	for _, entry := range entries {
+		entryBytes, err := json.Marshal(entry)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		layerDesc, err := pushCatalogBlob(ctx, target, config.CatalogEntryMediaType, entryBytes)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		manifest := ocispec.Manifest{
+			Versioned:   specs.Versioned{SchemaVersion: 2},
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Config:      configDesc,
+			Layers:      []ocispec.Descriptor{layerDesc},
+			Annotations: entry.annotations(),
+		}
+		manifestBytes, err := json.Marshal(manifest)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		manifestDesc, err := pushCatalogBlob(ctx, target, ocispec.MediaTypeImageManifest, manifestBytes)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		// the index carries the annotations too so listing the catalog only needs to fetch the index
+		manifestDesc.Annotations = entry.annotations()
+		index.Manifests = append(index.Manifests, manifestDesc)
+	}
+
+	indexBytes, err := json.Marshal(index)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	indexDesc, err := pushCatalogBlob(ctx, target, ocispec.MediaTypeImageIndex, indexBytes)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return indexDesc, target.Tag(ctx, indexDesc, tag)
+}
+
+// pushCatalogBlob pushes b to target unless it's already there, catalogs re-pushed with the same bundles reuse their blobs
+func pushCatalogBlob(ctx context.Context, target oras.Target, mediaType string, b []byte) (ocispec.Descriptor, error) {
+	desc := content.NewDescriptorFromBytes(mediaType, b)
+	exists, err := target.Exists(ctx, desc)
+	if err != nil || exists {
+		return desc, err
+	}
+	return desc, target.Push(ctx, desc, bytes.NewReader(b))
+}
+
+// listCatalog returns the entries of the catalog tagged tag in target
+func listCatalog(ctx context.Context, target oras.ReadOnlyTarget, tag string) ([]catalogEntry, error) {
+	indexDesc, err := target.Resolve(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	if indexDesc.MediaType != ocispec.MediaTypeImageIndex {
+		return nil, fmt.Errorf("%s is not a bundle catalog, its media type is %s", tag, indexDesc.MediaType)
+	}
+	b, err := content.FetchAll(ctx, target, indexDesc)
+	if err != nil {
+		return nil, err
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, err
+	}
+	entries := []catalogEntry{}
+	for _, desc := range index.Manifests {
+		if _, ok := desc.Annotations[config.CatalogBundleRefAnnotation]; !ok {
+			continue
+		}
+		entries = append(entries, catalogEntryFromAnnotations(desc.Annotations))
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s is not a bundle catalog, it lists no bundles", tag)
+	}
+	return entries, nil
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func Test_catalogEntryAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		entry       catalogEntry
+	}{
+		{
+			name:        "Full",
+			description: "every field survives the round trip through annotations",
+			entry: catalogEntry{
+				Name:         "app",
+				Version:      "0.1.0",
+				Architecture: "amd64",
+				Description:  "an app",
+				Ref:          "ghcr.io/my-org/bundles/app:0.1.0-amd64",
+				Digest:       "sha256:abc",
+			},
+		},
+		{
+			name:        "NoDescription",
+			description: "bundles without a description round trip without one",
+			entry: catalogEntry{
+				Name:         "app",
+				Version:      "0.1.0",
+				Architecture: "arm64",
+				Ref:          "ghcr.io/my-org/bundles/app:0.1.0-arm64",
+				Digest:       "sha256:def",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := catalogEntryFromAnnotations(tt.entry.annotations()); !reflect.DeepEqual(got, tt.entry) {
+				t.Errorf("catalogEntryFromAnnotations() = %+v, want %+v (%s)", got, tt.entry, tt.description)
+			}
+		})
+	}
+}
+
+func Test_pushCatalog(t *testing.T) {
+	ctx := context.TODO()
+	store := memory.New()
+	entries := []catalogEntry{
+		{Name: "app", Version: "0.1.0", Architecture: "amd64", Ref: "localhost:5000/app:0.1.0-amd64", Digest: "sha256:abc"},
+		{Name: "app", Version: "0.1.0", Architecture: "arm64", Ref: "localhost:5000/app:0.1.0-arm64", Digest: "sha256:def"},
+	}
+
+	first, err := pushCatalog(ctx, store, "latest", entries)
+	if err != nil {
+		t.Fatalf("pushCatalog() error = %v", err)
+	}
+	got, err := listCatalog(ctx, store, "latest")
+	if err != nil {
+		t.Fatalf("listCatalog() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("listCatalog() = %+v, want %+v", got, entries)
+	}
+
+	// pushing the same bundles again reuses the blobs already in the store
+	second, err := pushCatalog(ctx, store, "latest", entries)
+	if err != nil {
+		t.Fatalf("pushCatalog() again error = %v", err)
+	}
+	if first.Digest != second.Digest {
+		t.Errorf("pushCatalog() of the same bundles = %s, want %s", second.Digest, first.Digest)
+	}
+
+	if _, err := pushCatalog(ctx, store, "older", entries[:1]); err != nil {
+		t.Fatalf("pushCatalog() older error = %v", err)
+	}
+	if got, err := listCatalog(ctx, store, "older"); err != nil || len(got) != 1 {
+		t.Errorf("listCatalog() older = %+v, %v, want one entry", got, err)
+	}
+}
+
+func Test_listCatalogNotACatalog(t *testing.T) {
+	ctx := context.TODO()
+	store := memory.New()
+
+	// an index without bundle annotations, like a multi-arch image
+	index := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`)
+	desc, err := pushCatalogBlob(ctx, store, ocispec.MediaTypeImageIndex, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, desc, "index"); err != nil {
+		t.Fatal(err)
+	}
+	configDesc, err := pushCatalogBlob(ctx, store, ocispec.MediaTypeImageConfig, []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := json.Marshal(ocispec.Manifest{Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: ocispec.MediaTypeImageManifest, Config: configDesc, Layers: []ocispec.Descriptor{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err = pushCatalogBlob(ctx, store, ocispec.MediaTypeImageManifest, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, desc, "image"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := listCatalog(ctx, store, "image"); err == nil {
+		t.Errorf("listCatalog() of a manifest should fail")
+	}
+	if _, err := listCatalog(ctx, store, "index"); err == nil {
+		t.Errorf("listCatalog() of an index without bundles should fail")
+	}
+	if _, err := listCatalog(ctx, store, "missing"); err == nil {
+		t.Errorf("listCatalog() of a missing tag should fail")
+	}
+}
@@ -9,21 +9,40 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/corang/uds-cli/src/pkg/bundler"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
 	zarfConfig "github.com/defenseunicorns/zarf/src/config"
 	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/packager"
-	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
 	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
 	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/docker/go-units"
+	"github.com/opencontainers/go-digest"
 )
 
+// platformVariantRegex matches the CPU variants used in OCI platform descriptors (ie. v7, v8)
+var platformVariantRegex = regexp.MustCompile(`^v[0-9]+$`)
+
+// bundleNameRegex matches bundle names, they end up in tarball names and registry refs so they must be a valid
+// repository path component: lowercase letters, numbers and hyphens, starting and ending with a letter or number
+var bundleNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// invalidBundleNameChars matches the runs of characters normalizeBundleName replaces with a hyphen
+var invalidBundleNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sha256Regex matches the expectedSha256 of local packages, a hex digest with an optional sha256: prefix
+var sha256Regex = regexp.MustCompile(`^(sha256:)?[a-fA-F0-9]{64}$`)
+
 // Bundler handles bundler operations
 type Bundler struct {
 	// cfg is the Bundler's configuration options
@@ -32,6 +51,11 @@ type Bundler struct {
 	bundle types.UDSBundle
 	// tmp is the temporary directory used by the Bundler cleaned up with ClearPaths()
 	tmp string
+	// layout is the directory of the OCI layout a bundle tarball is created in, tmp unless --store-backend places it
+	// elsewhere
+	layout string
+	// stream is the named pipe, or - for stdout, that --output streams the bundle tarball to instead of a registry
+	stream string
 }
 
 // New creates a new Bundler
@@ -48,25 +72,129 @@ func New(cfg *types.BundlerConfig) (*Bundler, error) {
 		}
 	)
 
-	tmp, err := utils.MakeTempDir()
+	tmp, err := zarfUtils.MakeTempDir()
 	if err != nil {
 		return nil, fmt.Errorf("bundler unable to create temp directory: %w", err)
 	}
 	bundler.tmp = tmp
 
+	// commands resolve the architecture at startup, anything else calling the bundler gets it resolved here
+	if cfg.Arch.Host == "" {
+		cfg.Arch = config.NewArchContext(config.CLIArch)
+	}
+
 	return bundler, nil
 }
 
-// NewOrDie creates a new Bundler or dies
-func NewOrDie(cfg *types.BundlerConfig) *Bundler {
-	var (
-		err     error
-		bundler *Bundler
-	)
-	if bundler, err = New(cfg); err != nil {
-		message.Fatalf(err, "bundler unable to setup, bad config: %s", err.Error())
+// decryptSource downloads, reassembles and decrypts a bundle tarball into the Bundler's tmp dir as needed and returns
+// the path to use as the bundle source
+func (b *Bundler) decryptSource(source string, decrypt bool, identityPath string) (string, error) {
+	source, err := b.fetchSource(source)
+	if err != nil {
+		return "", err
+	}
+	if !decrypt && !utils.IsEncrypted(source) {
+		return source, nil
+	}
+	if helpers.IsOCIURL(source) {
+		return "", fmt.Errorf("bundles in an OCI registry are not encrypted, remove --decrypt to use %s", source)
+	}
+
+	decryptSpinner := message.NewProgressSpinner("Decrypting bundle archive")
+	defer decryptSpinner.Stop()
+
+	decryptDir := filepath.Join(b.tmp, "decrypted")
+	if err := zarfUtils.CreateDirectory(decryptDir, 0700); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(decryptDir, strings.TrimSuffix(filepath.Base(source), utils.EncryptedSuffix))
+	if err := utils.DecryptFile(source, dst, identityPath); err != nil {
+		return "", err
+	}
+
+	decryptSpinner.Successf("Decrypted bundle archive")
+	return dst, nil
+}
+
+// fetchSource downloads a bundle tarball from an s3:// or gs:// URL and reassembles a split one into the Bundler's tmp
+// dir, returning the path to use as the bundle source
+func (b *Bundler) fetchSource(source string) (string, error) {
+	source, err := b.downloadSource(source)
+	if err != nil {
+		return "", err
+	}
+	return b.joinSource(source)
+}
+
+// downloadSource downloads a bundle tarball from an s3:// or gs:// URL into the Bundler's tmp dir and returns its path,
+// other sources are returned as-is
+func (b *Bundler) downloadSource(source string) (string, error) {
+	if !utils.IsObjectStoreURL(source) {
+		return source, nil
+	}
+
+	downloadSpinner := message.NewProgressSpinner("Downloading %s", source)
+	defer downloadSpinner.Stop()
+
+	downloadDir := filepath.Join(b.tmp, "downloaded")
+	if err := zarfUtils.CreateDirectory(downloadDir, 0700); err != nil {
+		return "", err
 	}
-	return bundler
+	dst, err := utils.DownloadObject(utils.NetworkContext(), source, downloadDir, b.cfg.ObjectStoreOpts)
+	if err != nil {
+		return "", err
+	}
+
+	downloadSpinner.Successf("Downloaded %s", source)
+	return dst, nil
+}
+
+// joinSource reassembles a split bundle tarball into the Bundler's tmp dir and returns the path to use as the bundle
+// source, any part or the parts manifest can be given as the source
+func (b *Bundler) joinSource(source string) (string, error) {
+	if helpers.IsOCIURL(source) || !utils.IsSplitTarball(source) {
+		return source, nil
+	}
+
+	joinSpinner := message.NewProgressSpinner("Reassembling split bundle archive")
+	defer joinSpinner.Stop()
+
+	joinDir := filepath.Join(b.tmp, "joined")
+	if err := zarfUtils.CreateDirectory(joinDir, 0700); err != nil {
+		return "", err
+	}
+	dst, err := utils.JoinParts(utils.PartsManifestPath(source), joinDir)
+	if err != nil {
+		return "", err
+	}
+
+	joinSpinner.Successf("Reassembled split bundle archive")
+	return dst, nil
+}
+
+// parseSplitSize parses --split-size as a decimal size (ie. 4GB is 4,000,000,000 bytes, which fits on FAT32)
+func parseSplitSize(size string) (int64, error) {
+	partSize, err := units.FromHumanSize(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --split-size %q: %w", size, err)
+	}
+	if partSize <= 0 {
+		return 0, fmt.Errorf("invalid --split-size %q, it must be greater than 0", size)
+	}
+	return partSize, nil
+}
+
+// zarfPackageTarballName returns the file name Zarf gives a local package tarball
+func zarfPackageTarballName(name, arch, ref string) string {
+	if name == zarfInitPackageName {
+		return fmt.Sprintf("zarf-%s-%s-%s.tar.zst", name, arch, ref)
+	}
+	return fmt.Sprintf("zarf-package-%s-%s-%s.tar.zst", name, arch, ref)
+}
+
+// bundleTarballName returns the file name of a bundle tarball
+func bundleTarballName(metadata *types.UDSMetadata) string {
+	return fmt.Sprintf("%s%s-%s-%s.tar.zst", config.BundlePrefix, metadata.Name, metadata.Architecture, metadata.Version)
 }
 
 // ClearPaths clears out the paths used by Bundler
@@ -75,6 +203,11 @@ func (b *Bundler) ClearPaths() {
 	_ = os.RemoveAll(zarfConfig.ZarfSBOMDir)
 }
 
+// sbomCacheDir is the dir in the Zarf cache package sboms.tar layers are cached in by digest
+func sbomCacheDir() string {
+	return filepath.Join(zarfConfig.GetAbsCachePath(), config.SBOMCacheDir)
+}
+
 // ValidateBundleResources validates the bundle's metadata and package references
 func (b *Bundler) ValidateBundleResources(bundle *types.UDSBundle, spinner *message.Spinner) error {
 	// TODO: need to validate arch of local OS
@@ -93,16 +226,37 @@ func (b *Bundler) ValidateBundleResources(bundle *types.UDSBundle, spinner *mess
 	if bundle.Metadata.Name == "" {
 		return fmt.Errorf("%s is missing required field: metadata.name", config.BundleYAML)
 	}
+	if err := validateBundleName(bundle.Metadata.Name); err != nil {
+		return err
+	}
 
-	if len(bundle.ZarfPackages) == 0 {
+	if bundle.Metadata.PlatformVariant != "" && !platformVariantRegex.MatchString(bundle.Metadata.PlatformVariant) {
+		return fmt.Errorf("%s has an invalid metadata.platformVariant: %s, must be of the form v7, v8, etc", config.BundleYAML, bundle.Metadata.PlatformVariant)
+	}
+
+	if len(bundle.ZarfPackages) == 0 && !b.cfg.CreateOpts.AllowEmpty {
 		return fmt.Errorf("%s is missing required list: packages", config.BundleYAML)
 	}
 
-	if err := validateBundleVars(bundle.ZarfPackages); err != nil {
+	// vars flow between packages in deploy order
+	deployOrder, err := sortPackagesByDependencies(bundle.ZarfPackages)
+	if err != nil {
+		return err
+	}
+	if err := validateBundleVars(deployOrder); err != nil {
 		return fmt.Errorf("error validating bundle vars: %s", err)
 	}
 
-	tmp, err := utils.MakeTempDir()
+	policy, err := loadImagePolicy(b.cfg.CreateOpts.ImagePolicy, b.cfg.CreateOpts.DenyImages)
+	if err != nil {
+		return err
+	}
+	// every violation across the bundle is reported at once instead of failing on the first
+	violations := []imageViolation{}
+	// so are the images remote packages are missing, local packages are checked once they're in the bundle's store
+	missing := []missingImage{}
+
+	tmp, err := zarfUtils.MakeTempDir()
 	if err != nil {
 		return err
 	}
@@ -111,11 +265,11 @@ func (b *Bundler) ValidateBundleResources(bundle *types.UDSBundle, spinner *mess
 	for idx, pkg := range bundle.ZarfPackages {
 		spinner.Updatef("Validating Bundle Package: %s", pkg.Name)
 		if pkg.Name == "" {
-			return fmt.Errorf("%s is missing required field: name", pkg)
+			return fmt.Errorf("%s .packages[%d] is missing required field: name", config.BundleYAML, idx)
 		}
 
 		if pkg.Repository == "" && pkg.Path == "" {
-			return fmt.Errorf("zarf pkg %s must have either a repository or path field", pkg.Name)
+			return fmt.Errorf("zarf pkg %s must have either a repository, path, git or source field", pkg.Name)
 		}
 
 		if pkg.Repository != "" && pkg.Path != "" {
@@ -125,12 +279,26 @@ func (b *Bundler) ValidateBundleResources(bundle *types.UDSBundle, spinner *mess
 		if pkg.Ref == "" {
 			return fmt.Errorf("%s .packages[%s] is missing required field: ref", config.BundleYAML, pkg.Repository)
 		}
+
+		if len(pkg.BuildArgs) > 0 {
+			return fmt.Errorf("zarf pkg %s: buildArgs only apply to packages built from a git source", pkg.Name)
+		}
+		if pkg.ExpectedSha256 != "" && !sha256Regex.MatchString(pkg.ExpectedSha256) {
+			return fmt.Errorf("zarf pkg %s has an invalid expectedSha256 %q, it must be a SHA256 hex digest", pkg.Name, pkg.ExpectedSha256)
+		}
+		if pkg.ExpectedSha256 != "" && pkg.Repository != "" {
+			return fmt.Errorf("zarf pkg %s: expectedSha256 only applies to local package tarballs, pin remote packages with a digest ref", pkg.Name)
+		}
+		if err := validateReadinessChecks(pkg); err != nil {
+			return err
+		}
 		zarfYAML := zarfTypes.ZarfPackage{}
 		var url string
+		var remoteSrc *oci.OrasRemote
 		// if using a remote repository
 		if pkg.Repository != "" {
 			url = fmt.Sprintf("%s:%s-%s", pkg.Repository, pkg.Ref, bundle.Metadata.Architecture)
-			if strings.Contains(pkg.Ref, "@sha256:") {
+			if strings.Contains(pkg.Ref, "@") {
 				url = fmt.Sprintf("%s:%s", pkg.Repository, pkg.Ref)
 			}
 			remotePkg, err := bundler.NewRemoteBundler(pkg, url, nil, nil)
@@ -138,25 +306,29 @@ func (b *Bundler) ValidateBundleResources(bundle *types.UDSBundle, spinner *mess
 				return err
 			}
 			if err := remotePkg.RemoteSrc.Repo().Reference.ValidateReferenceAsDigest(); err != nil {
-				manifestDesc, _ := remotePkg.RemoteSrc.ResolveRoot()
-				bundle.ZarfPackages[idx].Ref = pkg.Ref + "-" + bundle.Metadata.Architecture + "@sha256:" + manifestDesc.Digest.Encoded()
+				manifestDesc, err := remotePkg.RemoteSrc.ResolveRoot()
+				if err != nil {
+					return fmt.Errorf("unable to resolve the digest of zarf pkg %s: %w", pkg.Name, err)
+				}
+				bundle.ZarfPackages[idx].Ref = pkg.Ref + "-" + bundle.Metadata.Architecture + "@" + manifestDesc.Digest.String()
 			}
 			zarfYAML, err = remotePkg.GetMetadata(url, tmp)
 			if err != nil {
 				return err
 			}
+			remoteSrc = remotePkg.RemoteSrc
 		} else {
 			// atm we don't support outputting a bundle with local pkgs outputting to OCI
 			if b.cfg.CreateOpts.Output != "" {
 				return fmt.Errorf("detected local Zarf package: %s, outputting to an OCI registry is not supported when using local Zarf packages", pkg.Name)
 			}
-			var fullPkgName string
-			if pkg.Name == "init" {
-				fullPkgName = fmt.Sprintf("zarf-%s-%s-%s.tar.zst", pkg.Name, bundle.Metadata.Architecture, pkg.Ref)
-			} else {
-				fullPkgName = fmt.Sprintf("zarf-package-%s-%s-%s.tar.zst", pkg.Name, bundle.Metadata.Architecture, pkg.Ref)
+			// paths are either an unpacked package or a directory holding the package's tarball
+			path := pkg.Path
+			if !bundler.IsPackageDir(path) {
+				path = filepath.Join(pkg.Path, zarfPackageTarballName(pkg.Name, bundle.Metadata.Architecture, pkg.Ref))
+			} else if pkg.ExpectedSha256 != "" {
+				return fmt.Errorf("zarf pkg %s: expectedSha256 only applies to local package tarballs, not unpacked packages", pkg.Name)
 			}
-			path := filepath.Join(pkg.Path, fullPkgName)
 			bundle.ZarfPackages[idx].Path = path
 			p := bundler.NewLocalBundler(pkg.Path, tmp)
 			if err != nil {
@@ -174,7 +346,7 @@ func (b *Bundler) ValidateBundleResources(bundle *types.UDSBundle, spinner *mess
 
 		publicKeyPath := filepath.Join(b.tmp, config.PublicKeyFile)
 		if pkg.PublicKey != "" {
-			if err := utils.WriteFile(publicKeyPath, []byte(pkg.PublicKey)); err != nil {
+			if err := zarfUtils.WriteFile(publicKeyPath, []byte(pkg.PublicKey)); err != nil {
 				return err
 			}
 			defer os.Remove(publicKeyPath)
@@ -201,10 +373,129 @@ func (b *Bundler) ValidateBundleResources(bundle *types.UDSBundle, spinner *mess
 				}
 			}
 		}
+
+		if policy != nil {
+			spinner.Updatef("Checking the images of %s against the image policy", pkg.Name)
+			pkgViolations, err := policy.checkPackageImages(pkg, zarfYAML)
+			if err != nil {
+				return err
+			}
+			violations = append(violations, pkgViolations...)
+		}
+
+		if remoteSrc != nil {
+			spinner.Updatef("Checking the images of %s are in the package", pkg.Name)
+			root, err := remoteSrc.FetchRoot()
+			if err != nil {
+				return err
+			}
+			pkgMissing, err := findMissingImages(utils.NetworkContext(), remoteSrc.Repo(), root, pkg, zarfYAML)
+			if err != nil {
+				return err
+			}
+			missing = append(missing, pkgMissing...)
+		}
+	}
+
+	if len(violations) > 0 {
+		msgs := []string{fmt.Sprintf("%d image(s) are denied by the image policy:", len(violations))}
+		for _, violation := range violations {
+			msgs = append(msgs, " - "+violation.String())
+		}
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	if err := reportMissingImages(missing, b.cfg.CreateOpts.Strict); err != nil {
+		return err
+	}
+
+	// every remote package must now reference its manifest immutably
+	return validatePinnedRefs(bundle.ZarfPackages)
+}
+
+// sortPackagesByDependencies orders packages so each is deployed after the packages in its dependsOn,
+// packages without dependencies between them keep their order in the bundle
+func sortPackagesByDependencies(packages []types.BundleZarfPackage) ([]types.BundleZarfPackage, error) {
+	known := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		known[pkg.Name] = true
+	}
+	for _, pkg := range packages {
+		for _, dep := range pkg.DependsOn {
+			if !known[dep] {
+				return nil, fmt.Errorf("zarf pkg %s depends on %s, which is not in the bundle", pkg.Name, dep)
+			}
+			if dep == pkg.Name {
+				return nil, fmt.Errorf("zarf pkg %s cannot depend on itself", pkg.Name)
+			}
+		}
+	}
+
+	sorted := make([]types.BundleZarfPackage, 0, len(packages))
+	deployed := make(map[string]bool, len(packages))
+	for len(sorted) < len(packages) {
+		progressed := false
+		// take the first package in bundle order whose dependencies have all been deployed
+		for _, pkg := range packages {
+			if deployed[pkg.Name] {
+				continue
+			}
+			ready := true
+			for _, dep := range pkg.DependsOn {
+				if !deployed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				sorted = append(sorted, pkg)
+				deployed[pkg.Name] = true
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			cycle := []string{}
+			for _, pkg := range packages {
+				if !deployed[pkg.Name] {
+					cycle = append(cycle, pkg.Name)
+				}
+			}
+			return nil, fmt.Errorf("zarf pkgs have a dependency cycle: %s", strings.Join(cycle, ", "))
+		}
+	}
+	return sorted, nil
+}
+
+// validatePinnedRefs ensures each remote package's ref is pinned to a digest (ie. <tag>-<arch>@sha256:<digest>)
+func validatePinnedRefs(packages []types.BundleZarfPackage) error {
+	for _, pkg := range packages {
+		if pkg.Repository == "" {
+			continue
+		}
+		tag, pin, ok := strings.Cut(pkg.Ref, "@")
+		if !ok || tag == "" {
+			return fmt.Errorf("zarf pkg %s ref %q is not pinned to a tag and digest", pkg.Name, pkg.Ref)
+		}
+		if err := digest.Digest(pin).Validate(); err != nil {
+			return fmt.Errorf("zarf pkg %s ref %q has an invalid digest: %w", pkg.Name, pkg.Ref, err)
+		}
 	}
 	return nil
 }
 
+// pinnedDigest returns the digest create pinned a package's ref to (ie. <tag>-<arch>@sha256:<digest>)
+func pinnedDigest(pkg types.BundleZarfPackage) (digest.Digest, error) {
+	_, pin, ok := strings.Cut(pkg.Ref, "@")
+	if !ok {
+		return "", fmt.Errorf("zarf pkg %s ref %q is not pinned to a digest", pkg.Name, pkg.Ref)
+	}
+	dgst, err := digest.Parse(pin)
+	if err != nil {
+		return "", fmt.Errorf("zarf pkg %s ref %q has an invalid digest: %w", pkg.Name, pkg.Ref, err)
+	}
+	return dgst, nil
+}
+
 // validateBundleVars ensures imports and exports between Zarf pkgs match up
 func validateBundleVars(packages []types.BundleZarfPackage) error {
 	exports := make(map[string]string)
@@ -231,11 +522,31 @@ func validateBundleVars(packages []types.BundleZarfPackage) error {
 	return nil
 }
 
+// stripHistory blanks the metadata and build data that identify who built a bundle, where and from what source
+//
+// this also keeps their annotations off the root manifest, the packages' own zarf.yaml build data is left alone
+// since changing it would break their checksums and signatures
+func stripHistory(bundle *types.UDSBundle) {
+	bundle.Metadata.Authors = ""
+	bundle.Metadata.Source = ""
+	bundle.Build.User = ""
+	bundle.Build.Terminal = ""
+}
+
 // CalculateBuildInfo calculates the build info for the bundle
 //
 // this is mainly mirrored from packager.writeYaml()
 func (b *Bundler) CalculateBuildInfo() error {
-	now := time.Now()
+	// --created > SOURCE_DATE_EPOCH > time.Now() (default)
+	now, err := utils.BuildTime()
+	if err != nil {
+		return err
+	}
+	if b.cfg.CreateOpts.Created != "" {
+		if now, err = parseCreated(b.cfg.CreateOpts.Created); err != nil {
+			return err
+		}
+	}
 	b.bundle.Build.User = os.Getenv("USER")
 
 	hostname, err := os.Hostname()
@@ -245,7 +556,7 @@ func (b *Bundler) CalculateBuildInfo() error {
 	b.bundle.Build.Terminal = hostname
 
 	// --architecture flag > metadata.arch > build.arch / runtime.GOARCH (default)
-	b.bundle.Build.Architecture = config.GetArch(b.bundle.Metadata.Architecture, b.bundle.Build.Architecture)
+	b.bundle.Build.Architecture = b.cfg.Arch.Resolve(b.bundle.Metadata.Architecture, b.bundle.Build.Architecture)
 	b.bundle.Metadata.Architecture = b.bundle.Build.Architecture
 
 	b.bundle.Build.Timestamp = now.Format(time.RFC1123Z)
@@ -255,24 +566,123 @@ func (b *Bundler) CalculateBuildInfo() error {
 	return nil
 }
 
-// ValidateBundleSignature validates the bundle signature
-func ValidateBundleSignature(bundleYAMLPath, signaturePath, publicKeyPath string) error {
-	if utils.InvalidPath(bundleYAMLPath) {
-		return fmt.Errorf("path for %s at %s does not exist", config.BundleYAML, bundleYAMLPath)
+// validateBundleName ensures metadata.name can be used in tarball names and registry refs, suggesting a valid name when it can't
+func validateBundleName(name string) error {
+	if bundleNameRegex.MatchString(name) {
+		return nil
 	}
-	// The package is not signed, and no public key was provided
-	if signaturePath == "" && publicKeyPath == "" {
+	err := fmt.Errorf("%s has an invalid metadata.name %q: must be lowercase letters, numbers and hyphens, starting and ending with a letter or number", config.BundleYAML, name)
+	if suggestion := normalizeBundleName(name); suggestion != "" {
+		return fmt.Errorf("%w, try %q", err, suggestion)
+	}
+	return err
+}
+
+// normalizeBundleName lowercases name and replaces each run of other characters with a hyphen, ie. "My App/v2" is "my-app-v2"
+func normalizeBundleName(name string) string {
+	return strings.Trim(invalidBundleNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// parseCreated parses the RFC3339 timestamp given to --created
+func parseCreated(created string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, created)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --created %q: must be an RFC3339 timestamp (ie. 2023-07-22T04:26:40Z)", created)
+	}
+	return t.UTC(), nil
+}
+
+// validateSigAlgo ensures --sig-algo is an algorithm cosign signs with, empty infers it from the signing key
+func validateSigAlgo(algo string) error {
+	if algo == "" || helpers.SliceContains(config.SigAlgos, algo) {
 		return nil
 	}
+	return fmt.Errorf("invalid --sig-algo %q, must be one of: %s", algo, strings.Join(config.SigAlgos, ", "))
+}
+
+// signatureAlgorithm returns the algorithm recorded on the bundle manifest's signature layer, bundles signed before
+// the algorithm was recorded (and unsigned bundles) return an empty string
+func signatureAlgorithm(manifest *oci.ZarfOCIManifest) string {
+	return manifest.Locate(config.BundleYAMLSignature).Annotations[config.SigAlgoAnnotation]
+}
+
+// ValidateBundleSignature validates the bundle signature, it's valid if any of the trusted keys verifies it and the key
+// that did is returned (an empty key for an unsigned bundle given no keys), a non-empty sigAlgo must match the key's type
+func ValidateBundleSignature(bundleYAMLPath, signaturePath string, keys []utils.TrustedKey, sigAlgo string) (utils.TrustedKey, error) {
+	if zarfUtils.InvalidPath(bundleYAMLPath) {
+		return utils.TrustedKey{}, fmt.Errorf("path for %s at %s does not exist", config.BundleYAML, bundleYAMLPath)
+	}
+	// The package is not signed, and no public key was provided
+	if signaturePath == "" && len(keys) == 0 {
+		return utils.TrustedKey{}, nil
+	}
+	present := 0
+	for _, key := range keys {
+		if !zarfUtils.InvalidPath(key.Path) {
+			present++
+		}
+	}
 	// The package is not signed, but a public key was provided
-	if utils.InvalidPath(signaturePath) && !utils.InvalidPath(publicKeyPath) {
-		return fmt.Errorf("package is not signed, but a public key was provided")
+	if zarfUtils.InvalidPath(signaturePath) && present > 0 {
+		return utils.TrustedKey{}, fmt.Errorf("package is not signed, but a public key was provided")
 	}
 	// The package is signed, but no public key was provided
-	if !utils.InvalidPath(signaturePath) && utils.InvalidPath(publicKeyPath) {
-		return fmt.Errorf("package is signed, but no public key was provided")
+	if !zarfUtils.InvalidPath(signaturePath) && present == 0 {
+		return utils.TrustedKey{}, fmt.Errorf("package is signed, but no public key was provided")
 	}
 
-	// The package is signed, and a public key was provided
-	return utils.CosignVerifyBlob(bundleYAMLPath, signaturePath, publicKeyPath)
+	// The package is signed, and public keys were provided, during a key rotation any of them may have signed it
+	var errs []error
+	for _, key := range keys {
+		err := utils.CheckKeyAlgorithm(key.Path, sigAlgo)
+		if err == nil {
+			err = zarfUtils.CosignVerifyBlob(bundleYAMLPath, signaturePath, key.Path)
+		}
+		if err == nil {
+			return key, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(keys) == 1 {
+		return utils.TrustedKey{}, errs[0]
+	}
+	failures := make([]string, len(keys))
+	for i, key := range keys {
+		failures[i] = fmt.Sprintf("%s: %s", key.Source, errs[i].Error())
+	}
+	return utils.TrustedKey{}, fmt.Errorf("the bundle's signature doesn't verify with any of the %d trusted keys: %s", len(keys), strings.Join(failures, "; "))
+}
+
+// verifyBundleSignature validates the signature of the loaded bundle metadata against the trusted keys, reporting the
+// key it verified with when more than one was trusted
+func verifyBundleSignature(loaded PathMap, keys []utils.TrustedKey, sigAlgo string) (utils.TrustedKey, error) {
+	key, err := ValidateBundleSignature(loaded[config.BundleYAML], loaded[config.BundleYAMLSignature], keys, sigAlgo)
+	if err != nil {
+		return key, err
+	}
+	if len(keys) > 1 {
+		message.Successf("Bundle signature verified with %s", key.Source)
+	}
+	return key, nil
+}
+
+// validateMinUdsVersion ensures cliVersion is at least the bundle's metadata.minUdsVersion, development builds of the
+// CLI can't be compared and only warn
+func validateMinUdsVersion(metadata types.UDSMetadata, cliVersion string, skip bool) error {
+	if metadata.MinUdsVersion == "" || skip {
+		return nil
+	}
+	minVersion, err := semver.NewVersion(metadata.MinUdsVersion)
+	if err != nil {
+		return fmt.Errorf("bundle %s has an invalid minUdsVersion %q: %w", metadata.Name, metadata.MinUdsVersion, err)
+	}
+	current, err := semver.NewVersion(cliVersion)
+	if err != nil {
+		message.Warnf("Unable to check that this build of uds (%s) meets the minUdsVersion %s of bundle %s", cliVersion, metadata.MinUdsVersion, metadata.Name)
+		return nil
+	}
+	if current.LessThan(minVersion) {
+		return fmt.Errorf("bundle %s requires uds %s or later but this is uds %s, upgrade uds or use --skip-version-check to proceed anyway", metadata.Name, metadata.MinUdsVersion, cliVersion)
+	}
+	return nil
 }
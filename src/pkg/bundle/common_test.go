@@ -1,9 +1,23 @@
 package bundle
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"oras.land/oras-go/v2/registry/remote"
 )
 
 func Test_validateBundleVars(t *testing.T) {
@@ -67,3 +81,959 @@ func Test_validateBundleVars(t *testing.T) {
 		})
 	}
 }
+
+func Test_CalculateBuildInfoSourceDateEpoch(t *testing.T) {
+	t.Setenv(config.SourceDateEpochEnvVar, "1690000000")
+	b := Bundler{cfg: &types.BundlerConfig{Arch: types.ArchContext{Host: "amd64"}}}
+	if err := b.CalculateBuildInfo(); err != nil {
+		t.Fatalf("CalculateBuildInfo() error = %v", err)
+	}
+	want := time.Unix(1690000000, 0).UTC().Format(time.RFC1123Z)
+	if b.bundle.Build.Timestamp != want {
+		t.Errorf("Build.Timestamp = %s, want %s", b.bundle.Build.Timestamp, want)
+	}
+	annotations := manifestAnnotationsFromMetadata(&b.bundle.Metadata, &b.bundle.Build)
+	if got := annotations[ocispec.AnnotationCreated]; got != "2023-07-22T04:26:40Z" {
+		t.Errorf("%s annotation = %s, want 2023-07-22T04:26:40Z", ocispec.AnnotationCreated, got)
+	}
+}
+
+func Test_CalculateBuildInfoCreated(t *testing.T) {
+	t.Setenv(config.SourceDateEpochEnvVar, "1690000000")
+	tests := []struct {
+		name        string
+		description string
+		created     string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "UTC",
+			description: "--created wins over SOURCE_DATE_EPOCH",
+			created:     "2024-01-02T03:04:05Z",
+			want:        "2024-01-02T03:04:05Z",
+		},
+		{
+			name:        "Offset",
+			description: "timestamps with an offset are stamped in UTC",
+			created:     "2024-01-02T03:04:05-05:00",
+			want:        "2024-01-02T08:04:05Z",
+		},
+		{
+			name:        "Invalid",
+			description: "timestamps must be RFC3339",
+			created:     "Jan 2 2024",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Bundler{cfg: &types.BundlerConfig{Arch: types.ArchContext{Host: "amd64"}, CreateOpts: types.BundlerCreateOptions{Created: tt.created}}}
+			err := b.CalculateBuildInfo()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateBuildInfo() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			annotations := manifestAnnotationsFromMetadata(&b.bundle.Metadata, &b.bundle.Build)
+			if got := annotations[ocispec.AnnotationCreated]; got != tt.want {
+				t.Errorf("%s annotation = %s, want %s (%s)", ocispec.AnnotationCreated, got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_CalculateBuildInfoArch(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		arch        types.ArchContext
+		metadata    string
+		build       string
+		want        string
+	}{
+		{
+			name:        "Override",
+			description: "--architecture wins over the bundle's own architecture",
+			arch:        types.ArchContext{Override: "arm64", Host: "amd64"},
+			metadata:    "amd64",
+			want:        "arm64",
+		},
+		{
+			name:        "Metadata",
+			description: "metadata.architecture wins over build.architecture and the host",
+			arch:        types.ArchContext{Host: "amd64"},
+			metadata:    "arm64",
+			build:       "amd64",
+			want:        "arm64",
+		},
+		{
+			name:        "Build",
+			description: "build.architecture is used when there is no metadata.architecture",
+			arch:        types.ArchContext{Host: "amd64"},
+			build:       "arm64",
+			want:        "arm64",
+		},
+		{
+			name:        "Host",
+			description: "the host's architecture is the fallback",
+			arch:        types.ArchContext{Host: "arm64"},
+			want:        "arm64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Bundler{cfg: &types.BundlerConfig{Arch: tt.arch}}
+			b.bundle.Metadata.Architecture = tt.metadata
+			b.bundle.Build.Architecture = tt.build
+			if err := b.CalculateBuildInfo(); err != nil {
+				t.Fatalf("CalculateBuildInfo() error = %v", err)
+			}
+			if b.bundle.Metadata.Architecture != tt.want || b.bundle.Build.Architecture != tt.want {
+				t.Errorf("CalculateBuildInfo() architecture = %s, %s, want %s (%s)", b.bundle.Metadata.Architecture, b.bundle.Build.Architecture, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_stripHistory(t *testing.T) {
+	bundle := types.UDSBundle{
+		Metadata: types.UDSMetadata{
+			Name:          "example",
+			Description:   "an example bundle",
+			Authors:       "Doug <hello@defenseunicorns.com>",
+			Source:        "https://git.internal.example.com/bundles/example",
+			Documentation: "https://docs.example.com",
+		},
+		Build: types.UDSBuildData{
+			Terminal:     "build-host-01.internal",
+			User:         "doug",
+			Architecture: "amd64",
+			Timestamp:    "Sat, 22 Jul 2023 04:26:40 +0000",
+			Version:      "v0.1.0",
+		},
+	}
+	stripHistory(&bundle)
+
+	want := types.UDSBuildData{Architecture: "amd64", Timestamp: "Sat, 22 Jul 2023 04:26:40 +0000", Version: "v0.1.0"}
+	if bundle.Build != want {
+		t.Errorf("stripHistory() build = %+v, want %+v", bundle.Build, want)
+	}
+	annotations := manifestAnnotationsFromMetadata(&bundle.Metadata, &bundle.Build)
+	for _, annotation := range []string{ocispec.AnnotationAuthors, ocispec.AnnotationSource} {
+		if value, ok := annotations[annotation]; ok {
+			t.Errorf("stripHistory() kept the %s annotation: %s", annotation, value)
+		}
+	}
+	for _, annotation := range []string{ocispec.AnnotationDescription, ocispec.AnnotationDocumentation, ocispec.AnnotationCreated} {
+		if _, ok := annotations[annotation]; !ok {
+			t.Errorf("stripHistory() removed the %s annotation", annotation)
+		}
+	}
+}
+
+func Test_validatePinnedRefs(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		packages    []types.BundleZarfPackage
+		wantErr     bool
+	}{
+		{
+			name:        "RemotePinned",
+			description: "remote ref with a tag and digest",
+			packages:    []types.BundleZarfPackage{{Name: "foo", Repository: "localhost:888/foo", Ref: "0.0.1-amd64@sha256:" + strings.Repeat("a", 64)}},
+			wantErr:     false,
+		}, {
+			name:        "RemoteTagOnly",
+			description: "remote ref that is still a mutable tag",
+			packages:    []types.BundleZarfPackage{{Name: "foo", Repository: "localhost:888/foo", Ref: "0.0.1"}},
+			wantErr:     true,
+		}, {
+			name:        "RemoteEmptyDigest",
+			description: "remote ref whose digest failed to resolve",
+			packages:    []types.BundleZarfPackage{{Name: "foo", Repository: "localhost:888/foo", Ref: "0.0.1-amd64@"}},
+			wantErr:     true,
+		}, {
+			name:        "RemoteDigestOnly",
+			description: "remote ref without the human-readable tag",
+			packages:    []types.BundleZarfPackage{{Name: "foo", Repository: "localhost:888/foo", Ref: "@sha256:" + strings.Repeat("a", 64)}},
+			wantErr:     true,
+		}, {
+			name:        "LocalIgnored",
+			description: "local packages are pinned when they are bundled",
+			packages:    []types.BundleZarfPackage{{Name: "foo", Path: "../packages", Ref: "0.0.1"}},
+			wantErr:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePinnedRefs(tt.packages); (err != nil) != tt.wantErr {
+				t.Errorf("validatePinnedRefs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_pinnedDigest(t *testing.T) {
+	sha256Digest := digest.FromString("package")
+	sha512Digest := digest.SHA512.FromString("package")
+	tests := []struct {
+		name        string
+		description string
+		ref         string
+		want        digest.Digest
+		wantErr     bool
+	}{
+		{
+			name:        "SHA256",
+			description: "refs are pinned to sha256 digests by default",
+			ref:         "0.0.1-amd64@" + sha256Digest.String(),
+			want:        sha256Digest,
+		},
+		{
+			name:        "SHA512",
+			description: "packages with sha512 digests are pinned to them",
+			ref:         "0.0.1-amd64@" + sha512Digest.String(),
+			want:        sha512Digest,
+		},
+		{
+			name:        "Unpinned",
+			description: "a ref without a digest is refused instead of panicking",
+			ref:         "0.0.1-amd64",
+			wantErr:     true,
+		},
+		{
+			name:        "Malformed",
+			description: "a ref with a malformed digest is refused",
+			ref:         "0.0.1-amd64@sha512:abc",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pinnedDigest(types.BundleZarfPackage{Name: "foo", Ref: tt.ref})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pinnedDigest() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if got != tt.want {
+				t.Errorf("pinnedDigest() = %s, want %s (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_sortPackagesByDependencies(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		packages    []types.BundleZarfPackage
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "NoDependencies",
+			description: "packages keep their bundle order",
+			packages:    []types.BundleZarfPackage{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			want:        []string{"a", "b", "c"},
+		}, {
+			name:        "LinearChain",
+			description: "c -> b -> a listed in reverse deploys a, b, c",
+			packages: []types.BundleZarfPackage{
+				{Name: "c", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "a"},
+			},
+			want: []string{"a", "b", "c"},
+		}, {
+			name:        "Diamond",
+			description: "app depends on api and worker which both depend on db",
+			packages: []types.BundleZarfPackage{
+				{Name: "app", DependsOn: []string{"api", "worker"}},
+				{Name: "api", DependsOn: []string{"db"}},
+				{Name: "worker", DependsOn: []string{"db"}},
+				{Name: "db"},
+			},
+			want: []string{"db", "api", "worker", "app"},
+		}, {
+			name:        "Cycle",
+			description: "error when packages depend on each other",
+			packages: []types.BundleZarfPackage{
+				{Name: "a", DependsOn: []string{"c"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c", DependsOn: []string{"b"}},
+			},
+			wantErr: true,
+		}, {
+			name:        "SelfCycle",
+			description: "error when a package depends on itself",
+			packages:    []types.BundleZarfPackage{{Name: "a", DependsOn: []string{"a"}}},
+			wantErr:     true,
+		}, {
+			name:        "UnknownDependency",
+			description: "error when a dependency isn't in the bundle",
+			packages:    []types.BundleZarfPackage{{Name: "a", DependsOn: []string{"missing"}}},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := sortPackagesByDependencies(tt.packages)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sortPackagesByDependencies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := []string{}
+			for _, pkg := range sorted {
+				got = append(got, pkg.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sortPackagesByDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_packageMirrorRepository(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		repoPrefix  string
+		repository  string
+		want        string
+	}{
+		{
+			name:        "Prefix",
+			description: "the package keeps the last element of its repository",
+			repoPrefix:  "registry.example.com/mirror",
+			repository:  "ghcr.io/defenseunicorns/packages/init",
+			want:        "registry.example.com/mirror/init",
+		}, {
+			name:        "OCIPrefix",
+			description: "oci:// and trailing slashes are trimmed from the prefix",
+			repoPrefix:  "oci://registry.example.com/mirror/",
+			repository:  "localhost:5000/podinfo",
+			want:        "registry.example.com/mirror/podinfo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := types.BundleZarfPackage{Name: "test", Repository: tt.repository}
+			if got := packageMirrorRepository(tt.repoPrefix, pkg); got != tt.want {
+				t.Errorf("packageMirrorRepository() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mergeIncludedPackages(t *testing.T) {
+	base := types.BundleZarfPackage{Name: "base", Repository: "localhost:5000/base", Ref: "0.0.1-amd64@sha256:aaa"}
+	app := types.BundleZarfPackage{Name: "app", Repository: "localhost:5000/app", Ref: "0.0.1-amd64@sha256:bbb"}
+	db := types.BundleZarfPackage{Name: "db", Repository: "localhost:5000/db", Ref: "0.0.1-amd64@sha256:ddd"}
+	newBase := types.BundleZarfPackage{Name: "base", Repository: "localhost:5000/base", Ref: "0.0.2-amd64@sha256:ccc"}
+	// refs in the bundle's own uds-bundle.yaml aren't pinned until after includes are merged
+	unpinnedBase := types.BundleZarfPackage{Name: "base", Repository: "localhost:5000/base", Ref: "0.0.1"}
+	unpinnedNewBase := types.BundleZarfPackage{Name: "base", Repository: "localhost:5000/base", Ref: "0.0.2"}
+	resolved := map[string]digest.Digest{"0.0.1": "sha256:aaa", "0.0.2": "sha256:ccc"}
+	resolve := func(pkg types.BundleZarfPackage) (digest.Digest, error) {
+		if _, pin, ok := strings.Cut(pkg.Ref, "@"); ok {
+			return digest.Digest(pin), nil
+		}
+		if dgst, ok := resolved[pkg.Ref]; ok {
+			return dgst, nil
+		}
+		return "", fmt.Errorf("%s not found", pkg.Ref)
+	}
+	tests := []struct {
+		name        string
+		description string
+		own         []types.BundleZarfPackage
+		includes    [][]types.BundleZarfPackage
+		strategy    string
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "IncludedFirst",
+			description: "included packages deploy before the bundle's own packages",
+			own:         []types.BundleZarfPackage{app},
+			includes:    [][]types.BundleZarfPackage{{base}},
+			strategy:    config.IncludeStrategyError,
+			want:        []string{"base:0.0.1-amd64@sha256:aaa", "app:0.0.1-amd64@sha256:bbb"},
+		}, {
+			name:        "IncludesInOrder",
+			description: "each include's packages deploy after the packages of the includes before it",
+			own:         []types.BundleZarfPackage{app},
+			includes:    [][]types.BundleZarfPackage{{base}, {db}},
+			strategy:    config.IncludeStrategyError,
+			want:        []string{"base:0.0.1-amd64@sha256:aaa", "db:0.0.1-amd64@sha256:ddd", "app:0.0.1-amd64@sha256:bbb"},
+		}, {
+			name:        "IncludesSameDigest",
+			description: "a package in several includes with the same digest is only bundled once, where it was first included",
+			own:         []types.BundleZarfPackage{app},
+			includes:    [][]types.BundleZarfPackage{{base}, {db, base}},
+			strategy:    config.IncludeStrategyError,
+			want:        []string{"base:0.0.1-amd64@sha256:aaa", "db:0.0.1-amd64@sha256:ddd", "app:0.0.1-amd64@sha256:bbb"},
+		}, {
+			name:        "IncludesConflict",
+			description: "error when two includes have a package with the same name but a different digest",
+			own:         []types.BundleZarfPackage{app},
+			includes:    [][]types.BundleZarfPackage{{base}, {newBase}},
+			strategy:    config.IncludeStrategyOverride,
+			wantErr:     true,
+		}, {
+			name:        "SameDigest",
+			description: "a package that is both included and listed with the same digest is only bundled once",
+			own:         []types.BundleZarfPackage{base, app},
+			includes:    [][]types.BundleZarfPackage{{base}},
+			strategy:    config.IncludeStrategyError,
+			want:        []string{"base:0.0.1-amd64@sha256:aaa", "app:0.0.1-amd64@sha256:bbb"},
+		}, {
+			name:        "Conflict",
+			description: "error when an included package has the same name but a different digest",
+			own:         []types.BundleZarfPackage{newBase, app},
+			includes:    [][]types.BundleZarfPackage{{base}},
+			strategy:    config.IncludeStrategyError,
+			wantErr:     true,
+		}, {
+			name:        "Override",
+			description: "the bundle's own package wins a conflict when overriding",
+			own:         []types.BundleZarfPackage{newBase, app},
+			includes:    [][]types.BundleZarfPackage{{base}},
+			strategy:    config.IncludeStrategyOverride,
+			want:        []string{"base:0.0.2-amd64@sha256:ccc", "app:0.0.1-amd64@sha256:bbb"},
+		}, {
+			name:        "UnpinnedSameDigest",
+			description: "an unpinned package that resolves to the included package's digest is only bundled once",
+			own:         []types.BundleZarfPackage{unpinnedBase, app},
+			includes:    [][]types.BundleZarfPackage{{base}},
+			strategy:    config.IncludeStrategyError,
+			want:        []string{"base:0.0.1", "app:0.0.1-amd64@sha256:bbb"},
+		}, {
+			name:        "UnpinnedConflict",
+			description: "error when an unpinned package resolves to a different digest than the included package",
+			own:         []types.BundleZarfPackage{unpinnedNewBase, app},
+			includes:    [][]types.BundleZarfPackage{{base}},
+			strategy:    config.IncludeStrategyError,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var included []types.BundleZarfPackage
+			var err error
+			for _, include := range tt.includes {
+				if included, err = appendIncludedPackages(included, include, resolve); err != nil {
+					break
+				}
+			}
+			var merged []types.BundleZarfPackage
+			if err == nil {
+				merged, err = mergeIncludedPackages(tt.own, included, tt.strategy, resolve)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergeIncludedPackages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, pkg := range merged {
+				got = append(got, pkg.Name+":"+pkg.Ref)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("mergeIncludedPackages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_normalizeRegistryRef(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		ref         string
+		style       string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "HarborWithoutProject",
+			description: "error when a harbor ref has no project",
+			ref:         "oci://harbor.example.com/bundle:0.0.1-amd64",
+			style:       config.RegistryStyleHarbor,
+			wantErr:     true,
+		}, {
+			name:        "HarborWithProject",
+			description: "harbor refs with a project are unchanged",
+			ref:         "oci://harbor.example.com/uds/bundle:0.0.1-amd64",
+			style:       config.RegistryStyleHarbor,
+			want:        "harbor.example.com/uds/bundle:0.0.1-amd64",
+		}, {
+			name:        "DockerHub",
+			description: "docker.io refs are pointed at the Docker Hub registry host",
+			ref:         "docker.io/corang/bundle:0.0.1-amd64",
+			style:       config.RegistryStyleAuto,
+			want:        "registry-1.docker.io/corang/bundle:0.0.1-amd64",
+		}, {
+			name:        "Lowercase",
+			description: "the repository is lowercased and the tag is kept",
+			ref:         "ghcr.io/Corang/MyBundle:0.0.1-RC1-amd64",
+			style:       config.RegistryStyleAuto,
+			want:        "ghcr.io/corang/mybundle:0.0.1-RC1-amd64",
+		}, {
+			name:        "ArtifactRegistryTooShort",
+			description: "error when an artifact registry ref has no repository",
+			ref:         "us-docker.pkg.dev/project/bundle:0.0.1-amd64",
+			style:       config.RegistryStyleAuto,
+			wantErr:     true,
+		}, {
+			name:        "ArtifactRegistry",
+			description: "artifact registry refs with a project and repository are unchanged",
+			ref:         "us-docker.pkg.dev/project/repo/bundle:0.0.1-amd64",
+			style:       config.RegistryStyleAuto,
+			want:        "us-docker.pkg.dev/project/repo/bundle:0.0.1-amd64",
+		}, {
+			name:        "ECRTooLong",
+			description: "error when an ECR repository name is over 256 characters",
+			ref:         "public.ecr.aws/" + strings.Repeat("a", 257) + ":0.0.1-amd64",
+			style:       config.RegistryStyleAuto,
+			wantErr:     true,
+		}, {
+			name:        "Generic",
+			description: "generic registries allow a single path component",
+			ref:         "localhost:888/bundle:0.0.1-amd64",
+			style:       config.RegistryStyleGeneric,
+			want:        "localhost:888/bundle:0.0.1-amd64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeRegistryRef(tt.ref, tt.style)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeRegistryRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeRegistryRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_detectRegistryStyle(t *testing.T) {
+	tests := map[string]string{
+		"public.ecr.aws": config.RegistryStyleECR,
+		"123456789012.dkr.ecr.us-east-1.amazonaws.com": config.RegistryStyleECR,
+		"ghcr.io":                     config.RegistryStyleGHCR,
+		"docker.io":                   config.RegistryStyleDockerHub,
+		"europe-west1-docker.pkg.dev": config.RegistryStyleArtifactRegistry,
+		"localhost:888":               config.RegistryStyleGeneric,
+	}
+	for host, want := range tests {
+		if got := detectRegistryStyle(host); got != want {
+			t.Errorf("detectRegistryStyle(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func Test_validateOCIVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		version     string
+		wantErr     bool
+	}{
+		{
+			name:        "Default",
+			description: "an unset --oci-version uses the default",
+			version:     "",
+		}, {
+			name:        "Released",
+			description: "released image-spec versions are valid",
+			version:     "1.1.0",
+		}, {
+			name:        "Spec",
+			description: "'spec' resolves to the image-spec version UDS is built against",
+			version:     config.OCIVersionSpec,
+		}, {
+			name:        "Unreleased",
+			description: "error for a version the image-spec never released",
+			version:     "1.2.0",
+			wantErr:     true,
+		}, {
+			name:        "NotAVersion",
+			description: "error for a value that isn't a version",
+			version:     "latest",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateOCIVersion(tt.version); (err != nil) != tt.wantErr {
+				t.Errorf("validateOCIVersion() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_checkPushPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		status      int
+		wantCancel  bool
+		wantErr     bool
+	}{
+		{
+			name:        "Allowed",
+			description: "a started upload means the identity can push, the upload is cancelled",
+			status:      http.StatusAccepted,
+			wantCancel:  true,
+		},
+		{
+			name:        "Denied",
+			description: "a registry that refuses the upload denies pushing",
+			status:      http.StatusForbidden,
+			wantErr:     true,
+		},
+		{
+			name:        "Unauthorized",
+			description: "anonymous or bad credentials can't push",
+			status:      http.StatusUnauthorized,
+			wantErr:     true,
+		},
+		{
+			name:        "NoRepository",
+			description: "registries that don't create repositories on push report them missing",
+			status:      http.StatusNotFound,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cancelled := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/v2/org/bundles/blobs/uploads/":
+					if tt.status == http.StatusAccepted {
+						w.Header().Set("Location", "/v2/org/bundles/blobs/uploads/session")
+					}
+					w.WriteHeader(tt.status)
+				case r.Method == http.MethodDelete && r.URL.Path == "/v2/org/bundles/blobs/uploads/session":
+					cancelled = true
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer server.Close()
+
+			repo, err := remote.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/org/bundles")
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo.PlainHTTP = true
+			if err := checkPushPermission(context.Background(), repo); (err != nil) != tt.wantErr {
+				t.Errorf("checkPushPermission() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if cancelled != tt.wantCancel {
+				t.Errorf("checkPushPermission() cancelled the upload = %v, want %v (%s)", cancelled, tt.wantCancel, tt.description)
+			}
+		})
+	}
+}
+
+func Test_prepareOutputDirectory(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "file")
+	if err := os.WriteFile(file, []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		description string
+		dir         string
+		wantErr     bool
+	}{
+		{
+			name:        "Existing",
+			description: "an existing directory is used as-is",
+			dir:         tmp,
+		}, {
+			name:        "Nested",
+			description: "missing directories are created",
+			dir:         filepath.Join(tmp, "build", "bundles"),
+		}, {
+			name:        "File",
+			description: "error when the path is a file",
+			dir:         file,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := prepareOutputDirectory(tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prepareOutputDirectory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if info, err := os.Stat(got); err != nil || !info.IsDir() {
+				t.Errorf("prepareOutputDirectory() = %s, not a directory: %v", got, err)
+			}
+			entries, err := os.ReadDir(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), ".uds-write-check-") {
+					t.Errorf("prepareOutputDirectory() left %s behind", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func Test_createManifestConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		legacy      bool
+		want        string
+	}{
+		{
+			name:        "Default",
+			description: "bundle configs use the UDS config media type",
+			want:        config.BundleConfigMediaType,
+		}, {
+			name:        "Legacy",
+			description: "--legacy-config-media-type falls back to the image manifest media type",
+			legacy:      true,
+			want:        ocispec.MediaTypeImageManifest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc, err := createManifestConfig(types.UDSMetadata{Name: "test"}, types.UDSBuildData{Architecture: "amd64"}, manifestConfigMediaType(tt.legacy, ocispec.MediaTypeImageManifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if desc.MediaType != tt.want {
+				t.Errorf("createManifestConfig() media type = %s, want %s", desc.MediaType, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateMinUdsVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		description   string
+		minUdsVersion string
+		cliVersion    string
+		skip          bool
+		wantErr       bool
+	}{
+		{
+			name:        "Unset",
+			description: "bundles without a minUdsVersion work with any CLI",
+			cliVersion:  "v0.0.1",
+		},
+		{
+			name:          "Newer",
+			description:   "CLIs at or past the minUdsVersion proceed",
+			minUdsVersion: "v0.2.0",
+			cliVersion:    "v0.2.0",
+		},
+		{
+			name:          "Older",
+			description:   "CLIs older than the minUdsVersion refuse the bundle",
+			minUdsVersion: "0.3.0",
+			cliVersion:    "v0.2.9",
+			wantErr:       true,
+		},
+		{
+			name:          "OlderPrerelease",
+			description:   "prereleases sort before their release",
+			minUdsVersion: "v0.3.0",
+			cliVersion:    "v0.3.0-rc.1",
+			wantErr:       true,
+		},
+		{
+			name:          "Skipped",
+			description:   "--skip-version-check proceeds with an older CLI",
+			minUdsVersion: "v0.3.0",
+			cliVersion:    "v0.2.9",
+			skip:          true,
+		},
+		{
+			name:          "DevelopmentBuild",
+			description:   "CLIs built without a version only warn",
+			minUdsVersion: "v0.3.0",
+			cliVersion:    "unset",
+		},
+		{
+			name:          "Invalid",
+			description:   "a minUdsVersion that isn't a semantic version is rejected",
+			minUdsVersion: "latest",
+			cliVersion:    "v0.3.0",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := types.UDSMetadata{Name: "test", MinUdsVersion: tt.minUdsVersion}
+			if err := validateMinUdsVersion(metadata, tt.cliVersion, tt.skip); (err != nil) != tt.wantErr {
+				t.Errorf("validateMinUdsVersion() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_validateBundleName(t *testing.T) {
+	tests := []struct {
+		name           string
+		description    string
+		bundleName     string
+		wantErr        bool
+		wantSuggestion string
+	}{
+		{
+			name:        "Valid",
+			description: "lowercase letters, numbers and hyphens are valid",
+			bundleName:  "my-app-2",
+		},
+		{
+			name:        "SingleCharacter",
+			description: "a single letter or number is a valid name",
+			bundleName:  "a",
+		},
+		{
+			name:           "Uppercase",
+			description:    "uppercase letters aren't valid in registry refs",
+			bundleName:     "MyApp",
+			wantErr:        true,
+			wantSuggestion: "myapp",
+		},
+		{
+			name:           "SpacesAndSlashes",
+			description:    "spaces and slashes break tarball names and refs, runs of them become one hyphen",
+			bundleName:     "My App / v2",
+			wantErr:        true,
+			wantSuggestion: "my-app-v2",
+		},
+		{
+			name:           "DotsAndUnderscores",
+			description:    "dots and underscores are replaced too, the tarball name and tag split on them",
+			bundleName:     "my_app.core",
+			wantErr:        true,
+			wantSuggestion: "my-app-core",
+		},
+		{
+			name:           "LeadingAndTrailingHyphens",
+			description:    "names must start and end with a letter or number",
+			bundleName:     "-my-app-",
+			wantErr:        true,
+			wantSuggestion: "my-app",
+		},
+		{
+			name:        "NothingValid",
+			description: "names without a single valid character have no suggestion",
+			bundleName:  "ü/ö",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBundleName(tt.bundleName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateBundleName() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if got := normalizeBundleName(tt.bundleName); tt.wantErr && got != tt.wantSuggestion {
+				t.Errorf("normalizeBundleName() = %q, want %q (%s)", got, tt.wantSuggestion, tt.description)
+			}
+			if tt.wantSuggestion != "" && !strings.Contains(err.Error(), fmt.Sprintf("try %q", tt.wantSuggestion)) {
+				t.Errorf("validateBundleName() error = %v, want it to suggest %q (%s)", err, tt.wantSuggestion, tt.description)
+			}
+		})
+	}
+}
+
+func Test_ValidateBundleSignature(t *testing.T) {
+	dir := t.TempDir()
+	password := func(bool) ([]byte, error) { return []byte("uds"), nil }
+	// the old key is being rotated out, the bundle is signed with the new one
+	trusted := map[string]utils.TrustedKey{}
+	var newKeyPath string
+	for _, name := range []string{"old", "new", "other"} {
+		keys, err := cosign.GenerateKeyPair(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyPath, pubPath := filepath.Join(dir, name+".key"), filepath.Join(dir, name+".pub")
+		if err := os.WriteFile(keyPath, keys.PrivateBytes, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pubPath, keys.PublicBytes, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if name == "new" {
+			newKeyPath = keyPath
+		}
+		trusted[name] = utils.TrustedKey{Path: pubPath, Source: name + ".pub"}
+	}
+	bundleYAML := filepath.Join(dir, config.BundleYAML)
+	if err := os.WriteFile(bundleYAML, []byte("kind: UDSBundle\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signature := filepath.Join(dir, config.BundleYAMLSignature)
+	if _, _, err := utils.SignBlob(bundleYAML, signature, newKeyPath, "", password); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		keys        []utils.TrustedKey
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "SigningKey",
+			description: "the key the bundle was signed with verifies it",
+			keys:        []utils.TrustedKey{trusted["new"]},
+			want:        "new.pub",
+		},
+		{
+			name:        "Rotation",
+			description: "the bundle verifies if any trusted key signed it, and the key that did is returned",
+			keys:        []utils.TrustedKey{trusted["old"], trusted["new"]},
+			want:        "new.pub",
+		},
+		{
+			name:        "NoTrustedKey",
+			description: "the bundle fails if none of the trusted keys signed it",
+			keys:        []utils.TrustedKey{trusted["old"], trusted["other"]},
+			wantErr:     true,
+		},
+		{
+			name:        "NoKeys",
+			description: "a signed bundle fails when no key is given",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateBundleSignature(bundleYAML, signature, tt.keys, "")
+			if (err != nil) != tt.wantErr || got.Source != tt.want {
+				t.Errorf("ValidateBundleSignature() = %q, %v, want %q, wantErr %v (%s)", got.Source, err, tt.want, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
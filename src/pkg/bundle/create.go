@@ -5,6 +5,7 @@
 package bundle
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -14,32 +15,104 @@ import (
 	"oras.land/oras-go/v2/registry"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/Masterminds/semver/v3"
 	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
 	zarfConfig "github.com/defenseunicorns/zarf/src/config"
 	"github.com/defenseunicorns/zarf/src/pkg/interactive"
 	"github.com/defenseunicorns/zarf/src/pkg/message"
-	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
 	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
 	zarfTypes "github.com/defenseunicorns/zarf/src/types"
 	"github.com/pterm/pterm"
 )
 
-// Create creates a bundle
+// Create creates a bundle, every registry request it makes must finish within --timeout
 func (b *Bundler) Create() error {
+	// --cred-helper authenticates the publish destination and the remote package sources
+	helper, err := udsUtils.LookupCredentialHelper(b.cfg.CreateOpts.CredHelper)
+	if err != nil {
+		return err
+	}
+	udsUtils.SetCredentialHelper(helper)
+	defer udsUtils.SetCredentialHelper("")
+
+	if b.cfg.CreateOpts.Timeout <= 0 {
+		return b.create()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.CreateOpts.Timeout)
+	defer cancel()
+	udsUtils.SetNetworkContext(ctx)
+	defer udsUtils.SetNetworkContext(context.Background())
+
+	err = b.create()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("bundle create exceeded --timeout of %s: %w", b.cfg.CreateOpts.Timeout, err)
+	}
+	return err
+}
+
+func (b *Bundler) create() error {
 	// get the current working directory
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
+	// --output - or a named pipe streams the bundle tarball, resolved against the directory create was run from
+	if isTarballStream(b.cfg.CreateOpts.Output) {
+		b.stream = b.cfg.CreateOpts.Output
+		if b.stream != tarballStdout {
+			if b.stream, err = filepath.Abs(b.stream); err != nil {
+				return err
+			}
+		}
+		b.cfg.CreateOpts.Output = ""
+		if err := validateTarballStream(&b.cfg.CreateOpts, b.stream); err != nil {
+			return err
+		}
+	}
+
+	// resolve --output-dir against the directory create was run from, before cd'ing into base
+	if b.cfg.CreateOpts.OutputDirectory != "" {
+		if b.cfg.CreateOpts.Output != "" {
+			return fmt.Errorf("--output-dir only applies to bundle tarballs and can't be used with --output")
+		}
+		outputDir, err := prepareOutputDirectory(b.cfg.CreateOpts.OutputDirectory)
+		if err != nil {
+			return err
+		}
+		b.cfg.CreateOpts.OutputDirectory = outputDir
+	}
+
+	// read --annotations-file relative to the directory create was run from, before cd'ing into base
+	if b.cfg.CreateOpts.AnnotationsFile != "" {
+		annotations, err := loadAnnotationsFile(b.cfg.CreateOpts.AnnotationsFile)
+		if err != nil {
+			return err
+		}
+		if err := validateAnnotations(annotations, b.cfg.CreateOpts.AllowOverride); err != nil {
+			return err
+		}
+		b.cfg.CreateOpts.Annotations = annotations
+	} else if b.cfg.CreateOpts.AllowOverride {
+		return fmt.Errorf("--allow-override only applies to annotations loaded with --annotations-file")
+	}
+
 	// cd into base
 	if err := os.Chdir(b.cfg.CreateOpts.SourceDirectory); err != nil {
 		return err
 	}
 	defer os.Chdir(cwd)
 
+	// structural validation, the unmarshal below silently drops unknown keys
+	if b.cfg.CreateOpts.ValidateSchema {
+		if err := ValidateBundleSchema(config.BundleYAML); err != nil {
+			return err
+		}
+	}
+
 	// read the bundle's metadata into memory
 	if err := utils.ReadYaml(config.BundleYAML, &b.bundle); err != nil {
 		return err
@@ -50,6 +123,120 @@ func (b *Bundler) Create() error {
 		return err
 	}
 
+	// --exclude-package builds a variant of the bundle without some of its packages
+	if err := excludePackages(&b.bundle, b.cfg.CreateOpts.ExcludePackages, b.cfg.CreateOpts.AllowEmpty); err != nil {
+		return err
+	}
+
+	if minVersion := b.bundle.Metadata.MinUdsVersion; minVersion != "" {
+		if _, err := semver.NewVersion(minVersion); err != nil {
+			return fmt.Errorf("invalid metadata.minUdsVersion %q, it must be a semantic version: %w", minVersion, err)
+		}
+	}
+
+	if err := validateSignMethod(b.cfg.CreateOpts.SignMethod); err != nil {
+		return err
+	}
+	if err := validateSigAlgo(b.cfg.CreateOpts.SigAlgo); err != nil {
+		return err
+	}
+	if err := validateRegistryStyle(b.cfg.CreateOpts.RegistryStyle); err != nil {
+		return err
+	}
+	if err := validateOCIVersion(config.CommonOptions.OCIVersion); err != nil {
+		return err
+	}
+	if b.cfg.CreateOpts.ParallelPackages < 1 {
+		return fmt.Errorf("--parallel-packages must be at least 1, got %d", b.cfg.CreateOpts.ParallelPackages)
+	}
+	if err := validateAttachments(b.cfg.CreateOpts.Attachments); err != nil {
+		return err
+	}
+	if _, err := archiveLimitsFromOpts(&b.cfg.CreateOpts); err != nil {
+		return err
+	}
+	if b.cfg.CreateOpts.RepoPrefix != "" {
+		if b.cfg.CreateOpts.Output == "" {
+			return fmt.Errorf("--repo-prefix only applies to bundles created in an OCI registry, use --output")
+		}
+		for _, pkg := range b.bundle.ZarfPackages {
+			if pkg.Path != "" || pkg.Git != "" {
+				return fmt.Errorf("--repo-prefix requires all packages to come from an OCI registry, %s is a local package", pkg.Name)
+			}
+		}
+	}
+	if b.cfg.CreateOpts.ManifestOnly {
+		if b.cfg.CreateOpts.Output == "" {
+			return fmt.Errorf("--manifest-only only applies to bundles created in an OCI registry, use --output")
+		}
+		for _, pkg := range b.bundle.ZarfPackages {
+			if pkg.Path != "" || pkg.Git != "" {
+				return fmt.Errorf("--manifest-only requires all packages to come from an OCI registry, %s is a local package", pkg.Name)
+			}
+		}
+	}
+	if b.cfg.CreateOpts.OptimizeLayers {
+		if b.cfg.CreateOpts.Output != "" {
+			return fmt.Errorf("--optimize-layers rewrites packages in a bundle tarball and can't be used with --output")
+		}
+		if b.cfg.CreateOpts.SigningKeyPath != "" {
+			return fmt.Errorf("--optimize-layers changes package digests after the bundle is signed and can't be used with --signing-key")
+		}
+		message.Warn("--optimize-layers rewrites the packages it rebases, their digests change and their signatures are removed")
+	}
+	if b.cfg.CreateOpts.PinImages {
+		if b.cfg.CreateOpts.Output != "" {
+			return fmt.Errorf("--pin-images rewrites packages in a bundle tarball and can't be used with --output")
+		}
+		if b.cfg.CreateOpts.SigningKeyPath != "" {
+			return fmt.Errorf("--pin-images changes package digests after the bundle is signed and can't be used with --signing-key")
+		}
+		message.Warn("--pin-images rewrites the packages whose images it pins, their digests change and their signatures are removed")
+	}
+	if !b.cfg.CreateOpts.FailFast && b.cfg.CreateOpts.Output == "" {
+		return fmt.Errorf("--fail-fast=false only applies to bundles created in an OCI registry, use --output")
+	}
+	if err := validateSizeReportFormat(b.cfg.CreateOpts.SizeReport); err != nil {
+		return err
+	}
+	if b.cfg.CreateOpts.Created != "" {
+		if _, err := parseCreated(b.cfg.CreateOpts.Created); err != nil {
+			return err
+		}
+	}
+	if _, _, err := parseStoreBackend(b.cfg.CreateOpts.StoreBackend); err != nil {
+		return err
+	}
+	if b.cfg.CreateOpts.StoreBackend != "" && b.cfg.CreateOpts.StoreBackend != StoreBackendLocal && b.cfg.CreateOpts.Output != "" {
+		return fmt.Errorf("--store-backend only applies to bundle tarballs, bundles created with --output are pushed straight to the registry")
+	}
+	if b.cfg.CreateOpts.SizeReport != "" && b.cfg.CreateOpts.Output != "" {
+		message.Warn("--size-report is only available when creating a bundle tarball, skipping the report")
+	}
+	notation := b.cfg.CreateOpts.SignMethod == config.SignMethodNotation
+	if notation && b.cfg.CreateOpts.Output == "" {
+		return fmt.Errorf("--sign-method %s signs the bundle in an OCI registry, use --output or sign when publishing", config.SignMethodNotation)
+	}
+	if b.cfg.CreateOpts.SigAlgo != "" && (b.cfg.CreateOpts.SigningKeyPath == "" || notation) {
+		return fmt.Errorf("--sig-algo only applies to bundles signed with --signing-key")
+	}
+
+	// --offline refuses anything that would reach out to a registry
+	if b.cfg.CreateOpts.Offline {
+		if err := validateOfflineCreate(&b.bundle, b.cfg.CreateOpts.Output); err != nil {
+			return err
+		}
+	}
+
+	if b.cfg.CreateOpts.ExcludeSBOM {
+		message.Warn("--exclude-sbom removes the SBOMs of every package in the bundle, reducing its provenance")
+	}
+
+	// merge in the packages of included bundles
+	if err := b.resolveIncludes(); err != nil {
+		return err
+	}
+
 	// confirm creation
 	if ok := b.confirmBundleCreation(); !ok {
 		return fmt.Errorf("bundle creation cancelled")
@@ -60,9 +247,63 @@ func (b *Bundler) Create() error {
 		return err
 	}
 
+	// --strip-history leaves who built the bundle, where and from what source out of the artifact
+	if b.cfg.CreateOpts.StripHistory {
+		stripHistory(&b.bundle)
+	}
+
+	// --platform-variant flag > metadata.platformVariant
+	if b.cfg.CreateOpts.PlatformVariant != "" {
+		b.bundle.Metadata.PlatformVariant = b.cfg.CreateOpts.PlatformVariant
+	}
+
+	// encryption only applies to bundle tarballs
+	if b.cfg.CreateOpts.Encrypt {
+		if b.cfg.CreateOpts.Output != "" {
+			return fmt.Errorf("--encrypt cannot be used when creating a bundle in an OCI registry")
+		}
+		if len(b.cfg.CreateOpts.Recipients) == 0 {
+			return fmt.Errorf("--encrypt requires at least one --recipient")
+		}
+	}
+
+	// content-addressed names only apply to bundle tarballs on disk
+	if b.cfg.CreateOpts.Snapshot && b.cfg.CreateOpts.Output != "" {
+		return fmt.Errorf("--snapshot cannot be used when creating a bundle in an OCI registry")
+	}
+
+	// splitting only applies to bundle tarballs
+	if b.cfg.CreateOpts.SplitSize != "" {
+		if b.cfg.CreateOpts.Output != "" {
+			return fmt.Errorf("--split-size cannot be used when creating a bundle in an OCI registry")
+		}
+		if _, err := parseSplitSize(b.cfg.CreateOpts.SplitSize); err != nil {
+			return err
+		}
+	}
+
 	// populate Zarf config
 	zarfConfig.CommonOptions.Insecure = config.CommonOptions.Insecure
 
+	// --include-zarf-init bundles the Zarf init package first, deploy applies it before the bundle's other packages
+	if err := b.includeZarfInit(); err != nil {
+		return err
+	}
+
+	// resolve packages with a source through their type's resolver, they're bundled like packages with a path or repository
+	cleanupPackageSources, err := b.resolvePackageSources()
+	defer cleanupPackageSources()
+	if err != nil {
+		return err
+	}
+
+	// clone (and build) packages with git sources, they're bundled from the clones like local packages
+	cleanupGitSources, err := b.resolveGitSources()
+	defer cleanupGitSources()
+	if err != nil {
+		return err
+	}
+
 	validateSpinner := message.NewProgressSpinner("Validating bundle")
 
 	defer validateSpinner.Stop()
@@ -78,26 +319,33 @@ func (b *Bundler) Create() error {
 	var signatureBytes []byte
 
 	// sign the bundle if a signing key was provided
-	if b.cfg.CreateOpts.SigningKeyPath != "" {
+	if b.cfg.CreateOpts.SigningKeyPath != "" && !notation {
 		// write the bundle to disk so we can sign it
 		bundlePath := filepath.Join(b.tmp, config.BundleYAML)
 		if err := utils.WriteYaml(bundlePath, &b.bundle, 0600); err != nil {
 			return err
 		}
 
-		getSigCreatePassword := func(_ bool) ([]byte, error) {
-			if b.cfg.CreateOpts.SigningKeyPassword != "" {
-				return []byte(b.cfg.CreateOpts.SigningKeyPassword), nil
+		// passwords handed to cosign are zeroed once the bundle is signed
+		var passwords [][]byte
+		defer func() {
+			for _, password := range passwords {
+				udsUtils.ZeroBytes(password)
 			}
-			return interactive.PromptSigPassword()
+		}()
+		getSigCreatePassword := func(_ bool) ([]byte, error) {
+			password, err := udsUtils.KeyPassword(b.cfg.CreateOpts.SigningKeyPassword, b.cfg.CreateOpts.SigningKeyPasswordFile, interactive.PromptSigPassword)
+			passwords = append(passwords, password)
+			return password, err
 		}
-		// sign the bundle
+		// sign the bundle, recording the algorithm so the signature layer can be annotated with it
 		signaturePath := filepath.Join(b.tmp, config.BundleYAMLSignature)
-		bytes, err := utils.CosignSignBlob(bundlePath, signaturePath, b.cfg.CreateOpts.SigningKeyPath, getSigCreatePassword)
+		bytes, sigAlgo, err := udsUtils.SignBlob(bundlePath, signaturePath, b.cfg.CreateOpts.SigningKeyPath, b.cfg.CreateOpts.SigAlgo, getSigCreatePassword)
 		if err != nil {
 			return err
 		}
 		signatureBytes = bytes
+		b.cfg.CreateOpts.SigAlgo = sigAlgo
 	}
 
 	if b.cfg.CreateOpts.Output != "" {
@@ -106,11 +354,24 @@ func (b *Bundler) Create() error {
 		if err != nil {
 			return err
 		}
-		remote, err := oci.NewOrasRemote(ref)
+		ref, err = normalizeRegistryRef(ref, b.cfg.CreateOpts.RegistryStyle)
+		if err != nil {
+			return err
+		}
+		remote, err := udsUtils.NewOrasRemote(ref)
 		if err != nil {
 			return err
 		}
-		return CreateAndPublish(remote, &b.bundle, signatureBytes)
+		if err := checkRepositoryExists(remote, b.cfg.CreateOpts.RegistryStyle); err != nil {
+			return err
+		}
+		if err := CreateAndPublish(remote, &b.bundle, signatureBytes, &b.cfg.CreateOpts); err != nil {
+			return err
+		}
+		if notation {
+			return notationSign(remote, b.cfg.CreateOpts.NotationKey)
+		}
+		return nil
 	}
 	return Create(b, signatureBytes)
 }
@@ -202,3 +463,39 @@ func referenceFromMetadata(registryLocation string, metadata *types.UDSMetadata,
 
 	return ref.String(), nil
 }
+
+// validateOfflineCreate ensures a bundle can be created without network access
+func validateOfflineCreate(bundle *types.UDSBundle, output string) error {
+	if output != "" {
+		return fmt.Errorf("cannot output to an OCI registry (%s) when creating a bundle with --offline", output)
+	}
+	for _, pkg := range bundle.ZarfPackages {
+		if pkg.Repository != "" {
+			return fmt.Errorf("zarf pkg %s references a remote repository (%s), only local paths can be used with --offline", pkg.Name, pkg.Repository)
+		}
+		if pkg.Git != "" {
+			return fmt.Errorf("zarf pkg %s is cloned from git (%s), only local paths can be used with --offline", pkg.Name, pkg.Git)
+		}
+	}
+	if len(bundle.Includes) > 0 {
+		return fmt.Errorf("included bundles are pulled from an OCI registry, includes can't be used with --offline")
+	}
+	return nil
+}
+
+// prepareOutputDirectory creates dir if needed and ensures a tarball can be written to it, returning its absolute path
+func prepareOutputDirectory(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.CreateDirectory(abs, 0755); err != nil {
+		return "", fmt.Errorf("unable to create --output-dir %s: %w", dir, err)
+	}
+	probe, err := os.CreateTemp(abs, ".uds-write-check-*")
+	if err != nil {
+		return "", fmt.Errorf("--output-dir %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	return abs, os.Remove(probe.Name())
+}
@@ -6,23 +6,34 @@ package bundle
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime/debug"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pterm/pterm"
 	"golang.org/x/exp/maps"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 
 	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/k8s"
 	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/packager"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
 	zarfTypes "github.com/defenseunicorns/zarf/src/types"
 
 	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
 )
 
@@ -39,13 +50,39 @@ import (
 func (b *Bundler) Deploy() error {
 	ctx := context.TODO()
 
+	if err := validateSignMethod(b.cfg.DeployOpts.SignMethod); err != nil {
+		return err
+	}
+	if b.cfg.DeployOpts.PackageRetries < 0 {
+		return fmt.Errorf("--package-retries can't be negative, got %d", b.cfg.DeployOpts.PackageRetries)
+	}
+	labelMapping := defaultBundleLabels
+	if len(b.cfg.DeployOpts.BundleLabels) > 0 {
+		labelMapping = b.cfg.DeployOpts.BundleLabels
+	}
+	if err := validateBundleLabels(labelMapping); err != nil {
+		return err
+	}
+
+	// --kubeconfig and --kube-context target a specific cluster for the whole deploy, checked before anything is loaded
+	restoreKubeconfig, err := udsUtils.UseKubeconfig(b.cfg.DeployOpts.Kubeconfig, b.cfg.DeployOpts.KubeContext)
+	if err != nil {
+		return err
+	}
+	defer restoreKubeconfig()
+
 	pterm.Println()
 	metadataSpinner := message.NewProgressSpinner("Loading bundle metadata")
 
 	defer metadataSpinner.Stop()
 
+	source, err := b.decryptSource(b.cfg.DeployOpts.Source, b.cfg.DeployOpts.Decrypt, b.cfg.DeployOpts.IdentityPath)
+	if err != nil {
+		return err
+	}
+
 	// create a new provider
-	provider, err := NewBundleProvider(ctx, b.cfg.DeployOpts.Source, b.tmp)
+	provider, err := NewBundleProvider(ctx, source, b.tmp)
 	if err != nil {
 		return err
 	}
@@ -57,104 +94,452 @@ func (b *Bundler) Deploy() error {
 	}
 
 	// validate the sig (if present)
-	if err := ValidateBundleSignature(loaded[config.BundleYAML], loaded[config.BundleYAMLSignature], b.cfg.DeployOpts.PublicKeyPath); err != nil {
+	publicKeys, err := udsUtils.FetchPublicKeys(b.cfg.DeployOpts.PublicKeyPaths, b.tmp)
+	if err != nil {
+		return err
+	}
+	sigAlgo, err := provider.SignatureAlgorithm()
+	if err != nil {
+		return err
+	}
+	if _, err := verifyBundleSignature(loaded, publicKeys, sigAlgo); err != nil {
 		return err
 	}
 
+	// validate the Notary v2 signature against the notation trust policy
+	if b.cfg.DeployOpts.SignMethod == config.SignMethodNotation {
+		if !helpers.IsOCIURL(source) {
+			return fmt.Errorf("--sign-method %s can only verify bundles in an OCI registry", config.SignMethodNotation)
+		}
+		if err := notationVerify(source); err != nil {
+			return err
+		}
+	}
+
 	// read the bundle's metadata into memory
 	if err := utils.ReadYaml(loaded[config.BundleYAML], &b.bundle); err != nil {
 		return err
 	}
+	if err := requireBundleYAML(&b.bundle, "deploy"); err != nil {
+		return err
+	}
+
+	// label the namespaces packages deploy into with the bundle, for finding everything a bundle installed
+	labels := bundleLabels{}
+	if !b.cfg.DeployOpts.SkipBundleLabels {
+		annotations, err := provider.Annotations()
+		if err != nil {
+			return err
+		}
+		labels = newBundleLabels(&b.bundle, annotations, labelMapping)
+	}
 
 	metadataSpinner.Successf("Loaded bundle metadata")
 
+	if err := validateMinUdsVersion(b.bundle.Metadata, config.CLIVersion, b.cfg.DeployOpts.SkipVersionCheck); err != nil {
+		return err
+	}
+
+	if err := b.validateArchitecture(clusterNodes); err != nil {
+		return err
+	}
+
+	if err := b.validatePlatformVariant(clusterNodes); err != nil {
+		return err
+	}
+
+	// verify the package digests haven't changed since they were first trusted
+	savePins := func() error { return nil }
+	if b.cfg.DeployOpts.PinFile != "" {
+		if savePins, err = b.checkPins(); err != nil {
+			return err
+		}
+	} else if b.cfg.DeployOpts.UpdatePins {
+		return fmt.Errorf("--update-pins requires --pin-file")
+	}
+
+	// --values-file and --set variables, checked before anything deploys
+	overrides, err := b.loadPackageOverrides()
+	if err != nil {
+		return err
+	}
+
 	// confirm deploy
 	if ok := b.confirmBundleDeploy(); !ok {
 		return fmt.Errorf("bundle deployment cancelled")
 	}
+	if err := savePins(); err != nil {
+		return err
+	}
 
 	// map of Zarf pkgs and their vars
 	bundleExportedVars := make(map[string]map[string]string)
 
-	// deploy each package
-	for _, pkg := range b.bundle.ZarfPackages {
-		sha := strings.Split(pkg.Ref, "@sha256:")[1] // using appended SHA from create!
-		pkgTmp, err := utils.MakeTempDir()
+	// deploy packages after the packages they depend on
+	packages, err := sortPackagesByDependencies(b.bundle.ZarfPackages)
+	if err != nil {
+		return err
+	}
+
+	// record of what has been deployed, written to the cluster as each package deploys
+	record := newDeployRecord(&b.bundle, b.cfg.DeployOpts.Source, b.cfg.DeployOpts.NamespacePrefix)
+
+	// --resume skips the packages the last deploy of this bundle already applied
+	resumeAt := 0
+	if b.cfg.DeployOpts.Resume && b.cfg.DeployOpts.OnlyChanged {
+		return fmt.Errorf("--resume and --only-changed cannot be used together")
+	}
+	if b.cfg.DeployOpts.Resume {
+		previous, err := readDeployRecords(b.bundle.Metadata.Name, b.cfg.DeployOpts.NamespacePrefix)
 		if err != nil {
-			return err
+			message.Warnf("Unable to resume, deploying all packages: %s", err.Error())
+		} else {
+			resumeAt = resumeFrom(packages, &previous[0])
+			for _, deployed := range previous[0].Packages[:resumeAt] {
+				record.Packages = append(record.Packages, deployed)
+				bundleExportedVars[deployed.Name] = deployed.Exports
+			}
 		}
-		defer os.RemoveAll(pkgTmp)
+	}
+
+	// --only-changed skips every package that's deployed with the same digest, wherever it is in the deploy order
+	unchanged := make(map[string]types.UDSDeployedPackage)
+	if b.cfg.DeployOpts.OnlyChanged {
+		previous, err := readDeployRecords(b.bundle.Metadata.Name, b.cfg.DeployOpts.NamespacePrefix)
+		if err != nil {
+			message.Warnf("Unable to compare to the deployed bundle, deploying all packages: %s", err.Error())
+		} else {
+			unchanged = unchangedPackages(packages, &previous[0])
+		}
+	}
 
-		packageSpinner := message.NewProgressSpinner("Loading bundled Zarf package: %s", pkg.Name)
+	// --total-timeout bounds the whole bundle deploy, --timeout bounds each package
+	deployCtx := ctx
+	if b.cfg.DeployOpts.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		deployCtx, cancel = context.WithTimeout(ctx, b.cfg.DeployOpts.TotalTimeout)
+		defer cancel()
+	}
 
-		defer packageSpinner.Stop()
+	// --prefetch pushes every image before the first package is applied, after the init package since that creates the registry
+	prefetched := !b.cfg.DeployOpts.Prefetch
 
-		// todo: LoadPackage should return an err if the tmp dir (or wherever) is empty
-		_, err = provider.LoadPackage(sha, pkgTmp, config.CommonOptions.OCIConcurrency)
-		if err != nil {
+	// deploy each package
+	for i, pkg := range packages {
+		if i < resumeAt {
+			message.Successf("Skipping package %d of %d: %s, already deployed with digest %s", i+1, len(packages), pkg.Name, record.Packages[i].Digest)
+			continue
+		}
+		if deployed, ok := unchanged[pkg.Name]; ok {
+			message.Successf("Skipping package %d of %d: %s, unchanged at digest %s", i+1, len(packages), pkg.Name, deployed.Digest)
+			record.Packages = append(record.Packages, deployed)
+			bundleExportedVars[deployed.Name] = deployed.Exports
+			continue
+		}
+		if err := deployCtx.Err(); err != nil {
+			return fmt.Errorf("bundle deploy exceeded --total-timeout of %s with %d of %d packages remaining", b.cfg.DeployOpts.TotalTimeout, len(packages)-i, len(packages))
+		}
+		if !prefetched && pkg.Name != zarfInitPackageName {
+			if err := b.prefetchImages(provider, prefetchPackages(packages[i:], unchanged)); err != nil {
+				return fmt.Errorf("%w, %d packages were not deployed", err, len(packages)-i)
+			}
+			prefetched = true
+		}
+		message.HeaderInfof("📦 Deploying package %d of %d: %s", i+1, len(packages), pkg.Name)
+
+		// --package-retries loads each attempt into a fresh temp dir, a failed Zarf deploy leaves its package partly unpacked
+		var pkgExportedVars map[string]string
+		err := retryPackageDeploy(deployCtx, b.cfg.DeployOpts.PackageRetries, config.PackageRetryBackoff, pkg.Name, func() (err error) {
+			pkgExportedVars, err = b.deployPackage(deployCtx, provider, pkg, bundleExportedVars, overrides[pkg.Name], labels)
 			return err
+		})
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("timed out deploying zarf pkg %s (%d of %d), %d packages were not deployed", pkg.Name, i+1, len(packages), len(packages)-i-1)
+		} else if err != nil {
+			return err
+		}
+		if err := runReadinessChecks(deployCtx, pkg); err != nil {
+			return fmt.Errorf("zarf pkg %s (%d of %d) is not ready, %d packages were not deployed: %w", pkg.Name, i+1, len(packages), len(packages)-i-1, err)
 		}
 
-		packageSpinner.Successf("Loaded bundled Zarf package: %s", pkg.Name)
+		// save exported vars
+		bundleExportedVars[pkg.Name] = pkgExportedVars
+
+		recordDeployedPackage(record, pkg, pkgExportedVars)
+	}
 
-		publicKeyPath := filepath.Join(b.tmp, config.PublicKeyFile)
-		if pkg.PublicKey != "" {
-			if err := utils.WriteFile(publicKeyPath, []byte(pkg.PublicKey)); err != nil {
-				return err
+	if len(unchanged) > 0 {
+		skipped := []string{}
+		for _, pkg := range packages {
+			if _, ok := unchanged[pkg.Name]; ok {
+				skipped = append(skipped, pkg.Name)
 			}
-			defer os.Remove(publicKeyPath)
-		} else {
-			publicKeyPath = ""
 		}
+		message.Infof("Skipped %d of %d packages that were already deployed unchanged: %s", len(skipped), len(packages), strings.Join(skipped, ", "))
+	}
+	return nil
+}
+
+// deployPackage loads pkg from provider into a temp dir and deploys it with Zarf, labeling the namespaces it deployed
+// into with labels and returning the variables it exports
+func (b *Bundler) deployPackage(ctx context.Context, provider Provider, pkg types.BundleZarfPackage, bundleExportedVars map[string]map[string]string, overrides map[string]string, labels bundleLabels) (map[string]string, error) {
+	pin, err := pinnedDigest(pkg) // using appended SHA from create!
+	if err != nil {
+		return nil, err
+	}
+	sha := pin.Encoded()
+	pkgTmp, err := utils.MakeTempDir()
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(pkgTmp)
+
+	packageSpinner := message.NewProgressSpinner("Loading bundled Zarf package: %s", pkg.Name)
 
-		pkgVars := b.loadVariables(pkg, bundleExportedVars)
+	defer packageSpinner.Stop()
 
-		opts := zarfTypes.ZarfPackageOptions{
-			PackagePath:        pkgTmp,
-			OptionalComponents: strings.Join(pkg.OptionalComponents, ","),
-			PublicKeyPath:      publicKeyPath,
-			SetVariables:       pkgVars,
+	// todo: LoadPackage should return an err if the tmp dir (or wherever) is empty
+	_, err = provider.LoadPackage(sha, pkgTmp, config.CommonOptions.OCIConcurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	packageSpinner.Successf("Loaded bundled Zarf package: %s", pkg.Name)
+
+	if b.cfg.DeployOpts.NamespacePrefix != "" {
+		if err := prefixPackageNamespaces(pkgTmp, b.cfg.DeployOpts.NamespacePrefix); err != nil {
+			return nil, err
 		}
-		pkgCfg := zarfTypes.PackagerConfig{
-			PkgOpts:  opts,
-			InitOpts: config.DefaultZarfInitOptions,
+	}
+
+	publicKeyPath := filepath.Join(b.tmp, config.PublicKeyFile)
+	if pkg.PublicKey != "" {
+		if err := utils.WriteFile(publicKeyPath, []byte(pkg.PublicKey)); err != nil {
+			return nil, err
 		}
+		defer os.Remove(publicKeyPath)
+	} else {
+		publicKeyPath = ""
+	}
+
+	warnUnknownVariables(pkg.Name, pkgTmp, overrides)
+	pkgVars := b.loadVariables(pkg, bundleExportedVars, overrides)
 
-		// grab Zarf version to make Zarf library checks happy
-		if buildInfo, ok := debug.ReadBuildInfo(); ok {
-			for _, dep := range buildInfo.Deps {
-				if dep.Path == "github.com/defenseunicorns/zarf" {
-					zarfConfig.CLIVersion = strings.Split(dep.Version, "v")[1]
-				}
+	opts := zarfTypes.ZarfPackageOptions{
+		PackagePath:        pkgTmp,
+		OptionalComponents: strings.Join(pkg.OptionalComponents, ","),
+		PublicKeyPath:      publicKeyPath,
+		SetVariables:       pkgVars,
+	}
+	pkgCfg := zarfTypes.PackagerConfig{
+		PkgOpts:  opts,
+		InitOpts: config.DefaultZarfInitOptions,
+	}
+
+	// grab Zarf version to make Zarf library checks happy
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range buildInfo.Deps {
+			if dep.Path == "github.com/defenseunicorns/zarf" {
+				zarfConfig.CLIVersion = strings.Split(dep.Version, "v")[1]
 			}
 		}
+	}
+
+	// Automatically confirm the package deployment
+	zarfConfig.CommonOptions.Confirm = true
+
+	pkgClient, err := packager.New(&pkgCfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := pkgClient.SetTempDirectory(pkgTmp); err != nil {
+		return nil, err
+	}
+	if err := deployWithTimeout(ctx, b.cfg.DeployOpts.Timeout, pkgClient.Deploy); err != nil {
+		return nil, err
+	}
+	labelPackageNamespaces(ctx, pkg, pkgTmp, labels)
+
+	pkgExportedVars := make(map[string]string)
+	for _, exp := range pkg.Exports {
+		pkgExportedVars[strings.ToUpper(exp.Name)] = pkgCfg.SetVariableMap[exp.Name].Value
+	}
+	return pkgExportedVars, nil
+}
+
+// deployWithTimeout runs deploy until it returns, timeout elapses or ctx is done
+//
+// the Zarf library doesn't take a context, so a deploy that times out is abandoned rather than interrupted
+func deployWithTimeout(ctx context.Context, timeout time.Duration, deploy func() error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- deploy()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// nonRetryableDeployErrors are the parts of Zarf and Helm errors for manifests the cluster rejected, which fail the
+// same way every time
+var nonRetryableDeployErrors = []string{
+	"error validating",
+	"is invalid",
+	"unable to build kubernetes objects",
+	"admission webhook",
+	"forbidden",
+}
+
+// retryableDeployErrors are the parts of Zarf and Helm errors for transient cluster conditions, Zarf flattens most
+// errors into strings so they can't all be matched by type
+var retryableDeployErrors = []string{
+	"connection refused",
+	"connection reset",
+	"i/o timeout",
+	"tls handshake timeout",
+	"etcdserver: request timed out",
+	"the server is currently unable to handle the request",
+	"too many requests",
+	"imagepullbackoff",
+	"errimagepull",
+	"timed out waiting for the condition",
+}
+
+// isRetryableDeployError returns true if a package deploy failed on a transient cluster condition
+//
+// deploys bounded by --timeout or --total-timeout aren't retried, the abandoned deploy may still be running
+func isRetryableDeployError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if kerrors.IsInvalid(err) || kerrors.IsBadRequest(err) || kerrors.IsForbidden(err) || kerrors.IsUnauthorized(err) {
+		return false
+	}
+	if kerrors.IsServerTimeout(err) || kerrors.IsTimeout(err) || kerrors.IsTooManyRequests(err) ||
+		kerrors.IsServiceUnavailable(err) || kerrors.IsInternalError(err) || kerrors.IsUnexpectedServerError(err) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range nonRetryableDeployErrors {
+		if strings.Contains(msg, s) {
+			return false
+		}
+	}
+	for _, s := range retryableDeployErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryPackageDeploy runs deploy, retrying it up to retries times on retryable errors and doubling backoff between
+// attempts
+func retryPackageDeploy(ctx context.Context, retries int, backoff time.Duration, pkgName string, deploy func() error) error {
+	err := deploy()
+	for attempt := 1; attempt <= retries && err != nil && isRetryableDeployError(err); attempt++ {
+		message.Warnf("Deploying zarf pkg %s failed, retrying in %s (retry %d of %d): %s", pkgName, backoff, attempt, retries, err.Error())
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+		err = deploy()
+	}
+	return err
+}
 
-		// Automatically confirm the package deployment
-		zarfConfig.CommonOptions.Confirm = true
+// nodeLister lists the nodes of the cluster a bundle deploys to
+type nodeLister func() ([]corev1.Node, error)
 
-		pkgClient, err := packager.New(&pkgCfg)
+// clusterNodes lists the nodes of the cluster in the current kube context
+func clusterNodes() ([]corev1.Node, error) {
+	cluster, err := k8s.New(message.Debugf, nil)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := cluster.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
+
+// targetPlatforms returns the platform of each of the cluster's nodes, falling back to the local platform when there is
+// no cluster yet (ie. the bundle deploys one). A node's CPU variant is read from its uds.dev/platform-variant label
+func (b *Bundler) targetPlatforms(listNodes nodeLister) []ocispec.Platform {
+	nodes, err := listNodes()
+	if err != nil || len(nodes) == 0 {
 		if err != nil {
-			return err
+			message.Debugf("unable to list the cluster's nodes, comparing against the local platform: %s", err.Error())
 		}
-		if err := pkgClient.SetTempDirectory(pkgTmp); err != nil {
-			return err
+		arch := b.cfg.Arch.Target()
+		return []ocispec.Platform{{Architecture: arch, Variant: config.GetArchVariant(arch)}}
+	}
+	platforms := []ocispec.Platform{}
+	for _, node := range nodes {
+		platforms = append(platforms, ocispec.Platform{
+			Architecture: node.Status.NodeInfo.Architecture,
+			Variant:      node.Labels[config.NodePlatformVariantLabel],
+		})
+	}
+	return platforms
+}
+
+// validatePlatformVariant ensures the bundle's CPU variant (if present) matches the variant of the cluster's nodes,
+// targets whose variant isn't known are skipped
+func (b *Bundler) validatePlatformVariant(listNodes nodeLister) error {
+	bundleVariant := b.bundle.Metadata.PlatformVariant
+	if bundleVariant == "" || b.cfg.DeployOpts.SkipVariantCheck {
+		return nil
+	}
+	for _, platform := range b.targetPlatforms(listNodes) {
+		if platform.Variant == "" {
+			message.Debugf("unable to tell the CPU variant of the %s target, skipping the variant check for it", platform.Architecture)
+			continue
 		}
-		if err := pkgClient.Deploy(); err != nil {
-			return err
+		if platform.Variant != bundleVariant {
+			return fmt.Errorf("bundle was built for %s/%s but the target platform is %s/%s, use --skip-variant-check to deploy anyway",
+				b.bundle.Metadata.Architecture, bundleVariant, platform.Architecture, platform.Variant)
 		}
+	}
+	return nil
+}
 
-		// save exported vars
-		pkgExportedVars := make(map[string]string)
-		for _, exp := range pkg.Exports {
-			pkgExportedVars[strings.ToUpper(exp.Name)] = pkgCfg.SetVariableMap[exp.Name].Value
+// validateArchitecture ensures the bundle was built for the architecture of the cluster's nodes,
+// falling back to the local architecture when there is no cluster yet (ie. the bundle deploys one)
+func (b *Bundler) validateArchitecture(listNodes nodeLister) error {
+	bundleArch := b.bundle.Metadata.Architecture
+	if bundleArch == "" || b.cfg.DeployOpts.SkipArchCheck {
+		return nil
+	}
+	for _, platform := range b.targetPlatforms(listNodes) {
+		if platform.Architecture != bundleArch {
+			return fmt.Errorf("bundle was built for %s but the target architecture is %s, use --skip-arch-check to deploy anyway", bundleArch, platform.Architecture)
 		}
-		bundleExportedVars[pkg.Name] = pkgExportedVars
 	}
 	return nil
 }
 
-// loadVariables loads and sets precedence for config-level and imported variables
-func (b *Bundler) loadVariables(pkg types.BundleZarfPackage, bundleExportedVars map[string]map[string]string) map[string]string {
+// loadVariables loads and sets precedence for imported, config-level and command line (--values-file and --set) variables
+func (b *Bundler) loadVariables(pkg types.BundleZarfPackage, bundleExportedVars map[string]map[string]string, overrides map[string]string) map[string]string {
 	pkgVars := make(map[string]string)
 	pkgConfigVars := make(map[string]string)
 	for name, val := range b.cfg.DeployOpts.ZarfPackageVariables[pkg.Name].Set {
@@ -168,6 +553,7 @@ func (b *Bundler) loadVariables(pkg types.BundleZarfPackage, bundleExportedVars
 	// set var precedence
 	maps.Copy(pkgVars, pkgImportedVars)
 	maps.Copy(pkgVars, pkgConfigVars)
+	maps.Copy(pkgVars, overrides)
 	return pkgVars
 }
 
@@ -0,0 +1,650 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_deployWithTimeout(t *testing.T) {
+	errDeploy := errors.New("deploy failed")
+	tests := []struct {
+		name        string
+		description string
+		timeout     time.Duration
+		deploy      func() error
+		wantErr     error
+	}{
+		{
+			name:        "NoTimeout",
+			description: "a timeout of 0 waits for the deploy to finish",
+			timeout:     0,
+			deploy:      func() error { return nil },
+			wantErr:     nil,
+		}, {
+			name:        "DeployError",
+			description: "errors from the deploy are returned as-is",
+			timeout:     time.Minute,
+			deploy:      func() error { return errDeploy },
+			wantErr:     errDeploy,
+		}, {
+			name:        "TimedOut",
+			description: "a deploy that outlives its timeout is abandoned",
+			timeout:     10 * time.Millisecond,
+			deploy:      func() error { time.Sleep(time.Second); return nil },
+			wantErr:     context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := deployWithTimeout(context.Background(), tt.timeout, tt.deploy); !errors.Is(err, tt.wantErr) {
+				t.Errorf("deployWithTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_isRetryableDeployError(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		err         error
+		want        bool
+	}{
+		{
+			name:        "ServerTimeout",
+			description: "API server timeouts are transient",
+			err:         kerrors.NewServerTimeout(schema.GroupResource{Resource: "deployments"}, "create", 1),
+			want:        true,
+		}, {
+			name:        "Invalid",
+			description: "manifests the API server rejects fail the same way every time",
+			err:         kerrors.NewInvalid(schema.GroupKind{Kind: "Deployment"}, "podinfo", nil),
+			want:        false,
+		}, {
+			name:        "ImagePullBackOff",
+			description: "flattened Helm errors waiting on image pulls are transient",
+			err:         errors.New("unable to install chart podinfo: pod podinfo-0 is in ImagePullBackOff"),
+			want:        true,
+		}, {
+			name:        "ValidationFailure",
+			description: "Helm validation failures aren't retried, even if they mention a timeout",
+			err:         errors.New("unable to build kubernetes objects from release manifest: error validating data: timed out waiting for the condition"),
+			want:        false,
+		}, {
+			name:        "Timeout",
+			description: "packages that exceed --timeout are abandoned, not retried",
+			err:         context.DeadlineExceeded,
+			want:        false,
+		}, {
+			name:        "Unknown",
+			description: "unrecognized errors aren't retried",
+			err:         errors.New("component podinfo failed"),
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableDeployError(tt.err); got != tt.want {
+				t.Errorf("isRetryableDeployError() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_retryPackageDeploy(t *testing.T) {
+	errTransient := errors.New("dial tcp 127.0.0.1:6443: connect: connection refused")
+	errInvalid := errors.New("error validating data: unknown field")
+	tests := []struct {
+		name         string
+		description  string
+		retries      int
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:         "RecoversOnRetry",
+			description:  "a transient failure succeeds on retry",
+			retries:      2,
+			errs:         []error{errTransient, nil},
+			wantAttempts: 2,
+			wantErr:      nil,
+		}, {
+			name:         "RetriesExhausted",
+			description:  "the last error is returned once the retries run out",
+			retries:      2,
+			errs:         []error{errTransient, errTransient, errTransient},
+			wantAttempts: 3,
+			wantErr:      errTransient,
+		}, {
+			name:         "NotRetryable",
+			description:  "validation failures fail on the first attempt",
+			retries:      2,
+			errs:         []error{errInvalid},
+			wantAttempts: 1,
+			wantErr:      errInvalid,
+		}, {
+			name:         "NoRetries",
+			description:  "without --package-retries a failure is returned as-is",
+			retries:      0,
+			errs:         []error{errTransient},
+			wantAttempts: 1,
+			wantErr:      errTransient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := retryPackageDeploy(context.Background(), tt.retries, time.Millisecond, "podinfo", func() error {
+				err := tt.errs[attempts]
+				attempts++
+				return err
+			})
+			if !errors.Is(err, tt.wantErr) || attempts != tt.wantAttempts {
+				t.Errorf("retryPackageDeploy() error = %v after %d attempts, want %v after %d (%s)", err, attempts, tt.wantErr, tt.wantAttempts, tt.description)
+			}
+		})
+	}
+}
+
+func Test_resumeFrom(t *testing.T) {
+	previous := &types.UDSDeployRecord{
+		Packages: []types.UDSDeployedPackage{
+			{Name: "init", Ref: "v0.29.1-amd64", Digest: "sha256:aaa", OptionalComponents: []string{"git-server"}},
+			{Name: "podinfo", Ref: "0.0.1-amd64", Digest: "sha256:bbb"},
+		},
+	}
+	tests := []struct {
+		name        string
+		description string
+		packages    []types.BundleZarfPackage
+		want        int
+	}{
+		{
+			name:        "AllDeployed",
+			description: "every package matches the record",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa", OptionalComponents: []string{"git-server"}},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: 2,
+		}, {
+			name:        "NewPackage",
+			description: "packages missing from the record are deployed",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa", OptionalComponents: []string{"git-server"}},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+				{Name: "nginx", Ref: "0.0.1-amd64@sha256:ccc"},
+			},
+			want: 2,
+		}, {
+			name:        "ChangedDigest",
+			description: "a changed package and everything after it is redeployed",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.2-amd64@sha256:ddd", OptionalComponents: []string{"git-server"}},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: 0,
+		}, {
+			name:        "ChangedComponents",
+			description: "a package deployed with different optional components is redeployed",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: 0,
+		}, {
+			name:        "Reordered",
+			description: "packages are compared in deploy order",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa", OptionalComponents: []string{"git-server"}},
+				{Name: "nginx", Ref: "0.0.1-amd64@sha256:ccc"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumeFrom(tt.packages, previous); got != tt.want {
+				t.Errorf("resumeFrom() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_unchangedPackages(t *testing.T) {
+	previous := &types.UDSDeployRecord{
+		Packages: []types.UDSDeployedPackage{
+			{Name: "init", Ref: "v0.29.1-amd64", Digest: "sha256:aaa", OptionalComponents: []string{"git-server"}},
+			{Name: "podinfo", Ref: "0.0.1-amd64", Digest: "sha256:bbb", Exports: map[string]string{"PORT": "9898"}},
+		},
+	}
+	tests := []struct {
+		name        string
+		description string
+		packages    []types.BundleZarfPackage
+		want        []string
+	}{
+		{
+			name:        "AllUnchanged",
+			description: "every package matches the record",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa", OptionalComponents: []string{"git-server"}},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: []string{"init", "podinfo"},
+		}, {
+			name:        "ChangedDigest",
+			description: "only the changed package is deployed, the packages after it are skipped",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.2-amd64@sha256:ddd", OptionalComponents: []string{"git-server"}},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: []string{"podinfo"},
+		}, {
+			name:        "ChangedComponents",
+			description: "a package deployed with different optional components is deployed",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: []string{"podinfo"},
+		}, {
+			name:        "Reordered",
+			description: "packages are matched by name, a new package doesn't redeploy the ones after it",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa", OptionalComponents: []string{"git-server"}},
+				{Name: "nginx", Ref: "0.0.1-amd64@sha256:ccc"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: []string{"init", "podinfo"},
+		}, {
+			name:        "NoDigest",
+			description: "a package without a digest can't be compared and is deployed",
+			packages: []types.BundleZarfPackage{
+				{Name: "podinfo", Ref: "0.0.1-amd64"},
+			},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unchangedPackages(tt.packages, previous)
+			if len(got) != len(tt.want) {
+				t.Fatalf("unchangedPackages() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+			for _, name := range tt.want {
+				if _, ok := got[name]; !ok {
+					t.Errorf("unchangedPackages() is missing %s, want %v (%s)", name, tt.want, tt.description)
+				}
+			}
+			if deployed, ok := got["podinfo"]; ok && deployed.Exports["PORT"] != "9898" {
+				t.Errorf("unchangedPackages() should keep the exports of skipped packages, got %v", deployed.Exports)
+			}
+		})
+	}
+}
+
+func Test_verifyPins(t *testing.T) {
+	pinned := map[string]string{"init": "sha256:aaa", "podinfo": "sha256:bbb"}
+	tests := []struct {
+		name        string
+		description string
+		pins        map[string]map[string]string
+		packages    []types.BundleZarfPackage
+		update      bool
+		want        map[string]string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name:        "FirstUse",
+			description: "a bundle without pins has all of its packages pinned",
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want:        pinned,
+			wantChanged: true,
+		}, {
+			name:        "Match",
+			description: "pins are left alone when every digest matches",
+			pins:        map[string]map[string]string{"test": {"init": "sha256:aaa", "podinfo": "sha256:bbb"}},
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want: pinned,
+		}, {
+			name:        "NewPackage",
+			description: "a package added to a pinned bundle is trusted on first use",
+			pins:        map[string]map[string]string{"test": {"init": "sha256:aaa"}},
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want:        pinned,
+			wantChanged: true,
+		}, {
+			name:        "Mismatch",
+			description: "error when a pinned package's digest changed",
+			pins:        map[string]map[string]string{"test": {"init": "sha256:aaa", "podinfo": "sha256:bbb"}},
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:ccc"},
+			},
+			wantErr: true,
+		}, {
+			name:        "Update",
+			description: "--update-pins replaces the bundle's pins with its current digests",
+			pins:        map[string]map[string]string{"test": {"init": "sha256:aaa", "podinfo": "sha256:ccc"}},
+			packages: []types.BundleZarfPackage{
+				{Name: "init", Ref: "v0.29.1-amd64@sha256:aaa"},
+				{Name: "podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			update:      true,
+			want:        pinned,
+			wantChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pins := &types.UDSPins{Bundles: tt.pins}
+			bundle := &types.UDSBundle{Metadata: types.UDSMetadata{Name: "test"}, ZarfPackages: tt.packages}
+			changed, err := verifyPins(pins, bundle, tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyPins() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("verifyPins() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !mapsEqual(pins.Bundles["test"], tt.want) {
+				t.Errorf("verifyPins() pins = %v, want %v", pins.Bundles["test"], tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkPins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.yaml")
+	b := &Bundler{
+		cfg: &types.BundlerConfig{DeployOpts: types.BundlerDeployOptions{PinFile: path}},
+		bundle: types.UDSBundle{
+			Metadata:     types.UDSMetadata{Name: "test"},
+			ZarfPackages: []types.BundleZarfPackage{{Name: "podinfo", Ref: "0.0.1-amd64@sha256:aaa"}},
+		},
+	}
+	savePins, err := b.checkPins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a deploy that isn't confirmed never calls savePins
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("checkPins() wrote the pin file before the deploy was confirmed")
+	}
+	if err := savePins(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("savePins() didn't write the pin file: %v", err)
+	}
+}
+
+func Test_prefixNamespaces(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		prefix      string
+		namespace   string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "Prefixed",
+			description: "namespaces are prefixed",
+			prefix:      "tenant-a-",
+			namespace:   "podinfo",
+			want:        "tenant-a-podinfo",
+		}, {
+			name:        "Empty",
+			description: "empty namespaces are left to Zarf's defaults",
+			prefix:      "tenant-a-",
+			namespace:   "",
+			want:        "",
+		}, {
+			name:        "TooLong",
+			description: "error when the prefixed namespace exceeds 63 characters",
+			prefix:      "tenant-with-a-very-long-name-that-leaves-no-room-",
+			namespace:   "for-the-original-namespace",
+			wantErr:     true,
+		}, {
+			name:        "Invalid",
+			description: "error when the prefix isn't a valid namespace",
+			prefix:      "Tenant_A-",
+			namespace:   "podinfo",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := zarfTypes.ZarfPackage{
+				Components: []zarfTypes.ZarfComponent{{
+					Charts:    []zarfTypes.ZarfChart{{Name: "chart", Namespace: tt.namespace}},
+					Manifests: []zarfTypes.ZarfManifest{{Name: "manifest", Namespace: tt.namespace}},
+					Actions: zarfTypes.ZarfComponentActions{OnDeploy: zarfTypes.ZarfComponentActionSet{
+						After: []zarfTypes.ZarfComponentAction{{Wait: &zarfTypes.ZarfComponentActionWait{
+							Cluster: &zarfTypes.ZarfComponentActionWaitCluster{Kind: "Pod", Namespace: tt.namespace},
+						}}},
+					}},
+				}},
+			}
+			err := prefixNamespaces(&pkg, tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prefixNamespaces() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			component := pkg.Components[0]
+			for _, got := range []string{component.Charts[0].Namespace, component.Manifests[0].Namespace, component.Actions.OnDeploy.After[0].Wait.Cluster.Namespace} {
+				if got != tt.want {
+					t.Errorf("prefixNamespaces() namespace = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_prefixPackageName(t *testing.T) {
+	for _, prefix := range []string{"tenant-a-", "tenant-b-"} {
+		pkg := zarfTypes.ZarfPackage{Metadata: zarfTypes.ZarfMetadata{Name: "podinfo"}}
+		if err := prefixPackageName(&pkg, prefix); err != nil {
+			t.Fatal(err)
+		}
+		if want := prefix + "podinfo"; pkg.Metadata.Name != want {
+			t.Errorf("prefixPackageName() = %s, want %s", pkg.Metadata.Name, want)
+		}
+		if got := prefixedPackageName("podinfo", prefix); got != pkg.Metadata.Name {
+			t.Errorf("prefixedPackageName() = %s, want the deployed name %s", got, pkg.Metadata.Name)
+		}
+	}
+	if got := prefixedPackageName(zarfInitPackageName, "tenant-a-"); got != zarfInitPackageName {
+		t.Errorf("prefixedPackageName() = %s, the init package is never prefixed", got)
+	}
+	pkg := zarfTypes.ZarfPackage{Metadata: zarfTypes.ZarfMetadata{Name: "podinfo"}}
+	if err := prefixPackageName(&pkg, "Tenant_A-"); err == nil {
+		t.Errorf("prefixPackageName() accepted an invalid prefix")
+	}
+}
+
+// listTestNodes returns a nodeLister for nodes of the given <arch>[/<variant>] platforms, no platforms is no cluster
+func listTestNodes(platforms ...string) nodeLister {
+	return func() ([]corev1.Node, error) {
+		if len(platforms) == 0 {
+			return nil, errors.New("no cluster")
+		}
+		nodes := []corev1.Node{}
+		for _, platform := range platforms {
+			arch, variant, _ := strings.Cut(platform, "/")
+			node := corev1.Node{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{Architecture: arch}}}
+			if variant != "" {
+				node.ObjectMeta = metav1.ObjectMeta{Labels: map[string]string{config.NodePlatformVariantLabel: variant}}
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes, nil
+	}
+}
+
+func Test_validatePlatformVariant(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		variant     string
+		nodes       []string
+		skip        bool
+		wantErr     bool
+	}{
+		{
+			name:        "NoVariant",
+			description: "bundles without a variant deploy anywhere",
+			nodes:       []string{"arm64/v7"},
+		}, {
+			name:        "NodesMatch",
+			description: "every node has the bundle's variant",
+			variant:     "v8",
+			nodes:       []string{"arm64/v8", "arm64/v8"},
+		}, {
+			name:        "NodeMismatch",
+			description: "error when a node has another variant",
+			variant:     "v8",
+			nodes:       []string{"arm64/v8", "arm64/v7"},
+			wantErr:     true,
+		}, {
+			name:        "ArchMismatch",
+			description: "the variant is still checked when the architecture check was skipped",
+			variant:     "v8",
+			nodes:       []string{"amd64/v3"},
+			wantErr:     true,
+		}, {
+			name:        "UnlabeledNodes",
+			description: "nodes with an unknown variant are skipped",
+			variant:     "v8",
+			nodes:       []string{"arm64", "arm64/v8"},
+		}, {
+			name:        "Skipped",
+			description: "--skip-variant-check deploys anyway",
+			variant:     "v8",
+			nodes:       []string{"arm64/v7"},
+			skip:        true,
+		}, {
+			name:        "NoCluster",
+			description: "without a cluster the local variant is compared, it's unknown for amd64",
+			variant:     "v8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bundler{
+				cfg: &types.BundlerConfig{
+					Arch:       types.ArchContext{Host: "amd64"},
+					DeployOpts: types.BundlerDeployOptions{SkipVariantCheck: tt.skip},
+				},
+				bundle: types.UDSBundle{Metadata: types.UDSMetadata{Architecture: "arm64", PlatformVariant: tt.variant}},
+			}
+			if err := b.validatePlatformVariant(listTestNodes(tt.nodes...)); (err != nil) != tt.wantErr {
+				t.Errorf("validatePlatformVariant() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_validateArchitecture(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		arch        string
+		nodes       []string
+		skip        bool
+		wantErr     bool
+	}{
+		{
+			name:        "AllMatch",
+			description: "every node has the bundle's architecture",
+			arch:        "arm64",
+			nodes:       []string{"arm64", "arm64", "arm64"},
+		}, {
+			name:        "OneMismatch",
+			description: "error when a single node has another architecture",
+			arch:        "arm64",
+			nodes:       []string{"amd64"},
+			wantErr:     true,
+		}, {
+			name:        "Mixed",
+			description: "error when the cluster mixes architectures, some nodes can't run the bundle",
+			arch:        "arm64",
+			nodes:       []string{"arm64", "amd64", "arm64"},
+			wantErr:     true,
+		}, {
+			name:        "Skipped",
+			description: "--skip-arch-check deploys anyway",
+			arch:        "arm64",
+			nodes:       []string{"arm64", "amd64"},
+			skip:        true,
+		}, {
+			name:        "NoArch",
+			description: "bundles without an architecture aren't checked",
+			nodes:       []string{"amd64"},
+		}, {
+			name:        "NoClusterMatch",
+			description: "without a cluster the local architecture is compared",
+			arch:        "amd64",
+		}, {
+			name:        "NoClusterMismatch",
+			description: "error when there's no cluster and the local architecture is different",
+			arch:        "arm64",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bundler{
+				cfg: &types.BundlerConfig{
+					Arch:       types.ArchContext{Host: "amd64"},
+					DeployOpts: types.BundlerDeployOptions{SkipArchCheck: tt.skip},
+				},
+				bundle: types.UDSBundle{Metadata: types.UDSMetadata{Architecture: tt.arch}},
+			}
+			if err := b.validateArchitecture(listTestNodes(tt.nodes...)); (err != nil) != tt.wantErr {
+				t.Errorf("validateArchitecture() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
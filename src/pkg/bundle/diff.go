@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/pterm/pterm"
+)
+
+// the changes a deploy of a bundle would make to each of its packages
+const (
+	packageChangeAdd         = "add"
+	packageChangeUpdate      = "update"
+	packageChangeUnchanged   = "unchanged"
+	packageChangeNotInBundle = "not-in-bundle"
+)
+
+// packageChange is what deploying a bundle would do to one package compared to the bundle's deploy record
+type packageChange struct {
+	Package  string `json:"package"`
+	Change   string `json:"change"`
+	Deployed string `json:"deployed,omitempty"`
+	Bundle   string `json:"bundle,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// diffDeployed compares a bundle's packages to its deploy record, in deploy order with the recorded packages the
+// bundle no longer has last. Deploy leaves those installed, they're listed so drift is visible. A nil record means
+// the bundle was never deployed
+func diffDeployed(packages []types.BundleZarfPackage, record *types.UDSDeployRecord) []packageChange {
+	if record == nil {
+		record = &types.UDSDeployRecord{}
+	}
+	deployed := make(map[string]types.UDSDeployedPackage)
+	for _, pkg := range record.Packages {
+		deployed[pkg.Name] = pkg
+	}
+	unchanged := unchangedPackages(packages, record)
+
+	changes := []packageChange{}
+	inBundle := make(map[string]bool)
+	for _, pkg := range packages {
+		inBundle[pkg.Name] = true
+		_, digest, _ := strings.Cut(pkg.Ref, "@")
+		change := packageChange{Package: pkg.Name, Bundle: digest}
+		prev, ok := deployed[pkg.Name]
+		_, same := unchanged[pkg.Name]
+		switch {
+		case !ok:
+			change.Change = packageChangeAdd
+		case same:
+			change.Change = packageChangeUnchanged
+			change.Deployed = prev.Digest
+		default:
+			change.Change = packageChangeUpdate
+			change.Deployed = prev.Digest
+			reasons := []string{}
+			if prev.Digest != digest {
+				reasons = append(reasons, "digest changed")
+			}
+			if strings.Join(prev.OptionalComponents, ",") != strings.Join(pkg.OptionalComponents, ",") {
+				reasons = append(reasons, fmt.Sprintf("optional components changed from [%s] to [%s]",
+					strings.Join(prev.OptionalComponents, ", "), strings.Join(pkg.OptionalComponents, ", ")))
+			}
+			change.Reason = strings.Join(reasons, ", ")
+		}
+		changes = append(changes, change)
+	}
+	for _, pkg := range record.Packages {
+		if !inBundle[pkg.Name] {
+			changes = append(changes, packageChange{
+				Package:  pkg.Name,
+				Change:   packageChangeNotInBundle,
+				Deployed: pkg.Digest,
+				Reason:   "deployed but no longer in the bundle, deploy leaves it installed",
+			})
+		}
+	}
+	return changes
+}
+
+// showDeployedDiff reports what deploying the bundle would change compared to the deploy record in the cluster,
+// without changing anything
+func (b *Bundler) showDeployedDiff() error {
+	if err := requireBundleYAML(&b.bundle, "diff"); err != nil {
+		return err
+	}
+	packages, err := sortPackagesByDependencies(b.bundle.ZarfPackages)
+	if err != nil {
+		return err
+	}
+	var record *types.UDSDeployRecord
+	records, err := readDeployRecords(b.bundle.Metadata.Name, "")
+	if errors.Is(err, errNoDeployRecord) {
+		message.Infof("Bundle %s has no deploy record in the cluster, every package would be added", b.bundle.Metadata.Name)
+	} else if err != nil {
+		return err
+	} else {
+		record = &records[0]
+	}
+	changes := diffDeployed(packages, record)
+
+	if b.cfg.InspectOpts.JSON {
+		out, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	counts := make(map[string]int)
+	table := pterm.TableData{{"Package", "Change", "Deployed", "Bundle", "Reason"}}
+	for _, change := range changes {
+		counts[change.Change]++
+		table = append(table, []string{change.Package, change.Change, change.Deployed, change.Bundle, change.Reason})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return err
+	}
+	message.Infof("A deploy would add %d, update %d and leave %d packages unchanged", counts[packageChangeAdd], counts[packageChangeUpdate], counts[packageChangeUnchanged])
+	return nil
+}
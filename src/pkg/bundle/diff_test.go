@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_diffDeployed(t *testing.T) {
+	const (
+		oldDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+		newDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	)
+	record := &types.UDSDeployRecord{Packages: []types.UDSDeployedPackage{
+		{Name: "init", Digest: oldDigest},
+		{Name: "podinfo", Digest: oldDigest},
+		{Name: "nginx", Digest: oldDigest, OptionalComponents: []string{"metrics"}},
+		{Name: "legacy", Digest: oldDigest},
+	}}
+	pkg := func(name, digest string, optional ...string) types.BundleZarfPackage {
+		return types.BundleZarfPackage{Name: name, Ref: "0.0.1@" + digest, OptionalComponents: optional}
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		packages    []types.BundleZarfPackage
+		record      *types.UDSDeployRecord
+		want        []packageChange
+	}{
+		{
+			name:        "NeverDeployed",
+			description: "without a deploy record every package is added",
+			packages:    []types.BundleZarfPackage{pkg("podinfo", newDigest)},
+			want:        []packageChange{{Package: "podinfo", Change: packageChangeAdd, Bundle: newDigest}},
+		},
+		{
+			name:        "Mixed",
+			description: "packages are added, updated or unchanged and recorded packages the bundle dropped are listed last",
+			packages: []types.BundleZarfPackage{
+				pkg("init", oldDigest),
+				pkg("podinfo", newDigest),
+				pkg("nginx", oldDigest),
+				pkg("redis", newDigest),
+			},
+			record: record,
+			want: []packageChange{
+				{Package: "init", Change: packageChangeUnchanged, Deployed: oldDigest, Bundle: oldDigest},
+				{Package: "podinfo", Change: packageChangeUpdate, Deployed: oldDigest, Bundle: newDigest, Reason: "digest changed"},
+				{Package: "nginx", Change: packageChangeUpdate, Deployed: oldDigest, Bundle: oldDigest, Reason: "optional components changed from [metrics] to []"},
+				{Package: "redis", Change: packageChangeAdd, Bundle: newDigest},
+				{Package: "legacy", Change: packageChangeNotInBundle, Deployed: oldDigest, Reason: "deployed but no longer in the bundle, deploy leaves it installed"},
+			},
+		},
+		{
+			name:        "BothChanged",
+			description: "a package whose digest and optional components changed reports both",
+			packages:    []types.BundleZarfPackage{pkg("nginx", newDigest, "metrics", "logging")},
+			record:      &types.UDSDeployRecord{Packages: record.Packages[2:3]},
+			want: []packageChange{{Package: "nginx", Change: packageChangeUpdate, Deployed: oldDigest, Bundle: newDigest,
+				Reason: "digest changed, optional components changed from [metrics] to [metrics, logging]"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffDeployed(tt.packages, tt.record); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffDeployed() = %+v, want %+v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
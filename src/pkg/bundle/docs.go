@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// docsExtensions are the extensions of the package layers shown as docs, anything else under docs/ (ie. images) isn't text
+var docsExtensions = []string{"", ".md", ".markdown", ".txt"}
+
+var (
+	markdownFenceRegex    = regexp.MustCompile("^\\s*(```|~~~)")
+	markdownHeadingRegex  = regexp.MustCompile(`^#{1,6}\s+`)
+	markdownImageRegex    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLinkRegex     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	markdownEmphasisRegex = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	markdownCodeRegex     = regexp.MustCompile("`([^`]+)`")
+)
+
+// isDocsLayer returns true for the README of a package and the text files under its docs/ directory
+func isDocsLayer(title string) bool {
+	ext := strings.ToLower(path.Ext(title))
+	if !isDocsExtension(ext) {
+		return false
+	}
+	if strings.HasPrefix(title, "docs/") {
+		return true
+	}
+	return !strings.Contains(title, "/") && strings.EqualFold(strings.TrimSuffix(title, path.Ext(title)), "readme")
+}
+
+// isDocsExtension returns true if ext is one of docsExtensions
+func isDocsExtension(ext string) bool {
+	for _, docsExt := range docsExtensions {
+		if ext == docsExt {
+			return true
+		}
+	}
+	return false
+}
+
+// docsLayers returns the docs layers of a package manifest with the README first and the rest sorted by title
+func docsLayers(manifest *oci.ZarfOCIManifest) []ocispec.Descriptor {
+	var layers []ocispec.Descriptor
+	for _, layer := range manifest.Layers {
+		if isDocsLayer(layer.Annotations[ocispec.AnnotationTitle]) {
+			layers = append(layers, layer)
+		}
+	}
+	sort.SliceStable(layers, func(i, j int) bool {
+		ti, tj := layers[i].Annotations[ocispec.AnnotationTitle], layers[j].Annotations[ocispec.AnnotationTitle]
+		if inDocsI, inDocsJ := strings.HasPrefix(ti, "docs/"), strings.HasPrefix(tj, "docs/"); inDocsI != inDocsJ {
+			return inDocsJ
+		}
+		return ti < tj
+	})
+	return layers
+}
+
+// markdownToText renders markdown as plain text for the terminal, keeping the words of headings, links and emphasis
+// and the contents of code blocks
+func markdownToText(md string) string {
+	var lines []string
+	inFence := false
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		if markdownFenceRegex.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			lines = append(lines, "    "+line)
+			continue
+		}
+		line = markdownHeadingRegex.ReplaceAllString(line, "")
+		line = markdownImageRegex.ReplaceAllString(line, "$1")
+		line = markdownLinkRegex.ReplaceAllString(line, "$1 ($2)")
+		line = markdownEmphasisRegex.ReplaceAllString(line, "$2")
+		line = markdownCodeRegex.ReplaceAllString(line, "$1")
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// showPackageDocs prints the README and docs/ of the bundle's packages (or only --package) without loading their other layers
+func (b *Bundler) showPackageDocs(provider Provider) error {
+	pkgName := b.cfg.InspectOpts.Package
+	found := false
+	for _, pkg := range b.bundle.ZarfPackages {
+		if pkgName != "" && pkg.Name != pkgName {
+			continue
+		}
+		found = true
+		pin, err := pinnedDigest(pkg)
+		if err != nil {
+			return err
+		}
+		sha := pin.Encoded()
+		manifest, err := provider.LoadPackageManifest(sha)
+		if err != nil {
+			return err
+		}
+		layers := docsLayers(manifest)
+		if len(layers) == 0 {
+			message.Infof("Package %s has no docs", pkg.Name)
+			continue
+		}
+		docs, err := provider.FetchPackageLayers(sha, layers...)
+		if err != nil {
+			return err
+		}
+		for _, layer := range layers {
+			message.HeaderInfof("%s: %s", pkg.Name, layer.Annotations[ocispec.AnnotationTitle])
+			fmt.Println(markdownToText(string(docs[layer.Digest])))
+		}
+	}
+	if !found {
+		return fmt.Errorf("package %s is not in bundle %s", pkgName, b.bundle.Metadata.Name)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"testing"
+
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func Test_docsLayers(t *testing.T) {
+	titled := func(title string) ocispec.Descriptor {
+		return ocispec.Descriptor{Annotations: map[string]string{ocispec.AnnotationTitle: title}}
+	}
+	tests := []struct {
+		name        string
+		description string
+		titles      []string
+		want        []string
+	}{
+		{
+			name:        "NoDocs",
+			description: "packages without a README or docs/ have no docs",
+			titles:      []string{"zarf.yaml", "checksums.txt", "components/app.tar"},
+		},
+		{
+			name:        "READMEFirst",
+			description: "the README comes before docs/, which are sorted by title",
+			titles:      []string{"docs/upgrade.md", "zarf.yaml", "docs/install.md", "README.md"},
+			want:        []string{"README.md", "docs/install.md", "docs/upgrade.md"},
+		},
+		{
+			name:        "TextOnly",
+			description: "images under docs/ and READMEs inside components aren't docs",
+			titles:      []string{"docs/diagram.png", "components/app/README.md", "readme.txt"},
+			want:        []string{"readme.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := &oci.ZarfOCIManifest{}
+			for _, title := range tt.titles {
+				manifest.Layers = append(manifest.Layers, titled(title))
+			}
+			got := docsLayers(manifest)
+			if len(got) != len(tt.want) {
+				t.Fatalf("docsLayers() returned %d layers, want %d (%s)", len(got), len(tt.want), tt.description)
+			}
+			for i, layer := range got {
+				if title := layer.Annotations[ocispec.AnnotationTitle]; title != tt.want[i] {
+					t.Errorf("docsLayers()[%d] = %s, want %s (%s)", i, title, tt.want[i], tt.description)
+				}
+			}
+		})
+	}
+}
+
+func Test_markdownToText(t *testing.T) {
+	md := "# Podinfo\n\nDeploys **podinfo**, see the [docs](https://example.com) ![logo](logo.png).\n\n```bash\nuds deploy `podinfo`\n```\nSet `PODINFO_REPLICAS` to scale."
+	want := "Podinfo\n\nDeploys podinfo, see the docs (https://example.com) logo.\n\n    uds deploy `podinfo`\nSet PODINFO_REPLICAS to scale."
+	if got := markdownToText(md); got != want {
+		t.Errorf("markdownToText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+)
+
+// excludePackages removes the named packages from a bundle so one uds-bundle.yaml can build several variants of
+// it. Every name must be in the bundle, and the packages left can't depend on or import variables from the excluded
+// ones. Excluding every package is an error unless allowEmpty
+func excludePackages(bundle *types.UDSBundle, names []string, allowEmpty bool) error {
+	if len(names) == 0 {
+		return nil
+	}
+	exists := map[string]bool{}
+	for _, pkg := range bundle.ZarfPackages {
+		exists[pkg.Name] = true
+	}
+	for _, name := range names {
+		if !exists[name] {
+			return fmt.Errorf("--exclude-package %s is not a package in %s", name, config.BundleYAML)
+		}
+	}
+
+	kept := []types.BundleZarfPackage{}
+	for _, pkg := range bundle.ZarfPackages {
+		if !helpers.SliceContains(names, pkg.Name) {
+			kept = append(kept, pkg)
+		}
+	}
+
+	var errs []string
+	for _, pkg := range kept {
+		for _, dep := range pkg.DependsOn {
+			if helpers.SliceContains(names, dep) {
+				errs = append(errs, fmt.Sprintf("zarf pkg %s depends on %s", pkg.Name, dep))
+			}
+		}
+		for _, imp := range pkg.Imports {
+			if helpers.SliceContains(names, imp.Package) {
+				errs = append(errs, fmt.Sprintf("zarf pkg %s imports %s from %s", pkg.Name, imp.Name, imp.Package))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("--exclude-package removes packages the rest of the bundle needs:\n - %s", strings.Join(errs, "\n - "))
+	}
+	if len(kept) == 0 && !allowEmpty {
+		return fmt.Errorf("--exclude-package removes every package in %s, use --allow-empty to create an empty bundle", config.BundleYAML)
+	}
+
+	bundle.ZarfPackages = kept
+	return nil
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_excludePackages(t *testing.T) {
+	packages := []types.BundleZarfPackage{
+		{Name: "init"},
+		{Name: "podinfo", DependsOn: []string{"init"}},
+		{Name: "nginx", Imports: []types.BundleVariableImport{{Name: "DOMAIN", Package: "podinfo"}}},
+		{Name: "redis"},
+	}
+	tests := []struct {
+		name        string
+		description string
+		exclude     []string
+		allowEmpty  bool
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "None",
+			description: "no exclusions keep every package",
+			want:        []string{"init", "podinfo", "nginx", "redis"},
+		},
+		{
+			name:        "Exclude",
+			description: "excluded packages are removed in place",
+			exclude:     []string{"redis", "nginx"},
+			want:        []string{"init", "podinfo"},
+		},
+		{
+			name:        "Unknown",
+			description: "names that aren't in the bundle are refused",
+			exclude:     []string{"redis", "postgres"},
+			wantErr:     true,
+		},
+		{
+			name:        "DependedOn",
+			description: "packages the kept ones depend on can't be excluded",
+			exclude:     []string{"init"},
+			wantErr:     true,
+		},
+		{
+			name:        "Imported",
+			description: "packages the kept ones import variables from can't be excluded",
+			exclude:     []string{"podinfo"},
+			wantErr:     true,
+		},
+		{
+			name:        "DependentsExcluded",
+			description: "a package can be excluded along with the packages that need it",
+			exclude:     []string{"podinfo", "nginx"},
+			want:        []string{"init", "redis"},
+		},
+		{
+			name:        "Empty",
+			description: "excluding every package is refused",
+			exclude:     []string{"init", "podinfo", "nginx", "redis"},
+			wantErr:     true,
+		},
+		{
+			name:        "AllowEmpty",
+			description: "--allow-empty allows excluding every package",
+			exclude:     []string{"init", "podinfo", "nginx", "redis"},
+			allowEmpty:  true,
+			want:        []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := &types.UDSBundle{ZarfPackages: append([]types.BundleZarfPackage{}, packages...)}
+			err := excludePackages(bundle, tt.exclude, tt.allowEmpty)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("excludePackages() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if err != nil {
+				if len(bundle.ZarfPackages) != len(packages) {
+					t.Errorf("excludePackages() modified the bundle though it failed (%s)", tt.description)
+				}
+				return
+			}
+			got := []string{}
+			for _, pkg := range bundle.ZarfPackages {
+				got = append(got, pkg.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("excludePackages() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
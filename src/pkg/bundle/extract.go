@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	av4 "github.com/mholt/archiver/v4"
+)
+
+// Extract pulls a single file out of a bundle tarball without unpacking the rest of the archive
+func (b *Bundler) Extract() error {
+	source, err := b.fetchSource(b.cfg.ExtractOpts.Source)
+	if err != nil {
+		return err
+	}
+	tp := &tarballBundleProvider{ctx: context.TODO(), src: source, dst: b.tmp}
+
+	pathInArchive, err := tp.resolvePathInArchive(b.cfg.ExtractOpts.Path)
+	if err != nil {
+		return err
+	}
+
+	dst := b.cfg.ExtractOpts.OutputFile
+	if dst == "" {
+		dst = filepath.Base(b.cfg.ExtractOpts.Path)
+	}
+
+	if err := tp.extractFile(pathInArchive, dst); err != nil {
+		return err
+	}
+
+	message.Successf("Extracted %s to %s", pathInArchive, dst)
+	return nil
+}
+
+// resolvePathInArchive maps a friendly name (ie. uds-bundle.yaml) to its blob path, otherwise the path is returned as-is
+func (tp *tarballBundleProvider) resolvePathInArchive(path string) (string, error) {
+	if err := tp.getBundleManifest(); err != nil {
+		return "", err
+	}
+	layer := tp.manifest.Locate(path)
+	if !oci.IsEmptyDescriptor(layer) {
+		return utils.BlobPath(layer.Digest), nil
+	}
+	return filepath.Clean(path), nil
+}
+
+// matchesPathInArchive reports whether the archive entry name is pathInArchive or in the directory pathInArchive, paths only
+// match on whole path segments (ie. blobs/sha256/abc doesn't match blobs/sha256/abcdef)
+func matchesPathInArchive(name, pathInArchive string) bool {
+	return name == pathInArchive || strings.HasPrefix(name, pathInArchive+"/")
+}
+
+// extractFile writes a single entry from the bundle tarball to dst, if pathInArchive is a directory its files are
+// written under dst
+func (tp *tarballBundleProvider) extractFile(pathInArchive, dst string) error {
+	pathInArchive = strings.TrimSuffix(pathInArchive, "/")
+	format := av4.CompressedArchive{
+		Compression: av4.Zstd{},
+		Archival:    av4.Tar{},
+	}
+
+	sourceArchive, err := os.Open(tp.src)
+	if err != nil {
+		return err
+	}
+	defer sourceArchive.Close()
+
+	found := false
+	extractEntry := func(_ context.Context, file av4.File) error {
+		if file.IsDir() || !matchesPathInArchive(file.NameInArchive, pathInArchive) {
+			return nil
+		}
+		found = true
+		target := dst
+		if file.NameInArchive != pathInArchive {
+			target = filepath.Join(dst, strings.TrimPrefix(file.NameInArchive, pathInArchive+"/"))
+		}
+		stream, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		if err := zarfUtils.CreateDirectory(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, stream)
+		return err
+	}
+
+	if err := format.Extract(tp.ctx, sourceArchive, []string{pathInArchive}, extractEntry); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s does not exist in %s", pathInArchive, tp.src)
+	}
+	return nil
+}
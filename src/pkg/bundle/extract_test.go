@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func Test_matchesPathInArchive(t *testing.T) {
+	tests := []struct {
+		name          string
+		pathInArchive string
+		want          bool
+	}{
+		{name: "blobs/sha256/abc", pathInArchive: "blobs/sha256/abc", want: true},
+		{name: "blobs/sha256/abc", pathInArchive: "blobs/sha256", want: true},
+		{name: "blobs/sha256/abcdef", pathInArchive: "blobs/sha256/abc", want: false},
+		{name: "blobs/sha256/abc", pathInArchive: "blobs/sha", want: false},
+		{name: "index.json", pathInArchive: "index", want: false},
+	}
+	for _, tt := range tests {
+		if got := matchesPathInArchive(tt.name, tt.pathInArchive); got != tt.want {
+			t.Errorf("matchesPathInArchive(%s, %s) = %v, want %v", tt.name, tt.pathInArchive, got, tt.want)
+		}
+	}
+}
+
+func Test_extractFile(t *testing.T) {
+	tarball, blobs := writeTestBundle(t)
+	tp := &tarballBundleProvider{ctx: context.TODO(), src: tarball, dst: t.TempDir()}
+
+	var blob digest.Digest
+	for dgst := range blobs {
+		blob = digest.Digest(dgst)
+		break
+	}
+	blobPath := filepath.Join("blobs", "sha256", blob.Encoded())
+
+	tests := []struct {
+		name          string
+		description   string
+		pathInArchive string
+		wantFiles     int
+		wantErr       bool
+	}{
+		{
+			name:          "File",
+			description:   "a file's path extracts only that file",
+			pathInArchive: blobPath,
+			wantFiles:     1,
+		}, {
+			name:          "Directory",
+			description:   "a directory's path extracts every file in it",
+			pathInArchive: "blobs/sha256/",
+			wantFiles:     len(blobs),
+		}, {
+			name:          "PartialName",
+			description:   "error when the path is only a prefix of a file's name",
+			pathInArchive: blobPath[:len(blobPath)-4],
+			wantErr:       true,
+		}, {
+			name:          "PartialDirectory",
+			description:   "error when the path is only a prefix of a directory's name",
+			pathInArchive: "blobs/sha",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "out")
+			err := tp.extractFile(tt.pathInArchive, dst)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractFile() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			files := 0
+			err = filepath.Walk(dst, func(_ string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					files++
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if files != tt.wantFiles {
+				t.Errorf("extractFile() wrote %d files, want %d (%s)", files, tt.wantFiles, tt.description)
+			}
+		})
+	}
+}
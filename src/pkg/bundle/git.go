@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/bundler"
+	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/packager"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/spf13/pflag"
+)
+
+// commitSHARegex matches full commit SHAs, which can't be cloned with --branch and are fetched instead
+var commitSHARegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// resolveGitSources clones each package with a git source and points it at the package built from (or found in) the
+// clone, after which it's bundled like any other local package
+//
+// the returned func removes the clones, it must only be called once the bundle has been written
+func (b *Bundler) resolveGitSources() (func(), error) {
+	cleanup := func() {}
+	buildArgs, err := b.packageBuildArgs()
+	if err != nil {
+		return cleanup, err
+	}
+	if !hasGitSources(b.bundle.ZarfPackages) {
+		return cleanup, nil
+	}
+	cloneRoot, err := zarfUtils.MakeTempDir()
+	if err != nil {
+		return cleanup, err
+	}
+	cleanup = func() { _ = os.RemoveAll(cloneRoot) }
+
+	arch := b.cfg.Arch.Resolve(b.bundle.Metadata.Architecture)
+	for i, pkg := range b.bundle.ZarfPackages {
+		if pkg.Git == "" {
+			continue
+		}
+		if pkg.Repository != "" {
+			return cleanup, fmt.Errorf("zarf pkg %s cannot have both a repository and a git source", pkg.Name)
+		}
+		if pkg.Ref == "" {
+			return cleanup, fmt.Errorf("%s .packages[%s] is missing required field: ref", config.BundleYAML, pkg.Name)
+		}
+		// parse the build args before cloning so unrecognized flags fail fast
+		pkg.BuildArgs = append(pkg.BuildArgs, buildArgs[pkg.Name]...)
+		if _, err := parseBuildArgs(pkg.BuildArgs); err != nil {
+			return cleanup, fmt.Errorf("zarf pkg %s: %w", pkg.Name, err)
+		}
+
+		spinner := message.NewProgressSpinner("Cloning %s@%s for zarf pkg %s", pkg.Git, pkg.Ref, pkg.Name)
+		cloneDir := filepath.Join(cloneRoot, pkg.Name)
+		if err := cloneGitSource(pkg.Git, pkg.Ref, cloneDir); err != nil {
+			spinner.Stop()
+			return cleanup, fmt.Errorf("unable to clone %s for zarf pkg %s: %w", pkg.Git, pkg.Name, err)
+		}
+		pkgDir, err := gitPackageDir(cloneDir, pkg.Path)
+		if err != nil {
+			spinner.Stop()
+			return cleanup, fmt.Errorf("zarf pkg %s: %w", pkg.Name, err)
+		}
+		spinner.Successf("Cloned %s@%s", pkg.Git, pkg.Ref)
+
+		resolved, err := b.gitPackage(pkg, pkgDir, filepath.Join(cloneRoot, pkg.Name+"-build"), arch)
+		if err != nil {
+			return cleanup, fmt.Errorf("zarf pkg %s: %w", pkg.Name, err)
+		}
+		b.bundle.ZarfPackages[i] = resolved
+	}
+	return cleanup, nil
+}
+
+// packageBuildArgs returns the --package-build-args of each package, which must all be packages with a git source
+func (b *Bundler) packageBuildArgs() (map[string][]string, error) {
+	buildArgs := make(map[string][]string)
+	for _, value := range b.cfg.CreateOpts.PackageBuildArgs {
+		name, args, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --package-build-args %q, must be PACKAGE=ARGS", value)
+		}
+		if !hasGitSource(b.bundle.ZarfPackages, name) {
+			return nil, fmt.Errorf("--package-build-args %s: %s is not a package with a git source, only those are built", name, name)
+		}
+		buildArgs[name] = append(buildArgs[name], strings.Fields(args)...)
+	}
+	return buildArgs, nil
+}
+
+// hasGitSource returns true if the named package has a git source
+func hasGitSource(packages []types.BundleZarfPackage, name string) bool {
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return pkg.Git != ""
+		}
+	}
+	return false
+}
+
+// parseBuildArgs parses the build args of a package into Zarf's create options
+//
+// only the flags that don't change where the built package is written or how it's named are recognized, the bundle
+// has to find the package once it's built
+func parseBuildArgs(args []string) (zarfTypes.ZarfCreateOptions, error) {
+	opts := zarfTypes.ZarfCreateOptions{}
+	flags := pflag.NewFlagSet("zarf package create", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringToStringVar(&opts.SetVariables, "set", map[string]string{}, "")
+	flags.BoolVar(&opts.SkipSBOM, "skip-sbom", false, "")
+	flags.StringToStringVar(&opts.RegistryOverrides, "registry-override", map[string]string{}, "")
+	flags.StringVarP(&opts.SigningKeyPath, "key", "k", "", "")
+	flags.StringVar(&opts.SigningKeyPassword, "key-pass", "", "")
+	if err := flags.Parse(args); err != nil {
+		return opts, fmt.Errorf("invalid build args, the recognized flags are --set, --skip-sbom, --registry-override, --key and --key-pass: %w", err)
+	}
+	if flags.NArg() > 0 {
+		return opts, fmt.Errorf("invalid build args, expected only flags but got %q", strings.Join(flags.Args(), " "))
+	}
+	return opts, nil
+}
+
+// hasGitSources returns true if any of the packages have a git source
+func hasGitSources(packages []types.BundleZarfPackage) bool {
+	for _, pkg := range packages {
+		if pkg.Git != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneGitSource shallowly clones ref (a tag, branch or full commit SHA) of url into dir with the git CLI
+func cloneGitSource(url, ref, dir string) error {
+	if _, err := exec.LookPath(config.GitBinary); err != nil {
+		return fmt.Errorf("unable to find %s on the PATH, it is required to bundle packages from git", config.GitBinary)
+	}
+	if !commitSHARegex.MatchString(ref) {
+		return runGit("", "clone", "--quiet", "--depth", "1", "--branch", ref, "--", url, dir)
+	}
+	// commits aren't branches, fetch the one commit instead (most hosts allow fetching reachable commits by SHA)
+	if err := runGit("", "init", "--quiet", dir); err != nil {
+		return err
+	}
+	if err := runGit(dir, "fetch", "--quiet", "--depth", "1", "--", url, ref); err != nil {
+		return err
+	}
+	return runGit(dir, "checkout", "--quiet", "FETCH_HEAD")
+}
+
+// runGit runs the git CLI in dir, including git's output in the error when it fails
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command(config.GitBinary, args...)
+	cmd.Dir = dir
+	// --confirm runs unattended, fail instead of waiting on a credential prompt
+	if config.CommonOptions.Confirm {
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// gitPackageDir returns the package's directory within the clone, path may not point outside of it
+func gitPackageDir(cloneDir, path string) (string, error) {
+	pkgDir := filepath.Join(cloneDir, path)
+	rel, err := filepath.Rel(cloneDir, pkgDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of the git repository", path)
+	}
+	if !zarfUtils.IsDir(pkgDir) {
+		return "", fmt.Errorf("path %s is not a directory in the git repository", path)
+	}
+	return pkgDir, nil
+}
+
+// gitPackage returns pkg pointed at its package in pkgDir: a prebuilt package tarball, an unpacked package, or else a
+// package built from the zarf.yaml into buildDir
+func (b *Bundler) gitPackage(pkg types.BundleZarfPackage, pkgDir, buildDir, arch string) (types.BundleZarfPackage, error) {
+	buildArgs := pkg.BuildArgs
+	pkg.Git, pkg.BuildArgs = "", nil
+	tarball, version, ok, err := findPackageTarball(pkgDir, pkg.Name, arch)
+	if err != nil {
+		return pkg, err
+	}
+	if ok {
+		message.Debugf("Using prebuilt package %s", tarball)
+		warnUnusedBuildArgs(pkg.Name, buildArgs)
+		pkg.Path, pkg.Ref = pkgDir, version
+		return pkg, nil
+	}
+	// built packages carry checksums.txt, a bare zarf.yaml is a package definition
+	if bundler.IsPackageDir(pkgDir) && !zarfUtils.InvalidPath(filepath.Join(pkgDir, zarfConfig.ZarfChecksumsTxt)) {
+		warnUnusedBuildArgs(pkg.Name, buildArgs)
+		pkg.Path = pkgDir
+		return pkg, nil
+	}
+	if !bundler.IsPackageDir(pkgDir) {
+		return pkg, fmt.Errorf("no %s or prebuilt package found in %s", config.ZarfYAML, pkg.Path)
+	}
+
+	message.Infof("Building zarf pkg %s from %s@%s", pkg.Name, pkg.Path, pkg.Ref)
+	if err := b.buildGitPackage(pkgDir, buildDir, arch, buildArgs); err != nil {
+		return pkg, err
+	}
+	tarball, version, ok, err = findPackageTarball(buildDir, pkg.Name, arch)
+	if err != nil {
+		return pkg, err
+	}
+	if !ok {
+		return pkg, fmt.Errorf("the package built from %s must be named %s", pkg.Path, pkg.Name)
+	}
+	message.Debugf("Built package %s", tarball)
+	pkg.Path, pkg.Ref = buildDir, version
+	return pkg, nil
+}
+
+// warnUnusedBuildArgs warns that a package's build args were ignored because it was prebuilt
+func warnUnusedBuildArgs(name string, buildArgs []string) {
+	if len(buildArgs) > 0 {
+		message.Warnf("zarf pkg %s is prebuilt in its git repository, its build args are not used", name)
+	}
+}
+
+// findPackageTarball looks for the package tarball of the named package in dir and returns its path and version
+func findPackageTarball(dir, name, arch string) (string, string, bool, error) {
+	prefix := strings.TrimSuffix(zarfPackageTarballName(name, arch, ""), ".tar.zst")
+	matches, err := filepath.Glob(filepath.Join(dir, prefix+"*.tar.zst"))
+	if err != nil || len(matches) == 0 {
+		return "", "", false, err
+	}
+	if len(matches) > 1 {
+		return "", "", false, fmt.Errorf("found %d %s packages in %s, expected one", len(matches), name, dir)
+	}
+	version := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(matches[0]), prefix), ".tar.zst")
+	return matches[0], version, true, nil
+}
+
+// buildGitPackage runs a Zarf package create of the zarf.yaml in pkgDir for the bundle's architecture with the
+// package's build args, writing the package tarball to buildDir
+func (b *Bundler) buildGitPackage(pkgDir, buildDir, arch string, buildArgs []string) error {
+	createOpts, err := parseBuildArgs(buildArgs)
+	if err != nil {
+		return err
+	}
+	if err := zarfUtils.CreateDirectory(buildDir, 0700); err != nil {
+		return err
+	}
+	// the bundle's create was already confirmed, don't prompt again for each package
+	confirm, cliArch := zarfConfig.CommonOptions.Confirm, zarfConfig.CLIArch
+	zarfConfig.CommonOptions.Confirm, zarfConfig.CLIArch = true, arch
+	defer func() {
+		zarfConfig.CommonOptions.Confirm, zarfConfig.CLIArch = confirm, cliArch
+	}()
+
+	createOpts.Output = buildDir
+	createOpts.SkipSBOM = createOpts.SkipSBOM || b.cfg.CreateOpts.ExcludeSBOM
+	pkgCfg := zarfTypes.PackagerConfig{CreateOpts: createOpts}
+	pkgClient, err := packager.New(&pkgCfg)
+	if err != nil {
+		return err
+	}
+	defer pkgClient.ClearTempPaths()
+	if err := pkgClient.Create(pkgDir); err != nil {
+		return fmt.Errorf("unable to build the package in %s: %w", pkgDir, err)
+	}
+	return nil
+}
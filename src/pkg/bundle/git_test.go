@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+)
+
+// writeTestGitRepo creates a git repo with a single commit tagged v0.0.1 holding path/zarf.yaml and returns its path and the commit's SHA
+func writeTestGitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath(config.GitBinary); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "pkg"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "pkg", config.ZarfYAML), []byte(testZarfYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command(config.GitBinary, append([]string{"-c", "user.name=test", "-c", "user.email=test@uds.dev"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %s", args[0], out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "add package")
+	git("tag", "v0.0.1")
+	return repo, git("rev-parse", "HEAD")
+}
+
+func Test_cloneGitSource(t *testing.T) {
+	repo, sha := writeTestGitRepo(t)
+
+	tests := []struct {
+		name        string
+		description string
+		ref         string
+		wantErr     bool
+	}{
+		{
+			name:        "Tag",
+			description: "tags are shallowly cloned",
+			ref:         "v0.0.1",
+		},
+		{
+			name:        "Commit",
+			description: "full commit SHAs are fetched",
+			ref:         sha,
+		},
+		{
+			name:        "MissingRef",
+			description: "refs that don't exist fail with git's error",
+			ref:         "v9.9.9",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "clone")
+			err := cloneGitSource(repo, tt.ref, dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cloneGitSource() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, err := os.Stat(filepath.Join(dir, "pkg", config.ZarfYAML)); err != nil {
+				t.Errorf("cloneGitSource() did not check out pkg/%s: %v", config.ZarfYAML, err)
+			}
+		})
+	}
+}
+
+func Test_gitPackageDir(t *testing.T) {
+	clone := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(clone, "packages", "app"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		path        string
+		wantErr     bool
+	}{
+		{
+			name:        "Root",
+			description: "an empty path is the root of the repository",
+			path:        "",
+		},
+		{
+			name:        "Subdirectory",
+			description: "paths are relative to the root of the repository",
+			path:        "packages/app",
+		},
+		{
+			name:        "Escapes",
+			description: "paths may not point outside of the repository",
+			path:        "../packages",
+			wantErr:     true,
+		},
+		{
+			name:        "Missing",
+			description: "paths must be directories in the repository",
+			path:        "packages/database",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gitPackageDir(clone, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("gitPackageDir() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_findPackageTarball(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		files       []string
+		wantVersion string
+		wantFound   bool
+		wantErr     bool
+	}{
+		{
+			name:        "NoTarball",
+			description: "a directory without the package's tarball isn't prebuilt",
+			files:       []string{config.ZarfYAML, "zarf-package-other-amd64-0.0.1.tar.zst"},
+		},
+		{
+			name:        "Tarball",
+			description: "the package's version is read from its tarball's name",
+			files:       []string{"zarf-package-app-amd64-1.2.3.tar.zst"},
+			wantVersion: "1.2.3",
+			wantFound:   true,
+		},
+		{
+			name:        "OtherArchitecture",
+			description: "tarballs for other architectures are ignored",
+			files:       []string{"zarf-package-app-arm64-1.2.3.tar.zst"},
+		},
+		{
+			name:        "Ambiguous",
+			description: "more than one version of the package is an error",
+			files:       []string{"zarf-package-app-amd64-1.2.3.tar.zst", "zarf-package-app-amd64-1.2.4.tar.zst"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, version, found, err := findPackageTarball(dir, "app", "amd64")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findPackageTarball() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if found != tt.wantFound || version != tt.wantVersion {
+				t.Errorf("findPackageTarball() = %q, %v, want %q, %v (%s)", version, found, tt.wantVersion, tt.wantFound, tt.description)
+			}
+		})
+	}
+}
+
+func Test_parseBuildArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		args        []string
+		wantSet     map[string]string
+		wantSkip    bool
+		wantErr     bool
+	}{
+		{
+			name:        "SetVariables",
+			description: "repeated --set flags are merged, the last value of a variable wins",
+			args:        []string{"--set", "DOMAIN=uds.dev", "--set=REPLICAS=1", "--set", "REPLICAS=3"},
+			wantSet:     map[string]string{"DOMAIN": "uds.dev", "REPLICAS": "3"},
+		},
+		{
+			name:        "SkipSBOM",
+			description: "boolean flags are recognized",
+			args:        []string{"--skip-sbom"},
+			wantSet:     map[string]string{},
+			wantSkip:    true,
+		},
+		{
+			name:        "Unrecognized",
+			description: "flags that would change where the package is written are rejected",
+			args:        []string{"--output", "/tmp"},
+			wantErr:     true,
+		},
+		{
+			name:        "Positional",
+			description: "arguments that aren't flags are rejected",
+			args:        []string{"--skip-sbom", "zarf.yaml"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseBuildArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBuildArgs() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(opts.SetVariables, tt.wantSet) || opts.SkipSBOM != tt.wantSkip {
+				t.Errorf("parseBuildArgs() = %v, skip-sbom %v, want %v, %v (%s)", opts.SetVariables, opts.SkipSBOM, tt.wantSet, tt.wantSkip, tt.description)
+			}
+		})
+	}
+}
+
+func Test_packageBuildArgs(t *testing.T) {
+	packages := []types.BundleZarfPackage{
+		{Name: "app", Git: "https://github.com/corang/app.git", Ref: "v0.0.1"},
+		{Name: "init", Repository: "ghcr.io/defenseunicorns/packages/init", Ref: "v0.29.1"},
+	}
+	tests := []struct {
+		name        string
+		description string
+		values      []string
+		want        map[string][]string
+		wantErr     bool
+	}{
+		{
+			name:        "GitPackage",
+			description: "the args of a package with a git source are split on whitespace and repeats are appended",
+			values:      []string{"app=--set DOMAIN=uds.dev", "app=--skip-sbom"},
+			want:        map[string][]string{"app": {"--set", "DOMAIN=uds.dev", "--skip-sbom"}},
+		},
+		{
+			name:        "RemotePackage",
+			description: "packages without a git source aren't built",
+			values:      []string{"init=--skip-sbom"},
+			wantErr:     true,
+		},
+		{
+			name:        "MissingPackage",
+			description: "the package name is required",
+			values:      []string{"--skip-sbom"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bundler{
+				cfg:    &types.BundlerConfig{CreateOpts: types.BundlerCreateOptions{PackageBuildArgs: tt.values}},
+				bundle: types.UDSBundle{ZarfPackages: packages},
+			}
+			got, err := b.packageBuildArgs()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("packageBuildArgs() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packageBuildArgs() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/transform"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	goyaml "github.com/goccy/go-yaml"
+)
+
+// imagePolicy is the policy images in a bundle's packages are checked against at create time
+type imagePolicy struct {
+	Deny []imageRule `json:"deny"`
+	// Scanner is a command run with each image as its last argument, a nonzero exit denies the image
+	Scanner string `json:"scanner,omitempty"`
+}
+
+// imageRule denies images matching Pattern, where * matches any run of characters (including /)
+type imageRule struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// imageViolation is an image a package would bundle that the image policy denies
+type imageViolation struct {
+	Package   string
+	Component string
+	Image     string
+	Rule      string
+}
+
+func (v imageViolation) String() string {
+	return fmt.Sprintf("%s (package %s, component %s): %s", v.Image, v.Package, v.Component, v.Rule)
+}
+
+// loadImagePolicy merges the --image-policy file with the --deny-image patterns, nil means there's nothing to enforce
+func loadImagePolicy(policyPath string, denyPatterns []string) (*imagePolicy, error) {
+	policy := &imagePolicy{}
+	if policyPath != "" {
+		b, err := os.ReadFile(policyPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read image policy: %w", err)
+		}
+		if err := goyaml.UnmarshalWithOptions(b, policy, goyaml.Strict()); err != nil {
+			return nil, fmt.Errorf("invalid image policy %s: %w", policyPath, err)
+		}
+	}
+	for _, pattern := range denyPatterns {
+		policy.Deny = append(policy.Deny, imageRule{Pattern: pattern})
+	}
+	for _, rule := range policy.Deny {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("image policy deny rules must have a pattern")
+		}
+	}
+	if len(policy.Deny) == 0 && policy.Scanner == "" {
+		return nil, nil
+	}
+	return policy, nil
+}
+
+// check returns the reason image is denied, or an empty string if it's allowed
+//
+// patterns are matched against the image as written and fully qualified, so *:latest catches untagged images and
+// docker.io/* catches images without a registry
+func (p *imagePolicy) check(image string) (string, error) {
+	refs := []string{image}
+	if parsed, err := transform.ParseImageRef(image); err == nil && parsed.Reference != image {
+		refs = append(refs, parsed.Reference)
+	}
+	for _, rule := range p.Deny {
+		for _, ref := range refs {
+			if !globMatch(rule.Pattern, ref) {
+				continue
+			}
+			reason := fmt.Sprintf("matches deny pattern %q", rule.Pattern)
+			if rule.Reason != "" {
+				reason += ", " + rule.Reason
+			}
+			return reason, nil
+		}
+	}
+
+	if p.Scanner == "" {
+		return "", nil
+	}
+	args := strings.Fields(p.Scanner)
+	out, err := exec.Command(args[0], append(args[1:], image)...).CombinedOutput()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		reason := fmt.Sprintf("denied by scanner %s", args[0])
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			reason += ": " + msg
+		}
+		return reason, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to run image scanner %s: %w", args[0], err)
+	}
+	return "", nil
+}
+
+// globMatch reports whether s matches pattern in full, where * matches any run of characters and ? any one character
+func globMatch(pattern, s string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$").MatchString(s)
+}
+
+// checkPackageImages returns the images of a package's bundled components that the policy denies
+func (p *imagePolicy) checkPackageImages(pkg types.BundleZarfPackage, zarfPkg zarfTypes.ZarfPackage) ([]imageViolation, error) {
+	violations := []imageViolation{}
+	for _, component := range bundledComponents(pkg, zarfPkg) {
+		for _, image := range helpers.Unique(component.Images) {
+			message.Debugf("Checking image %s of %s against the image policy", image, pkg.Name)
+			reason, err := p.check(image)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				violations = append(violations, imageViolation{Package: pkg.Name, Component: component.Name, Image: image, Rule: reason})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// bundledComponents returns the components of a package that go into the bundle, required components and the
+// optional components listed in optional-components
+func bundledComponents(pkg types.BundleZarfPackage, zarfPkg zarfTypes.ZarfPackage) []zarfTypes.ZarfComponent {
+	components := []zarfTypes.ZarfComponent{}
+	for _, component := range zarfPkg.Components {
+		if component.Required || helpers.SliceContains(pkg.OptionalComponents, component.Name) {
+			components = append(components, component)
+		}
+	}
+	return components
+}
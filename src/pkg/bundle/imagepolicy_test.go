@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+)
+
+func Test_checkPackageImages(t *testing.T) {
+	zarfPkg := zarfTypes.ZarfPackage{Components: []zarfTypes.ZarfComponent{
+		{Name: "app", Required: true, Images: []string{"nginx", "ghcr.io/stefanprodan/podinfo:6.4.0", "registry.internal/app:1.0.0"}},
+		{Name: "extras", Images: []string{"busybox:latest"}},
+	}}
+	pkg := types.BundleZarfPackage{Name: "example"}
+
+	tests := []struct {
+		name        string
+		description string
+		policy      imagePolicy
+		pkg         types.BundleZarfPackage
+		want        []imageViolation
+	}{
+		{
+			name:        "Latest",
+			description: "untagged images are denied by a :latest pattern",
+			policy:      imagePolicy{Deny: []imageRule{{Pattern: "*:latest", Reason: "floating tags aren't reproducible"}}},
+			pkg:         pkg,
+			want: []imageViolation{
+				{Package: "example", Component: "app", Image: "nginx", Rule: `matches deny pattern "*:latest", floating tags aren't reproducible`},
+			},
+		}, {
+			name:        "OptionalComponents",
+			description: "images of selected optional components are checked too",
+			policy:      imagePolicy{Deny: []imageRule{{Pattern: "*:latest"}}},
+			pkg:         types.BundleZarfPackage{Name: "example", OptionalComponents: []string{"extras"}},
+			want: []imageViolation{
+				{Package: "example", Component: "app", Image: "nginx", Rule: `matches deny pattern "*:latest"`},
+				{Package: "example", Component: "extras", Image: "busybox:latest", Rule: `matches deny pattern "*:latest"`},
+			},
+		}, {
+			name:        "Registry",
+			description: "registry patterns match across path components",
+			policy:      imagePolicy{Deny: []imageRule{{Pattern: "ghcr.io/*"}}},
+			pkg:         pkg,
+			want: []imageViolation{
+				{Package: "example", Component: "app", Image: "ghcr.io/stefanprodan/podinfo:6.4.0", Rule: `matches deny pattern "ghcr.io/*"`},
+			},
+		}, {
+			name:        "Allowed",
+			description: "no violations when nothing matches",
+			policy:      imagePolicy{Deny: []imageRule{{Pattern: "quay.io/*"}}},
+			pkg:         pkg,
+			want:        []imageViolation{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.checkPackageImages(tt.pkg, zarfPkg)
+			if err != nil {
+				t.Fatalf("checkPackageImages() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkPackageImages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Scanner", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the fake scanner is a shell script")
+		}
+		scanner := filepath.Join(t.TempDir(), "scan")
+		script := "#!/bin/sh\ncase \"$1\" in registry.internal/*) exit 0;; esac\necho \"$1 is not from registry.internal\"\nexit 1\n"
+		if err := os.WriteFile(scanner, []byte(script), 0700); err != nil {
+			t.Fatal(err)
+		}
+		policy := imagePolicy{Scanner: scanner}
+		got, err := policy.checkPackageImages(pkg, zarfPkg)
+		if err != nil {
+			t.Fatalf("checkPackageImages() error = %v", err)
+		}
+		want := []imageViolation{
+			{Package: "example", Component: "app", Image: "nginx", Rule: "denied by scanner " + scanner + ": nginx is not from registry.internal"},
+			{Package: "example", Component: "app", Image: "ghcr.io/stefanprodan/podinfo:6.4.0", Rule: "denied by scanner " + scanner + ": ghcr.io/stefanprodan/podinfo:6.4.0 is not from registry.internal"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("checkPackageImages() = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	"github.com/opencontainers/go-digest"
+)
+
+// resolveIncludes merges the packages of each bundle in the uds-bundle.yaml's includes ahead of the bundle's own packages
+func (b *Bundler) resolveIncludes() error {
+	if len(b.bundle.Includes) == 0 {
+		return nil
+	}
+	if err := validateIncludeStrategy(b.cfg.CreateOpts.IncludeStrategy); err != nil {
+		return err
+	}
+
+	var included []types.BundleZarfPackage
+	for _, include := range b.bundle.Includes {
+		packages, err := b.includeBundle(include, included)
+		if err != nil {
+			return err
+		}
+		included = packages
+	}
+
+	merged, err := mergeIncludedPackages(b.bundle.ZarfPackages, included, b.cfg.CreateOpts.IncludeStrategy, b.packageDigest)
+	if err != nil {
+		return err
+	}
+	b.bundle.ZarfPackages = merged
+	return nil
+}
+
+// includeBundle appends the packages of the bundle include after the packages included so far
+func (b *Bundler) includeBundle(include string, included []types.BundleZarfPackage) ([]types.BundleZarfPackage, error) {
+	spinner := message.NewProgressSpinner("Resolving included bundle %s", include)
+	defer spinner.Stop()
+
+	packages, err := loadIncludedPackages(include)
+	if err != nil {
+		return nil, fmt.Errorf("unable to include bundle %s: %w", include, err)
+	}
+	merged, err := appendIncludedPackages(included, packages, b.packageDigest)
+	if err != nil {
+		return nil, err
+	}
+	spinner.Successf("Included %d packages from %s", len(packages), include)
+	return merged, nil
+}
+
+// packageDigest returns the digest of a remote package, a ref that isn't pinned yet is resolved the way
+// ValidateBundleResources pins it
+func (b *Bundler) packageDigest(pkg types.BundleZarfPackage) (digest.Digest, error) {
+	if strings.Contains(pkg.Ref, "@") {
+		return pinnedDigest(pkg)
+	}
+	arch := b.cfg.Arch.Resolve(b.bundle.Metadata.Architecture, b.bundle.Build.Architecture)
+	remote, err := utils.NewOrasRemote(fmt.Sprintf("%s:%s-%s", pkg.Repository, pkg.Ref, arch))
+	if err != nil {
+		return "", err
+	}
+	manifestDesc, err := remote.ResolveRoot()
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the digest of zarf pkg %s: %w", pkg.Name, err)
+	}
+	return manifestDesc.Digest, nil
+}
+
+// loadIncludedPackages reads the packages out of a published bundle's uds-bundle.yaml
+//
+// only remote packages can be included, they are pulled again from their digest-pinned repository
+func loadIncludedPackages(include string) ([]types.BundleZarfPackage, error) {
+	if !helpers.IsOCIURL(include) {
+		return nil, fmt.Errorf("includes must be OCI URLs (ie. oci://<registry>/<bundle>:<tag>)")
+	}
+	tmp, err := zarfUtils.MakeTempDir()
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	provider, err := NewBundleProvider(utils.NetworkContext(), include, tmp)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := provider.LoadBundleMetadata()
+	if err != nil {
+		return nil, err
+	}
+	var bundle types.UDSBundle
+	if err := zarfUtils.ReadYaml(loaded[config.BundleYAML], &bundle); err != nil {
+		return nil, err
+	}
+	if err := requireBundleYAML(&bundle, "include"); err != nil {
+		return nil, err
+	}
+	for _, pkg := range bundle.ZarfPackages {
+		if pkg.Repository == "" {
+			return nil, fmt.Errorf("zarf pkg %s is a local package, only packages from OCI registries can be included", pkg.Name)
+		}
+	}
+	return bundle.ZarfPackages, nil
+}
+
+// validateIncludeStrategy ensures --include-strategy is either error or override
+func validateIncludeStrategy(strategy string) error {
+	switch strategy {
+	case config.IncludeStrategyError, config.IncludeStrategyOverride:
+		return nil
+	default:
+		return fmt.Errorf("invalid --include-strategy %q, must be one of: %s, %s", strategy, config.IncludeStrategyError, config.IncludeStrategyOverride)
+	}
+}
+
+// mergeIncludedPackages returns the included packages followed by the bundle's own packages
+//
+// packages included more than once with the same digest are deduplicated, a package with the same name but a
+// different digest is an error unless the strategy is override, where the bundle's own package wins. Digests are
+// compared with resolve, the bundle's own packages may not be pinned yet
+func mergeIncludedPackages(own, included []types.BundleZarfPackage, strategy string, resolve func(types.BundleZarfPackage) (digest.Digest, error)) ([]types.BundleZarfPackage, error) {
+	merged, err := newIncludedPackages(own, included, strategy, resolve)
+	if err != nil {
+		return nil, err
+	}
+	return append(merged, own...), nil
+}
+
+// appendIncludedPackages returns the packages included so far followed by the packages of the next include, so
+// includes keep their declared (and deploy) order
+func appendIncludedPackages(earlier, included []types.BundleZarfPackage, resolve func(types.BundleZarfPackage) (digest.Digest, error)) ([]types.BundleZarfPackage, error) {
+	added, err := newIncludedPackages(earlier, included, config.IncludeStrategyError, resolve)
+	if err != nil {
+		return nil, err
+	}
+	return append(earlier, added...), nil
+}
+
+// newIncludedPackages returns the included packages that aren't already in own, see mergeIncludedPackages
+func newIncludedPackages(own, included []types.BundleZarfPackage, strategy string, resolve func(types.BundleZarfPackage) (digest.Digest, error)) ([]types.BundleZarfPackage, error) {
+	ownByName := make(map[string]types.BundleZarfPackage)
+	for _, pkg := range own {
+		ownByName[pkg.Name] = pkg
+	}
+
+	var merged []types.BundleZarfPackage
+	for _, pkg := range included {
+		existing, ok := ownByName[pkg.Name]
+		if !ok {
+			merged = append(merged, pkg)
+			continue
+		}
+		if existing.Repository == pkg.Repository {
+			same, err := samePackageDigest(existing, pkg, resolve)
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				continue
+			}
+		}
+		if strategy != config.IncludeStrategyOverride {
+			return nil, fmt.Errorf("zarf pkg %s is included as %s:%s but is already %s:%s, use --include-strategy %s to keep the bundle's own package",
+				pkg.Name, pkg.Repository, pkg.Ref, existing.Repository, existing.Ref, config.IncludeStrategyOverride)
+		}
+		message.Debugf("Overriding included zarf pkg %s with %s:%s", pkg.Name, existing.Repository, existing.Ref)
+	}
+	return merged, nil
+}
+
+// samePackageDigest returns true if two packages from the same repository resolve to the same digest
+func samePackageDigest(a, b types.BundleZarfPackage, resolve func(types.BundleZarfPackage) (digest.Digest, error)) (bool, error) {
+	if a.Ref == b.Ref {
+		return true, nil
+	}
+	aDigest, err := resolve(a)
+	if err != nil {
+		return false, err
+	}
+	bDigest, err := resolve(b)
+	if err != nil {
+		return false, err
+	}
+	return aDigest == bDigest, nil
+}
@@ -6,16 +6,43 @@ package bundle
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
 )
 
 // Inspect pulls/unpacks a bundle's metadata and shows it
 func (b *Bundler) Inspect() error {
+	if b.cfg.InspectOpts.FromCluster && b.cfg.InspectOpts.DiffDeployed {
+		return fmt.Errorf("--diff-deployed compares a bundle to the cluster and can't be used with --from-cluster")
+	}
+	if b.cfg.InspectOpts.FromCluster {
+		return b.inspectFromCluster()
+	}
+	if b.cfg.InspectOpts.Package != "" && !b.cfg.InspectOpts.Docs {
+		return fmt.Errorf("--package only applies to --docs")
+	}
+
 	ctx := context.TODO()
+	source, err := b.decryptSource(b.cfg.InspectOpts.Source, b.cfg.InspectOpts.Decrypt, b.cfg.InspectOpts.IdentityPath)
+	if err != nil {
+		return err
+	}
+
 	// create a new provider
-	provider, err := NewBundleProvider(ctx, b.cfg.InspectOpts.Source, b.tmp)
+	provider, err := NewBundleProvider(ctx, source, b.tmp)
 	if err != nil {
 		return err
 	}
@@ -27,7 +54,19 @@ func (b *Bundler) Inspect() error {
 	}
 
 	// validate the sig (if present)
-	if err := ValidateBundleSignature(loaded[config.BundleYAML], loaded[config.BundleYAMLSignature], b.cfg.InspectOpts.PublicKeyPath); err != nil {
+	publicKeys, err := udsUtils.FetchPublicKeys(b.cfg.InspectOpts.PublicKeyPaths, b.tmp)
+	if err != nil {
+		return err
+	}
+	sigAlgo, err := provider.SignatureAlgorithm()
+	if err != nil {
+		return err
+	}
+	// auditors correlating a signature with what it signed may not have the bundle's public key
+	if b.cfg.InspectOpts.SignedDigest {
+		return b.showSignedDigest(loaded, publicKeys, sigAlgo)
+	}
+	if _, err := verifyBundleSignature(loaded, publicKeys, sigAlgo); err != nil {
 		return err
 	}
 
@@ -42,11 +81,283 @@ func (b *Bundler) Inspect() error {
 	if err := utils.ReadYaml(loaded[config.BundleYAML], &b.bundle); err != nil {
 		return err
 	}
+	if err := validateMinUdsVersion(b.bundle.Metadata, config.CLIVersion, b.cfg.InspectOpts.SkipVersionCheck); err != nil {
+		return err
+	}
+
+	// write the bundle's packages as Zarf OCI refs instead of showing the bundle's metadata
+	if b.cfg.InspectOpts.ExportZarfPackages != "" {
+		return b.exportZarfPackages(b.cfg.InspectOpts.ExportZarfPackages)
+	}
+
+	// list the deploy variables of the bundle's packages instead of the bundle's metadata
+	if b.cfg.InspectOpts.Variables {
+		return b.showPackageVariables(provider)
+	}
+
+	// print the packages' README and docs/ instead of the bundle's metadata
+	if b.cfg.InspectOpts.Docs {
+		return b.showPackageDocs(provider)
+	}
+
+	// compare the bundle's packages to what's deployed instead of showing the bundle's metadata
+	if b.cfg.InspectOpts.DiffDeployed {
+		return b.showDeployedDiff()
+	}
+
+	// show the bundle -> packages -> images/charts hierarchy instead of the bundle's metadata
+	if b.cfg.InspectOpts.Tree {
+		return b.showTree(provider)
+	}
 
 	// show the bundle's metadata
 	utils.ColorPrintYAML(b.bundle, nil, false)
 
+	// show every annotation of the root manifest, including those added with --annotations-file
+	annotations, err := provider.Annotations()
+	if err != nil {
+		return err
+	}
+	if len(annotations) > 0 {
+		utils.ColorPrintYAML(map[string]map[string]string{"annotations": annotations}, nil, false)
+	}
+
+	// show the tags the packages' pinned digests were resolved from
+	tags, err := provider.PackageTags()
+	if err != nil {
+		return err
+	}
+	pkgTags, err := namePackageTags(b.bundle.ZarfPackages, tags)
+	if err != nil {
+		return err
+	}
+	if len(pkgTags) > 0 {
+		utils.ColorPrintYAML(map[string]map[string]string{"packageTags": pkgTags}, nil, false)
+	}
+
+	// list + extract (optional) files attached to the bundle
+	attachments, err := provider.ListAttachments()
+	if err != nil {
+		return err
+	}
+	if len(attachments) > 0 {
+		message.Infof("Attached files: %s", strings.Join(attachments, ", "))
+	}
+	for _, name := range b.cfg.InspectOpts.Attachments {
+		if err := provider.ExtractAttachment(name, "."); err != nil {
+			return err
+		}
+		message.Successf("Extracted attached file %s", name)
+	}
+
 	// TODO: showing package metadata?
 	// TODO: could be cool to have an interactive mode that lets you select a package and show its metadata
 	return nil
 }
+
+// inspectFromCluster shows the deploy records written to the cluster by bundle.Deploy()
+func (b *Bundler) inspectFromCluster() error {
+	records, err := readDeployRecords(b.cfg.InspectOpts.Source, "")
+	if err != nil {
+		return err
+	}
+	// exported variables are kept for deploy --resume but may be sensitive, don't print them
+	for i := range records {
+		for j := range records[i].Packages {
+			records[i].Packages[j].Exports = nil
+		}
+	}
+
+	if b.cfg.InspectOpts.JSON {
+		var out []byte
+		if len(records) == 1 {
+			out, err = json.MarshalIndent(records[0], "", "  ")
+		} else {
+			out, err = json.MarshalIndent(records, "", "  ")
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	for _, record := range records {
+		utils.ColorPrintYAML(record, nil, false)
+	}
+	return nil
+}
+
+// packageTagsFromManifest reads the package tag annotations of a root manifest's package manifest layers
+func packageTagsFromManifest(manifest *oci.ZarfOCIManifest) map[digest.Digest]string {
+	tags := make(map[digest.Digest]string)
+	for _, layer := range manifest.Layers {
+		if tag := layer.Annotations[config.PackageTagAnnotation]; layer.MediaType == ocispec.MediaTypeImageManifest && tag != "" {
+			tags[layer.Digest] = tag
+		}
+	}
+	return tags
+}
+
+// namePackageTags keys the package tags of a root manifest by the name of the bundle package pinned to each digest
+func namePackageTags(bundlePkgs []types.BundleZarfPackage, tags map[digest.Digest]string) (map[string]string, error) {
+	named := make(map[string]string)
+	for _, pkg := range bundlePkgs {
+		pin, err := pinnedDigest(pkg)
+		if err != nil {
+			return nil, err
+		}
+		if tag, ok := tags[pin]; ok {
+			named[pkg.Name] = tag
+		}
+	}
+	return named, nil
+}
+
+// packageVariables are the deploy variables declared in a package's zarf.yaml
+type packageVariables struct {
+	Package   string            `json:"package"`
+	Variables []packageVariable `json:"variables"`
+}
+
+// packageVariable is a Zarf deploy variable as seen from the bundle
+type packageVariable struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Default      string `json:"default,omitempty"`
+	Required     bool   `json:"required"`
+	Sensitive    bool   `json:"sensitive,omitempty"`
+	ImportedFrom string `json:"importedFrom,omitempty"`
+}
+
+// showPackageVariables reads the zarf.yaml of each package in the bundle and prints the variables it accepts
+func (b *Bundler) showPackageVariables(provider Provider) error {
+	zarfPkgs := make(map[string]zarfTypes.ZarfPackage)
+	for _, pkg := range b.bundle.ZarfPackages {
+		pin, err := pinnedDigest(pkg)
+		if err != nil {
+			return err
+		}
+		sha := pin.Encoded()
+		zarfPkg, err := loadPackageConfig(provider, sha)
+		if err != nil {
+			return err
+		}
+		zarfPkgs[pkg.Name] = zarfPkg
+	}
+	variables := collectPackageVariables(b.bundle.ZarfPackages, zarfPkgs)
+
+	if b.cfg.InspectOpts.JSON {
+		out, err := json.MarshalIndent(variables, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	table := pterm.TableData{{"Package", "Variable", "Required", "Default", "Description"}}
+	for _, pkg := range variables {
+		for _, variable := range pkg.Variables {
+			def := variable.Default
+			if variable.ImportedFrom != "" {
+				def = fmt.Sprintf("(imported from %s)", variable.ImportedFrom)
+			}
+			table = append(table, []string{pkg.Package, variable.Name, fmt.Sprint(variable.Required), def, variable.Description})
+		}
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(table).Render()
+}
+
+// collectPackageVariables lists the variables each package declares in bundle order
+//
+// variables without a default are required unless the bundle imports them from another package,
+// defaults of sensitive variables are masked
+func collectPackageVariables(bundlePkgs []types.BundleZarfPackage, zarfPkgs map[string]zarfTypes.ZarfPackage) []packageVariables {
+	result := []packageVariables{}
+	for _, pkg := range bundlePkgs {
+		imports := make(map[string]string)
+		for _, imp := range pkg.Imports {
+			imports[imp.Name] = imp.Package
+		}
+
+		pkgVars := packageVariables{Package: pkg.Name, Variables: []packageVariable{}}
+		for _, v := range zarfPkgs[pkg.Name].Variables {
+			variable := packageVariable{
+				Name:         v.Name,
+				Description:  v.Description,
+				Default:      v.Default,
+				Sensitive:    v.Sensitive,
+				ImportedFrom: imports[v.Name],
+			}
+			variable.Required = v.Default == "" && variable.ImportedFrom == ""
+			if v.Sensitive && v.Default != "" {
+				variable.Default = "***"
+			}
+			pkgVars.Variables = append(pkgVars.Variables, variable)
+		}
+		result = append(result, pkgVars)
+	}
+	return result
+}
+
+// signedDigest is what a bundle's uds-bundle.yaml.sig covers, printed by inspect --signed-digest
+type signedDigest struct {
+	Signed          string `json:"signed"`
+	Digest          string `json:"digest"`
+	SignatureDigest string `json:"signatureDigest"`
+	Algorithm       string `json:"algorithm,omitempty"`
+	Verified        bool   `json:"verified"`
+	VerifiedWith    string `json:"verifiedWith,omitempty"`
+}
+
+// showSignedDigest prints the digest of the uds-bundle.yaml the bundle's signature covers, the signature is only
+// verified when a public key was given
+func (b *Bundler) showSignedDigest(loaded PathMap, publicKeys []udsUtils.TrustedKey, sigAlgo string) error {
+	signed, err := loadSignedDigest(loaded[config.BundleYAML], loaded[config.BundleYAMLSignature], sigAlgo)
+	if err != nil {
+		return err
+	}
+	if len(publicKeys) > 0 {
+		key, err := verifyBundleSignature(loaded, publicKeys, sigAlgo)
+		if err != nil {
+			return err
+		}
+		signed.Verified = true
+		signed.VerifiedWith = key.Source
+	}
+
+	if b.cfg.InspectOpts.JSON {
+		out, err := json.MarshalIndent(signed, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	fmt.Println(signed.Digest)
+	return nil
+}
+
+// loadSignedDigest computes the digests of a bundle's uds-bundle.yaml and of its signature
+//
+// the signature is over the uds-bundle.yaml bytes, which is also the bundle's uds-bundle.yaml layer
+func loadSignedDigest(bundleYAMLPath, signaturePath, sigAlgo string) (signedDigest, error) {
+	if signaturePath == "" || utils.InvalidPath(signaturePath) {
+		return signedDigest{}, fmt.Errorf("bundle is not signed, it has no %s", config.BundleYAMLSignature)
+	}
+	bundleYAML, err := os.ReadFile(bundleYAMLPath)
+	if err != nil {
+		return signedDigest{}, err
+	}
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return signedDigest{}, err
+	}
+	return signedDigest{
+		Signed:          config.BundleYAML,
+		Digest:          digest.FromBytes(bundleYAML).String(),
+		SignatureDigest: digest.FromBytes(signature).String(),
+		Algorithm:       sigAlgo,
+	}, nil
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func Test_collectPackageVariables(t *testing.T) {
+	zarfPkgs := map[string]zarfTypes.ZarfPackage{
+		"output-var": {Variables: []zarfTypes.ZarfPackageVariable{
+			{Name: "DOMAIN", Description: "The cluster's domain", Default: "uds.dev"},
+			{Name: "PASSWORD", Default: "hunter2", Sensitive: true},
+		}},
+		"receive-var": {Variables: []zarfTypes.ZarfPackageVariable{
+			{Name: "OUTPUT"},
+			{Name: "REPLICAS"},
+		}},
+	}
+	tests := []struct {
+		name        string
+		description string
+		bundlePkgs  []types.BundleZarfPackage
+		want        []packageVariables
+	}{
+		{
+			name:        "DefaultsAndSensitive",
+			description: "variables with defaults aren't required and sensitive defaults are masked",
+			bundlePkgs:  []types.BundleZarfPackage{{Name: "output-var"}},
+			want: []packageVariables{{Package: "output-var", Variables: []packageVariable{
+				{Name: "DOMAIN", Description: "The cluster's domain", Default: "uds.dev"},
+				{Name: "PASSWORD", Default: "***", Sensitive: true},
+			}}},
+		}, {
+			name:        "Imported",
+			description: "variables without a default are required unless the bundle imports them",
+			bundlePkgs: []types.BundleZarfPackage{{
+				Name:    "receive-var",
+				Imports: []types.BundleVariableImport{{Name: "OUTPUT", Package: "output-var"}},
+			}},
+			want: []packageVariables{{Package: "receive-var", Variables: []packageVariable{
+				{Name: "OUTPUT", ImportedFrom: "output-var"},
+				{Name: "REPLICAS", Required: true},
+			}}},
+		}, {
+			name:        "NoVariables",
+			description: "packages without variables are listed with none",
+			bundlePkgs:  []types.BundleZarfPackage{{Name: "init"}},
+			want:        []packageVariables{{Package: "init", Variables: []packageVariable{}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collectPackageVariables(tt.bundlePkgs, zarfPkgs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectPackageVariables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loadSignedDigest(t *testing.T) {
+	dir := t.TempDir()
+	bundleYAML := filepath.Join(dir, config.BundleYAML)
+	signature := filepath.Join(dir, config.BundleYAMLSignature)
+	if err := os.WriteFile(bundleYAML, []byte("kind: UDSBundle\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(signature, []byte("c2lnbmF0dXJl"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadSignedDigest(bundleYAML, signature, config.SigAlgoECDSAP256)
+	if err != nil {
+		t.Fatalf("loadSignedDigest() error = %v", err)
+	}
+	want := signedDigest{
+		Signed:          config.BundleYAML,
+		Digest:          "sha256:082492def0ee1be4db57242e2686e69131ab2751f2416e2d913e7342b96e8a18",
+		SignatureDigest: "sha256:5b46b2821536f0a33e3459a1492479c048f3262e423aaf0536c17b89ac1e6d11",
+		Algorithm:       config.SigAlgoECDSAP256,
+	}
+	if got != want {
+		t.Errorf("loadSignedDigest() = %+v, want %+v", got, want)
+	}
+
+	if _, err := loadSignedDigest(bundleYAML, "", ""); err == nil {
+		t.Errorf("loadSignedDigest() error = nil, want an error for an unsigned bundle")
+	}
+}
+
+func Test_namePackageTags(t *testing.T) {
+	podinfoDesc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("podinfo")}
+	annotatePackageTag(&podinfoDesc, "0.0.1-amd64@"+podinfoDesc.Digest.String())
+	nginxDesc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("nginx")}
+	manifest := &oci.ZarfOCIManifest{Manifest: ocispec.Manifest{Layers: []ocispec.Descriptor{
+		podinfoDesc,
+		nginxDesc,
+		{MediaType: oci.ZarfLayerMediaTypeBlob, Digest: digest.FromString(config.BundleYAML), Annotations: map[string]string{ocispec.AnnotationTitle: config.BundleYAML}},
+	}}}
+	bundlePkgs := []types.BundleZarfPackage{
+		{Name: "podinfo", Ref: "0.0.1-amd64@" + podinfoDesc.Digest.String()},
+		{Name: "nginx", Ref: "0.0.1@" + nginxDesc.Digest.String()},
+	}
+
+	// packages created without the annotation, ie. by older versions of uds, have no tag to show
+	got, err := namePackageTags(bundlePkgs, packageTagsFromManifest(manifest))
+	if err != nil {
+		t.Fatalf("namePackageTags() error = %v", err)
+	}
+	want := map[string]string{"podinfo": "0.0.1-amd64"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("namePackageTags() = %v, want %v", got, want)
+	}
+
+	if _, err := namePackageTags([]types.BundleZarfPackage{{Name: "podinfo", Ref: "0.0.1-amd64"}}, nil); err == nil {
+		t.Errorf("namePackageTags() error = nil, want an error for a ref that isn't pinned")
+	}
+}
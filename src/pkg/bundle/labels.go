@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/k8s"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultBundleLabels maps the bundle manifest annotations that identify a bundle to the namespace labels they're
+// applied as
+var defaultBundleLabels = map[string]string{
+	ocispec.AnnotationTitle:    config.BundleNameLabel,
+	ocispec.AnnotationVersion:  config.BundleVersionLabel,
+	ocispec.AnnotationRevision: config.BundleRevisionLabel,
+}
+
+// bundleLabels are the labels and annotations a deploy applies to the namespaces its packages deploy into
+type bundleLabels struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// validateBundleLabels ensures each annotation is mapped to a valid label key
+func validateBundleLabels(mapping map[string]string) error {
+	for annotation, key := range mapping {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			return fmt.Errorf("invalid label key %q for annotation %s: %s", key, annotation, strings.Join(msgs, ", "))
+		}
+	}
+	return nil
+}
+
+// newBundleLabels maps the bundle's manifest annotations to namespace labels, the bundle's name and version stand in
+// for the title and version annotations bundles don't carry. Values that can't be label values (ie. versions with
+// build metadata) are applied as annotations of the same key instead
+func newBundleLabels(bundle *types.UDSBundle, annotations map[string]string, mapping map[string]string) bundleLabels {
+	values := mergeAnnotations(map[string]string{
+		ocispec.AnnotationTitle:   bundle.Metadata.Name,
+		ocispec.AnnotationVersion: bundle.Metadata.Version,
+	}, annotations)
+
+	labels := bundleLabels{Labels: map[string]string{}, Annotations: map[string]string{}}
+	for annotation, key := range mapping {
+		value := values[annotation]
+		if value == "" {
+			continue
+		}
+		if len(validation.IsValidLabelValue(value)) > 0 {
+			labels.Annotations[key] = value
+			continue
+		}
+		labels.Labels[key] = value
+	}
+	return labels
+}
+
+// packageNamespaces returns the namespaces the bundled components of a loaded package deploy charts and manifests
+// into, none for Zarf init packages since the zarf namespace is shared by every bundle
+func packageNamespaces(pkg types.BundleZarfPackage, pkgDir string) ([]string, error) {
+	var zarfPkg zarfTypes.ZarfPackage
+	if err := utils.ReadYaml(filepath.Join(pkgDir, config.ZarfYAML), &zarfPkg); err != nil {
+		return nil, err
+	}
+	if zarfPkg.Kind == zarfTypes.ZarfInitConfig {
+		return nil, nil
+	}
+	namespaces := map[string]bool{}
+	for _, component := range bundledComponents(pkg, zarfPkg) {
+		for _, chart := range component.Charts {
+			namespaces[chart.Namespace] = true
+		}
+		for _, manifest := range component.Manifests {
+			namespaces[manifest.Namespace] = true
+		}
+	}
+	delete(namespaces, "")
+	sorted := []string{}
+	for namespace := range namespaces {
+		sorted = append(sorted, namespace)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+// applyNamespaceLabels adds labels to each of namespaces, skipping the ones that don't exist
+func applyNamespaceLabels(ctx context.Context, clientset kubernetes.Interface, namespaces []string, labels bundleLabels) error {
+	for _, name := range namespaces {
+		namespace, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			message.Debugf("Namespace %s wasn't created, not labeling it", name)
+			continue
+		} else if err != nil {
+			return err
+		}
+		if namespace.Labels == nil {
+			namespace.Labels = map[string]string{}
+		}
+		for key, value := range labels.Labels {
+			namespace.Labels[key] = value
+		}
+		if len(labels.Annotations) > 0 && namespace.Annotations == nil {
+			namespace.Annotations = map[string]string{}
+		}
+		for key, value := range labels.Annotations {
+			namespace.Annotations[key] = value
+		}
+		if _, err := clientset.CoreV1().Namespaces().Update(ctx, namespace, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelPackageNamespaces labels the namespaces a deployed package's charts and manifests went into with the bundle
+// that deployed them. The labels are only for finding what a bundle installed, so failing to apply them is a warning
+func labelPackageNamespaces(ctx context.Context, pkg types.BundleZarfPackage, pkgDir string, labels bundleLabels) {
+	if len(labels.Labels) == 0 && len(labels.Annotations) == 0 {
+		return
+	}
+	namespaces, err := packageNamespaces(pkg, pkgDir)
+	if err != nil {
+		message.Warnf("Unable to find the namespaces of zarf pkg %s to label: %s", pkg.Name, err.Error())
+		return
+	}
+	if len(namespaces) == 0 {
+		return
+	}
+	cluster, err := k8s.New(message.Debugf, nil)
+	if err != nil {
+		message.Warnf("Unable to connect to the cluster to label the namespaces of zarf pkg %s: %s", pkg.Name, err.Error())
+		return
+	}
+	if err := applyNamespaceLabels(ctx, cluster.Clientset, namespaces, labels); err != nil {
+		message.Warnf("Unable to label the namespaces of zarf pkg %s: %s", pkg.Name, err.Error())
+		return
+	}
+	message.Debugf("Labeled the namespaces of zarf pkg %s: %s", pkg.Name, strings.Join(namespaces, ", "))
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_newBundleLabels(t *testing.T) {
+	bundle := &types.UDSBundle{Metadata: types.UDSMetadata{Name: "example", Version: "0.0.1"}}
+	tests := []struct {
+		name        string
+		description string
+		annotations map[string]string
+		mapping     map[string]string
+		want        bundleLabels
+	}{
+		{
+			name:        "Defaults",
+			description: "the bundle's name and version stand in for the annotations, an unset revision isn't applied",
+			mapping:     defaultBundleLabels,
+			want: bundleLabels{
+				Labels:      map[string]string{config.BundleNameLabel: "example", config.BundleVersionLabel: "0.0.1"},
+				Annotations: map[string]string{},
+			},
+		},
+		{
+			name:        "Revision",
+			description: "annotations set when the bundle was created are applied",
+			annotations: map[string]string{ocispec.AnnotationRevision: "4b4abea"},
+			mapping:     defaultBundleLabels,
+			want: bundleLabels{
+				Labels:      map[string]string{config.BundleNameLabel: "example", config.BundleVersionLabel: "0.0.1", config.BundleRevisionLabel: "4b4abea"},
+				Annotations: map[string]string{},
+			},
+		},
+		{
+			name:        "InvalidLabelValue",
+			description: "values that can't be label values are applied as annotations",
+			annotations: map[string]string{ocispec.AnnotationVersion: "0.0.1+build.1"},
+			mapping:     map[string]string{ocispec.AnnotationVersion: "example.com/version"},
+			want: bundleLabels{
+				Labels:      map[string]string{},
+				Annotations: map[string]string{"example.com/version": "0.0.1+build.1"},
+			},
+		},
+		{
+			name:        "CustomMapping",
+			description: "a custom mapping replaces the defaults",
+			annotations: map[string]string{"example.com/team": "platform"},
+			mapping:     map[string]string{"example.com/team": "team"},
+			want: bundleLabels{
+				Labels:      map[string]string{"team": "platform"},
+				Annotations: map[string]string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newBundleLabels(bundle, tt.annotations, tt.mapping); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newBundleLabels() = %+v, want %+v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+
+	if err := validateBundleLabels(defaultBundleLabels); err != nil {
+		t.Errorf("validateBundleLabels() error = %v for the default labels", err)
+	}
+	if err := validateBundleLabels(map[string]string{ocispec.AnnotationTitle: "not a label"}); err == nil {
+		t.Errorf("validateBundleLabels() didn't fail for an invalid label key")
+	}
+}
+
+func Test_packageNamespaces(t *testing.T) {
+	zarfPkg := zarfTypes.ZarfPackage{
+		Kind: zarfTypes.ZarfPackageConfig,
+		Components: []zarfTypes.ZarfComponent{
+			{
+				Name:      "podinfo",
+				Required:  true,
+				Charts:    []zarfTypes.ZarfChart{{Name: "podinfo", Namespace: "podinfo"}},
+				Manifests: []zarfTypes.ZarfManifest{{Name: "extra", Namespace: "podinfo"}, {Name: "cluster-scoped"}},
+			},
+			{Name: "monitoring", Charts: []zarfTypes.ZarfChart{{Name: "grafana", Namespace: "monitoring"}}},
+			{Name: "logging", Manifests: []zarfTypes.ZarfManifest{{Name: "loki", Namespace: "logging"}}},
+		},
+	}
+	dir := t.TempDir()
+	if err := utils.WriteYaml(filepath.Join(dir, config.ZarfYAML), zarfPkg, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the bundled optional components deployed
+	got, err := packageNamespaces(types.BundleZarfPackage{Name: "podinfo", OptionalComponents: []string{"logging"}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"logging", "podinfo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("packageNamespaces() = %v, want %v", got, want)
+	}
+
+	// the zarf namespace is shared by every bundle
+	zarfPkg.Kind = zarfTypes.ZarfInitConfig
+	if err := utils.WriteYaml(filepath.Join(dir, config.ZarfYAML), zarfPkg, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := packageNamespaces(types.BundleZarfPackage{Name: zarfInitPackageName}, dir); err != nil || len(got) != 0 {
+		t.Errorf("packageNamespaces() = %v, %v, want no namespaces for a Zarf init package", got, err)
+	}
+}
+
+func Test_applyNamespaceLabels(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "podinfo",
+		Labels: map[string]string{"app.kubernetes.io/managed-by": "zarf"},
+	}})
+	labels := bundleLabels{
+		Labels:      map[string]string{config.BundleNameLabel: "example"},
+		Annotations: map[string]string{config.BundleVersionLabel: "0.0.1+build.1"},
+	}
+
+	// namespaces that weren't created are skipped
+	if err := applyNamespaceLabels(ctx, clientset, []string{"podinfo", "missing"}, labels); err != nil {
+		t.Fatal(err)
+	}
+	namespace, err := clientset.CoreV1().Namespaces().Get(ctx, "podinfo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLabels := map[string]string{"app.kubernetes.io/managed-by": "zarf", config.BundleNameLabel: "example"}
+	if !reflect.DeepEqual(namespace.Labels, wantLabels) {
+		t.Errorf("applyNamespaceLabels() labels = %v, want %v", namespace.Labels, wantLabels)
+	}
+	if !reflect.DeepEqual(namespace.Annotations, labels.Annotations) {
+		t.Errorf("applyNamespaceLabels() annotations = %v, want %v", namespace.Annotations, labels.Annotations)
+	}
+}
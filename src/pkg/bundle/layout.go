@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	av3 "github.com/mholt/archiver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// layoutIndex is the result of checking a bundle's index.json
+type layoutIndex struct {
+	index     ocispec.Index
+	root      ocispec.Descriptor
+	referrers []ocispec.Descriptor
+	extra     []ocispec.Descriptor
+}
+
+// VerifyLayout checks that the index.json of a bundle tarball or OCI layout directory lists only the bundle's root
+// manifest and the referrers pulled with it, and with --repair rewrites the index.json of a directory to do so
+func (b *Bundler) VerifyLayout() error {
+	source := b.cfg.VerifyLayoutOpts.Source
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+
+	readFile := func(path string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(source, path))
+	}
+	if !info.IsDir() {
+		if b.cfg.VerifyLayoutOpts.Repair {
+			return fmt.Errorf("--repair rewrites the index.json of an OCI layout directory, unpack %s first", source)
+		}
+		if source, err = b.joinSource(source); err != nil {
+			return err
+		}
+		readFile = func(path string) ([]byte, error) {
+			if err := av3.Extract(source, path, b.tmp); err != nil {
+				return nil, fmt.Errorf("failed to extract %s from %s: %w", path, source, err)
+			}
+			return os.ReadFile(filepath.Join(b.tmp, path))
+		}
+	}
+
+	layout, err := checkLayoutIndex(readFile)
+	if err != nil {
+		return err
+	}
+	if len(layout.extra) == 0 {
+		message.Successf("index.json lists only the bundle's root manifest %s", layout.root.Digest)
+		return nil
+	}
+
+	for _, desc := range layout.extra {
+		message.Warnf("index.json has an unnecessary entry: %s %s", desc.MediaType, desc.Digest)
+	}
+	if !b.cfg.VerifyLayoutOpts.Repair {
+		return fmt.Errorf("index.json should only list the bundle's root manifest %s, found %d other entries, rerun with --repair on an unpacked bundle to remove them", layout.root.Digest, len(layout.extra))
+	}
+
+	// the referrers pulled with the bundle are kept, they're needed to verify it
+	layout.index.Manifests = append([]ocispec.Descriptor{layout.root}, layout.referrers...)
+	indexBytes, err := json.Marshal(layout.index)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(source, "index.json"), indexBytes, 0644); err != nil {
+		return err
+	}
+	message.Successf("Removed %d unnecessary entries from index.json", len(layout.extra))
+	return nil
+}
+
+// checkLayoutIndex reads index.json with readFile and finds the bundle's root manifest among its entries, the root
+// manifest is the one with a uds-bundle.yaml layer. Referrers pulled with the bundle are expected entries
+func checkLayoutIndex(readFile func(path string) ([]byte, error)) (layoutIndex, error) {
+	indexBytes, err := readFile("index.json")
+	if err != nil {
+		return layoutIndex{}, err
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return layoutIndex{}, fmt.Errorf("invalid index.json: %w", err)
+	}
+
+	layout := layoutIndex{index: index}
+	var roots []ocispec.Descriptor
+	for _, desc := range index.Manifests {
+		if isReferrer(desc) {
+			layout.referrers = append(layout.referrers, desc)
+			continue
+		}
+		if desc.MediaType != ocispec.MediaTypeImageManifest {
+			layout.extra = append(layout.extra, desc)
+			continue
+		}
+		manifestBytes, err := readFile(utils.BlobPath(desc.Digest))
+		if err != nil {
+			return layoutIndex{}, fmt.Errorf("index.json lists %s, which is missing from the layout: %w", desc.Digest, err)
+		}
+		var manifest oci.ZarfOCIManifest
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+			return layoutIndex{}, fmt.Errorf("invalid manifest %s: %w", desc.Digest, err)
+		}
+		if oci.IsEmptyDescriptor(manifest.Locate(config.BundleYAML)) {
+			layout.extra = append(layout.extra, desc)
+			continue
+		}
+		roots = append(roots, desc)
+	}
+
+	switch len(roots) {
+	case 0:
+		return layoutIndex{}, fmt.Errorf("index.json doesn't list a bundle root manifest, no manifest has a %s layer", config.BundleYAML)
+	case 1:
+		layout.root = roots[0]
+		return layout, nil
+	}
+	return layoutIndex{}, fmt.Errorf("index.json lists %d bundle root manifests, the layout is malformed", len(roots))
+}
+
+// indexWriteAttempts is how many times rebuildBundleIndex writes index.json before giving up on a layout
+const indexWriteAttempts = 3
+
+// rebuildBundleIndex rewrites the index.json of the OCI layout in dir to list only the bundle's root manifest, pushing
+// Zarf image manifests adds unnecessary entries. The new index.json is re-read and checked before it's used, and
+// rewritten if the check fails
+func rebuildBundleIndex(dir string, root ocispec.Descriptor) error {
+	indexBytes, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return err
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return fmt.Errorf("invalid index.json: %w", err)
+	}
+	index.Manifests = []ocispec.Descriptor{root}
+	if indexBytes, err = json.Marshal(index); err != nil {
+		return err
+	}
+
+	for attempt := 1; attempt <= indexWriteAttempts; attempt++ {
+		if err = replaceLayoutFile(dir, "index.json", indexBytes); err == nil {
+			if err = validateBundleIndex(dir, root); err == nil {
+				return nil
+			}
+		}
+		message.Debugf("Attempt %d of %d to rebuild index.json failed: %s", attempt, indexWriteAttempts, err)
+	}
+	return fmt.Errorf("unable to rebuild the bundle's index.json: %w", err)
+}
+
+// replaceLayoutFile writes b next to the file rel in the OCI layout in dir then renames it over the file, so a failed
+// write never leaves the layout with a partial file
+func replaceLayoutFile(dir, rel string, b []byte) error {
+	tmp, err := os.CreateTemp(dir, "."+rel+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, rel))
+}
+
+// validateBundleIndex checks that the index.json of the OCI layout in dir lists only root, and that root is a bundle
+// root manifest in the layout
+func validateBundleIndex(dir string, root ocispec.Descriptor) error {
+	layout, err := checkLayoutIndex(func(path string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, path))
+	})
+	if err != nil {
+		return err
+	}
+	if len(layout.index.Manifests) != 1 || layout.root.Digest != root.Digest || layout.root.Size != root.Size {
+		return fmt.Errorf("index.json should list only the bundle's root manifest %s, it lists %d manifests", root.Digest, len(layout.index.Manifests))
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// writeLayoutBlob writes b to the blobs of the OCI layout in dir, returning its descriptor
+func writeLayoutBlob(t *testing.T, dir, mediaType string, b []byte) ocispec.Descriptor {
+	t.Helper()
+	desc := content.NewDescriptorFromBytes(mediaType, b)
+	path := filepath.Join(dir, utils.BlobPath(desc.Digest))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+// writeLayoutManifest writes a manifest with a layer of data titled title to the OCI layout in dir
+func writeLayoutManifest(t *testing.T, dir, title, data string) ocispec.Descriptor {
+	t.Helper()
+	layer := writeLayoutBlob(t, dir, ocispec.MediaTypeImageLayer, []byte(data))
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: title}
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    writeLayoutBlob(t, dir, ocispec.MediaTypeImageConfig, []byte("{}")),
+		Layers:    []ocispec.Descriptor{layer},
+	}
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeLayoutBlob(t, dir, ocispec.MediaTypeImageManifest, b)
+}
+
+// writeLayoutIndex writes an index.json listing manifests to the OCI layout in dir
+func writeLayoutIndex(t *testing.T, dir string, manifests ...ocispec.Descriptor) {
+	t.Helper()
+	b, err := json.Marshal(ocispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}, Manifests: manifests})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_checkLayoutIndex(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		entries     [][2]string
+		wantExtra   int
+		wantErr     bool
+	}{
+		{
+			name:        "RootOnly",
+			description: "a bundle's index.json lists only its root manifest",
+			entries:     [][2]string{{config.BundleYAML, "kind: UDSBundle"}},
+		},
+		{
+			name:        "UnnecessaryEntries",
+			description: "Zarf image manifests left in index.json are reported",
+			entries:     [][2]string{{"image", "layer"}, {config.BundleYAML, "kind: UDSBundle"}, {"other-image", "other layer"}},
+			wantExtra:   2,
+		},
+		{
+			name:        "NoRoot",
+			description: "an index.json without a bundle root manifest isn't a bundle",
+			entries:     [][2]string{{"image", "layer"}},
+			wantErr:     true,
+		},
+		{
+			name:        "TwoRoots",
+			description: "an index.json with more than one root manifest is malformed",
+			entries:     [][2]string{{config.BundleYAML, "kind: UDSBundle"}, {config.BundleYAML, "kind: UDSBundle # old"}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var manifests []ocispec.Descriptor
+			for _, entry := range tt.entries {
+				manifests = append(manifests, writeLayoutManifest(t, dir, entry[0], entry[1]))
+			}
+			writeLayoutIndex(t, dir, manifests...)
+
+			layout, err := checkLayoutIndex(func(path string) ([]byte, error) {
+				return os.ReadFile(filepath.Join(dir, path))
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkLayoutIndex() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if len(layout.extra) != tt.wantExtra {
+				t.Errorf("checkLayoutIndex() found %d extra entries, want %d (%s)", len(layout.extra), tt.wantExtra, tt.description)
+			}
+		})
+	}
+}
+
+func Test_VerifyLayoutRepair(t *testing.T) {
+	dir := t.TempDir()
+	image := writeLayoutManifest(t, dir, "image", "layer")
+	root := writeLayoutManifest(t, dir, config.BundleYAML, "kind: UDSBundle")
+	writeLayoutIndex(t, dir, image, root)
+
+	b := &Bundler{cfg: &types.BundlerConfig{VerifyLayoutOpts: types.BundlerVerifyLayoutOptions{Source: dir}}, tmp: t.TempDir()}
+	if err := b.VerifyLayout(); err == nil {
+		t.Fatalf("VerifyLayout() error = nil, want an error for an index.json with an unnecessary entry")
+	}
+
+	b.cfg.VerifyLayoutOpts.Repair = true
+	if err := b.VerifyLayout(); err != nil {
+		t.Fatalf("VerifyLayout() with --repair error = %v", err)
+	}
+	b.cfg.VerifyLayoutOpts.Repair = false
+	if err := b.VerifyLayout(); err != nil {
+		t.Errorf("VerifyLayout() error = %v after --repair, want the root manifest alone", err)
+	}
+}
+
+func Test_rebuildBundleIndex(t *testing.T) {
+	dir := t.TempDir()
+	image := writeLayoutManifest(t, dir, "image", "layer")
+	root := writeLayoutManifest(t, dir, config.BundleYAML, "kind: UDSBundle")
+	root.Platform = &ocispec.Platform{Architecture: "amd64", OS: config.MultiOS}
+	writeLayoutIndex(t, dir, image, root)
+
+	if err := rebuildBundleIndex(dir, root); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := checkLayoutIndex(func(path string) ([]byte, error) { return os.ReadFile(filepath.Join(dir, path)) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layout.index.Manifests) != 1 || layout.root.Digest != root.Digest || layout.root.Platform.Architecture != "amd64" {
+		t.Errorf("rebuildBundleIndex() left %v, want only the root manifest %s", layout.index.Manifests, root.Digest)
+	}
+	// nothing is left beside index.json from writing it
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "index.json" && entry.Name() != config.BlobsRoot {
+			t.Errorf("rebuildBundleIndex() left %s in the layout", entry.Name())
+		}
+	}
+
+	// a root manifest that isn't in the layout is never accepted
+	missing := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, []byte("{}"))
+	if err := rebuildBundleIndex(dir, missing); err == nil {
+		t.Errorf("rebuildBundleIndex() didn't fail for a root manifest missing from the layout")
+	}
+}
+
+func Test_validateBundleIndex(t *testing.T) {
+	dir := t.TempDir()
+	image := writeLayoutManifest(t, dir, "image", "layer")
+	root := writeLayoutManifest(t, dir, config.BundleYAML, "kind: UDSBundle")
+	other := writeLayoutManifest(t, dir, config.BundleYAML, "kind: UDSBundle\nmetadata: {name: other}")
+	tests := []struct {
+		name        string
+		description string
+		index       func()
+		wantErr     bool
+	}{
+		{
+			name:        "RootOnly",
+			description: "an index.json listing only the root manifest is valid",
+			index:       func() { writeLayoutIndex(t, dir, root) },
+		},
+		{
+			name:        "Corrupt",
+			description: "a partially written index.json is detected",
+			index: func() {
+				if err := os.WriteFile(filepath.Join(dir, "index.json"), []byte(`{"schemaVersion":2,"manifests":[{"mediaType":`), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name:        "UnnecessaryEntries",
+			description: "index.json listing more than the root manifest is detected",
+			index:       func() { writeLayoutIndex(t, dir, image, root) },
+			wantErr:     true,
+		},
+		{
+			name:        "OtherRoot",
+			description: "index.json listing a different root manifest is detected",
+			index:       func() { writeLayoutIndex(t, dir, other) },
+			wantErr:     true,
+		},
+		{
+			name:        "Empty",
+			description: "index.json without manifests is detected",
+			index:       func() { writeLayoutIndex(t, dir) },
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.index()
+			if err := validateBundleIndex(dir, root); (err != nil) != tt.wantErr {
+				t.Errorf("validateBundleIndex() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
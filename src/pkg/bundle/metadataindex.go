@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+)
+
+// metadataIndexEntry is a bundle listed in the index written by pull --metadata-only
+type metadataIndexEntry struct {
+	catalogEntry
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Signed      bool              `json:"signed"`
+	// Path is the directory holding the bundle's uds-bundle.yaml and signature, relative to the index
+	Path string `json:"path"`
+}
+
+// pullMetadataIndex pulls the uds-bundle.yaml, signature and manifest annotations of the bundle ref and the refs listed
+// in --from-file into a local index in the output directory, without pulling any packages
+func (b *Bundler) pullMetadataIndex() error {
+	opts := b.cfg.PullOpts
+	if !opts.MetadataOnly {
+		return fmt.Errorf("--from-file lists the bundles to pull with --metadata-only")
+	}
+	if opts.SignatureOnly || opts.TarballName != "" {
+		return fmt.Errorf("--metadata-only can't be used with --signature-only or --tarball-name")
+	}
+	refs, err := metadataRefs(opts.Source, opts.FromFile)
+	if err != nil {
+		return err
+	}
+
+	publicKeys, err := udsUtils.FetchPublicKeys(opts.PublicKeyPaths, b.tmp)
+	if err != nil {
+		return err
+	}
+
+	entries := []metadataIndexEntry{}
+	digests := map[string]string{}
+	for i, ref := range refs {
+		spinner := message.NewProgressSpinner("Pulling the metadata of %s", ref)
+		entry, loaded, err := pullBundleMetadata(ref, filepath.Join(b.tmp, strconv.Itoa(i)), publicKeys)
+		if err != nil {
+			spinner.Stop()
+			return fmt.Errorf("unable to pull the metadata of %s: %w", ref, err)
+		}
+		// the same bundle mirrored to several registries shares a directory
+		if digest, ok := digests[entry.Path]; ok && digest != entry.Digest {
+			spinner.Stop()
+			return fmt.Errorf("%s is a different %s %s (%s) than another ref in the index", ref, entry.Name, entry.Version, entry.Architecture)
+		}
+		digests[entry.Path] = entry.Digest
+		if err := copyBundleMetadata(opts.OutputDirectory, entry.Path, loaded); err != nil {
+			spinner.Stop()
+			return err
+		}
+		entries = append(entries, entry)
+		spinner.Successf("Pulled the metadata of %s %s (%s)", entry.Name, entry.Version, entry.Architecture)
+	}
+
+	if err := writeMetadataIndex(opts.OutputDirectory, entries); err != nil {
+		return err
+	}
+	message.Successf("Wrote an index of %d bundles to %s", len(entries), filepath.Join(opts.OutputDirectory, config.BundleMetadataIndex))
+	return nil
+}
+
+// metadataRefs returns the bundle ref given as an argument followed by the refs in refsFile, which lists one per line
+// and ignores blank lines and # comments
+func metadataRefs(source, refsFile string) ([]string, error) {
+	refs := []string{}
+	if source != "" {
+		refs = append(refs, source)
+	}
+	if refsFile != "" {
+		b, err := os.ReadFile(refsFile)
+		if err != nil {
+			return nil, err
+		}
+		for i, line := range strings.Split(string(b), "\n") {
+			ref := strings.TrimSpace(line)
+			if ref == "" || strings.HasPrefix(ref, "#") {
+				continue
+			}
+			if err := oci.ValidateReference(ref); err != nil {
+				return nil, fmt.Errorf("%s:%d: %q must be a valid OCI URL: %w", refsFile, i+1, ref, err)
+			}
+			refs = append(refs, ref)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if seen[ref] {
+			return nil, fmt.Errorf("%s is listed more than once", ref)
+		}
+		seen[ref] = true
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no bundles to pull, %s doesn't list any refs", refsFile)
+	}
+	return refs, nil
+}
+
+// pullBundleMetadata pulls the metadata + sig of the bundle at ref into dst and verifies the sig, only the root
+// manifest and its metadata layers are fetched
+func pullBundleMetadata(ref, dst string, publicKeys []udsUtils.TrustedKey) (metadataIndexEntry, PathMap, error) {
+	provider, err := NewBundleProvider(context.TODO(), ref, dst)
+	if err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+	loaded, err := provider.LoadBundleMetadata()
+	if err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+	sigAlgo, err := provider.SignatureAlgorithm()
+	if err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+	if _, err := verifyBundleSignature(loaded, publicKeys, sigAlgo); err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+	annotations, err := provider.Annotations()
+	if err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+	var bundle types.UDSBundle
+	if err := utils.ReadYaml(loaded[config.BundleYAML], &bundle); err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+
+	remote, err := udsUtils.NewOrasRemote(ref)
+	if err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+	rootDesc, err := remote.ResolveRoot()
+	if err != nil {
+		return metadataIndexEntry{}, nil, err
+	}
+
+	_, signed := loaded[config.BundleYAMLSignature]
+	return metadataIndexEntry{
+		catalogEntry: catalogEntry{
+			Name:         bundle.Metadata.Name,
+			Version:      bundle.Metadata.Version,
+			Architecture: bundle.Metadata.Architecture,
+			Description:  bundle.Metadata.Description,
+			Ref:          remote.Repo().Reference.String(),
+			Digest:       rootDesc.Digest.String(),
+		},
+		Annotations: annotations,
+		Signed:      signed,
+		Path:        strings.TrimSuffix(bundleTarballName(&bundle.Metadata), ".tar.zst"),
+	}, loaded, nil
+}
+
+// copyBundleMetadata copies the pulled metadata + sig into their bundle's directory in the index, replacing what an
+// earlier pull left there
+func copyBundleMetadata(indexDir, path string, loaded PathMap) error {
+	dir := filepath.Join(indexDir, path)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for _, rel := range config.BundleAlwaysPull {
+		abs, ok := loaded[rel]
+		if !ok {
+			continue
+		}
+		if err := utils.CreatePathAndCopy(abs, filepath.Join(dir, rel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMetadataIndex writes the entries to the index file in indexDir, in the order they were pulled
+func writeMetadataIndex(indexDir string, entries []metadataIndexEntry) error {
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := utils.CreateDirectory(indexDir, 0755); err != nil {
+		return err
+	}
+	return utils.WriteFile(filepath.Join(indexDir, config.BundleMetadataIndex), b)
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func Test_metadataRefs(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		source      string
+		contents    string
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "FromFile",
+			description: "one ref per line, blank lines and comments are skipped",
+			contents:    "# platform bundles\noci://ghcr.io/example/core:0.1.0\n\n  oci://ghcr.io/example/monitoring:0.2.0  \n",
+			want:        []string{"oci://ghcr.io/example/core:0.1.0", "oci://ghcr.io/example/monitoring:0.2.0"},
+		},
+		{
+			name:        "SourceFirst",
+			description: "the ref given as an argument comes before the file's refs",
+			source:      "oci://ghcr.io/example/core:0.1.0",
+			contents:    "oci://ghcr.io/example/monitoring:0.2.0\n",
+			want:        []string{"oci://ghcr.io/example/core:0.1.0", "oci://ghcr.io/example/monitoring:0.2.0"},
+		},
+		{
+			name:        "InvalidRef",
+			description: "lines that aren't OCI refs are refused",
+			contents:    "oci://ghcr.io/example/core:0.1.0\nuds-bundle-core-amd64-0.1.0.tar.zst\n",
+			wantErr:     true,
+		},
+		{
+			name:        "Duplicate",
+			description: "a ref listed twice is refused",
+			source:      "oci://ghcr.io/example/core:0.1.0",
+			contents:    "oci://ghcr.io/example/core:0.1.0\n",
+			wantErr:     true,
+		},
+		{
+			name:        "Empty",
+			description: "a file without any refs is refused",
+			contents:    "# nothing yet\n",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refsFile := filepath.Join(t.TempDir(), "refs.txt")
+			if err := os.WriteFile(refsFile, []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := metadataRefs(tt.source, refsFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("metadataRefs() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metadataRefs() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_writeMetadataIndex(t *testing.T) {
+	indexDir := t.TempDir()
+	pulled := t.TempDir()
+	bundleYAML := filepath.Join(pulled, "bundle-yaml-blob")
+	if err := os.WriteFile(bundleYAML, []byte("kind: UDSBundle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entry := metadataIndexEntry{
+		catalogEntry: catalogEntry{Name: "core", Version: "0.1.0", Architecture: "amd64", Ref: "ghcr.io/example/core:0.1.0", Digest: "sha256:abc"},
+		Annotations:  map[string]string{ocispec.AnnotationRevision: "4b4abea"},
+		Path:         "uds-bundle-core-amd64-0.1.0",
+	}
+
+	// a signature left by an earlier pull of a bundle that's no longer signed is removed
+	stale := filepath.Join(indexDir, entry.Path, config.BundleYAMLSignature)
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("old signature"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyBundleMetadata(indexDir, entry.Path, PathMap{config.BundleYAML: bundleYAML}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(indexDir, entry.Path, config.BundleYAML)); err != nil {
+		t.Errorf("copyBundleMetadata() didn't copy %s: %v", config.BundleYAML, err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("copyBundleMetadata() kept the stale %s", config.BundleYAMLSignature)
+	}
+
+	if err := writeMetadataIndex(indexDir, []metadataIndexEntry{entry}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(indexDir, config.BundleMetadataIndex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []metadataIndexEntry
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []metadataIndexEntry{entry}; !reflect.DeepEqual(got, want) {
+		t.Errorf("writeMetadataIndex() wrote %+v, want %+v", got, want)
+	}
+	// the catalog entry's fields are at the top level of each entry
+	var raw []map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw[0]["name"] != "core" || raw[0]["path"] != entry.Path {
+		t.Errorf("writeMetadataIndex() wrote %v, want the entry's fields at the top level", raw[0])
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	goyaml "github.com/goccy/go-yaml"
+)
+
+// bundleMigration upgrades a uds-bundle.yaml from one spec version to the next
+type bundleMigration struct {
+	to string
+	// migrate transforms the bundle in place and describes each change it made
+	migrate func(bundle map[string]interface{}) []string
+}
+
+// bundleMigrations are keyed by the apiVersion they upgrade from, bundles without an apiVersion predate versioning
+var bundleMigrations = map[string]bundleMigration{
+	"": {to: config.BundleAPIVersionV1Alpha1, migrate: migrateUnversioned},
+}
+
+// MigrateBundleYAML upgrades a uds-bundle.yaml to the current spec version, returning it and a summary of the changes
+func MigrateBundleYAML(bundleYAML []byte) ([]byte, []string, error) {
+	bundle := make(map[string]interface{})
+	if err := goyaml.Unmarshal(bundleYAML, &bundle); err != nil {
+		return nil, nil, err
+	}
+
+	changes := []string{}
+	version, _ := bundle["apiVersion"].(string)
+	for version != config.BundleAPIVersion {
+		migration, ok := bundleMigrations[version]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown apiVersion %q, this version of uds can migrate bundles up to %s", version, config.BundleAPIVersion)
+		}
+		changes = append(changes, migration.migrate(bundle)...)
+		bundle["apiVersion"] = migration.to
+		changes = append(changes, fmt.Sprintf("set apiVersion to %s", migration.to))
+		version = migration.to
+	}
+
+	// round trip through the current types so the result is known to load and fields are in their usual order
+	b, err := goyaml.Marshal(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	var migrated types.UDSBundle
+	if err := goyaml.UnmarshalWithOptions(b, &migrated, goyaml.Strict()); err != nil {
+		return nil, nil, fmt.Errorf("unable to migrate bundle, it has fields the migration doesn't know about: %w", err)
+	}
+	out, err := goyaml.Marshal(migrated)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, changes, nil
+}
+
+// migrateUnversioned moves bundles written before apiVersion existed onto uds.dev/v1alpha1
+//
+// early bundles listed packages under packages, used camel and snake case keys and put the tag in the repository
+func migrateUnversioned(bundle map[string]interface{}) []string {
+	changes := renameKeys(bundle, "", map[string]string{"packages": "zarf-packages"})
+	if metadata, ok := bundle["metadata"].(map[string]interface{}); ok {
+		changes = append(changes, renameKeys(metadata, "metadata.", map[string]string{"arch": "architecture"})...)
+	}
+
+	pkgs, _ := bundle["zarf-packages"].([]interface{})
+	for i, entry := range pkgs {
+		pkg, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		prefix := fmt.Sprintf("zarf-packages[%d].", i)
+		changes = append(changes, renameKeys(pkg, prefix, map[string]string{
+			"tag":                 "ref",
+			"publicKey":           "public-key",
+			"public_key":          "public-key",
+			"optionalComponents":  "optional-components",
+			"optional_components": "optional-components",
+			"depends-on":          "dependsOn",
+			"depends_on":          "dependsOn",
+		})...)
+
+		// repository: ghcr.io/defenseunicorns/packages/podinfo:0.0.1 becomes a repository and a ref
+		repo, _ := pkg["repository"].(string)
+		if _, hasRef := pkg["ref"]; repo == "" || hasRef {
+			continue
+		}
+		if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+			pkg["repository"], pkg["ref"] = repo[:i], repo[i+1:]
+			changes = append(changes, fmt.Sprintf("split the tag in %srepository into %sref", prefix, prefix))
+		}
+	}
+	return changes
+}
+
+// renameKeys renames the keys of m that are in renames, a renamed key doesn't overwrite a key already using the new name
+func renameKeys(m map[string]interface{}, prefix string, renames map[string]string) []string {
+	olds := make([]string, 0, len(renames))
+	for old := range renames {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+
+	changes := []string{}
+	for _, old := range olds {
+		renamed := renames[old]
+		value, ok := m[old]
+		if !ok {
+			continue
+		}
+		if _, exists := m[renamed]; exists {
+			continue
+		}
+		m[renamed] = value
+		delete(m, old)
+		changes = append(changes, fmt.Sprintf("renamed %s%s to %s%s", prefix, old, prefix, renamed))
+	}
+	return changes
+}
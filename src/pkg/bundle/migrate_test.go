@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	goyaml "github.com/goccy/go-yaml"
+)
+
+func Test_MigrateBundleYAML(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		bundleYAML  string
+		want        types.UDSBundle
+		wantChanges []string
+		wantErr     bool
+	}{
+		{
+			name:        "Unversioned",
+			description: "renamed fields and tags in repositories are moved to the current spec",
+			bundleYAML: `kind: UDSBundle
+metadata:
+  name: example
+  arch: amd64
+packages:
+  - name: podinfo
+    repository: ghcr.io/defenseunicorns/packages/podinfo:0.0.1
+    publicKey: ./public.key
+    optionalComponents: [extras]
+  - name: nginx
+    repository: localhost:5000/nginx
+    tag: 0.0.1
+    depends_on: [podinfo]
+`,
+			want: types.UDSBundle{
+				APIVersion: config.BundleAPIVersion,
+				Kind:       "UDSBundle",
+				Metadata:   types.UDSMetadata{Name: "example", Architecture: "amd64"},
+				ZarfPackages: []types.BundleZarfPackage{
+					{Name: "podinfo", Repository: "ghcr.io/defenseunicorns/packages/podinfo", Ref: "0.0.1", PublicKey: "./public.key", OptionalComponents: []string{"extras"}},
+					{Name: "nginx", Repository: "localhost:5000/nginx", Ref: "0.0.1", DependsOn: []string{"podinfo"}},
+				},
+			},
+			wantChanges: []string{
+				"renamed packages to zarf-packages",
+				"renamed metadata.arch to metadata.architecture",
+				"renamed zarf-packages[0].optionalComponents to zarf-packages[0].optional-components",
+				"renamed zarf-packages[0].publicKey to zarf-packages[0].public-key",
+				"split the tag in zarf-packages[0].repository into zarf-packages[0].ref",
+				"renamed zarf-packages[1].depends_on to zarf-packages[1].dependsOn",
+				"renamed zarf-packages[1].tag to zarf-packages[1].ref",
+				"set apiVersion to " + config.BundleAPIVersion,
+			},
+		}, {
+			name:        "Current",
+			description: "bundles already at the current spec are unchanged",
+			bundleYAML: `apiVersion: ` + config.BundleAPIVersion + `
+kind: UDSBundle
+metadata:
+  name: example
+zarf-packages:
+  - name: podinfo
+    repository: localhost:5000/podinfo
+    ref: 0.0.1
+`,
+			want: types.UDSBundle{
+				APIVersion:   config.BundleAPIVersion,
+				Kind:         "UDSBundle",
+				Metadata:     types.UDSMetadata{Name: "example"},
+				ZarfPackages: []types.BundleZarfPackage{{Name: "podinfo", Repository: "localhost:5000/podinfo", Ref: "0.0.1"}},
+			},
+			wantChanges: []string{},
+		}, {
+			name:        "UnknownVersion",
+			description: "error on an apiVersion newer than this version of uds knows about",
+			bundleYAML:  "apiVersion: uds.dev/v9\nkind: UDSBundle\n",
+			wantErr:     true,
+		}, {
+			name:        "UnknownField",
+			description: "error when fields are left that the current spec doesn't have",
+			bundleYAML:  "kind: UDSBundle\nmetadata:\n  name: example\nzarf-packages: []\nsignature: abc\n",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changes, err := MigrateBundleYAML([]byte(tt.bundleYAML))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MigrateBundleYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var bundle types.UDSBundle
+			if err := goyaml.Unmarshal(got, &bundle); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(bundle, tt.want) {
+				t.Errorf("MigrateBundleYAML() = %+v, want %+v", bundle, tt.want)
+			}
+			if !reflect.DeepEqual(changes, tt.wantChanges) {
+				t.Errorf("MigrateBundleYAML() changes = %v, want %v", changes, tt.wantChanges)
+			}
+		})
+	}
+}
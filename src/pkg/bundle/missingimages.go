@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// missingImage is an image a bundled component lists in its package's zarf.yaml that isn't in the package
+type missingImage struct {
+	Package   string
+	Component string
+	Image     string
+	Reason    string
+}
+
+// String returns a human readable description of the missing image
+func (m missingImage) String() string {
+	return fmt.Sprintf("%s (package %s, component %s): %s", m.Image, m.Package, m.Component, m.Reason)
+}
+
+// findMissingImages cross-checks the images of a package's bundled components against its images/index.json, an image is
+// missing if the index has no entry for it or its manifest, config or layers aren't layers of the package manifest
+func findMissingImages(ctx context.Context, fetcher content.Fetcher, manifest *oci.ZarfOCIManifest, pkg types.BundleZarfPackage,
+	zarfPkg zarfTypes.ZarfPackage) ([]missingImage, error) {
+	missing := []missingImage{}
+	var index *ocispec.Index
+	// images shared by components are only checked once
+	reasons := make(map[string]string)
+	for _, component := range bundledComponents(pkg, zarfPkg) {
+		for _, image := range helpers.Unique(component.Images) {
+			reason, ok := reasons[image]
+			if !ok {
+				if index == nil {
+					index = &ocispec.Index{}
+					if indexDesc := manifest.Locate(packageImagesIndex); !oci.IsEmptyDescriptor(indexDesc) {
+						if err := fetchJSON(ctx, fetcher, indexDesc, index); err != nil {
+							return nil, fmt.Errorf("unable to read the %s of zarf pkg %s: %w", packageImagesIndex, pkg.Name, err)
+						}
+					}
+				}
+				var err error
+				if reason, err = missingImageReason(ctx, fetcher, manifest, index, image); err != nil {
+					return nil, fmt.Errorf("unable to check image %s of zarf pkg %s: %w", image, pkg.Name, err)
+				}
+				reasons[image] = reason
+			}
+			if reason != "" {
+				missing = append(missing, missingImage{Package: pkg.Name, Component: component.Name, Image: image, Reason: reason})
+			}
+		}
+	}
+	return missing, nil
+}
+
+// missingImageReason returns why image isn't in the package, or "" if it is
+func missingImageReason(ctx context.Context, fetcher content.Fetcher, manifest *oci.ZarfOCIManifest, index *ocispec.Index, image string) (string, error) {
+	entry := helpers.Find(index.Manifests, func(desc ocispec.Descriptor) bool {
+		return desc.Annotations[ocispec.AnnotationBaseImageName] == image
+	})
+	if entry.Digest == "" {
+		return fmt.Sprintf("it has no entry in %s", packageImagesIndex), nil
+	}
+	manifestLayer := manifest.Locate(imageBlobTitle(entry.Digest))
+	if oci.IsEmptyDescriptor(manifestLayer) {
+		return fmt.Sprintf("its manifest %s isn't in the package", entry.Digest), nil
+	}
+	// Zarf packages single-platform images, anything else only needs its manifest
+	if entry.MediaType != ocispec.MediaTypeImageManifest && entry.MediaType != dockerManifestMediaType {
+		return "", nil
+	}
+	var imageManifest ocispec.Manifest
+	if err := fetchJSON(ctx, fetcher, manifestLayer, &imageManifest); err != nil {
+		return "", err
+	}
+	absent := 0
+	for _, desc := range append([]ocispec.Descriptor{imageManifest.Config}, imageManifest.Layers...) {
+		if oci.IsEmptyDescriptor(manifest.Locate(imageBlobTitle(desc.Digest))) {
+			absent++
+		}
+	}
+	if absent > 0 {
+		return fmt.Sprintf("%d of its %d config and layer blobs aren't in the package", absent, len(imageManifest.Layers)+1), nil
+	}
+	return "", nil
+}
+
+// reportMissingImages warns about the images packages are missing, or fails the create with --strict
+func reportMissingImages(missing []missingImage, strict bool) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	msgs := []string{fmt.Sprintf("%d image(s) referenced in zarf.yaml are missing from their packages and will fail to deploy:", len(missing))}
+	for _, image := range missing {
+		msgs = append(msgs, " - "+image.String())
+	}
+	if strict {
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	message.Warn(strings.Join(msgs, "\n"))
+	return nil
+}
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func Test_findMissingImages(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	push := func(mediaType string, b []byte) ocispec.Descriptor {
+		t.Helper()
+		desc, err := pushBlob(ctx, store, mediaType, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	layer := func(desc ocispec.Descriptor, title string) ocispec.Descriptor {
+		desc.MediaType = oci.ZarfLayerMediaTypeBlob
+		desc.Annotations = map[string]string{ocispec.AnnotationTitle: title}
+		return desc
+	}
+
+	// packages store every blob of their images as a Zarf blob
+	configDesc := push(oci.ZarfLayerMediaTypeBlob, []byte("{}"))
+	layerDesc := push(oci.ZarfLayerMediaTypeBlob, []byte("layer"))
+	imageManifest, _ := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig, Digest: configDesc.Digest, Size: configDesc.Size},
+		Layers:    []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: layerDesc.Digest, Size: layerDesc.Size}},
+	})
+	imageDesc := push(oci.ZarfLayerMediaTypeBlob, imageManifest)
+	imageDesc.MediaType = ocispec.MediaTypeImageManifest
+	imageDesc.Annotations = map[string]string{ocispec.AnnotationBaseImageName: "nginx:1.25"}
+	index, _ := json.Marshal(ocispec.Index{Manifests: []ocispec.Descriptor{imageDesc}})
+	indexLayer := layer(push(oci.ZarfLayerMediaTypeBlob, index), packageImagesIndex)
+	imageLayers := []ocispec.Descriptor{
+		layer(imageDesc, imageBlobTitle(imageDesc.Digest)),
+		layer(configDesc, imageBlobTitle(configDesc.Digest)),
+		layer(layerDesc, imageBlobTitle(layerDesc.Digest)),
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		components  []zarfTypes.ZarfComponent
+		layers      []ocispec.Descriptor
+		want        []missingImage
+	}{
+		{
+			name:        "Present",
+			description: "images whose manifest, config and layers are in the package aren't missing",
+			components:  []zarfTypes.ZarfComponent{{Name: "nginx", Required: true, Images: []string{"nginx:1.25"}}},
+			layers:      append([]ocispec.Descriptor{indexLayer}, imageLayers...),
+			want:        []missingImage{},
+		},
+		{
+			name:        "NotIndexed",
+			description: "images without an entry in images/index.json are missing",
+			components:  []zarfTypes.ZarfComponent{{Name: "nginx", Required: true, Images: []string{"nginx:1.25", "busybox:1.36"}}},
+			layers:      append([]ocispec.Descriptor{indexLayer}, imageLayers...),
+			want:        []missingImage{{Package: "pkg", Component: "nginx", Image: "busybox:1.36", Reason: "it has no entry in images/index.json"}},
+		},
+		{
+			name:        "NoIndex",
+			description: "packages without an images/index.json are missing all their images",
+			components:  []zarfTypes.ZarfComponent{{Name: "nginx", Required: true, Images: []string{"nginx:1.25"}}},
+			want:        []missingImage{{Package: "pkg", Component: "nginx", Image: "nginx:1.25", Reason: "it has no entry in images/index.json"}},
+		},
+		{
+			name:        "NoManifest",
+			description: "images whose manifest isn't a layer of the package are missing",
+			components:  []zarfTypes.ZarfComponent{{Name: "nginx", Required: true, Images: []string{"nginx:1.25"}}},
+			layers:      append([]ocispec.Descriptor{indexLayer}, imageLayers[1:]...),
+			want:        []missingImage{{Package: "pkg", Component: "nginx", Image: "nginx:1.25", Reason: "its manifest " + imageDesc.Digest.String() + " isn't in the package"}},
+		},
+		{
+			name:        "NoLayer",
+			description: "images with layers that aren't in the package are missing",
+			components:  []zarfTypes.ZarfComponent{{Name: "nginx", Required: true, Images: []string{"nginx:1.25"}}},
+			layers:      []ocispec.Descriptor{indexLayer, imageLayers[0], imageLayers[1]},
+			want:        []missingImage{{Package: "pkg", Component: "nginx", Image: "nginx:1.25", Reason: "1 of its 2 config and layer blobs aren't in the package"}},
+		},
+		{
+			name:        "SharedImage",
+			description: "an image missing for several components is reported for each of them",
+			components: []zarfTypes.ZarfComponent{
+				{Name: "first", Required: true, Images: []string{"busybox:1.36"}},
+				{Name: "second", Required: true, Images: []string{"busybox:1.36"}},
+			},
+			layers: append([]ocispec.Descriptor{indexLayer}, imageLayers...),
+			want: []missingImage{
+				{Package: "pkg", Component: "first", Image: "busybox:1.36", Reason: "it has no entry in images/index.json"},
+				{Package: "pkg", Component: "second", Image: "busybox:1.36", Reason: "it has no entry in images/index.json"},
+			},
+		},
+		{
+			name:        "OptionalNotBundled",
+			description: "images of optional components left out of the bundle aren't checked",
+			components:  []zarfTypes.ZarfComponent{{Name: "extra", Images: []string{"busybox:1.36"}}},
+			layers:      append([]ocispec.Descriptor{indexLayer}, imageLayers...),
+			want:        []missingImage{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := &oci.ZarfOCIManifest{Manifest: ocispec.Manifest{Layers: tt.layers}}
+			got, err := findMissingImages(ctx, store, manifest, types.BundleZarfPackage{Name: "pkg"}, zarfTypes.ZarfPackage{Components: tt.components})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findMissingImages() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_reportMissingImages(t *testing.T) {
+	missing := []missingImage{{Package: "pkg", Component: "nginx", Image: "nginx:1.25", Reason: "it has no entry in images/index.json"}}
+	tests := []struct {
+		name        string
+		description string
+		missing     []missingImage
+		strict      bool
+		wantErr     bool
+	}{
+		{
+			name:        "Warn",
+			description: "missing images are only warned about by default",
+			missing:     missing,
+		},
+		{
+			name:        "Strict",
+			description: "--strict fails the create on missing images",
+			missing:     missing,
+			strict:      true,
+			wantErr:     true,
+		},
+		{
+			name:        "StrictNoneMissing",
+			description: "--strict passes when nothing is missing",
+			strict:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := reportMissingImages(tt.missing, tt.strict); (err != nil) != tt.wantErr {
+				t.Errorf("reportMissingImages() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
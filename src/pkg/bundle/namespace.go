@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// prefixPackageNamespaces rewrites the zarf.yaml of a loaded package so it deploys into prefixed namespaces under a
+// prefixed name
+//
+// the zarf.yaml signature covers the namespaces, so signed packages can't be prefixed
+func prefixPackageNamespaces(pkgDir, prefix string) error {
+	if !utils.InvalidPath(filepath.Join(pkgDir, zarfConfig.ZarfYAMLSignature)) {
+		return fmt.Errorf("--namespace-prefix can't be used with signed Zarf packages, prefixing would invalidate the signature")
+	}
+
+	zarfYAMLPath := filepath.Join(pkgDir, zarfConfig.ZarfYAML)
+	var pkg zarfTypes.ZarfPackage
+	if err := utils.ReadYaml(zarfYAMLPath, &pkg); err != nil {
+		return err
+	}
+	if pkg.Kind == zarfTypes.ZarfInitConfig {
+		// Zarf's own components have to live in the zarf namespace
+		return nil
+	}
+	if err := prefixNamespaces(&pkg, prefix); err != nil {
+		return err
+	}
+	if err := prefixPackageName(&pkg, prefix); err != nil {
+		return err
+	}
+	return utils.WriteYaml(zarfYAMLPath, pkg, 0644)
+}
+
+// prefixPackageName prefixes a package's name, Zarf keeps the state of a deployed package in a zarf-package-<name>
+// secret that tenants deploying the same package would otherwise overwrite
+func prefixPackageName(pkg *zarfTypes.ZarfPackage, prefix string) error {
+	prefixed := prefix + pkg.Metadata.Name
+	if msgs := validation.IsDNS1123Subdomain(zarfConfig.ZarfPackagePrefix + prefixed); len(msgs) > 0 {
+		return fmt.Errorf("invalid prefixed package name %s: %s", prefixed, strings.Join(msgs, ", "))
+	}
+	pkg.Metadata.Name = prefixed
+	return nil
+}
+
+// prefixedPackageName returns the name a bundle's package is deployed under with --namespace-prefix, the Zarf init
+// package is never prefixed
+func prefixedPackageName(pkgName, prefix string) string {
+	if pkgName == zarfInitPackageName {
+		return pkgName
+	}
+	return prefix + pkgName
+}
+
+// prefixNamespaces prefixes the namespaces a package's charts, manifests, data injections and deploy waits target
+func prefixNamespaces(pkg *zarfTypes.ZarfPackage, prefix string) error {
+	var errs []string
+	prefixNamespace := func(namespace *string) {
+		if *namespace == "" {
+			return
+		}
+		prefixed := prefix + *namespace
+		if msgs := validation.IsDNS1123Label(prefixed); len(msgs) > 0 {
+			errs = append(errs, fmt.Sprintf("%s: %s", prefixed, strings.Join(msgs, ", ")))
+			return
+		}
+		*namespace = prefixed
+	}
+
+	for i := range pkg.Components {
+		component := &pkg.Components[i]
+		for j := range component.Charts {
+			prefixNamespace(&component.Charts[j].Namespace)
+		}
+		for j := range component.Manifests {
+			prefixNamespace(&component.Manifests[j].Namespace)
+		}
+		for j := range component.DataInjections {
+			prefixNamespace(&component.DataInjections[j].Target.Namespace)
+		}
+		onDeploy := &component.Actions.OnDeploy
+		for _, actions := range [][]zarfTypes.ZarfComponentAction{onDeploy.Before, onDeploy.After, onDeploy.OnSuccess, onDeploy.OnFailure} {
+			for j := range actions {
+				if actions[j].Wait != nil && actions[j].Wait.Cluster != nil {
+					prefixNamespace(&actions[j].Wait.Cluster.Namespace)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid prefixed namespaces in package %s:\n - %s", pkg.Metadata.Name, strings.Join(errs, "\n - "))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfExec "github.com/defenseunicorns/zarf/src/pkg/utils/exec"
+)
+
+// validateSignMethod ensures the sign method is one UDS knows how to produce/verify
+func validateSignMethod(method string) error {
+	switch method {
+	case "", config.SignMethodSig, config.SignMethodNotation:
+		return nil
+	default:
+		return fmt.Errorf("invalid sign method %q, must be one of: %s, %s", method, config.SignMethodSig, config.SignMethodNotation)
+	}
+}
+
+// notationDigestRef resolves the bundle's root manifest in remote and returns a registry/repo@digest reference to it
+//
+// notation signs/verifies manifests by digest, tags are mutable
+func notationDigestRef(remote *oci.OrasRemote) (string, error) {
+	root, err := remote.ResolveRoot()
+	if err != nil {
+		return "", err
+	}
+	ref := remote.Repo().Reference
+	return fmt.Sprintf("%s/%s@%s", ref.Registry, ref.Repository, root.Digest), nil
+}
+
+// runNotation runs the notation CLI, honoring --insecure and --plain-http for plain HTTP registries
+func runNotation(args ...string) error {
+	if _, err := exec.LookPath(config.NotationBinary); err != nil {
+		return fmt.Errorf("unable to find %s on the PATH, it is required to use --sign-method %s", config.NotationBinary, config.SignMethodNotation)
+	}
+	if config.CommonOptions.Insecure || config.CommonOptions.PlainHTTP {
+		args = append(args, "--insecure-registry")
+	}
+	return zarfExec.CmdWithPrint(config.NotationBinary, args...)
+}
+
+// notationSign produces a Notary v2 signature over the bundle manifest in remote, stored as a referrer in the registry
+func notationSign(remote *oci.OrasRemote, key string) error {
+	ref, err := notationDigestRef(remote)
+	if err != nil {
+		return err
+	}
+	args := []string{"sign", ref}
+	if key != "" {
+		args = append(args, "--key", key)
+	}
+	message.Debug("Signing", ref, "with notation")
+	if err := runNotation(args...); err != nil {
+		return fmt.Errorf("unable to sign %s with notation: %w", ref, err)
+	}
+	message.Successf("Signed %s with notation", ref)
+	return nil
+}
+
+// notationVerify verifies the Notary v2 signature of the bundle at source against the configured notation trust policy and trust store
+func notationVerify(source string) error {
+	remote, err := utils.NewOrasRemote(source)
+	if err != nil {
+		return err
+	}
+	ref, err := notationDigestRef(remote)
+	if err != nil {
+		return err
+	}
+	message.Debug("Verifying", ref, "with notation")
+	if err := runNotation("verify", ref); err != nil {
+		return fmt.Errorf("unable to verify %s with notation: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,453 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// packageImagesIndex is the title of the index.json of the OCI layout holding a Zarf package's images
+const packageImagesIndex = "images/index.json"
+
+// dockerManifestMediaType is the media type of Docker v2 image manifests, Zarf keeps the manifests of images as pulled
+const dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+
+// rebasedLayer is an image layer of a package replaced by a layer of another package with the same uncompressed content
+type rebasedLayer struct {
+	Package string
+	Image   string
+	From    ocispec.Descriptor
+	To      ocispec.Descriptor
+}
+
+// layerOptimization records what --optimize-layers changed in a bundle
+type layerOptimization struct {
+	rebased  []rebasedLayer
+	repinned map[string]digest.Digest
+	unsigned []string
+}
+
+// packageImage is an image manifest in a package's images/index.json
+type packageImage struct {
+	desc     ocispec.Descriptor
+	manifest ocispec.Manifest
+}
+
+// packageImages is a package manifest along with the images in its images/index.json
+type packageImages struct {
+	manifest oci.ZarfOCIManifest
+	index    ocispec.Index
+	images   []packageImage
+}
+
+// layerDiffID returns the digest of a layer's uncompressed content, layers that aren't tarballs or gzipped tarballs return false
+func layerDiffID(ctx context.Context, store content.Fetcher, desc ocispec.Descriptor) (digest.Digest, bool, error) {
+	gzipped := false
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageLayerGzip, "application/vnd.docker.image.rootfs.diff.tar.gzip":
+		gzipped = true
+	case ocispec.MediaTypeImageLayer, "application/vnd.docker.image.rootfs.diff.tar":
+	default:
+		return "", false, nil
+	}
+
+	rc, err := store.Fetch(ctx, desc)
+	if err != nil {
+		return "", false, err
+	}
+	defer rc.Close()
+	var r io.Reader = rc
+	if gzipped {
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			return "", false, fmt.Errorf("unable to decompress layer %s: %w", desc.Digest, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	dgst, err := digest.FromReader(r)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to decompress layer %s: %w", desc.Digest, err)
+	}
+	return dgst, true, nil
+}
+
+// fetchJSON reads the blob described by desc out of store into v
+func fetchJSON(ctx context.Context, store content.Fetcher, desc ocispec.Descriptor, v any) error {
+	b, err := content.FetchAll(ctx, store, desc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// pushBlob pushes b into store unless the store already has it
+func pushBlob(ctx context.Context, store content.Storage, mediaType string, b []byte) (ocispec.Descriptor, error) {
+	desc := content.NewDescriptorFromBytes(mediaType, b)
+	if err := store.Push(ctx, desc, bytes.NewReader(b)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// imageBlobTitle is the title of a blob of a package's images in its package manifest
+func imageBlobTitle(dgst digest.Digest) string {
+	return "images/" + utils.BlobPath(dgst)
+}
+
+// loadPackageImages reads a package manifest and the image manifests of its images/index.json that are in store,
+// packages without images return a nil index
+func loadPackageImages(ctx context.Context, store content.Storage, manifestDesc ocispec.Descriptor) (*packageImages, error) {
+	pkg := &packageImages{}
+	if err := fetchJSON(ctx, store, manifestDesc, &pkg.manifest); err != nil {
+		return nil, err
+	}
+	indexDesc := pkg.manifest.Locate(packageImagesIndex)
+	if oci.IsEmptyDescriptor(indexDesc) {
+		return pkg, nil
+	}
+	if err := fetchJSON(ctx, store, indexDesc, &pkg.index); err != nil {
+		return nil, err
+	}
+	for _, desc := range pkg.index.Manifests {
+		if desc.MediaType != ocispec.MediaTypeImageManifest && desc.MediaType != dockerManifestMediaType {
+			continue
+		}
+		// images of optional components that weren't bundled aren't in the store
+		if exists, err := store.Exists(ctx, desc); err != nil {
+			return nil, err
+		} else if !exists {
+			continue
+		}
+		image := packageImage{desc: desc}
+		if err := fetchJSON(ctx, store, desc, &image.manifest); err != nil {
+			return nil, err
+		}
+		pkg.images = append(pkg.images, image)
+	}
+	return pkg, nil
+}
+
+// optimizeLayers rebases the image layers of the fetched packages onto a single copy of each layer, layers are only
+// replaced by layers with the same uncompressed content (diffID) so image configs and their diffIDs stay valid
+//
+// the rebased packages' image manifests, images/index.json, checksums.txt, zarf.yaml and package manifest are rewritten,
+// their zarf.yaml.sig no longer matches and is dropped and their refs are repinned to the new package manifests
+func (b *Bundler) optimizeLayers(ctx context.Context, store content.Storage, fetched []fetchedPackage) (*layerOptimization, error) {
+	opt := &layerOptimization{repinned: make(map[string]digest.Digest)}
+
+	// find the smallest copy of each layer's content across every package, the first package wins ties
+	pkgs := make([]*packageImages, len(fetched))
+	diffIDs := make(map[digest.Digest]digest.Digest)
+	shared := make(map[digest.Digest]ocispec.Descriptor)
+	for i := range fetched {
+		pkg, err := loadPackageImages(ctx, store, fetched[i].manifestDesc)
+		if err != nil {
+			return nil, err
+		}
+		pkgs[i] = pkg
+		for _, image := range pkg.images {
+			for _, layer := range image.manifest.Layers {
+				if _, ok := diffIDs[layer.Digest]; ok {
+					continue
+				}
+				if exists, err := store.Exists(ctx, layer); err != nil || !exists {
+					continue
+				}
+				diffID, ok, err := layerDiffID(ctx, store, layer)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				diffIDs[layer.Digest] = diffID
+				if current, ok := shared[diffID]; !ok || layer.Size < current.Size {
+					shared[diffID] = layer
+				}
+			}
+		}
+	}
+
+	for i, pkg := range pkgs {
+		if err := b.rebasePackage(ctx, store, &fetched[i], i, pkg, diffIDs, shared, opt); err != nil {
+			return nil, err
+		}
+	}
+	return opt, nil
+}
+
+// rebasePackage rewrites the i-th package so its images use the shared copy of each of their layers
+func (b *Bundler) rebasePackage(ctx context.Context, store content.Storage, fetched *fetchedPackage, i int, pkg *packageImages,
+	diffIDs map[digest.Digest]digest.Digest, shared map[digest.Digest]ocispec.Descriptor, opt *layerOptimization) error {
+	name := b.bundle.ZarfPackages[i].Name
+
+	// blob digests of the package that were replaced, mapped to their replacements
+	replaced := make(map[digest.Digest]ocispec.Descriptor)
+	pinned := digestPinnedImages(pkg)
+	for j, image := range pkg.images {
+		// rebasing changes the image manifest's digest, which an image referenced by digest must keep
+		if pinned[image.desc.Digest] {
+			continue
+		}
+		changed := false
+		for k, layer := range image.manifest.Layers {
+			diffID, ok := diffIDs[layer.Digest]
+			if !ok || shared[diffID].Digest == layer.Digest {
+				continue
+			}
+			to := shared[diffID]
+			opt.rebased = append(opt.rebased, rebasedLayer{Package: name, Image: image.desc.Annotations[ocispec.AnnotationBaseImageName], From: layer, To: to})
+			image.manifest.Layers[k] = to
+			replaced[layer.Digest] = to
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		manifestBytes, err := json.Marshal(image.manifest)
+		if err != nil {
+			return err
+		}
+		newDesc, err := pushBlob(ctx, store, image.desc.MediaType, manifestBytes)
+		if err != nil {
+			return err
+		}
+		newDesc.Annotations = image.desc.Annotations
+		newDesc.Platform = image.desc.Platform
+		replaced[image.desc.Digest] = newDesc
+		for k, desc := range pkg.index.Manifests {
+			if desc.Digest == image.desc.Digest {
+				pkg.index.Manifests[k] = newDesc
+			}
+		}
+		pkg.images[j].desc = newDesc
+	}
+	if len(replaced) == 0 {
+		return nil
+	}
+
+	newDigest, signed, err := b.rewritePackage(ctx, store, fetched, i, pkg, replaced, nil)
+	if err != nil {
+		return err
+	}
+	if signed {
+		opt.unsigned = append(opt.unsigned, name)
+	}
+	opt.repinned[name] = newDigest
+	return nil
+}
+
+// digestPinnedImages returns the image manifests the package's zarf.yaml lists by digest (ie. <image>@sha256:<digest>),
+// including those that are also listed by tag
+func digestPinnedImages(pkg *packageImages) map[digest.Digest]bool {
+	pinned := make(map[digest.Digest]bool)
+	for _, desc := range pkg.index.Manifests {
+		if strings.Contains(desc.Annotations[ocispec.AnnotationBaseImageName], "@") {
+			pinned[desc.Digest] = true
+		}
+	}
+	return pinned
+}
+
+// rewritePackage pushes the i-th package's changed images/index.json (pkg.index) along with the checksums.txt, zarf.yaml
+// and package manifest listing it, replaced maps the package's blobs that were swapped for others to their replacements
+//
+// edit (if not nil) changes the zarf.yaml before it's pushed. The package's zarf.yaml.sig no longer matches and is dropped,
+// the package is repinned to its new manifest and whether it was signed is returned
+func (b *Bundler) rewritePackage(ctx context.Context, store content.Storage, fetched *fetchedPackage, i int, pkg *packageImages,
+	replaced map[digest.Digest]ocispec.Descriptor, edit func(*zarfTypes.ZarfPackage)) (digest.Digest, bool, error) {
+	indexBytes, err := json.Marshal(pkg.index)
+	if err != nil {
+		return "", false, err
+	}
+	indexDesc, err := pushBlob(ctx, store, oci.ZarfLayerMediaTypeBlob, indexBytes)
+	if err != nil {
+		return "", false, err
+	}
+
+	manifest := &pkg.manifest
+	checksumsDesc := manifest.Locate(zarfConfig.ZarfChecksumsTxt)
+	checksums, err := content.FetchAll(ctx, store, checksumsDesc)
+	if err != nil {
+		return "", false, err
+	}
+	newChecksums := rewriteChecksums(string(checksums), replaced, indexDesc.Digest)
+	newChecksumsDesc, err := pushBlob(ctx, store, oci.ZarfLayerMediaTypeBlob, []byte(newChecksums))
+	if err != nil {
+		return "", false, err
+	}
+
+	// the aggregate checksum in zarf.yaml is the checksum of checksums.txt
+	zarfYAMLDesc := manifest.Locate(config.ZarfYAML)
+	zarfYAMLBytes, err := content.FetchAll(ctx, store, zarfYAMLDesc)
+	if err != nil {
+		return "", false, err
+	}
+	var zarfPkg zarfTypes.ZarfPackage
+	if err := goyaml.Unmarshal(zarfYAMLBytes, &zarfPkg); err != nil {
+		return "", false, err
+	}
+	if edit != nil {
+		edit(&zarfPkg)
+	}
+	zarfPkg.Metadata.AggregateChecksum = newChecksumsDesc.Digest.Encoded()
+	if zarfYAMLBytes, err = goyaml.Marshal(zarfPkg); err != nil {
+		return "", false, err
+	}
+	newZarfYAMLDesc, err := pushBlob(ctx, store, oci.ZarfLayerMediaTypeBlob, zarfYAMLBytes)
+	if err != nil {
+		return "", false, err
+	}
+
+	// swap the rewritten blobs into the package manifest, each blob is listed once
+	signed := false
+	dropped := []ocispec.Descriptor{}
+	layers := []ocispec.Descriptor{}
+	listed := make(map[digest.Digest]bool)
+	appendLayer := func(desc ocispec.Descriptor, title string) {
+		if listed[desc.Digest] {
+			return
+		}
+		listed[desc.Digest] = true
+		layers = append(layers, ocispec.Descriptor{
+			MediaType:   oci.ZarfLayerMediaTypeBlob,
+			Digest:      desc.Digest,
+			Size:        desc.Size,
+			Annotations: map[string]string{ocispec.AnnotationTitle: title},
+		})
+	}
+	for _, layer := range pkg.manifest.Layers {
+		title := layer.Annotations[ocispec.AnnotationTitle]
+		switch {
+		case title == packageImagesIndex:
+			dropped = append(dropped, layer)
+			appendLayer(indexDesc, title)
+		case title == zarfConfig.ZarfChecksumsTxt:
+			dropped = append(dropped, layer)
+			appendLayer(newChecksumsDesc, title)
+		case title == config.ZarfYAML:
+			dropped = append(dropped, layer)
+			appendLayer(newZarfYAMLDesc, title)
+		case title == zarfConfig.ZarfYAMLSignature:
+			dropped = append(dropped, layer)
+			signed = true
+		default:
+			if to, ok := replaced[layer.Digest]; ok {
+				dropped = append(dropped, layer)
+				appendLayer(to, imageBlobTitle(to.Digest))
+				continue
+			}
+			if !listed[layer.Digest] {
+				listed[layer.Digest] = true
+				layers = append(layers, layer)
+			}
+		}
+	}
+	pkg.manifest.Layers = layers
+	manifestBytes, err := json.Marshal(pkg.manifest)
+	if err != nil {
+		return "", false, err
+	}
+	newManifestDesc, err := pushBlob(ctx, store, ocispec.MediaTypeImageManifest, manifestBytes)
+	if err != nil {
+		return "", false, err
+	}
+
+	// swap the package's blobs in the bundle tarball
+	for _, desc := range append(dropped, fetched.manifestDesc) {
+		delete(fetched.paths, filepath.Join(b.layout, utils.BlobPath(desc.Digest)))
+	}
+	for _, desc := range []ocispec.Descriptor{newManifestDesc, indexDesc, newChecksumsDesc, newZarfYAMLDesc} {
+		fetched.paths.addBlob(b.layout, desc)
+	}
+	for _, to := range replaced {
+		fetched.paths.addBlob(b.layout, to)
+	}
+	fetched.layers = nil
+	for _, layer := range layers {
+		if _, ok := fetched.paths[filepath.Join(b.layout, utils.BlobPath(layer.Digest))]; ok {
+			fetched.layers = append(fetched.layers, layer)
+		}
+	}
+
+	newDesc := fetched.manifestDesc
+	newDesc.Digest, newDesc.Size = newManifestDesc.Digest, newManifestDesc.Size
+	fetched.manifestDesc = newDesc
+	b.bundle.ZarfPackages[i].Ref = repinRef(b.bundle.ZarfPackages[i].Ref, newDesc.Digest)
+	return newDesc.Digest, signed, nil
+}
+
+// rewriteChecksums updates a package's checksums.txt for its rebased images, replaced blobs are swapped for their
+// replacements (listed once) and images/index.json gets its new checksum
+func rewriteChecksums(checksums string, replaced map[digest.Digest]ocispec.Descriptor, indexDigest digest.Digest) string {
+	listed := make(map[string]bool)
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(checksums, "\n"), "\n") {
+		sha, rel, ok := strings.Cut(line, " ")
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+		if rel == packageImagesIndex {
+			sha = indexDigest.Encoded()
+		} else if to, ok := replaced[digest.NewDigestFromEncoded(digest.SHA256, sha)]; ok && rel == imageBlobTitle(digest.NewDigestFromEncoded(digest.SHA256, sha)) {
+			sha, rel = to.Digest.Encoded(), imageBlobTitle(to.Digest)
+		}
+		if listed[rel] {
+			continue
+		}
+		listed[rel] = true
+		lines = append(lines, sha+" "+rel)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// print shows the layers --optimize-layers rebased, the packages it repinned and the signatures it invalidated
+func (o *layerOptimization) print() error {
+	if len(o.rebased) == 0 {
+		message.Info("--optimize-layers found no layers with identical content across packages, nothing was changed")
+		return nil
+	}
+	var saved int64
+	table := pterm.TableData{{"Package", "Image", "Replaced Layer", "With", "Saved"}}
+	for _, layer := range o.rebased {
+		saved += layer.From.Size
+		table = append(table, []string{layer.Package, layer.Image, layer.From.Digest.String(), layer.To.Digest.String(), zarfUtils.ByteFormat(float64(layer.From.Size), 2)})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return err
+	}
+	message.Infof("--optimize-layers rebased %d layers, saving up to %s", len(o.rebased), zarfUtils.ByteFormat(float64(saved), 2))
+	for name, dgst := range o.repinned {
+		message.Warnf("Package %s was rewritten and is now pinned to %s, update any pins of its previous digest", name, dgst)
+	}
+	for _, name := range o.unsigned {
+		message.Warnf("Package %s was signed, its zarf.yaml.sig no longer matches the rewritten package and was removed", name)
+	}
+	return nil
+}
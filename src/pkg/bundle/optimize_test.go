@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// gzipLayer compresses data at level, different levels give different blobs for the same content
+func gzipLayer(t *testing.T, data []byte, level int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_layerDiffID(t *testing.T) {
+	ctx := context.TODO()
+	store := memory.New()
+	data := bytes.Repeat([]byte("base layer "), 1024)
+	tests := []struct {
+		name        string
+		description string
+		mediaType   string
+		blob        []byte
+		wantOK      bool
+	}{
+		{
+			name:        "Uncompressed",
+			description: "uncompressed layers are their own diffID",
+			mediaType:   ocispec.MediaTypeImageLayer,
+			blob:        data,
+			wantOK:      true,
+		},
+		{
+			name:        "Gzip",
+			description: "gzipped layers have the diffID of their uncompressed content",
+			mediaType:   ocispec.MediaTypeImageLayerGzip,
+			blob:        gzipLayer(t, data, gzip.BestSpeed),
+			wantOK:      true,
+		},
+		{
+			name:        "DockerGzip",
+			description: "Docker layers compressed differently have the same diffID",
+			mediaType:   "application/vnd.docker.image.rootfs.diff.tar.gzip",
+			blob:        gzipLayer(t, data, gzip.BestCompression),
+			wantOK:      true,
+		},
+		{
+			name:        "Zstd",
+			description: "layers that aren't tarballs or gzipped tarballs are skipped",
+			mediaType:   "application/vnd.oci.image.layer.v1.tar+zstd",
+			blob:        []byte("zstd"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc, err := pushBlob(ctx, store, tt.mediaType, tt.blob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diffID, ok, err := layerDiffID(ctx, store, desc)
+			if err != nil || ok != tt.wantOK {
+				t.Fatalf("layerDiffID() = %s, %v, %v, want ok %v (%s)", diffID, ok, err, tt.wantOK, tt.description)
+			}
+			if ok && diffID != digest.FromBytes(data) {
+				t.Errorf("layerDiffID() = %s, want %s (%s)", diffID, digest.FromBytes(data), tt.description)
+			}
+		})
+	}
+}
+
+func Test_rewriteChecksums(t *testing.T) {
+	oldLayer, sharedLayer := digest.FromString("old layer"), digest.FromString("shared layer")
+	oldImage, newImage := digest.FromString("old image"), digest.FromString("new image")
+	index := digest.FromString("index")
+	replaced := map[digest.Digest]ocispec.Descriptor{
+		oldLayer: {Digest: sharedLayer},
+		oldImage: {Digest: newImage},
+	}
+	tests := []struct {
+		name        string
+		description string
+		checksums   string
+		want        string
+	}{
+		{
+			name:        "Rebased",
+			description: "replaced image blobs are swapped for their replacements and images/index.json is updated",
+			checksums: oldImage.Encoded() + " " + imageBlobTitle(oldImage) + "\n" +
+				oldLayer.Encoded() + " " + imageBlobTitle(oldLayer) + "\n" +
+				digest.FromString("old index").Encoded() + " images/index.json\n" +
+				digest.FromString("component").Encoded() + " components/app.tar\n",
+			want: newImage.Encoded() + " " + imageBlobTitle(newImage) + "\n" +
+				sharedLayer.Encoded() + " " + imageBlobTitle(sharedLayer) + "\n" +
+				index.Encoded() + " images/index.json\n" +
+				digest.FromString("component").Encoded() + " components/app.tar\n",
+		},
+		{
+			name:        "AlreadyListed",
+			description: "a replacement the package already had is listed once",
+			checksums: sharedLayer.Encoded() + " " + imageBlobTitle(sharedLayer) + "\n" +
+				oldLayer.Encoded() + " " + imageBlobTitle(oldLayer) + "\n",
+			want: sharedLayer.Encoded() + " " + imageBlobTitle(sharedLayer) + "\n",
+		},
+		{
+			name:        "NotAnImageBlob",
+			description: "files outside of images with a replaced digest are left alone",
+			checksums:   oldLayer.Encoded() + " components/app.tar\n",
+			want:        oldLayer.Encoded() + " components/app.tar\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteChecksums(tt.checksums, replaced, index); got != tt.want {
+				t.Errorf("rewriteChecksums() = %q, want %q (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_optimizeLayersDigestPinnedImage(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	push := func(mediaType string, b []byte) ocispec.Descriptor {
+		t.Helper()
+		desc, err := pushBlob(ctx, store, mediaType, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	var data bytes.Buffer
+	for i := 0; i < 2048; i++ {
+		fmt.Fprintf(&data, "base layer file %d\n", i)
+	}
+	// the same layer content compressed three ways, the smallest copy is the one the others are rebased onto
+	sharedLayer := push(ocispec.MediaTypeImageLayerGzip, gzipLayer(t, data.Bytes(), gzip.BestCompression))
+	taggedLayer := push(ocispec.MediaTypeImageLayer, data.Bytes())
+	pinnedLayer := push(ocispec.MediaTypeImageLayerGzip, gzipLayer(t, data.Bytes(), gzip.BestSpeed))
+	if pinnedLayer.Digest == sharedLayer.Digest || pinnedLayer.Size <= sharedLayer.Size {
+		t.Fatal("test layers must be different blobs with the shared layer the smallest")
+	}
+
+	pushImage := func(ref string, layer ocispec.Descriptor) ocispec.Descriptor {
+		manifest, _ := json.Marshal(ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Layers: []ocispec.Descriptor{layer}})
+		desc := push(ocispec.MediaTypeImageManifest, manifest)
+		desc.Annotations = map[string]string{ocispec.AnnotationBaseImageName: ref}
+		return desc
+	}
+	pushPackage := func(name string, images ...ocispec.Descriptor) ocispec.Descriptor {
+		layer := func(desc ocispec.Descriptor, title string) ocispec.Descriptor {
+			desc.Annotations = map[string]string{ocispec.AnnotationTitle: title}
+			return desc
+		}
+		index, _ := json.Marshal(ocispec.Index{Manifests: images})
+		indexDesc := push(oci.ZarfLayerMediaTypeBlob, index)
+		checksumsDesc := push(oci.ZarfLayerMediaTypeBlob, []byte(indexDesc.Digest.Encoded()+" "+packageImagesIndex+"\n"))
+		zarfYAML, _ := goyaml.Marshal(zarfTypes.ZarfPackage{Metadata: zarfTypes.ZarfMetadata{Name: name}})
+		layers := []ocispec.Descriptor{
+			layer(push(oci.ZarfLayerMediaTypeBlob, zarfYAML), config.ZarfYAML),
+			layer(checksumsDesc, zarfConfig.ZarfChecksumsTxt),
+			layer(indexDesc, packageImagesIndex),
+		}
+		for _, image := range images {
+			layers = append(layers, layer(image, imageBlobTitle(image.Digest)))
+		}
+		manifest, _ := json.Marshal(ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Layers: layers})
+		return push(ocispec.MediaTypeImageManifest, manifest)
+	}
+
+	pinnedRef := "ghcr.io/org/pinned@" + digest.FromString("pinned").String()
+	pinnedImage := pushImage(pinnedRef, pinnedLayer)
+	b := &Bundler{
+		bundle: types.UDSBundle{ZarfPackages: []types.BundleZarfPackage{
+			{Name: "base", Repository: "ghcr.io/org/base", Ref: "0.0.1"},
+			{Name: "app", Repository: "ghcr.io/org/app", Ref: "0.0.1"},
+		}},
+		layout: t.TempDir(),
+	}
+	fetched := []fetchedPackage{
+		{manifestDesc: pushPackage("base", pushImage("ghcr.io/org/base:1.0", sharedLayer)), paths: PathMap{}},
+		{manifestDesc: pushPackage("app", pushImage("ghcr.io/org/app:1.0", taggedLayer), pinnedImage), paths: PathMap{}},
+	}
+	opt, err := b.optimizeLayers(ctx, store, fetched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opt.rebased) != 1 || opt.rebased[0].Image != "ghcr.io/org/app:1.0" || opt.rebased[0].To.Digest != sharedLayer.Digest {
+		t.Fatalf("optimizeLayers() rebased %+v, want only the tagged app image rebased onto the shared layer", opt.rebased)
+	}
+	pkg, err := loadPackageImages(ctx, store, fetched[1].manifestDesc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, image := range pkg.images {
+		if image.desc.Annotations[ocispec.AnnotationBaseImageName] != pinnedRef {
+			continue
+		}
+		if image.desc.Digest != pinnedImage.Digest || image.manifest.Layers[0].Digest != pinnedLayer.Digest {
+			t.Errorf("optimizeLayers() rewrote the image referenced by digest to %s", image.desc.Digest)
+		}
+		return
+	}
+	t.Errorf("optimizeLayers() dropped the image referenced by digest from images/index.json")
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+)
+
+// zarfPackageList is the bundle's packages as Zarf OCI refs, written by inspect --export-zarf-packages
+type zarfPackageList struct {
+	Bundle   string                 `json:"bundle"`
+	Version  string                 `json:"version,omitempty"`
+	Packages []zarfPackageListEntry `json:"packages"`
+}
+
+// zarfPackageListEntry is a package that can be deployed on its own with zarf package deploy <source> --components <components>
+type zarfPackageListEntry struct {
+	Name       string `json:"name"`
+	Source     string `json:"source"`
+	Components string `json:"components,omitempty"`
+}
+
+// exportZarfPackages writes the bundle's packages to path as digest-pinned Zarf OCI refs in deploy order
+func (b *Bundler) exportZarfPackages(path string) error {
+	list, skipped, err := newZarfPackageList(b.bundle)
+	if err != nil {
+		return err
+	}
+	for _, name := range skipped {
+		message.Warnf("zarf pkg %s was bundled from a local path and has no OCI ref Zarf can deploy, it was left out of %s", name, path)
+	}
+	if err := utils.WriteYaml(path, list, 0644); err != nil {
+		return err
+	}
+	message.Successf("Exported %d zarf packages to %s", len(list.Packages), path)
+	return nil
+}
+
+// newZarfPackageList lists the bundle's remote packages in deploy order, returning the names of the local packages it skipped
+func newZarfPackageList(bundle types.UDSBundle) (zarfPackageList, []string, error) {
+	packages, err := sortPackagesByDependencies(bundle.ZarfPackages)
+	if err != nil {
+		return zarfPackageList{}, nil, err
+	}
+	list := zarfPackageList{Bundle: bundle.Metadata.Name, Version: bundle.Metadata.Version, Packages: []zarfPackageListEntry{}}
+	skipped := []string{}
+	for _, pkg := range packages {
+		if pkg.Repository == "" {
+			skipped = append(skipped, pkg.Name)
+			continue
+		}
+		if !strings.Contains(pkg.Ref, "@") {
+			return zarfPackageList{}, nil, fmt.Errorf("zarf pkg %s is not pinned to a digest, the bundle was not created by uds create", pkg.Name)
+		}
+		list.Packages = append(list.Packages, zarfPackageListEntry{
+			Name:       pkg.Name,
+			Source:     helpers.OCIURLPrefix + strings.TrimPrefix(pkg.Repository, helpers.OCIURLPrefix) + ":" + pkg.Ref,
+			Components: strings.Join(pkg.OptionalComponents, ","),
+		})
+	}
+	return list, skipped, nil
+}
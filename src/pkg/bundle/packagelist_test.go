@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_newZarfPackageList(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		packages    []types.BundleZarfPackage
+		want        []zarfPackageListEntry
+		wantSkipped []string
+		wantErr     bool
+	}{
+		{
+			name:        "DeployOrder",
+			description: "packages are listed in deploy order as digest-pinned oci:// refs with their optional components",
+			packages: []types.BundleZarfPackage{
+				{Name: "podinfo", Repository: "localhost:888/podinfo", Ref: "0.0.1-amd64@sha256:bbb", DependsOn: []string{"init"}},
+				{Name: "init", Repository: "oci://ghcr.io/defenseunicorns/packages/init", Ref: "v0.29.1-amd64@sha256:aaa", OptionalComponents: []string{"git-server", "logging"}},
+			},
+			want: []zarfPackageListEntry{
+				{Name: "init", Source: "oci://ghcr.io/defenseunicorns/packages/init:v0.29.1-amd64@sha256:aaa", Components: "git-server,logging"},
+				{Name: "podinfo", Source: "oci://localhost:888/podinfo:0.0.1-amd64@sha256:bbb"},
+			},
+			wantSkipped: []string{},
+		},
+		{
+			name:        "LocalPackage",
+			description: "packages bundled from a local path are skipped",
+			packages: []types.BundleZarfPackage{
+				{Name: "local", Path: "zarf-package-local-amd64-0.0.1.tar.zst", Ref: "0.0.1-amd64@sha256:ccc"},
+				{Name: "podinfo", Repository: "localhost:888/podinfo", Ref: "0.0.1-amd64@sha256:bbb"},
+			},
+			want:        []zarfPackageListEntry{{Name: "podinfo", Source: "oci://localhost:888/podinfo:0.0.1-amd64@sha256:bbb"}},
+			wantSkipped: []string{"local"},
+		},
+		{
+			name:        "NotPinned",
+			description: "a package without a digest isn't from a created bundle",
+			packages:    []types.BundleZarfPackage{{Name: "podinfo", Repository: "localhost:888/podinfo", Ref: "0.0.1"}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle := types.UDSBundle{Metadata: types.UDSMetadata{Name: "example", Version: "0.0.1"}, ZarfPackages: tt.packages}
+			list, skipped, err := newZarfPackageList(bundle)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newZarfPackageList() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(list.Packages, tt.want) {
+				t.Errorf("newZarfPackageList() packages = %+v, want %+v (%s)", list.Packages, tt.want, tt.description)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("newZarfPackageList() skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+			if list.Bundle != "example" || list.Version != "0.0.1" {
+				t.Errorf("newZarfPackageList() bundle = %s %s, want example 0.0.1", list.Bundle, list.Version)
+			}
+		})
+	}
+}
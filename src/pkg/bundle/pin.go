@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"sort"
+
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/transform"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
+	"oras.land/oras-go/v2/content"
+)
+
+// pinnedImage is a tagged image of a package that --pin-images pinned to the digest of the image packaged for it
+type pinnedImage struct {
+	Package string
+	Image   string
+	Pinned  string
+}
+
+// imagePinning records what --pin-images changed in a bundle
+type imagePinning struct {
+	pinned   []pinnedImage
+	repinned map[string]digest.Digest
+	unsigned []string
+}
+
+// pinnedImageRef returns ref pinned to dgst (<ref>@<digest>), refs that already have a digest or can't be parsed return false
+func pinnedImageRef(ref string, dgst digest.Digest) (string, bool) {
+	image, err := transform.ParseImageRef(ref)
+	if err != nil || image.Digest != "" {
+		return "", false
+	}
+	return ref + "@" + dgst.String(), true
+}
+
+// pinComponentImages lists each pinned image's ref right after its tagged ref in the components of zarfPkg, the tagged
+// refs are kept so Zarf still pushes the tags that workloads referencing them are mutated to
+func pinComponentImages(zarfPkg *zarfTypes.ZarfPackage, pinned map[string]string) {
+	for i, component := range zarfPkg.Components {
+		listed := make(map[string]bool)
+		for _, image := range component.Images {
+			listed[image] = true
+		}
+		images := []string{}
+		for _, image := range component.Images {
+			images = append(images, image)
+			if ref, ok := pinned[image]; ok && !listed[ref] {
+				listed[ref] = true
+				images = append(images, ref)
+			}
+		}
+		zarfPkg.Components[i].Images = images
+	}
+}
+
+// pinImages pins the tagged images of the fetched packages to the digests of the images packaged for them, which are
+// what deploy pushes no matter where the tags point now
+//
+// each pinned ref is added to the package's images/index.json for the same image manifest as its tag and listed in
+// zarf.yaml after it, the package's checksums.txt and package manifest are rewritten, its zarf.yaml.sig no longer
+// matches and is dropped and its ref is repinned to the new package manifest
+func (b *Bundler) pinImages(ctx context.Context, store content.Storage, fetched []fetchedPackage) (*imagePinning, error) {
+	pinning := &imagePinning{repinned: make(map[string]digest.Digest)}
+	for i := range fetched {
+		name := b.bundle.ZarfPackages[i].Name
+		pkg, err := loadPackageImages(ctx, store, fetched[i].manifestDesc)
+		if err != nil {
+			return nil, err
+		}
+
+		indexed := make(map[string]bool)
+		for _, desc := range pkg.index.Manifests {
+			indexed[desc.Annotations[ocispec.AnnotationBaseImageName]] = true
+		}
+		pinned := make(map[string]string)
+		for _, desc := range pkg.index.Manifests {
+			ref := desc.Annotations[ocispec.AnnotationBaseImageName]
+			pinnedRef, ok := pinnedImageRef(ref, desc.Digest)
+			if !ok || indexed[pinnedRef] {
+				continue
+			}
+			indexed[pinnedRef] = true
+			pinned[ref] = pinnedRef
+			pinning.pinned = append(pinning.pinned, pinnedImage{Package: name, Image: ref, Pinned: pinnedRef})
+
+			entry := desc
+			entry.Annotations = make(map[string]string, len(desc.Annotations))
+			for k, v := range desc.Annotations {
+				entry.Annotations[k] = v
+			}
+			entry.Annotations[ocispec.AnnotationBaseImageName] = pinnedRef
+			pkg.index.Manifests = append(pkg.index.Manifests, entry)
+		}
+		if len(pinned) == 0 {
+			continue
+		}
+
+		newDigest, signed, err := b.rewritePackage(ctx, store, &fetched[i], i, pkg, nil, func(zarfPkg *zarfTypes.ZarfPackage) {
+			pinComponentImages(zarfPkg, pinned)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if signed {
+			pinning.unsigned = append(pinning.unsigned, name)
+		}
+		pinning.repinned[name] = newDigest
+	}
+	return pinning, nil
+}
+
+// print shows the images --pin-images pinned, the packages it repinned and the signatures it invalidated
+func (p *imagePinning) print() error {
+	if len(p.pinned) == 0 {
+		message.Info("--pin-images found no tagged images in the bundle's packages, nothing was changed")
+		return nil
+	}
+	table := pterm.TableData{{"Package", "Image", "Pinned To"}}
+	for _, image := range p.pinned {
+		table = append(table, []string{image.Package, image.Image, image.Pinned})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return err
+	}
+	message.Infof("--pin-images pinned %d images", len(p.pinned))
+	names := []string{}
+	for name := range p.repinned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		message.Warnf("Package %s was rewritten and is now pinned to %s, update any pins of its previous digest", name, p.repinned[name])
+	}
+	for _, name := range p.unsigned {
+		message.Warnf("Package %s was signed, its zarf.yaml.sig no longer matches the rewritten package and was removed", name)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func Test_pinnedImageRef(t *testing.T) {
+	dgst := digest.FromString("image")
+	tests := []struct {
+		name        string
+		description string
+		ref         string
+		want        string
+		wantOK      bool
+	}{
+		{
+			name:        "Tagged",
+			description: "tagged refs are pinned to the digest",
+			ref:         "ghcr.io/stefanprodan/podinfo:6.4.0",
+			want:        "ghcr.io/stefanprodan/podinfo:6.4.0@" + dgst.String(),
+			wantOK:      true,
+		},
+		{
+			name:        "Untagged",
+			description: "refs without a tag are pinned as written",
+			ref:         "nginx",
+			want:        "nginx@" + dgst.String(),
+			wantOK:      true,
+		},
+		{
+			name:        "Digested",
+			description: "refs that already have a digest are left alone",
+			ref:         "nginx:1.25@" + digest.FromString("other").String(),
+		},
+		{
+			name:        "Invalid",
+			description: "refs that can't be parsed are left alone",
+			ref:         "Not A Ref",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pinnedImageRef(tt.ref, dgst)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("pinnedImageRef() = %q, %v, want %q, %v (%s)", got, ok, tt.want, tt.wantOK, tt.description)
+			}
+		})
+	}
+}
+
+func Test_pinComponentImages(t *testing.T) {
+	pinned := map[string]string{"nginx:1.25": "nginx:1.25@sha256:abc"}
+	tests := []struct {
+		name        string
+		description string
+		images      []string
+		want        []string
+	}{
+		{
+			name:        "Pinned",
+			description: "pinned refs are listed right after their tags, which are kept",
+			images:      []string{"nginx:1.25", "busybox:1.36"},
+			want:        []string{"nginx:1.25", "nginx:1.25@sha256:abc", "busybox:1.36"},
+		},
+		{
+			name:        "AlreadyListed",
+			description: "pinned refs the component already lists aren't listed twice",
+			images:      []string{"nginx:1.25", "nginx:1.25@sha256:abc"},
+			want:        []string{"nginx:1.25", "nginx:1.25@sha256:abc"},
+		},
+		{
+			name:        "NoImages",
+			description: "components without the pinned images are unchanged",
+			images:      []string{},
+			want:        []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zarfPkg := zarfTypes.ZarfPackage{Components: []zarfTypes.ZarfComponent{{Name: "component", Images: tt.images}}}
+			pinComponentImages(&zarfPkg, pinned)
+			if got := zarfPkg.Components[0].Images; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pinComponentImages() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_pinImages(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	push := func(mediaType string, b []byte) ocispec.Descriptor {
+		t.Helper()
+		desc, err := pushBlob(ctx, store, mediaType, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	layer := func(desc ocispec.Descriptor, title string) ocispec.Descriptor {
+		desc.Annotations = map[string]string{ocispec.AnnotationTitle: title}
+		return desc
+	}
+
+	imageManifest, _ := json.Marshal(ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest})
+	imageDesc := push(ocispec.MediaTypeImageManifest, imageManifest)
+	imageDesc.Annotations = map[string]string{ocispec.AnnotationBaseImageName: "nginx:1.25"}
+	index, _ := json.Marshal(ocispec.Index{Manifests: []ocispec.Descriptor{imageDesc}})
+	indexDesc := push(oci.ZarfLayerMediaTypeBlob, index)
+	checksumsDesc := push(oci.ZarfLayerMediaTypeBlob, []byte(indexDesc.Digest.Encoded()+" "+packageImagesIndex+"\n"))
+	zarfYAML, _ := goyaml.Marshal(zarfTypes.ZarfPackage{
+		Metadata:   zarfTypes.ZarfMetadata{Name: "nginx"},
+		Components: []zarfTypes.ZarfComponent{{Name: "nginx", Images: []string{"nginx:1.25"}}},
+	})
+	zarfYAMLDesc := push(oci.ZarfLayerMediaTypeBlob, zarfYAML)
+	sigDesc := push(oci.ZarfLayerMediaTypeBlob, []byte("signature"))
+	manifest, _ := json.Marshal(ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Layers: []ocispec.Descriptor{
+		layer(zarfYAMLDesc, config.ZarfYAML),
+		layer(sigDesc, zarfConfig.ZarfYAMLSignature),
+		layer(checksumsDesc, zarfConfig.ZarfChecksumsTxt),
+		layer(indexDesc, packageImagesIndex),
+		layer(imageDesc, imageBlobTitle(imageDesc.Digest)),
+	}})
+	manifestDesc := push(ocispec.MediaTypeImageManifest, manifest)
+
+	b := &Bundler{
+		bundle: types.UDSBundle{ZarfPackages: []types.BundleZarfPackage{{Name: "nginx", Repository: "ghcr.io/org/nginx", Ref: "0.0.1"}}},
+		layout: t.TempDir(),
+	}
+	fetched := []fetchedPackage{{manifestDesc: manifestDesc, paths: PathMap{}}}
+	pinning, err := b.pinImages(ctx, store, fetched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinnedRef := "nginx:1.25@" + imageDesc.Digest.String()
+	if want := []pinnedImage{{Package: "nginx", Image: "nginx:1.25", Pinned: pinnedRef}}; !reflect.DeepEqual(pinning.pinned, want) {
+		t.Errorf("pinImages() pinned %v, want %v", pinning.pinned, want)
+	}
+	if !reflect.DeepEqual(pinning.unsigned, []string{"nginx"}) {
+		t.Errorf("pinImages() unsigned = %v, want the signed package", pinning.unsigned)
+	}
+	if got := b.bundle.ZarfPackages[0].Ref; got != "0.0.1@"+fetched[0].manifestDesc.Digest.String() {
+		t.Errorf("pinImages() ref = %s, want it repinned to the new package manifest %s", got, fetched[0].manifestDesc.Digest)
+	}
+
+	pkg, err := loadPackageImages(ctx, store, fetched[0].manifestDesc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := map[string]digest.Digest{}
+	for _, desc := range pkg.index.Manifests {
+		refs[desc.Annotations[ocispec.AnnotationBaseImageName]] = desc.Digest
+	}
+	if want := map[string]digest.Digest{"nginx:1.25": imageDesc.Digest, pinnedRef: imageDesc.Digest}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("pinImages() images/index.json refs = %v, want %v", refs, want)
+	}
+	if !oci.IsEmptyDescriptor(pkg.manifest.Locate(zarfConfig.ZarfYAMLSignature)) {
+		t.Errorf("pinImages() kept the package's zarf.yaml.sig")
+	}
+
+	zarfYAML, err = content.FetchAll(ctx, store, pkg.manifest.Locate(config.ZarfYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zarfPkg zarfTypes.ZarfPackage
+	if err := goyaml.Unmarshal(zarfYAML, &zarfPkg); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nginx:1.25", pinnedRef}; !reflect.DeepEqual(zarfPkg.Components[0].Images, want) {
+		t.Errorf("pinImages() zarf.yaml images = %v, want %v", zarfPkg.Components[0].Images, want)
+	}
+	checksumsDesc = pkg.manifest.Locate(zarfConfig.ZarfChecksumsTxt)
+	if zarfPkg.Metadata.AggregateChecksum != checksumsDesc.Digest.Encoded() {
+		t.Errorf("pinImages() aggregate checksum = %s, want the new checksums.txt's %s", zarfPkg.Metadata.AggregateChecksum, checksumsDesc.Digest.Encoded())
+	}
+
+	// pinning again finds nothing left to pin
+	pinning, err = b.pinImages(ctx, store, fetched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pinning.pinned) != 0 || len(pinning.repinned) != 0 {
+		t.Errorf("pinImages() pinned %v again", pinning.pinned)
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+)
+
+// checkPins verifies the bundle's package digests against --pin-file, pinning packages seen for the first time
+//
+// the returned savePins writes the new pins, it's only called once the deploy is confirmed so a declined deploy
+// leaves the pin file as is
+func (b *Bundler) checkPins() (savePins func() error, err error) {
+	path := b.cfg.DeployOpts.PinFile
+	pins := types.UDSPins{}
+	if _, err := os.Stat(path); err == nil {
+		if err := utils.ReadYaml(path, &pins); err != nil {
+			return nil, fmt.Errorf("unable to read pin file %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	changed, err := verifyPins(&pins, &b.bundle, b.cfg.DeployOpts.UpdatePins)
+	if err != nil {
+		return nil, fmt.Errorf("%w, use --update-pins to trust the new digests", err)
+	}
+	if !changed {
+		message.Debugf("All packages in %s match %s", b.bundle.Metadata.Name, path)
+		return func() error { return nil }, nil
+	}
+
+	return func() error {
+		if err := utils.CreateDirectory(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		if err := utils.WriteYaml(path, pins, 0600); err != nil {
+			return fmt.Errorf("unable to write pin file %s: %w", path, err)
+		}
+		message.Successf("Pinned the package digests of %s in %s", b.bundle.Metadata.Name, path)
+		return nil
+	}, nil
+}
+
+// verifyPins compares the bundle's package digests with its pins and returns whether the pins were changed
+//
+// packages without a pin are trusted on first use, a pinned package whose digest changed is an error unless
+// updating, in which case the bundle's pins are replaced with its current digests
+func verifyPins(pins *types.UDSPins, bundle *types.UDSBundle, update bool) (bool, error) {
+	if pins.Bundles == nil {
+		pins.Bundles = make(map[string]map[string]string)
+	}
+	current := make(map[string]string)
+	for _, pkg := range bundle.ZarfPackages {
+		_, digest, ok := strings.Cut(pkg.Ref, "@")
+		if !ok {
+			return false, fmt.Errorf("zarf pkg %s ref %q is not pinned to a digest", pkg.Name, pkg.Ref)
+		}
+		current[pkg.Name] = digest
+	}
+
+	pinned, ok := pins.Bundles[bundle.Metadata.Name]
+	if !ok || update {
+		changed := !ok || !mapsEqual(pinned, current)
+		pins.Bundles[bundle.Metadata.Name] = current
+		return changed, nil
+	}
+
+	var mismatches []string
+	changed := false
+	for name, digest := range current {
+		pin, ok := pinned[name]
+		if !ok {
+			message.Debugf("Pinning new zarf pkg %s to %s", name, digest)
+			pinned[name] = digest
+			changed = true
+			continue
+		}
+		if pin != digest {
+			mismatches = append(mismatches, fmt.Sprintf("%s (pinned %s, got %s)", name, pin, digest))
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return false, fmt.Errorf("package digests of %s do not match their pins: %s", bundle.Metadata.Name, strings.Join(mismatches, ", "))
+	}
+	return changed, nil
+}
+
+// mapsEqual returns true if a and b have the same keys and values
+func mapsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
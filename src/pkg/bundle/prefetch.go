@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/k8s"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/transform"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/google/go-containerregistry/pkg/crane"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	// zarfNamespace is the namespace Zarf keeps its state and registry in
+	zarfNamespace = "zarf"
+	// zarfStateSecret is the secret in zarfNamespace that holds Zarf's state under zarfStateKey
+	zarfStateSecret = "zarf-state"
+	zarfStateKey    = "state"
+	// zarfRegistryService is the service of the registry Zarf runs in the cluster, it serves on zarfRegistryPort
+	zarfRegistryService = "zarf-docker-registry"
+	zarfRegistryPort    = 5000
+)
+
+// bundledImages returns the images of a package's bundled components, which are the images in the bundle's copy of it
+func bundledImages(pkg types.BundleZarfPackage, zarfPkg zarfTypes.ZarfPackage) []string {
+	images := []string{}
+	for _, component := range bundledComponents(pkg, zarfPkg) {
+		images = append(images, component.Images...)
+	}
+	return helpers.Unique(images)
+}
+
+// prefetchPackages returns the packages of the rest of a deploy whose images are prefetched, which are the packages that
+// will be applied except the Zarf init package
+func prefetchPackages(packages []types.BundleZarfPackage, unchanged map[string]types.UDSDeployedPackage) []types.BundleZarfPackage {
+	prefetch := []types.BundleZarfPackage{}
+	for _, pkg := range packages {
+		if _, ok := unchanged[pkg.Name]; ok || pkg.Name == zarfInitPackageName {
+			continue
+		}
+		prefetch = append(prefetch, pkg)
+	}
+	return prefetch
+}
+
+// zarfRegistryInfo reads the registry packages' images are pushed to out of Zarf's state in the cluster
+func zarfRegistryInfo(clientset kubernetes.Interface) (zarfTypes.RegistryInfo, error) {
+	secret, err := clientset.CoreV1().Secrets(zarfNamespace).Get(context.TODO(), zarfStateSecret, metav1.GetOptions{})
+	if err != nil {
+		return zarfTypes.RegistryInfo{}, fmt.Errorf("unable to read the Zarf state, is the cluster initialized with Zarf? %w", err)
+	}
+	var state zarfTypes.ZarfState
+	if err := json.Unmarshal(secret.Data[zarfStateKey], &state); err != nil {
+		return zarfTypes.RegistryInfo{}, fmt.Errorf("unable to read the Zarf state: %w", err)
+	}
+	if state.RegistryInfo.Address == "" {
+		return zarfTypes.RegistryInfo{}, fmt.Errorf("the Zarf state has no registry")
+	}
+	return state.RegistryInfo, nil
+}
+
+// openRegistryTunnel port-forwards a local port to a pod of Zarf's in-cluster registry like Zarf's own registry
+// tunnel, returning the registry's local address and a func that closes the tunnel
+func openRegistryTunnel(cluster *k8s.K8s) (string, func(), error) {
+	svc, err := cluster.Clientset.CoreV1().Services(zarfNamespace).Get(context.TODO(), zarfRegistryService, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to find the Zarf registry: %w", err)
+	}
+	pods, err := cluster.Clientset.CoreV1().Pods(zarfNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+		FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to find a pod of the Zarf registry: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", nil, fmt.Errorf("the Zarf registry has no running pods")
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(cluster.RestConfig)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create the spdy client: %w", err)
+	}
+	url := cluster.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(zarfNamespace).Name(pods.Items[0].Name).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	// a local port of 0 lets the forwarder pick a free one
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", zarfRegistryPort)},
+		stop, ready, &message.DebugWriter{}, &message.DebugWriter{})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create the port forward to the Zarf registry: %w", err)
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- forwarder.ForwardPorts()
+	}()
+	select {
+	case err := <-errChan:
+		return "", nil, fmt.Errorf("unable to open a tunnel to the Zarf registry: %w", err)
+	case <-ready:
+	}
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		close(stop)
+		return "", nil, err
+	}
+	return fmt.Sprintf("127.0.0.1:%d", ports[0].Local), func() { close(stop) }, nil
+}
+
+// prefetchImages pushes the images of packages into the Zarf registry before any of them is applied, so a slow or
+// failing image push can't leave the bundle half deployed
+//
+// pushes are tagged like Zarf's so the agent finds them, Zarf pushes each package's images again as it deploys it,
+// which is quick since every blob is already in the registry
+func (b *Bundler) prefetchImages(provider Provider, packages []types.BundleZarfPackage) error {
+	cluster, err := k8s.New(message.Debugf, nil)
+	if err != nil {
+		return fmt.Errorf("unable to connect to the cluster: %w", err)
+	}
+	regInfo, err := zarfRegistryInfo(cluster.Clientset)
+	if err != nil {
+		return err
+	}
+	registryURL := regInfo.Address
+	if regInfo.InternalRegistry {
+		var closeTunnel func()
+		if registryURL, closeTunnel, err = openRegistryTunnel(cluster); err != nil {
+			return err
+		}
+		defer closeTunnel()
+	}
+
+	pushed := 0
+	for _, pkg := range packages {
+		spinner := message.NewProgressSpinner("Prefetching the images of %s", pkg.Name)
+		count, err := b.prefetchPackageImages(provider, pkg, registryURL, regInfo)
+		spinner.Stop()
+		if err != nil {
+			return fmt.Errorf("unable to prefetch the images of zarf pkg %s: %w", pkg.Name, err)
+		}
+		pushed += count
+	}
+	message.Successf("Prefetched %d images of %d packages into the Zarf registry", pushed, len(packages))
+	return nil
+}
+
+// prefetchPackageImages loads pkg from provider and pushes its images to the registry at registryURL, returning how
+// many it pushed
+func (b *Bundler) prefetchPackageImages(provider Provider, pkg types.BundleZarfPackage, registryURL string, regInfo zarfTypes.RegistryInfo) (int, error) {
+	pin, err := pinnedDigest(pkg)
+	if err != nil {
+		return 0, err
+	}
+	sha := pin.Encoded()
+	pkgTmp, err := zarfUtils.MakeTempDir()
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(pkgTmp)
+	if _, err := provider.LoadPackage(sha, pkgTmp, config.CommonOptions.OCIConcurrency); err != nil {
+		return 0, err
+	}
+	var zarfPkg zarfTypes.ZarfPackage
+	if err := zarfUtils.ReadYaml(filepath.Join(pkgTmp, config.ZarfYAML), &zarfPkg); err != nil {
+		return 0, err
+	}
+
+	images := bundledImages(pkg, zarfPkg)
+	opts := zarfConfig.GetCraneOptions(zarfConfig.CommonOptions.Insecure, zarfPkg.Metadata.Architecture, zarfPkg.Build.Architecture)
+	opts = append(opts, zarfConfig.GetCraneAuthOption(regInfo.PushUsername, regInfo.PushPassword))
+	for _, image := range images {
+		img, err := zarfUtils.LoadOCIImage(filepath.Join(pkgTmp, "images"), image)
+		if err != nil {
+			return 0, err
+		}
+		// the checksummed tag is what the Zarf agent rewrites workloads to, the plain tag is for everything else
+		withChecksum, err := transform.ImageTransformHost(registryURL, image)
+		if err != nil {
+			return 0, err
+		}
+		withoutChecksum, err := transform.ImageTransformHostWithoutChecksum(registryURL, image)
+		if err != nil {
+			return 0, err
+		}
+		for _, dst := range []string{withChecksum, withoutChecksum} {
+			message.Debugf("Prefetching %s of %s to %s", image, pkg.Name, dst)
+			if err := crane.Push(img, dst, opts...); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(images), nil
+}
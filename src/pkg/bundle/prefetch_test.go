@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_prefetchPackages(t *testing.T) {
+	packages := []types.BundleZarfPackage{{Name: zarfInitPackageName}, {Name: "podinfo"}, {Name: "nginx"}, {Name: "redis"}}
+	tests := []struct {
+		name        string
+		description string
+		packages    []types.BundleZarfPackage
+		unchanged   map[string]types.UDSDeployedPackage
+		want        []string
+	}{
+		{
+			name:        "All",
+			description: "every package is prefetched",
+			packages:    packages[1:],
+			want:        []string{"podinfo", "nginx", "redis"},
+		},
+		{
+			name:        "Init",
+			description: "the init package isn't prefetched, it creates the registry",
+			packages:    packages,
+			want:        []string{"podinfo", "nginx", "redis"},
+		},
+		{
+			name:        "Unchanged",
+			description: "packages --only-changed skips aren't prefetched",
+			packages:    packages[1:],
+			unchanged:   map[string]types.UDSDeployedPackage{"nginx": {Name: "nginx"}},
+			want:        []string{"podinfo", "redis"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, pkg := range prefetchPackages(tt.packages, tt.unchanged) {
+				got = append(got, pkg.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prefetchPackages() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_bundledImages(t *testing.T) {
+	zarfPkg := zarfTypes.ZarfPackage{Components: []zarfTypes.ZarfComponent{
+		{Name: "first", Required: true, Images: []string{"nginx:1.25", "busybox:1.36"}},
+		{Name: "second", Required: true, Images: []string{"busybox:1.36"}},
+		{Name: "extra", Images: []string{"redis:7"}},
+	}}
+	got := bundledImages(types.BundleZarfPackage{Name: "pkg"}, zarfPkg)
+	if want := []string{"nginx:1.25", "busybox:1.36"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bundledImages() = %v, want %v", got, want)
+	}
+}
+
+func Test_zarfRegistryInfo(t *testing.T) {
+	stateSecret := func(state zarfTypes.ZarfState) *corev1.Secret {
+		data, _ := json.Marshal(state)
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: zarfStateSecret, Namespace: zarfNamespace},
+			Data:       map[string][]byte{zarfStateKey: data},
+		}
+	}
+	registry := zarfTypes.RegistryInfo{Address: "127.0.0.1:31999", PushUsername: "zarf-push", PushPassword: "secret", InternalRegistry: true}
+	tests := []struct {
+		name        string
+		description string
+		secrets     []*corev1.Secret
+		want        zarfTypes.RegistryInfo
+		wantErr     bool
+	}{
+		{
+			name:        "Initialized",
+			description: "the registry is read from the Zarf state",
+			secrets:     []*corev1.Secret{stateSecret(zarfTypes.ZarfState{RegistryInfo: registry})},
+			want:        registry,
+		},
+		{
+			name:        "NotInitialized",
+			description: "clusters without a Zarf state can't be prefetched into",
+			wantErr:     true,
+		},
+		{
+			name:        "NoRegistry",
+			description: "a Zarf state without a registry can't be prefetched into",
+			secrets:     []*corev1.Secret{stateSecret(zarfTypes.ZarfState{})},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			for _, secret := range tt.secrets {
+				if err := clientset.Tracker().Add(secret); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := zarfRegistryInfo(clientset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("zarfRegistryInfo() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("zarfRegistryInfo() = %+v, want %+v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
@@ -7,11 +7,16 @@ package bundle
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
+	"github.com/corang/uds-cli/src/config"
 	"github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
 	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // Provider is an interface for processing bundles
@@ -29,6 +34,15 @@ type Provider interface {
 	// : : pulls the metadata from the OCI ref
 	LoadBundleMetadata() (PathMap, error)
 
+	// SignatureAlgorithm returns the algorithm recorded on the bundle's signature layer, empty if none was recorded
+	SignatureAlgorithm() (string, error)
+
+	// Annotations returns the annotations of the bundle's root manifest
+	Annotations() (map[string]string, error)
+
+	// PackageTags returns the tags the bundle's packages were resolved from, keyed by package manifest digest
+	PackageTags() (map[digest.Digest]string, error)
+
 	// LoadPackage loads a package with a given `sha` from the bundle into the `destinationDir`
 	//
 	// : if tarball
@@ -38,6 +52,12 @@ type Provider interface {
 	// : : pulls the package from the OCI ref
 	LoadPackage(sha, destinationDir string, concurrency int) (PathMap, error)
 
+	// LoadPackageManifest reads the manifest of the package with a given `sha` without loading the package's layers
+	LoadPackageManifest(sha string) (*oci.ZarfOCIManifest, error)
+
+	// FetchPackageLayers reads the given layers of the package with a given `sha` into memory, keyed by digest
+	FetchPackageLayers(sha string, descs ...ocispec.Descriptor) (map[digest.Digest][]byte, error)
+
 	// LoadBundle loads a bundle into the temporary directory and returns a map of the bundle's files
 	//
 	// (currently only the remote provider utilizes the concurrency parameter)
@@ -46,7 +66,18 @@ type Provider interface {
 	// CreateBundleSBOM creates a bundle-level SBOM from the underlying Zarf packages, if the Zarf package contains an SBOM
 	CreateBundleSBOM(extractSBOM bool) error
 
-	PublishBundle(bundle types.UDSBundle, remote *oci.OrasRemote) error
+	// PublishBundle publishes a bundle to remote, its manifest config is pushed with configMediaType and a non-empty
+	// artifactType replaces the manifest's
+	PublishBundle(bundle types.UDSBundle, remote *oci.OrasRemote, configMediaType, artifactType string) error
+
+	// ListAttachments returns the names of the files attached to the bundle with --attach
+	ListAttachments() ([]string, error)
+
+	// ExtractAttachment writes the named attached file to dstDir
+	ExtractAttachment(name, dstDir string) error
+
+	// VerifyLayers reads every layer of the bundle and checks it against its digest and size, returning how many layers it checked
+	VerifyLayers() (int, error)
 
 	getBundleManifest() error
 }
@@ -54,11 +85,17 @@ type Provider interface {
 // PathMap is a map of either absolute paths to relative paths or relative paths to absolute paths
 type PathMap map[string]string
 
+// addBlob maps the absolute path of a blob in the OCI layout at root to its path relative to root
+func (pm PathMap) addBlob(root string, desc ocispec.Descriptor) {
+	blobPath := utils.BlobPath(desc.Digest)
+	pm[filepath.Join(root, blobPath)] = blobPath
+}
+
 // NewBundleProvider returns a new bundler Provider based on the source type
 func NewBundleProvider(ctx context.Context, source, destination string) (Provider, error) {
 	if helpers.IsOCIURL(source) {
 		provider := ociProvider{ctx: ctx, src: source, dst: destination}
-		remote, err := oci.NewOrasRemote(source)
+		remote, err := utils.NewOrasRemote(source)
 		if err != nil {
 			return nil, err
 		}
@@ -70,3 +107,23 @@ func NewBundleProvider(ctx context.Context, source, destination string) (Provide
 	}
 	return &tarballBundleProvider{ctx: ctx, src: source, dst: destination}, nil
 }
+
+// loadPackageConfig reads the zarf.yaml of the package with a given sha in the bundle
+func loadPackageConfig(provider Provider, sha string) (zarfTypes.ZarfPackage, error) {
+	manifest, err := provider.LoadPackageManifest(sha)
+	if err != nil {
+		return zarfTypes.ZarfPackage{}, err
+	}
+	return oci.FetchYAMLFile[zarfTypes.ZarfPackage](packageLayerFetcher(provider, sha), manifest, config.ZarfYAML)
+}
+
+// packageLayerFetcher adapts FetchPackageLayers to the single-layer fetchers used by Zarf's oci.Fetch* helpers
+func packageLayerFetcher(provider Provider, sha string) func(desc ocispec.Descriptor) ([]byte, error) {
+	return func(desc ocispec.Descriptor) ([]byte, error) {
+		layers, err := provider.FetchPackageLayers(sha, desc)
+		if err != nil {
+			return nil, err
+		}
+		return layers[desc.Digest], nil
+	}
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
+	"oras.land/oras-go/v2"
+)
+
+// prunableBundle is a bundle root manifest in a repository along with every tag pointing at it
+type prunableBundle struct {
+	desc    ocispec.Descriptor
+	tags    []string
+	created time.Time
+}
+
+// Prune deletes the bundles in a repository outside of --keep-last and older than --older-than
+func (b *Bundler) Prune() error {
+	opts := b.cfg.PruneOpts
+	if opts.KeepLast < 0 {
+		return fmt.Errorf("--keep-last must be 0 or more, got %d", opts.KeepLast)
+	}
+	var olderThan time.Duration
+	if opts.OlderThan != "" {
+		var err error
+		if olderThan, err = parseAge(opts.OlderThan); err != nil {
+			return err
+		}
+	}
+	if opts.KeepLast == 0 && olderThan == 0 {
+		return fmt.Errorf("prune needs --keep-last, --older-than or both to know which bundles to delete")
+	}
+
+	remote, err := utils.NewOrasRemote(opts.Source)
+	if err != nil {
+		return err
+	}
+	repo := remote.Repo()
+	ctx := context.TODO()
+
+	spinner := message.NewProgressSpinner("Listing bundles in %s/%s", repo.Reference.Registry, repo.Reference.Repository)
+	defer spinner.Stop()
+	tags := []string{}
+	if err := repo.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("unable to list the tags of %s: %w", opts.Source, err)
+	}
+	bundles, err := loadPrunableBundles(ctx, repo, tags)
+	if err != nil {
+		return err
+	}
+	spinner.Successf("Found %d bundles in %d tags", len(bundles), len(tags))
+
+	pruned := selectPrunable(bundles, opts.KeepLast, olderThan, time.Now())
+	if len(pruned) == 0 {
+		message.Info("No bundles to prune")
+		return nil
+	}
+	table := pterm.TableData{{"Tags", "Digest", "Created"}}
+	for _, bundle := range pruned {
+		created := "unknown"
+		if !bundle.created.IsZero() {
+			created = bundle.created.Format(time.RFC3339)
+		}
+		table = append(table, []string{strings.Join(bundle.tags, ", "), bundle.desc.Digest.String(), created})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		message.Infof("--dry-run: %d bundles would be deleted", len(pruned))
+		return nil
+	}
+
+	for _, bundle := range pruned {
+		// deleting the root manifest untags it everywhere, its packages and layers are left for the registry's garbage collection
+		if err := repo.Delete(ctx, bundle.desc); err != nil {
+			return fmt.Errorf("unable to delete %s (%s): %w", strings.Join(bundle.tags, ", "), bundle.desc.Digest, err)
+		}
+		message.Successf("Deleted %s (%s)", strings.Join(bundle.tags, ", "), bundle.desc.Digest)
+	}
+	return nil
+}
+
+// parseAge parses --older-than, which is a Go duration or a whole number of days (ie. 30d)
+func parseAge(age string) (time.Duration, error) {
+	if strings.HasSuffix(age, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(age); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid --older-than %q: must be a positive duration (ie. 30d, 12h)", age)
+}
+
+// loadPrunableBundles resolves tags in target and returns the bundle root manifests among them, tags of the same
+// manifest are grouped together since deleting the manifest deletes all of them
+func loadPrunableBundles(ctx context.Context, target oras.ReadOnlyTarget, tags []string) ([]prunableBundle, error) {
+	bundles := []prunableBundle{}
+	found := make(map[string]int)
+	for _, tag := range tags {
+		desc, err := target.Resolve(ctx, tag)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %s: %w", tag, err)
+		}
+		if i, ok := found[desc.Digest.String()]; ok {
+			bundles[i].tags = append(bundles[i].tags, tag)
+			continue
+		}
+		if desc.MediaType != ocispec.MediaTypeImageManifest {
+			continue
+		}
+		var manifest oci.ZarfOCIManifest
+		if err := fetchJSON(ctx, target, desc, &manifest); err != nil {
+			return nil, err
+		}
+		// bundles have their own config media type, older bundles are only recognizable by their uds-bundle.yaml
+		if manifest.Config.MediaType != config.BundleConfigMediaType && oci.IsEmptyDescriptor(manifest.Locate(config.BundleYAML)) {
+			continue
+		}
+		bundle := prunableBundle{desc: desc, tags: []string{tag}}
+		if created, err := time.Parse(time.RFC3339, manifest.Annotations[ocispec.AnnotationCreated]); err == nil {
+			bundle.created = created
+		}
+		found[desc.Digest.String()] = len(bundles)
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+// selectPrunable returns the bundles to delete, newest first: those outside the keepLast most recently created and
+// created more than olderThan before now, a zero keepLast or olderThan doesn't limit the selection
+//
+// bundles without a created annotation can't be dated, they're never deleted by olderThan and count as the oldest for keepLast
+func selectPrunable(bundles []prunableBundle, keepLast int, olderThan time.Duration, now time.Time) []prunableBundle {
+	sorted := append([]prunableBundle{}, bundles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].created.After(sorted[j].created)
+	})
+	pruned := []prunableBundle{}
+	for i, bundle := range sorted {
+		if i < keepLast {
+			continue
+		}
+		if olderThan > 0 && (bundle.created.IsZero() || now.Sub(bundle.created) <= olderThan) {
+			continue
+		}
+		pruned = append(pruned, bundle)
+	}
+	return pruned
+}
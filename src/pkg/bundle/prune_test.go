@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func Test_parseAge(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		age         string
+		want        time.Duration
+		wantErr     bool
+	}{
+		{
+			name:        "Days",
+			description: "a whole number of days",
+			age:         "30d",
+			want:        30 * 24 * time.Hour,
+		},
+		{
+			name:        "Duration",
+			description: "Go durations are accepted as-is",
+			age:         "12h30m",
+			want:        12*time.Hour + 30*time.Minute,
+		},
+		{
+			name:        "FractionalDays",
+			description: "days must be whole",
+			age:         "1.5d",
+			wantErr:     true,
+		},
+		{
+			name:        "Negative",
+			description: "ages must be positive",
+			age:         "-1h",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAge(tt.age)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseAge() = %v, %v, want %v, wantErr %v (%s)", got, err, tt.want, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_selectPrunable(t *testing.T) {
+	now := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	day := func(d int) prunableBundle {
+		return prunableBundle{tags: []string{now.AddDate(0, 0, -d).Format("2006-01-02")}, created: now.AddDate(0, 0, -d)}
+	}
+	undated := prunableBundle{tags: []string{"undated"}}
+	bundles := []prunableBundle{day(40), day(1), undated, day(10), day(20)}
+	tests := []struct {
+		name        string
+		description string
+		keepLast    int
+		olderThan   time.Duration
+		want        []prunableBundle
+	}{
+		{
+			name:        "KeepLast",
+			description: "everything but the most recent bundles is deleted, undated bundles count as the oldest",
+			keepLast:    2,
+			want:        []prunableBundle{day(20), day(40), undated},
+		},
+		{
+			name:        "OlderThan",
+			description: "only bundles older than the age are deleted, undated bundles are kept",
+			olderThan:   15 * 24 * time.Hour,
+			want:        []prunableBundle{day(20), day(40)},
+		},
+		{
+			name:        "Both",
+			description: "bundles must be outside the most recent and older than the age",
+			keepLast:    3,
+			olderThan:   15 * 24 * time.Hour,
+			want:        []prunableBundle{day(40)},
+		},
+		{
+			name:        "KeepAll",
+			description: "keeping more bundles than the repository has deletes nothing",
+			keepLast:    10,
+			want:        []prunableBundle{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectPrunable(bundles, tt.keepLast, tt.olderThan, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectPrunable() = %+v, want %+v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_loadPrunableBundles(t *testing.T) {
+	ctx := context.TODO()
+	store := memory.New()
+	push := func(configMediaType, created string, tags ...string) ocispec.Descriptor {
+		configDesc, err := pushBlob(ctx, store, configMediaType, []byte("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest := ocispec.Manifest{
+			Versioned:   specs.Versioned{SchemaVersion: 2},
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Config:      configDesc,
+			Layers:      []ocispec.Descriptor{},
+			Annotations: map[string]string{ocispec.AnnotationCreated: created},
+		}
+		b, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc, err := pushBlob(ctx, store, ocispec.MediaTypeImageManifest, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tag := range tags {
+			if err := store.Tag(ctx, desc, tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return desc
+	}
+	bundle := push(config.BundleConfigMediaType, "2024-01-02T03:04:05Z", "0.1.0", "latest")
+	push(ocispec.MediaTypeImageConfig, "2024-01-02T03:04:05Z", "image")
+
+	got, err := loadPrunableBundles(ctx, store, []string{"0.1.0", "image", "latest"})
+	if err != nil {
+		t.Fatalf("loadPrunableBundles() error = %v", err)
+	}
+	want := []prunableBundle{{desc: bundle, tags: []string{"0.1.0", "latest"}, created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadPrunableBundles() = %+v, want %+v, the image isn't a bundle and both tags share one manifest", got, want)
+	}
+}
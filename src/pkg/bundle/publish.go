@@ -11,15 +11,33 @@ import (
 	"path/filepath"
 
 	"github.com/corang/uds-cli/src/config"
-	oci "github.com/defenseunicorns/zarf/src/pkg/oci"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
 	av3 "github.com/mholt/archiver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // Publish publishes a bundle to a remote OCI registry
 func (b *Bundler) Publish() error {
+	if err := validateSignMethod(b.cfg.PublishOpts.SignMethod); err != nil {
+		return err
+	}
+	if err := validateRegistryStyle(b.cfg.PublishOpts.RegistryStyle); err != nil {
+		return err
+	}
+	if err := validateOCIVersion(config.CommonOptions.OCIVersion); err != nil {
+		return err
+	}
+
+	source, err := b.decryptSource(b.cfg.PublishOpts.Source, b.cfg.PublishOpts.Decrypt, b.cfg.PublishOpts.IdentityPath)
+	if err != nil {
+		return err
+	}
+
 	// load bundle metadata into memory
-	provider, err := NewBundleProvider(context.TODO(), b.cfg.PublishOpts.Source, b.tmp)
+	provider, err := NewBundleProvider(context.TODO(), source, b.tmp)
 	if err != nil {
 		return err
 	}
@@ -30,29 +48,95 @@ func (b *Bundler) Publish() error {
 	if err := utils.ReadYaml(loaded[config.BundleYAML], &b.bundle); err != nil {
 		return err
 	}
+	if err := requireBundleYAML(&b.bundle, "publish"); err != nil {
+		return err
+	}
 	err = os.RemoveAll(filepath.Join(b.tmp, "blobs")) // clear tmp dir
 	if err != nil {
 		return err
 	}
 
 	// unarchive bundle into empty tmp dir
-	err = av3.Unarchive(b.cfg.PublishOpts.Source, b.tmp) // todo: awkward to use old version of mholt/archiver
+	err = av3.Unarchive(source, b.tmp) // todo: awkward to use old version of mholt/archiver
 	if err != nil {
 		return err
 	}
 
 	// create new OCI artifact in remote
-	ociURL := b.cfg.PublishOpts.Destination
-	bundleName := b.bundle.Metadata.Name
-	bundleTag := b.bundle.Metadata.Version
-	bundleArch := b.bundle.Metadata.Architecture
-	remote, err := oci.NewOrasRemote(fmt.Sprintf("%s/%s:%s-%s", ociURL, bundleName, bundleTag, bundleArch))
+	ref, err := publishRef(b.cfg.PublishOpts.Destination, &b.bundle.Metadata, b.cfg.PublishOpts.RegistryStyle)
+	if err != nil {
+		return err
+	}
+	remote, err := udsUtils.NewOrasRemote(ref)
 	if err != nil {
 		return err
 	}
-	err = provider.PublishBundle(b.bundle, remote)
+	if err := checkRepositoryExists(remote, b.cfg.PublishOpts.RegistryStyle); err != nil {
+		return err
+	}
+	err = provider.PublishBundle(b.bundle, remote, manifestConfigMediaType(b.cfg.PublishOpts.LegacyConfigMediaType, ocispec.MediaTypeImageConfig), b.cfg.PublishOpts.ArtifactType)
 	if err != nil {
 		return err
 	}
+	// bundles pulled with their referrers (signatures, SBOMs, attestations) keep them in the new registry
+	if !b.cfg.PublishOpts.NoReferrers {
+		if err := b.publishReferrers(remote); err != nil {
+			return err
+		}
+	}
+	if b.cfg.PublishOpts.SignMethod == config.SignMethodNotation {
+		return notationSign(remote, b.cfg.PublishOpts.NotationKey)
+	}
+	return nil
+}
+
+// publishRef returns the ref a bundle is published to under the oci:// URL dest
+func publishRef(dest string, metadata *types.UDSMetadata, style string) (string, error) {
+	return normalizeRegistryRef(fmt.Sprintf("%s/%s:%s-%s", dest, metadata.Name, metadata.Version, metadata.Architecture), style)
+}
+
+// CheckPush checks that this identity can push to the publish destination without publishing anything. With a bundle
+// tarball as the source it checks the repository the bundle would be published to, otherwise the destination itself
+func (b *Bundler) CheckPush() error {
+	if err := validateRegistryStyle(b.cfg.PublishOpts.RegistryStyle); err != nil {
+		return err
+	}
+	ref, err := normalizeRegistryRef(b.cfg.PublishOpts.Destination, b.cfg.PublishOpts.RegistryStyle)
+	if err != nil {
+		return err
+	}
+	if b.cfg.PublishOpts.Source != "" {
+		source, err := b.decryptSource(b.cfg.PublishOpts.Source, b.cfg.PublishOpts.Decrypt, b.cfg.PublishOpts.IdentityPath)
+		if err != nil {
+			return err
+		}
+		provider, err := NewBundleProvider(context.TODO(), source, b.tmp)
+		if err != nil {
+			return err
+		}
+		loaded, err := provider.LoadBundleMetadata()
+		if err != nil {
+			return err
+		}
+		if err := utils.ReadYaml(loaded[config.BundleYAML], &b.bundle); err != nil {
+			return err
+		}
+		if ref, err = publishRef(b.cfg.PublishOpts.Destination, &b.bundle.Metadata, b.cfg.PublishOpts.RegistryStyle); err != nil {
+			return err
+		}
+	}
+
+	remote, err := udsUtils.NewOrasRemote(ref)
+	if err != nil {
+		return err
+	}
+	if err := checkRepositoryExists(remote, b.cfg.PublishOpts.RegistryStyle); err != nil {
+		return err
+	}
+	repo := remote.Repo()
+	if err := checkPushPermission(udsUtils.NetworkContext(), repo); err != nil {
+		return err
+	}
+	message.Successf("Able to push to %s/%s", repo.Reference.Registry, repo.Reference.Repository)
 	return nil
 }
@@ -10,25 +10,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
 	zarfConfig "github.com/defenseunicorns/zarf/src/config"
 	"github.com/defenseunicorns/zarf/src/pkg/message"
-	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
 	"github.com/mholt/archiver/v4"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	ocistore "oras.land/oras-go/v2/content/oci"
 )
 
 // Pull pulls a bundle and saves it locally + caches it
 func (b *Bundler) Pull() error {
-	cacheDir := filepath.Join(zarfConfig.GetAbsCachePath(), "packages")
+	if b.cfg.PullOpts.MetadataOnly || b.cfg.PullOpts.FromFile != "" {
+		return b.pullMetadataIndex()
+	}
+	if b.cfg.PullOpts.TarballName != "" {
+		if b.cfg.PullOpts.SignatureOnly {
+			return fmt.Errorf("--tarball-name names the pulled bundle tarball and can't be used with --signature-only")
+		}
+		if err := validatePullTarballName(b.cfg.PullOpts.TarballName, udsUtils.IsEncrypted(b.cfg.PullOpts.Source)); err != nil {
+			return err
+		}
+	}
+
+	cacheDir := filepath.Join(zarfConfig.GetAbsCachePath(), config.PackageCacheDir)
 	// create the cache directory if it doesn't exist
 	if err := utils.CreateDirectory(cacheDir, 0755); err != nil {
 		return err
 	}
 
-	provider, err := NewBundleProvider(context.TODO(), b.cfg.PullOpts.Source, cacheDir)
+	// bundles in object storage are already tarballs, they're downloaded and checked like a local bundle. Encrypted
+	// tarballs are decrypted to check them, the tarball is pulled as it was stored
+	source, providerDst := b.cfg.PullOpts.Source, cacheDir
+	fromObjectStore := udsUtils.IsObjectStoreURL(source)
+	if b.cfg.PullOpts.Decrypt && !fromObjectStore {
+		return fmt.Errorf("bundles in an OCI registry are not encrypted, remove --decrypt to pull %s", source)
+	}
+	downloaded := source
+	if fromObjectStore {
+		var err error
+		if downloaded, err = b.downloadSource(source); err != nil {
+			return err
+		}
+		if source, err = b.decryptSource(downloaded, b.cfg.PullOpts.Decrypt, b.cfg.PullOpts.IdentityPath); err != nil {
+			return err
+		}
+		providerDst = b.tmp
+	}
+
+	provider, err := NewBundleProvider(context.TODO(), source, providerDst)
 	if err != nil {
 		return err
 	}
@@ -40,10 +73,52 @@ func (b *Bundler) Pull() error {
 	}
 
 	// validate the sig (if present)
-	if err := ValidateBundleSignature(loadedMetadata[config.BundleYAML], loadedMetadata[config.BundleYAMLSignature], b.cfg.PullOpts.PublicKeyPath); err != nil {
+	publicKeys, err := udsUtils.FetchPublicKeys(b.cfg.PullOpts.PublicKeyPaths, b.tmp)
+	if err != nil {
+		return err
+	}
+	sigAlgo, err := provider.SignatureAlgorithm()
+	if err != nil {
+		return err
+	}
+	if _, err := verifyBundleSignature(loadedMetadata, publicKeys, sigAlgo); err != nil {
 		return err
 	}
 
+	// refuse bundles that need a newer CLI before pulling their packages
+	if err := utils.ReadYaml(loadedMetadata[config.BundleYAML], &b.bundle); err != nil {
+		return err
+	}
+	if err := validateMinUdsVersion(b.bundle.Metadata, config.CLIVersion, b.cfg.PullOpts.SkipVersionCheck); err != nil {
+		return err
+	}
+
+	// only keep the bundle's metadata + sig, skip the package layers
+	if b.cfg.PullOpts.SignatureOnly {
+		for _, rel := range config.BundleAlwaysPull {
+			abs, ok := loadedMetadata[rel]
+			if !ok {
+				message.Warnf("%s does not contain %s", b.cfg.PullOpts.Source, rel)
+				continue
+			}
+			dst := filepath.Join(b.cfg.PullOpts.OutputDirectory, rel)
+			if err := utils.CreatePathAndCopy(abs, dst); err != nil {
+				return err
+			}
+			message.Debug("Pulled", rel, "to", dst)
+		}
+		return nil
+	}
+
+	if fromObjectStore {
+		tarballPath := filepath.Join(b.cfg.PullOpts.OutputDirectory, firstNonEmpty(b.cfg.PullOpts.TarballName, filepath.Base(downloaded)))
+		if err := utils.CreatePathAndCopy(downloaded, tarballPath); err != nil {
+			return err
+		}
+		message.Debug("Pulled", b.cfg.PullOpts.Source, "to", tarballPath)
+		return nil
+	}
+
 	// pull the bundle
 	loaded, err := provider.LoadBundle(zarfConfig.CommonOptions.OCIConcurrency)
 	if err != nil {
@@ -51,7 +126,7 @@ func (b *Bundler) Pull() error {
 	}
 
 	// create a remote client just to resolve the root descriptor
-	remote, err := oci.NewOrasRemote(b.cfg.PullOpts.Source)
+	remote, err := udsUtils.NewOrasRemote(b.cfg.PullOpts.Source)
 	if err != nil {
 		return err
 	}
@@ -62,11 +137,29 @@ func (b *Bundler) Pull() error {
 		return err
 	}
 
-	// make an index.json specifically for this bundle
+	// bring along the referrers attached to the bundle (signatures, SBOMs, attestations), so it can still be verified
+	// once it's published to another registry
+	referrersLayout := filepath.Join(b.tmp, referrersDir)
+	referrers := []ocispec.Descriptor{}
+	if !b.cfg.PullOpts.NoReferrers {
+		store, err := ocistore.NewWithContext(context.TODO(), referrersLayout)
+		if err != nil {
+			return err
+		}
+		if referrers, err = copyReferrers(context.TODO(), remote.Repo(), store, rootDesc); err != nil {
+			return fmt.Errorf("unable to pull the bundle's referrers, use --no-referrers to skip them: %w", err)
+		}
+		if len(referrers) > 0 {
+			message.Successf("Pulled %d referrers of the bundle", len(referrers))
+		}
+	}
+
+	// make an index.json specifically for this bundle, its referrers are listed after it
 	index := ocispec.Index{}
 	index.SchemaVersion = 2
 	index.MediaType = ocispec.MediaTypeImageIndex
 	index.Manifests = append(index.Manifests, rootDesc)
+	index.Manifests = append(index.Manifests, referrers...)
 
 	// write the index.json to tmp
 	bytes, err := json.MarshalIndent(index, "", "  ")
@@ -84,7 +177,7 @@ func (b *Bundler) Pull() error {
 	}
 
 	// tarball the bundle
-	filename := fmt.Sprintf("%s%s-%s-%s.tar.zst", config.BundlePrefix, b.bundle.Metadata.Name, b.bundle.Metadata.Architecture, b.bundle.Metadata.Version)
+	filename := firstNonEmpty(b.cfg.PullOpts.TarballName, bundleTarballName(&b.bundle.Metadata))
 	dst := filepath.Join(b.cfg.PullOpts.OutputDirectory, filename)
 
 	_ = os.RemoveAll(dst)
@@ -108,12 +201,26 @@ func (b *Bundler) Pull() error {
 	pathMap[indexJSONPath] = "index.json"
 	pathMap[filepath.Join(cacheDir, "oci-layout")] = "oci-layout"
 
-	// re-map the paths to be relative to the cache directory
-	for sha, abs := range loaded {
-		if sha == config.BundleYAML || sha == config.BundleYAMLSignature {
-			sha = filepath.Base(abs)
+	// re-map the paths to be relative to the cache directory, blobs are under the directory of their digest's algorithm
+	for rel, abs := range loaded {
+		// the cache's index.json lists every bundle in the cache, the bundle's own is written above
+		if rel == "index.json" {
+			continue
+		}
+		blobPath, err := filepath.Rel(cacheDir, abs)
+		if err != nil {
+			return err
+		}
+		pathMap[abs] = blobPath
+	}
+	if len(referrers) > 0 {
+		paths, err := referrerPaths(referrersLayout, pathMap)
+		if err != nil {
+			return err
+		}
+		for abs, rel := range paths {
+			pathMap[abs] = rel
 		}
-		pathMap[abs] = filepath.Join(config.BlobsDir, sha)
 	}
 
 	files, err := archiver.FilesFromDisk(nil, pathMap)
@@ -130,3 +237,22 @@ func (b *Bundler) Pull() error {
 
 	return nil
 }
+
+// validatePullTarballName ensures --tarball-name is a file name with the extension of the pulled bundle, encrypted
+// bundles keep their .age suffix
+func validatePullTarballName(name string, encrypted bool) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("--tarball-name %s must be a file name, use --output for the directory", name)
+	}
+	ext := ".tar.zst"
+	if encrypted {
+		ext += udsUtils.EncryptedSuffix
+	}
+	if !strings.HasSuffix(name, ext) || name == ext {
+		return fmt.Errorf("--tarball-name %s must end in %s to match the pulled bundle's format", name, ext)
+	}
+	if !udsUtils.IsValidTarballName(name) {
+		message.Warnf("--tarball-name %s isn't named like uds-bundle-<name>-<arch>-<version>%s, rename it before deploying or inspecting it", name, ext)
+	}
+	return nil
+}
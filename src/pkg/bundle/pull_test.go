@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import "testing"
+
+func Test_validatePullTarballName(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		tarballName string
+		encrypted   bool
+		wantErr     bool
+	}{
+		{
+			name:        "BundleName",
+			description: "names like create's are accepted",
+			tarballName: "uds-bundle-podinfo-amd64-0.0.1.tar.zst",
+		},
+		{
+			name:        "CustomName",
+			description: "any file name with the bundle's extension is accepted",
+			tarballName: "podinfo-nightly.tar.zst",
+		},
+		{
+			name:        "WrongExtension",
+			description: "pulled bundles are zstd compressed tarballs",
+			tarballName: "podinfo.tar.gz",
+			wantErr:     true,
+		},
+		{
+			name:        "ExtensionOnly",
+			description: "the name needs more than the extension",
+			tarballName: ".tar.zst",
+			wantErr:     true,
+		},
+		{
+			name:        "Encrypted",
+			description: "encrypted bundles keep their .age suffix",
+			tarballName: "podinfo.tar.zst.age",
+			encrypted:   true,
+		},
+		{
+			name:        "EncryptedWithoutAge",
+			description: "dropping .age from an encrypted bundle would hide that it's encrypted",
+			tarballName: "podinfo.tar.zst",
+			encrypted:   true,
+			wantErr:     true,
+		},
+		{
+			name:        "Directory",
+			description: "the directory comes from --output",
+			tarballName: "bundles/podinfo.tar.zst",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePullTarballName(tt.tarballName, tt.encrypted); (err != nil) != tt.wantErr {
+				t.Errorf("validatePullTarballName() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/k8s"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// readinessKinds maps the kinds (and their short names) a readiness check's resource can name to the kind checked
+var readinessKinds = map[string]string{
+	"deployment": "deployment", "deployments": "deployment", "deploy": "deployment",
+	"statefulset": "statefulset", "statefulsets": "statefulset", "sts": "statefulset",
+	"daemonset": "daemonset", "daemonsets": "daemonset", "ds": "daemonset",
+	"job": "job", "jobs": "job",
+	"pod": "pod", "pods": "pod", "po": "pod",
+}
+
+// readinessChecker runs a package's readiness checks against a cluster and over HTTP
+type readinessChecker struct {
+	clientset kubernetes.Interface
+	client    *http.Client
+	interval  time.Duration
+}
+
+// validateReadinessChecks ensures each of pkg's readiness checks names exactly one supported resource or URL and has a
+// valid timeout
+func validateReadinessChecks(pkg types.BundleZarfPackage) error {
+	for i, check := range pkg.Readiness {
+		if (check.Resource == "") == (check.URL == "") {
+			return fmt.Errorf("zarf pkg %s readiness[%d] must have either a resource or a url", pkg.Name, i)
+		}
+		if check.Resource != "" {
+			if _, _, err := parseReadinessResource(check.Resource); err != nil {
+				return fmt.Errorf("zarf pkg %s readiness[%d]: %w", pkg.Name, i, err)
+			}
+		} else {
+			if u, err := url.Parse(check.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("zarf pkg %s readiness[%d] has an invalid url %q, it must be an http:// or https:// URL", pkg.Name, i, check.URL)
+			}
+			if check.Status != 0 && (check.Status < 100 || check.Status > 599) {
+				return fmt.Errorf("zarf pkg %s readiness[%d] has an invalid status %d", pkg.Name, i, check.Status)
+			}
+		}
+		if _, err := readinessTimeout(check); err != nil {
+			return fmt.Errorf("zarf pkg %s readiness[%d]: %w", pkg.Name, i, err)
+		}
+	}
+	return nil
+}
+
+// parseReadinessResource splits a readiness check's kind/name resource, returning the kind it checks
+func parseReadinessResource(resource string) (string, string, error) {
+	kind, name, ok := strings.Cut(resource, "/")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("resource %q must be kind/name (ie. deployment/podinfo)", resource)
+	}
+	checked, ok := readinessKinds[strings.ToLower(kind)]
+	if !ok {
+		return "", "", fmt.Errorf("resource %q has an unsupported kind, readiness checks support deployments, statefulsets, daemonsets, jobs and pods", resource)
+	}
+	return checked, name, nil
+}
+
+// readinessTimeout returns how long to wait for check to pass
+func readinessTimeout(check types.ReadinessCheck) (time.Duration, error) {
+	if check.Timeout == "" {
+		return config.DefaultReadinessTimeout, nil
+	}
+	timeout, err := time.ParseDuration(check.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must be a positive duration (ie. 2m)", check.Timeout)
+	}
+	return timeout, nil
+}
+
+// readinessName returns the name deploy reports check by
+func readinessName(check types.ReadinessCheck) string {
+	if check.Name != "" {
+		return check.Name
+	}
+	if check.Resource != "" {
+		return check.Resource
+	}
+	return check.URL
+}
+
+// runReadinessChecks waits for each of pkg's readiness checks to pass in order, connecting to the cluster only when a
+// check names a resource
+func runReadinessChecks(ctx context.Context, pkg types.BundleZarfPackage) error {
+	if len(pkg.Readiness) == 0 {
+		return nil
+	}
+	checker := &readinessChecker{client: &http.Client{}, interval: config.ReadinessPollInterval}
+	for _, check := range pkg.Readiness {
+		if check.Resource != "" && checker.clientset == nil {
+			cluster, err := k8s.New(message.Debugf, nil)
+			if err != nil {
+				return fmt.Errorf("unable to connect to the cluster to run the readiness checks of zarf pkg %s: %w", pkg.Name, err)
+			}
+			checker.clientset = cluster.Clientset
+		}
+		if err := checker.wait(ctx, pkg.Name, check); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wait polls check until it passes, failing once its timeout elapses or ctx is done
+func (c *readinessChecker) wait(ctx context.Context, pkgName string, check types.ReadinessCheck) error {
+	timeout, err := readinessTimeout(check)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	name := readinessName(check)
+	spinner := message.NewProgressSpinner("Waiting for readiness check %s of zarf pkg %s", name, pkgName)
+	defer spinner.Stop()
+	for {
+		ready, reason := c.ready(ctx, check)
+		if ready {
+			spinner.Successf("Readiness check %s of zarf pkg %s passed", name, pkgName)
+			return nil
+		}
+		spinner.Updatef("Waiting for readiness check %s of zarf pkg %s: %s", name, pkgName, reason)
+		select {
+		case <-time.After(c.interval):
+		case <-ctx.Done():
+			return fmt.Errorf("readiness check %s of zarf pkg %s did not pass within %s: %s", name, pkgName, timeout, reason)
+		}
+	}
+}
+
+// ready runs check once, returning whether it passed and why not
+func (c *readinessChecker) ready(ctx context.Context, check types.ReadinessCheck) (bool, string) {
+	if check.URL != "" {
+		return c.endpointReady(ctx, check)
+	}
+	kind, name, err := parseReadinessResource(check.Resource)
+	if err != nil {
+		return false, err.Error()
+	}
+	namespace := check.Namespace
+	if namespace == "" {
+		namespace = corev1.NamespaceDefault
+	}
+	return workloadReady(ctx, c.clientset, kind, namespace, name)
+}
+
+// endpointReady returns whether check's URL responds to a GET request with its status
+func (c *readinessChecker) endpointReady(ctx context.Context, check types.ReadinessCheck) (bool, string) {
+	status := check.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return false, err.Error()
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err.Error()
+	}
+	resp.Body.Close()
+	if resp.StatusCode != status {
+		return false, fmt.Sprintf("%s responded with %d, want %d", check.URL, resp.StatusCode, status)
+	}
+	return true, ""
+}
+
+// workloadReady returns whether the kind workload namespace/name has rolled out, a workload that doesn't exist yet
+// isn't ready
+func workloadReady(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string) (bool, string) {
+	replicas := func(r *int32) int32 {
+		if r == nil {
+			return 1
+		}
+		return *r
+	}
+	switch kind {
+	case "deployment":
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err.Error()
+		}
+		want, status := replicas(deployment.Spec.Replicas), deployment.Status
+		if status.ObservedGeneration < deployment.Generation || status.UpdatedReplicas < want || status.AvailableReplicas < want {
+			return false, fmt.Sprintf("%d of %d replicas updated and available", minReplicas(status.UpdatedReplicas, status.AvailableReplicas), want)
+		}
+	case "statefulset":
+		statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err.Error()
+		}
+		want, status := replicas(statefulSet.Spec.Replicas), statefulSet.Status
+		if status.ObservedGeneration < statefulSet.Generation || status.UpdatedReplicas < want || status.ReadyReplicas < want {
+			return false, fmt.Sprintf("%d of %d replicas updated and ready", minReplicas(status.UpdatedReplicas, status.ReadyReplicas), want)
+		}
+	case "daemonset":
+		daemonSet, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err.Error()
+		}
+		status := daemonSet.Status
+		if status.ObservedGeneration < daemonSet.Generation || status.UpdatedNumberScheduled < status.DesiredNumberScheduled ||
+			status.NumberReady < status.DesiredNumberScheduled {
+			return false, fmt.Sprintf("%d of %d pods updated and ready", minReplicas(status.UpdatedNumberScheduled, status.NumberReady), status.DesiredNumberScheduled)
+		}
+	case "job":
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err.Error()
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+				return false, fmt.Sprintf("job failed: %s", condition.Message)
+			}
+		}
+		if want := replicas(job.Spec.Completions); job.Status.Succeeded < want {
+			return false, fmt.Sprintf("%d of %d completions succeeded", job.Status.Succeeded, want)
+		}
+	case "pod":
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err.Error()
+		}
+		if pod.Status.Phase == corev1.PodSucceeded {
+			return true, ""
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("pod is %s and not ready", pod.Status.Phase)
+	default:
+		return false, fmt.Sprintf("unsupported kind %s", kind)
+	}
+	return true, ""
+}
+
+// minReplicas returns the smaller of a and b
+func minReplicas(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corang/uds-cli/src/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_validateReadinessChecks(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		check       types.ReadinessCheck
+		wantErr     bool
+	}{
+		{
+			name:        "Resource",
+			description: "a workload named by its short kind is checked",
+			check:       types.ReadinessCheck{Resource: "deploy/podinfo", Namespace: "podinfo", Timeout: "2m"},
+		},
+		{
+			name:        "URL",
+			description: "an http URL is checked",
+			check:       types.ReadinessCheck{URL: "https://podinfo.uds.dev/healthz", Status: 204},
+		},
+		{
+			name:        "Neither",
+			description: "a check needs something to check",
+			check:       types.ReadinessCheck{Name: "empty"},
+			wantErr:     true,
+		},
+		{
+			name:        "Both",
+			description: "a check can't be both a resource and a url",
+			check:       types.ReadinessCheck{Resource: "pod/podinfo", URL: "http://podinfo"},
+			wantErr:     true,
+		},
+		{
+			name:        "UnsupportedKind",
+			description: "only workloads with a rollout status are supported",
+			check:       types.ReadinessCheck{Resource: "configmap/podinfo"},
+			wantErr:     true,
+		},
+		{
+			name:        "NotAURL",
+			description: "urls must be http or https",
+			check:       types.ReadinessCheck{URL: "podinfo:9898"},
+			wantErr:     true,
+		},
+		{
+			name:        "InvalidTimeout",
+			description: "timeouts must be positive durations",
+			check:       types.ReadinessCheck{Resource: "sts/podinfo", Timeout: "soon"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := types.BundleZarfPackage{Name: "podinfo", Readiness: []types.ReadinessCheck{tt.check}}
+			if err := validateReadinessChecks(pkg); (err != nil) != tt.wantErr {
+				t.Errorf("validateReadinessChecks() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_workloadReady(t *testing.T) {
+	replicas := int32(2)
+	deployment := func(name string, available int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "podinfo", Generation: 1},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 2, AvailableReplicas: available},
+		}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "podinfo"},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(deployment("ready", 2), deployment("rolling", 1), pod)
+
+	tests := []struct {
+		name        string
+		description string
+		kind        string
+		resource    string
+		want        bool
+	}{
+		{
+			name:        "Available",
+			description: "a deployment with every replica updated and available is ready",
+			kind:        "deployment",
+			resource:    "ready",
+			want:        true,
+		},
+		{
+			name:        "RollingOut",
+			description: "a deployment still rolling out isn't ready",
+			kind:        "deployment",
+			resource:    "rolling",
+		},
+		{
+			name:        "Missing",
+			description: "a workload that doesn't exist yet isn't ready",
+			kind:        "statefulset",
+			resource:    "podinfo",
+		},
+		{
+			name:        "PodReady",
+			description: "a pod with a true Ready condition is ready",
+			kind:        "pod",
+			resource:    "podinfo",
+			want:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, reason := workloadReady(context.TODO(), clientset, tt.kind, "podinfo", tt.resource); got != tt.want {
+				t.Errorf("workloadReady() = %v (%s), want %v (%s)", got, reason, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_readinessCheckerWait(t *testing.T) {
+	// an endpoint that comes up after its second request
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	checker := &readinessChecker{client: server.Client(), interval: 10 * time.Millisecond}
+	if err := checker.wait(context.TODO(), "podinfo", types.ReadinessCheck{URL: server.URL, Timeout: "5s"}); err != nil {
+		t.Fatalf("wait() error = %v, want the check to pass once the endpoint is up", err)
+	}
+
+	err := checker.wait(context.TODO(), "podinfo", types.ReadinessCheck{Name: "teapot", URL: server.URL, Status: http.StatusTeapot, Timeout: "50ms"})
+	if err == nil || !strings.Contains(err.Error(), "teapot") || !strings.Contains(err.Error(), "did not pass within 50ms") {
+		t.Errorf("wait() error = %v, want the check to time out", err)
+	}
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// reconstructedBundleYAML is the file the uds-bundle.yaml reconstructed for a bundle without one is written to
+const reconstructedBundleYAML = "reconstructed-" + config.BundleYAML
+
+// metadataFromManifestAnnotations is the inverse of manifestAnnotationsFromMetadata, it reads the bundle metadata and
+// build timestamp back out of a root manifest's annotations
+//
+// bundles pushed by other tools may also have a title and version annotation, which stand in for the name and version
+func metadataFromManifestAnnotations(annotations map[string]string) (types.UDSMetadata, types.UDSBuildData) {
+	metadata := types.UDSMetadata{
+		Name:            annotations[ocispec.AnnotationTitle],
+		Version:         annotations[ocispec.AnnotationVersion],
+		Description:     annotations[ocispec.AnnotationDescription],
+		URL:             annotations[ocispec.AnnotationURL],
+		Authors:         annotations[ocispec.AnnotationAuthors],
+		Documentation:   annotations[ocispec.AnnotationDocumentation],
+		Source:          annotations[ocispec.AnnotationSource],
+		Vendor:          annotations[ocispec.AnnotationVendor],
+		PlatformVariant: annotations[config.PlatformVariantAnnotation],
+	}
+	build := types.UDSBuildData{}
+	if created, err := time.Parse(time.RFC3339, annotations[ocispec.AnnotationCreated]); err == nil {
+		build.Timestamp = created.Format(time.RFC1123Z)
+	}
+	return metadata, build
+}
+
+// reconstructBundle returns the partial bundle a root manifest without a uds-bundle.yaml layer describes, marked as
+// reconstructed. Its metadata comes from the manifest's annotations and each package manifest layer becomes a package
+// pinned to its digest; packages are named by their title annotation or their position since their names are unknown
+func reconstructBundle(manifest *oci.ZarfOCIManifest) types.UDSBundle {
+	bundle := types.UDSBundle{Kind: "UDSBundle"}
+	bundle.Metadata, bundle.Build = metadataFromManifestAnnotations(manifest.Annotations)
+	bundle.Build.Reconstructed = true
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != ocispec.MediaTypeImageManifest {
+			continue
+		}
+		name := layer.Annotations[ocispec.AnnotationTitle]
+		if name == "" {
+			name = fmt.Sprintf("package-%d", len(bundle.ZarfPackages))
+		}
+		bundle.ZarfPackages = append(bundle.ZarfPackages, types.BundleZarfPackage{
+			Name:       name,
+			Repository: layer.Annotations[config.PackageRepositoryAnnotation],
+			Ref:        repinRef(layer.Annotations[config.PackageTagAnnotation], layer.Digest),
+		})
+	}
+	return bundle
+}
+
+// writeReconstructedBundleYAML writes the bundle reconstructed from a root manifest without a uds-bundle.yaml layer
+// into dir, returning its path
+func writeReconstructedBundleYAML(manifest *oci.ZarfOCIManifest, dir string) (string, error) {
+	message.Warnf("The bundle has no %s, its metadata was reconstructed from its root manifest's annotations and is partial", config.BundleYAML)
+	path := filepath.Join(dir, reconstructedBundleYAML)
+	return path, zarfUtils.WriteYaml(path, reconstructBundle(manifest), 0600)
+}
+
+// requireBundleYAML fails actions that need a bundle's full uds-bundle.yaml if the bundle was reconstructed
+func requireBundleYAML(bundle *types.UDSBundle, action string) error {
+	if bundle.Build.Reconstructed {
+		return fmt.Errorf("unable to %s a bundle without a %s, its reconstructed metadata is only partial", action, config.BundleYAML)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func Test_metadataFromManifestAnnotations(t *testing.T) {
+	metadata := types.UDSMetadata{
+		Description:     "a bundle",
+		URL:             "https://example.com",
+		Authors:         "UDS Authors",
+		Documentation:   "https://example.com/docs",
+		Source:          "https://example.com/src",
+		Vendor:          "Defense Unicorns",
+		PlatformVariant: "v8",
+	}
+	build := types.UDSBuildData{Timestamp: "Tue, 03 Oct 2023 09:30:00 +0000"}
+
+	// bundles created by uds round trip through their annotations
+	gotMetadata, gotBuild := metadataFromManifestAnnotations(manifestAnnotationsFromMetadata(&metadata, &build))
+	if !reflect.DeepEqual(gotMetadata, metadata) {
+		t.Errorf("metadataFromManifestAnnotations() metadata = %+v, want %+v", gotMetadata, metadata)
+	}
+	if !reflect.DeepEqual(gotBuild, build) {
+		t.Errorf("metadataFromManifestAnnotations() build = %+v, want %+v", gotBuild, build)
+	}
+
+	// other tools' title and version annotations stand in for the name and version
+	gotMetadata, _ = metadataFromManifestAnnotations(map[string]string{ocispec.AnnotationTitle: "podinfo", ocispec.AnnotationVersion: "0.0.1"})
+	if gotMetadata.Name != "podinfo" || gotMetadata.Version != "0.0.1" {
+		t.Errorf("metadataFromManifestAnnotations() name, version = %s, %s, want podinfo, 0.0.1", gotMetadata.Name, gotMetadata.Version)
+	}
+}
+
+func Test_reconstructBundle(t *testing.T) {
+	first := digest.FromString("first")
+	second := digest.FromString("second")
+	manifest := &oci.ZarfOCIManifest{Manifest: ocispec.Manifest{
+		Annotations: map[string]string{ocispec.AnnotationTitle: "podinfo", ocispec.AnnotationDescription: "pushed by another tool"},
+		Layers: []ocispec.Descriptor{
+			{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    first,
+				Annotations: map[string]string{
+					config.PackageTagAnnotation:        "0.0.1",
+					config.PackageRepositoryAnnotation: "ghcr.io/org/podinfo",
+				},
+			},
+			{MediaType: ocispec.MediaTypeImageManifest, Digest: second, Annotations: map[string]string{ocispec.AnnotationTitle: "nginx"}},
+			{MediaType: oci.ZarfLayerMediaTypeBlob, Digest: digest.FromString("sig"), Annotations: map[string]string{ocispec.AnnotationTitle: config.BundleYAMLSignature}},
+		},
+	}}
+
+	bundle := reconstructBundle(manifest)
+	if !bundle.Build.Reconstructed {
+		t.Errorf("reconstructBundle() isn't marked as reconstructed")
+	}
+	if bundle.Metadata.Name != "podinfo" || bundle.Metadata.Description != "pushed by another tool" {
+		t.Errorf("reconstructBundle() metadata = %+v, want it read from the annotations", bundle.Metadata)
+	}
+	want := []types.BundleZarfPackage{
+		{Name: "package-0", Repository: "ghcr.io/org/podinfo", Ref: "0.0.1@" + first.String()},
+		{Name: "nginx", Ref: "@" + second.String()},
+	}
+	if !reflect.DeepEqual(bundle.ZarfPackages, want) {
+		t.Errorf("reconstructBundle() packages = %+v, want %+v", bundle.ZarfPackages, want)
+	}
+	if err := requireBundleYAML(&bundle, "deploy"); err == nil {
+		t.Errorf("requireBundleYAML() didn't fail for a reconstructed bundle")
+	}
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/k8s"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// errNoDeployRecord is returned by readDeployRecords when the bundle has never been deployed to the cluster
+var errNoDeployRecord = errors.New("no UDS deploy record exists")
+
+// newDeployRecord creates an empty deploy record for the bundle, packages are added as they deploy
+func newDeployRecord(bundle *types.UDSBundle, source, namespacePrefix string) *types.UDSDeployRecord {
+	return &types.UDSDeployRecord{
+		Metadata:        bundle.Metadata,
+		Build:           bundle.Build,
+		Source:          source,
+		NamespacePrefix: namespacePrefix,
+		DeployedAt:      time.Now().UTC().Format(time.RFC3339),
+		Packages:        []types.UDSDeployedPackage{},
+	}
+}
+
+// deployRecordName returns the name of the secret holding a bundle's deploy record, a bundle deployed with
+// --namespace-prefix has a record per prefix (ie. uds-bundle-tenant-a-<name>)
+func deployRecordName(bundleName, namespacePrefix string) string {
+	return config.BundlePrefix + namespacePrefix + bundleName
+}
+
+// ownsDeployRecord returns true if record is the deploy record of bundleName deployed with namespacePrefix, the record
+// names of a prefixed and an unprefixed bundle can be the same (ie. tenant-a- and podinfo, or tenant-a-podinfo)
+func ownsDeployRecord(record *types.UDSDeployRecord, bundleName, namespacePrefix string) bool {
+	return record.Metadata.Name == bundleName && record.NamespacePrefix == namespacePrefix
+}
+
+// recordDeployedPackage adds a deployed package to the record and writes the record to the cluster
+//
+// a failure to write the record doesn't fail the deploy, the packages are already installed
+func recordDeployedPackage(record *types.UDSDeployRecord, pkg types.BundleZarfPackage, exports map[string]string) {
+	ref, digest, _ := strings.Cut(pkg.Ref, "@")
+	record.Packages = append(record.Packages, types.UDSDeployedPackage{
+		Name:               pkg.Name,
+		Ref:                ref,
+		Digest:             digest,
+		OptionalComponents: pkg.OptionalComponents,
+		Exports:            exports,
+	})
+
+	cluster, err := k8s.New(message.Debugf, nil)
+	if err != nil {
+		message.Debugf("unable to connect to the cluster, skipping deploy record: %s", err.Error())
+		return
+	}
+	if err := writeDeployRecord(cluster, record); err != nil {
+		message.Warnf("Unable to write the deploy record for bundle %s: %s", record.Metadata.Name, err.Error())
+	}
+}
+
+// resumeFrom returns the number of leading packages that a previous deploy already applied unchanged
+//
+// packages are compared in deploy order, everything after the first new or changed package is redeployed
+func resumeFrom(packages []types.BundleZarfPackage, previous *types.UDSDeployRecord) int {
+	for i, pkg := range packages {
+		if i >= len(previous.Packages) {
+			return i
+		}
+		deployed := previous.Packages[i]
+		_, digest, _ := strings.Cut(pkg.Ref, "@")
+		if deployed.Name != pkg.Name || deployed.Digest != digest ||
+			strings.Join(deployed.OptionalComponents, ",") != strings.Join(pkg.OptionalComponents, ",") {
+			return i
+		}
+	}
+	return len(packages)
+}
+
+// unchangedPackages returns the packages that a previous deploy already applied unchanged, keyed by name
+//
+// unlike resumeFrom, packages are matched by name regardless of order, so a changed package doesn't redeploy the
+// packages after it
+func unchangedPackages(packages []types.BundleZarfPackage, previous *types.UDSDeployRecord) map[string]types.UDSDeployedPackage {
+	deployed := make(map[string]types.UDSDeployedPackage)
+	for _, pkg := range previous.Packages {
+		deployed[pkg.Name] = pkg
+	}
+	unchanged := make(map[string]types.UDSDeployedPackage)
+	for _, pkg := range packages {
+		prev, ok := deployed[pkg.Name]
+		_, digest, _ := strings.Cut(pkg.Ref, "@")
+		if !ok || digest == "" || prev.Digest != digest ||
+			strings.Join(prev.OptionalComponents, ",") != strings.Join(pkg.OptionalComponents, ",") {
+			continue
+		}
+		unchanged[pkg.Name] = prev
+	}
+	return unchanged
+}
+
+// writeDeployRecord creates or updates the deploy record secret for a bundle
+func writeDeployRecord(cluster *k8s.K8s, record *types.UDSDeployRecord) error {
+	if _, err := cluster.CreateNamespace(cluster.NewZarfManagedNamespace(config.DeployRecordNamespace)); err != nil {
+		return err
+	}
+
+	name := deployRecordName(record.Metadata.Name, record.NamespacePrefix)
+	if existing, err := cluster.GetSecret(config.DeployRecordNamespace, name); err == nil {
+		var owner types.UDSDeployRecord
+		if err := json.Unmarshal(existing.Data[config.DeployRecordDataKey], &owner); err == nil && !ownsDeployRecord(&owner, record.Metadata.Name, record.NamespacePrefix) {
+			return fmt.Errorf("deploy record %s belongs to bundle %s deployed with namespace prefix %q", name, owner.Metadata.Name, owner.NamespacePrefix)
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	secret := cluster.GenerateSecret(config.DeployRecordNamespace, name, corev1.SecretTypeOpaque)
+	secret.Labels[config.DeployRecordLabel] = record.Metadata.Name
+	secret.Data[config.DeployRecordDataKey] = data
+	return cluster.CreateOrUpdateSecret(secret)
+}
+
+// readDeployRecords reads the deploy record for bundleName deployed with namespacePrefix from the cluster, or all deploy
+// records if bundleName is empty
+func readDeployRecords(bundleName, namespacePrefix string) ([]types.UDSDeployRecord, error) {
+	cluster, err := k8s.New(message.Debugf, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the cluster: %w", err)
+	}
+	return readClusterDeployRecords(cluster, bundleName, namespacePrefix)
+}
+
+// readClusterDeployRecords reads deploy records from cluster, see readDeployRecords
+func readClusterDeployRecords(cluster *k8s.K8s, bundleName, namespacePrefix string) ([]types.UDSDeployRecord, error) {
+	var secrets []corev1.Secret
+	if bundleName != "" {
+		name := deployRecordName(bundleName, namespacePrefix)
+		secret, err := cluster.GetSecret(config.DeployRecordNamespace, name)
+		if kerrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w for bundle %s", errNoDeployRecord, bundleName)
+		} else if err != nil {
+			return nil, err
+		}
+		var record types.UDSDeployRecord
+		if err := json.Unmarshal(secret.Data[config.DeployRecordDataKey], &record); err == nil && !ownsDeployRecord(&record, bundleName, namespacePrefix) {
+			return nil, fmt.Errorf("%w for bundle %s with namespace prefix %q, %s belongs to bundle %s", errNoDeployRecord, bundleName, namespacePrefix, name, record.Metadata.Name)
+		}
+		secrets = append(secrets, *secret)
+	} else {
+		secretList, err := cluster.GetSecretsWithLabel(config.DeployRecordNamespace, config.DeployRecordLabel)
+		if err != nil {
+			return nil, err
+		}
+		if len(secretList.Items) == 0 {
+			return nil, fmt.Errorf("no UDS deploy records exist in the cluster")
+		}
+		secrets = secretList.Items
+	}
+
+	records := []types.UDSDeployRecord{}
+	for _, secret := range secrets {
+		var record types.UDSDeployRecord
+		if err := json.Unmarshal(secret.Data[config.DeployRecordDataKey], &record); err != nil {
+			return nil, fmt.Errorf("unable to read deploy record %s: %w", secret.Name, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
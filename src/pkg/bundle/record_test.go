@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/k8s"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_deployRecordNamespacePrefixes(t *testing.T) {
+	cluster := &k8s.K8s{Clientset: fake.NewSimpleClientset()}
+	bundle := &types.UDSBundle{Metadata: types.UDSMetadata{Name: "podinfo", Version: "0.0.1"}}
+
+	// two tenants deploying the same bundle each get their own record
+	for _, prefix := range []string{"tenant-a-", "tenant-b-"} {
+		record := newDeployRecord(bundle, "oci://ghcr.io/org/podinfo:0.0.1", prefix)
+		record.Packages = []types.UDSDeployedPackage{{Name: "podinfo", Digest: "sha256:" + prefix}}
+		if err := writeDeployRecord(cluster, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, prefix := range []string{"tenant-a-", "tenant-b-"} {
+		records, err := readClusterDeployRecords(cluster, "podinfo", prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := records[0]; got.NamespacePrefix != prefix || got.Packages[0].Digest != "sha256:"+prefix {
+			t.Errorf("readClusterDeployRecords(%q) = %+v, want the record deployed with that prefix", prefix, got)
+		}
+	}
+	if _, err := readClusterDeployRecords(cluster, "podinfo", ""); !errors.Is(err, errNoDeployRecord) {
+		t.Errorf("readClusterDeployRecords() without a prefix error = %v, want %v", err, errNoDeployRecord)
+	}
+
+	// an unprefixed bundle whose record name is the same as a tenant's doesn't read or overwrite it
+	other := newDeployRecord(&types.UDSBundle{Metadata: types.UDSMetadata{Name: "tenant-a-podinfo"}}, "", "")
+	if err := writeDeployRecord(cluster, other); err == nil {
+		t.Errorf("writeDeployRecord() overwrote the record of tenant-a- with bundle %s", other.Metadata.Name)
+	}
+	if _, err := readClusterDeployRecords(cluster, "tenant-a-podinfo", ""); !errors.Is(err, errNoDeployRecord) {
+		t.Errorf("readClusterDeployRecords() of the colliding bundle error = %v, want %v", err, errNoDeployRecord)
+	}
+
+	records, err := readClusterDeployRecords(cluster, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("readClusterDeployRecords() listed %d records, want one per tenant", len(records))
+	}
+}
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	ocistore "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry"
+)
+
+// referrersDir is the OCI layout under the tmp dir that pull copies the bundle's referrers into
+const referrersDir = "referrers"
+
+// referrerSource is a repository that lists the referrers of its manifests, ie. a remote.Repository
+type referrerSource interface {
+	content.ReadOnlyStorage
+	registry.ReferrerLister
+}
+
+// isReferrer returns true if desc is an index.json entry for a referrer pulled with the bundle rather than the bundle
+func isReferrer(desc ocispec.Descriptor) bool {
+	return desc.Annotations[config.ReferrerSubjectAnnotation] != ""
+}
+
+// splitReferrers separates the referrers pulled with a bundle from the other entries of its index.json
+func splitReferrers(manifests []ocispec.Descriptor) (others []ocispec.Descriptor, referrers []ocispec.Descriptor) {
+	for _, desc := range manifests {
+		if isReferrer(desc) {
+			referrers = append(referrers, desc)
+		} else {
+			others = append(others, desc)
+		}
+	}
+	return others, referrers
+}
+
+// withoutSubject returns a FindSuccessors for copying a referrer without the bundle it refers to, which is copied on
+// its own
+func withoutSubject(root digest.Digest) func(context.Context, content.Fetcher, ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	return func(ctx context.Context, fetcher content.Fetcher, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		successors, err := content.Successors(ctx, fetcher, desc)
+		if err != nil {
+			return nil, err
+		}
+		filtered := []ocispec.Descriptor{}
+		for _, successor := range successors {
+			if successor.Digest != root {
+				filtered = append(filtered, successor)
+			}
+		}
+		return filtered, nil
+	}
+}
+
+// copyReferrers copies the referrers of root in src (signatures, SBOMs, attestations), and the referrers of those, into
+// dst. It returns their descriptors for index.json, each annotated with the digest of the manifest it refers to
+func copyReferrers(ctx context.Context, src referrerSource, dst content.Storage, root ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	opts := oras.DefaultCopyGraphOptions
+	opts.FindSuccessors = withoutSubject(root.Digest)
+
+	copied := []ocispec.Descriptor{}
+	seen := map[digest.Digest]bool{root.Digest: true}
+	subjects := []ocispec.Descriptor{root}
+	for len(subjects) > 0 {
+		next := []ocispec.Descriptor{}
+		for _, subject := range subjects {
+			err := src.Referrers(ctx, subject, "", func(referrers []ocispec.Descriptor) error {
+				for _, referrer := range referrers {
+					if seen[referrer.Digest] {
+						continue
+					}
+					seen[referrer.Digest] = true
+					if err := oras.CopyGraph(ctx, src, dst, referrer, opts); err != nil {
+						return fmt.Errorf("unable to copy referrer %s: %w", referrer.Digest, err)
+					}
+					next = append(next, referrer)
+
+					entry := referrer
+					entry.Annotations = mergeAnnotations(referrer.Annotations, map[string]string{config.ReferrerSubjectAnnotation: subject.Digest.String()})
+					copied = append(copied, entry)
+					message.Debugf("Copied referrer %s (%s) of %s", referrer.Digest, referrer.ArtifactType, subject.Digest)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		subjects = next
+	}
+	return copied, nil
+}
+
+// referrerPaths maps the blobs of an OCI layout of referrers to their paths in a bundle tarball, leaving out blobs the
+// bundle already has
+func referrerPaths(layoutDir string, pathMap PathMap) (PathMap, error) {
+	inTarball := map[string]bool{}
+	for _, path := range pathMap {
+		inTarball[path] = true
+	}
+	// blobs are stored under a directory per digest algorithm (ie. blobs/sha256, blobs/sha512)
+	algorithms, err := os.ReadDir(filepath.Join(layoutDir, config.BlobsRoot))
+	if os.IsNotExist(err) {
+		return PathMap{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	paths := PathMap{}
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+		blobs, err := os.ReadDir(filepath.Join(layoutDir, config.BlobsRoot, algorithm.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range blobs {
+			path := filepath.Join(config.BlobsRoot, algorithm.Name(), blob.Name())
+			if !inTarball[path] {
+				paths[filepath.Join(layoutDir, path)] = path
+			}
+		}
+	}
+	return paths, nil
+}
+
+// pushReferrers copies referrers from src to dst in the order they were pulled, subjects before the referrers of them.
+// They refer to the bundle by digest, so they're only pushed if the bundle kept its digest
+func pushReferrers(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, referrers []ocispec.Descriptor, pulledRoot, pushedRoot ocispec.Descriptor) error {
+	if len(referrers) == 0 {
+		return nil
+	}
+	if pulledRoot.Digest != pushedRoot.Digest {
+		message.Warnf("The published bundle manifest is %s rather than %s, skipping the %d referrers that refer to the original", pushedRoot.Digest, pulledRoot.Digest, len(referrers))
+		return nil
+	}
+	opts := oras.DefaultCopyGraphOptions
+	opts.FindSuccessors = withoutSubject(pulledRoot.Digest)
+	for _, referrer := range referrers {
+		if err := oras.CopyGraph(ctx, src, dst, referrer, opts); err != nil {
+			return fmt.Errorf("unable to push referrer %s: %w", referrer.Digest, err)
+		}
+	}
+	message.Successf("Pushed %d referrers of the bundle", len(referrers))
+	return nil
+}
+
+// publishReferrers pushes the referrers listed in the index.json of the bundle tarball unpacked into the tmp dir to
+// remote, after the bundle they refer to
+func (b *Bundler) publishReferrers(remote *oci.OrasRemote) error {
+	indexBytes, err := os.ReadFile(filepath.Join(b.tmp, "index.json"))
+	if err != nil {
+		return err
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return err
+	}
+	roots, referrers := splitReferrers(index.Manifests)
+	if len(referrers) == 0 || len(roots) == 0 {
+		return nil
+	}
+	store, err := ocistore.NewWithContext(context.TODO(), b.tmp)
+	if err != nil {
+		return err
+	}
+	pushedRoot, err := remote.ResolveRoot()
+	if err != nil {
+		return err
+	}
+	return pushReferrers(context.TODO(), store, remote.Repo(), referrers, roots[0], pushedRoot)
+}
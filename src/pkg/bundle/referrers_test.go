@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// fakeReferrerRegistry lists the referrers of a manifest like the referrers API, from the manifests pushed to it with a
+// subject
+type fakeReferrerRegistry struct {
+	*memory.Store
+}
+
+func (r fakeReferrerRegistry) Referrers(ctx context.Context, desc ocispec.Descriptor, _ string, fn func([]ocispec.Descriptor) error) error {
+	predecessors, err := r.Predecessors(ctx, desc)
+	if err != nil {
+		return err
+	}
+	return fn(predecessors)
+}
+
+// pushTestManifest pushes a manifest with a layer of data to store, referring to subject if it's set
+func pushTestManifest(t *testing.T, store content.Storage, artifactType, data string, subject *ocispec.Descriptor) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+	push := func(mediaType string, b []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, b)
+		if exists, _ := store.Exists(ctx, desc); !exists {
+			if err := store.Push(ctx, desc, bytes.NewReader(b)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return desc
+	}
+	manifest := ocispec.Manifest{
+		Versioned:    specs.Versioned{SchemaVersion: 2},
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: artifactType,
+		Config:       push(ocispec.MediaTypeImageConfig, []byte("{}")),
+		Layers:       []ocispec.Descriptor{push(ocispec.MediaTypeImageLayer, []byte(data))},
+		Subject:      subject,
+	}
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := push(ocispec.MediaTypeImageManifest, b)
+	desc.ArtifactType = artifactType
+	return desc
+}
+
+func Test_copyReferrers(t *testing.T) {
+	ctx := context.Background()
+	src := fakeReferrerRegistry{memory.New()}
+	root := pushTestManifest(t, src, "", "bundle", nil)
+	sig := pushTestManifest(t, src, "application/vnd.cncf.notary.signature", "signature", &root)
+	sbom := pushTestManifest(t, src, "application/spdx+json", "sbom", &root)
+	// a signature over the SBOM refers to the SBOM rather than the bundle
+	sbomSig := pushTestManifest(t, src, "application/vnd.cncf.notary.signature", "sbom signature", &sbom)
+
+	dst := memory.New()
+	copied, err := copyReferrers(ctx, src, dst, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subjects := map[string]string{}
+	for _, desc := range copied {
+		subjects[desc.Digest.String()] = desc.Annotations[config.ReferrerSubjectAnnotation]
+		if exists, err := dst.Exists(ctx, desc); err != nil || !exists {
+			t.Errorf("copyReferrers() didn't copy referrer %s", desc.Digest)
+		}
+	}
+	want := map[string]string{
+		sig.Digest.String():     root.Digest.String(),
+		sbom.Digest.String():    root.Digest.String(),
+		sbomSig.Digest.String(): sbom.Digest.String(),
+	}
+	if !reflect.DeepEqual(subjects, want) {
+		t.Errorf("copyReferrers() copied referrers of %v, want %v", subjects, want)
+	}
+	// subjects come before the referrers of them
+	if copied[len(copied)-1].Digest != sbomSig.Digest {
+		t.Errorf("copyReferrers() copied %s last, want the SBOM's signature after the SBOM", copied[len(copied)-1].Digest)
+	}
+	// the bundle itself isn't copied with its referrers
+	if exists, _ := dst.Exists(ctx, root); exists {
+		t.Errorf("copyReferrers() copied the bundle manifest along with its referrers")
+	}
+
+	// the copied referrers can be pushed on to another registry once the bundle is there
+	mirror := memory.New()
+	if err := pushReferrers(ctx, dst, mirror, copied, root, pushTestManifest(t, mirror, "", "changed bundle", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := mirror.Exists(ctx, sig); exists {
+		t.Errorf("pushReferrers() pushed referrers though the bundle's digest changed")
+	}
+	if err := pushReferrers(ctx, dst, mirror, copied, root, root); err != nil {
+		t.Fatal(err)
+	}
+	for _, desc := range []ocispec.Descriptor{sig, sbom, sbomSig} {
+		if exists, err := mirror.Exists(ctx, desc); err != nil || !exists {
+			t.Errorf("pushReferrers() didn't push referrer %s", desc.Digest)
+		}
+	}
+}
+
+func Test_VerifyLayoutReferrers(t *testing.T) {
+	dir := t.TempDir()
+	image := writeLayoutManifest(t, dir, "image", "layer")
+	root := writeLayoutManifest(t, dir, config.BundleYAML, "kind: UDSBundle")
+	referrer := writeLayoutManifest(t, dir, "signature", "sig")
+	referrer.Annotations = map[string]string{config.ReferrerSubjectAnnotation: root.Digest.String()}
+
+	// referrers pulled with the bundle aren't unnecessary entries
+	writeLayoutIndex(t, dir, root, referrer)
+	b := &Bundler{cfg: &types.BundlerConfig{VerifyLayoutOpts: types.BundlerVerifyLayoutOptions{Source: dir}}, tmp: t.TempDir()}
+	if err := b.VerifyLayout(); err != nil {
+		t.Fatalf("VerifyLayout() error = %v, want the referrer accepted", err)
+	}
+
+	// and --repair keeps them
+	writeLayoutIndex(t, dir, image, root, referrer)
+	b.cfg.VerifyLayoutOpts.Repair = true
+	if err := b.VerifyLayout(); err != nil {
+		t.Fatalf("VerifyLayout() with --repair error = %v", err)
+	}
+	indexBytes, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		t.Fatal(err)
+	}
+	if want := []ocispec.Descriptor{root, referrer}; !reflect.DeepEqual(index.Manifests, want) {
+		t.Errorf("VerifyLayout() with --repair left %v, want %v", index.Manifests, want)
+	}
+}
+
+func Test_referrerPaths(t *testing.T) {
+	layoutDir := t.TempDir()
+	write := func(rel string) {
+		t.Helper()
+		path := filepath.Join(layoutDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bundled := filepath.Join(config.BlobsDir, "bundled")
+	for _, rel := range []string{bundled, filepath.Join(config.BlobsDir, "signature"), filepath.Join(config.BlobsRoot, "sha512", "sbom")} {
+		write(rel)
+	}
+
+	got, err := referrerPaths(layoutDir, PathMap{"/tmp/bundled": bundled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PathMap{}
+	for _, rel := range []string{filepath.Join(config.BlobsDir, "signature"), filepath.Join(config.BlobsRoot, "sha512", "sbom")} {
+		want[filepath.Join(layoutDir, rel)] = rel
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("referrerPaths() = %v, want the sha256 and sha512 referrer blobs the bundle doesn't have %v", got, want)
+	}
+}
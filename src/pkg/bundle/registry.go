@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// validateRegistryStyle ensures --registry-style is a known registry type
+func validateRegistryStyle(style string) error {
+	if style == "" || helpers.SliceContains(config.RegistryStyles, style) {
+		return nil
+	}
+	return fmt.Errorf("invalid --registry-style %q, must be one of: %s", style, strings.Join(config.RegistryStyles, ", "))
+}
+
+// validateOCIVersion ensures --oci-version is a released version of the OCI image-spec or 'spec'
+func validateOCIVersion(version string) error {
+	if version == "" || helpers.SliceContains(config.OCIVersions, version) {
+		return nil
+	}
+	return fmt.Errorf("invalid --oci-version %q, must be one of: %s", version, strings.Join(config.OCIVersions, ", "))
+}
+
+// detectRegistryStyle returns the registry type implied by a registry host, harbor can't be detected and must be set
+func detectRegistryStyle(host string) string {
+	switch {
+	case host == "public.ecr.aws", strings.Contains(host, ".dkr.ecr.") && strings.HasSuffix(host, ".amazonaws.com"):
+		return config.RegistryStyleECR
+	case host == "ghcr.io":
+		return config.RegistryStyleGHCR
+	case host == "docker.io", host == "registry-1.docker.io", host == "index.docker.io":
+		return config.RegistryStyleDockerHub
+	case strings.HasSuffix(host, "-docker.pkg.dev"):
+		return config.RegistryStyleArtifactRegistry
+	default:
+		return config.RegistryStyleGeneric
+	}
+}
+
+// normalizeRegistryRef validates a publish destination against the path rules of its registry and normalizes it
+//
+// repositories are lowercased (bundle names from metadata may not be) and Docker Hub refs are pointed at its API
+// host, refs with too few path components for their registry (ie. a Harbor ref without a project) are errors
+func normalizeRegistryRef(raw, style string) (string, error) {
+	ref, err := registry.ParseReference(lowerRepository(strings.TrimPrefix(raw, helpers.OCIURLPrefix)))
+	if err != nil {
+		return "", fmt.Errorf("invalid OCI reference %q: %w", raw, err)
+	}
+	if style == "" || style == config.RegistryStyleAuto {
+		style = detectRegistryStyle(ref.Registry)
+	}
+
+	components := strings.Count(ref.Repository, "/") + 1
+	require := func(want int, layout string) error {
+		if components < want {
+			return fmt.Errorf("%s is not a valid %s repository, it must be of the form %s", ref.Registry+"/"+ref.Repository, style, layout)
+		}
+		return nil
+	}
+	switch style {
+	case config.RegistryStyleHarbor:
+		err = require(2, "<harbor host>/<project>/<name>")
+	case config.RegistryStyleGHCR:
+		err = require(2, "ghcr.io/<owner>/<name>")
+	case config.RegistryStyleDockerHub:
+		err = require(2, "docker.io/<namespace>/<name>")
+		ref.Registry = "registry-1.docker.io"
+	case config.RegistryStyleArtifactRegistry:
+		err = require(3, "<region>-docker.pkg.dev/<project>/<repository>/<name>")
+	case config.RegistryStyleECR:
+		if len(ref.Repository) > 256 {
+			err = fmt.Errorf("ECR repository names can be at most 256 characters, %s is %d", ref.Repository, len(ref.Repository))
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	normalized := ref.String()
+	if normalized != strings.TrimPrefix(raw, helpers.OCIURLPrefix) {
+		message.Debugf("Normalized %s registry ref %s to %s", style, raw, normalized)
+	}
+	return normalized, nil
+}
+
+// lowerRepository lowercases the registry and repository of a ref, leaving its (case-sensitive) tag alone
+func lowerRepository(ref string) string {
+	repoEnd := len(ref)
+	if i := strings.Index(ref, "@"); i >= 0 {
+		repoEnd = i
+	} else if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		repoEnd = i
+	}
+	return strings.ToLower(ref[:repoEnd]) + ref[repoEnd:]
+}
+
+// checkRepositoryExists fails early when pushing to a registry that doesn't create repositories on push (ie. ECR)
+func checkRepositoryExists(remote *oci.OrasRemote, style string) error {
+	ref := remote.Repo().Reference
+	if style == "" || style == config.RegistryStyleAuto {
+		style = detectRegistryStyle(ref.Registry)
+	}
+	if style != config.RegistryStyleECR {
+		return nil
+	}
+	err := remote.Repo().Tags(utils.NetworkContext(), "", func(_ []string) error { return nil })
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) && errResp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("ECR repository %s does not exist, ECR doesn't create repositories on push (ie. aws ecr create-repository --repository-name %s)", ref.Repository, ref.Repository)
+	}
+	if err != nil {
+		message.Debugf("Unable to check that %s exists: %s", ref, err.Error())
+	}
+	return nil
+}
+
+// checkPushPermission checks that the registry lets this identity push to repo without pushing anything: it starts a
+// blob upload, which needs push permission, then cancels it
+func checkPushPermission(ctx context.Context, repo *remote.Repository) error {
+	ref := repo.Reference
+	ctx = auth.AppendScopes(ctx, auth.ScopeRepository(ref.Repository, auth.ActionPull, auth.ActionPush))
+	scheme := "https"
+	if repo.PlainHTTP {
+		scheme = "http"
+	}
+	uploadURL := fmt.Sprintf("%s://%s/v2/%s/blobs/uploads/", scheme, ref.Host(), ref.Repository)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, nil)
+	if err != nil {
+		return err
+	}
+	// like oras, repositories without a client use the default one
+	client := repo.Client
+	if client == nil {
+		client = auth.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to reach %s: %w", ref.Registry, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("the registry denied pushing to %s/%s (%s), check the credentials for %s", ref.Registry, ref.Repository, resp.Status, ref.Registry)
+	case http.StatusNotFound:
+		return fmt.Errorf("the registry has no repository %s/%s and didn't create it (%s)", ref.Registry, ref.Repository, resp.Status)
+	default:
+		return fmt.Errorf("unexpected response starting an upload to %s/%s: %s", ref.Registry, ref.Repository, resp.Status)
+	}
+
+	// cancel the upload so the registry can drop it right away instead of waiting for it to expire
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || location.String() == "" {
+		message.Debugf("Unable to cancel the upload to %s/%s, it has no location", ref.Registry, ref.Repository)
+		return nil
+	}
+	cancelReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, req.URL.ResolveReference(location).String(), nil)
+	if err != nil {
+		return nil
+	}
+	cancelResp, err := client.Do(cancelReq)
+	if err != nil {
+		message.Debugf("Unable to cancel the upload to %s/%s: %s", ref.Registry, ref.Repository, err.Error())
+		return nil
+	}
+	cancelResp.Body.Close()
+	return nil
+}
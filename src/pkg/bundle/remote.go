@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/corang/uds-cli/src/config"
 	"github.com/corang/uds-cli/src/pkg/utils"
@@ -21,9 +20,12 @@ import (
 	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
 	goyaml "github.com/goccy/go-yaml"
 	"github.com/mholt/archiver/v4"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/file"
 	ocistore "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry/remote"
 )
 
 type ociProvider struct {
@@ -52,20 +54,20 @@ func (op *ociProvider) LoadPackage(sha, destinationDir string, _ int) (PathMap,
 		return nil, fmt.Errorf("destination directory cannot be the same as the bundle directory")
 	}
 
-	if err := op.getBundleManifest(); err != nil {
+	pkgManifestDesc, err := op.packageManifestDesc(sha)
+	if err != nil {
 		return nil, err
 	}
-	pkgManifestDesc := op.manifest.Locate(sha)
-	if oci.IsEmptyDescriptor(pkgManifestDesc) {
-		return nil, fmt.Errorf("package %s does not exist in this bundle", sha)
-	}
-	// hack to Zarf media type so that FetchManifest works
-	pkgManifestDesc.MediaType = oci.ZarfLayerMediaTypeBlob
 	pkgManifest, err := op.FetchManifest(pkgManifestDesc)
 	if err != nil || pkgManifest == nil {
 		return nil, err
 	}
 
+	layerRepo, err := op.packageLayerRepo(pkgManifestDesc)
+	if err != nil {
+		return nil, err
+	}
+
 	// including the package manifest uses some ORAs FindSuccessors hackery to expand the manifest into all layers
 	// as oras.Copy was designed for resolving layers via a manifest reference, not a manifest embedded inside of another
 	// image
@@ -74,7 +76,7 @@ func (op *ociProvider) LoadPackage(sha, destinationDir string, _ int) (PathMap,
 		// only fetch layers that exist
 		// since optional-components exists, there will be layers that don't exist
 		// as the package's preserved manifest will contain all layers for all components
-		ok, _ := op.Repo().Blobs().Exists(op.ctx, layer)
+		ok, _ := layerRepo.Blobs().Exists(op.ctx, layer)
 		if ok {
 			layersToPull = append(layersToPull, layer)
 		}
@@ -90,7 +92,11 @@ func (op *ociProvider) LoadPackage(sha, destinationDir string, _ int) (PathMap,
 	defer spinner.Stop()
 	for _, layer := range layersToPull {
 		spinner.Updatef(fmt.Sprintf("Pulling bundle layer: %s", layer.Digest.Encoded()))
-		lb, err := op.Repo().Fetch(op.ctx, layer)
+		repo := layerRepo
+		if layer.Digest == pkgManifestDesc.Digest {
+			repo = op.Repo()
+		}
+		lb, err := repo.Fetch(op.ctx, layer)
 		if err != nil {
 			return nil, err
 		}
@@ -111,6 +117,63 @@ func (op *ociProvider) LoadPackage(sha, destinationDir string, _ int) (PathMap,
 	return loaded, nil
 }
 
+// LoadPackageManifest fetches the manifest of a package in a remote bundle
+func (op *ociProvider) LoadPackageManifest(sha string) (*oci.ZarfOCIManifest, error) {
+	pkgManifestDesc, err := op.packageManifestDesc(sha)
+	if err != nil {
+		return nil, err
+	}
+	return op.FetchManifest(pkgManifestDesc)
+}
+
+// FetchPackageLayers fetches layers of a package in a remote bundle from the repository holding them
+func (op *ociProvider) FetchPackageLayers(sha string, descs ...ocispec.Descriptor) (map[digest.Digest][]byte, error) {
+	pkgManifestDesc, err := op.packageManifestDesc(sha)
+	if err != nil {
+		return nil, err
+	}
+	layerRepo, err := op.packageLayerRepo(pkgManifestDesc)
+	if err != nil {
+		return nil, err
+	}
+	layers := make(map[digest.Digest][]byte)
+	for _, desc := range descs {
+		b, err := content.FetchAll(op.ctx, layerRepo, desc)
+		if err != nil {
+			return nil, err
+		}
+		layers[desc.Digest] = b
+	}
+	return layers, nil
+}
+
+// packageManifestDesc returns the descriptor of the manifest of a package in the bundle
+func (op *ociProvider) packageManifestDesc(sha string) (ocispec.Descriptor, error) {
+	if err := op.getBundleManifest(); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	pkgManifestDesc := op.manifest.Locate(sha)
+	if oci.IsEmptyDescriptor(pkgManifestDesc) {
+		return ocispec.Descriptor{}, fmt.Errorf("package %s does not exist in this bundle", sha)
+	}
+	// hack to Zarf media type so that FetchManifest works
+	pkgManifestDesc.MediaType = oci.ZarfLayerMediaTypeBlob
+	return pkgManifestDesc, nil
+}
+
+// packageLayerRepo returns the repository holding a package's layers, packages pushed with --repo-prefix live outside the bundle's repository
+func (op *ociProvider) packageLayerRepo(pkgManifestDesc ocispec.Descriptor) (*remote.Repository, error) {
+	pkgRepo, ok := pkgManifestDesc.Annotations[config.PackageRepositoryAnnotation]
+	if !ok {
+		return op.Repo(), nil
+	}
+	pkgRemote, err := utils.NewOrasRemote(pkgRepo)
+	if err != nil {
+		return nil, err
+	}
+	return pkgRemote.Repo(), nil
+}
+
 // LoadBundleMetadata loads a remote bundle's metadata
 func (op *ociProvider) LoadBundleMetadata() (PathMap, error) {
 	if err := zarfUtils.CreateDirectory(filepath.Join(op.dst, config.BlobsDir), 0700); err != nil {
@@ -125,15 +188,50 @@ func (op *ociProvider) LoadBundleMetadata() (PathMap, error) {
 	for _, layer := range layers {
 		rel := layer.Annotations[ocispec.AnnotationTitle]
 		abs := filepath.Join(op.dst, config.BlobsDir, rel)
-		absSha := filepath.Join(op.dst, config.BlobsDir, layer.Digest.Encoded())
+		absSha := filepath.Join(op.dst, utils.BlobPath(layer.Digest))
 		if err := os.Rename(abs, absSha); err != nil {
 			return nil, err
 		}
 		loaded[rel] = absSha
 	}
+	// bundles pushed by other tools may only have annotations
+	if _, ok := loaded[config.BundleYAML]; !ok {
+		if err := op.getBundleManifest(); err != nil {
+			return nil, err
+		}
+		path, err := writeReconstructedBundleYAML(op.manifest, op.dst)
+		if err != nil {
+			return nil, err
+		}
+		loaded[config.BundleYAML] = path
+	}
 	return loaded, nil
 }
 
+// SignatureAlgorithm returns the algorithm recorded on the signature layer of the remote bundle's root manifest
+func (op *ociProvider) SignatureAlgorithm() (string, error) {
+	if err := op.getBundleManifest(); err != nil {
+		return "", err
+	}
+	return signatureAlgorithm(op.manifest), nil
+}
+
+// Annotations returns the annotations of the remote bundle's root manifest
+func (op *ociProvider) Annotations() (map[string]string, error) {
+	if err := op.getBundleManifest(); err != nil {
+		return nil, err
+	}
+	return op.manifest.Annotations, nil
+}
+
+// PackageTags returns the package tags annotated on the layers of the remote bundle's root manifest
+func (op *ociProvider) PackageTags() (map[digest.Digest]string, error) {
+	if err := op.getBundleManifest(); err != nil {
+		return nil, err
+	}
+	return packageTagsFromManifest(op.manifest), nil
+}
+
 // CreateBundleSBOM creates a bundle-level SBOM from the underlying Zarf packages, if the Zarf package contains an SBOM
 func (op *ociProvider) CreateBundleSBOM(extractSBOM bool) error {
 	SBOMArtifactPathMap := make(PathMap)
@@ -161,8 +259,10 @@ func (op *ociProvider) CreateBundleSBOM(extractSBOM bool) error {
 		if sbomDesc.Annotations == nil {
 			message.Warnf("%s not found in Zarf pkg: %s", config.SBOMsTar, zarfYAML.Metadata.Name)
 		}
-		// grab sboms.tar and extract
-		sbomBytes, err := op.OrasRemote.FetchLayer(sbomDesc)
+		// grab sboms.tar and extract, packages inspected before are read from the SBOM cache
+		sbomBytes, err := utils.CachedSBOMsTar(sbomCacheDir(), sbomDesc, func() ([]byte, error) {
+			return op.OrasRemote.FetchLayer(sbomDesc)
+		})
 		if err != nil {
 			return err
 		}
@@ -215,12 +315,23 @@ func (op *ociProvider) LoadBundle(_ int) (PathMap, error) {
 		return nil, err
 	}
 
+	// layers pulled from a package's own repository rather than the bundle's
+	layerRepos := make(map[digest.Digest]*remote.Repository)
+
 	for _, pkg := range bundle.ZarfPackages {
-		sha := strings.Split(pkg.Ref, "@sha256:")[1] // this is where we use the SHA appended to the Zarf pkg inside the bundle
+		pin, err := pinnedDigest(pkg) // this is where we use the SHA appended to the Zarf pkg inside the bundle
+		if err != nil {
+			return nil, err
+		}
+		sha := pin.Encoded()
 		manifestDesc := op.manifest.Locate(sha)
 		if err != nil {
 			return nil, err
 		}
+		layerRepo, err := op.packageLayerRepo(manifestDesc)
+		if err != nil {
+			return nil, err
+		}
 		manifestBytes, err := op.FetchLayer(manifestDesc)
 		if err != nil {
 			return nil, err
@@ -231,16 +342,24 @@ func (op *ociProvider) LoadBundle(_ int) (PathMap, error) {
 		}
 		layersToPull = append(layersToPull, manifestDesc)
 		for _, layer := range manifest.Layers {
-			ok, err := op.Repo().Blobs().Exists(op.ctx, layer)
+			ok, err := layerRepo.Blobs().Exists(op.ctx, layer)
 			if err != nil {
 				return nil, err
 			}
 			if ok {
 				layersToPull = append(layersToPull, layer)
+				if layerRepo != op.Repo() {
+					layerRepos[layer.Digest] = layerRepo
+				}
 			}
 		}
 	}
 
+	// files attached with --attach
+	for _, name := range attachmentNames(op.manifest) {
+		layersToPull = append(layersToPull, op.manifest.Locate(name))
+	}
+
 	store, err := ocistore.NewWithContext(op.ctx, op.dst)
 	if err != nil {
 		return nil, err
@@ -256,8 +375,12 @@ func (op *ociProvider) LoadBundle(_ int) (PathMap, error) {
 	// would like to use oci.CopyWithProgress here but it breaks when the media type of the image manifest is a Zarf blob
 	for _, layer := range layersToPull {
 		spinner.Updatef(fmt.Sprintf("Pulling bundle layer: %s", layer.Digest.Encoded()))
-		if ok, _ := op.Repo().Exists(op.ctx, layer); ok {
-			lb, err := op.Repo().Fetch(op.ctx, layer)
+		repo := op.Repo()
+		if layerRepo, ok := layerRepos[layer.Digest]; ok {
+			repo = layerRepo
+		}
+		if ok, _ := repo.Exists(op.ctx, layer); ok {
+			lb, err := repo.Fetch(op.ctx, layer)
 			if err != nil {
 				return nil, err
 			}
@@ -277,16 +400,68 @@ func (op *ociProvider) LoadBundle(_ int) (PathMap, error) {
 	spinner.Stop()
 
 	for _, layer := range layersToPull {
-		sha := layer.Digest.Encoded()
-		loaded[sha] = filepath.Join(op.dst, config.BlobsDir, sha)
+		loaded[layer.Digest.Encoded()] = filepath.Join(op.dst, utils.BlobPath(layer.Digest))
 	}
 	loaded["index.json"] = filepath.Join(op.dst, "index.json")
 
 	return loaded, nil
 }
 
-func (op *ociProvider) PublishBundle(_ types.UDSBundle, _ *oci.OrasRemote) error {
+func (op *ociProvider) PublishBundle(_ types.UDSBundle, _ *oci.OrasRemote, _, _ string) error {
 	// todo: implement moving bundles from one registry to another
 	message.Warnf("moving bundles in between remote registries not yet supported")
 	return nil
 }
+
+// VerifyLayers fetches every layer of the remote bundle and its packages and checks it against its digest
+func (op *ociProvider) VerifyLayers() (int, error) {
+	if err := op.getBundleManifest(); err != nil {
+		return 0, err
+	}
+	verified := make(map[digest.Digest]bool)
+	verify := func(repo *remote.Repository, desc ocispec.Descriptor) error {
+		if verified[desc.Digest] {
+			return nil
+		}
+		rc, err := repo.Blobs().Fetch(op.ctx, desc)
+		if err != nil {
+			return fmt.Errorf("layer %s: %w", desc.Digest, err)
+		}
+		defer rc.Close()
+		if err := verifyBlob(rc, desc); err != nil {
+			return err
+		}
+		verified[desc.Digest] = true
+		return nil
+	}
+
+	if err := verify(op.Repo(), op.manifest.Config); err != nil {
+		return 0, err
+	}
+	for _, layer := range op.manifest.Layers {
+		if err := verify(op.Repo(), layer); err != nil {
+			return 0, err
+		}
+		if layer.MediaType != ocispec.MediaTypeImageManifest {
+			continue
+		}
+		pkgManifest, err := op.LoadPackageManifest(layer.Digest.Encoded())
+		if err != nil {
+			return 0, err
+		}
+		layerRepo, err := op.packageLayerRepo(layer)
+		if err != nil {
+			return 0, err
+		}
+		for _, pkgLayer := range append([]ocispec.Descriptor{pkgManifest.Config}, pkgManifest.Layers...) {
+			// the package's manifest lists the layers of optional components that weren't bundled
+			if ok, _ := layerRepo.Blobs().Exists(op.ctx, pkgLayer); !ok {
+				continue
+			}
+			if err := verify(layerRepo, pkgLayer); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(verified), nil
+}
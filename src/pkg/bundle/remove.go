@@ -20,8 +20,12 @@ import (
 // should this support some form of `--components`?
 func (b *Bundler) Remove() error {
 	ctx := context.TODO()
+	source, err := b.fetchSource(b.cfg.RemoveOpts.Source)
+	if err != nil {
+		return err
+	}
 	// create a new provider
-	provider, err := NewBundleProvider(ctx, b.cfg.RemoveOpts.Source, b.tmp)
+	provider, err := NewBundleProvider(ctx, source, b.tmp)
 	if err != nil {
 		return err
 	}
@@ -36,11 +40,21 @@ func (b *Bundler) Remove() error {
 	if err := utils.ReadYaml(loaded[config.BundleYAML], &b.bundle); err != nil {
 		return err
 	}
+	if err := requireBundleYAML(&b.bundle, "remove"); err != nil {
+		return err
+	}
 
-	// remove in reverse order
-	for i := len(b.bundle.ZarfPackages) - 1; i >= 0; i-- {
-		pkg := b.bundle.ZarfPackages[i]
+	// remove in reverse deploy order
+	packages, err := sortPackagesByDependencies(b.bundle.ZarfPackages)
+	if err != nil {
+		return err
+	}
+	for i := len(packages) - 1; i >= 0; i-- {
+		pkg := packages[i]
 		name := pkg.Name
+		if b.cfg.RemoveOpts.NamespacePrefix != "" {
+			name = prefixedPackageName(name, b.cfg.RemoveOpts.NamespacePrefix)
+		}
 		pkgTmp, err := utils.MakeTempDir()
 		if err != nil {
 			return err
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
+	"oras.land/oras-go/v2/content"
+)
+
+// sizeReportBundleSource attributes bundle-level layers (uds-bundle.yaml, attachments, etc) in a size report
+const sizeReportBundleSource = "(bundle)"
+
+// sizeReportLayer is a single blob in a bundle and the packages that contributed it
+type sizeReportLayer struct {
+	Digest    string   `json:"digest"`
+	MediaType string   `json:"mediaType"`
+	Title     string   `json:"title,omitempty"`
+	Size      int64    `json:"size"`
+	Packages  []string `json:"packages"`
+}
+
+// sizeReport is the per-layer breakdown printed by create --size-report
+type sizeReport struct {
+	Layers       []*sizeReportLayer `json:"layers"`
+	TotalSize    int64              `json:"totalSize"`
+	DedupSavings int64              `json:"dedupSavings"`
+	byDigest     map[digest.Digest]*sizeReportLayer
+}
+
+// validateSizeReportFormat ensures --size-report is either empty, table or json
+func validateSizeReportFormat(format string) error {
+	switch format {
+	case "", config.SizeReportTable, config.SizeReportJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid --size-report format %q, must be one of: %s, %s", format, config.SizeReportTable, config.SizeReportJSON)
+	}
+}
+
+func newSizeReport() *sizeReport {
+	return &sizeReport{byDigest: make(map[digest.Digest]*sizeReportLayer)}
+}
+
+// add records the layers contributed by source, blobs that are already in the report count towards the dedup savings
+func (r *sizeReport) add(source string, descs ...ocispec.Descriptor) {
+	for _, desc := range descs {
+		if layer, ok := r.byDigest[desc.Digest]; ok {
+			r.DedupSavings += desc.Size
+			if !helpers.SliceContains(layer.Packages, source) {
+				layer.Packages = append(layer.Packages, source)
+			}
+			continue
+		}
+		layer := &sizeReportLayer{
+			Digest:    desc.Digest.String(),
+			MediaType: desc.MediaType,
+			Title:     desc.Annotations[ocispec.AnnotationTitle],
+			Size:      desc.Size,
+			Packages:  []string{source},
+		}
+		r.byDigest[desc.Digest] = layer
+		r.Layers = append(r.Layers, layer)
+		r.TotalSize += desc.Size
+	}
+}
+
+// addPackageManifest records a Zarf package's manifest along with its config and layers
+func (r *sizeReport) addPackageManifest(ctx context.Context, store content.Fetcher, source string, manifestDesc ocispec.Descriptor) error {
+	manifestBytes, err := content.FetchAll(ctx, store, manifestDesc)
+	if err != nil {
+		return err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return err
+	}
+	r.add(source, manifestDesc, manifest.Config)
+	r.add(source, manifest.Layers...)
+	return nil
+}
+
+// sort orders the report's layers by size, largest first
+func (r *sizeReport) sort() {
+	sort.SliceStable(r.Layers, func(i, j int) bool {
+		if r.Layers[i].Size == r.Layers[j].Size {
+			return r.Layers[i].Digest < r.Layers[j].Digest
+		}
+		return r.Layers[i].Size > r.Layers[j].Size
+	})
+}
+
+// print writes the report to stdout as a table or JSON
+func (r *sizeReport) print(format string) error {
+	r.sort()
+
+	if format == config.SizeReportJSON {
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	table := pterm.TableData{{"Size", "Media Type", "Packages", "Title", "Digest"}}
+	for _, layer := range r.Layers {
+		table = append(table, []string{
+			utils.ByteFormat(float64(layer.Size), 2),
+			layer.MediaType,
+			strings.Join(layer.Packages, ", "),
+			layer.Title,
+			digest.Digest(layer.Digest).Encoded()[:12],
+		})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(table).Render(); err != nil {
+		return err
+	}
+	pterm.Printfln("Total size: %s, saved %s by deduplicating layers",
+		utils.ByteFormat(float64(r.TotalSize), 2), utils.ByteFormat(float64(r.DedupSavings), 2))
+	return nil
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func Test_sizeReport(t *testing.T) {
+	layer := func(name string, size int64) ocispec.Descriptor {
+		return ocispec.Descriptor{
+			MediaType:   "application/vnd.zarf.layer.v1.blob",
+			Digest:      digest.FromString(name),
+			Size:        size,
+			Annotations: map[string]string{ocispec.AnnotationTitle: name},
+		}
+	}
+	shared := layer("images/registry", 300)
+
+	report := newSizeReport()
+	report.add("init", layer("zarf.yaml", 10), shared)
+	report.add("podinfo", layer("components/podinfo.tar", 200), shared)
+	report.add("nginx", shared)
+	report.sort()
+
+	if report.TotalSize != 510 {
+		t.Errorf("TotalSize = %d, want 510", report.TotalSize)
+	}
+	if report.DedupSavings != 600 {
+		t.Errorf("DedupSavings = %d, want 600", report.DedupSavings)
+	}
+
+	var titles []string
+	for _, l := range report.Layers {
+		titles = append(titles, l.Title)
+	}
+	if want := []string{"images/registry", "components/podinfo.tar", "zarf.yaml"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("layers sorted by size = %v, want %v", titles, want)
+	}
+	if want := []string{"init", "podinfo", "nginx"}; !reflect.DeepEqual(report.Layers[0].Packages, want) {
+		t.Errorf("shared layer packages = %v, want %v", report.Layers[0].Packages, want)
+	}
+}
+
+func Test_sizeReportRemotePackages(t *testing.T) {
+	ctx := context.TODO()
+	store := memory.New()
+	push := func(mediaType string, data []byte) ocispec.Descriptor {
+		desc, err := pushBlob(ctx, store, mediaType, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	shared := push(ocispec.MediaTypeImageLayer, []byte("shared image layer"))
+	pushPackage := func(name string) ocispec.Descriptor {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    push(ocispec.MediaTypeImageConfig, []byte(name+" config")),
+			Layers:    []ocispec.Descriptor{push(ocispec.MediaTypeImageLayer, []byte(name+" zarf.yaml")), shared},
+		}
+		manifest.SchemaVersion = 2
+		b, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return push(ocispec.MediaTypeImageManifest, b)
+	}
+
+	// the second remote package's pushed layers wouldn't include the shared layer, it was already in the store
+	report := newSizeReport()
+	for _, name := range []string{"podinfo", "nginx"} {
+		if err := report.addPackageManifest(ctx, store, name, pushPackage(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if report.DedupSavings != shared.Size {
+		t.Errorf("DedupSavings = %d, want %d", report.DedupSavings, shared.Size)
+	}
+	layer, ok := report.byDigest[shared.Digest]
+	if !ok {
+		t.Fatal("shared layer missing from the report")
+	}
+	if want := []string{"podinfo", "nginx"}; !reflect.DeepEqual(layer.Packages, want) {
+		t.Errorf("shared layer packages = %v, want %v", layer.Packages, want)
+	}
+}
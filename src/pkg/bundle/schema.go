@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/jsonschema"
+	"github.com/corang/uds-cli/src/types"
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// schemaError is a single violation of the uds-bundle.yaml schema
+type schemaError struct {
+	Line        int
+	Field       string
+	Description string
+}
+
+func (e schemaError) String() string {
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Description)
+}
+
+// ValidateBundleSchema validates a uds-bundle.yaml against the schema generated from types.UDSBundle
+//
+// unlike unmarshalling, this catches unknown (ie. misspelled) keys, wrong types and missing required fields
+func ValidateBundleSchema(path string) error {
+	bundleYAML, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	schemaErrs, err := validateBundleSchema(bundleYAML)
+	if err != nil {
+		return err
+	}
+	if len(schemaErrs) == 0 {
+		return nil
+	}
+	msgs := []string{fmt.Sprintf("%s does not match the UDSBundle schema:", path)}
+	for _, schemaErr := range schemaErrs {
+		msgs = append(msgs, " - "+schemaErr.String())
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// validateBundleSchema returns the schema violations in bundleYAML, each with the line it occurs on
+func validateBundleSchema(bundleYAML []byte) ([]schemaError, error) {
+	schemaBytes, err := json.Marshal(jsonschema.Reflect(&types.UDSBundle{}))
+	if err != nil {
+		return nil, err
+	}
+	bundleJSON, err := goyaml.YAMLToJSON(bundleYAML)
+	if err != nil {
+		return nil, err
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schemaBytes), gojsonschema.NewBytesLoader(bundleJSON))
+	if err != nil {
+		return nil, err
+	}
+	if result.Valid() {
+		return nil, nil
+	}
+
+	file, err := parser.ParseBytes(bundleYAML, 0)
+	if err != nil {
+		return nil, err
+	}
+	var schemaErrs []schemaError
+	for _, resultErr := range result.Errors() {
+		field := resultErr.Field()
+		// point at the offending key itself rather than the object containing it
+		if resultErr.Type() == "additional_property_not_allowed" {
+			if property, ok := resultErr.Details()["property"].(string); ok {
+				field = strings.TrimPrefix(field+"."+property, gojsonschema.STRING_CONTEXT_ROOT+".")
+			}
+		}
+		schemaErrs = append(schemaErrs, schemaError{
+			Line:        lineOfField(file, field),
+			Field:       field,
+			Description: resultErr.Description(),
+		})
+	}
+	return schemaErrs, nil
+}
+
+// lineOfField finds the line of a gojsonschema field (ie. zarf-packages.0.name) in the parsed YAML, defaulting to the first line
+func lineOfField(file *ast.File, field string) int {
+	if field == gojsonschema.STRING_CONTEXT_ROOT {
+		return 1
+	}
+	parts := strings.Split(field, ".")
+	parent := "$"
+	for _, part := range parts[:len(parts)-1] {
+		parent += yamlPathElement(part)
+	}
+	node := filterYAMLPath(file, parent+yamlPathElement(parts[len(parts)-1]))
+	if node == nil {
+		return 1
+	}
+
+	// the key's line, a nested value (ie. a list) starts on the line after its key
+	key := parts[len(parts)-1]
+	if mapping := filterYAMLPath(file, parent); mapping != nil {
+		for _, value := range mappingValues(mapping) {
+			if value.Key.GetToken().Value == key {
+				return value.Key.GetToken().Position.Line
+			}
+		}
+	}
+	return node.GetToken().Position.Line
+}
+
+// yamlPathElement converts a gojsonschema field element into a goccy/go-yaml path element
+func yamlPathElement(part string) string {
+	if _, err := strconv.Atoi(part); err == nil {
+		return "[" + part + "]"
+	}
+	return "." + part
+}
+
+// filterYAMLPath returns the node at yamlPath, or nil if it doesn't exist
+func filterYAMLPath(file *ast.File, yamlPath string) ast.Node {
+	if yamlPath == "$" {
+		if len(file.Docs) == 0 {
+			return nil
+		}
+		return file.Docs[0].Body
+	}
+	path, err := goyaml.PathString(yamlPath)
+	if err != nil {
+		return nil
+	}
+	node, err := path.FilterFile(file)
+	if err != nil {
+		return nil
+	}
+	return node
+}
+
+// mappingValues returns the key/value pairs of a YAML mapping
+func mappingValues(node ast.Node) []*ast.MappingValueNode {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		return n.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_validateBundleSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		bundleYAML  string
+		want        []schemaError
+	}{
+		{
+			name:        "Valid",
+			description: "a valid bundle has no schema errors",
+			bundleYAML: `kind: UDSBundle
+metadata:
+  name: example
+  version: 0.0.1
+zarf-packages:
+  - name: podinfo
+    repository: localhost:5000/podinfo
+    ref: 0.0.1
+`,
+		}, {
+			name:        "UnknownKey",
+			description: "misspelled keys are reported on their own line",
+			bundleYAML: `kind: UDSBundle
+metadata:
+  name: example
+  version: 0.0.1
+zarf-packages:
+  - name: podinfo
+    repository: localhost:5000/podinfo
+    ref: 0.0.1
+    optional-component:
+      - podinfo
+`,
+			want: []schemaError{{Line: 9, Field: "zarf-packages.0.optional-component", Description: "Additional property optional-component is not allowed"}},
+		}, {
+			name:        "MissingRequired",
+			description: "missing required fields are reported on the object missing them",
+			bundleYAML: `kind: UDSBundle
+metadata:
+  name: example
+  version: 0.0.1
+zarf-packages:
+  - name: podinfo
+    repository: localhost:5000/podinfo
+`,
+			want: []schemaError{{Line: 6, Field: "zarf-packages.0", Description: "ref is required"}},
+		}, {
+			name:        "WrongType",
+			description: "values of the wrong type are reported",
+			bundleYAML: `kind: UDSBundle
+metadata:
+  name: example
+  version: 0.0.1
+zarf-packages:
+  - name: podinfo
+    repository: localhost:5000/podinfo
+    ref: 0.0.1
+    optional-components: podinfo
+`,
+			want: []schemaError{{Line: 9, Field: "zarf-packages.0.optional-components", Description: "Invalid type. Expected: array, given: string"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateBundleSchema([]byte(tt.bundleYAML))
+			if err != nil {
+				t.Fatalf("validateBundleSchema() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateBundleSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
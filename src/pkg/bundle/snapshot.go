@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+)
+
+// snapshotSuffix is the extension of the file mapping a bundle's logical name to its --snapshot tarball
+const snapshotSuffix = ".snapshot.json"
+
+// bundleSnapshot maps a bundle's name, version and architecture to the content-addressed tarball --snapshot wrote
+type bundleSnapshot struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Architecture string `json:"architecture"`
+	Tarball      string `json:"tarball"`
+	Sha256       string `json:"sha256"`
+}
+
+// snapshotTarballName returns the content-addressed name of a bundle tarball with the given sha256, keeping the
+// extension of an encrypted tarball
+func snapshotTarballName(sha string, encrypted bool) string {
+	name := config.BundlePrefix + sha + ".tar.zst"
+	if encrypted {
+		name += utils.EncryptedSuffix
+	}
+	return name
+}
+
+// snapshotMappingName returns the name of the file mapping a bundle's logical name to its --snapshot tarball
+func snapshotMappingName(metadata *types.UDSMetadata) string {
+	return strings.TrimSuffix(bundleTarballName(metadata), ".tar.zst") + snapshotSuffix
+}
+
+// snapshotTarball renames the bundle tarball at tarballPath to its content-addressed name and writes the mapping from
+// the bundle's logical name to it, returning the new path. A snapshot with the same name already holds the identical
+// bundle, so the new tarball is removed in favor of it
+func snapshotTarball(bundle *types.UDSBundle, tarballPath string) (string, error) {
+	sha, err := zarfUtils.GetSHA256OfFile(tarballPath)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(tarballPath)
+	dst := filepath.Join(dir, snapshotTarballName(sha, utils.IsEncrypted(tarballPath)))
+	if _, err := os.Stat(dst); err == nil && dst != tarballPath {
+		message.Infof("An identical bundle is already stored at %s", dst)
+		if err := os.Remove(tarballPath); err != nil {
+			return "", err
+		}
+	} else if err := os.Rename(tarballPath, dst); err != nil {
+		return "", err
+	}
+
+	snapshot := bundleSnapshot{
+		Name:         bundle.Metadata.Name,
+		Version:      bundle.Metadata.Version,
+		Architecture: bundle.Metadata.Architecture,
+		Tarball:      filepath.Base(dst),
+		Sha256:       sha,
+	}
+	snapshotBytes, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	mappingPath := filepath.Join(dir, snapshotMappingName(&bundle.Metadata))
+	if err := os.WriteFile(mappingPath, snapshotBytes, 0644); err != nil {
+		return "", err
+	}
+	message.Successf("Snapshot of %s %s (%s) is %s, mapped in %s", snapshot.Name, snapshot.Version, snapshot.Architecture, snapshot.Tarball, mappingPath)
+	return dst, nil
+}
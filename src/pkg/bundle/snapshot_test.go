@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_snapshotTarball(t *testing.T) {
+	bundle := &types.UDSBundle{Metadata: types.UDSMetadata{Name: "example", Version: "0.0.1", Architecture: "amd64"}}
+	contents := []byte("bundle")
+	sum := sha256.Sum256(contents)
+	sha := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name        string
+		description string
+		tarball     string
+		existing    bool
+		want        string
+	}{
+		{
+			name:        "Plain",
+			description: "the tarball is renamed to its sha256",
+			tarball:     "uds-bundle-example-amd64-0.0.1.tar.zst",
+			want:        "uds-bundle-" + sha + ".tar.zst",
+		},
+		{
+			name:        "Encrypted",
+			description: "encrypted tarballs keep their .age extension",
+			tarball:     "uds-bundle-example-amd64-0.0.1.tar.zst.age",
+			want:        "uds-bundle-" + sha + ".tar.zst.age",
+		},
+		{
+			name:        "Identical",
+			description: "an identical bundle already stored under the name is kept and the new tarball removed",
+			tarball:     "uds-bundle-example-amd64-0.0.1.tar.zst",
+			existing:    true,
+			want:        "uds-bundle-" + sha + ".tar.zst",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tarballPath := filepath.Join(dir, tt.tarball)
+			if err := os.WriteFile(tarballPath, contents, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, tt.want), contents, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := snapshotTarball(bundle, tarballPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != filepath.Join(dir, tt.want) {
+				t.Errorf("snapshotTarball() = %s, want %s (%s)", got, filepath.Join(dir, tt.want), tt.description)
+			}
+			if _, err := os.Stat(tarballPath); !os.IsNotExist(err) {
+				t.Errorf("snapshotTarball() left %s behind (%s)", tt.tarball, tt.description)
+			}
+
+			mappingBytes, err := os.ReadFile(filepath.Join(dir, "uds-bundle-example-amd64-0.0.1"+snapshotSuffix))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var mapping bundleSnapshot
+			if err := json.Unmarshal(mappingBytes, &mapping); err != nil {
+				t.Fatal(err)
+			}
+			want := bundleSnapshot{Name: "example", Version: "0.0.1", Architecture: "amd64", Tarball: tt.want, Sha256: sha}
+			if !reflect.DeepEqual(mapping, want) {
+				t.Errorf("snapshotTarball() mapped %+v, want %+v (%s)", mapping, want, tt.description)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+)
+
+const (
+	// SourceTypePath is the built-in source type of packages on the local filesystem, its url is the package's path
+	SourceTypePath = "path"
+	// SourceTypeRepository is the built-in source type of packages in an OCI registry, its url is the package's repository
+	SourceTypeRepository = "repository"
+)
+
+// SourceResolver resolves the source of a package in uds-bundle.yaml to where the package is bundled from
+//
+// Resolve returns the path of an unpacked package or of a directory holding the package's tarball (named like the
+// tarball of a package with a path), tmp is a directory the resolver can download or build the package into. It may
+// instead return the oci:// URL of a repository, the package is then copied from the registry like a package with a
+// repository
+type SourceResolver interface {
+	Resolve(pkg types.BundleZarfPackage, tmp string) (string, error)
+}
+
+// SourceResolverFunc is a func that implements SourceResolver
+type SourceResolverFunc func(pkg types.BundleZarfPackage, tmp string) (string, error)
+
+// Resolve calls f
+func (f SourceResolverFunc) Resolve(pkg types.BundleZarfPackage, tmp string) (string, error) {
+	return f(pkg, tmp)
+}
+
+var (
+	sourceResolversMu sync.RWMutex
+	sourceResolvers   = map[string]SourceResolver{
+		SourceTypePath: SourceResolverFunc(func(pkg types.BundleZarfPackage, _ string) (string, error) {
+			return pkg.Source.URL, nil
+		}),
+		SourceTypeRepository: SourceResolverFunc(func(pkg types.BundleZarfPackage, _ string) (string, error) {
+			return helpers.OCIURLPrefix + strings.TrimPrefix(pkg.Source.URL, helpers.OCIURLPrefix), nil
+		}),
+	}
+)
+
+// RegisterSourceResolver makes resolver handle the packages whose source has the given type, programs compiling
+// uds-cli in call it from an init func to add their own source types
+//
+// it panics if resolver is nil or the type already has a resolver
+func RegisterSourceResolver(sourceType string, resolver SourceResolver) {
+	sourceResolversMu.Lock()
+	defer sourceResolversMu.Unlock()
+	if resolver == nil {
+		panic("bundle: RegisterSourceResolver resolver is nil")
+	}
+	if _, ok := sourceResolvers[sourceType]; ok {
+		panic("bundle: RegisterSourceResolver called twice for source type " + sourceType)
+	}
+	sourceResolvers[sourceType] = resolver
+}
+
+// sourceResolver returns the resolver registered for sourceType
+func sourceResolver(sourceType string) (SourceResolver, error) {
+	sourceResolversMu.RLock()
+	defer sourceResolversMu.RUnlock()
+	if resolver, ok := sourceResolvers[sourceType]; ok {
+		return resolver, nil
+	}
+	registered := []string{}
+	for t := range sourceResolvers {
+		registered = append(registered, t)
+	}
+	sort.Strings(registered)
+	return nil, fmt.Errorf("unknown source type %q, must be one of: %s", sourceType, strings.Join(registered, ", "))
+}
+
+// resolvePackageSources resolves each package with a source through the resolver registered for its type, after
+// which it's bundled like a package with a path or a repository
+//
+// the returned func removes the resolvers' tmp dirs, it must only be called once the bundle has been written
+func (b *Bundler) resolvePackageSources() (func(), error) {
+	cleanup := func() {}
+	var sourceRoot string
+	for i, pkg := range b.bundle.ZarfPackages {
+		if pkg.Source == nil {
+			continue
+		}
+		if pkg.Repository != "" || pkg.Path != "" || pkg.Git != "" {
+			return cleanup, fmt.Errorf("zarf pkg %s cannot have both a source and a repository, path or git field", pkg.Name)
+		}
+		if pkg.Source.Type == "" {
+			return cleanup, fmt.Errorf("zarf pkg %s is missing required field: source.type", pkg.Name)
+		}
+		resolver, err := sourceResolver(pkg.Source.Type)
+		if err != nil {
+			return cleanup, fmt.Errorf("zarf pkg %s: %w", pkg.Name, err)
+		}
+
+		if sourceRoot == "" {
+			if sourceRoot, err = zarfUtils.MakeTempDir(); err != nil {
+				return cleanup, err
+			}
+			root := sourceRoot
+			cleanup = func() { _ = os.RemoveAll(root) }
+		}
+		tmp := filepath.Join(sourceRoot, pkg.Name)
+		if err := zarfUtils.CreateDirectory(tmp, 0700); err != nil {
+			return cleanup, err
+		}
+
+		spinner := message.NewProgressSpinner("Resolving %s source of zarf pkg %s", pkg.Source.Type, pkg.Name)
+		resolved, err := resolver.Resolve(pkg, tmp)
+		if err != nil {
+			spinner.Stop()
+			return cleanup, fmt.Errorf("unable to resolve the %s source of zarf pkg %s: %w", pkg.Source.Type, pkg.Name, err)
+		}
+		if resolved == "" {
+			spinner.Stop()
+			return cleanup, fmt.Errorf("the %s source of zarf pkg %s resolved to an empty path", pkg.Source.Type, pkg.Name)
+		}
+		if helpers.IsOCIURL(resolved) {
+			b.bundle.ZarfPackages[i].Repository = strings.TrimPrefix(resolved, helpers.OCIURLPrefix)
+		} else {
+			b.bundle.ZarfPackages[i].Path = resolved
+		}
+		b.bundle.ZarfPackages[i].Source = nil
+		spinner.Successf("Resolved zarf pkg %s to %s", pkg.Name, resolved)
+	}
+	return cleanup, nil
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_resolvePackageSources(t *testing.T) {
+	RegisterSourceResolver("test-tmp", SourceResolverFunc(func(pkg types.BundleZarfPackage, tmp string) (string, error) {
+		return filepath.Join(tmp, pkg.Source.Options["dir"]), nil
+	}))
+	RegisterSourceResolver("test-fail", SourceResolverFunc(func(types.BundleZarfPackage, string) (string, error) {
+		return "", errors.New("unreachable")
+	}))
+
+	tests := []struct {
+		name           string
+		description    string
+		pkg            types.BundleZarfPackage
+		wantPath       string
+		wantRepository string
+		wantErr        bool
+	}{
+		{
+			name:        "Path",
+			description: "the built-in path source resolves to its url",
+			pkg:         types.BundleZarfPackage{Name: "app", Source: &types.PackageSource{Type: SourceTypePath, URL: "./packages"}},
+			wantPath:    "./packages",
+		},
+		{
+			name:           "Repository",
+			description:    "the built-in repository source resolves to a repository, with or without oci://",
+			pkg:            types.BundleZarfPackage{Name: "app", Source: &types.PackageSource{Type: SourceTypeRepository, URL: "oci://ghcr.io/my-org/app"}},
+			wantRepository: "ghcr.io/my-org/app",
+		},
+		{
+			name:        "Registered",
+			description: "registered resolvers are given the package and a tmp dir of their own",
+			pkg:         types.BundleZarfPackage{Name: "app", Source: &types.PackageSource{Type: "test-tmp", Options: map[string]string{"dir": "built"}}},
+			wantPath:    filepath.Join("app", "built"),
+		},
+		{
+			name:        "Unknown",
+			description: "source types without a resolver are rejected",
+			pkg:         types.BundleZarfPackage{Name: "app", Source: &types.PackageSource{Type: "helm"}},
+			wantErr:     true,
+		},
+		{
+			name:        "ResolverFails",
+			description: "resolver errors fail the create",
+			pkg:         types.BundleZarfPackage{Name: "app", Source: &types.PackageSource{Type: "test-fail"}},
+			wantErr:     true,
+		},
+		{
+			name:        "SourceAndPath",
+			description: "a package can't have a source and a path",
+			pkg:         types.BundleZarfPackage{Name: "app", Path: "./packages", Source: &types.PackageSource{Type: SourceTypePath}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bundler{bundle: types.UDSBundle{ZarfPackages: []types.BundleZarfPackage{tt.pkg}}}
+			cleanup, err := b.resolvePackageSources()
+			defer cleanup()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePackageSources() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := b.bundle.ZarfPackages[0]
+			// registered resolvers build into their tmp dir, which is named after the package
+			if (got.Path != tt.wantPath && !strings.HasSuffix(got.Path, string(filepath.Separator)+tt.wantPath)) || got.Repository != tt.wantRepository || got.Source != nil {
+				t.Errorf("resolvePackageSources() = path %q, repository %q, source %v, want %q, %q, no source (%s)", got.Path, got.Repository, got.Source, tt.wantPath, tt.wantRepository, tt.description)
+			}
+		})
+	}
+}
+
+func Test_RegisterSourceResolverTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterSourceResolver() didn't panic for a source type that already has a resolver")
+		}
+	}()
+	RegisterSourceResolver(SourceTypePath, SourceResolverFunc(func(types.BundleZarfPackage, string) (string, error) {
+		return "", nil
+	}))
+}
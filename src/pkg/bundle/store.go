@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// StoreBackendLocal is the default store backend, bundles are built in the bundler's temporary directory
+	StoreBackendLocal = "local"
+	// StoreBackendDir is the built-in store backend that builds bundles under a directory, ie. a mounted object store or
+	// network share, its location is the directory
+	StoreBackendDir = "dir"
+)
+
+// StoreBackend provides the OCI layout a bundle tarball is built in before it's archived
+//
+// Open returns the directory of an empty OCI layout for the bundle, location is what follows the backend's type in
+// --store-backend and tmp is the bundler's temporary directory. Every blob of the bundle is pushed there and the
+// tarball is written from its files, so the directory must be readable as a filesystem (a remote store has to be
+// mounted). The returned func removes the layout, it's called once the tarball has been written
+type StoreBackend interface {
+	Open(location, tmp string) (string, func(), error)
+}
+
+// StoreBackendFunc is a func that implements StoreBackend
+type StoreBackendFunc func(location, tmp string) (string, func(), error)
+
+// Open calls f
+func (f StoreBackendFunc) Open(location, tmp string) (string, func(), error) {
+	return f(location, tmp)
+}
+
+var (
+	storeBackendsMu sync.RWMutex
+	storeBackends   = map[string]StoreBackend{
+		StoreBackendLocal: StoreBackendFunc(func(location, tmp string) (string, func(), error) {
+			if location != "" {
+				return "", nil, fmt.Errorf("the %s store backend doesn't take a location, got %q", StoreBackendLocal, location)
+			}
+			// tmp is removed with the rest of the bundler's paths
+			return tmp, func() {}, nil
+		}),
+		StoreBackendDir: StoreBackendFunc(openDirStore),
+	}
+)
+
+// RegisterStoreBackend makes backend provide the store of bundles created with --store-backend <backendType>, programs
+// compiling uds-cli in call it from an init func to add their own backends
+//
+// it panics if backend is nil or the type already has a backend
+func RegisterStoreBackend(backendType string, backend StoreBackend) {
+	storeBackendsMu.Lock()
+	defer storeBackendsMu.Unlock()
+	if backend == nil {
+		panic("bundle: RegisterStoreBackend backend is nil")
+	}
+	if _, ok := storeBackends[backendType]; ok {
+		panic("bundle: RegisterStoreBackend called twice for backend type " + backendType)
+	}
+	storeBackends[backendType] = backend
+}
+
+// parseStoreBackend splits --store-backend into the backend registered for its type and its location (<type>[:<location>])
+func parseStoreBackend(flag string) (StoreBackend, string, error) {
+	backendType, location, _ := strings.Cut(flag, ":")
+	if backendType == "" {
+		backendType = StoreBackendLocal
+	}
+	storeBackendsMu.RLock()
+	defer storeBackendsMu.RUnlock()
+	if backend, ok := storeBackends[backendType]; ok {
+		return backend, location, nil
+	}
+	registered := []string{}
+	for t := range storeBackends {
+		registered = append(registered, t)
+	}
+	sort.Strings(registered)
+	return nil, "", fmt.Errorf("unknown --store-backend %q, must be one of: %s", backendType, strings.Join(registered, ", "))
+}
+
+// openDirStore makes a new directory under location for the bundle's OCI layout, so creates sharing a location don't
+// collide
+func openDirStore(location, _ string) (string, func(), error) {
+	if location == "" {
+		return "", nil, fmt.Errorf("the %s store backend requires a directory (ie. %s:/mnt/staging)", StoreBackendDir, StoreBackendDir)
+	}
+	if info, err := os.Stat(location); err != nil {
+		return "", nil, fmt.Errorf("unable to use %s as the bundle store: %w", location, err)
+	} else if !info.IsDir() {
+		return "", nil, fmt.Errorf("unable to use %s as the bundle store: it is not a directory", location)
+	}
+	dir, err := os.MkdirTemp(location, "uds-bundle-store-")
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to use %s as the bundle store: %w", location, err)
+	}
+	return dir, func() { _ = os.RemoveAll(dir) }, nil
+}
+
+// openStore opens the OCI layout the bundle tarball is built in through --store-backend, setting b.layout to its
+// directory
+func (b *Bundler) openStore() (func(), error) {
+	backend, location, err := parseStoreBackend(b.cfg.CreateOpts.StoreBackend)
+	if err != nil {
+		return func() {}, err
+	}
+	dir, cleanup, err := backend.Open(location, b.tmp)
+	if err != nil {
+		return func() {}, err
+	}
+	if cleanup == nil {
+		cleanup = func() {}
+	}
+	b.layout = dir
+	return cleanup, nil
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_openStore(t *testing.T) {
+	RegisterStoreBackend("test-staging", StoreBackendFunc(func(location, _ string) (string, func(), error) {
+		return location, nil, nil
+	}))
+	staging := t.TempDir()
+	notDir := filepath.Join(staging, "file")
+	if err := os.WriteFile(notDir, []byte("not a dir"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		description  string
+		storeBackend string
+		wantTmp      bool
+		wantLayout   string
+		wantParent   string
+		wantErr      bool
+	}{
+		{
+			name:        "Default",
+			description: "bundles are built in the bundler's tmp dir without --store-backend",
+			wantTmp:     true,
+		},
+		{
+			name:         "Local",
+			description:  "the local backend is the bundler's tmp dir",
+			storeBackend: StoreBackendLocal,
+			wantTmp:      true,
+		},
+		{
+			name:         "LocalLocation",
+			description:  "the local backend has no location to build in",
+			storeBackend: StoreBackendLocal + ":" + staging,
+			wantErr:      true,
+		},
+		{
+			name:         "Dir",
+			description:  "the dir backend builds in a new directory under its location",
+			storeBackend: StoreBackendDir + ":" + staging,
+			wantParent:   staging,
+		},
+		{
+			name:         "DirMissing",
+			description:  "the dir backend's location must exist",
+			storeBackend: StoreBackendDir + ":" + filepath.Join(staging, "missing"),
+			wantErr:      true,
+		},
+		{
+			name:         "DirNotDir",
+			description:  "the dir backend's location must be a directory",
+			storeBackend: StoreBackendDir + ":" + notDir,
+			wantErr:      true,
+		},
+		{
+			name:         "DirNoLocation",
+			description:  "the dir backend requires a location",
+			storeBackend: StoreBackendDir,
+			wantErr:      true,
+		},
+		{
+			name:         "Registered",
+			description:  "registered backends are given the rest of --store-backend as their location",
+			storeBackend: "test-staging:" + filepath.Join(staging, "mounted"),
+			wantLayout:   filepath.Join(staging, "mounted"),
+		},
+		{
+			name:         "Unknown",
+			description:  "backend types that aren't registered are rejected",
+			storeBackend: "s3:my-bucket",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bundler{cfg: &types.BundlerConfig{CreateOpts: types.BundlerCreateOptions{StoreBackend: tt.storeBackend}}, tmp: t.TempDir()}
+			cleanup, err := b.openStore()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openStore() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantTmp && b.layout != b.tmp {
+				t.Errorf("openStore() layout = %s, want the bundler's tmp dir %s (%s)", b.layout, b.tmp, tt.description)
+			}
+			if tt.wantLayout != "" && b.layout != tt.wantLayout {
+				t.Errorf("openStore() layout = %s, want %s (%s)", b.layout, tt.wantLayout, tt.description)
+			}
+			// a nil cleanup from a backend is replaced so it's always safe to call
+			cleanup()
+			if tt.wantParent != "" {
+				if filepath.Dir(b.layout) != tt.wantParent {
+					t.Errorf("openStore() layout = %s, want a directory under %s (%s)", b.layout, tt.wantParent, tt.description)
+				}
+				if _, err := os.Stat(b.layout); !os.IsNotExist(err) {
+					t.Errorf("openStore() cleanup left %s behind", b.layout)
+				}
+			}
+		})
+	}
+}
+
+func Test_RegisterStoreBackendTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterStoreBackend() didn't panic for a backend type that already has a backend")
+		}
+	}()
+	RegisterStoreBackend(StoreBackendDir, StoreBackendFunc(openDirStore))
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+)
+
+// tarballStdout is the --output that streams the bundle tarball to stdout
+const tarballStdout = "-"
+
+// isTarballStream returns true if output is stdout or a named pipe, which the bundle tarball is streamed to instead of
+// creating the bundle in a registry
+func isTarballStream(output string) bool {
+	if output == tarballStdout {
+		return true
+	}
+	info, err := os.Stat(output)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// validateTarballStream refuses the create options that need to seek in or reread the bundle tarball, a stream can
+// only be written once from start to end
+func validateTarballStream(opts *types.BundlerCreateOptions, stream string) error {
+	if opts.OutputDirectory != "" {
+		return fmt.Errorf("--output-dir can't be used when streaming the bundle tarball to %s", stream)
+	}
+	if opts.Snapshot {
+		return fmt.Errorf("--snapshot names the bundle tarball by its digest on disk and can't be used when streaming it to %s", stream)
+	}
+	if opts.SplitSize != "" {
+		return fmt.Errorf("--split-size splits a bundle tarball on disk and can't be used when streaming it to %s", stream)
+	}
+	if opts.SizeReport != "" && stream == tarballStdout {
+		return fmt.Errorf("--size-report prints to stdout and can't be used when streaming the bundle tarball to stdout")
+	}
+	return nil
+}
+
+// streamTarball writes the bundle tarball of a file map to stream in a single forward pass, encrypting it as it's
+// written with --encrypt
+func (b *Bundler) streamTarball(artifactPathMap PathMap) error {
+	var out io.Writer = os.Stdout
+	dst := "stdout"
+	if b.stream != tarballStdout {
+		// opening a named pipe blocks until its reader opens it
+		pipe, err := os.OpenFile(b.stream, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer pipe.Close()
+		out = pipe
+		dst = b.stream
+	}
+
+	limits, err := archiveLimitsFromOpts(&b.cfg.CreateOpts)
+	if err != nil {
+		return err
+	}
+	if !b.cfg.CreateOpts.Encrypt {
+		return archiveBundle(out, artifactPathMap, dst, limits)
+	}
+	encrypted, err := utils.EncryptWriter(out, b.cfg.CreateOpts.Recipients)
+	if err != nil {
+		return err
+	}
+	if err := archiveBundle(encrypted, artifactPathMap, dst, limits); err != nil {
+		return err
+	}
+	// Close flushes the final chunk, the stream is unreadable without it
+	return encrypted.Close()
+}
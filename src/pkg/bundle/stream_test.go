@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/mholt/archiver/v4"
+)
+
+func Test_isTarballStream(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "uds-bundle.tar.zst")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	fifo := filepath.Join(dir, "fifo")
+	haveFifo := exec.Command("mkfifo", fifo).Run() == nil
+
+	tests := []struct {
+		name        string
+		description string
+		output      string
+		want        bool
+		needsFifo   bool
+	}{
+		{
+			name:        "Stdout",
+			description: "- streams to stdout",
+			output:      tarballStdout,
+			want:        true,
+		},
+		{
+			name:        "NamedPipe",
+			description: "named pipes are streamed to",
+			output:      fifo,
+			want:        true,
+			needsFifo:   true,
+		},
+		{
+			name:        "RegularFile",
+			description: "regular files aren't streams",
+			output:      file,
+		},
+		{
+			name:        "OCI",
+			description: "oci:// URLs create the bundle in a registry",
+			output:      "oci://ghcr.io/org/bundles",
+		},
+		{
+			name:        "Empty",
+			description: "no --output creates a bundle tarball",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.needsFifo && !haveFifo {
+				t.Skip("mkfifo is unavailable")
+			}
+			if got := isTarballStream(tt.output); got != tt.want {
+				t.Errorf("isTarballStream(%q) = %v, want %v (%s)", tt.output, got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_validateTarballStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		opts        types.BundlerCreateOptions
+		stream      string
+		wantErr     bool
+	}{
+		{
+			name:        "Plain",
+			description: "a plain bundle tarball can be streamed",
+			stream:      tarballStdout,
+		},
+		{
+			name:        "Encrypt",
+			description: "encryption is applied as the stream is written",
+			opts:        types.BundlerCreateOptions{Encrypt: true, Recipients: []string{"age1"}},
+			stream:      tarballStdout,
+		},
+		{
+			name:        "SplitSize",
+			description: "splitting needs the whole tarball on disk",
+			opts:        types.BundlerCreateOptions{SplitSize: "4GB"},
+			stream:      "/tmp/fifo",
+			wantErr:     true,
+		},
+		{
+			name:        "Snapshot",
+			description: "snapshots are named by the digest of the tarball on disk",
+			opts:        types.BundlerCreateOptions{Snapshot: true},
+			stream:      tarballStdout,
+			wantErr:     true,
+		},
+		{
+			name:        "OutputDirectory",
+			description: "streams have no output directory",
+			opts:        types.BundlerCreateOptions{OutputDirectory: "out"},
+			stream:      "/tmp/fifo",
+			wantErr:     true,
+		},
+		{
+			name:        "SizeReportPipe",
+			description: "the size report is printed alongside a named pipe",
+			opts:        types.BundlerCreateOptions{SizeReport: "json"},
+			stream:      "/tmp/fifo",
+		},
+		{
+			name:        "SizeReportStdout",
+			description: "the size report would corrupt a stream to stdout",
+			opts:        types.BundlerCreateOptions{SizeReport: "json"},
+			stream:      tarballStdout,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTarballStream(&tt.opts, tt.stream); (err != nil) != tt.wantErr {
+				t.Errorf("validateTarballStream() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_archiveBundlePipe(t *testing.T) {
+	dir := t.TempDir()
+	paths := make(PathMap)
+	for _, name := range []string{"index.json", "oci-layout", "blobs/sha256/abc"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths[path] = name
+	}
+
+	// a pipe can't seek, the archive must be readable as it's written
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(archiveBundle(w, paths, "pipe", archiveLimits{}))
+	}()
+	format := archiver.CompressedArchive{Compression: archiver.Zstd{}, Archival: archiver.Tar{}}
+	got := []string{}
+	err := format.Extract(context.Background(), r, nil, func(_ context.Context, f archiver.File) error {
+		got = append(got, f.NameInArchive)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if want := []string{"blobs/sha256/abc", "index.json", "oci-layout"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("archiveBundle() wrote %v, want %v", got, want)
+	}
+}
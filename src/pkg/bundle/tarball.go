@@ -14,8 +14,6 @@ import (
 	"path/filepath"
 	"time"
 
-	"oras.land/oras-go/v2/content"
-
 	"github.com/corang/uds-cli/src/config"
 	"github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
@@ -25,6 +23,7 @@ import (
 	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
 	av3 "github.com/mholt/archiver/v3"
 	av4 "github.com/mholt/archiver/v4"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	ocistore "oras.land/oras-go/v2/content/oci"
 )
@@ -70,7 +69,7 @@ func (tp *tarballBundleProvider) CreateBundleSBOM(extractSBOM bool) error {
 		if len(layer.Annotations) != 0 {
 			continue
 		}
-		layerFilePath := filepath.Join(config.BlobsDir, layer.Digest.Encoded())
+		layerFilePath := utils.BlobPath(layer.Digest)
 		if err := av3.Extract(tp.src, layerFilePath, tp.dst); err != nil {
 			return fmt.Errorf("failed to extract %s from %s: %w", layer.Digest.Encoded(), tp.src, err)
 		}
@@ -85,13 +84,15 @@ func (tp *tarballBundleProvider) CreateBundleSBOM(extractSBOM bool) error {
 			return err
 		}
 
-		// find sbom layer descriptor and extract sbom tar from archive
+		// find sbom layer descriptor and extract sbom tar from archive, packages inspected before are read from the SBOM cache
 		sbomDesc := zarfImageManifest.Locate(config.SBOMsTar)
-		sbomFilePath := filepath.Join(config.BlobsDir, sbomDesc.Digest.Encoded())
-		if err := av3.Extract(tp.src, sbomFilePath, tp.dst); err != nil {
-			return fmt.Errorf("failed to extract %s from %s: %w", layer.Digest.Encoded(), tp.src, err)
-		}
-		sbomTarBytes, err := os.ReadFile(filepath.Join(tp.dst, sbomFilePath))
+		sbomTarBytes, err := utils.CachedSBOMsTar(sbomCacheDir(), sbomDesc, func() ([]byte, error) {
+			sbomFilePath := utils.BlobPath(sbomDesc.Digest)
+			if err := av3.Extract(tp.src, sbomFilePath, tp.dst); err != nil {
+				return nil, fmt.Errorf("failed to extract %s from %s: %w", layer.Digest.Encoded(), tp.src, err)
+			}
+			return os.ReadFile(filepath.Join(tp.dst, sbomFilePath))
+		})
 		if err != nil {
 			return err
 		}
@@ -143,14 +144,19 @@ func (tp *tarballBundleProvider) getBundleManifest() error {
 		return err
 	}
 
-	// due to logic during the bundle pull process, this index.json should only have one manifest
-	bundleManifestDesc := index.Manifests[0]
+	// due to logic during the bundle pull process, this index.json should only have one manifest besides the
+	// referrers pulled with it
+	manifests, _ := splitReferrers(index.Manifests)
+	if len(manifests) == 0 {
+		return fmt.Errorf("expected a manifest in index.json, found none")
+	}
+	bundleManifestDesc := manifests[0]
 
-	if len(index.Manifests) > 1 {
-		return fmt.Errorf("expected only one manifest in index.json, found %d", len(index.Manifests))
+	if len(manifests) > 1 {
+		return fmt.Errorf("expected only one manifest in index.json, found %d", len(manifests))
 	}
 
-	manifestRelativePath := filepath.Join(config.BlobsDir, bundleManifestDesc.Digest.Encoded())
+	manifestRelativePath := utils.BlobPath(bundleManifestDesc.Digest)
 
 	if err := av3.Extract(tp.src, manifestRelativePath, tp.dst); err != nil {
 		return fmt.Errorf("failed to extract %s from %s: %w", bundleManifestDesc.Digest.Encoded(), tp.src, err)
@@ -209,7 +215,11 @@ func (tp *tarballBundleProvider) LoadBundle(_ int) (PathMap, error) {
 	for _, layer := range tp.manifest.Layers {
 		if layer.MediaType == ocispec.MediaTypeImageManifest {
 			var manifest oci.ZarfOCIManifest
-			if err := format.Extract(tp.ctx, sourceArchive, []string{filepath.Join(config.BlobsDir, layer.Digest.Encoded())}, extractJSON(&manifest)); err != nil {
+			// each extract reads the archive to the end, rewind so every package manifest can be found
+			if _, err := sourceArchive.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			if err := format.Extract(tp.ctx, sourceArchive, []string{utils.BlobPath(layer.Digest)}, extractJSON(&manifest)); err != nil {
 				return nil, err
 			}
 			layersToExtract = append(layersToExtract, layer)
@@ -217,7 +227,7 @@ func (tp *tarballBundleProvider) LoadBundle(_ int) (PathMap, error) {
 		} else if layer.MediaType == oci.ZarfLayerMediaTypeBlob {
 			rel := layer.Annotations[ocispec.AnnotationTitle]
 			layersToExtract = append(layersToExtract, layer)
-			loaded[rel] = filepath.Join(tp.dst, config.BlobsDir, layer.Digest.Encoded())
+			loaded[rel] = filepath.Join(tp.dst, utils.BlobPath(layer.Digest))
 		}
 	}
 
@@ -235,13 +245,17 @@ func (tp *tarballBundleProvider) LoadBundle(_ int) (PathMap, error) {
 
 	pathsInArchive := []string{}
 	for _, layer := range layersToExtract {
-		sha := layer.Digest.Encoded()
-		if layer.MediaType == oci.ZarfLayerMediaTypeBlob {
-			pathsInArchive = append(pathsInArchive, filepath.Join(config.BlobsDir, sha))
-			loaded[sha] = filepath.Join(tp.dst, config.BlobsDir, sha)
+		// package layers keep the media type of their source package, anything that isn't a manifest is a blob
+		if layer.MediaType != ocispec.MediaTypeImageManifest {
+			pathsInArchive = append(pathsInArchive, utils.BlobPath(layer.Digest))
+			loaded[layer.Digest.Encoded()] = filepath.Join(tp.dst, utils.BlobPath(layer.Digest))
 		}
 	}
 
+	// the package manifests were read from the same archive, rewind before extracting their layers
+	if _, err := sourceArchive.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
 	if err := format.Extract(tp.ctx, sourceArchive, pathsInArchive, cacheFunc); err != nil {
 		return nil, err
 	}
@@ -251,7 +265,8 @@ func (tp *tarballBundleProvider) LoadBundle(_ int) (PathMap, error) {
 
 // LoadPackage loads a package from a tarball
 func (tp *tarballBundleProvider) LoadPackage(sha, destinationDir string, _ int) (PathMap, error) {
-	if err := tp.getBundleManifest(); err != nil {
+	manifestPath, err := tp.packageManifestPath(sha)
+	if err != nil {
 		return nil, err
 	}
 
@@ -267,7 +282,7 @@ func (tp *tarballBundleProvider) LoadPackage(sha, destinationDir string, _ int)
 
 	var manifest oci.ZarfOCIManifest
 
-	if err := format.Extract(tp.ctx, sourceArchive, []string{filepath.Join(config.BlobsDir, sha)}, extractJSON(&manifest)); err != nil {
+	if err := format.Extract(tp.ctx, sourceArchive, []string{manifestPath}, extractJSON(&manifest)); err != nil {
 		sourceArchive.Close()
 		return nil, err
 	}
@@ -321,8 +336,8 @@ func (tp *tarballBundleProvider) LoadPackage(sha, destinationDir string, _ int)
 	loaded := make(PathMap)
 
 	for _, layer := range manifest.Layers {
-		layersToExtract = append(layersToExtract, filepath.Join(config.BlobsDir, layer.Digest.Encoded()))
-		loaded[layer.Annotations[ocispec.AnnotationTitle]] = filepath.Join(destinationDir, config.BlobsDir, layer.Digest.Encoded())
+		layersToExtract = append(layersToExtract, utils.BlobPath(layer.Digest))
+		loaded[layer.Annotations[ocispec.AnnotationTitle]] = filepath.Join(destinationDir, utils.BlobPath(layer.Digest))
 	}
 
 	sourceArchive, err = os.Open(tp.src)
@@ -338,6 +353,73 @@ func (tp *tarballBundleProvider) LoadPackage(sha, destinationDir string, _ int)
 	return loaded, nil
 }
 
+// packageManifestPath returns the path in the bundle tarball of the manifest of a package in the bundle, by the
+// algorithm of its digest
+func (tp *tarballBundleProvider) packageManifestPath(sha string) (string, error) {
+	if err := tp.getBundleManifest(); err != nil {
+		return "", err
+	}
+	desc := tp.manifest.Locate(sha)
+	if oci.IsEmptyDescriptor(desc) {
+		return "", fmt.Errorf("package %s does not exist in this bundle", sha)
+	}
+	return utils.BlobPath(desc.Digest), nil
+}
+
+// LoadPackageManifest streams the manifest of a package out of the bundle tarball
+func (tp *tarballBundleProvider) LoadPackageManifest(sha string) (*oci.ZarfOCIManifest, error) {
+	manifestPath, err := tp.packageManifestPath(sha)
+	if err != nil {
+		return nil, err
+	}
+	var manifest oci.ZarfOCIManifest
+	if err := walkArchive(tp.ctx, tp.src, []string{manifestPath}, extractJSON(&manifest)); err != nil {
+		return nil, err
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("package %s does not exist in this bundle", sha)
+	}
+	return &manifest, nil
+}
+
+// FetchPackageLayers streams layers of a package out of the bundle tarball in a single pass over the archive
+func (tp *tarballBundleProvider) FetchPackageLayers(_ string, descs ...ocispec.Descriptor) (map[digest.Digest][]byte, error) {
+	pathsInArchive := []string{}
+	for _, desc := range descs {
+		pathsInArchive = append(pathsInArchive, utils.BlobPath(desc.Digest))
+	}
+	layers := make(map[digest.Digest][]byte)
+	err := walkArchive(tp.ctx, tp.src, pathsInArchive, func(_ context.Context, file av4.File) error {
+		if file.IsDir() {
+			return nil
+		}
+		dgst, err := blobDigest(file.NameInArchive)
+		if err != nil {
+			return err
+		}
+		stream, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		b, err := io.ReadAll(stream)
+		if err != nil {
+			return err
+		}
+		layers[dgst] = b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, desc := range descs {
+		if _, ok := layers[desc.Digest]; !ok {
+			return nil, fmt.Errorf("%s does not exist in %s", desc.Digest, tp.src)
+		}
+	}
+	return layers, nil
+}
+
 // LoadBundleMetadata loads a bundle's metadata from a tarball
 func (tp *tarballBundleProvider) LoadBundleMetadata() (PathMap, error) {
 	if err := tp.getBundleManifest(); err != nil {
@@ -350,7 +432,7 @@ func (tp *tarballBundleProvider) LoadBundleMetadata() (PathMap, error) {
 	for _, path := range pathsToExtract {
 		layer := tp.manifest.Locate(path)
 		if !oci.IsEmptyDescriptor(layer) {
-			pathInTarball := filepath.Join(config.BlobsDir, layer.Digest.Encoded())
+			pathInTarball := utils.BlobPath(layer.Digest)
 			abs := filepath.Join(tp.dst, pathInTarball)
 			loaded[path] = abs
 			if !zarfUtils.InvalidPath(abs) && zarfUtils.SHAsMatch(abs, layer.Digest.Encoded()) == nil {
@@ -361,17 +443,52 @@ func (tp *tarballBundleProvider) LoadBundleMetadata() (PathMap, error) {
 			}
 		}
 	}
+	// bundles built by other tools may only have annotations
+	if _, ok := loaded[config.BundleYAML]; !ok {
+		path, err := writeReconstructedBundleYAML(tp.manifest, tp.dst)
+		if err != nil {
+			return nil, err
+		}
+		loaded[config.BundleYAML] = path
+	}
 	return loaded, nil
 }
 
+// SignatureAlgorithm returns the algorithm recorded on the signature layer of the bundle tarball's root manifest
+func (tp *tarballBundleProvider) SignatureAlgorithm() (string, error) {
+	if err := tp.getBundleManifest(); err != nil {
+		return "", err
+	}
+	return signatureAlgorithm(tp.manifest), nil
+}
+
+// Annotations returns the annotations of the bundle tarball's root manifest
+func (tp *tarballBundleProvider) Annotations() (map[string]string, error) {
+	if err := tp.getBundleManifest(); err != nil {
+		return nil, err
+	}
+	return tp.manifest.Annotations, nil
+}
+
+// PackageTags returns the package tags annotated on the layers of the bundle tarball's root manifest
+func (tp *tarballBundleProvider) PackageTags() (map[digest.Digest]string, error) {
+	if err := tp.getBundleManifest(); err != nil {
+		return nil, err
+	}
+	return packageTagsFromManifest(tp.manifest), nil
+}
+
 func (tp *tarballBundleProvider) pushPackageLayersWithSpinner(spinner *message.Spinner, store *ocistore.Store, remote *oci.OrasRemote, pkgManifestDesc ocispec.Descriptor) error {
-	layerBytes, err := os.ReadFile(filepath.Join(tp.dst, config.BlobsDir, pkgManifestDesc.Digest.Encoded()))
+	layerBytes, err := os.ReadFile(filepath.Join(tp.dst, utils.BlobPath(pkgManifestDesc.Digest)))
 	if err != nil {
 		return err
 	}
 
 	// handle uds-bundle.yaml push
 	if pkgManifestDesc.Annotations != nil {
+		if utils.LayerExists(remote.Repo(), pkgManifestDesc) {
+			return nil
+		}
 		spinner.Updatef("Pushing uds.bundle.yaml")
 		err = remote.Repo().Push(tp.ctx, pkgManifestDesc, bytes.NewReader(layerBytes))
 		if err != nil {
@@ -389,6 +506,10 @@ func (tp *tarballBundleProvider) pushPackageLayersWithSpinner(spinner *message.S
 	numRetries := 5
 	for _, layer := range zarfImageManifest.Manifest.Layers {
 		spinner.Updatef("Starting Zarf pkg push")
+		// layers pushed by a previous, interrupted publish are skipped
+		if utils.LayerExists(remote.Repo(), layer) {
+			continue
+		}
 		if ok, _ := store.Exists(tp.ctx, layer); ok {
 			b, err := store.Fetch(tp.ctx, layer)
 			if err != nil {
@@ -419,6 +540,9 @@ func (tp *tarballBundleProvider) pushPackageLayersWithSpinner(spinner *message.S
 	// we don't always want that because we sometimes use optional components
 	// can try to use oras.Copy() but refs are weird with local stores, but this would allow using oci.CopyWithProgress
 	pkgManifestDesc.MediaType = oci.ZarfLayerMediaTypeBlob
+	if utils.LayerExists(remote.Repo(), pkgManifestDesc) {
+		return nil
+	}
 	for i := 0; i < numRetries; i++ {
 		spinner.Updatef(fmt.Sprintf("Pushing bundle layer: %s", pkgManifestDesc.Digest.Encoded()))
 		if err := remote.Repo().Push(tp.ctx, pkgManifestDesc, bytes.NewReader(layerBytes)); err == nil {
@@ -432,7 +556,7 @@ func (tp *tarballBundleProvider) pushPackageLayersWithSpinner(spinner *message.S
 	return nil
 }
 
-func (tp *tarballBundleProvider) PublishBundle(bundle types.UDSBundle, remote *oci.OrasRemote) error {
+func (tp *tarballBundleProvider) PublishBundle(bundle types.UDSBundle, remote *oci.OrasRemote, configMediaType, artifactType string) error {
 	if err := tp.getBundleManifest(); err != nil {
 		return err
 	}
@@ -456,23 +580,40 @@ func (tp *tarballBundleProvider) PublishBundle(bundle types.UDSBundle, remote *o
 
 	// push manifest config
 	// todo: sometimes the manifest config isn't present, doesn't hurt anything but it's weird
-	configDesc, err := pushManifestConfigFromMetadata(remote, &bundle.Metadata, &bundle.Build)
+	configDesc, err := pushManifestConfigFromMetadata(remote, &bundle.Metadata, &bundle.Build, configMediaType)
 	tp.manifest.Manifest.Config = configDesc
 	if err != nil {
 		return err
 	}
-	b, err := json.Marshal(tp.manifest.Manifest)
-	if err != nil {
-		return err
+	if artifactType != "" {
+		tp.manifest.Manifest.ArtifactType = artifactType
 	}
-	expected := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, b)
-
-	message.Debug("Pushing manifest:", message.JSONValue(expected))
-
-	if err := remote.Repo().Manifests().PushReference(context.TODO(), expected, bytes.NewReader(b), remote.Repo().Reference.String()); err != nil {
-		return fmt.Errorf("failed to push manifest: %w", err)
+	if _, err := pushRootManifest(context.TODO(), remote.Repo().Manifests(), tp.manifest.Manifest, remote.Repo().Reference.String()); err != nil {
+		return err
 	}
 	spinner.Successf("Bundle publish successful!")
 	spinner.Stop()
 	return nil
 }
+
+// VerifyLayers streams every blob out of the bundle tarball and checks it against its digest, and that none of the
+// root manifest's layers are missing
+func (tp *tarballBundleProvider) VerifyLayers() (int, error) {
+	if err := tp.getBundleManifest(); err != nil {
+		return 0, err
+	}
+	seen := make(map[digest.Digest]bool)
+	err := WalkLayers(tp.src, func(desc ocispec.Descriptor, r io.Reader) error {
+		seen[desc.Digest] = true
+		return verifyBlob(r, desc)
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, layer := range tp.manifest.Layers {
+		if !seen[layer.Digest] {
+			return 0, fmt.Errorf("layer %s is missing from %s", layer.Digest, tp.src)
+		}
+	}
+	return len(seen), nil
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pterm/pterm"
+	"github.com/pterm/pterm/putils"
+)
+
+// kinds of nodes in inspect --tree
+const (
+	treeKindBundle    = "bundle"
+	treeKindPackage   = "package"
+	treeKindComponent = "component"
+	treeKindImage     = "image"
+	treeKindChart     = "chart"
+)
+
+// bundleTreeNode is a node of the bundle -> packages -> components -> images/charts tree shown by inspect --tree
+type bundleTreeNode struct {
+	Name     string           `json:"name"`
+	Kind     string           `json:"kind"`
+	Size     int64            `json:"size,omitempty"`
+	Children []bundleTreeNode `json:"children,omitempty"`
+}
+
+// showTree prints the bundle's packages and their images and charts as a tree with sizes at each node
+func (b *Bundler) showTree(provider Provider) error {
+	root := bundleTreeNode{
+		Name: fmt.Sprintf("%s:%s", b.bundle.Metadata.Name, b.bundle.Metadata.Version),
+		Kind: treeKindBundle,
+	}
+	// the bundle's size counts layers shared between packages once, like the bundle tarball does
+	layerSizes := make(map[digest.Digest]int64)
+	for _, pkg := range b.bundle.ZarfPackages {
+		pin, err := pinnedDigest(pkg)
+		if err != nil {
+			return err
+		}
+		sha := pin.Encoded()
+		manifest, err := provider.LoadPackageManifest(sha)
+		if err != nil {
+			return err
+		}
+		node, err := loadPackageTree(provider, pkg, sha, manifest)
+		if err != nil {
+			return err
+		}
+		layerSizes[manifest.Config.Digest] = manifest.Config.Size
+		for _, layer := range manifest.Layers {
+			layerSizes[layer.Digest] = layer.Size
+		}
+		root.Children = append(root.Children, node)
+	}
+	for _, size := range layerSizes {
+		root.Size += size
+	}
+
+	if b.cfg.InspectOpts.JSON {
+		out, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	return pterm.DefaultTree.WithRoot(putils.TreeFromLeveledList(treeLeveledList(root, 0))).Render()
+}
+
+// loadPackageTree reads a package's zarf.yaml and image manifests out of the bundle and builds its node
+func loadPackageTree(provider Provider, pkg types.BundleZarfPackage, sha string, manifest *oci.ZarfOCIManifest) (bundleTreeNode, error) {
+	// grab zarf.yaml and the image index in one pass
+	zarfYAMLDesc := manifest.Locate(config.ZarfYAML)
+	if oci.IsEmptyDescriptor(zarfYAMLDesc) {
+		return bundleTreeNode{}, fmt.Errorf("package %s has no %s", pkg.Name, config.ZarfYAML)
+	}
+	descs := []ocispec.Descriptor{zarfYAMLDesc}
+	indexDesc := manifest.Locate(oci.ZarfPackageIndexPath)
+	if !oci.IsEmptyDescriptor(indexDesc) {
+		descs = append(descs, indexDesc)
+	}
+	layers, err := provider.FetchPackageLayers(sha, descs...)
+	if err != nil {
+		return bundleTreeNode{}, err
+	}
+	var zarfPkg zarfTypes.ZarfPackage
+	if err := goyaml.Unmarshal(layers[zarfYAMLDesc.Digest], &zarfPkg); err != nil {
+		return bundleTreeNode{}, err
+	}
+
+	imageSizes := make(map[string]int64)
+	if !oci.IsEmptyDescriptor(indexDesc) {
+		var index ocispec.Index
+		if err := json.Unmarshal(layers[indexDesc.Digest], &index); err != nil {
+			return bundleTreeNode{}, err
+		}
+		// images of optional components that weren't bundled aren't in the package's layers
+		imageManifestDescs := make(map[string]ocispec.Descriptor)
+		for _, desc := range index.Manifests {
+			layer := manifest.Locate(filepath.Join(oci.ZarfPackageImagesBlobsDir, desc.Digest.Encoded()))
+			if name, ok := desc.Annotations[ocispec.AnnotationBaseImageName]; ok && !oci.IsEmptyDescriptor(layer) {
+				imageManifestDescs[name] = layer
+			}
+		}
+		toFetch := []ocispec.Descriptor{}
+		for _, desc := range imageManifestDescs {
+			toFetch = append(toFetch, desc)
+		}
+		imageManifests, err := provider.FetchPackageLayers(sha, toFetch...)
+		if err != nil {
+			return bundleTreeNode{}, err
+		}
+		for name, desc := range imageManifestDescs {
+			var imageManifest ocispec.Manifest
+			if err := json.Unmarshal(imageManifests[desc.Digest], &imageManifest); err != nil {
+				return bundleTreeNode{}, err
+			}
+			size := desc.Size + imageManifest.Config.Size
+			for _, layer := range imageManifest.Layers {
+				size += layer.Size
+			}
+			imageSizes[name] = size
+		}
+	}
+
+	return buildPackageTree(pkg, zarfPkg, manifest, imageSizes), nil
+}
+
+// buildPackageTree builds a package's node from its zarf.yaml, its manifest and the sizes of its images
+//
+// only the components that are in the bundle (required or listed in optional-components) are included
+func buildPackageTree(pkg types.BundleZarfPackage, zarfPkg zarfTypes.ZarfPackage, manifest *oci.ZarfOCIManifest, imageSizes map[string]int64) bundleTreeNode {
+	tag, _, _ := strings.Cut(pkg.Ref, "@")
+	node := bundleTreeNode{Name: fmt.Sprintf("%s:%s", pkg.Name, tag), Kind: treeKindPackage, Size: manifest.Config.Size}
+	for _, layer := range manifest.Layers {
+		node.Size += layer.Size
+	}
+
+	for _, component := range bundledComponents(pkg, zarfPkg) {
+		componentNode := bundleTreeNode{Name: component.Name, Kind: treeKindComponent}
+		componentNode.Size = manifest.Locate(filepath.Join(zarfConfig.ZarfComponentsDir, component.Name+".tar")).Size
+		for _, image := range component.Images {
+			componentNode.Children = append(componentNode.Children, bundleTreeNode{Name: image, Kind: treeKindImage, Size: imageSizes[image]})
+			componentNode.Size += imageSizes[image]
+		}
+		for _, chart := range component.Charts {
+			name := chart.Name
+			if chart.Version != "" {
+				name = fmt.Sprintf("%s@%s", chart.Name, chart.Version)
+			}
+			componentNode.Children = append(componentNode.Children, bundleTreeNode{Name: name, Kind: treeKindChart})
+		}
+		node.Children = append(node.Children, componentNode)
+	}
+	return node
+}
+
+// treeLeveledList flattens a tree into the leveled list pterm renders trees from
+func treeLeveledList(node bundleTreeNode, level int) pterm.LeveledList {
+	text := fmt.Sprintf("%s (%s)", node.Name, node.Kind)
+	if node.Size > 0 {
+		text = fmt.Sprintf("%s (%s, %s)", node.Name, node.Kind, utils.ByteFormat(float64(node.Size), 2))
+	}
+	list := pterm.LeveledList{{Level: level, Text: text}}
+	for _, child := range node.Children {
+		list = append(list, treeLeveledList(child, level+1)...)
+	}
+	return list
+}
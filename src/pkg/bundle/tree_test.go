@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func Test_buildPackageTree(t *testing.T) {
+	layer := func(title string, size int64) ocispec.Descriptor {
+		return ocispec.Descriptor{Digest: digest.FromString(title), Size: size, Annotations: map[string]string{ocispec.AnnotationTitle: title}}
+	}
+	manifest := &oci.ZarfOCIManifest{Manifest: ocispec.Manifest{
+		Config: ocispec.Descriptor{Digest: digest.FromString("config"), Size: 1},
+		Layers: []ocispec.Descriptor{
+			layer("zarf.yaml", 10),
+			layer("components/podinfo.tar", 100),
+			layer("components/extras.tar", 1000),
+		},
+	}}
+	zarfPkg := zarfTypes.ZarfPackage{Components: []zarfTypes.ZarfComponent{
+		{
+			Name:     "podinfo",
+			Required: true,
+			Images:   []string{"ghcr.io/stefanprodan/podinfo:6.4.0"},
+			Charts:   []zarfTypes.ZarfChart{{Name: "podinfo", Version: "6.4.0"}},
+		},
+		{
+			Name:   "extras",
+			Charts: []zarfTypes.ZarfChart{{Name: "extras"}},
+		},
+	}}
+	imageSizes := map[string]int64{"ghcr.io/stefanprodan/podinfo:6.4.0": 5000}
+	podinfo := bundleTreeNode{Name: "podinfo", Kind: treeKindComponent, Size: 5100, Children: []bundleTreeNode{
+		{Name: "ghcr.io/stefanprodan/podinfo:6.4.0", Kind: treeKindImage, Size: 5000},
+		{Name: "podinfo@6.4.0", Kind: treeKindChart},
+	}}
+	extras := bundleTreeNode{Name: "extras", Kind: treeKindComponent, Size: 1000, Children: []bundleTreeNode{
+		{Name: "extras", Kind: treeKindChart},
+	}}
+
+	tests := []struct {
+		name        string
+		description string
+		pkg         types.BundleZarfPackage
+		want        bundleTreeNode
+	}{
+		{
+			name:        "RequiredOnly",
+			description: "optional components that weren't selected are left out",
+			pkg:         types.BundleZarfPackage{Name: "podinfo", Ref: "0.0.1-amd64@sha256:abc"},
+			want:        bundleTreeNode{Name: "podinfo:0.0.1-amd64", Kind: treeKindPackage, Size: 1111, Children: []bundleTreeNode{podinfo}},
+		}, {
+			name:        "OptionalComponents",
+			description: "optional components listed in optional-components are included",
+			pkg:         types.BundleZarfPackage{Name: "podinfo", Ref: "0.0.1-amd64@sha256:abc", OptionalComponents: []string{"extras"}},
+			want:        bundleTreeNode{Name: "podinfo:0.0.1-amd64", Kind: treeKindPackage, Size: 1111, Children: []bundleTreeNode{podinfo, extras}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildPackageTree(tt.pkg, zarfPkg, manifest, imageSizes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildPackageTree() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	goyaml "github.com/goccy/go-yaml"
+)
+
+// packageOverrides are the variables set on the deploy command line for each package, keyed by package then variable
+type packageOverrides map[string]map[string]string
+
+// loadPackageOverrides merges the --values-file with --set, where --set wins, and errors on packages the bundle doesn't have
+func (b *Bundler) loadPackageOverrides() (packageOverrides, error) {
+	overrides := packageOverrides{}
+	if b.cfg.DeployOpts.ValuesFile != "" {
+		values, err := readValuesFile(b.cfg.DeployOpts.ValuesFile)
+		if err != nil {
+			return nil, err
+		}
+		overrides.merge(values)
+	}
+	set, err := parseSetVariables(b.cfg.DeployOpts.SetVariables)
+	if err != nil {
+		return nil, err
+	}
+	overrides.merge(set)
+
+	for _, name := range overrides.packages() {
+		if !bundleHasPackage(b.bundle.ZarfPackages, name) {
+			return nil, fmt.Errorf("variables are set for zarf pkg %s, which is not in bundle %s", name, b.bundle.Metadata.Name)
+		}
+	}
+	return overrides, nil
+}
+
+// readValuesFile reads a YAML file mapping package names to the variables to set for them, scalar values of any
+// type are taken as strings
+func readValuesFile(path string) (packageOverrides, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read values file: %w", err)
+	}
+	var raw map[string]map[string]interface{}
+	if err := goyaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("invalid values file %s, it must map package names to variables: %w", path, err)
+	}
+	values := packageOverrides{}
+	for pkg, vars := range raw {
+		values[pkg] = make(map[string]string)
+		for name, val := range vars {
+			switch val.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, fmt.Errorf("invalid values file %s, %s.%s must be a string, number or boolean", path, pkg, name)
+			case nil:
+				values[pkg][name] = ""
+			default:
+				values[pkg][name] = fmt.Sprint(val)
+			}
+		}
+	}
+	return values, nil
+}
+
+// parseSetVariables splits --set PACKAGE.VARIABLE=value flags by package
+func parseSetVariables(set map[string]string) (packageOverrides, error) {
+	overrides := packageOverrides{}
+	for key, val := range set {
+		pkg, name, ok := strings.Cut(key, ".")
+		if !ok || pkg == "" || name == "" {
+			return nil, fmt.Errorf("invalid --set %s, variables are set with PACKAGE.VARIABLE=value", key)
+		}
+		if overrides[pkg] == nil {
+			overrides[pkg] = make(map[string]string)
+		}
+		overrides[pkg][name] = val
+	}
+	return overrides, nil
+}
+
+// merge copies src into o, upper-casing variable names like Zarf does
+func (o packageOverrides) merge(src packageOverrides) {
+	for pkg, vars := range src {
+		if o[pkg] == nil {
+			o[pkg] = make(map[string]string)
+		}
+		for name, val := range vars {
+			o[pkg][strings.ToUpper(name)] = val
+		}
+	}
+}
+
+// packages returns the names of the packages with overrides in a stable order
+func (o packageOverrides) packages() []string {
+	names := []string{}
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func bundleHasPackage(packages []types.BundleZarfPackage, name string) bool {
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownVariables returns the names of the variables in vars that the package's zarf.yaml doesn't declare
+func unknownVariables(zarfPkg zarfTypes.ZarfPackage, vars map[string]string) []string {
+	declared := make(map[string]bool)
+	for _, variable := range zarfPkg.Variables {
+		declared[variable.Name] = true
+	}
+	unknown := []string{}
+	for name := range vars {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// warnUnknownVariables warns about overrides the package loaded into pkgTmp won't use, likely typos
+func warnUnknownVariables(pkgName, pkgTmp string, vars map[string]string) {
+	if len(vars) == 0 {
+		return
+	}
+	var zarfPkg zarfTypes.ZarfPackage
+	if err := utils.ReadYaml(filepath.Join(pkgTmp, config.ZarfYAML), &zarfPkg); err != nil {
+		message.Debugf("unable to read the variables of zarf pkg %s: %s", pkgName, err.Error())
+		return
+	}
+	if unknown := unknownVariables(zarfPkg, vars); len(unknown) > 0 {
+		message.Warnf("zarf pkg %s does not declare the variables %s, they will have no effect", pkgName, strings.Join(unknown, ", "))
+	}
+}
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+)
+
+func Test_loadPackageOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeValues := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	valuesFile := writeValues("values.yaml", "podinfo:\n  ui_color: blue\n  REPLICAS: 2\n  DEBUG: true\n")
+
+	tests := []struct {
+		name        string
+		description string
+		valuesFile  string
+		set         map[string]string
+		want        packageOverrides
+		wantErr     bool
+	}{
+		{
+			name:        "None",
+			description: "no values file or --set sets nothing",
+			want:        packageOverrides{},
+		},
+		{
+			name:        "ValuesFile",
+			description: "values are upper-cased and scalars are taken as strings",
+			valuesFile:  valuesFile,
+			want:        packageOverrides{"podinfo": {"UI_COLOR": "blue", "REPLICAS": "2", "DEBUG": "true"}},
+		},
+		{
+			name:        "SetOverridesFile",
+			description: "--set wins over the values file",
+			valuesFile:  valuesFile,
+			set:         map[string]string{"podinfo.UI_COLOR": "red", "init.REGISTRY_NODEPORT": "31999"},
+			want: packageOverrides{
+				"podinfo": {"UI_COLOR": "red", "REPLICAS": "2", "DEBUG": "true"},
+				"init":    {"REGISTRY_NODEPORT": "31999"},
+			},
+		},
+		{
+			name:        "UnknownPackage",
+			description: "variables for a package the bundle doesn't have are an error",
+			valuesFile:  writeValues("unknown.yaml", "podinfoo:\n  UI_COLOR: blue\n"),
+			wantErr:     true,
+		},
+		{
+			name:        "SetWithoutPackage",
+			description: "--set without a PACKAGE. prefix is an error",
+			set:         map[string]string{"UI_COLOR": "red"},
+			wantErr:     true,
+		},
+		{
+			name:        "NestedValue",
+			description: "values must be scalars",
+			valuesFile:  writeValues("nested.yaml", "podinfo:\n  UI_COLOR:\n    - blue\n"),
+			wantErr:     true,
+		},
+		{
+			name:        "MissingFile",
+			description: "a values file that doesn't exist is an error",
+			valuesFile:  filepath.Join(dir, "missing.yaml"),
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bundler{
+				cfg:    &types.BundlerConfig{DeployOpts: types.BundlerDeployOptions{ValuesFile: tt.valuesFile, SetVariables: tt.set}},
+				bundle: types.UDSBundle{ZarfPackages: []types.BundleZarfPackage{{Name: "init"}, {Name: "podinfo"}}},
+			}
+			got, err := b.loadPackageOverrides()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadPackageOverrides() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadPackageOverrides() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_loadVariablesPrecedence(t *testing.T) {
+	b := &Bundler{cfg: &types.BundlerConfig{DeployOpts: types.BundlerDeployOptions{
+		ZarfPackageVariables: map[string]types.SetVariables{"podinfo": {Set: map[string]string{"ui_color": "green", "replicas": "1"}}},
+	}}}
+	pkg := types.BundleZarfPackage{Name: "podinfo", Imports: []types.BundleVariableImport{{Name: "DOMAIN", Package: "init"}}}
+	exported := map[string]map[string]string{"init": {"DOMAIN": "uds.dev"}}
+
+	got := b.loadVariables(pkg, exported, map[string]string{"UI_COLOR": "blue"})
+	want := map[string]string{"DOMAIN": "uds.dev", "REPLICAS": "1", "UI_COLOR": "blue"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadVariables() = %v, want %v", got, want)
+	}
+}
+
+func Test_unknownVariables(t *testing.T) {
+	zarfPkg := zarfTypes.ZarfPackage{Variables: []zarfTypes.ZarfPackageVariable{{Name: "UI_COLOR"}}}
+	got := unknownVariables(zarfPkg, map[string]string{"UI_COLOR": "blue", "UI_COLOUR": "blue"})
+	if !reflect.DeepEqual(got, []string{"UI_COLOUR"}) {
+		t.Errorf("unknownVariables() = %v, want [UI_COLOUR]", got)
+	}
+}
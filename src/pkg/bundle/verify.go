@@ -0,0 +1,192 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// verifyCheck is the outcome of one of the checks run by uds verify
+type verifyCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Message string `json:"message"`
+}
+
+// verifyResult is what uds verify reports, the bundle passes if every check passed or was skipped
+type verifyResult struct {
+	Source string        `json:"source"`
+	Passed bool          `json:"passed"`
+	Checks []verifyCheck `json:"checks"`
+}
+
+// Verify checks a bundle's signature and architecture, and with --digests every layer's digest, without deploying it
+func (b *Bundler) Verify() error {
+	ctx := context.TODO()
+	source, err := b.decryptSource(b.cfg.VerifyOpts.Source, b.cfg.VerifyOpts.Decrypt, b.cfg.VerifyOpts.IdentityPath)
+	if err != nil {
+		return err
+	}
+
+	provider, err := NewBundleProvider(ctx, source, b.tmp)
+	if err != nil {
+		return err
+	}
+	loaded, err := provider.LoadBundleMetadata()
+	if err != nil {
+		return err
+	}
+	if err := utils.ReadYaml(loaded[config.BundleYAML], &b.bundle); err != nil {
+		return err
+	}
+
+	publicKeys, err := udsUtils.FetchPublicKeys(b.cfg.VerifyOpts.PublicKeyPaths, b.tmp)
+	if err != nil {
+		return err
+	}
+
+	sigAlgo, err := provider.SignatureAlgorithm()
+	if err != nil {
+		return err
+	}
+
+	result := verifyResult{Source: b.cfg.VerifyOpts.Source, Passed: true}
+	result.add(signatureCheck(loaded[config.BundleYAML], loaded[config.BundleYAMLSignature], publicKeys, sigAlgo))
+	result.add(architectureCheck(b.bundle.Metadata.Architecture, b.cfg.Arch.Target()))
+	result.add(digestsCheck(provider, b.cfg.VerifyOpts.Digests))
+
+	if err := result.print(b.cfg.VerifyOpts.Output); err != nil {
+		return err
+	}
+	if !result.Passed {
+		return fmt.Errorf("%s failed verification: %s", b.cfg.VerifyOpts.Source, strings.Join(result.failed(), ", "))
+	}
+	return nil
+}
+
+func (r *verifyResult) add(check verifyCheck) {
+	r.Checks = append(r.Checks, check)
+	r.Passed = r.Passed && check.Passed
+}
+
+// failed returns the names of the checks that didn't pass
+func (r *verifyResult) failed() []string {
+	failed := []string{}
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
+
+// print writes the result to stdout as JSON, or as one line per check for the text output
+func (r *verifyResult) print(output string) error {
+	if output == config.VerifyOutputJSON {
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	for _, check := range r.Checks {
+		switch {
+		case check.Skipped:
+			message.Infof("%s: skipped, %s", check.Name, check.Message)
+		case check.Passed:
+			message.Successf("%s: %s", check.Name, check.Message)
+		default:
+			message.Warnf("%s: %s", check.Name, check.Message)
+		}
+	}
+	if r.Passed {
+		message.Successf("%s passed verification", r.Source)
+	}
+	return nil
+}
+
+// signatureCheck validates the bundle's signature against the trusted keys, an unsigned bundle is only skipped when no key was given
+func signatureCheck(bundleYAMLPath, signaturePath string, publicKeys []udsUtils.TrustedKey, sigAlgo string) verifyCheck {
+	check := verifyCheck{Name: "signature"}
+	if utils.InvalidPath(signaturePath) && len(publicKeys) == 0 {
+		check.Passed = true
+		check.Skipped = true
+		check.Message = "the bundle is not signed and no key was provided"
+		return check
+	}
+	key, err := ValidateBundleSignature(bundleYAMLPath, signaturePath, publicKeys, sigAlgo)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	check.Passed = true
+	check.Message = "the bundle's signature is valid"
+	if sigAlgo != "" {
+		check.Message = fmt.Sprintf("the bundle's %s signature is valid", sigAlgo)
+	}
+	if len(publicKeys) > 1 {
+		check.Message += fmt.Sprintf(", verified with %s", key.Source)
+	}
+	return check
+}
+
+// architectureCheck confirms the bundle was built for arch, the --architecture flag or the host's architecture
+func architectureCheck(bundleArch, arch string) verifyCheck {
+	check := verifyCheck{Name: "architecture"}
+	if bundleArch != arch {
+		check.Message = fmt.Sprintf("the bundle is built for %q, expected %q", bundleArch, arch)
+		return check
+	}
+	check.Passed = true
+	check.Message = fmt.Sprintf("the bundle is built for %s", arch)
+	return check
+}
+
+// digestsCheck reads every layer of the bundle and checks it against its digest, reading a remote bundle downloads it
+// in full so this only runs with --digests
+func digestsCheck(provider Provider, enabled bool) verifyCheck {
+	check := verifyCheck{Name: "digests"}
+	if !enabled {
+		check.Passed = true
+		check.Skipped = true
+		check.Message = "use --digests to check every layer"
+		return check
+	}
+	spinner := message.NewProgressSpinner("Verifying bundle layers")
+	defer spinner.Stop()
+	verified, err := provider.VerifyLayers()
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	check.Passed = true
+	check.Message = fmt.Sprintf("%d layers match their digests", verified)
+	return check
+}
+
+// verifyBlob reads r to the end and checks it matches the size and digest of desc
+func verifyBlob(r io.Reader, desc ocispec.Descriptor) error {
+	vr := content.NewVerifyReader(r, desc)
+	if _, err := io.Copy(io.Discard, vr); err != nil {
+		return fmt.Errorf("layer %s: %w", desc.Digest, err)
+	}
+	if err := vr.Verify(); err != nil {
+		return fmt.Errorf("layer %s: %w", desc.Digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+func Test_verifyBlob(t *testing.T) {
+	contents := []byte("layer contents")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, contents)
+
+	tests := []struct {
+		name        string
+		description string
+		contents    []byte
+		desc        ocispec.Descriptor
+		wantErr     bool
+	}{
+		{
+			name:        "Matches",
+			description: "contents matching the descriptor pass",
+			contents:    contents,
+			desc:        desc,
+		},
+		{
+			name:        "Tampered",
+			description: "contents of the same size with a different digest fail",
+			contents:    []byte("layer Contents"),
+			desc:        desc,
+			wantErr:     true,
+		},
+		{
+			name:        "Truncated",
+			description: "contents shorter than the descriptor's size fail",
+			contents:    contents[:5],
+			desc:        desc,
+			wantErr:     true,
+		},
+		{
+			name:        "TrailingData",
+			description: "contents longer than the descriptor's size fail",
+			contents:    append(append([]byte{}, contents...), '!'),
+			desc:        desc,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyBlob(bytes.NewReader(tt.contents), tt.desc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyBlob() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_tarballVerifyLayers(t *testing.T) {
+	tarball, blobs := writeTestBundle(t)
+	tp := &tarballBundleProvider{ctx: context.TODO(), src: tarball, dst: t.TempDir()}
+
+	verified, err := tp.VerifyLayers()
+	if err != nil {
+		t.Fatalf("VerifyLayers() error = %v", err)
+	}
+	if verified != len(blobs) {
+		t.Errorf("VerifyLayers() verified %d layers, want %d", verified, len(blobs))
+	}
+}
+
+func Test_verifyChecks(t *testing.T) {
+	dir := t.TempDir()
+	bundleYAML := filepath.Join(dir, config.BundleYAML)
+	if err := os.WriteFile(bundleYAML, []byte("kind: UDSBundle\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	key := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(key, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		check       verifyCheck
+		wantPassed  bool
+		wantSkipped bool
+	}{
+		{
+			name:        "UnsignedWithoutKey",
+			description: "an unsigned bundle is skipped when no key is given",
+			check:       signatureCheck(bundleYAML, "", nil, ""),
+			wantPassed:  true,
+			wantSkipped: true,
+		},
+		{
+			name:        "UnsignedWithKey",
+			description: "an unsigned bundle fails when a key is given",
+			check:       signatureCheck(bundleYAML, "", []utils.TrustedKey{{Path: key, Source: key}}, ""),
+		},
+		{
+			name:        "SignedWithoutKey",
+			description: "a signed bundle fails when no key is given",
+			check:       signatureCheck(bundleYAML, bundleYAML, nil, ""),
+		},
+		{
+			name:        "SameArchitecture",
+			description: "a bundle built for the target architecture passes",
+			check:       architectureCheck("amd64", "amd64"),
+			wantPassed:  true,
+		},
+		{
+			name:        "OtherArchitecture",
+			description: "a bundle built for another architecture fails",
+			check:       architectureCheck("arm64", "amd64"),
+		},
+		{
+			name:        "NoArchitecture",
+			description: "a bundle without an architecture fails",
+			check:       architectureCheck("", "amd64"),
+		},
+		{
+			name:        "DigestsNotRequested",
+			description: "layers are only checked with --digests",
+			check:       digestsCheck(nil, false),
+			wantPassed:  true,
+			wantSkipped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.check.Passed != tt.wantPassed || tt.check.Skipped != tt.wantSkipped {
+				t.Errorf("%s check passed = %v, skipped = %v, want %v, %v (%s): %s", tt.check.Name, tt.check.Passed, tt.check.Skipped, tt.wantPassed, tt.wantSkipped, tt.description, tt.check.Message)
+			}
+		})
+	}
+}
+
+func Test_verifyResult(t *testing.T) {
+	result := verifyResult{Passed: true}
+	result.add(verifyCheck{Name: "signature", Passed: true, Skipped: true})
+	result.add(verifyCheck{Name: "architecture", Passed: true})
+	if !result.Passed {
+		t.Errorf("verifyResult with only passed and skipped checks should pass")
+	}
+	result.add(verifyCheck{Name: "digests"})
+	if result.Passed {
+		t.Errorf("verifyResult with a failed check should fail")
+	}
+	if failed := result.failed(); len(failed) != 1 || failed[0] != "digests" {
+		t.Errorf("verifyResult.failed() = %v, want [digests]", failed)
+	}
+}
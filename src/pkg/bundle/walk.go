@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	av4 "github.com/mholt/archiver/v4"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// WalkLayers calls fn for each blob in the bundle tarball at path with the blob's descriptor and a reader over its contents
+//
+// the archive is streamed rather than extracted to disk, the reader is only valid until fn returns.
+// an error returned from fn aborts the walk and is returned from WalkLayers
+func WalkLayers(path string, fn func(desc ocispec.Descriptor, r io.Reader) error) error {
+	ctx := context.TODO()
+
+	descs, err := bundleDescriptors(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	return walkArchive(ctx, path, []string{config.BlobsRoot}, func(_ context.Context, file av4.File) error {
+		if file.IsDir() {
+			return nil
+		}
+		dgst, err := blobDigest(file.NameInArchive)
+		if err != nil {
+			return err
+		}
+		desc, ok := descs[dgst]
+		if !ok {
+			// blobs not referenced by a manifest are still walked
+			desc = ocispec.Descriptor{MediaType: "application/octet-stream", Digest: dgst, Size: file.Size()}
+		}
+
+		r, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		return fn(desc, r)
+	})
+}
+
+// bundleDescriptors reads index.json, the bundle's root manifest and the Zarf package manifests out of the bundle tarball
+// and returns the descriptors of every blob they reference keyed by digest
+func bundleDescriptors(ctx context.Context, path string) (map[digest.Digest]ocispec.Descriptor, error) {
+	descs := make(map[digest.Digest]ocispec.Descriptor)
+
+	var index ocispec.Index
+	if err := walkArchive(ctx, path, []string{"index.json"}, extractJSON(&index)); err != nil {
+		return nil, err
+	}
+	if len(index.Manifests) == 0 {
+		return nil, fmt.Errorf("%s has no manifests in index.json", path)
+	}
+
+	// manifests reference other manifests, read them level by level
+	manifestDescs := index.Manifests
+	for len(manifestDescs) > 0 {
+		pathsInArchive := []string{}
+		for _, desc := range manifestDescs {
+			descs[desc.Digest] = desc
+			pathsInArchive = append(pathsInArchive, utils.BlobPath(desc.Digest))
+		}
+
+		nested := []ocispec.Descriptor{}
+		if err := walkArchive(ctx, path, pathsInArchive, func(_ context.Context, file av4.File) error {
+			var manifest ocispec.Manifest
+			if err := extractJSON(&manifest)(ctx, file); err != nil {
+				return err
+			}
+			if manifest.Config.Digest != "" {
+				descs[manifest.Config.Digest] = manifest.Config
+			}
+			for _, layer := range manifest.Layers {
+				if layer.MediaType == ocispec.MediaTypeImageManifest {
+					nested = append(nested, layer)
+					continue
+				}
+				descs[layer.Digest] = layer
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		manifestDescs = nested
+	}
+
+	return descs, nil
+}
+
+// walkArchive streams the entries of the bundle tarball at path that match pathsInArchive through handler
+func walkArchive(ctx context.Context, path string, pathsInArchive []string, handler av4.FileHandler) error {
+	format := av4.CompressedArchive{
+		Compression: av4.Zstd{},
+		Archival:    av4.Tar{},
+	}
+
+	sourceArchive, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer sourceArchive.Close()
+
+	return format.Extract(ctx, sourceArchive, pathsInArchive, handler)
+}
+
+// blobDigest returns the digest of a blob from its path in an OCI layout (ie. blobs/sha256/<encoded>)
+func blobDigest(pathInArchive string) (digest.Digest, error) {
+	algorithm, encoded := path.Split(strings.TrimPrefix(pathInArchive, config.BlobsRoot+"/"))
+	dgst := digest.NewDigestFromEncoded(digest.Algorithm(strings.TrimSuffix(algorithm, "/")), encoded)
+	if err := dgst.Validate(); err != nil {
+		return "", fmt.Errorf("invalid blob %s: %w", pathInArchive, err)
+	}
+	return dgst, nil
+}
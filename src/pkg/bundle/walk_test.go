@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	av4 "github.com/mholt/archiver/v4"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	ocistore "oras.land/oras-go/v2/content/oci"
+)
+
+// testZarfYAML is the zarf.yaml of the package in the bundle written by writeTestBundle
+const testZarfYAML = `kind: ZarfPackageConfig
+metadata:
+  name: test
+variables:
+  - name: DOMAIN
+    description: The cluster's domain
+    default: uds.dev
+  - name: ADMIN_PASSWORD
+    sensitive: true
+`
+
+// writeTestBundle writes a minimal bundle tarball (index.json -> root manifest -> package manifest -> layer) and returns its path
+func writeTestBundle(t *testing.T) (string, map[string]string) {
+	t.Helper()
+	return writeTestBundleWithAlgorithm(t, digest.SHA256)
+}
+
+// writeTestBundleWithAlgorithm writes a test bundle like writeTestBundle with the package's blobs addressed by alg
+func writeTestBundleWithAlgorithm(t *testing.T, alg digest.Algorithm) (string, map[string]string) {
+	t.Helper()
+	blobAlg := alg
+	dir := t.TempDir()
+	pathMap := make(PathMap)
+	blobs := make(map[string]string)
+
+	writeBlob := func(mediaType string, b []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, b)
+		desc.Digest = blobAlg.FromBytes(b)
+		abs := filepath.Join(dir, utils.BlobPath(desc.Digest))
+		if err := os.MkdirAll(filepath.Dir(abs), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+		pathMap.addBlob(dir, desc)
+		blobs[desc.Digest.String()] = mediaType
+		return desc
+	}
+	writeJSON := func(mediaType string, v any) ocispec.Descriptor {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return writeBlob(mediaType, b)
+	}
+
+	layer := writeBlob("application/vnd.zarf.layer.v1.blob", []byte(testZarfYAML))
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: config.ZarfYAML}
+	imageLayer := writeBlob(ocispec.MediaTypeImageLayerGzip, []byte("image layer contents"))
+	imageLayer.Annotations = map[string]string{ocispec.AnnotationTitle: "images/layer"}
+	pkgConfig := writeBlob(ocispec.MediaTypeImageConfig, []byte(`{"architecture":"amd64"}`))
+	pkgManifest := writeJSON(ocispec.MediaTypeImageManifest, ocispec.Manifest{Config: pkgConfig, Layers: []ocispec.Descriptor{layer, imageLayer}})
+	// create writes the root manifest itself, it's always addressed by sha256
+	blobAlg = digest.SHA256
+	rootManifest := writeJSON(ocispec.MediaTypeImageManifest, ocispec.Manifest{Layers: []ocispec.Descriptor{pkgManifest}})
+
+	indexBytes, err := json.Marshal(ocispec.Index{Manifests: []ocispec.Descriptor{rootManifest}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), indexBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	pathMap[filepath.Join(dir, "index.json")] = "index.json"
+
+	files, err := av4.FilesFromDisk(nil, pathMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarball := filepath.Join(t.TempDir(), "uds-bundle-test-amd64-0.0.1.tar.zst")
+	out, err := os.Create(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	format := av4.CompressedArchive{Compression: av4.Zstd{}, Archival: av4.Tar{}}
+	if err := format.Archive(context.TODO(), out, files); err != nil {
+		t.Fatal(err)
+	}
+	return tarball, blobs
+}
+
+func Test_WalkLayers(t *testing.T) {
+	tarball, blobs := writeTestBundle(t)
+
+	t.Run("AllBlobs", func(t *testing.T) {
+		walked := make(map[string]string)
+		err := WalkLayers(tarball, func(desc ocispec.Descriptor, r io.Reader) error {
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if int64(len(b)) != desc.Size {
+				t.Errorf("%s read %d bytes, want %d", desc.Digest, len(b), desc.Size)
+			}
+			walked[desc.Digest.String()] = desc.MediaType
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WalkLayers() error = %v", err)
+		}
+		if len(walked) != len(blobs) {
+			t.Fatalf("WalkLayers() walked %d blobs, want %d", len(walked), len(blobs))
+		}
+		for dgst, mediaType := range blobs {
+			if walked[dgst] != mediaType {
+				t.Errorf("WalkLayers() %s media type = %q, want %q", dgst, walked[dgst], mediaType)
+			}
+		}
+	})
+
+	t.Run("CallbackErrorAborts", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := WalkLayers(tarball, func(_ ocispec.Descriptor, _ io.Reader) error {
+			calls++
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("WalkLayers() error = %v, want %v", err, errStop)
+		}
+		if calls != 1 {
+			t.Errorf("WalkLayers() called fn %d times after an error, want 1", calls)
+		}
+	})
+}
+
+func Test_tarballLoadBundle(t *testing.T) {
+	tarball, blobs := writeTestBundle(t)
+	tp := &tarballBundleProvider{ctx: context.TODO(), src: tarball, dst: t.TempDir()}
+
+	loaded, err := tp.LoadBundle(0)
+	if err != nil {
+		t.Fatalf("LoadBundle() error = %v", err)
+	}
+	store, err := ocistore.NewWithContext(context.TODO(), tp.dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every package layer is loaded regardless of its media type
+	for dgst, mediaType := range blobs {
+		if mediaType == ocispec.MediaTypeImageManifest || mediaType == ocispec.MediaTypeImageConfig {
+			continue
+		}
+		desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.Digest(dgst)}
+		if _, ok := loaded[desc.Digest.Encoded()]; !ok {
+			t.Errorf("LoadBundle() did not load %s layer %s", mediaType, dgst)
+		}
+		if exists, err := store.Exists(context.TODO(), desc); !exists || err != nil {
+			t.Errorf("LoadBundle() did not store %s layer %s: %v", mediaType, dgst, err)
+		}
+	}
+}
+
+func Test_loadPackageConfig(t *testing.T) {
+	tarball, _ := writeTestBundle(t)
+	tp := &tarballBundleProvider{ctx: context.TODO(), src: tarball, dst: t.TempDir()}
+	if err := tp.getBundleManifest(); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := loadPackageConfig(tp, tp.manifest.Layers[0].Digest.Encoded())
+	if err != nil {
+		t.Fatalf("loadPackageConfig() error = %v", err)
+	}
+	if pkg.Metadata.Name != "test" || len(pkg.Variables) != 2 {
+		t.Errorf("loadPackageConfig() = %s with %d variables, want test with 2", pkg.Metadata.Name, len(pkg.Variables))
+	}
+
+	if _, err := loadPackageConfig(tp, digest.FromString("missing").Encoded()); err == nil {
+		t.Error("loadPackageConfig() of a package not in the bundle should error")
+	}
+}
+
+func Test_tarballLoadPackageManifestSHA512(t *testing.T) {
+	tarball, _ := writeTestBundleWithAlgorithm(t, digest.SHA512)
+	tp := &tarballBundleProvider{ctx: context.TODO(), src: tarball, dst: t.TempDir()}
+	if err := tp.getBundleManifest(); err != nil {
+		t.Fatal(err)
+	}
+
+	// create pins packages with sha512 digests to tag@sha512:<digest>
+	pkg := types.BundleZarfPackage{Name: "test", Ref: repinRef("0.0.1-amd64", tp.manifest.Layers[0].Digest)}
+	pin, err := pinnedDigest(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := tp.LoadPackageManifest(pin.Encoded())
+	if err != nil {
+		t.Fatalf("LoadPackageManifest() error = %v for a sha512-pinned package", err)
+	}
+	if len(manifest.Layers) != 2 {
+		t.Errorf("LoadPackageManifest() = %d layers, want 2", len(manifest.Layers))
+	}
+	if _, err := tp.LoadPackage(pin.Encoded(), t.TempDir(), 0); err != nil {
+		t.Errorf("LoadPackage() error = %v for a sha512-pinned package", err)
+	}
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/pkg/bundler"
+	"github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+)
+
+// Wrap creates a single-package bundle from a local Zarf package tarball
+//
+// : read the Zarf package's metadata
+// : synthesize a uds-bundle.yaml referencing the package by path
+// : run the normal create flow against the synthesized bundle
+// : move the bundle tarball into the current working directory
+func (b *Bundler) Wrap() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	pkgPath, err := filepath.Abs(b.cfg.WrapOpts.PackagePath)
+	if err != nil {
+		return err
+	}
+
+	// read the Zarf package's metadata
+	pkgTmp, err := zarfUtils.MakeTempDir()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(pkgTmp)
+	localBundler := bundler.NewLocalBundler(pkgPath, pkgTmp)
+	if err := localBundler.Extract(); err != nil {
+		return err
+	}
+	zarfPkg, err := localBundler.Load()
+	if err != nil {
+		return err
+	}
+
+	// flags > zarf.yaml metadata
+	arch := b.cfg.Arch.Resolve(zarfPkg.Metadata.Architecture, zarfPkg.Build.Architecture)
+	version := firstNonEmpty(b.cfg.WrapOpts.Version, zarfPkg.Metadata.Version)
+	if version == "" {
+		return fmt.Errorf("%s has no metadata.version, use --version to set the bundle's version", b.cfg.WrapOpts.PackagePath)
+	}
+	ref := firstNonEmpty(zarfPkg.Metadata.Version, version)
+	bundle := types.UDSBundle{
+		Kind: "UDSBundle",
+		Metadata: types.UDSMetadata{
+			Name:         firstNonEmpty(b.cfg.WrapOpts.Name, zarfPkg.Metadata.Name),
+			Description:  firstNonEmpty(b.cfg.WrapOpts.Description, zarfPkg.Metadata.Description),
+			Version:      version,
+			Architecture: arch,
+		},
+	}
+
+	// stage the package under the name the create flow expects next to the synthesized uds-bundle.yaml
+	stagingDir, err := zarfUtils.MakeTempDir()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingDir)
+	if err := os.Symlink(pkgPath, filepath.Join(stagingDir, zarfPackageTarballName(zarfPkg.Metadata.Name, arch, ref))); err != nil {
+		return err
+	}
+	bundle.ZarfPackages = []types.BundleZarfPackage{{
+		Name: zarfPkg.Metadata.Name,
+		Path: stagingDir,
+		Ref:  ref,
+	}}
+	if err := zarfUtils.WriteYaml(filepath.Join(stagingDir, config.BundleYAML), &bundle, 0600); err != nil {
+		return err
+	}
+	message.Debug("Wrapping", b.cfg.WrapOpts.PackagePath, "as", message.JSONValue(bundle))
+
+	// run the normal create flow
+	b.cfg.CreateOpts.SourceDirectory = stagingDir
+	if err := b.Create(); err != nil {
+		return err
+	}
+
+	// create writes the tarball next to the uds-bundle.yaml, move it to where the user ran wrap
+	filename := bundleTarballName(&b.bundle.Metadata)
+	for _, name := range []string{filename, filename + utils.EncryptedSuffix} {
+		src := filepath.Join(stagingDir, name)
+		if zarfUtils.InvalidPath(src) {
+			continue
+		}
+		dst := filepath.Join(cwd, name)
+		if err := os.Rename(src, dst); err != nil {
+			// tmp may be on a different filesystem
+			if err := zarfUtils.CreatePathAndCopy(src, dst); err != nil {
+				return err
+			}
+		}
+		message.Successf("Wrapped %s into %s", b.cfg.WrapOpts.PackagePath, dst)
+	}
+	return nil
+}
+
+// firstNonEmpty returns the first value that is set, in priority order
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundle contains functions for interacting with, managing and deploying UDS packages
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/corang/uds-cli/src/config"
+	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+)
+
+// zarfInitPackageName is the name Zarf init packages have in bundles, deploy applies it with the Zarf init options
+const zarfInitPackageName = "init"
+
+// includeZarfInit adds the Zarf init package of --include-zarf-init's version as the bundle's first package, so the
+// bundle can bootstrap a cluster that Zarf hasn't been initialized in
+func (b *Bundler) includeZarfInit() error {
+	if b.cfg.CreateOpts.IncludeZarfInit == "" {
+		return nil
+	}
+	version, err := zarfInitVersion(b.cfg.CreateOpts.IncludeZarfInit)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range b.bundle.ZarfPackages {
+		if pkg.Name == zarfInitPackageName {
+			return fmt.Errorf("--include-zarf-init adds a package named %s, but the bundle already has one", zarfInitPackageName)
+		}
+	}
+
+	arch := b.cfg.Arch.Resolve(b.bundle.Metadata.Architecture)
+	cacheDir := filepath.Join(zarfConfig.GetAbsCachePath(), config.ZarfInitCacheDir)
+	if err := downloadZarfInit(config.ZarfReleasesURL, version, arch, cacheDir, b.cfg.CreateOpts.Offline); err != nil {
+		return err
+	}
+	initPkg := types.BundleZarfPackage{Name: zarfInitPackageName, Path: cacheDir, Ref: version}
+	b.bundle.ZarfPackages = append([]types.BundleZarfPackage{initPkg}, b.bundle.ZarfPackages...)
+	return nil
+}
+
+// zarfInitVersion returns the Zarf release tag of version, which must be a semantic version with or without a v prefix
+func zarfInitVersion(version string) (string, error) {
+	if _, err := semver.StrictNewVersion(strings.TrimPrefix(version, "v")); err != nil {
+		return "", fmt.Errorf("invalid --include-zarf-init %q, it must be a Zarf version (ie. v0.29.1): %w", version, err)
+	}
+	return "v" + strings.TrimPrefix(version, "v"), nil
+}
+
+// downloadZarfInit downloads the Zarf init package of version for arch from releasesURL into dir by the name Zarf
+// gives it, unless it's already cached there
+func downloadZarfInit(releasesURL, version, arch, dir string, offline bool) error {
+	name := zarfPackageTarballName(zarfInitPackageName, arch, version)
+	dst := filepath.Join(dir, name)
+	if !zarfUtils.InvalidPath(dst) {
+		message.Debugf("Using the cached Zarf init package %s", dst)
+		return nil
+	}
+	if offline {
+		return fmt.Errorf("the Zarf init package %s isn't cached in %s, it can't be downloaded with --offline", name, dir)
+	}
+
+	// downloaded under another name so a failed download is never mistaken for a cached package
+	src := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(releasesURL, "/"), version, name)
+	partial := dst + ".partial"
+	if err := zarfUtils.DownloadToFile(src, partial, ""); err != nil {
+		_ = os.Remove(partial)
+		return fmt.Errorf("unable to download the Zarf init package from %s: %w", src, err)
+	}
+	return os.Rename(partial, dst)
+}
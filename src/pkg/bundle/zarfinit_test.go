@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_zarfInitVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		version     string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "Tag",
+			description: "Zarf release tags are used as is",
+			version:     "v0.29.1",
+			want:        "v0.29.1",
+		},
+		{
+			name:        "NoPrefix",
+			description: "versions without a v prefix are given one like Zarf's release tags",
+			version:     "0.29.1",
+			want:        "v0.29.1",
+		},
+		{
+			name:        "Invalid",
+			description: "versions must be semantic versions",
+			version:     "latest",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := zarfInitVersion(tt.version)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("zarfInitVersion() = %q, %v, want %q, wantErr %v (%s)", got, err, tt.want, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_downloadZarfInit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v0.29.1/zarf-init-amd64-v0.29.1.tar.zst" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("init package"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		description  string
+		version      string
+		cached       bool
+		offline      bool
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "Download",
+			description:  "the init package is downloaded from the release into the cache",
+			version:      "v0.29.1",
+			wantRequests: 1,
+		},
+		{
+			name:         "Cached",
+			description:  "a cached init package isn't downloaded again",
+			version:      "v0.29.1",
+			cached:       true,
+			wantRequests: 0,
+		},
+		{
+			name:         "CachedOffline",
+			description:  "a cached init package can be bundled with --offline",
+			version:      "v0.29.1",
+			cached:       true,
+			offline:      true,
+			wantRequests: 0,
+		},
+		{
+			name:        "Offline",
+			description: "init packages aren't downloaded with --offline",
+			version:     "v0.29.1",
+			offline:     true,
+			wantErr:     true,
+		},
+		{
+			name:         "NotReleased",
+			description:  "versions without a release fail without leaving a file in the cache",
+			version:      "v0.0.0",
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			dir := t.TempDir()
+			dst := filepath.Join(dir, zarfPackageTarballName(zarfInitPackageName, "amd64", tt.version))
+			if tt.cached {
+				if err := os.WriteFile(dst, []byte("cached init package"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := downloadZarfInit(server.URL, tt.version, "amd64", dir, tt.offline)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadZarfInit() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("downloadZarfInit() made %d requests, want %d (%s)", requests, tt.wantRequests, tt.description)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr {
+				if len(entries) != 0 {
+					t.Errorf("downloadZarfInit() left %s in the cache (%s)", entries[0].Name(), tt.description)
+				}
+				return
+			}
+			if len(entries) != 1 || entries[0].Name() != filepath.Base(dst) {
+				t.Errorf("downloadZarfInit() cache has %v, want only %s (%s)", entries, filepath.Base(dst), tt.description)
+			}
+		})
+	}
+}
+
+func Test_includeZarfInitDuplicate(t *testing.T) {
+	b := &Bundler{
+		cfg:    &types.BundlerConfig{CreateOpts: types.BundlerCreateOptions{IncludeZarfInit: "v0.29.1"}},
+		bundle: types.UDSBundle{ZarfPackages: []types.BundleZarfPackage{{Name: zarfInitPackageName, Path: "../zarf", Ref: "v0.29.1"}}},
+	}
+	if err := b.includeZarfInit(); err == nil {
+		t.Errorf("includeZarfInit() didn't fail for a bundle that already has an init package")
+	}
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundler defines behavior for bundling packages
+package bundler
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// blobCache finds blobs in the Zarf cache by digest: the OCI layout uds pull keeps bundles in and Zarf's cache of
+// image layers, so packages and images already on the machine aren't downloaded again
+type blobCache struct {
+	root string
+}
+
+// newBlobCache returns a cache reading from the Zarf cache at root, an empty root caches nothing
+func newBlobCache(root string) blobCache {
+	return blobCache{root: root}
+}
+
+// open returns the cached blob of desc, or false if it isn't cached
+//
+// only the blob's size is checked, the caller must verify its digest as it's read
+func (c blobCache) open(desc ocispec.Descriptor) (io.ReadCloser, bool) {
+	if c.root == "" || desc.Digest.Validate() != nil {
+		return nil, false
+	}
+	for _, path := range []string{
+		filepath.Join(c.root, config.PackageCacheDir, udsUtils.BlobPath(desc.Digest)),
+		// Zarf's image cache names layers after their digest
+		filepath.Join(c.root, zarfConfig.ZarfImageCacheDir, desc.Digest.String()),
+	} {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Size() != desc.Size {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		return f, true
+	}
+	return nil, false
+}
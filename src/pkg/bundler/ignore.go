@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundler defines behavior for bundling packages
+package bundler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/corang/uds-cli/src/config"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// IsPackageDir returns true if path is an unpacked Zarf package rather than a directory of package tarballs
+func IsPackageDir(path string) bool {
+	info, err := os.Stat(filepath.Join(path, config.ZarfYAML))
+	return err == nil && !info.IsDir()
+}
+
+// loadUDSIgnore reads the gitignore-style patterns in a package's .udsignore, a nil matcher means nothing is ignored
+func loadUDSIgnore(pkgDir string) (gitignore.Matcher, error) {
+	f, err := os.Open(filepath.Join(pkgDir, config.UDSIgnore))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, nil))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", config.UDSIgnore, err)
+	}
+	return gitignore.NewMatcher(patterns), nil
+}
+
+// requiredPackageFiles returns the files Zarf needs to load and validate a package
+//
+// this is zarf.yaml, its signature and checksums.txt, along with every file checksums.txt lists
+func requiredPackageFiles(pkgDir string) (map[string]bool, error) {
+	required := map[string]bool{config.ZarfYAML: true}
+	for _, name := range []string{zarfConfig.ZarfYAMLSignature, zarfConfig.ZarfChecksumsTxt} {
+		if _, err := os.Stat(filepath.Join(pkgDir, name)); err == nil {
+			required[name] = true
+		}
+	}
+	if !required[zarfConfig.ZarfChecksumsTxt] {
+		return required, nil
+	}
+
+	f, err := os.Open(filepath.Join(pkgDir, zarfConfig.ZarfChecksumsTxt))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// lines are "<sha256> <path>"
+		if _, rel, ok := strings.Cut(scanner.Text(), " "); ok && rel != "" {
+			required[filepath.ToSlash(rel)] = true
+		}
+	}
+	return required, scanner.Err()
+}
+
+// isIgnored reports whether the file or directory at rel is excluded by a package's .udsignore
+func isIgnored(matcher gitignore.Matcher, rel string, isDir bool) bool {
+	if matcher == nil {
+		return false
+	}
+	return matcher.Match(strings.Split(filepath.ToSlash(rel), "/"), isDir)
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package bundler defines behavior for bundling packages
+package bundler
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/defenseunicorns/zarf/src/pkg/utils"
+)
+
+// progressInterval is how often a layer's progress is reported, so spinners aren't redrawn on every read
+const progressInterval = 500 * time.Millisecond
+
+// progressReader reports the bytes read from a layer and the time left to read the rest
+type progressReader struct {
+	r        io.Reader
+	total    int64
+	read     int64
+	start    time.Time
+	reported time.Time
+	now      func() time.Time
+	report   func(progress string)
+}
+
+// newProgressReader wraps r, a layer of total bytes, calling report with its progress at most every progressInterval
+func newProgressReader(r io.Reader, total int64, report func(progress string)) *progressReader {
+	start := time.Now()
+	return &progressReader{r: r, total: total, start: start, reported: start, now: time.Now, report: report}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if now := p.now(); now.Sub(p.reported) >= progressInterval || err == io.EOF {
+		p.reported = now
+		p.report(p.String())
+	}
+	return n, err
+}
+
+// String formats the progress as read / total, with the time left once there's been enough time to estimate it
+func (p *progressReader) String() string {
+	progress := fmt.Sprintf("%s / %s", utils.ByteFormat(float64(p.read), 2), utils.ByteFormat(float64(p.total), 2))
+	if eta, ok := p.eta(); ok {
+		progress += fmt.Sprintf(", %s left", eta)
+	}
+	return progress
+}
+
+// eta estimates the time left to read the layer from the average rate so far
+func (p *progressReader) eta() (time.Duration, bool) {
+	elapsed := p.reported.Sub(p.start)
+	if p.read <= 0 || p.read >= p.total || elapsed <= 0 {
+		return 0, false
+	}
+	rate := float64(p.read) / elapsed.Seconds()
+	remaining := time.Duration(float64(p.total-p.read) / rate * float64(time.Second))
+	return remaining.Round(time.Second), true
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundler
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_progressReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		size        int
+		elapsed     time.Duration
+		readBytes   int
+		want        string
+	}{
+		{
+			name:        "HalfRead",
+			description: "the time left is estimated from the rate so far",
+			size:        1000,
+			elapsed:     10 * time.Second,
+			readBytes:   500,
+			want:        "500.00 Bytes / 1.00 KB, 10s left",
+		},
+		{
+			name:        "JustStarted",
+			description: "no estimate is given before any time has passed",
+			size:        1000,
+			readBytes:   500,
+			want:        "500.00 Bytes / 1.00 KB",
+		},
+		{
+			name:        "Done",
+			description: "a fully read layer has no time left",
+			size:        1000,
+			elapsed:     10 * time.Second,
+			readBytes:   1000,
+			want:        "1.00 KB / 1.00 KB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			clock := start
+			var reported []string
+			p := newProgressReader(bytes.NewReader(make([]byte, tt.size)), int64(tt.size), func(progress string) {
+				reported = append(reported, progress)
+			})
+			p.start, p.reported = start, start
+			p.now = func() time.Time { return clock }
+
+			clock = start.Add(tt.elapsed)
+			if _, err := io.ReadFull(p, make([]byte, tt.readBytes)); err != nil {
+				t.Fatal(err)
+			}
+			if tt.elapsed > 0 && len(reported) != 1 {
+				t.Errorf("progressReader reported %d times, want 1 (%s)", len(reported), tt.description)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("progressReader.String() = %q, want %q (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_progressReaderReportsEOF(t *testing.T) {
+	var reported []string
+	p := newProgressReader(strings.NewReader("layer"), 5, func(progress string) {
+		reported = append(reported, progress)
+	})
+	if _, err := io.ReadAll(p); err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) == 0 {
+		t.Errorf("progressReader did not report the end of the layer")
+	}
+}
@@ -8,11 +8,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"path/filepath"
 
 	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
 	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
 	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
@@ -20,6 +24,7 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
 	ocistore "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
 )
 
 // RemoteBundler contains methods for pulling remote Zarf packages into a bundle
@@ -30,11 +35,13 @@ type RemoteBundler struct {
 	RemoteSrc       *oci.OrasRemote
 	RemoteDst       *oci.OrasRemote
 	localDst        *ocistore.Store
+	cache           blobCache
+	excludeSBOM     bool
 }
 
 // NewRemoteBundler creates a bundler to pull remote Zarf pkgs
 func NewRemoteBundler(pkg types.BundleZarfPackage, url string, localDst *ocistore.Store, remoteDst *oci.OrasRemote) (RemoteBundler, error) {
-	src, err := oci.NewOrasRemote(url)
+	src, err := udsUtils.NewOrasRemote(url)
 	if err != nil {
 		return RemoteBundler{}, err
 	}
@@ -43,14 +50,14 @@ func NewRemoteBundler(pkg types.BundleZarfPackage, url string, localDst *ocistor
 		return RemoteBundler{}, err
 	}
 	if localDst != nil {
-		return RemoteBundler{ctx: context.TODO(), RemoteSrc: src, localDst: localDst, PkgRootManifest: pkgRootManifest, pkg: pkg}, err
+		return RemoteBundler{ctx: udsUtils.NetworkContext(), RemoteSrc: src, localDst: localDst, PkgRootManifest: pkgRootManifest, pkg: pkg, cache: newBlobCache(zarfConfig.GetAbsCachePath())}, err
 	}
-	return RemoteBundler{ctx: context.TODO(), RemoteSrc: src, RemoteDst: remoteDst, PkgRootManifest: pkgRootManifest, pkg: pkg}, err
+	return RemoteBundler{ctx: udsUtils.NetworkContext(), RemoteSrc: src, RemoteDst: remoteDst, PkgRootManifest: pkgRootManifest, pkg: pkg}, err
 }
 
 // GetMetadata grabs metadata from a remote Zarf package's zarf.yaml
 func (b *RemoteBundler) GetMetadata(url string, tmpDir string) (zarfTypes.ZarfPackage, error) {
-	remote, err := oci.NewOrasRemote(url)
+	remote, err := udsUtils.NewOrasRemote(url)
 	if err != nil {
 		return zarfTypes.ZarfPackage{}, err
 	}
@@ -68,6 +75,14 @@ func (b *RemoteBundler) GetMetadata(url string, tmpDir string) (zarfTypes.ZarfPa
 	return zarfYAML, err
 }
 
+// ExcludeSBOM leaves the package's sboms.tar out of the bundle, dropping it from the package manifest
+//
+// the package manifest's digest changes, so the bundle must reference the digest of the pushed manifest
+func (b *RemoteBundler) ExcludeSBOM() {
+	b.excludeSBOM = true
+	b.PkgRootManifest.Layers = withoutSBOM(b.PkgRootManifest.Layers)
+}
+
 // PushManifest pushes the Zarf pkg's manifest to either a local or remote bundle
 func (b *RemoteBundler) PushManifest() (ocispec.Descriptor, error) {
 	pkgManifestBytes, err := json.Marshal(b.PkgRootManifest)
@@ -80,7 +95,7 @@ func (b *RemoteBundler) PushManifest() (ocispec.Descriptor, error) {
 		zarfManifestDesc = content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, pkgManifestBytes)
 		err = b.localDst.Push(b.ctx, zarfManifestDesc, bytes.NewReader(pkgManifestBytes))
 	} else {
-		zarfManifestDesc, err = b.RemoteDst.PushLayer(pkgManifestBytes, oci.ZarfLayerMediaTypeBlob)
+		zarfManifestDesc, err = udsUtils.PushLayer(b.RemoteDst, pkgManifestBytes, oci.ZarfLayerMediaTypeBlob)
 	}
 	if err != nil {
 		return ocispec.Descriptor{}, err
@@ -89,13 +104,16 @@ func (b *RemoteBundler) PushManifest() (ocispec.Descriptor, error) {
 }
 
 // PushLayers pushes a Zarf pkg's layers to either a local or remote bundle
-func (b *RemoteBundler) PushLayers(spinner *message.Spinner, currentPackageIter int, totalPackages int) ([]ocispec.Descriptor, error) {
+func (b *RemoteBundler) PushLayers(spinner udsUtils.Progress, currentPackageIter int, totalPackages int) ([]ocispec.Descriptor, error) {
 	// get only the layers that are required by the components
 	spinner.Updatef("Fetching %s package layer metadata (package %d of %d)", b.pkg.Name, currentPackageIter, totalPackages)
 	layersToCopy, err := getZarfLayers(b.RemoteSrc, b.pkg, b.PkgRootManifest)
 	if err != nil {
 		return nil, err
 	}
+	if b.excludeSBOM {
+		layersToCopy = withoutSBOM(layersToCopy)
+	}
 	if b.localDst != nil {
 		layerDescs, err := handleLocalCopy(layersToCopy, b, spinner, currentPackageIter, totalPackages)
 		if err != nil {
@@ -112,6 +130,37 @@ func (b *RemoteBundler) PushLayers(spinner *message.Spinner, currentPackageIter
 	return nil, err
 }
 
+// MissingLayers returns the Zarf pkg's layers that don't exist in the remote bundle, for bundles published with
+// --manifest-only whose layers were pushed by a separate mirror process
+func (b *RemoteBundler) MissingLayers() ([]ocispec.Descriptor, error) {
+	layers, err := getZarfLayers(b.RemoteSrc, b.pkg, b.PkgRootManifest)
+	if err != nil {
+		return nil, err
+	}
+	if b.excludeSBOM {
+		layers = withoutSBOM(layers)
+	}
+	return missingLayers(b.ctx, b.RemoteDst.Repo(), layers)
+}
+
+// missingLayers returns the layers that don't exist in target
+func missingLayers(ctx context.Context, target content.ReadOnlyStorage, layers []ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	var missing []ocispec.Descriptor
+	for _, layer := range layers {
+		if layer.Digest == "" {
+			continue
+		}
+		exists, err := target.Exists(ctx, layer)
+		if err != nil {
+			return nil, fmt.Errorf("unable to check if %s exists: %w", layer.Digest, err)
+		}
+		if !exists {
+			missing = append(missing, layer)
+		}
+	}
+	return missing, nil
+}
+
 // handleRemoteCopy copies a remote Zarf pkg to a remote OCI registry
 func handleRemoteCopy(b *RemoteBundler, layersToCopy []ocispec.Descriptor) error {
 	// stream copy if different registry
@@ -140,7 +189,7 @@ func handleRemoteCopy(b *RemoteBundler, layersToCopy []ocispec.Descriptor) error
 		spinner := message.NewProgressSpinner("Mounting layers from %s", srcRef.Repository)
 		layersToCopy = append(layersToCopy, b.PkgRootManifest.Config)
 		for _, layer := range layersToCopy {
-			if layer.Digest == "" {
+			if layer.Digest == "" || udsUtils.LayerExists(b.RemoteDst.Repo(), layer) {
 				continue
 			}
 			spinner.Updatef("Mounting %s", layer.Digest.Encoded())
@@ -156,7 +205,7 @@ func handleRemoteCopy(b *RemoteBundler, layersToCopy []ocispec.Descriptor) error
 }
 
 // handleLocalCopy copies a remote Zarf pkg to a local OCI store
-func handleLocalCopy(layersToCopy []ocispec.Descriptor, b *RemoteBundler, spinner *message.Spinner, currentPackageIter int, totalPackages int) ([]ocispec.Descriptor, error) {
+func handleLocalCopy(layersToCopy []ocispec.Descriptor, b *RemoteBundler, spinner udsUtils.Progress, currentPackageIter int, totalPackages int) ([]ocispec.Descriptor, error) {
 	// pull layers from remote and write to OCI artifact dir
 	var layerDescs []ocispec.Descriptor
 	for i, layer := range layersToCopy {
@@ -170,16 +219,39 @@ func handleLocalCopy(layersToCopy []ocispec.Descriptor, b *RemoteBundler, spinne
 			return nil, err
 		}
 
+		// layers already in the Zarf cache are read from disk, the store checks their digest as they're pushed
+		if cached, ok := b.cache.open(layer); ok {
+			spinner.Updatef("Reusing %s layer %d of %d from the Zarf cache (package %d of %d)", b.pkg.Name, i+1, len(layersToCopy), currentPackageIter, totalPackages)
+			err := b.localDst.Push(b.ctx, layer, cached)
+			cached.Close()
+			if errors.Is(err, errdef.ErrAlreadyExists) {
+				continue
+			} else if err == nil {
+				layerDescs = append(layerDescs, layer)
+				continue
+			}
+			message.Debugf("Cached layer %s doesn't match its digest, fetching it from the registry: %s", layer.Digest, err)
+		}
+
 		spinner.Updatef("Fetching %s layer %d of %d (package %d of %d)", b.pkg.Name, i+1, len(layersToCopy), currentPackageIter, totalPackages)
-		layerBytes, err := b.RemoteSrc.FetchLayer(layer)
+		rc, err := b.RemoteSrc.Repo().Fetch(b.ctx, layer)
 		if err != nil {
 			return nil, err
 		}
-		layerDesc := content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, layerBytes)
-		if err := b.localDst.Push(b.ctx, layerDesc, bytes.NewReader(layerBytes)); err != nil {
+		// stream the layer into the store, reporting bytes and time left on the package's spinner
+		progress := newProgressReader(rc, layer.Size, func(progress string) {
+			spinner.Updatef("Fetching %s layer %d of %d: %s (package %d of %d)", b.pkg.Name, i+1, len(layersToCopy), progress, currentPackageIter, totalPackages)
+		})
+		// keep the source descriptor as-is so image layers and configs keep their original media types
+		err = b.localDst.Push(b.ctx, layer, progress)
+		rc.Close()
+		if errors.Is(err, errdef.ErrAlreadyExists) {
+			// a package fetched in parallel pushed the same layer first
+			continue
+		} else if err != nil {
 			return nil, err
 		}
-		layerDescs = append(layerDescs, layerDesc)
+		layerDescs = append(layerDescs, layer)
 	}
 	return layerDescs, nil
 }
@@ -202,3 +274,15 @@ func getZarfLayers(remote *oci.OrasRemote, pkg types.BundleZarfPackage, pkgRootM
 	layersToCopy = append(layersToCopy, pkgRootManifest.Config)
 	return layersToCopy, err
 }
+
+// withoutSBOM returns the layers other than a Zarf pkg's sboms.tar
+func withoutSBOM(layers []ocispec.Descriptor) []ocispec.Descriptor {
+	var filtered []ocispec.Descriptor
+	for _, layer := range layers {
+		if layer.Annotations[ocispec.AnnotationTitle] == config.SBOMsTar {
+			continue
+		}
+		filtered = append(filtered, layer)
+	}
+	return filtered
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/corang/uds-cli/src/types"
+	zarfConfig "github.com/defenseunicorns/zarf/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	ocistore "oras.land/oras-go/v2/content/oci"
+)
+
+// newBlobRegistry serves the given blobs from /v2/<repo>/blobs/<digest>
+func newBlobRegistry(t *testing.T, blobs map[digest.Digest][]byte) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, dgst, found := strings.Cut(r.URL.Path, "/blobs/")
+		b, ok := blobs[digest.Digest(dgst)]
+		if !found || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(b)))
+		w.Header().Set("Docker-Content-Digest", dgst)
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_handleLocalCopy(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	blobs := map[string][]byte{
+		ocispec.MediaTypeImageLayerGzip: []byte("image layer"),
+		ocispec.MediaTypeImageConfig:    []byte(`{"architecture":"amd64"}`),
+		oci.ZarfLayerMediaTypeBlob:      []byte("kind: ZarfPackageConfig"),
+	}
+	var layers []ocispec.Descriptor
+	byDigest := make(map[digest.Digest][]byte)
+	for mediaType, b := range blobs {
+		layer := content.NewDescriptorFromBytes(mediaType, b)
+		layer.Annotations = map[string]string{ocispec.AnnotationTitle: mediaType}
+		layers = append(layers, layer)
+		byDigest[layer.Digest] = b
+	}
+	server := newBlobRegistry(t, byDigest)
+
+	src, err := udsUtils.NewOrasRemote(fmt.Sprintf("oci://%s/packages/test:0.0.1", strings.TrimPrefix(server.URL, "http://")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.WithInsecureConnection(true)
+	store, err := ocistore.NewWithContext(context.TODO(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &RemoteBundler{ctx: context.TODO(), pkg: types.BundleZarfPackage{Name: "test"}, RemoteSrc: src, localDst: store}
+
+	spinner := message.NewProgressSpinner("Copying layers")
+	defer spinner.Stop()
+	got, err := handleLocalCopy(layers, b, spinner, 1, 1)
+	if err != nil {
+		t.Fatalf("handleLocalCopy() error = %v", err)
+	}
+	if len(got) != len(layers) {
+		t.Fatalf("handleLocalCopy() copied %d layers, want %d", len(got), len(layers))
+	}
+	for i, layer := range layers {
+		if got[i].MediaType != layer.MediaType {
+			t.Errorf("layer %s media type = %s, want %s", layer.Digest, got[i].MediaType, layer.MediaType)
+		}
+		if got[i].Annotations[ocispec.AnnotationTitle] != layer.Annotations[ocispec.AnnotationTitle] {
+			t.Errorf("layer %s annotations = %v, want %v", layer.Digest, got[i].Annotations, layer.Annotations)
+		}
+		if exists, err := store.Exists(context.TODO(), layer); !exists || err != nil {
+			t.Errorf("layer %s was not copied to the store: %v", layer.Digest, err)
+		}
+	}
+}
+
+func Test_handleLocalCopyCache(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	cacheRoot := t.TempDir()
+	writeCached := func(path string, b []byte) {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// pulled is in uds pull's bundle cache, image is in Zarf's image cache and corrupt doesn't match its digest
+	pulled := content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, []byte("pulled package layer"))
+	writeCached(filepath.Join(cacheRoot, config.PackageCacheDir, udsUtils.BlobPath(pulled.Digest)), []byte("pulled package layer"))
+	image := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, []byte("cached image layer"))
+	writeCached(filepath.Join(cacheRoot, zarfConfig.ZarfImageCacheDir, image.Digest.String()), []byte("cached image layer"))
+	corrupt := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, []byte("corrupt layer"))
+	writeCached(filepath.Join(cacheRoot, zarfConfig.ZarfImageCacheDir, corrupt.Digest.String()), []byte("CORRUPT LAYER"))
+
+	// the registry only has the layer whose cached copy is corrupt
+	server := newBlobRegistry(t, map[digest.Digest][]byte{corrupt.Digest: []byte("corrupt layer")})
+	src, err := udsUtils.NewOrasRemote(fmt.Sprintf("oci://%s/packages/test:0.0.1", strings.TrimPrefix(server.URL, "http://")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.WithInsecureConnection(true)
+	store, err := ocistore.NewWithContext(context.TODO(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &RemoteBundler{ctx: context.TODO(), pkg: types.BundleZarfPackage{Name: "test"}, RemoteSrc: src, localDst: store, cache: newBlobCache(cacheRoot)}
+
+	spinner := message.NewProgressSpinner("Copying layers")
+	defer spinner.Stop()
+	layers := []ocispec.Descriptor{pulled, image, corrupt}
+	got, err := handleLocalCopy(layers, b, spinner, 1, 1)
+	if err != nil {
+		t.Fatalf("handleLocalCopy() error = %v", err)
+	}
+	if len(got) != len(layers) {
+		t.Fatalf("handleLocalCopy() copied %d layers, want %d", len(got), len(layers))
+	}
+	for _, layer := range layers {
+		if exists, err := store.Exists(context.TODO(), layer); !exists || err != nil {
+			t.Errorf("layer %s was not copied to the store: %v", layer.Digest, err)
+		}
+	}
+}
+
+func Test_withoutSBOM(t *testing.T) {
+	layers := []ocispec.Descriptor{
+		{Digest: digest.FromString("zarf.yaml"), Annotations: map[string]string{ocispec.AnnotationTitle: "zarf.yaml"}},
+		{Digest: digest.FromString("sboms.tar"), Annotations: map[string]string{ocispec.AnnotationTitle: "sboms.tar"}},
+		{Digest: digest.FromString("config")},
+	}
+	got := withoutSBOM(layers)
+	if len(got) != 2 {
+		t.Fatalf("withoutSBOM() returned %d layers, want 2", len(got))
+	}
+	for _, layer := range got {
+		if layer.Annotations[ocispec.AnnotationTitle] == "sboms.tar" {
+			t.Errorf("withoutSBOM() kept %s", layer.Digest)
+		}
+	}
+}
+
+func Test_missingLayers(t *testing.T) {
+	ctx := context.TODO()
+	store := memory.New()
+	seeded := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, []byte("seeded layer"))
+	if err := store.Push(ctx, seeded, bytes.NewReader([]byte("seeded layer"))); err != nil {
+		t.Fatal(err)
+	}
+	unseeded := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, []byte("unseeded layer"))
+
+	tests := []struct {
+		name        string
+		description string
+		layers      []ocispec.Descriptor
+		want        []digest.Digest
+	}{
+		{
+			name:        "AllExist",
+			description: "layers seeded by a mirror aren't missing",
+			layers:      []ocispec.Descriptor{seeded},
+			want:        nil,
+		},
+		{
+			name:        "Missing",
+			description: "only the layers that aren't in the registry are returned",
+			layers:      []ocispec.Descriptor{seeded, unseeded},
+			want:        []digest.Digest{unseeded.Digest},
+		},
+		{
+			name:        "EmptyDigest",
+			description: "empty descriptors are skipped",
+			layers:      []ocispec.Descriptor{{}},
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, err := missingLayers(ctx, store, tt.layers)
+			if err != nil {
+				t.Fatalf("missingLayers() error = %v", err)
+			}
+			var got []digest.Digest
+			for _, layer := range missing {
+				got = append(got, layer.Digest)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("missingLayers() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
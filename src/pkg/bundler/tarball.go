@@ -8,12 +8,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/corang/uds-cli/src/config"
+	udsUtils "github.com/corang/uds-cli/src/pkg/utils"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/oci"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
 	zarfTypes "github.com/defenseunicorns/zarf/src/types"
@@ -25,6 +29,7 @@ import (
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/file"
 	ocistore "oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
 )
 
 // LocalBundler contains methods for loading local Zarf packages into a bundle
@@ -32,15 +37,46 @@ type LocalBundler struct {
 	ctx          context.Context
 	tarballSrc   string
 	extractedDst string
+	excludeSBOM  bool
+	// srcIsDir is set when the package is an unpacked directory, which is bundled in place instead of extracted
+	srcIsDir bool
+	// expectedSha256 is the digest the tarball must match before it's extracted, empty skips the check
+	expectedSha256 string
 }
 
-// NewLocalBundler creates a bundler for bundling local Zarf pkgs
+// NewLocalBundler creates a bundler for bundling local Zarf pkgs, either tarballs or unpacked package directories
 func NewLocalBundler(src, dest string) LocalBundler {
-	return LocalBundler{tarballSrc: src, extractedDst: dest, ctx: context.TODO()}
+	return LocalBundler{tarballSrc: src, extractedDst: dest, ctx: context.TODO(), srcIsDir: IsPackageDir(src)}
+}
+
+// PackageDir returns the directory holding the package's files once it has been extracted
+func (b *LocalBundler) PackageDir() string {
+	if b.srcIsDir {
+		return b.tarballSrc
+	}
+	return b.extractedDst
+}
+
+// ExcludeSBOM leaves the package's sboms.tar out of the bundle
+func (b *LocalBundler) ExcludeSBOM() {
+	b.excludeSBOM = true
+}
+
+// ExpectSha256 makes Extract fail unless the package tarball's SHA256 digest is expected (with or without a sha256: prefix)
+func (b *LocalBundler) ExpectSha256(expected string) {
+	b.expectedSha256 = strings.ToLower(strings.TrimPrefix(expected, "sha256:"))
 }
 
 // GetMetadata grabs metadata from a local Zarf package's zarf.yaml
 func (b *LocalBundler) GetMetadata(pathToTarball string, tmpDir string) (zarfTypes.ZarfPackage, error) {
+	if IsPackageDir(pathToTarball) {
+		zarfYAML := zarfTypes.ZarfPackage{}
+		if err := utils.ReadYaml(filepath.Join(pathToTarball, config.ZarfYAML), &zarfYAML); err != nil {
+			return zarfTypes.ZarfPackage{}, err
+		}
+		// write zarf.yaml to tmp for checking optional components later on, like it is for tarballs
+		return zarfYAML, utils.WriteYaml(filepath.Join(tmpDir, config.ZarfYAML), zarfYAML, 0600)
+	}
 	zarfTarball, err := os.Open(pathToTarball)
 	if err != nil {
 		return zarfTypes.ZarfPackage{}, err
@@ -82,6 +118,12 @@ func (b *LocalBundler) GetMetadata(pathToTarball string, tmpDir string) (zarfTyp
 
 // Extract extracts a compressed Zarf archive into a directory
 func (b *LocalBundler) Extract() error {
+	if b.srcIsDir {
+		return nil
+	}
+	if err := b.verifySha256(); err != nil {
+		return err
+	}
 	err := av3.Unarchive(b.tarballSrc, b.extractedDst) // todo: awkward to use old version of mholt/archiver
 	if err != nil {
 		return err
@@ -89,10 +131,26 @@ func (b *LocalBundler) Extract() error {
 	return nil
 }
 
+// verifySha256 checks the package tarball against the expected digest, if one was given
+func (b *LocalBundler) verifySha256() error {
+	if b.expectedSha256 == "" {
+		return nil
+	}
+	actual, err := utils.GetSHA256OfFile(b.tarballSrc)
+	if err != nil {
+		return err
+	}
+	if actual != b.expectedSha256 {
+		return fmt.Errorf("package tarball %s has digest sha256:%s, expected sha256:%s", b.tarballSrc, actual, b.expectedSha256)
+	}
+	message.Debugf("Package tarball %s matches its expected digest sha256:%s", b.tarballSrc, actual)
+	return nil
+}
+
 // Load loads a zarf.yaml into a Zarf object
 func (b *LocalBundler) Load() (zarfTypes.ZarfPackage, error) {
 	// grab zarf.yaml from extracted archive
-	p, err := os.ReadFile(filepath.Join(b.extractedDst, config.ZarfYAML))
+	p, err := os.ReadFile(filepath.Join(b.PackageDir(), config.ZarfYAML))
 	if err != nil {
 		return zarfTypes.ZarfPackage{}, err
 	}
@@ -111,23 +169,53 @@ func (b *LocalBundler) ToBundle(bundleStore *ocistore.Store, pkg zarfTypes.ZarfP
 	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
+	ignore, err := loadUDSIgnore(packageTmpDir)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	required, err := requiredPackageFiles(packageTmpDir)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
 	// Grab Zarf layers
 	paths := []string{}
+	included := make(map[string]bool)
 	err = filepath.Walk(packageTmpDir, func(path string, info os.FileInfo, err error) error {
 		// Catch any errors that happened during the walk
 		if err != nil {
 			return err
 		}
+		rel, err := filepath.Rel(packageTmpDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if rel == config.UDSIgnore || isIgnored(ignore, rel, info.IsDir()) {
+			message.Debugf("Excluding %s from %s, it matches %s", rel, packageTmpDir, config.UDSIgnore)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		// Add any resource that is not a directory to the paths of objects we will include into the package
 		if !info.IsDir() {
 			paths = append(paths, path)
+			included[filepath.ToSlash(rel)] = true
 		}
 		return nil
 	})
 	if err != nil {
 		return ocispec.Descriptor{}, fmt.Errorf("unable to get the layers in the package to publish: %w", err)
 	}
+	for name := range required {
+		if !included[name] {
+			return ocispec.Descriptor{}, fmt.Errorf("%s excludes %s, which is required to deploy the package", config.UDSIgnore, name)
+		}
+	}
 
 	var descs []ocispec.Descriptor
 	for _, path := range paths {
@@ -135,6 +223,9 @@ func (b *LocalBundler) ToBundle(bundleStore *ocistore.Store, pkg zarfTypes.ZarfP
 		if err != nil {
 			return ocispec.Descriptor{}, err
 		}
+		if b.excludeSBOM && name == config.SBOMsTar {
+			continue
+		}
 
 		mediaType := oci.ZarfLayerMediaTypeBlob
 
@@ -150,15 +241,16 @@ func (b *LocalBundler) ToBundle(bundleStore *ocistore.Store, pkg zarfTypes.ZarfP
 
 		// push if layer doesn't already exist in bundleStore
 		if exists, err := bundleStore.Exists(ctx, desc); !exists && err == nil {
-			if err := bundleStore.Push(ctx, desc, layer); err != nil {
+			// a package fetched in parallel may have pushed the same layer first
+			if err := bundleStore.Push(ctx, desc, layer); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
 				return ocispec.Descriptor{}, err
 			}
 		} else if err != nil {
 			return ocispec.Descriptor{}, err
 		}
 
-		digest := desc.Digest.Encoded()
-		artifactPathMap[filepath.Join(bundleTmpDir, config.BlobsDir, digest)] = filepath.Join(config.BlobsDir, digest)
+		blobPath := udsUtils.BlobPath(desc.Digest)
+		artifactPathMap[filepath.Join(bundleTmpDir, blobPath)] = blobPath
 		descs = append(descs, desc)
 	}
 	// push the manifest config
@@ -184,7 +276,7 @@ func pushZarfManifestConfigFromMetadata(store *ocistore.Store, metadata *zarfTyp
 	}
 	manifestConfig := oci.ConfigPartial{
 		Architecture: build.Architecture,
-		OCIVersion:   "1.0.1",
+		OCIVersion:   config.GetOCIVersion(),
 		Annotations:  annotations,
 	}
 	manifestConfigBytes, err := json.Marshal(manifestConfig)
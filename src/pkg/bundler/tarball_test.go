@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package bundler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	zarfTypes "github.com/defenseunicorns/zarf/src/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	ocistore "oras.land/oras-go/v2/content/oci"
+)
+
+func Test_ToBundleExcludeSBOM(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		excludeSBOM bool
+		wantLayers  []string
+	}{
+		{
+			name:        "KeepSBOM",
+			description: "sboms.tar is bundled by default",
+			wantLayers:  []string{config.SBOMsTar, config.ZarfYAML},
+		}, {
+			name:        "ExcludeSBOM",
+			description: "sboms.tar is left out of the package manifest",
+			excludeSBOM: true,
+			wantLayers:  []string{config.ZarfYAML},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgTmp := t.TempDir()
+			for _, name := range []string{config.ZarfYAML, config.SBOMsTar} {
+				if err := os.WriteFile(filepath.Join(pkgTmp, name), []byte(name), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			bundleTmp := t.TempDir()
+			store, err := ocistore.NewWithContext(context.TODO(), bundleTmp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := NewLocalBundler("", pkgTmp)
+			if tt.excludeSBOM {
+				b.ExcludeSBOM()
+			}
+			desc, err := b.ToBundle(store, zarfTypes.ZarfPackage{}, make(map[string]string), bundleTmp, pkgTmp)
+			if err != nil {
+				t.Fatalf("ToBundle() error = %v", err)
+			}
+			manifestBytes, err := content.FetchAll(context.TODO(), store, desc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var manifest ocispec.Manifest
+			if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			if len(manifest.Layers) != len(tt.wantLayers) {
+				t.Fatalf("ToBundle() manifest has %d layers, want %d", len(manifest.Layers), len(tt.wantLayers))
+			}
+			for i, layer := range manifest.Layers {
+				if title := layer.Annotations[ocispec.AnnotationTitle]; title != tt.wantLayers[i] {
+					t.Errorf("ToBundle() layer %d = %s, want %s", i, title, tt.wantLayers[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_ToBundleUDSIgnore(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		udsIgnore   string
+		wantLayers  []string
+		wantErr     bool
+	}{
+		{
+			name:        "NoIgnore",
+			description: "every file in the package directory is bundled without a .udsignore",
+			wantLayers:  []string{"README.md", "build/cache.bin", "checksums.txt", "components/podinfo.tar", config.ZarfYAML},
+		}, {
+			name:        "Ignore",
+			description: "files and directories matching .udsignore are left out",
+			udsIgnore:   "# build leftovers\nbuild/\n*.md\n",
+			wantLayers:  []string{"checksums.txt", "components/podinfo.tar", config.ZarfYAML},
+		}, {
+			name:        "Negate",
+			description: "negated patterns re-include files",
+			udsIgnore:   "*.md\n!README.md\nbuild/\n",
+			wantLayers:  []string{"README.md", "checksums.txt", "components/podinfo.tar", config.ZarfYAML},
+		}, {
+			name:        "IgnoreZarfYAML",
+			description: "error when zarf.yaml is excluded",
+			udsIgnore:   "*.yaml\n",
+			wantErr:     true,
+		}, {
+			name:        "IgnoreChecksummedFile",
+			description: "error when a file listed in checksums.txt is excluded",
+			udsIgnore:   "components/\n",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgDir := t.TempDir()
+			files := map[string]string{
+				config.ZarfYAML:          "kind: ZarfPackageConfig",
+				"checksums.txt":          "abc components/podinfo.tar\n",
+				"components/podinfo.tar": "podinfo",
+				"README.md":              "readme",
+				"build/cache.bin":        "cache",
+			}
+			if tt.udsIgnore != "" {
+				files[config.UDSIgnore] = tt.udsIgnore
+			}
+			for name, data := range files {
+				path := filepath.Join(pkgDir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			bundleTmp := t.TempDir()
+			store, err := ocistore.NewWithContext(context.TODO(), bundleTmp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b := NewLocalBundler(pkgDir, t.TempDir())
+			if b.PackageDir() != pkgDir {
+				t.Fatalf("PackageDir() = %s, want the package directory %s", b.PackageDir(), pkgDir)
+			}
+			desc, err := b.ToBundle(store, zarfTypes.ZarfPackage{}, make(map[string]string), bundleTmp, b.PackageDir())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToBundle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			manifestBytes, err := content.FetchAll(context.TODO(), store, desc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var manifest ocispec.Manifest
+			if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, layer := range manifest.Layers {
+				got = append(got, layer.Annotations[ocispec.AnnotationTitle])
+			}
+			if !reflect.DeepEqual(got, tt.wantLayers) {
+				t.Errorf("ToBundle() layers = %v, want %v", got, tt.wantLayers)
+			}
+		})
+	}
+}
+
+func Test_verifySha256(t *testing.T) {
+	tarball := filepath.Join(t.TempDir(), "zarf-package-test-amd64-0.0.1.tar.zst")
+	if err := os.WriteFile(tarball, []byte("package contents"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	actual := digest.FromBytes([]byte("package contents")).Encoded()
+
+	tests := []struct {
+		name        string
+		description string
+		expected    string
+		wantErr     bool
+	}{
+		{
+			name:        "NotExpected",
+			description: "tarballs without an expectedSha256 aren't checked",
+		},
+		{
+			name:        "Matches",
+			description: "a tarball matching its expectedSha256 passes",
+			expected:    actual,
+		},
+		{
+			name:        "MatchesPrefixedUppercase",
+			description: "the sha256: prefix and case of expectedSha256 don't matter",
+			expected:    "sha256:" + strings.ToUpper(actual),
+		},
+		{
+			name:        "Mismatch",
+			description: "a tarball with a different digest fails",
+			expected:    digest.FromBytes([]byte("other contents")).Encoded(),
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewLocalBundler(tarball, t.TempDir())
+			if tt.expected != "" {
+				b.ExpectSha256(tt.expected)
+			}
+			err := b.verifySha256()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifySha256() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if tt.wantErr && (!strings.Contains(err.Error(), actual) || !strings.Contains(err.Error(), tt.expected)) {
+				t.Errorf("verifySha256() error = %v, want both the actual and expected digests", err)
+			}
+		})
+	}
+}
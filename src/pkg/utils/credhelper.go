@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// credentialHelperPrefix is prepended to a helper's name to get its binary, like docker does
+const credentialHelperPrefix = "docker-credential-"
+
+// cloudCredentialHelpers are the docker credential helpers that issue short-lived tokens for each cloud's registries,
+// in the order they are tried
+var cloudCredentialHelpers = []struct {
+	match   func(host string) bool
+	helpers []string
+}{
+	{
+		// ECR, ie. 123456789012.dkr.ecr.us-east-1.amazonaws.com
+		match: func(host string) bool {
+			return host == "public.ecr.aws" || strings.Contains(host, ".dkr.ecr.") && strings.HasSuffix(host, ".amazonaws.com")
+		},
+		helpers: []string{"ecr-login"},
+	}, {
+		// GCR and Artifact Registry, ie. us-docker.pkg.dev
+		match: func(host string) bool {
+			return host == "gcr.io" || strings.HasSuffix(host, ".gcr.io") || strings.HasSuffix(host, "-docker.pkg.dev")
+		},
+		helpers: []string{"gcloud", "gcr"},
+	}, {
+		// ACR, ie. example.azurecr.io
+		match:   func(host string) bool { return strings.HasSuffix(host, ".azurecr.io") },
+		helpers: []string{"acr-env"},
+	},
+}
+
+// cloudCredentialHelper returns the binary of the installed credential helper for a cloud registry host, if any
+func cloudCredentialHelper(host string) string {
+	for _, cloud := range cloudCredentialHelpers {
+		if !cloud.match(host) {
+			continue
+		}
+		for _, helper := range cloud.helpers {
+			if path, err := exec.LookPath(credentialHelperPrefix + helper); err == nil {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// withCloudCredentials falls back to a cloud registry's credential helper when the docker config has no credentials
+// for it
+//
+// the helper is called on every token exchange, so its short-lived tokens are refreshed as they expire
+func withCloudCredentials(credential func(context.Context, string) (auth.Credential, error)) func(context.Context, string) (auth.Credential, error) {
+	return func(ctx context.Context, host string) (auth.Credential, error) {
+		if credential != nil {
+			cred, err := credential(ctx, host)
+			if err != nil || cred != auth.EmptyCredential {
+				return cred, err
+			}
+		}
+		helper := cloudCredentialHelper(host)
+		if helper == "" {
+			return auth.EmptyCredential, nil
+		}
+		message.Debugf("Getting credentials for %s from %s", host, helper)
+		creds, err := client.Get(client.NewShellProgramFunc(helper), host)
+		if err != nil {
+			// anonymous access may still work (ie. public repos), let the registry decide
+			message.Debugf("Unable to get credentials for %s from %s: %s", host, helper, err.Error())
+			return auth.EmptyCredential, nil
+		}
+		return helperCredential(creds), nil
+	}
+}
+
+// helperCredential converts the credentials a docker credential helper returned to an oras credential, helpers return
+// identity tokens with a <token> username
+func helperCredential(creds *credentials.Credentials) auth.Credential {
+	if creds.Username == "<token>" {
+		return auth.Credential{RefreshToken: creds.Secret}
+	}
+	return auth.Credential{Username: creds.Username, Password: creds.Secret}
+}
+
+// credentialHelper is the binary remotes created with NewOrasRemote get their credentials from first, if any
+var credentialHelper string
+
+// SetCredentialHelper makes the remotes created after it's called get their credentials from the credential helper
+// binary helper before the docker config, ie. with uds create's --cred-helper. "" goes back to the docker config
+func SetCredentialHelper(helper string) {
+	credentialHelper = helper
+}
+
+// LookupCredentialHelper returns the binary of helper, which is the path of a credential helper, a binary on the PATH
+// or the name of a docker credential helper (ie. pass for docker-credential-pass)
+func LookupCredentialHelper(helper string) (string, error) {
+	if helper == "" {
+		return "", nil
+	}
+	if path, err := exec.LookPath(helper); err == nil {
+		return path, nil
+	}
+	if path, err := exec.LookPath(credentialHelperPrefix + helper); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("unable to find the credential helper %s, it must be a path, a binary on the PATH or the name of a %s<name> binary", helper, credentialHelperPrefix)
+}
+
+// withCredentialHelper gets credentials from helper before falling back to credential, hosts the helper has no
+// credentials for use the fallback
+//
+// unlike the cloud helpers, helper was chosen explicitly so any other failure fails the request instead of trying
+// anonymous access. The helper is called on every token exchange, so credentials it issues dynamically stay fresh
+func withCredentialHelper(helper string, credential func(context.Context, string) (auth.Credential, error)) func(context.Context, string) (auth.Credential, error) {
+	if helper == "" {
+		return credential
+	}
+	return func(ctx context.Context, host string) (auth.Credential, error) {
+		message.Debugf("Getting credentials for %s from %s", host, helper)
+		creds, err := client.Get(client.NewShellProgramFunc(helper), host)
+		if credentials.IsErrCredentialsNotFound(err) {
+			if credential == nil {
+				return auth.EmptyCredential, nil
+			}
+			return credential(ctx, host)
+		}
+		if err != nil {
+			return auth.EmptyCredential, fmt.Errorf("unable to get credentials for %s from %s: %w", host, helper, err)
+		}
+		return helperCredential(creds), nil
+	}
+}
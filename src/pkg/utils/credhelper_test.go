@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+func Test_withCloudCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake credential helpers are shell scripts")
+	}
+	// fake helpers answer the docker credential helper protocol's get with fixed credentials
+	bin := t.TempDir()
+	helpers := map[string]string{
+		"ecr-login": `{"ServerURL":"","Username":"AWS","Secret":"ecr-token"}`,
+		"gcloud":    `{"ServerURL":"","Username":"<token>","Secret":"gcp-identity-token"}`,
+	}
+	for name, out := range helpers {
+		script := "#!/bin/sh\nread -r host\necho '" + out + "'\n"
+		if err := os.WriteFile(filepath.Join(bin, credentialHelperPrefix+name), []byte(script), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+
+	dockerConfig := auth.StaticCredential("registry.example.com", auth.Credential{Username: "user", Password: "pass"})
+	tests := []struct {
+		name        string
+		description string
+		host        string
+		want        auth.Credential
+	}{
+		{
+			name:        "DockerConfig",
+			description: "credentials from the docker config are used as-is",
+			host:        "registry.example.com",
+			want:        auth.Credential{Username: "user", Password: "pass"},
+		}, {
+			name:        "ECR",
+			description: "ECR hosts get credentials from docker-credential-ecr-login",
+			host:        "123456789012.dkr.ecr.us-east-1.amazonaws.com",
+			want:        auth.Credential{Username: "AWS", Password: "ecr-token"},
+		}, {
+			name:        "ArtifactRegistry",
+			description: "identity tokens from docker-credential-gcloud are used as refresh tokens",
+			host:        "us-docker.pkg.dev",
+			want:        auth.Credential{RefreshToken: "gcp-identity-token"},
+		}, {
+			name:        "HelperNotInstalled",
+			description: "ACR hosts are anonymous when docker-credential-acr-env isn't installed",
+			host:        "example.azurecr.io",
+			want:        auth.EmptyCredential,
+		}, {
+			name:        "UnknownHost",
+			description: "other hosts without docker config credentials are anonymous",
+			host:        "localhost:5000",
+			want:        auth.EmptyCredential,
+		},
+	}
+
+	credential := withCloudCredentials(dockerConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := credential(context.TODO(), tt.host)
+			if err != nil {
+				t.Fatalf("withCloudCredentials() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("withCloudCredentials() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_withCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake credential helpers are shell scripts")
+	}
+	// the fake helper issues credentials for one host, says it has none for another and fails for the rest
+	helper := filepath.Join(t.TempDir(), credentialHelperPrefix+"issuer")
+	script := `#!/bin/sh
+read -r host
+case "$host" in
+  registry.example.com) echo '{"ServerURL":"","Username":"issued","Secret":"issued-token"}' ;;
+  mirror.example.com) echo 'credentials not found in native keychain'; exit 1 ;;
+  *) echo 'issuer unavailable'; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(helper, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	dockerConfig := auth.StaticCredential("mirror.example.com", auth.Credential{Username: "user", Password: "pass"})
+	tests := []struct {
+		name        string
+		description string
+		helper      string
+		host        string
+		want        auth.Credential
+		wantErr     bool
+	}{
+		{
+			name:        "NoHelper",
+			description: "without a helper the docker config is used",
+			host:        "mirror.example.com",
+			want:        auth.Credential{Username: "user", Password: "pass"},
+		}, {
+			name:        "Issued",
+			description: "credentials from the helper are used before the docker config",
+			helper:      helper,
+			host:        "registry.example.com",
+			want:        auth.Credential{Username: "issued", Password: "issued-token"},
+		}, {
+			name:        "NotFound",
+			description: "hosts the helper has no credentials for fall back to the docker config",
+			helper:      helper,
+			host:        "mirror.example.com",
+			want:        auth.Credential{Username: "user", Password: "pass"},
+		}, {
+			name:        "Failed",
+			description: "helper failures fail the request instead of trying anonymous access",
+			helper:      helper,
+			host:        "other.example.com",
+			want:        auth.EmptyCredential,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withCredentialHelper(tt.helper, dockerConfig)(context.TODO(), tt.host)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("withCredentialHelper() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if got != tt.want {
+				t.Errorf("withCredentialHelper() = %+v, want %+v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
+
+func Test_LookupCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake credential helpers are shell scripts")
+	}
+	bin := t.TempDir()
+	helper := filepath.Join(bin, credentialHelperPrefix+"pass")
+	if err := os.WriteFile(helper, []byte("#!/bin/sh\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name        string
+		description string
+		helper      string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "None",
+			description: "no helper is looked up without --cred-helper",
+		}, {
+			name:        "Path",
+			description: "helpers can be given by path",
+			helper:      helper,
+			want:        helper,
+		}, {
+			name:        "Binary",
+			description: "helpers can be given by their binary on the PATH",
+			helper:      credentialHelperPrefix + "pass",
+			want:        helper,
+		}, {
+			name:        "DockerName",
+			description: "helpers can be given by their docker credential helper name",
+			helper:      "pass",
+			want:        helper,
+		}, {
+			name:        "Missing",
+			description: "helpers that aren't installed are rejected",
+			helper:      "osxkeychain",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupCredentialHelper(tt.helper)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("LookupCredentialHelper() = %q, %v, want %q, wantErr %v (%s)", got, err, tt.want, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// EncryptedSuffix is the file extension appended to encrypted bundle tarballs
+const EncryptedSuffix = ".age"
+
+// IsEncrypted returns true if the path looks like an encrypted bundle tarball
+func IsEncrypted(path string) bool {
+	return strings.HasSuffix(path, EncryptedSuffix)
+}
+
+// EncryptFile encrypts src to the given age recipients (public keys) and writes the result to dst
+func EncryptFile(src, dst string, recipients []string) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("at least one recipient is required to encrypt %s", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w, err := EncryptWriter(out, recipients)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		return err
+	}
+	// Close flushes the final chunk, the file is unreadable without it
+	return w.Close()
+}
+
+// EncryptWriter returns a writer that encrypts what is written to it to the given age recipients (public keys) and
+// writes the result to out in a single forward pass, it must be closed to flush the final chunk
+func EncryptWriter(out io.Writer, recipients []string) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required to encrypt")
+	}
+	var ageRecipients []age.Recipient
+	for _, r := range recipients {
+		recipient, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", r, err)
+		}
+		ageRecipients = append(ageRecipients, recipient)
+	}
+	return age.Encrypt(out, ageRecipients...)
+}
+
+// DecryptFile decrypts src with the age identities (private keys) in identityPath and writes the result to dst
+func DecryptFile(src, dst, identityPath string) error {
+	if identityPath == "" {
+		return fmt.Errorf("an identity is required to decrypt %s", src)
+	}
+	identityFile, err := os.Open(identityPath)
+	if err != nil {
+		return err
+	}
+	defer identityFile.Close()
+
+	identities, err := age.ParseIdentities(identityFile)
+	if err != nil {
+		return fmt.Errorf("invalid identity file %s: %w", identityPath, err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := age.Decrypt(in, identities...)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt %s: %w", src, err)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, r)
+	return err
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func Test_EncryptDecryptFile(t *testing.T) {
+	dir := t.TempDir()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityPath := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	otherIdentity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherIdentityPath := filepath.Join(dir, "other.txt")
+	if err := os.WriteFile(otherIdentityPath, []byte(otherIdentity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("uds bundle contents")
+	src := filepath.Join(dir, "uds-bundle-test-amd64-0.0.1.tar.zst")
+	if err := os.WriteFile(src, plaintext, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		description  string
+		recipients   []string
+		identityPath string
+		wantEncErr   bool
+		wantDecErr   bool
+	}{
+		{
+			name:         "RoundTrip",
+			description:  "a file encrypted to a recipient decrypts with its identity",
+			recipients:   []string{identity.Recipient().String()},
+			identityPath: identityPath,
+		}, {
+			name:         "MultipleRecipients",
+			description:  "any of the recipients can decrypt",
+			recipients:   []string{otherIdentity.Recipient().String(), identity.Recipient().String()},
+			identityPath: identityPath,
+		}, {
+			name:         "WrongIdentity",
+			description:  "error when decrypting with an identity that isn't a recipient",
+			recipients:   []string{identity.Recipient().String()},
+			identityPath: otherIdentityPath,
+			wantDecErr:   true,
+		}, {
+			name:        "NoRecipients",
+			description: "error when encrypting without recipients",
+			wantEncErr:  true,
+		}, {
+			name:        "InvalidRecipient",
+			description: "error when a recipient isn't an age public key",
+			recipients:  []string{"not-a-key"},
+			wantEncErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted := filepath.Join(t.TempDir(), filepath.Base(src)+EncryptedSuffix)
+			err := EncryptFile(src, encrypted, tt.recipients)
+			if (err != nil) != tt.wantEncErr {
+				t.Fatalf("EncryptFile() error = %v, wantErr %v", err, tt.wantEncErr)
+			}
+			if tt.wantEncErr {
+				return
+			}
+			if !IsEncrypted(encrypted) {
+				t.Errorf("IsEncrypted(%s) = false, want true", encrypted)
+			}
+
+			decrypted := filepath.Join(t.TempDir(), filepath.Base(src))
+			err = DecryptFile(encrypted, decrypted, tt.identityPath)
+			if (err != nil) != tt.wantDecErr {
+				t.Fatalf("DecryptFile() error = %v, wantErr %v", err, tt.wantDecErr)
+			}
+			if tt.wantDecErr {
+				return
+			}
+			got, err := os.ReadFile(decrypted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(plaintext) {
+				t.Errorf("DecryptFile() = %q, want %q", got, plaintext)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+)
+
+// maxPublicKeySize caps how much of a remote response is read as a public key
+const maxPublicKeySize = 64 * 1024
+
+// keyHTTPClient fetches public keys from https:// URLs, TLS is verified against the system roots
+var keyHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// stdinIsTerminal reports whether a password can be prompted for
+var stdinIsTerminal = func() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// KeyPassword returns the password to a private signing key
+//
+// the password is taken from --signing-key-password, UDS_KEY_PASSWORD or --key-password-file in that order, and is
+// only prompted for when attached to a terminal so non-interactive runs (ie. CI) fail instead of hanging
+func KeyPassword(password, passwordFile string, prompt func() ([]byte, error)) ([]byte, error) {
+	if password != "" {
+		return []byte(password), nil
+	}
+	if password, ok := os.LookupEnv(config.KeyPasswordEnvVar); ok && password != "" {
+		return []byte(password), nil
+	}
+	if passwordFile != "" {
+		b, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read key password file: %w", err)
+		}
+		// drop the trailing newline most editors and `echo` add
+		trimmed := bytes.TrimRight(b, "\r\n")
+		password := make([]byte, len(trimmed))
+		copy(password, trimmed)
+		ZeroBytes(b)
+		return password, nil
+	}
+	if !stdinIsTerminal() {
+		return nil, fmt.Errorf("the signing key password must be set with --signing-key-password, %s or --key-password-file when not attached to a terminal", config.KeyPasswordEnvVar)
+	}
+	return prompt()
+}
+
+// ZeroBytes overwrites b so secrets don't linger in memory after use
+func ZeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// IsRemoteKey returns true if key is an http(s):// URL or oci:// ref rather than a local path
+func IsRemoteKey(key string) bool {
+	return strings.HasPrefix(key, "https://") || strings.HasPrefix(key, "http://") || helpers.IsOCIURL(key)
+}
+
+// FetchPublicKey returns a local path to the public key at key, fetching it into dstDir if it's remote
+//
+// keys are cached in dstDir by their URL so a key referenced more than once in a run is only fetched once
+func FetchPublicKey(key, dstDir string) (string, error) {
+	if key == "" || !IsRemoteKey(key) {
+		return key, nil
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	dst := filepath.Join(dstDir, "public-"+hex.EncodeToString(sum[:])[:12]+".key")
+	if _, err := os.Stat(dst); err == nil {
+		return dst, nil
+	}
+
+	var b []byte
+	var err error
+	if helpers.IsOCIURL(key) {
+		b, err = fetchOCIPublicKey(key)
+	} else {
+		b, err = fetchHTTPPublicKey(key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch public key from %s: %w", key, err)
+	}
+	if err := validatePublicKey(b); err != nil {
+		return "", fmt.Errorf("public key from %s is malformed: %w", key, err)
+	}
+	if err := os.WriteFile(dst, b, 0600); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// TrustedKey is a local public key a bundle's signature can be verified with, Source names the --key it came from
+type TrustedKey struct {
+	Path   string
+	Source string
+}
+
+// keyringExtensions are the files of a keyring directory that are read as public keys
+var keyringExtensions = []string{".pub", ".pem", ".key"}
+
+// FetchPublicKeys returns the trusted keys of every --key, fetching remote keys into dstDir like FetchPublicKey
+//
+// a local directory is a keyring of its .pub, .pem and .key files, and a key file with several PEM public keys is a
+// keyring of one key per block, so a key can be rotated by trusting the old and the new key at once
+func FetchPublicKeys(keys []string, dstDir string) ([]TrustedKey, error) {
+	trusted := []TrustedKey{}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		sources, err := keyringFiles(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			path, err := FetchPublicKey(source, dstDir)
+			if err != nil {
+				return nil, err
+			}
+			split, err := splitKeyring(path, source, dstDir)
+			if err != nil {
+				return nil, err
+			}
+			trusted = append(trusted, split...)
+		}
+	}
+	return trusted, nil
+}
+
+// keyringFiles returns the key files of a keyring directory, any other key is returned as is
+func keyringFiles(key string) ([]string, error) {
+	if IsRemoteKey(key) {
+		return []string{key}, nil
+	}
+	info, err := os.Stat(key)
+	if err != nil || !info.IsDir() {
+		// a missing key is reported by the signature check like before
+		return []string{key}, nil
+	}
+	entries, err := os.ReadDir(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read keyring %s: %w", key, err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		for _, ext := range keyringExtensions {
+			if filepath.Ext(entry.Name()) == ext {
+				files = append(files, filepath.Join(key, entry.Name()))
+				break
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("keyring %s has no %s files", key, strings.Join(keyringExtensions, ", "))
+	}
+	return files, nil
+}
+
+// splitKeyring returns a trusted key for each PEM public key in the file at path, writing each key of a file with
+// several into dstDir since the signature is verified against one key per file
+func splitKeyring(path, source, dstDir string) ([]TrustedKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		// a missing key is reported by the signature check like before
+		return []TrustedKey{{Path: path, Source: source}}, nil
+	}
+	blocks := [][]byte{}
+	for rest := b; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		blocks = append(blocks, pem.EncodeToMemory(block))
+	}
+	if len(blocks) <= 1 {
+		return []TrustedKey{{Path: path, Source: source}}, nil
+	}
+
+	keys := make([]TrustedKey, 0, len(blocks))
+	for i, block := range blocks {
+		if err := validatePublicKey(block); err != nil {
+			return nil, fmt.Errorf("key %d of %s is malformed: %w", i+1, source, err)
+		}
+		sum := sha256.Sum256(block)
+		dst := filepath.Join(dstDir, "public-"+hex.EncodeToString(sum[:])[:12]+".key")
+		if err := os.WriteFile(dst, block, 0600); err != nil {
+			return nil, err
+		}
+		keys = append(keys, TrustedKey{Path: dst, Source: fmt.Sprintf("%s (key %d)", source, i+1)})
+	}
+	return keys, nil
+}
+
+// fetchHTTPPublicKey downloads a public key, plain http:// is only allowed with --insecure
+func fetchHTTPPublicKey(url string) ([]byte, error) {
+	if strings.HasPrefix(url, "http://") && !config.CommonOptions.Insecure {
+		return nil, fmt.Errorf("refusing to fetch a public key over plain http without --insecure")
+	}
+	client := *keyHTTPClient
+	client.CheckRedirect = checkKeyRedirect
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxPublicKeySize))
+}
+
+// checkKeyRedirect follows the redirects of a public key download like the default client, refusing any redirect to
+// plain http without --insecure
+func checkKeyRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if req.URL.Scheme == "http" && !config.CommonOptions.Insecure {
+		return fmt.Errorf("refusing to follow a redirect to plain http (%s) without --insecure", req.URL.Redacted())
+	}
+	return nil
+}
+
+// fetchOCIPublicKey pulls a public key pushed as an OCI artifact, either its public.key layer or its only layer
+func fetchOCIPublicKey(url string) ([]byte, error) {
+	remote, err := NewOrasRemote(url)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := remote.FetchRoot()
+	if err != nil {
+		return nil, err
+	}
+	layer := manifest.Locate(config.PublicKeyFile)
+	if oci.IsEmptyDescriptor(layer) {
+		if len(manifest.Layers) != 1 {
+			return nil, fmt.Errorf("expected a %s layer or a single layer, found %d layers", config.PublicKeyFile, len(manifest.Layers))
+		}
+		layer = manifest.Layers[0]
+	}
+	if layer.Size > maxPublicKeySize {
+		return nil, fmt.Errorf("layer %s is %d bytes, too large to be a public key", layer.Digest, layer.Size)
+	}
+	return remote.FetchLayer(layer)
+}
+
+// validatePublicKey ensures b is a PEM-encoded public key
+func validatePublicKey(b []byte) error {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return fmt.Errorf("no PEM data found")
+	}
+	if block.Type != "PUBLIC KEY" {
+		return fmt.Errorf("expected a PUBLIC KEY PEM block, found %s", block.Type)
+	}
+	_, err := x509.ParsePKIXPublicKey(block.Bytes)
+	return err
+}
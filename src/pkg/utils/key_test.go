@@ -0,0 +1,324 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+)
+
+func Test_FetchPublicKey(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(publicKey)
+	}))
+	defer plainServer.Close()
+
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/public.key":
+			_, _ = w.Write(publicKey)
+		case "/downgrade.key":
+			http.Redirect(w, r, plainServer.URL+"/public.key", http.StatusFound)
+		case "/malformed.key":
+			_, _ = w.Write([]byte("not a key"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := keyHTTPClient
+	keyHTTPClient = server.Client()
+	defer func() { keyHTTPClient = client }()
+
+	tests := []struct {
+		name        string
+		description string
+		key         string
+		insecure    bool
+		wantErr     bool
+	}{
+		{
+			name:        "LocalPath",
+			description: "local paths are returned as-is",
+			key:         "./public.key",
+		}, {
+			name:        "HTTPS",
+			description: "a public key is fetched from an https URL",
+			key:         server.URL + "/public.key",
+		}, {
+			name:        "Malformed",
+			description: "error when the fetched key isn't a PEM public key",
+			key:         server.URL + "/malformed.key",
+			wantErr:     true,
+		}, {
+			name:        "NotFound",
+			description: "error when the key can't be fetched",
+			key:         server.URL + "/missing.key",
+			wantErr:     true,
+		}, {
+			name:        "PlainHTTP",
+			description: "error when fetching over plain http without --insecure",
+			key:         "http://localhost/public.key",
+			wantErr:     true,
+		}, {
+			name:        "RedirectToPlainHTTP",
+			description: "error when an https URL redirects to plain http without --insecure",
+			key:         server.URL + "/downgrade.key",
+			wantErr:     true,
+		}, {
+			name:        "RedirectToPlainHTTPInsecure",
+			description: "an https URL may redirect to plain http with --insecure",
+			key:         server.URL + "/downgrade.key",
+			insecure:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insecure := config.CommonOptions.Insecure
+			config.CommonOptions.Insecure = tt.insecure
+			defer func() { config.CommonOptions.Insecure = insecure }()
+			got, err := FetchPublicKey(tt.key, t.TempDir())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !IsRemoteKey(tt.key) {
+				if got != tt.key {
+					t.Errorf("FetchPublicKey() = %v, want %v", got, tt.key)
+				}
+				return
+			}
+			b, err := os.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != string(publicKey) {
+				t.Errorf("FetchPublicKey() wrote %q, want %q", b, publicKey)
+			}
+		})
+	}
+
+	t.Run("Cached", func(t *testing.T) {
+		dir := t.TempDir()
+		before := requests
+		for i := 0; i < 2; i++ {
+			if _, err := FetchPublicKey(server.URL+"/public.key", dir); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if requests-before != 1 {
+			t.Errorf("FetchPublicKey() made %d requests for the same key, want 1", requests-before)
+		}
+	})
+}
+
+func Test_KeyPassword(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	prompt := func() ([]byte, error) { return []byte("from-prompt"), nil }
+
+	tests := []struct {
+		name         string
+		description  string
+		password     string
+		env          string
+		passwordFile string
+		terminal     bool
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:        "Flag",
+			description: "--signing-key-password takes precedence over the env var",
+			password:    "from-flag",
+			env:         "from-env",
+			want:        "from-flag",
+		}, {
+			name:         "Env",
+			description:  "UDS_KEY_PASSWORD takes precedence over --key-password-file",
+			env:          "from-env",
+			passwordFile: passwordFile,
+			want:         "from-env",
+		}, {
+			name:         "File",
+			description:  "the password file is read without its trailing newline",
+			passwordFile: passwordFile,
+			want:         "from-file",
+		}, {
+			name:         "MissingFile",
+			description:  "error when the password file can't be read",
+			passwordFile: filepath.Join(t.TempDir(), "missing"),
+			wantErr:      true,
+		}, {
+			name:        "Prompt",
+			description: "the password is prompted for when attached to a terminal",
+			terminal:    true,
+			want:        "from-prompt",
+		}, {
+			name:        "NoTerminal",
+			description: "error instead of prompting when not attached to a terminal",
+			wantErr:     true,
+		},
+	}
+
+	isTerminal := stdinIsTerminal
+	defer func() { stdinIsTerminal = isTerminal }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.KeyPasswordEnvVar, tt.env)
+			stdinIsTerminal = func() bool { return tt.terminal }
+			got, err := KeyPassword(tt.password, tt.passwordFile, prompt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("KeyPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("KeyPassword() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_FetchPublicKeys(t *testing.T) {
+	publicKeys := make([][]byte, 3)
+	for i := range publicKeys {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKeys[i] = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}
+	src := t.TempDir()
+	write := func(name string, b []byte) string {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	single := write("cosign.pub", publicKeys[0])
+	keyring := write("keyring.pem", append(append([]byte{}, publicKeys[1]...), publicKeys[2]...))
+	malformed := write("malformed.pem", append(append([]byte{}, publicKeys[1]...), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("not a key")})...))
+	keyringDir := filepath.Join(src, "keyring")
+	write("keyring/2023.pub", publicKeys[1])
+	write("keyring/2024.pub", publicKeys[2])
+	write("keyring/README.md", []byte("# trusted keys"))
+	emptyDir := filepath.Join(src, "empty")
+	write("empty/README.md", []byte("# no keys yet"))
+
+	tests := []struct {
+		name        string
+		description string
+		keys        []string
+		wantSources []string
+		wantErr     bool
+	}{
+		{
+			name:        "Single",
+			description: "a single key is trusted as is",
+			keys:        []string{single},
+			wantSources: []string{single},
+		},
+		{
+			name:        "Repeated",
+			description: "every --key is trusted, empty ones are ignored",
+			keys:        []string{single, "", filepath.Join(keyringDir, "2023.pub")},
+			wantSources: []string{single, filepath.Join(keyringDir, "2023.pub")},
+		},
+		{
+			name:        "KeyringFile",
+			description: "a file of several PEM keys trusts each of them",
+			keys:        []string{keyring},
+			wantSources: []string{keyring + " (key 1)", keyring + " (key 2)"},
+		},
+		{
+			name:        "KeyringDir",
+			description: "a directory trusts its key files, other files are ignored",
+			keys:        []string{keyringDir},
+			wantSources: []string{filepath.Join(keyringDir, "2023.pub"), filepath.Join(keyringDir, "2024.pub")},
+		},
+		{
+			name:        "EmptyKeyringDir",
+			description: "a directory without key files is an error",
+			keys:        []string{emptyDir},
+			wantErr:     true,
+		},
+		{
+			name:        "MalformedKeyringFile",
+			description: "every key of a keyring file must be a public key",
+			keys:        []string{malformed},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchPublicKeys(tt.keys, t.TempDir())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPublicKeys() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if len(got) != len(tt.wantSources) {
+				t.Fatalf("FetchPublicKeys() = %v, want keys from %v (%s)", got, tt.wantSources, tt.description)
+			}
+			for i, key := range got {
+				if key.Source != tt.wantSources[i] {
+					t.Errorf("FetchPublicKeys() key %d source = %s, want %s (%s)", i, key.Source, tt.wantSources[i], tt.description)
+				}
+				if err := validatePublicKeyFile(key.Path); err != nil {
+					t.Errorf("FetchPublicKeys() key %d at %s is not a single public key: %v", i, key.Path, err)
+				}
+			}
+		})
+	}
+}
+
+// validatePublicKeyFile ensures the file at path holds exactly one PEM-encoded public key
+func validatePublicKeyFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := validatePublicKey(b); err != nil {
+		return err
+	}
+	if _, rest := pem.Decode(b); len(rest) > 0 {
+		if block, _ := pem.Decode(rest); block != nil {
+			return os.ErrInvalid
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeconfigEnv is the env var Zarf, Helm and client-go read the kubeconfig path from
+const kubeconfigEnv = "KUBECONFIG"
+
+// UseKubeconfig points everything that connects to the cluster at kubeconfig and kubeContext until the returned func
+// restores the ambient kubeconfig. Either may be empty to keep the ambient one, both are checked to exist up front.
+//
+// Zarf always loads the default kubeconfig, so the merged config is written to a temp file with kubeContext as its
+// current context and KUBECONFIG is set to it
+func UseKubeconfig(kubeconfig, kubeContext string) (func(), error) {
+	if kubeconfig == "" && kubeContext == "" {
+		return func() {}, nil
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		if _, err := os.Stat(kubeconfig); err != nil {
+			return nil, fmt.Errorf("unable to read kubeconfig %s: %w", kubeconfig, err)
+		}
+		rules.ExplicitPath = kubeconfig
+	}
+	// Load resolves the config's relative paths against its file, so it can be written anywhere
+	config, err := rules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the kubeconfig: %w", err)
+	}
+	if kubeContext != "" {
+		config.CurrentContext = kubeContext
+	}
+	if _, ok := config.Contexts[config.CurrentContext]; !ok {
+		return nil, fmt.Errorf("context %q is not in the kubeconfig", config.CurrentContext)
+	}
+
+	tmp, err := os.MkdirTemp("", "uds-kubeconfig-")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(tmp, "config")
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	previous, set := os.LookupEnv(kubeconfigEnv)
+	if err := os.Setenv(kubeconfigEnv, path); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, err
+	}
+	return func() {
+		if set {
+			_ = os.Setenv(kubeconfigEnv, previous)
+		} else {
+			_ = os.Unsetenv(kubeconfigEnv)
+		}
+		_ = os.RemoveAll(tmp)
+	}, nil
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func Test_UseKubeconfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+    certificate-authority: ca.crt
+contexts:
+- name: dev
+  context:
+    cluster: dev
+- name: prod
+  context:
+    cluster: prod
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// relative paths in the kubeconfig are relative to it, not to the temp copy
+	if err := os.WriteFile(filepath.Join(filepath.Dir(kubeconfig), "ca.crt"), []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(kubeconfigEnv, "/ambient/config")
+
+	tests := []struct {
+		name        string
+		description string
+		kubeconfig  string
+		kubeContext string
+		wantServer  string
+		wantErr     bool
+	}{
+		{
+			name:        "Ambient",
+			description: "without either flag the ambient kubeconfig is kept",
+		},
+		{
+			name:        "Kubeconfig",
+			description: "--kubeconfig targets its current context",
+			kubeconfig:  kubeconfig,
+			wantServer:  "https://dev.example.com",
+		},
+		{
+			name:        "Context",
+			description: "--kube-context replaces the current context",
+			kubeconfig:  kubeconfig,
+			kubeContext: "prod",
+			wantServer:  "https://prod.example.com",
+		},
+		{
+			name:        "MissingKubeconfig",
+			description: "a kubeconfig that doesn't exist fails up front",
+			kubeconfig:  filepath.Join(t.TempDir(), "missing"),
+			wantErr:     true,
+		},
+		{
+			name:        "MissingContext",
+			description: "a context that isn't in the kubeconfig fails up front",
+			kubeconfig:  kubeconfig,
+			kubeContext: "staging",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore, err := UseKubeconfig(tt.kubeconfig, tt.kubeContext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseKubeconfig() error = %v, wantErr %v (%s)", err, tt.wantErr, tt.description)
+			}
+			if err != nil {
+				return
+			}
+
+			path := os.Getenv(kubeconfigEnv)
+			if tt.wantServer == "" {
+				if path != "/ambient/config" {
+					t.Errorf("UseKubeconfig() set %s to %s, want the ambient /ambient/config (%s)", kubeconfigEnv, path, tt.description)
+				}
+			} else {
+				config, err := clientcmd.BuildConfigFromFlags("", path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if config.Host != tt.wantServer {
+					t.Errorf("UseKubeconfig() targets %s, want %s (%s)", config.Host, tt.wantServer, tt.description)
+				}
+				if tt.kubeContext == "prod" && config.TLSClientConfig.CAFile != filepath.Join(filepath.Dir(kubeconfig), "ca.crt") {
+					t.Errorf("UseKubeconfig() CA file = %s, want it resolved against the original kubeconfig (%s)", config.TLSClientConfig.CAFile, tt.description)
+				}
+			}
+
+			restore()
+			if got := os.Getenv(kubeconfigEnv); got != "/ambient/config" {
+				t.Errorf("restore() left %s as %s, want /ambient/config (%s)", kubeconfigEnv, got, tt.description)
+			}
+			if tt.wantServer != "" {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("restore() left the temp kubeconfig %s behind (%s)", path, tt.description)
+				}
+			}
+		})
+	}
+}
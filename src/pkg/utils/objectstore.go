@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/corang/uds-cli/src/types"
+)
+
+const (
+	// S3URLPrefix is the prefix of bundle tarballs in S3 or an S3-compatible object store (ie. s3://bucket/key)
+	S3URLPrefix = "s3://"
+	// GCSURLPrefix is the prefix of bundle tarballs in Google Cloud Storage (ie. gs://bucket/object)
+	GCSURLPrefix = "gs://"
+
+	// defaultS3Region is used for S3-compatible endpoints when no region is configured, most of them ignore it
+	defaultS3Region = "us-east-1"
+)
+
+// IsObjectStoreURL returns true if source is an s3:// or gs:// URL
+func IsObjectStoreURL(source string) bool {
+	return strings.HasPrefix(source, S3URLPrefix) || strings.HasPrefix(source, GCSURLPrefix)
+}
+
+// ParseObjectStoreURL splits an s3:// or gs:// URL into its bucket and key, the key must name a whole bundle tarball
+func ParseObjectStoreURL(source string) (string, string, error) {
+	prefix := S3URLPrefix
+	if strings.HasPrefix(source, GCSURLPrefix) {
+		prefix = GCSURLPrefix
+	} else if !strings.HasPrefix(source, S3URLPrefix) {
+		return "", "", fmt.Errorf("%s is not an %s or %s URL", source, S3URLPrefix, GCSURLPrefix)
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(source, prefix), "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("%s must name a bucket and a key (ie. %sbucket/uds-bundle-<name>-<arch>-<version>.tar.zst)", source, prefix)
+	}
+	if !IsValidTarballName(path.Base(key)) || IsSplitTarball(key) {
+		return "", "", fmt.Errorf("%s must be a whole bundle tarball (uds-bundle-<name>-<arch>-<version>.tar.zst)", source)
+	}
+	return bucket, key, nil
+}
+
+// DownloadObject downloads the bundle tarball at an s3:// or gs:// URL into dstDir using the ambient cloud
+// credentials, returning the tarball's path
+func DownloadObject(ctx context.Context, source, dstDir string, opts types.BundlerObjectStoreOptions) (string, error) {
+	bucket, key, err := ParseObjectStoreURL(source)
+	if err != nil {
+		return "", err
+	}
+	var body io.ReadCloser
+	if strings.HasPrefix(source, S3URLPrefix) {
+		body, err = openS3Object(ctx, bucket, key, opts)
+	} else {
+		body, err = openGCSObject(ctx, bucket, key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to download %s: %w", source, err)
+	}
+	defer body.Close()
+
+	dst := filepath.Join(dstDir, path.Base(key))
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, body); err != nil {
+		return "", fmt.Errorf("unable to download %s: %w", source, err)
+	}
+	return dst, out.Close()
+}
+
+// openS3Object opens an object in S3, or the S3-compatible object store at opts.Endpoint
+func openS3Object(ctx context.Context, bucket, key string, opts types.BundlerObjectStoreOptions) (io.ReadCloser, error) {
+	var loadOpts []func(*awsConfig.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsConfig.WithRegion(opts.Region))
+	}
+	if opts.NoVerifySSL {
+		client := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+			tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402 -- opted into with --no-verify-ssl
+		})
+		loadOpts = append(loadOpts, awsConfig.WithHTTPClient(client))
+	}
+	cfg, err := awsConfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if opts.Endpoint == "" {
+			return
+		}
+		// S3-compatible stores are usually addressed by path rather than by a bucket subdomain
+		o.EndpointResolver = s3.EndpointResolverFromURL(opts.Endpoint)
+		o.UsePathStyle = true
+		if o.Region == "" {
+			o.Region = defaultS3Region
+		}
+	})
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// openGCSObject opens an object in Google Cloud Storage
+func openGCSObject(ctx context.Context, bucket, object string) (io.ReadCloser, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := client.Bucket(bucket).Object(object).NewReader(ctx)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &gcsObjectReader{Reader: reader, client: client}, nil
+}
+
+// gcsObjectReader closes the GCS client along with the object it reads
+type gcsObjectReader struct {
+	*storage.Reader
+	client *storage.Client
+}
+
+// Close closes the object and its client
+func (r *gcsObjectReader) Close() error {
+	err := r.Reader.Close()
+	if clientErr := r.client.Close(); err == nil {
+		err = clientErr
+	}
+	return err
+}
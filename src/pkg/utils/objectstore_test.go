@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/types"
+)
+
+func Test_ParseObjectStoreURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		source      string
+		wantBucket  string
+		wantKey     string
+		wantErr     bool
+	}{
+		{
+			name:        "S3",
+			description: "s3:// URLs are split into a bucket and key",
+			source:      "s3://bundles/releases/uds-bundle-test-amd64-0.0.1.tar.zst",
+			wantBucket:  "bundles",
+			wantKey:     "releases/uds-bundle-test-amd64-0.0.1.tar.zst",
+		},
+		{
+			name:        "GCS",
+			description: "gs:// URLs are split into a bucket and object",
+			source:      "gs://bundles/uds-bundle-test-arm64-0.0.1.tar.zst.age",
+			wantBucket:  "bundles",
+			wantKey:     "uds-bundle-test-arm64-0.0.1.tar.zst.age",
+		},
+		{
+			name:        "NoKey",
+			description: "a bucket alone isn't a bundle",
+			source:      "s3://bundles",
+			wantErr:     true,
+		},
+		{
+			name:        "NotABundle",
+			description: "keys must name a bundle tarball",
+			source:      "s3://bundles/zarf-package-test-amd64-0.0.1.tar.zst",
+			wantErr:     true,
+		},
+		{
+			name:        "Split",
+			description: "the parts of a split bundle can't be downloaded",
+			source:      "gs://bundles/uds-bundle-test-amd64-0.0.1.tar.zst.parts.json",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, key, err := ParseObjectStoreURL(tt.source)
+			if (err != nil) != tt.wantErr || bucket != tt.wantBucket || key != tt.wantKey {
+				t.Errorf("ParseObjectStoreURL() = %q, %q, %v, want %q, %q, wantErr %v (%s)", bucket, key, err, tt.wantBucket, tt.wantKey, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
+
+func Test_DownloadObjectS3Endpoint(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "uds")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "uds")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	// an S3-compatible store serving a single object, addressed by path
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bundles/uds-bundle-test-amd64-0.0.1.tar.zst" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("bundle"))
+	}))
+	defer server.Close()
+
+	opts := types.BundlerObjectStoreOptions{Endpoint: server.URL}
+	dst, err := DownloadObject(context.TODO(), "s3://bundles/uds-bundle-test-amd64-0.0.1.tar.zst", t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("DownloadObject() error = %v", err)
+	}
+	b, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "bundle" || filepath.Base(dst) != "uds-bundle-test-amd64-0.0.1.tar.zst" {
+		t.Errorf("DownloadObject() wrote %q to %s, want the object's contents in a file named after its key", b, dst)
+	}
+
+	if _, err := DownloadObject(context.TODO(), "s3://bundles/uds-bundle-missing-amd64-0.0.1.tar.zst", t.TempDir(), opts); err == nil {
+		t.Errorf("DownloadObject() error = nil, want an error for a missing object")
+	}
+}
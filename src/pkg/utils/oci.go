@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// networkCtx is the context the requests of remotes created with NewOrasRemote are made with
+var networkCtx = context.Background()
+
+// SetNetworkContext makes the remotes created after it's called make their requests with ctx, ie. with uds create's
+// --timeout as its deadline
+func SetNetworkContext(ctx context.Context) {
+	networkCtx = ctx
+}
+
+// NetworkContext returns the context registry requests are made with
+func NetworkContext() context.Context {
+	return networkCtx
+}
+
+// NewOrasRemote returns a Zarf oras remote whose registry tokens are scoped to the remote's repository
+//
+// Zarf's remotes share oras' global token cache, so registries that issue per-repository tokens could be sent a
+// token exchanged for a different repo. Each remote gets its own cache instead, which forces a fresh
+// WWW-Authenticate challenge and token exchange for every repository a bundle touches. Cloud registries (ECR, GCR,
+// Artifact Registry and ACR) without credentials in the docker config get them from their cloud's credential helper.
+// A helper set with SetCredentialHelper is asked before either of them.
+func NewOrasRemote(url string) (*oci.OrasRemote, error) {
+	remote, err := oci.NewOrasRemote(url)
+	if err != nil {
+		return nil, err
+	}
+	repo := remote.Repo()
+	client, ok := repo.Client.(*auth.Client)
+	if !ok {
+		return nil, fmt.Errorf("unexpected registry client for %s", url)
+	}
+	client.Cache = auth.NewCache()
+	client.Credential = withCredentialHelper(credentialHelper, withCloudCredentials(client.Credential))
+	client.SetUserAgent(config.GetUserAgent())
+	// a registry that accepts the connection but never answers fails the request instead of hanging it
+	if transport, ok := remote.Transport.Base.(*http.Transport); ok {
+		transport.ResponseHeaderTimeout = config.RegistryResponseHeaderTimeout
+	}
+	// --insecure already implies plain HTTP in Zarf, --plain-http gets it without skipping TLS verification
+	if config.CommonOptions.PlainHTTP {
+		repo.PlainHTTP = true
+	}
+
+	// hint the repository scope so the first token exchange doesn't need to be retried with the right scope
+	remote.WithContext(auth.WithScopes(networkCtx, auth.ScopeRepository(repo.Reference.Repository, auth.ActionPull)))
+	return remote, nil
+}
+
+// PushLayer pushes b to the remote's repository like Zarf's OrasRemote.PushLayer, unless the repository already has it
+//
+// skipping blobs that are already present makes publishes idempotent, re-running one after a failure only pushes what's missing
+func PushLayer(remote *oci.OrasRemote, b []byte, mediaType string) (ocispec.Descriptor, error) {
+	desc := content.NewDescriptorFromBytes(mediaType, b)
+	if LayerExists(remote.Repo(), desc) {
+		return desc, nil
+	}
+	return desc, remote.Repo().Push(networkCtx, desc, bytes.NewReader(b))
+}
+
+// LayerExists returns true if repo already has the blob described by desc
+//
+// the check is a HEAD request by digest, failures are logged and treated as missing so the blob is pushed anyway
+func LayerExists(repo *remote.Repository, desc ocispec.Descriptor) bool {
+	ctx, cancel := context.WithTimeout(networkCtx, config.RegistryHeadTimeout)
+	defer cancel()
+	exists, err := repo.Exists(ctx, desc)
+	if err != nil {
+		message.Debugf("Unable to check if %s exists in %s, pushing it: %s", desc.Digest, repo.Reference, err.Error())
+		return false
+	}
+	if exists {
+		message.Debugf("%s already exists in %s, skipping", desc.Digest, repo.Reference)
+	}
+	return exists
+}
@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/oci"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// newScopedTokenRegistry serves a registry that only accepts tokens issued for the exact repository being requested
+func newScopedTokenRegistry(t *testing.T) (*httptest.Server, map[string]int) {
+	manifest := []byte(`{"schemaVersion":2}`)
+	var mu sync.Mutex
+	exchanges := map[string]int{}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			scope := r.URL.Query().Get("scope")
+			mu.Lock()
+			exchanges[scope]++
+			mu.Unlock()
+			fmt.Fprintf(w, `{"token":%q}`, "token-for-"+scope)
+			return
+		}
+
+		// /v2/<repo>/manifests/<ref>
+		repo, _, found := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/manifests/")
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		scope := fmt.Sprintf("repository:%s:pull", repo)
+		if r.Header.Get("Authorization") != "Bearer token-for-"+scope {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="%s"`, server.URL, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+		w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+		_, _ = w.Write(manifest)
+	}))
+	t.Cleanup(server.Close)
+	return server, exchanges
+}
+
+func Test_NewOrasRemote(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	server, exchanges := newScopedTokenRegistry(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	repos := []string{"packages/init", "packages/podinfo", "other/nginx"}
+	for _, repo := range repos {
+		remote, err := NewOrasRemote(fmt.Sprintf("oci://%s/%s:0.0.1", host, repo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		remote.WithInsecureConnection(true)
+		if _, err := remote.Repo().Resolve(context.TODO(), "0.0.1"); err != nil {
+			t.Errorf("Resolve() for %s error = %v", repo, err)
+		}
+	}
+
+	for _, repo := range repos {
+		scope := fmt.Sprintf("repository:%s:pull", repo)
+		if exchanges[scope] != 1 {
+			t.Errorf("token exchanges for %s = %d, want 1", scope, exchanges[scope])
+		}
+	}
+}
+
+func Test_NewOrasRemotePlainHTTP(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	server, _ := newScopedTokenRegistry(t)
+	url := fmt.Sprintf("oci://%s/packages/podinfo:0.0.1", strings.TrimPrefix(server.URL, "http://"))
+
+	plainHTTP := config.CommonOptions.PlainHTTP
+	defer func() { config.CommonOptions.PlainHTTP = plainHTTP }()
+
+	config.CommonOptions.PlainHTTP = false
+	remote, err := NewOrasRemote(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Repo().Resolve(context.TODO(), "0.0.1"); err == nil {
+		t.Errorf("Resolve() over HTTPS to a plain HTTP registry succeeded without --plain-http")
+	}
+
+	config.CommonOptions.PlainHTTP = true
+	remote, err = NewOrasRemote(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Repo().Resolve(context.TODO(), "0.0.1"); err != nil {
+		t.Errorf("Resolve() with --plain-http error = %v", err)
+	}
+	if remote.Transport.Base.(*http.Transport).TLSClientConfig.InsecureSkipVerify {
+		t.Errorf("--plain-http disabled TLS verification")
+	}
+}
+
+func Test_PushLayer(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	existing := []byte("already pushed")
+	existingDesc := content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, existing)
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && strings.HasSuffix(r.URL.Path, "/blobs/"+existingDesc.Digest.String()):
+			w.Header().Set("Content-Length", fmt.Sprint(existingDesc.Size))
+			w.Header().Set("Docker-Content-Digest", existingDesc.Digest.String())
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/blobs/uploads/"):
+			uploads++
+			w.Header().Set("Location", r.URL.Path+"session")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	remote, err := NewOrasRemote(fmt.Sprintf("oci://%s/bundle:0.0.1", strings.TrimPrefix(server.URL, "http://")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote.WithInsecureConnection(true)
+
+	tests := []struct {
+		name        string
+		description string
+		layer       []byte
+		wantUploads int
+	}{
+		{
+			name:        "Existing",
+			description: "layers already in the repository aren't pushed again",
+			layer:       existing,
+			wantUploads: 0,
+		}, {
+			name:        "Missing",
+			description: "layers missing from the repository are pushed",
+			layer:       []byte("not pushed yet"),
+			wantUploads: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploads = 0
+			desc, err := PushLayer(remote, tt.layer, oci.ZarfLayerMediaTypeBlob)
+			if err != nil {
+				t.Fatalf("PushLayer() error = %v", err)
+			}
+			if desc.Digest != content.NewDescriptorFromBytes(oci.ZarfLayerMediaTypeBlob, tt.layer).Digest {
+				t.Errorf("PushLayer() returned digest %s for the wrong content", desc.Digest)
+			}
+			if uploads != tt.wantUploads {
+				t.Errorf("PushLayer() started %d uploads, want %d", uploads, tt.wantUploads)
+			}
+		})
+	}
+}
+
+func Test_SetNetworkContext(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	// a registry that accepts connections but never answers
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	SetNetworkContext(ctx)
+	defer SetNetworkContext(context.Background())
+
+	remote, err := NewOrasRemote(fmt.Sprintf("oci://%s/bundle:0.0.1", strings.TrimPrefix(server.URL, "http://")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote.WithInsecureConnection(true)
+
+	start := time.Now()
+	_, err = remote.ResolveRoot()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ResolveRoot() against a hung registry error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ResolveRoot() against a hung registry took %s, want it to stop at the network context's deadline", elapsed)
+	}
+}
+
+func Test_NewOrasRemoteUserAgent(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	var mu sync.Mutex
+	var agents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents = append(agents, r.Header.Get("User-Agent"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	url := fmt.Sprintf("oci://%s/packages/podinfo:0.0.1", strings.TrimPrefix(server.URL, "http://"))
+
+	plainHTTP, userAgent := config.CommonOptions.PlainHTTP, config.CommonOptions.UserAgent
+	defer func() { config.CommonOptions.PlainHTTP, config.CommonOptions.UserAgent = plainHTTP, userAgent }()
+	config.CommonOptions.PlainHTTP = true
+
+	for _, tt := range []struct{ userAgent, want string }{
+		{"", "uds-cli/" + config.CLIVersion},
+		{"mirror-bot/1.0", "mirror-bot/1.0"},
+	} {
+		config.CommonOptions.UserAgent = tt.userAgent
+		agents = nil
+		remote, err := NewOrasRemote(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = remote.Repo().Resolve(context.TODO(), "0.0.1")
+		if len(agents) == 0 {
+			t.Fatalf("Resolve() made no requests")
+		}
+		for _, agent := range agents {
+			if agent != tt.want {
+				t.Errorf("registry request User-Agent = %q, want %q", agent, tt.want)
+			}
+		}
+	}
+}
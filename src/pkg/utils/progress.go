@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// plainProgressInterval is how often a task's updates are printed without a terminal, so logs aren't flooded
+const plainProgressInterval = 10 * time.Second
+
+// Progress reports the progress of one task, *message.Spinner reports it for a task run on its own
+type Progress interface {
+	Updatef(format string, a ...any)
+	Successf(format string, a ...any)
+	Stop()
+}
+
+// MultiProgress renders the progress of tasks run concurrently as one line per active task
+//
+// updates from any goroutine are serialized, Zarf's spinner is a single global that concurrent tasks would share.
+// Without a terminal (or with --no-progress) each task's lines are printed in full instead of redrawn
+type MultiProgress struct {
+	mu       sync.Mutex
+	out      io.Writer
+	tty      bool
+	width    int
+	interval time.Duration
+	now      func() time.Time
+	active   []*progressTask
+	drawn    int
+}
+
+// progressTask is one line of a MultiProgress
+type progressTask struct {
+	m        *MultiProgress
+	text     string
+	reported time.Time
+}
+
+// NewMultiProgress creates a MultiProgress that redraws its lines on stderr when it's a terminal
+func NewMultiProgress() *MultiProgress {
+	if !message.NoProgress && term.IsTerminal(int(os.Stderr.Fd())) {
+		return newMultiProgress(os.Stderr, true, pterm.GetTerminalWidth())
+	}
+	return newMultiProgress(message.LogWriter, false, 0)
+}
+
+// newMultiProgress creates a MultiProgress writing to out, lines are cut to width when it's positive
+func newMultiProgress(out io.Writer, tty bool, width int) *MultiProgress {
+	return &MultiProgress{out: out, tty: tty, width: width, interval: plainProgressInterval, now: time.Now}
+}
+
+// Start adds a line for a new task, a nil MultiProgress starts a spinner for a task run on its own
+func (m *MultiProgress) Start(format string, a ...any) Progress {
+	if m == nil {
+		return message.NewProgressSpinner(format, a...)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task := &progressTask{m: m, text: fmt.Sprintf(format, a...), reported: m.now()}
+	m.active = append(m.active, task)
+	if m.tty {
+		m.render("")
+	} else {
+		m.printPlain("•", task.text)
+	}
+	return task
+}
+
+// Updatef replaces the task's line
+func (t *progressTask) Updatef(format string, a ...any) {
+	m := t.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.isActive(t) {
+		return
+	}
+	t.text = fmt.Sprintf(format, a...)
+	if m.tty {
+		m.render("")
+	} else if now := m.now(); now.Sub(t.reported) >= m.interval {
+		t.reported = now
+		m.printPlain("•", t.text)
+	}
+}
+
+// Successf removes the task's line, printing a success message above the active tasks
+func (t *progressTask) Successf(format string, a ...any) {
+	m := t.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.remove(t) {
+		return
+	}
+	text := fmt.Sprintf(format, a...)
+	if m.tty {
+		m.render(text)
+	} else {
+		m.printPlain("✔", text)
+	}
+}
+
+// Stop removes the task's line without a message, it does nothing after Successf
+func (t *progressTask) Stop() {
+	m := t.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.remove(t) && m.tty {
+		m.render("")
+	}
+}
+
+// isActive returns true if t still has a line
+func (m *MultiProgress) isActive(t *progressTask) bool {
+	for _, task := range m.active {
+		if task == t {
+			return true
+		}
+	}
+	return false
+}
+
+// remove removes t's line, returning false if it was already removed
+func (m *MultiProgress) remove(t *progressTask) bool {
+	for i, task := range m.active {
+		if task == t {
+			m.active = append(m.active[:i], m.active[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// render redraws the active tasks in place, printing done (if any) above them for good, only the active lines are cut
+// to fit since they're the ones moved over
+func (m *MultiProgress) render(done string) {
+	var buf bytes.Buffer
+	if m.drawn > 0 {
+		// move back to the first line drawn last time and clear everything below it
+		fmt.Fprintf(&buf, "\x1b[%dA", m.drawn)
+	}
+	buf.WriteString("\r\x1b[J")
+	if done != "" {
+		fmt.Fprintf(&buf, "  ✔ %s\n", done)
+	}
+	for _, task := range m.active {
+		fmt.Fprintf(&buf, "  • %s\n", m.fit(task.text))
+	}
+	m.drawn = len(m.active)
+	_, _ = m.out.Write(buf.Bytes())
+}
+
+// printPlain prints text as a line of its own
+func (m *MultiProgress) printPlain(symbol, text string) {
+	fmt.Fprintf(m.out, "  %s %s\n", symbol, text)
+}
+
+// fit cuts text to the terminal's width so no line wraps, a wrapped line would throw off the redraw
+func (m *MultiProgress) fit(text string) string {
+	runes := []rune(text)
+	if limit := m.width - 5; m.width > 0 && len(runes) > limit && limit > 1 {
+		return string(runes[:limit-1]) + "…"
+	}
+	return text
+}
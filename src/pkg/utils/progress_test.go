@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_MultiProgressPlain(t *testing.T) {
+	var out bytes.Buffer
+	progress := newMultiProgress(&out, false, 0)
+	now := time.Unix(0, 0)
+	progress.now = func() time.Time { return now }
+
+	task := progress.Start("Fetching package %s", "podinfo")
+	task.Updatef("Fetching podinfo layer 1 of 2")
+	now = now.Add(plainProgressInterval)
+	task.Updatef("Fetching podinfo layer 2 of 2")
+	task.Successf("Fetched package: %s", "podinfo")
+	task.Updatef("Fetching podinfo layer 3 of 2")
+	task.Stop()
+
+	// updates within the interval and after the task finished are dropped
+	want := "  • Fetching package podinfo\n  • Fetching podinfo layer 2 of 2\n  ✔ Fetched package: podinfo\n"
+	if out.String() != want {
+		t.Errorf("MultiProgress printed %q, want %q", out.String(), want)
+	}
+}
+
+func Test_MultiProgressTTY(t *testing.T) {
+	var out bytes.Buffer
+	progress := newMultiProgress(&out, true, 20)
+
+	first := progress.Start("Fetching package podinfo")
+	second := progress.Start("Fetching package nginx")
+	out.Reset()
+	first.Successf("Fetched package: podinfo")
+
+	// the two drawn lines are redrawn as the success message and the remaining task, cut to the terminal's width
+	want := "\x1b[2A\r\x1b[J  ✔ Fetched package: podinfo\n  • Fetching packa…\n"
+	if out.String() != want {
+		t.Errorf("MultiProgress drew %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	second.Stop()
+	if want := "\x1b[1A\r\x1b[J"; out.String() != want {
+		t.Errorf("MultiProgress drew %q after the last task stopped, want %q", out.String(), want)
+	}
+}
+
+func Test_MultiProgressConcurrent(t *testing.T) {
+	var out bytes.Buffer
+	progress := newMultiProgress(&out, false, 0)
+	progress.interval = 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			task := progress.Start("Fetching package %d", i)
+			for j := 0; j < 50; j++ {
+				task.Updatef("Fetching package %d layer %d", i, j)
+			}
+			task.Successf("Fetched package %d", i)
+		}(i)
+	}
+	wg.Wait()
+
+	// every line is written whole, none are interleaved
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 8*52 {
+		t.Fatalf("MultiProgress printed %d lines, want %d", len(lines), 8*52)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "  • Fetching package ") && !strings.HasPrefix(line, "  ✔ Fetched package ") {
+			t.Errorf("MultiProgress printed a garbled line %q", line)
+		}
+	}
+}
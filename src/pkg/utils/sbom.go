@@ -10,9 +10,35 @@ import (
 	"path/filepath"
 
 	"github.com/corang/uds-cli/src/config"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/mholt/archiver/v4"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+// CachedSBOMsTar returns a Zarf package's sboms.tar from the SBOM cache at cacheDir, or reads it with fetch and caches
+// it. The cache is keyed by the layer's digest, so cached copies never go stale and only new packages are fetched
+func CachedSBOMsTar(cacheDir string, desc ocispec.Descriptor, fetch func() ([]byte, error)) ([]byte, error) {
+	if desc.Digest.Validate() != nil {
+		return fetch()
+	}
+	path := filepath.Join(cacheDir, BlobPath(desc.Digest))
+	if b, err := os.ReadFile(path); err == nil && desc.Digest.Algorithm().FromBytes(b) == desc.Digest {
+		message.Debugf("Using the cached %s %s", config.SBOMsTar, desc.Digest)
+		return b, nil
+	}
+	b, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	// the SBOMs were fetched either way, a cache that can't be written only slows down the next inspect
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		message.Debugf("Unable to cache %s %s: %s", config.SBOMsTar, desc.Digest, err)
+	} else if err := os.WriteFile(path, b, 0644); err != nil {
+		message.Debugf("Unable to cache %s %s: %s", config.SBOMsTar, desc.Digest, err)
+	}
+	return b, nil
+}
+
 // CreateSBOMArtifact creates sbom artifacts in the form of a tar archive
 func CreateSBOMArtifact(SBOMArtifactPathMap map[string]string) error {
 	out, err := os.Create(config.BundleSBOMTar)
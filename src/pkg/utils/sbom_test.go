@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+func Test_CachedSBOMsTar(t *testing.T) {
+	sboms := []byte("sboms.tar")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, sboms)
+	tests := []struct {
+		name        string
+		description string
+		cached      []byte
+		wantFetch   bool
+	}{
+		{
+			name:        "Miss",
+			description: "uncached sboms.tar layers are fetched",
+			wantFetch:   true,
+		},
+		{
+			name:        "Hit",
+			description: "cached sboms.tar layers aren't fetched again",
+			cached:      sboms,
+		},
+		{
+			name:        "Corrupt",
+			description: "cached copies that don't match their digest are fetched again",
+			cached:      []byte("corrupt"),
+			wantFetch:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := filepath.Join(t.TempDir(), config.SBOMCacheDir)
+			path := filepath.Join(cacheDir, BlobPath(desc.Digest))
+			if tt.cached != nil {
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, tt.cached, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			fetched := false
+			got, err := CachedSBOMsTar(cacheDir, desc, func() ([]byte, error) {
+				fetched = true
+				return sboms, nil
+			})
+			if err != nil || string(got) != string(sboms) || fetched != tt.wantFetch {
+				t.Fatalf("CachedSBOMsTar() = %q, %v, fetched %v, want %q, fetched %v (%s)", got, err, fetched, sboms, tt.wantFetch, tt.description)
+			}
+			if b, err := os.ReadFile(path); err != nil || string(b) != string(sboms) {
+				t.Errorf("cached %s = %q, %v, want %q (%s)", config.SBOMsTar, b, err, sboms, tt.description)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// KeyAlgorithm returns the signature algorithm used with a public key, which cosign picks from the key's type
+func KeyAlgorithm(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return config.SigAlgoECDSAP256, nil
+		case elliptic.P384():
+			return config.SigAlgoECDSAP384, nil
+		case elliptic.P521():
+			return config.SigAlgoECDSAP521, nil
+		}
+		return "", fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return config.SigAlgoEd25519, nil
+	case *rsa.PublicKey:
+		return config.SigAlgoRSA, nil
+	}
+	return "", fmt.Errorf("unsupported key type %T", key)
+}
+
+// SignBlob signs the file at blobPath with the cosign signing key at keyPath, writing the base64 signature to
+// outputSigPath, and returns the raw signature and its algorithm
+//
+// the algorithm follows from the key's type, a non-empty sigAlgo must match it so a bundle isn't signed with a
+// different algorithm than its consumers expect
+func SignBlob(blobPath, outputSigPath, keyPath, sigAlgo string, passFunc func(bool) ([]byte, error)) ([]byte, string, error) {
+	payload, err := os.ReadFile(filepath.Clean(blobPath))
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx := context.TODO()
+	sv, err := sign.SignerFromKeyOpts(ctx, "", "", options.KeyOpts{KeyRef: keyPath, PassFunc: passFunc})
+	if err != nil {
+		return nil, "", err
+	}
+	defer sv.Close()
+
+	publicKey, err := sv.PublicKey()
+	if err != nil {
+		return nil, "", err
+	}
+	keyAlgo, err := KeyAlgorithm(publicKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to sign with %s: %w", keyPath, err)
+	}
+	if sigAlgo != "" && sigAlgo != keyAlgo {
+		return nil, "", fmt.Errorf("--sig-algo %s does not match the signing key, which signs with %s", sigAlgo, keyAlgo)
+	}
+
+	sig, err := sv.SignMessage(bytes.NewReader(payload))
+	if err != nil {
+		return nil, "", fmt.Errorf("signing blob: %w", err)
+	}
+	// cosign's sign-blob writes the signature file base64 encoded, keep it readable by cosign verify-blob
+	if err := os.WriteFile(outputSigPath, []byte(base64.StdEncoding.EncodeToString(sig)), 0600); err != nil {
+		return nil, "", fmt.Errorf("create signature file: %w", err)
+	}
+	return sig, keyAlgo, nil
+}
+
+// CheckKeyAlgorithm returns an error if the public key at publicKeyPath can't verify a signature made with sigAlgo
+//
+// signatures from before the algorithm was recorded have no sigAlgo and are left to the signature check itself
+func CheckKeyAlgorithm(publicKeyPath, sigAlgo string) error {
+	if sigAlgo == "" {
+		return nil
+	}
+	b, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	publicKey, err := cryptoutils.UnmarshalPEMToPublicKey(b)
+	if err != nil {
+		return fmt.Errorf("unable to read the public key %s: %w", publicKeyPath, err)
+	}
+	keyAlgo, err := KeyAlgorithm(publicKey)
+	if err != nil {
+		return fmt.Errorf("unable to verify with %s: %w", publicKeyPath, err)
+	}
+	if keyAlgo != sigAlgo {
+		return fmt.Errorf("the bundle is signed with %s but the public key is for %s", sigAlgo, keyAlgo)
+	}
+	return nil
+}
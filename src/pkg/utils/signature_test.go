@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corang/uds-cli/src/config"
+	zarfUtils "github.com/defenseunicorns/zarf/src/pkg/utils"
+	"github.com/sigstore/cosign/pkg/cosign"
+)
+
+// testKeyPassword is the password of the cosign keys written by writeCosignKeyPair
+func testKeyPassword(_ bool) ([]byte, error) {
+	return []byte("uds"), nil
+}
+
+// writeCosignKeyPair imports key as a cosign key pair into dir, returning the private and public key paths
+func writeCosignKeyPair(t *testing.T, dir string, key crypto.PrivateKey) (string, string) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8Path := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(pkcs8Path, pem.EncodeToMemory(&pem.Block{Type: cosign.PrivateKeyPemType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := cosign.ImportKeyPair(pkcs8Path, testKeyPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath, pubPath := filepath.Join(dir, "cosign.key"), filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(keyPath, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pubPath, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath, pubPath
+}
+
+func Test_SignBlob(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		generate    func() (crypto.PrivateKey, error)
+		wantAlgo    string
+		otherAlgo   string
+	}{
+		{
+			name:        "ECDSAP256",
+			description: "P-256 keys sign with ecdsa-p256",
+			generate: func() (crypto.PrivateKey, error) {
+				return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			},
+			wantAlgo:  config.SigAlgoECDSAP256,
+			otherAlgo: config.SigAlgoEd25519,
+		},
+		{
+			name:        "Ed25519",
+			description: "Ed25519 keys sign with ed25519",
+			generate: func() (crypto.PrivateKey, error) {
+				_, key, err := ed25519.GenerateKey(rand.Reader)
+				return key, err
+			},
+			wantAlgo:  config.SigAlgoEd25519,
+			otherAlgo: config.SigAlgoECDSAP256,
+		},
+		{
+			name:        "RSA",
+			description: "RSA keys sign with rsa",
+			generate: func() (crypto.PrivateKey, error) {
+				return rsa.GenerateKey(rand.Reader, 2048)
+			},
+			wantAlgo:  config.SigAlgoRSA,
+			otherAlgo: config.SigAlgoEd25519,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			key, err := tt.generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyPath, pubPath := writeCosignKeyPair(t, dir, key)
+			blobPath := filepath.Join(dir, config.BundleYAML)
+			if err := os.WriteFile(blobPath, []byte("kind: UDSBundle\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			sigPath := filepath.Join(dir, config.BundleYAMLSignature)
+
+			// the algorithm is inferred from the key, and naming it explicitly matches
+			for _, sigAlgo := range []string{"", tt.wantAlgo} {
+				sig, algo, err := SignBlob(blobPath, sigPath, keyPath, sigAlgo, testKeyPassword)
+				if err != nil {
+					t.Fatalf("SignBlob() with --sig-algo %q error = %v", sigAlgo, err)
+				}
+				if algo != tt.wantAlgo || len(sig) == 0 {
+					t.Errorf("SignBlob() algorithm = %s, want %s (%s)", algo, tt.wantAlgo, tt.description)
+				}
+			}
+			if err := zarfUtils.CosignVerifyBlob(blobPath, sigPath, pubPath); err != nil {
+				t.Errorf("CosignVerifyBlob() error = %v, the %s signature should verify", err, tt.wantAlgo)
+			}
+
+			if _, _, err := SignBlob(blobPath, sigPath, keyPath, tt.otherAlgo, testKeyPassword); err == nil {
+				t.Errorf("SignBlob() with --sig-algo %s should fail for a %s key", tt.otherAlgo, tt.wantAlgo)
+			}
+			if err := CheckKeyAlgorithm(pubPath, tt.wantAlgo); err != nil {
+				t.Errorf("CheckKeyAlgorithm() error = %v, want nil for a %s key", err, tt.wantAlgo)
+			}
+			if err := CheckKeyAlgorithm(pubPath, tt.otherAlgo); err == nil {
+				t.Errorf("CheckKeyAlgorithm() should reject a %s signature for a %s key", tt.otherAlgo, tt.wantAlgo)
+			}
+			if err := CheckKeyAlgorithm(pubPath, ""); err != nil {
+				t.Errorf("CheckKeyAlgorithm() error = %v, want nil for a signature without a recorded algorithm", err)
+			}
+		})
+	}
+}
+
+func Test_KeyAlgorithm(t *testing.T) {
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		key         crypto.PublicKey
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "ECDSAP384",
+			description: "P-384 keys sign with ecdsa-p384",
+			key:         &p384.PublicKey,
+			want:        config.SigAlgoECDSAP384,
+		},
+		{
+			name:        "UnsupportedCurve",
+			description: "curves cosign doesn't sign with are rejected",
+			key:         &p224.PublicKey,
+			wantErr:     true,
+		},
+		{
+			name:        "UnsupportedType",
+			description: "keys that aren't ECDSA, Ed25519 or RSA are rejected",
+			key:         "not a key",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := KeyAlgorithm(tt.key)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("KeyAlgorithm() = %v, %v, want %v, wantErr %v (%s)", got, err, tt.want, tt.wantErr, tt.description)
+			}
+		})
+	}
+}
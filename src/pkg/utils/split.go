@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package utils provides utility fns for UDS-CLI
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PartsManifestSuffix is the file extension of the manifest written next to the parts of a split bundle tarball
+const PartsManifestSuffix = ".parts.json"
+
+// partSuffixRegex matches the numbered suffix of each part of a split bundle tarball (ie. .001)
+var partSuffixRegex = regexp.MustCompile(`\.[0-9]{3}$`)
+
+// PartsManifest lists the parts a bundle tarball was split into, so reassembling it can be verified
+type PartsManifest struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+	Parts  []Part `json:"parts"`
+}
+
+// Part is one of the files a bundle tarball was split into
+type Part struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// IsSplitTarball returns true if path is a part or the parts manifest of a split bundle tarball
+func IsSplitTarball(path string) bool {
+	return strings.HasSuffix(path, PartsManifestSuffix) || partSuffixRegex.MatchString(path)
+}
+
+// PartsManifestPath returns the path of the parts manifest of the split bundle tarball that path is a part of
+func PartsManifestPath(path string) string {
+	if strings.HasSuffix(path, PartsManifestSuffix) {
+		return path
+	}
+	return partSuffixRegex.ReplaceAllString(path, "") + PartsManifestSuffix
+}
+
+// SplitFile splits path into numbered parts of at most partSize bytes next to it, writes their manifest and removes
+// path, returning the manifest's path
+func SplitFile(path string, partSize int64) (string, error) {
+	if partSize <= 0 {
+		return "", fmt.Errorf("invalid part size %d, it must be greater than 0", partSize)
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	manifest := PartsManifest{Name: filepath.Base(path), Parts: []Part{}}
+	whole := sha256.New()
+	r := io.TeeReader(src, whole)
+	for i := 1; ; i++ {
+		if i > 999 {
+			return "", fmt.Errorf("%s would be split into more than 999 parts, use a larger split size", path)
+		}
+		part, err := writePart(fmt.Sprintf("%s.%03d", path, i), r, partSize)
+		if err != nil {
+			return "", err
+		}
+		if part.Size == 0 {
+			_ = os.Remove(fmt.Sprintf("%s.%03d", path, i))
+			break
+		}
+		manifest.Parts = append(manifest.Parts, part)
+		manifest.Size += part.Size
+		if part.Size < partSize {
+			break
+		}
+	}
+	manifest.Sha256 = hex.EncodeToString(whole.Sum(nil))
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	manifestPath := path + PartsManifestSuffix
+	if err := os.WriteFile(manifestPath, b, 0644); err != nil {
+		return "", err
+	}
+	src.Close()
+	return manifestPath, os.Remove(path)
+}
+
+// writePart copies up to size bytes of r into a new file at path
+func writePart(path string, r io.Reader, size int64) (Part, error) {
+	dst, err := os.Create(path)
+	if err != nil {
+		return Part{}, err
+	}
+	defer dst.Close()
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(dst, h), io.LimitReader(r, size))
+	if err != nil {
+		return Part{}, err
+	}
+	return Part{Name: filepath.Base(path), Size: n, Sha256: hex.EncodeToString(h.Sum(nil))}, dst.Close()
+}
+
+// JoinParts reassembles the split bundle tarball described by the parts manifest at manifestPath into dstDir,
+// checking every part and the reassembled tarball against their checksums, and returns the tarball's path
+func JoinParts(manifestPath string, dstDir string) (string, error) {
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the parts manifest of a split bundle: %w", err)
+	}
+	var manifest PartsManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return "", fmt.Errorf("invalid parts manifest %s: %w", manifestPath, err)
+	}
+	if manifest.Name == "" || len(manifest.Parts) == 0 || filepath.Base(manifest.Name) != manifest.Name {
+		return "", fmt.Errorf("invalid parts manifest %s, it must name the bundle tarball and its parts", manifestPath)
+	}
+
+	dstPath := filepath.Join(dstDir, manifest.Name)
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+	whole := sha256.New()
+	w := io.MultiWriter(dst, whole)
+	partsDir := filepath.Dir(manifestPath)
+	for _, part := range manifest.Parts {
+		if filepath.Base(part.Name) != part.Name {
+			return "", fmt.Errorf("invalid parts manifest %s, part %s must be a file name", manifestPath, part.Name)
+		}
+		if err := appendPart(w, filepath.Join(partsDir, part.Name), part); err != nil {
+			return "", err
+		}
+	}
+	if actual := hex.EncodeToString(whole.Sum(nil)); actual != manifest.Sha256 {
+		return "", fmt.Errorf("reassembled %s has sha256 %s, expected %s", manifest.Name, actual, manifest.Sha256)
+	}
+	return dstPath, dst.Close()
+}
+
+// appendPart copies the part at path to w, checking it against its size and checksum
+func appendPart(w io.Writer, path string, part Part) error {
+	src, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("part %s of the split bundle is missing", part.Name)
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), src)
+	if err != nil {
+		return err
+	}
+	if n != part.Size {
+		return fmt.Errorf("part %s of the split bundle is %d bytes, expected %d", part.Name, n, part.Size)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != part.Sha256 {
+		return fmt.Errorf("part %s of the split bundle has sha256 %s, expected %s", part.Name, actual, part.Sha256)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSplitTarball writes a tarball of contents to dir and splits it into parts of partSize
+func writeSplitTarball(t *testing.T, dir string, contents []byte, partSize int64) string {
+	t.Helper()
+	tarball := filepath.Join(dir, "uds-bundle-test-amd64-0.0.1.tar.zst")
+	if err := os.WriteFile(tarball, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+	manifestPath, err := SplitFile(tarball, partSize)
+	if err != nil {
+		t.Fatalf("SplitFile() error = %v", err)
+	}
+	return manifestPath
+}
+
+func Test_SplitFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		size        int
+		partSize    int64
+		wantParts   int
+	}{
+		{
+			name:        "Remainder",
+			description: "the last part holds what's left over",
+			size:        25,
+			partSize:    10,
+			wantParts:   3,
+		},
+		{
+			name:        "Exact",
+			description: "a tarball that's a multiple of the part size doesn't get an empty last part",
+			size:        20,
+			partSize:    10,
+			wantParts:   2,
+		},
+		{
+			name:        "Smaller",
+			description: "a tarball smaller than the part size is a single part",
+			size:        5,
+			partSize:    10,
+			wantParts:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			contents := bytes.Repeat([]byte("u"), tt.size)
+			manifestPath := writeSplitTarball(t, dir, contents, tt.partSize)
+
+			parts, err := filepath.Glob(filepath.Join(dir, "*.tar.zst.[0-9][0-9][0-9]"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) != tt.wantParts {
+				t.Errorf("SplitFile() wrote %d parts, want %d (%s)", len(parts), tt.wantParts, tt.description)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "uds-bundle-test-amd64-0.0.1.tar.zst")); !os.IsNotExist(err) {
+				t.Errorf("SplitFile() should remove the original tarball")
+			}
+
+			joined, err := JoinParts(manifestPath, t.TempDir())
+			if err != nil {
+				t.Fatalf("JoinParts() error = %v", err)
+			}
+			got, err := os.ReadFile(joined)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, contents) {
+				t.Errorf("JoinParts() reassembled %d bytes, want the original %d", len(got), len(contents))
+			}
+		})
+	}
+}
+
+func Test_JoinParts(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		tamper      func(dir string) error
+	}{
+		{
+			name:        "Tampered",
+			description: "a part that doesn't match its checksum fails",
+			tamper: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "uds-bundle-test-amd64-0.0.1.tar.zst.002"), []byte("0123456789"), 0600)
+			},
+		},
+		{
+			name:        "Missing",
+			description: "a missing part fails",
+			tamper: func(dir string) error {
+				return os.Remove(filepath.Join(dir, "uds-bundle-test-amd64-0.0.1.tar.zst.003"))
+			},
+		},
+		{
+			name:        "Truncated",
+			description: "a part shorter than its recorded size fails",
+			tamper: func(dir string) error {
+				return os.Truncate(filepath.Join(dir, "uds-bundle-test-amd64-0.0.1.tar.zst.001"), 4)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			manifestPath := writeSplitTarball(t, dir, bytes.Repeat([]byte("abc"), 10), 10)
+			if err := tt.tamper(dir); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := JoinParts(manifestPath, t.TempDir()); err == nil {
+				t.Errorf("JoinParts() error = nil, want an error (%s)", tt.description)
+			}
+		})
+	}
+}
+
+func Test_PartsManifestPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		path        string
+		want        string
+		wantSplit   bool
+	}{
+		{
+			name:        "Part",
+			description: "a part maps to the manifest next to it",
+			path:        "out/uds-bundle-test-amd64-0.0.1.tar.zst.002",
+			want:        "out/uds-bundle-test-amd64-0.0.1.tar.zst.parts.json",
+			wantSplit:   true,
+		},
+		{
+			name:        "Manifest",
+			description: "the manifest is its own manifest",
+			path:        "uds-bundle-test-amd64-0.0.1.tar.zst.age.parts.json",
+			want:        "uds-bundle-test-amd64-0.0.1.tar.zst.age.parts.json",
+			wantSplit:   true,
+		},
+		{
+			name:        "Tarball",
+			description: "a whole tarball isn't split",
+			path:        "uds-bundle-test-amd64-0.0.1.tar.zst",
+			want:        "uds-bundle-test-amd64-0.0.1.tar.zst.parts.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSplitTarball(tt.path); got != tt.wantSplit {
+				t.Errorf("IsSplitTarball() = %v, want %v (%s)", got, tt.wantSplit, tt.description)
+			}
+			if got := PartsManifestPath(tt.path); got != tt.want {
+				t.Errorf("PartsManifestPath() = %v, want %v (%s)", got, tt.want, tt.description)
+			}
+		})
+	}
+}
@@ -7,9 +7,11 @@ package utils
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +19,8 @@ import (
 	"github.com/defenseunicorns/zarf/src/pkg/message"
 	"github.com/defenseunicorns/zarf/src/pkg/utils"
 	"github.com/defenseunicorns/zarf/src/pkg/utils/helpers"
+	"github.com/mholt/archiver/v4"
+	"github.com/opencontainers/go-digest"
 	"github.com/pterm/pterm"
 )
 
@@ -37,42 +41,94 @@ func IsValidTarballPath(path string) bool {
 	if utils.InvalidPath(path) || utils.IsDir(path) {
 		return false
 	}
-	name := filepath.Base(path)
+	return IsValidTarballName(filepath.Base(path))
+}
+
+// IsValidTarballName returns true if name is the file name of a bundle tarball, without checking that it exists
+func IsValidTarballName(name string) bool {
 	if name == "" {
 		return false
 	}
 	if !strings.HasPrefix(name, config.BundlePrefix) {
 		return false
 	}
-	re := regexp.MustCompile(`^uds-bundle-.*-.*.tar(.zst)?$`)
+	re := regexp.MustCompile(`^uds-bundle-.*-.*.tar(.zst)?(.age)?(\.[0-9]{3}|\.parts\.json)?$`)
 	return re.MatchString(name)
 }
 
-// UseLogFile writes output to stderr and a logFile.
-func UseLogFile() {
-	// LogWriter is the stream to write logs to.
-	var LogWriter io.Writer = os.Stderr
+// SourceDateEpoch returns the time set in the SOURCE_DATE_EPOCH env var and whether it was set
+//
+// see https://reproducible-builds.org/specs/source-date-epoch/
+func SourceDateEpoch() (time.Time, bool, error) {
+	epoch, ok := os.LookupEnv(config.SourceDateEpochEnvVar)
+	if !ok || epoch == "" {
+		return time.Time{}, false, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s %q: must be a unix timestamp", config.SourceDateEpochEnvVar, epoch)
+	}
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
+
+// BuildTime returns the time to stamp into created bundles, honoring SOURCE_DATE_EPOCH when set
+func BuildTime() (time.Time, error) {
+	epoch, ok, err := SourceDateEpoch()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ok {
+		return epoch, nil
+	}
+	return time.Now(), nil
+}
+
+// fixedModTimeInfo overrides the modification time of a file written to an archive
+type fixedModTimeInfo struct {
+	fs.FileInfo
+	modTime time.Time
+}
 
-	// Write logs to stderr and a buffer for logFile generation.
-	var logFile *os.File
+// ModTime returns the overridden modification time
+func (fi fixedModTimeInfo) ModTime() time.Time {
+	return fi.modTime
+}
 
-	// Prepend the log filename with a timestamp.
-	ts := time.Now().Format("2006-01-02-15-04-05")
+// SetArchiveModTimes sets the modification time of every file that will be written to an archive
+func SetArchiveModTimes(files []archiver.File, modTime time.Time) {
+	for i := range files {
+		files[i].FileInfo = fixedModTimeInfo{FileInfo: files[i].FileInfo, modTime: modTime}
+	}
+}
 
-	var err error
-	if logFile != nil {
-		// Use the existing log file if logFile is set
-		LogWriter = io.MultiWriter(os.Stderr, logFile)
-		pterm.SetDefaultOutput(LogWriter)
-	} else {
-		// Try to create a temp log file if one hasn't been made already
-		if logFile, err = os.CreateTemp("", fmt.Sprintf("uds-%s-*.log", ts)); err != nil {
-			message.WarnErr(err, "Error saving a log file to a temporary directory")
-		} else {
-			LogWriter = io.MultiWriter(os.Stderr, logFile)
-			pterm.SetDefaultOutput(LogWriter)
-			msg := fmt.Sprintf("Saving log file to %s", logFile.Name())
-			message.Note(msg)
-		}
+// UseLogFile writes output to stderr and a logFile, at path if set or else a timestamped file in the temp directory
+//
+// the "Saving log file to" line is printed either way, tooling reads the log's location from it
+func UseLogFile(path string) {
+	logFile, err := openLogFile(path)
+	if err != nil {
+		message.WarnErr(err, "Error saving a log file")
+		return
 	}
+	pterm.SetDefaultOutput(io.MultiWriter(os.Stderr, logFile))
+	message.Note(fmt.Sprintf("Saving log file to %s", logFile.Name()))
+}
+
+// openLogFile creates the log file at path, along with its parent directories, replacing a log left by an earlier run
+func openLogFile(path string) (*os.File, error) {
+	if path == "" {
+		// Prepend the log filename with a timestamp.
+		ts := time.Now().Format("2006-01-02-15-04-05")
+		return os.CreateTemp("", fmt.Sprintf("uds-%s-*.log", ts))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("unable to create the directory for log file %s: %w", path, err)
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+}
+
+// BlobPath returns the path of a blob relative to the root of an OCI layout (ie. blobs/sha256/<encoded>),
+// the algorithm subdirectory comes from the digest rather than assuming sha256
+func BlobPath(d digest.Digest) string {
+	return filepath.Join(config.BlobsRoot, d.Algorithm().String(), d.Encoded())
 }
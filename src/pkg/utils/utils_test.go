@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corang/uds-cli/src/config"
+	"github.com/mholt/archiver/v4"
+	"github.com/opencontainers/go-digest"
+)
+
+func Test_BuildTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		epoch       string
+		want        time.Time
+		wantErr     bool
+	}{
+		{
+			name:        "EpochRespected",
+			description: "SOURCE_DATE_EPOCH is used as the build time",
+			epoch:       "1690000000",
+			want:        time.Unix(1690000000, 0).UTC(),
+			wantErr:     false,
+		}, {
+			name:        "EpochZero",
+			description: "SOURCE_DATE_EPOCH of 0 is the unix epoch, not unset",
+			epoch:       "0",
+			want:        time.Unix(0, 0).UTC(),
+			wantErr:     false,
+		}, {
+			name:        "EpochInvalid",
+			description: "error when SOURCE_DATE_EPOCH isn't a unix timestamp",
+			epoch:       "yesterday",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.SourceDateEpochEnvVar, tt.epoch)
+			got, err := BuildTime()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("BuildTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("EpochUnset", func(t *testing.T) {
+		t.Setenv(config.SourceDateEpochEnvVar, "")
+		before := time.Now()
+		got, err := BuildTime()
+		if err != nil {
+			t.Fatalf("BuildTime() error = %v", err)
+		}
+		if got.Before(before) || got.After(time.Now()) {
+			t.Errorf("BuildTime() = %v, want current time", got)
+		}
+	})
+}
+
+func Test_SetArchiveModTimes(t *testing.T) {
+	files, err := archiver.FilesFromDisk(nil, map[string]string{"utils.go": "utils.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	epoch := time.Unix(1690000000, 0).UTC()
+	SetArchiveModTimes(files, epoch)
+	for _, f := range files {
+		if !f.ModTime().Equal(epoch) {
+			t.Errorf("%s ModTime() = %v, want %v", f.NameInArchive, f.ModTime(), epoch)
+		}
+	}
+}
+
+func Test_BlobPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		digest      digest.Digest
+		want        string
+	}{
+		{
+			name:        "SHA256",
+			description: "sha256 digests are stored in blobs/sha256",
+			digest:      digest.SHA256.FromString("uds"),
+			want:        "blobs/sha256/" + digest.SHA256.FromString("uds").Encoded(),
+		}, {
+			name:        "SHA512",
+			description: "sha512 digests are stored in blobs/sha512",
+			digest:      digest.SHA512.FromString("uds"),
+			want:        "blobs/sha512/" + digest.SHA512.FromString("uds").Encoded(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BlobPath(tt.digest); got != tt.want {
+				t.Errorf("BlobPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_openLogFile(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.log")
+	if err := os.WriteFile(existing, []byte("an earlier run"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		path        string
+		wantPrefix  string
+	}{
+		{
+			name:        "Default",
+			description: "without a path the log is a timestamped file in the temp directory",
+			wantPrefix:  filepath.Join(os.TempDir(), "uds-"),
+		}, {
+			name:        "MissingDirectory",
+			description: "the log file's parent directories are created",
+			path:        filepath.Join(dir, "logs", "nested", "uds.log"),
+			wantPrefix:  filepath.Join(dir, "logs", "nested", "uds.log"),
+		}, {
+			name:        "Existing",
+			description: "a log left by an earlier run is replaced",
+			path:        existing,
+			wantPrefix:  existing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := openLogFile(tt.path)
+			if err != nil {
+				t.Fatalf("openLogFile() error = %v", err)
+			}
+			defer f.Close()
+			if tt.path == "" {
+				defer os.Remove(f.Name())
+			}
+			if !strings.HasPrefix(f.Name(), tt.wantPrefix) {
+				t.Errorf("openLogFile() = %s, want %s* (%s)", f.Name(), tt.wantPrefix, tt.description)
+			}
+			info, err := f.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != 0 {
+				t.Errorf("openLogFile() opened a log with %d bytes, want an empty log (%s)", info.Size(), tt.description)
+			}
+		})
+	}
+}
@@ -87,6 +87,35 @@ func TestBundleVariables(t *testing.T) {
 	require.Contains(t, stderr, "This fun-fact demonstrates precedence: The Red Dragon is the national symbol of Wales")
 }
 
+func TestCreateParallelPackages(t *testing.T) {
+	zarfPkgPath1 := "src/test/packages/zarf/no-cluster/output-var"
+	zarfPkgPath2 := "src/test/packages/zarf/no-cluster/receive-var"
+	e2e.CreateZarfPkg(t, zarfPkgPath1)
+	e2e.CreateZarfPkg(t, zarfPkgPath2)
+
+	e2e.SetupDockerRegistry(t, 888)
+	defer e2e.TeardownRegistry(t, 888)
+
+	pkg := filepath.Join(zarfPkgPath1, fmt.Sprintf("zarf-package-output-var-%s-0.0.1.tar.zst", e2e.Arch))
+	zarfPublish(t, pkg, "localhost:888")
+
+	pkg = filepath.Join(zarfPkgPath2, fmt.Sprintf("zarf-package-receive-var-%s-0.0.1.tar.zst", e2e.Arch))
+	zarfPublish(t, pkg, "localhost:888")
+
+	bundleDir := "src/test/packages/02-simple-vars"
+	bundlePath := filepath.Join(bundleDir, fmt.Sprintf("uds-bundle-simple-vars-%s-0.0.1.tar.zst", e2e.Arch))
+
+	os.Setenv("UDS_CONFIG", filepath.Join("src/test/packages/02-simple-vars", "uds-config.yaml"))
+
+	cmd := strings.Split(fmt.Sprintf("create %s --parallel-packages 2 --confirm --insecure", bundleDir), " ")
+	_, _, err := e2e.UDS(cmd...)
+	require.NoError(t, err)
+	_, stderr := deploy(t, bundlePath)
+
+	// packages still deploy in bundle order, so variables exported by the first package reach the second
+	require.Contains(t, stderr, "This fun-fact was imported: Unicorns are the national animal of Scotland")
+}
+
 func TestBundleWithLocalInitPkg(t *testing.T) {
 	e2e.SetupWithCluster(t)
 
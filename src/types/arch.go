@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2023-Present The UDS Authors
+
+// Package types contains all the types used by UDS.
+package types
+
+// ArchContext is the architecture resolved once when a command starts and passed down in the BundlerConfig, so every
+// code path of the command resolves architectures the same way
+type ArchContext struct {
+	// Override is the --architecture flag, it takes precedence over the architecture of anything being bundled or deployed
+	Override string
+	// Host is the architecture of the device executing the CLI
+	Host string
+}
+
+// Resolve returns the architecture of an artifact: --architecture > the artifact's own archs in order > the host's
+func (a ArchContext) Resolve(archs ...string) string {
+	for _, arch := range append([]string{a.Override}, archs...) {
+		if arch != "" {
+			return arch
+		}
+	}
+	return a.Host
+}
+
+// Target returns the architecture bundles are checked against before they're deployed: --architecture > the host's
+func (a ArchContext) Target() string {
+	return a.Resolve()
+}
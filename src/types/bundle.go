@@ -6,9 +6,11 @@ package types
 
 // UDSBundle is the top-level structure of a UDS bundle
 type UDSBundle struct {
+	APIVersion   string              `json:"apiVersion,omitempty" jsonschema:"description=Version of the uds-bundle.yaml spec (upgrade older bundles with uds migrate),example=uds.dev/v1alpha1"`
 	Kind         string              `json:"kind" jsonschema:"description=The kind of UDS package,enum=UDSBundle"`
 	Metadata     UDSMetadata         `json:"metadata" jsonschema:"description=UDSBundle metadata"`
 	Build        UDSBuildData        `json:"build,omitempty" jsonschema:"description=Generated bundle build data"`
+	Includes     []string            `json:"includes,omitempty" jsonschema:"description=Published bundles (oci://<registry>/<bundle>:<tag>) whose packages are deployed before this bundle's packages"`
 	ZarfPackages []BundleZarfPackage `json:"zarf-packages" jsonschema:"description=List of Zarf packages"`
 }
 
@@ -16,12 +18,35 @@ type UDSBundle struct {
 type BundleZarfPackage struct {
 	Name               string                 `json:"name" jsonschema:"name=Name of the Zarf package"`
 	Repository         string                 `json:"repository,omitempty" jsonschema:"description=The repository to import the package from"`
-	Path               string                 `json:"path,omitempty" jsonschema:"description=The local path to import the package from"`
+	Path               string                 `json:"path,omitempty" jsonschema:"description=The local path to import the package from, or with git the package's directory within the repository"`
+	Git                string                 `json:"git,omitempty" jsonschema:"description=The git repository to build the package from or find a prebuilt package in, ref is the tag, branch or commit to check out"`
+	Source             *PackageSource         `json:"source,omitempty" jsonschema:"description=A source to resolve the package from with the resolver registered for its type, instead of a repository, path or git field"`
 	Ref                string                 `json:"ref" jsonschema:"description=Ref (tag) of the Zarf package"`
+	BuildArgs          []string               `json:"buildArgs,omitempty" jsonschema:"description=Zarf package create flags used when the package is built from its git source (ie. --set=KEY=value)"`
+	ExpectedSha256     string                 `json:"expectedSha256,omitempty" jsonschema:"description=SHA256 digest the local package tarball must match before it is bundled,pattern=^(sha256:)?[a-fA-F0-9]{64}$"`
 	OptionalComponents []string               `json:"optional-components,omitempty" jsonschema:"description=List of optional components to include from the package (required components are always included)"`
 	PublicKey          string                 `json:"public-key,omitempty" jsonschema:"description=The public key to use to verify the package"`
 	Imports            []BundleVariableImport `json:"imports,omitempty" jsonschema:"description=List of Zarf variables to import from another Zarf package"`
 	Exports            []BundleVariableExport `json:"exports,omitempty" jsonschema:"description=List of Zarf variables to export from the Zarf package"`
+	DependsOn          []string               `json:"dependsOn,omitempty" jsonschema:"description=List of Zarf packages in the bundle that must be deployed before this package"`
+	Readiness          []ReadinessCheck       `json:"readiness,omitempty" jsonschema:"description=Checks that must pass after the package deploys before the bundle deploy moves on"`
+}
+
+// ReadinessCheck is a check on a Kubernetes workload or an HTTP endpoint that deploy waits on after its package applies
+type ReadinessCheck struct {
+	Name      string `json:"name,omitempty" jsonschema:"description=Name of the check reported by deploy, defaults to the resource or url"`
+	Resource  string `json:"resource,omitempty" jsonschema:"description=Workload that must be ready as kind/name (ie. deployment/podinfo),pattern=^(deployments?|deploy|statefulsets?|sts|daemonsets?|ds|jobs?|pods?|po)/[a-z0-9]([a-z0-9.\\-]*[a-z0-9])?$"`
+	Namespace string `json:"namespace,omitempty" jsonschema:"description=Namespace of the resource, defaults to default"`
+	URL       string `json:"url,omitempty" jsonschema:"description=URL that must respond with status to a GET request"`
+	Status    int    `json:"status,omitempty" jsonschema:"description=HTTP status the url must respond with, defaults to 200"`
+	Timeout   string `json:"timeout,omitempty" jsonschema:"description=How long to wait for the check to pass (ie. 2m), defaults to 5m"`
+}
+
+// PackageSource is where a package is resolved from by the source resolver registered for its type
+type PackageSource struct {
+	Type    string            `json:"type" jsonschema:"description=Type of the source, path and repository are built in and others are registered by programs compiling uds-cli in"`
+	URL     string            `json:"url,omitempty" jsonschema:"description=Location of the package, its meaning is up to the source type"`
+	Options map[string]string `json:"options,omitempty" jsonschema:"description=Settings passed to the source's resolver"`
 }
 
 // BundleVariableImport represents variables in the bundle
@@ -39,24 +64,52 @@ type BundleVariableExport struct {
 
 // UDSMetadata lists information about the current UDS Bundle.
 type UDSMetadata struct {
-	Name              string `json:"name" jsonschema:"description=Name to identify this Zarf package,pattern=^[a-z0-9\\-]+$"`
+	Name              string `json:"name" jsonschema:"description=Name to identify this Zarf package,pattern=^[a-z0-9]([a-z0-9\\-]*[a-z0-9])?$"`
 	Description       string `json:"description,omitempty" jsonschema:"description=Additional information about this package"`
 	Version           string `json:"version,omitempty" jsonschema:"description=Generic string set by a package author to track the package version"`
 	URL               string `json:"url,omitempty" jsonschema:"description=Link to package information when online"`
 	Uncompressed      bool   `json:"uncompressed,omitempty" jsonschema:"description=Disable compression of this package"`
 	Architecture      string `json:"architecture,omitempty" jsonschema:"description=The target cluster architecture for this package,example=arm64,example=amd64"`
+	PlatformVariant   string `json:"platformVariant,omitempty" jsonschema:"description=The target CPU variant of the architecture for this package,example=v7,example=v8"`
 	Authors           string `json:"authors,omitempty" jsonschema:"description=Comma-separated list of package authors (including contact info),example=Doug &#60;hello@defenseunicorns.com&#62;&#44; Pepr &#60;hello@defenseunicorns.com&#62;"`
 	Documentation     string `json:"documentation,omitempty" jsonschema:"description=Link to package documentation when online"`
 	Source            string `json:"source,omitempty" jsonschema:"description=Link to package source code when online"`
 	Vendor            string `json:"vendor,omitempty" jsonschema_description:"Name of the distributing entity, organization or individual."`
+	MinUdsVersion     string `json:"minUdsVersion,omitempty" jsonschema:"example=v0.2.0" jsonschema_description:"Minimum version of the UDS CLI that can deploy, inspect or pull this bundle"`
 	AggregateChecksum string `json:"aggregateChecksum,omitempty" jsonschema:"description=Checksum of a checksums.txt file that contains checksums all the layers within the package."`
 }
 
 // UDSBuildData is written during the bundle.Create() operation to track details of the created package.
 type UDSBuildData struct {
-	Terminal     string `json:"terminal" jsonschema:"description=The machine name that created this package"`
-	User         string `json:"user" jsonschema:"description=The username who created this package"`
-	Architecture string `json:"architecture" jsonschema:"description=The architecture this package was created on"`
-	Timestamp    string `json:"timestamp" jsonschema:"description=The timestamp when this package was created"`
-	Version      string `json:"version" jsonschema:"description=The version of Zarf used to build this package"`
+	Terminal      string `json:"terminal" jsonschema:"description=The machine name that created this package"`
+	User          string `json:"user" jsonschema:"description=The username who created this package"`
+	Architecture  string `json:"architecture" jsonschema:"description=The architecture this package was created on"`
+	Timestamp     string `json:"timestamp" jsonschema:"description=The timestamp when this package was created"`
+	Version       string `json:"version" jsonschema:"description=The version of Zarf used to build this package"`
+	Reconstructed bool   `json:"reconstructed,omitempty" jsonschema:"description=Set when the bundle had no uds-bundle.yaml and this partial metadata was reconstructed from its root manifest"`
+}
+
+// UDSDeployRecord is written to the cluster during the bundle.Deploy() operation to track what was installed.
+type UDSDeployRecord struct {
+	Metadata        UDSMetadata          `json:"metadata"`
+	Build           UDSBuildData         `json:"build"`
+	Source          string               `json:"source"`
+	NamespacePrefix string               `json:"namespacePrefix,omitempty"`
+	DeployedAt      string               `json:"deployedAt"`
+	Packages        []UDSDeployedPackage `json:"packages"`
+}
+
+// UDSDeployedPackage is a Zarf package that has been deployed as part of a bundle
+type UDSDeployedPackage struct {
+	Name               string            `json:"name"`
+	Ref                string            `json:"ref"`
+	Digest             string            `json:"digest"`
+	OptionalComponents []string          `json:"optional-components,omitempty"`
+	Exports            map[string]string `json:"exports,omitempty"`
+}
+
+// UDSPins is the contents of a deploy --pin-file, the package digests each bundle was first trusted with
+type UDSPins struct {
+	// Bundles maps a bundle name to its package names and their pinned digests
+	Bundles map[string]map[string]string `json:"bundles"`
 }
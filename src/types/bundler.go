@@ -4,30 +4,105 @@
 // Package types contains all the types used by UDS.
 package types
 
+import "time"
+
 // BundlerConfig is the main struct that the bundler uses to hold high-level options.
 type BundlerConfig struct {
-	CreateOpts  BundlerCreateOptions
-	DeployOpts  BundlerDeployOptions
-	PublishOpts BundlerPublishOptions
-	PullOpts    BundlerPullOptions
-	InspectOpts BundlerInspectOptions
-	RemoveOpts  BundlerRemoveOptions
+	CreateOpts       BundlerCreateOptions
+	DeployOpts       BundlerDeployOptions
+	PublishOpts      BundlerPublishOptions
+	PullOpts         BundlerPullOptions
+	InspectOpts      BundlerInspectOptions
+	RemoveOpts       BundlerRemoveOptions
+	ExtractOpts      BundlerExtractOptions
+	VerifyLayoutOpts BundlerVerifyLayoutOptions
+	WrapOpts         BundlerWrapOptions
+	MigrateOpts      BundlerMigrateOptions
+	VerifyOpts       BundlerVerifyOptions
+	CatalogOpts      BundlerCatalogOptions
+	PruneOpts        BundlerPruneOptions
+	AppendOpts       BundlerAppendOptions
+	ObjectStoreOpts  BundlerObjectStoreOptions
+	Arch             ArchContext
 }
 
 // BundlerCreateOptions is the options for the bundler.Create() function
 type BundlerCreateOptions struct {
-	SourceDirectory    string
-	Output             string
-	SigningKeyPath     string
-	SigningKeyPassword string
-	SetVariables       map[string]string
+	SourceDirectory        string
+	Output                 string
+	SigningKeyPath         string
+	SigningKeyPassword     string
+	SigningKeyPasswordFile string
+	StripHistory           bool
+	DenyImages             []string
+	ImagePolicy            string
+	SetVariables           map[string]string
+	PlatformVariant        string
+	Encrypt                bool
+	Offline                bool
+	Recipients             []string
+	SignMethod             string
+	SigAlgo                string
+	NotationKey            string
+	Attachments            map[string]string
+	SizeReport             string
+	RepoPrefix             string
+	ValidateSchema         bool
+	IncludeStrategy        string
+	ExcludeSBOM            bool
+	RegistryStyle          string
+	ParallelPackages       int
+	OutputDirectory        string
+	LegacyConfigMediaType  bool
+	ArtifactType           string
+	Timeout                time.Duration
+	SplitSize              string
+	PackageBuildArgs       []string
+	ManifestOnly           bool
+	AnnotationsFile        string
+	AllowOverride          bool
+	Annotations            map[string]string
+	FailFast               bool
+	OptimizeLayers         bool
+	PinImages              bool
+	Created                string
+	StoreBackend           string
+	IncludeZarfInit        string
+	CredHelper             string
+	Strict                 bool
+	Snapshot               bool
+	ArchiveWorkers         int
+	ArchiveMaxInFlight     string
+	ExcludePackages        []string
+	AllowEmpty             bool
 }
 
 // BundlerDeployOptions is the options for the bundler.Deploy() function
 type BundlerDeployOptions struct {
 	Source               string
-	PublicKeyPath        string
+	PublicKeyPaths       []string
 	ZarfPackageVariables map[string]SetVariables
+	SkipVariantCheck     bool
+	SkipArchCheck        bool
+	SkipVersionCheck     bool
+	Decrypt              bool
+	IdentityPath         string
+	SignMethod           string
+	Timeout              time.Duration
+	TotalTimeout         time.Duration
+	Resume               bool
+	OnlyChanged          bool
+	PackageRetries       int
+	NamespacePrefix      string
+	PinFile              string
+	UpdatePins           bool
+	Prefetch             bool
+	Kubeconfig           string
+	KubeContext          string
+	ValuesFile           string
+	SetVariables         map[string]string
+	BundleLabels         map[string]string
+	SkipBundleLabels     bool
 }
 
 // SetVariables is a map of variables
@@ -37,35 +112,139 @@ type SetVariables struct {
 
 // BundlerInspectOptions is the options for the bundler.Inspect() function
 type BundlerInspectOptions struct {
-	PublicKeyPath string
-	Source        string
-	IncludeSBOM   bool
-	ExtractSBOM   bool
+	PublicKeyPaths     []string
+	Source             string
+	IncludeSBOM        bool
+	ExtractSBOM        bool
+	Decrypt            bool
+	IdentityPath       string
+	FromCluster        bool
+	JSON               bool
+	Attachments        []string
+	Variables          bool
+	Tree               bool
+	ExportZarfPackages string
+	DiffDeployed       bool
+	SkipVersionCheck   bool
+	Docs               bool
+	Package            string
+	SignedDigest       bool
 }
 
 // BundlerPublishOptions is the options for the bundle.Publish() function
 type BundlerPublishOptions struct {
-	Source      string
-	Destination string
+	Source                string
+	Destination           string
+	Decrypt               bool
+	IdentityPath          string
+	SignMethod            string
+	NotationKey           string
+	RegistryStyle         string
+	LegacyConfigMediaType bool
+	ArtifactType          string
+	CheckOnly             bool
+	NoReferrers           bool
 }
 
 // BundlerPullOptions is the options for the bundler.Pull() function
 type BundlerPullOptions struct {
-	OutputDirectory string
-	PublicKeyPath   string
-	Source          string
+	OutputDirectory  string
+	PublicKeyPaths   []string
+	Source           string
+	SignatureOnly    bool
+	SkipVersionCheck bool
+	TarballName      string
+	NoReferrers      bool
+	MetadataOnly     bool
+	FromFile         string
+	Decrypt          bool
+	IdentityPath     string
 }
 
 // BundlerRemoveOptions is the options for the bundler.Remove() function
 type BundlerRemoveOptions struct {
+	Source          string
+	NamespacePrefix string
+}
+
+// BundlerExtractOptions is the options for the bundler.Extract() function
+type BundlerExtractOptions struct {
+	Source     string
+	Path       string
+	OutputFile string
+}
+
+// BundlerObjectStoreOptions is the options for downloading bundle tarballs from s3:// and gs:// URLs
+type BundlerObjectStoreOptions struct {
+	Region      string
+	Endpoint    string
+	NoVerifySSL bool
+}
+
+// BundlerVerifyLayoutOptions is the options for the bundler.VerifyLayout() function
+type BundlerVerifyLayoutOptions struct {
 	Source string
+	Repair bool
 }
 
 // BundlerCommonOptions tracks the user-defined preferences used across commands.
 type BundlerCommonOptions struct {
 	Confirm        bool   `json:"confirm" jsonschema:"description=Verify that Zarf should perform an action"`
 	Insecure       bool   `json:"insecure" jsonschema:"description=Allow insecure connections for remote packages"`
+	PlainHTTP      bool   `json:"plainHTTP" jsonschema:"description=Connect to registries over plain HTTP without skipping TLS verification elsewhere"`
 	CachePath      string `json:"cachePath" jsonschema:"description=Path to use to cache images and git repos on package create"`
 	TempDirectory  string `json:"tempDirectory" jsonschema:"description=Location Zarf should use as a staging ground when managing files and images for package creation and deployment"`
 	OCIConcurrency int    `jsonschema:"description=Number of concurrent layer operations to perform when interacting with a remote package"`
+	OCIVersion     string `jsonschema:"description=The ociVersion written to bundle and package manifest configs"`
+	UserAgent      string `json:"userAgent" jsonschema:"description=User-Agent header sent on registry requests, defaults to uds-cli/<version>"`
+}
+
+// BundlerWrapOptions is the options for the bundler.Wrap() function
+type BundlerWrapOptions struct {
+	PackagePath string
+	Name        string
+	Version     string
+	Description string
+}
+
+// BundlerMigrateOptions is the options for the migrate command
+type BundlerMigrateOptions struct {
+	InPlace    bool
+	OutputFile string
+}
+
+// BundlerVerifyOptions is the options for the bundler.Verify() function
+type BundlerVerifyOptions struct {
+	Source         string
+	PublicKeyPaths []string
+	Digests        bool
+	Output         string
+	Decrypt        bool
+	IdentityPath   string
+}
+
+// BundlerCatalogOptions is the options for the bundler.CatalogPush() and bundler.CatalogList() functions
+type BundlerCatalogOptions struct {
+	Source  string
+	Bundles []string
+	JSON    bool
+}
+
+// BundlerPruneOptions is the options for the bundler.Prune() function
+type BundlerPruneOptions struct {
+	Source    string
+	KeepLast  int
+	OlderThan string
+	DryRun    bool
+}
+
+// BundlerAppendOptions is the options for the bundler.Append() function
+type BundlerAppendOptions struct {
+	Source                 string
+	Package                string
+	Name                   string
+	Output                 string
+	SigningKeyPath         string
+	SigningKeyPassword     string
+	SigningKeyPasswordFile string
 }